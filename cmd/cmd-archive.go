@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const archiveHelp = `
+This command archives all targeted repositories that are not already archived, or unarchives them if --unarchive is used. Archived repositories are read-only, which makes this useful to retire repositories that are no longer maintained.
+
+The repositories that will be changed are listed, and have to be confirmed before any of them is changed. Use --yes to skip the confirmation, for example when running without a terminal, or --dry-run to only list them. A report of the changed repositories is printed when done.
+`
+
+// ArchiveCmd archives or unarchives repositories
+func ArchiveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "archive",
+		Short:   "Archive or unarchive repositories.",
+		Long:    archiveHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    archive,
+	}
+
+	cmd.Flags().BoolP("unarchive", "", false, "Unarchive the repositories instead of archiving them.")
+	cmd.Flags().BoolP("yes", "y", false, "Change the repositories without asking for confirmation.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only list the repositories that would be changed.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func archive(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	unarchive, _ := flag.GetBool("unarchive")
+	assumeYes, _ := flag.GetBool("yes")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	archiver := multigitter.RepositoryArchiver{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+		Input:  os.Stdin,
+
+		Unarchive:  unarchive,
+		AssumeYes:  assumeYes,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return archiver.Archive(context.Background())
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/internal/multigitter/query"
+)
+
+const auditHelp = `
+This command clones all targeted repositories and classifies each of them as compliant, non-compliant or unknown, without changing anything. A report with all repositories grouped by their classification, and the aggregated numbers, is written at the end.
+
+Repositories are classified either with a query, set with --query, or with a script. The query is evaluated against the files of the repository, and consists of the functions:
+
+exists("path")            true if any file matches the path, which may be a glob pattern
+contains("path", "text")  true if any file matching the path contains the text
+matches("path", "regex")  true if any file matching the path matches the regular expression
+
+combined with "!", "&&", "||" and parentheses. For example:
+
+exists("Dockerfile") && !contains("Dockerfile", "FROM ubuntu:16.04")
+
+If a script is used instead, the repository is compliant if the script exits with 0, non-compliant if it exits with 1 and unknown for any other exit code. The environment variable REPOSITORY will be set to the name of the repository currently being audited.
+`
+
+// AuditCmd classifies repositories without changing them
+func AuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "audit [script path]",
+		Short:   "Classify repositories as compliant or not, with a query or a script, and report the result.",
+		Long:    auditHelp,
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: logFlagInit,
+		RunE:    audit,
+	}
+
+	cmd.Flags().StringP("query", "q", "", "The query that classifies each repository. Can not be used together with a script.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of concurrent runs.")
+	configureGit(cmd)
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func audit(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	queryStr, _ := flag.GetString("query")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if (queryStr == "") == (len(args) == 0) {
+		return errors.New("either a query or a script has to be set")
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	auditor := multigitter.Auditor{
+		Concurrent: concurrent,
+	}
+
+	if queryStr != "" {
+		expr, err := query.Parse(queryStr)
+		if err != nil {
+			return errors.Wrap(err, "could not parse the query")
+		}
+		auditor.Query = expr
+	} else {
+		executablePath, arguments, err := parseCommand(args[0])
+		if err != nil {
+			return err
+		}
+		auditor.ScriptPath = executablePath
+		auditor.Arguments = arguments
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return err
+	}
+	auditor.Token = token
+
+	auditor.Output, err = fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	auditor.VersionController, err = getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	auditor.Filter, err = getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	auditor.CreateGit, err = getGitCreator(flag)
+	if err != nil {
+		return err
+	}
+
+	// Set up signal listening to cancel the context and let started runs finish gracefully
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		fmt.Println("Finishing up ongoing runs. Press CTRL+C again to abort now.")
+		cancel()
+		<-c
+		os.Exit(1)
+	}()
+
+	return auditor.Audit(ctx)
+}
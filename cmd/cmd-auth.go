@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/eiannone/keyboard"
+	"github.com/lindell/multi-gitter/internal/keyring"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+const authHelp = `
+Tokens can be stored in the keychain of the operating system, instead of in environment variables or in the shell history. A stored token is used when no token is set with --token or an environment variable.
+
+The macOS Keychain, the Windows Credential Manager, and the Secret Service, through secret-tool, on Linux are supported. A token is stored for each platform, and for each --base-url, for example to use both github.com and a GitHub Enterprise Server.
+`
+
+// OverrideKeyring can be set to store tokens somewhere else than in the keychain of the operating system
+var OverrideKeyring keyring.Keyring = nil
+
+// AuthCmd contains commands to manage the tokens stored in the keychain of the operating system
+func AuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Store tokens in the keychain of the operating system.",
+		Long:  authHelp,
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(AuthSetCmd())
+	cmd.AddCommand(AuthRemoveCmd())
+
+	return cmd
+}
+
+// AuthSetCmd stores the token of a platform in the keychain
+func AuthSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Store the token of a platform in the keychain.",
+		Long:  "Store the token of a platform in the keychain of the operating system. The token is asked for in the terminal, or read from stdin if it is not a terminal.",
+		Args:  cobra.NoArgs,
+		RunE:  authSet,
+	}
+
+	configureAuth(cmd)
+
+	return cmd
+}
+
+// AuthRemoveCmd removes the token of a platform from the keychain
+func AuthRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove the token of a platform from the keychain.",
+		Long:  "Remove the token of a platform from the keychain of the operating system.",
+		Args:  cobra.NoArgs,
+		RunE:  authRemove,
+	}
+
+	configureAuth(cmd)
+
+	return cmd
+}
+
+func configureAuth(cmd *cobra.Command) {
+	cmd.Flags().StringP("platform", "p", "github", "The platform the token is used for.")
+	cmd.Flags().StringP("base-url", "g", "", "The base URL of the platform, if it is self-hosted. The token is only used together with the same base URL.")
+}
+
+func authSet(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	token, err := readToken(cmd.InOrStdin(), cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("no token was given")
+	}
+
+	account := keyringAccount(flag)
+	if err := getKeyring().Set(account, token); err != nil {
+		return fmt.Errorf("could not store the token in the keychain: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "The token of %s is stored in the keychain\n", account)
+	return nil
+}
+
+func authRemove(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	account := keyringAccount(flag)
+	if err := getKeyring().Delete(account); errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("no token of %s is stored in the keychain", account)
+	} else if err != nil {
+		return fmt.Errorf("could not remove the token from the keychain: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "The token of %s is removed from the keychain\n", account)
+	return nil
+}
+
+// readToken asks for the token, without showing it, if stdin is a terminal, and otherwise reads it from stdin
+func readToken(in io.Reader, out io.Writer) (string, error) {
+	if f, ok := in.(*os.File); !ok || !isTerminal(f) {
+		b, err := ioutil.ReadAll(in)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	if err := keyboard.Open(); err != nil {
+		return "", err
+	}
+	defer keyboard.Close()
+
+	fmt.Fprint(out, "Token: ")
+	defer fmt.Fprintln(out)
+
+	var token []rune
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyboard.KeyEnter:
+			return strings.TrimSpace(string(token)), nil
+		case keyboard.KeyEsc, keyboard.KeyCtrlC:
+			return "", errors.New("aborted")
+		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+			if len(token) > 0 {
+				token = token[:len(token)-1]
+			}
+		default:
+			if char != 0 {
+				token = append(token, char)
+			}
+		}
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// keyringAccount returns the account the token of the platform is stored under in the keychain
+func keyringAccount(flag *flag.FlagSet) string {
+	platform, _ := flag.GetString("platform")
+	baseURL, _ := flag.GetString("base-url")
+	if baseURL == "" {
+		return platform
+	}
+	return platform + " " + strings.TrimSuffix(baseURL, "/")
+}
+
+// keyringToken returns the token of the platform stored in the keychain, or an empty string if there is none
+func keyringToken(flag *flag.FlagSet) (string, error) {
+	token, err := getKeyring().Get(keyringAccount(flag))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	return token, err
+}
+
+func getKeyring() keyring.Keyring {
+	if OverrideKeyring != nil {
+		return OverrideKeyring
+	}
+	return keyring.System
+}
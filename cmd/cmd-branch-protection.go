@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const branchProtectionHelp = `
+This command applies the branch protection rules in a YAML file to multiple repositories. On GitHub, each rule is applied as a ruleset named "multi-gitter: <branch>". On GitLab, the branch is protected and the required number of approvals of the project is set. Rules without a branch are applied to the default branch of each repository.
+
+The difference between the current and the desired protection of each repository is shown, and then applied. Repositories where a rule can't be applied, for example because the branch does not exist, are skipped with the reason. Use --dry-run to only show the difference. Example of a rules file:
+
+- required_approving_reviews: 2
+  required_status_checks: [build, test]
+  enforce_admins: true
+- branch: release
+  required_approving_reviews: 1
+`
+
+// BranchProtectionCmd applies branch protection rules to repositories
+func BranchProtectionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "branch-protection",
+		Short:   "Apply branch protection rules to repositories.",
+		Long:    branchProtectionHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    branchProtection,
+	}
+
+	cmd.Flags().StringP("rules-file", "", "", "The YAML file containing the branch protection rules.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the difference between the current and the desired protection.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func branchProtection(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	rules, err := getBranchProtectionRules(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	updater := multigitter.BranchProtectionUpdater{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Rules:      rules,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return updater.Update(context.Background())
+}
+
+func getBranchProtectionRules(flag *flag.FlagSet) ([]domain.BranchProtection, error) {
+	rulesFile, _ := flag.GetString("rules-file")
+	if rulesFile == "" {
+		return nil, errors.New("no rules file set")
+	}
+
+	b, err := ioutil.ReadFile(rulesFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the rules file")
+	}
+
+	var rules []domain.BranchProtection
+	if err := yaml.UnmarshalStrict(b, &rules); err != nil {
+		return nil, errors.Wrap(err, "could not parse the rules file")
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("the rules file does not contain any rules")
+	}
+
+	branches := map[string]bool{}
+	for _, rule := range rules {
+		if rule.RequiredApprovingReviews < 0 {
+			return nil, errors.New("the number of required approving reviews can't be negative")
+		}
+		if branches[rule.Branch] {
+			return nil, errors.Errorf("there are multiple rules for the branch %q", rule.Branch)
+		}
+		branches[rule.Branch] = true
+	}
+
+	return rules, nil
+}
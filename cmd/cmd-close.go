@@ -19,9 +19,13 @@ func CloseCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("campaign-id", "", "", "If set, pull requests are found by the campaign id in their footer instead of the branch name.")
+	cmd.Flags().BoolP("dry-run", "d", false, "List the pull requests that would be closed without closing them.")
+	cmd.Flags().AddFlagSet(fromReportFlag())
 	configurePlatform(cmd)
 	configureLogging(cmd, "-")
 	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(summaryFlag())
 
 	return cmd
 }
@@ -30,16 +34,34 @@ func close(cmd *cobra.Command, args []string) error {
 	flag := cmd.Flags()
 
 	branchName, _ := flag.GetString("branch")
+	campaignID, _ := flag.GetString("campaign-id")
+	dryRun, _ := flag.GetBool("dry-run")
 
-	vc, err := getVersionController(flag, true)
+	report, err := getFromReport(flag)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, report == nil)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
 	if err != nil {
 		return err
 	}
 
 	statuser := multigitter.Closer{
 		VersionController: vc,
+		Filter:            filter,
 
 		FeatureBranch: branchName,
+		CampaignID:    campaignID,
+		Report:        report,
+		DryRun:        dryRun,
+
+		Summary: summaryOutput(cmd),
 	}
 
 	err = statuser.Close(context.Background())
@@ -47,5 +69,5 @@ func close(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return nil
+	return updateFromReport(flag, report)
 }
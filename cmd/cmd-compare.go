@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// CompareCmd compares the reports of two runs
+func CompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "compare [old report] [new report]",
+		Short:   "Compare the reports of two runs.",
+		Long:    "Compare the reports, created with the --report flag of the run command, of two runs. Lists the repositories that are newly failing, no longer need any change or where the changes differ between the runs.",
+		Args:    cobra.ExactArgs(2),
+		PreRunE: logFlagInit,
+		RunE:    compare,
+	}
+
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func compare(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	strOutput, _ := flag.GetString("output")
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	oldReport, err := readReport(args[0])
+	if err != nil {
+		return err
+	}
+
+	newReport, err := readReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	multigitter.CompareReports(oldReport, newReport).Write(output)
+
+	return nil
+}
+
+func readReport(path string) (multigitter.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return multigitter.Report{}, errors.Wrapf(err, "could not open report %s", path)
+	}
+	defer file.Close()
+
+	report, err := multigitter.ReadReport(file)
+	if err != nil {
+		return multigitter.Report{}, errors.Wrapf(err, "could not read report %s", path)
+	}
+	return report, nil
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/lindell/multi-gitter/internal/dashboard"
+	"github.com/lindell/multi-gitter/internal/history"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// DashboardCmd serves a web interface over the recorded runs
+func DashboardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Serve a web interface over the recorded runs.",
+		Long: `Serve a web interface over the runs recorded by the run command.
+Ongoing runs can be followed as they progress. Each run can be inspected down to the changes and log of every repository,
+failed repositories can be run again, and the pull requests of a run can be merged or closed.
+The dashboard is opened through the url it prints when started, which contains the token set with --dashboard-token, or the MULTI_GITTER_DASHBOARD_TOKEN environment variable.
+A random token is used if none is set.
+The platform flags are only used when pull requests are merged or closed, or when repositories are run again.`,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    dashboardCmd,
+	}
+
+	cmd.Flags().StringP("history-file", "", defaultHistoryFile, "The SQLite database the runs are recorded in.")
+	cmd.Flags().StringP("address", "", "localhost:8080", "The address the dashboard is served on.")
+	cmd.Flags().StringP("dashboard-token", "", "", "The token requests to the dashboard are authenticated with. Can also be set with the MULTI_GITTER_DASHBOARD_TOKEN environment variable.")
+	cmd.Flags().StringSliceP("allowed-host", "", nil, "Host names, other than localhost and the one in --address, the dashboard may be reached through. For example when it is served behind a proxy.")
+	cmd.Flags().StringSliceP("merge-type", "", []string{"merge", "squash", "rebase"}, "The type of merge that should be done (GitHub). Multiple types can be used as backup strategies if the first one is not allowed.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+
+	return cmd
+}
+
+func dashboardCmd(cmd *cobra.Command, _ []string) error {
+	flag := cmd.Flags()
+
+	historyFile, _ := flag.GetString("history-file")
+	address, _ := flag.GetString("address")
+	token, _ := flag.GetString("dashboard-token")
+	allowedHosts, _ := flag.GetStringSlice("allowed-host")
+
+	if historyFile == "" {
+		return errors.New("no history file set")
+	}
+	historyFile = expandHome(historyFile)
+
+	if token == "" {
+		token = os.Getenv("MULTI_GITTER_DASHBOARD_TOKEN")
+	}
+	if token == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(b)
+	}
+
+	mergeTypes, err := getMergeTypes(flag)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// The client is created once, since creating it reads and changes the flags, which is not safe for concurrent requests.
+	// The dashboard can be used without platform flags, so an error is only returned when pull requests are merged or closed
+	vc, vcErr := getVersionController(flag, false)
+
+	db, err := history.Open(historyFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	server, err := dashboard.New(db, dashboard.Config{
+		Token:        token,
+		AllowedHosts: dashboardHosts(address, allowedHosts),
+		Actions: dashboard.Actions{
+			// Only the pull requests recorded in the run are used, and not all pull requests with the same branch name
+			Merge: func(ctx context.Context, run history.Entry) error {
+				if vcErr != nil {
+					return vcErr
+				}
+				return multigitter.Merger{
+					VersionController: vc,
+					FeatureBranch:     run.BranchName,
+					Report:            &multigitter.Report{Repositories: run.Repositories},
+					MergeTypes:        mergeTypes,
+				}.Merge(ctx)
+			},
+			Close: func(ctx context.Context, run history.Entry) error {
+				if vcErr != nil {
+					return vcErr
+				}
+				return multigitter.Closer{
+					VersionController: vc,
+					FeatureBranch:     run.BranchName,
+					Report:            &multigitter.Report{Repositories: run.Repositories},
+				}.Close(ctx)
+			},
+			Retry: func(ctx context.Context, run history.Entry, repositories []string) error {
+				return retryRun(flag, executable, historyFile, run, repositories)
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Infof("Serving the dashboard on http://%s/?token=%s", address, token)
+	return httpServer.ListenAndServe()
+}
+
+// dashboardHosts returns the host names the dashboard may be reached through
+func dashboardHosts(address string, allowedHosts []string) []string {
+	hosts := append([]string{"localhost", "127.0.0.1", "::1"}, allowedHosts...)
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	// A dashboard served on all interfaces has to be given its host names explicitly
+	if host != "" && !net.ParseIP(host).IsUnspecified() {
+		hosts = append(hosts, host)
+	}
+
+	return hosts
+}
+
+// retryRun starts the run command again in the repositories, with the arguments the run was recorded with.
+// The new run is recorded in the history, where it can be followed
+func retryRun(flag *flag.FlagSet, executable, historyFile string, run history.Entry, repositories []string) error {
+	args := append([]string{"run", "--history-file", historyFile}, retryArguments(run.Arguments, repositories)...)
+
+	// The token is not recorded, and is instead passed on from the platform flags of the dashboard
+	_, env := platformArgs(flag)
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = run.Directory
+	cmd.Env = append(os.Environ(), env...)
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "could not start the run")
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.WithField("branch", run.BranchName).Errorf("The retried run failed: %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// retrySkippedFlags are flags of a recorded run that are not used when it is retried, since they select other repositories
+// than the retried ones, or distribute them to the workers of a queue
+var retrySkippedFlags = map[string]bool{
+	"only-repo":          true,
+	"from-report":        true,
+	"repo-file":          true,
+	"pick":               true,
+	"interactive-select": true,
+	"queue":              true,
+	"queue-role":         true,
+	"queue-lease":        true,
+}
+
+// retryArguments returns the recorded arguments of a run, changed to only run in the repositories
+func retryArguments(arguments []string, repositories []string) []string {
+	var args []string
+	for _, repo := range repositories {
+		args = append(args, "--only-repo", repo)
+	}
+
+	for i, arg := range arguments {
+		// The arguments after -- are the script and its arguments
+		if arg == "--" {
+			return append(args, arguments[i:]...)
+		}
+		name := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]
+		if !retrySkippedFlags[name] {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
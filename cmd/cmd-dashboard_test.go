@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryArguments(t *testing.T) {
+	arguments := []string{
+		"--org=my-org",
+		"--from-report=report.json",
+		"--repo-file=repos.txt",
+		"--only-repo=owner/a",
+		"--queue=redis://localhost",
+		"--queue-role=coordinator",
+		"--pick=true",
+		"--branch=my-branch",
+		"--",
+		"./script.sh",
+		"--queue=not-a-flag",
+	}
+
+	assert.Equal(t, []string{
+		"--only-repo", "owner/b",
+		"--only-repo", "owner/c",
+		"--org=my-org",
+		"--branch=my-branch",
+		"--",
+		"./script.sh",
+		"--queue=not-a-flag",
+	}, retryArguments(arguments, []string{"owner/b", "owner/c"}))
+}
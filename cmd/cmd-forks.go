@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/spf13/cobra"
+)
+
+// ForksCmd contains commands to manage forks created in fork mode
+func ForksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forks",
+		Short: "Manage forks created with --fork.",
+		Long:  "Manage forks created when running with --fork.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(ForksPruneCmd())
+
+	return cmd
+}
+
+// ForksPruneCmd deletes forks where all pull requests are closed or merged
+func ForksPruneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Delete forks where all pull requests are closed or merged.",
+		Long:    "Delete forks where all pull requests, made from the specified branch, are closed or merged. Forks without any pull request from the branch, or with open pull requests from other branches, are left untouched.",
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    forksPrune,
+	}
+
+	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("fork-owner", "", "", "The owner of the forks. Default behavior is to use the logged in user.")
+	cmd.Flags().DurationP("older-than", "", 0, `Only delete forks that has not been pushed to within this duration, for example "720h".`)
+	cmd.Flags().BoolP("dry-run", "d", false, "List the forks that would be deleted without deleting them.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func forksPrune(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	branchName, _ := flag.GetString("branch")
+	forkOwner, _ := flag.GetString("fork-owner")
+	olderThan, _ := flag.GetDuration("older-than")
+	dryRun, _ := flag.GetBool("dry-run")
+	strOutput, _ := flag.GetString("output")
+
+	vc, err := getVersionController(flag, false)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	pruner := multigitter.ForkPruner{
+		VersionController: vc,
+
+		Output: output,
+
+		FeatureBranch: branchName,
+		ForkOwner:     forkOwner,
+		OlderThan:     olderThan,
+		DryRun:        dryRun,
+	}
+
+	return pruner.Prune(context.Background())
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/lindell/multi-gitter/internal/history"
+)
+
+// defaultHistoryFile is where the recorded runs are read from, if not set to something else
+const defaultHistoryFile = "~/.multi-gitter/history.db"
+
+// HistoryCmd lists the runs recorded in the history database
+func HistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List the recorded runs, and their result in each repository.",
+		Long: `List the runs recorded by the run command, and the result of each repository.
+The history can be narrowed down to a single repository, to see what has been run against it and when.`,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    historyCmd,
+	}
+
+	cmd.Flags().StringP("history-file", "", defaultHistoryFile, "The SQLite database the runs are recorded in.")
+	cmd.Flags().StringP("repo", "R", "", `Only list the result of this repository, in the format "owner/name".`)
+	cmd.Flags().StringP("branch", "B", "", "Only list runs with this branch name.")
+	cmd.Flags().DurationP("since", "", 0, `Only list runs started within this duration, for example "720h".`)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func historyCmd(cmd *cobra.Command, _ []string) error {
+	flag := cmd.Flags()
+
+	historyFile, _ := flag.GetString("history-file")
+	repo, _ := flag.GetString("repo")
+	branchName, _ := flag.GetString("branch")
+	since, _ := flag.GetDuration("since")
+	strOutput, _ := flag.GetString("output")
+
+	if historyFile == "" {
+		return errors.New("no history file set")
+	}
+
+	db, err := history.Open(expandHome(historyFile))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	filter := history.Filter{
+		Repository: repo,
+		BranchName: branchName,
+	}
+	if since > 0 {
+		filter.Since = time.Now().Add(-since)
+	}
+
+	entries, err := db.Runs(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	history.Write(output, entries)
+
+	return nil
+}
+
+// unrecordedFlags are flags of the run command that are not recorded in the history. Either since they may contain secrets,
+// or since they should not be used when the run is made again
+var unrecordedFlags = map[string]bool{
+	"token":        true,
+	"history-file": true,
+	"only-repo":    true,
+	"interactive":  true,
+	"pick":         true,
+	"log-file":     true,
+	"output":       true,
+	"report":       true,
+}
+
+// sensitiveQueryParams are query parameters of urls set as flags, such as --queue and --report-sink, that may contain secrets
+var sensitiveQueryParams = []string{
+	"token",
+	"access_token",
+	"private_token",
+	"password",
+	"secret",
+	"signature",
+	"x-amz-signature",
+	"x-amz-credential",
+	"x-amz-security-token",
+}
+
+// historyArguments returns the arguments of the run command, without secrets, the run can be made again with
+func historyArguments(flags *flag.FlagSet, args []string) []string {
+	var arguments []string
+	flags.Visit(func(f *flag.Flag) {
+		if unrecordedFlags[f.Name] {
+			return
+		}
+		prefix := "--" + f.Name + "="
+		for _, arg := range flagArgs(f) {
+			arguments = append(arguments, prefix+redactURL(strings.TrimPrefix(arg, prefix)))
+		}
+	})
+	arguments = append(append(arguments, "--"), args...)
+
+	for i := range arguments {
+		arguments[i] = censor(arguments[i])
+	}
+	return arguments
+}
+
+// redactURL removes the user info, and sensitive query parameters, from a value that is an url. Other values are returned unchanged
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return value
+	}
+
+	u.User = nil
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		for _, sensitive := range sensitiveQueryParams {
+			if strings.EqualFold(name, sensitive) {
+				query.Set(name, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const issueHelp = `
+This command creates an issue in all targeted repositories, instead of a pull request. This is useful when the change can't be made by a script, for example to notify about a deprecation.
+
+The title and body of the issue are Go templates, where {{.Repository}} is the full name of the repository and {{.DefaultBranch}} the name of the default branch. For example:
+
+The service {{.Repository}} uses the deprecated logging library, please migrate before the end of the year.
+
+Repositories that already have an issue, open or closed, with the same title are left unchanged. Running the command again does therefore only create the issues that are missing.
+`
+
+// IssueCmd creates issues in repositories
+func IssueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "issue",
+		Short:   "Create an issue in repositories.",
+		Long:    issueHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    issue,
+	}
+
+	cmd.Flags().StringP("title", "t", "", "The title of the issue, as a Go template.")
+	cmd.Flags().StringP("body", "b", "", "The body of the issue, as a Go template.")
+	cmd.Flags().StringP("body-file", "", "", "A file containing the body of the issue, as a Go template.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the issues that would be created.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func issue(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	title, _ := flag.GetString("title")
+	body, _ := flag.GetString("body")
+	bodyFile, _ := flag.GetString("body-file")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if title == "" {
+		return errors.New("no title set")
+	}
+
+	if bodyFile != "" {
+		if body != "" {
+			return errors.New("--body and --body-file can not be used at the same time")
+		}
+		b, err := ioutil.ReadFile(bodyFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read the body file")
+		}
+		body = string(b)
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	campaign := multigitter.IssueCampaign{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		TitleTemplate: title,
+		BodyTemplate:  body,
+		DryRun:        dryRun,
+		Concurrent:    concurrent,
+	}
+
+	return campaign.Create(context.Background())
+}
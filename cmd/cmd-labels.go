@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const labelsHelp = `
+This command makes the labels of multiple repositories match the labels in a YAML file. Labels that are missing are created, and labels with a different color or description are updated. A label that is found by one of its previous names is renamed. Labels that are not in the file are left unchanged.
+
+The difference between the current and the desired labels of each repository is shown, and then applied. Use --dry-run to only show the difference. Example of a labels file:
+
+- name: bug
+  color: d73a4a
+  description: Something isn't working
+  previous_names: [defect, "type: bug"]
+- name: dependencies
+  color: 0366d6
+`
+
+// LabelsCmd syncs the labels of repositories
+func LabelsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "labels",
+		Short:   "Sync the labels of repositories.",
+		Long:    labelsHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    labels,
+	}
+
+	cmd.Flags().StringP("labels-file", "", "", "The YAML file containing the desired labels of the repositories.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the difference between the current and the desired labels.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func labels(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	repoLabels, err := getLabels(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	syncer := multigitter.LabelSyncer{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Labels:     repoLabels,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return syncer.Sync(context.Background())
+}
+
+func getLabels(flag *flag.FlagSet) ([]domain.Label, error) {
+	labelsFile, _ := flag.GetString("labels-file")
+	if labelsFile == "" {
+		return nil, errors.New("no labels file set")
+	}
+
+	b, err := ioutil.ReadFile(labelsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the labels file")
+	}
+
+	var repoLabels []domain.Label
+	if err := yaml.UnmarshalStrict(b, &repoLabels); err != nil {
+		return nil, errors.Wrap(err, "could not parse the labels file")
+	}
+
+	colorRegex := regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+	for _, label := range repoLabels {
+		if label.Name == "" {
+			return nil, errors.New("all labels need a name")
+		}
+		if !colorRegex.MatchString(label.Color) {
+			return nil, errors.Errorf(`the color of the label %s should be a hex color, for example "d73a4a"`, label.Name)
+		}
+	}
+
+	return repoLabels, nil
+}
@@ -2,9 +2,16 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
 
+	"github.com/lindell/multi-gitter/internal/domain"
 	"github.com/lindell/multi-gitter/internal/multigitter"
 	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
 )
 
 // MergeCmd merges pull requests
@@ -19,10 +26,23 @@ func MergeCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("campaign-id", "", "", "If set, pull requests are found by the campaign id in their footer instead of the branch name.")
 	cmd.Flags().StringSliceP("merge-type", "", []string{"merge", "squash", "rebase"}, "The type of merge that should be done (GitHub). Multiple types can be used as backup strategies if the first one is not allowed.")
+	cmd.Flags().BoolP("auto-merge-type", "", false, "Choose the merge type of each pull request based on its commits. A series of commits without merge commits is rebased, everything else is squashed. The merge types in --merge-type are used if the chosen one is not allowed (GitHub).")
+	cmd.Flags().StringSliceP("merge-type-override", "", nil, `The merge type used for a specific repository, in the format "owner/name=type", for example "my-org/my-repo=squash" (GitHub and Gitea).`)
+	cmd.Flags().BoolP("dry-run", "d", false, "List the pull requests that would be merged without merging them.")
+	cmd.Flags().AddFlagSet(fromReportFlag())
+	cmd.Flags().AddFlagSet(dependsOnFlag())
+	cmd.Flags().StringSliceP("backport-to", "", nil, `Backport the changes of the merged pull requests to the branches that match these patterns, for example "release/*". A pull request with the changes is created to each matching branch of the repositories (GitHub and GitLab).`)
+	cmd.Flags().StringP("backport-message", "", "Backport changes made by multi-gitter", "The commit message, and pull request title, of the backported changes. The title is prefixed with the name of the branch it is backported to.")
+	cmd.Flags().StringP("author-name", "", "", "Name of the committer of the backported changes. If not set, the global git config setting will be used.")
+	cmd.Flags().StringP("author-email", "", "", "Email of the committer of the backported changes. If not set, the global git config setting will be used.")
+	configureGit(cmd)
 	configurePlatform(cmd)
 	configureLogging(cmd, "-")
 	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+	cmd.Flags().AddFlagSet(summaryFlag())
 
 	return cmd
 }
@@ -31,16 +51,69 @@ func merge(cmd *cobra.Command, args []string) error {
 	flag := cmd.Flags()
 
 	branchName, _ := flag.GetString("branch")
+	campaignID, _ := flag.GetString("campaign-id")
+	dryRun, _ := flag.GetBool("dry-run")
+	autoMergeType, _ := flag.GetBool("auto-merge-type")
+	strMergeTypeOverrides, _ := flag.GetStringSlice("merge-type-override")
+	backportTo, _ := flag.GetStringSlice("backport-to")
 
-	vc, err := getVersionController(flag, true)
+	mergeTypes, err := getMergeTypes(flag)
 	if err != nil {
 		return err
 	}
 
+	mergeTypeOverrides, err := parseMergeTypeOverrides(strMergeTypeOverrides)
+	if err != nil {
+		return err
+	}
+
+	dependencies, err := getDependencies(flag)
+	if err != nil {
+		return err
+	}
+
+	report, err := getFromReport(flag)
+	if err != nil {
+		return err
+	}
+
+	// The repositories are fetched from the platform when backporting, even if a report is used
+	vc, err := getVersionController(flag, report == nil || len(backportTo) > 0)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	var backporter *multigitter.Backporter
+	if len(backportTo) > 0 {
+		backporter, err = getBackporter(flag, vc, filter, branchName, dryRun)
+		if err != nil {
+			return err
+		}
+	}
+
 	statuser := multigitter.Merger{
 		VersionController: vc,
+		Filter:            filter,
 
 		FeatureBranch: branchName,
+		CampaignID:    campaignID,
+		Report:        report,
+		DryRun:        dryRun,
+
+		MergeTypes:         mergeTypes,
+		AutoMergeType:      autoMergeType,
+		MergeTypeOverrides: mergeTypeOverrides,
+
+		Dependencies: dependencies,
+
+		Summary: summaryOutput(cmd),
+
+		Backporter: backporter,
 	}
 
 	err = statuser.Merge(context.Background())
@@ -48,5 +121,86 @@ func merge(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return nil
+	return updateFromReport(flag, report)
+}
+
+func getBackporter(flag *flag.FlagSet, vc multigitter.VersionController, filter multigitter.RepositoryFilter, branchName string, dryRun bool) (*multigitter.Backporter, error) {
+	backportTo, _ := flag.GetStringSlice("backport-to")
+	message, _ := flag.GetString("backport-message")
+	authorName, _ := flag.GetString("author-name")
+	authorEmail, _ := flag.GetString("author-email")
+	strOutput, _ := flag.GetString("output")
+
+	for _, pattern := range backportTo {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf(`could not parse the branch pattern "%s": %w`, pattern, err)
+		}
+	}
+
+	var commitAuthor *domain.CommitAuthor
+	if authorName != "" || authorEmail != "" {
+		if authorName == "" || authorEmail == "" {
+			return nil, errors.New("both author-name and author-email has to be set if the other is set")
+		}
+		commitAuthor = &domain.CommitAuthor{
+			Name:  authorName,
+			Email: authorEmail,
+		}
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	gitCreator, err := getGitCreator(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &multigitter.Backporter{
+		VersionController: vc,
+		Branches:          backportTo,
+
+		Runner: &multigitter.Runner{
+			VersionController: vc,
+			Filter:            filter,
+
+			FeatureBranch: branchName,
+			Token:         token,
+
+			Output: output,
+
+			CommitMessage:    message,
+			PullRequestTitle: message,
+			CommitAuthor:     commitAuthor,
+			DryRun:           dryRun,
+
+			Concurrent: 1,
+
+			CreateGit: gitCreator,
+		},
+	}, nil
+}
+
+func parseMergeTypeOverrides(strOverrides []string) (map[string][]domain.MergeType, error) {
+	overrides := map[string][]domain.MergeType{}
+	for _, str := range strOverrides {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return nil, fmt.Errorf(`could not parse merge type override "%s", it should be in the format "owner/name=type"`, str)
+		}
+
+		mergeType, err := domain.ParseMergeType(split[1])
+		if err != nil {
+			return nil, err
+		}
+		overrides[split[0]] = []domain.MergeType{mergeType}
+	}
+	return overrides, nil
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/spf13/cobra"
+)
+
+// OpenCmd opens pull requests in the browser
+func OpenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "open",
+		Short:   "Open pull requests in the browser.",
+		Long:    "Open all pull requests with a specified branch name in the browser, optionally filtered by their state.",
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    open,
+	}
+
+	states := make([]string, len(multigitter.OpenStates))
+	for i, s := range multigitter.OpenStates {
+		states[i] = string(s)
+	}
+
+	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("campaign-id", "", "", "If set, pull requests are found by the campaign id in their footer instead of the branch name.")
+	cmd.Flags().StringP("state", "", "open", "Only open pull requests in this state. Available values: "+strings.Join(states, ", ")+".")
+	_ = cmd.RegisterFlagCompletionFunc("state", func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return states, cobra.ShellCompDirectiveDefault
+	})
+	cmd.Flags().BoolP("print", "", false, "Print the urls of the pull requests instead of opening them.")
+	cmd.Flags().IntP("batch-size", "", 10, "The number of pull requests that are opened before waiting for confirmation. Set to 0 to open all at once.")
+	cmd.Flags().AddFlagSet(fromReportFlag())
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func open(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	branchName, _ := flag.GetString("branch")
+	campaignID, _ := flag.GetString("campaign-id")
+	strState, _ := flag.GetString("state")
+	printOnly, _ := flag.GetBool("print")
+	batchSize, _ := flag.GetInt("batch-size")
+	strOutput, _ := flag.GetString("output")
+
+	state, err := multigitter.ParseOpenState(strState)
+	if err != nil {
+		return err
+	}
+
+	report, err := getFromReport(flag)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, report == nil)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	opener := multigitter.Opener{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+		Input:  os.Stdin,
+		Prompt: cmd.ErrOrStderr(),
+
+		FeatureBranch: branchName,
+		CampaignID:    campaignID,
+		Report:        report,
+		State:         state,
+		PrintOnly:     printOnly,
+		BatchSize:     batchSize,
+	}
+
+	err = opener.Open(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return updateFromReport(flag, report)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const permissionsHelp = `
+This command compares the permissions users and teams have to multiple repositories with the permissions in a YAML file, and changes them to match. Users and teams that are not in the file are left unchanged, unless --remove-unlisted is used.
+
+The names of the permissions are the ones used by the platform. On GitHub, users are direct collaborators, including pending invitations, and the permissions are pull, triage, push, maintain and admin. On GitLab, users are direct members of the project, teams are the full paths of the groups the project is shared with, and the permissions are guest, reporter, developer, maintainer and owner.
+
+The difference between the current and the desired permissions of each repository is shown, and then applied. Use --dry-run to only show the difference, for example as part of an access review. Example of a permissions file:
+
+users:
+  octocat: admin
+teams:
+  backend: push
+  security: pull
+`
+
+// PermissionsCmd syncs the permissions of users and teams to repositories
+func PermissionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "permissions",
+		Short:   "Audit and sync the permissions of users and teams to repositories.",
+		Long:    permissionsHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    permissions,
+	}
+
+	cmd.Flags().StringP("permissions-file", "", "", "The YAML file containing the desired permissions of users and teams.")
+	cmd.Flags().BoolP("remove-unlisted", "", false, "Remove users and teams that are not in the permissions file from the repositories.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the difference between the current and the desired permissions.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func permissions(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	removeUnlisted, _ := flag.GetBool("remove-unlisted")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	repoPermissions, err := getPermissions(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	syncer := multigitter.PermissionSyncer{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Permissions:    repoPermissions,
+		RemoveUnlisted: removeUnlisted,
+		DryRun:         dryRun,
+		Concurrent:     concurrent,
+	}
+
+	return syncer.Sync(context.Background())
+}
+
+func getPermissions(flag *flag.FlagSet) (domain.Permissions, error) {
+	permissionsFile, _ := flag.GetString("permissions-file")
+	if permissionsFile == "" {
+		return domain.Permissions{}, errors.New("no permissions file set")
+	}
+
+	b, err := ioutil.ReadFile(permissionsFile)
+	if err != nil {
+		return domain.Permissions{}, errors.Wrap(err, "could not read the permissions file")
+	}
+
+	var repoPermissions domain.Permissions
+	if err := yaml.UnmarshalStrict(b, &repoPermissions); err != nil {
+		return domain.Permissions{}, errors.Wrap(err, "could not parse the permissions file")
+	}
+
+	for name, permission := range repoPermissions.Users {
+		if permission == "" {
+			return domain.Permissions{}, errors.Errorf("no permission set for the user %s", name)
+		}
+	}
+	for name, permission := range repoPermissions.Teams {
+		if permission == "" {
+			return domain.Permissions{}, errors.Errorf("no permission set for the team %s", name)
+		}
+	}
+
+	return repoPermissions, nil
+}
@@ -31,12 +31,16 @@ func PrintCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of concurrent runs.")
+	cmd.Flags().BoolP("pick", "", false, "Interactively pick which of the repositories that should be used before the run starts.")
+	cmd.Flags().BoolP("interactive-select", "", false, "The same as --pick.")
 	cmd.Flags().StringP("error-output", "E", "-", `The file that the output of the script should be outputted to. "-" means stderr.`)
+	cmd.Flags().BoolP("group-output", "", false, "Buffer the output of each repository and print it as one block, preceded by the name of the repository. Useful when running concurrently, to not interleave the output of different repositories.")
 	configureGit(cmd)
 	configurePlatform(cmd)
 	configureLogging(cmd, "")
 	configureConfig(cmd)
 	cmd.Flags().AddFlagSet(outputFlag())
+	cmd.Flags().AddFlagSet(summaryFlag())
 
 	return cmd
 }
@@ -47,6 +51,7 @@ func print(cmd *cobra.Command, args []string) error {
 	concurrent, _ := flag.GetInt("concurrent")
 	strOutput, _ := flag.GetString("output")
 	strErrOutput, _ := flag.GetString("error-output")
+	groupOutput, _ := flag.GetBool("group-output")
 
 	token, err := getToken(flag)
 	if err != nil {
@@ -72,6 +77,11 @@ func print(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
 	gitCreator, err := getGitCreator(flag)
 	if err != nil {
 		return err
@@ -100,11 +110,15 @@ func print(cmd *cobra.Command, args []string) error {
 		Token:      token,
 
 		VersionController: vc,
+		Filter:            filter,
 
-		Stdout: output,
-		Stderr: errOutput,
+		Stdout:      output,
+		Stderr:      errOutput,
+		GroupOutput: groupOutput,
+		Summary:     summaryOutput(cmd),
 
 		Concurrent: concurrent,
+		Pick:       getPick(flag),
 
 		CreateGit: gitCreator,
 	}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const releaseHelp = `
+This command creates a tag at the head of the default branch of all targeted repositories, together with a release of the tag. Repositories that already have the tag are left unchanged.
+
+The release notes are a Go template, where {{.Repository}} is the full name of the repository, {{.DefaultBranch}} the name of the default branch and {{.Tag}} the name of the tag. For example:
+
+Release {{.Tag}} of {{.Repository}}, created from {{.DefaultBranch}}.
+
+Use --merged-branch to only release repositories where the pull request of a previous run, with that branch name, has been merged.
+`
+
+// ReleaseCmd creates tags and releases in repositories
+func ReleaseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "release",
+		Short:   "Create a tag and release in repositories.",
+		Long:    releaseHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    release,
+	}
+
+	cmd.Flags().StringP("tag", "", "", "The name of the tag that is created.")
+	cmd.Flags().StringP("name", "", "", "The title of the release. Defaults to the name of the tag.")
+	cmd.Flags().StringP("notes", "", "", "The release notes, as a Go template.")
+	cmd.Flags().StringP("notes-file", "", "", "A file containing the release notes, as a Go template.")
+	cmd.Flags().StringP("merged-branch", "", "", "Only release repositories where the pull request from this branch has been merged.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the releases that would be created.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func release(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	tag, _ := flag.GetString("tag")
+	name, _ := flag.GetString("name")
+	notes, _ := flag.GetString("notes")
+	notesFile, _ := flag.GetString("notes-file")
+	mergedBranch, _ := flag.GetString("merged-branch")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if tag == "" {
+		return errors.New("no tag set")
+	}
+	if name == "" {
+		name = tag
+	}
+
+	if notesFile != "" {
+		if notes != "" {
+			return errors.New("--notes and --notes-file can not be used at the same time")
+		}
+		b, err := ioutil.ReadFile(notesFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read the notes file")
+		}
+		notes = string(b)
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	creator := multigitter.ReleaseCreator{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Tag:           tag,
+		Name:          name,
+		NotesTemplate: notes,
+		MergedBranch:  mergedBranch,
+		DryRun:        dryRun,
+		Concurrent:    concurrent,
+	}
+
+	return creator.Create(context.Background())
+}
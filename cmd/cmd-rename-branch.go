@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const renameBranchHelp = `
+This command renames the default branch of multiple repositories, for example from master to main. Only repositories where the default branch has the old name are renamed. The branch protection is moved to the new branch, and open pull requests are retargeted to it.
+
+If a script is set, it is run on all renamed repositories, and a pull request is created with the changes it made. This can be used to fix references to the old branch, for example in CI files. The environment variables OLD_BRANCH and NEW_BRANCH will be set to the old and new name of the branch.
+`
+
+// RenameBranchCmd renames the default branch of repositories
+func RenameBranchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rename-branch [script path]",
+		Short:   "Rename the default branch of repositories, and optionally fix references to it with a script.",
+		Long:    renameBranchHelp,
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: logFlagInit,
+		RunE:    renameBranch,
+	}
+
+	cmd.Flags().StringP("from", "", "master", "The current name of the default branch. Repositories with another default branch are skipped.")
+	cmd.Flags().StringP("to", "", "main", "The new name of the default branch.")
+	cmd.Flags().StringP("branch", "B", "multi-gitter-rename-branch", "The name of the branch where the changes made by the script are committed.")
+	cmd.Flags().StringP("commit-message", "m", "", "The commit message, and title of the pull request, of the changes made by the script. Defaults to a message about the renamed branch.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of concurrent runs of the script.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only log the repositories that would be renamed, and run the script without pushing changes or creating pull requests.")
+	cmd.Flags().StringP("author-name", "", "", "Name of the committer. If not set, the global git config setting will be used.")
+	cmd.Flags().StringP("author-email", "", "", "Email of the committer. If not set, the global git config setting will be used.")
+	configureGit(cmd)
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func renameBranch(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	from, _ := flag.GetString("from")
+	to, _ := flag.GetString("to")
+	branchName, _ := flag.GetString("branch")
+	commitMessage, _ := flag.GetString("commit-message")
+	concurrent, _ := flag.GetInt("concurrent")
+	dryRun, _ := flag.GetBool("dry-run")
+	authorName, _ := flag.GetString("author-name")
+	authorEmail, _ := flag.GetString("author-email")
+	strOutput, _ := flag.GetString("output")
+
+	if from == "" || to == "" {
+		return errors.New("both --from and --to has to be set")
+	}
+	if from == to {
+		return errors.New("--from and --to can't be the same branch")
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	var commitAuthor *domain.CommitAuthor
+	if authorName != "" || authorEmail != "" {
+		if authorName == "" || authorEmail == "" {
+			return errors.New("both author-name and author-email has to be set if the other is set")
+		}
+		commitAuthor = &domain.CommitAuthor{
+			Name:  authorName,
+			Email: authorEmail,
+		}
+	}
+
+	if commitMessage == "" {
+		commitMessage = fmt.Sprintf("Update references to the renamed %s branch", to)
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	renamer := multigitter.BranchRenamer{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		From:   from,
+		To:     to,
+		DryRun: dryRun,
+	}
+
+	if len(args) == 1 {
+		gitCreator, err := getGitCreator(flag)
+		if err != nil {
+			return err
+		}
+
+		executablePath, arguments, err := parseCommand(args[0])
+		if err != nil {
+			return err
+		}
+
+		renamer.Runner = &multigitter.Runner{
+			VersionController: vc,
+			Filter:            filter,
+
+			ScriptPath:    executablePath,
+			Arguments:     arguments,
+			FeatureBranch: branchName,
+			Token:         token,
+
+			Output: output,
+
+			CommitMessage:    commitMessage,
+			PullRequestTitle: commitMessage,
+			CommitAuthor:     commitAuthor,
+			DryRun:           dryRun,
+
+			Concurrent: concurrent,
+
+			CreateGit: gitCreator,
+		}
+	}
+
+	return renamer.Rename(context.Background())
+}
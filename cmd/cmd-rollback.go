@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"regexp"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const rollbackHelp = `
+This command reverts the changes of an earlier run. A pull request that reverts the changes is created in every repository where the pull request of the run has been merged.
+
+The changes are read from the report written by the run command with --report. The repositories are selected with the platform flags, in the same way as with the run command, and only those in the report are used.
+
+Changes can also be reverted without the report, by finding the commits to revert with --commit-pattern or --commit-trailer. All commits on the default branch of the selected repositories, whose message match, are reverted. This requires the full history of the repositories, so --fetch-depth defaults to 0 in this mode.
+`
+
+// RollbackCmd reverts the changes of the merged pull requests of an earlier run
+func RollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Revert the changes of the merged pull requests of an earlier run.",
+		Long:    rollbackHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    rollback,
+	}
+
+	cmd.Flags().StringP("from-report", "", "", "The report, written by the run command with --report, of the run that should be rolled back.")
+	cmd.Flags().StringP("commit-pattern", "", "", "Revert all commits with a message that matches this regular expression, instead of the changes in a report.")
+	cmd.Flags().StringArrayP("commit-trailer", "", nil, `Revert all commits with this trailer, in the format "Key: value", instead of the changes in a report. For example "Campaign-Id: my-campaign". Can be used multiple times, commits have to contain all trailers.`)
+	cmd.Flags().StringP("branch", "B", "multi-gitter-rollback", "The name of the branch where the reverted changes are committed.")
+	cmd.Flags().StringP("commit-message", "m", "Revert changes made by multi-gitter", "The commit message of the reverted changes.")
+	cmd.Flags().StringP("pr-title", "t", "", "The title of the pull requests. Defaults to the commit message.")
+	cmd.Flags().StringP("pr-body", "b", "", "The body of the pull requests.")
+	cmd.Flags().StringSliceP("reviewers", "r", nil, "The username of the reviewers to be added on the pull requests.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of concurrent runs.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Revert the changes without pushing them or creating pull requests.")
+	cmd.Flags().StringP("author-name", "", "", "Name of the committer. If not set, the global git config setting will be used.")
+	cmd.Flags().StringP("author-email", "", "", "Email of the committer. If not set, the global git config setting will be used.")
+	configureGit(cmd)
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+	cmd.Flags().AddFlagSet(summaryFlag())
+
+	return cmd
+}
+
+func rollback(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	reportPath, _ := flag.GetString("from-report")
+	commitPattern, _ := flag.GetString("commit-pattern")
+	commitTrailers, _ := flag.GetStringArray("commit-trailer")
+	branchName, _ := flag.GetString("branch")
+	commitMessage, _ := flag.GetString("commit-message")
+	prTitle, _ := flag.GetString("pr-title")
+	prBody, _ := flag.GetString("pr-body")
+	reviewers, _ := flag.GetStringSlice("reviewers")
+	concurrent, _ := flag.GetInt("concurrent")
+	dryRun, _ := flag.GetBool("dry-run")
+	authorName, _ := flag.GetString("author-name")
+	authorEmail, _ := flag.GetString("author-email")
+	strOutput, _ := flag.GetString("output")
+
+	commitMode := commitPattern != "" || len(commitTrailers) > 0
+	if (reportPath == "") == !commitMode {
+		return errors.New("either --from-report, or --commit-pattern and/or --commit-trailer, has to be set")
+	}
+
+	var commitMatcher *multigitter.CommitMatcher
+	if commitMode {
+		commitMatcher = &multigitter.CommitMatcher{}
+		if commitPattern != "" {
+			regex, err := regexp.Compile(commitPattern)
+			if err != nil {
+				return errors.Wrap(err, "could not parse --commit-pattern")
+			}
+			commitMatcher.MessagePattern = regex
+		}
+		for _, trailer := range commitTrailers {
+			if !commitTrailerRegex.MatchString(trailer) {
+				return errors.Errorf(`could not parse commit trailer "%s", it should be in the format "Key: value"`, trailer)
+			}
+		}
+		commitMatcher.Trailers = commitTrailers
+
+		// The commits can only be found with the history of the repositories
+		if !flag.Changed("fetch-depth") {
+			_ = flag.Set("fetch-depth", "0")
+		}
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	var commitAuthor *domain.CommitAuthor
+	if authorName != "" || authorEmail != "" {
+		if authorName == "" || authorEmail == "" {
+			return errors.New("both author-name and author-email has to be set if the other is set")
+		}
+		commitAuthor = &domain.CommitAuthor{
+			Name:  authorName,
+			Email: authorEmail,
+		}
+	}
+
+	if prTitle == "" {
+		prTitle = commitMessage
+	}
+
+	parsedReviewers := make([]domain.Reviewer, len(reviewers))
+	for i, reviewer := range reviewers {
+		parsedReviewers[i] = domain.ParseReviewer(reviewer)
+	}
+
+	var report multigitter.Report
+	if reportPath != "" {
+		var err error
+		report, err = readReport(reportPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	gitCreator, err := getGitCreator(flag)
+	if err != nil {
+		return err
+	}
+
+	rollbacker := multigitter.Rollbacker{
+		VersionController: vc,
+
+		Report:  report,
+		Commits: commitMatcher,
+
+		Runner: &multigitter.Runner{
+			VersionController: vc,
+			Filter:            filter,
+
+			FeatureBranch: branchName,
+			Token:         token,
+
+			Output:  output,
+			Summary: summaryOutput(cmd),
+
+			CommitMessage:    commitMessage,
+			PullRequestTitle: prTitle,
+			PullRequestBody:  prBody,
+			Reviewers:        parsedReviewers,
+			CommitAuthor:     commitAuthor,
+			DryRun:           dryRun,
+
+			Concurrent: concurrent,
+
+			CreateGit: gitCreator,
+		},
+	}
+
+	return rollbacker.Rollback(context.Background())
+}
@@ -22,6 +22,26 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(MergeCmd())
 	cmd.AddCommand(CloseCmd())
 	cmd.AddCommand(PrintCmd())
+	cmd.AddCommand(OpenCmd())
+	cmd.AddCommand(ForksCmd())
+	cmd.AddCommand(CompareCmd())
+	cmd.AddCommand(HistoryCmd())
+	cmd.AddCommand(DashboardCmd())
+	cmd.AddCommand(ServeCmd())
+	cmd.AddCommand(RollbackCmd())
+	cmd.AddCommand(RenameBranchCmd())
+	cmd.AddCommand(SettingsCmd())
+	cmd.AddCommand(SecretsCmd())
+	cmd.AddCommand(WebhooksCmd())
+	cmd.AddCommand(LabelsCmd())
+	cmd.AddCommand(BranchProtectionCmd())
+	cmd.AddCommand(PermissionsCmd())
+	cmd.AddCommand(ArchiveCmd())
+	cmd.AddCommand(TopicsCmd())
+	cmd.AddCommand(ReleaseCmd())
+	cmd.AddCommand(IssueCmd())
+	cmd.AddCommand(AuditCmd())
+	cmd.AddCommand(AuthCmd())
 	cmd.AddCommand(VersionCmd())
 
 	return cmd
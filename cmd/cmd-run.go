@@ -4,21 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/lindell/multi-gitter/internal/domain"
-
+	"github.com/lindell/multi-gitter/internal/history"
 	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/internal/queue"
+	"github.com/lindell/multi-gitter/internal/reportsink"
+	"github.com/lindell/multi-gitter/internal/servicenow"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
 )
 
 const runHelp = `
 This command will clone down multiple repositories. For each of those repositories, the script will be run in the context of that repository. If the script finished with a zero exit code, and the script resulted in file changes, a pull request will be created with.
 
 The environment variable REPOSITORY will be set to the name of the repository currently being executed by the script.
+
+Instead of running a script, the same change can be applied to all repositories with --cherry-pick or --patch-file. This can for example be used to propagate a fix from a template repository to the repositories created from it.
 `
 
 // RunCmd is the main command that runs a script for multiple repositories and creates PRs with the changes made
@@ -27,31 +38,95 @@ func RunCmd() *cobra.Command {
 		Use:     "run [script path]",
 		Short:   "Clones multiple repositories, run a script in that directory, and creates a PR with those changes.",
 		Long:    runHelp,
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.MaximumNArgs(1),
 		PreRunE: logFlagInit,
 		RunE:    run,
 	}
 
 	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("cherry-pick", "", "", `Apply the changes of this commit, instead of running a script. The commit message is used if no commit message or pull request title is set. Can also be a range in the format "from..to", for example two versions of a template repository, of which all changes are applied.`)
+	cmd.Flags().StringP("cherry-pick-repo", "", ".", "The path of a local repository, or the url of a remote repository, that contains the commit set with --cherry-pick.")
+	cmd.Flags().StringP("patch-file", "", "", "Apply the changes in this patch file, created with git diff or git format-patch, instead of running a script.")
 	cmd.Flags().StringP("base-branch", "", "", "The branch which the changes will be based on.")
 	cmd.Flags().StringP("pr-title", "t", "", "The title of the PR. Will default to the first line of the commit message if none is set.")
 	cmd.Flags().StringP("pr-body", "b", "", "The body of the commit message. Will default to everything but the first line of the commit message if none is set.")
+	cmd.Flags().StringArrayP("pr-template", "", nil, `A file with the title, on the first line, and the body of the PR that is used instead of --pr-title and --pr-body in some repositories. In the format "owner:name=path" or "topic:name=path", for example "owner:my-subsidiary=pr-sv.md". Can be used multiple times, the first matching template is used.`)
+	cmd.Flags().IntP("pr-max-title-length", "", 0, "The maximum number of characters allowed in the title of the PR. The limit of the platform is always checked.")
+	cmd.Flags().IntP("pr-max-body-length", "", 0, "The maximum number of characters allowed in the body of the PR, including the footer. The limit of the platform is always checked.")
+	cmd.Flags().StringSliceP("pr-required-section", "", nil, `Markdown headings that has to exist in the body of the PR, for example "Motivation".`)
+	cmd.Flags().StringSliceP("pr-forbidden-word", "", nil, "Words that are not allowed in the title or body of the PR.")
 	cmd.Flags().StringP("commit-message", "m", "", "The commit message. Will default to title + body if none is set.")
-	cmd.Flags().StringSliceP("reviewers", "r", nil, "The username of the reviewers to be added on the pull request.")
+	cmd.Flags().StringArrayP("commit-trailer", "", nil, `A trailer that is appended to the commit message, in the format "Key: value", for example "Campaign-Id: my-campaign". Can be used multiple times.`)
+	cmd.Flags().StringSliceP("reviewers", "r", nil, `The username of the reviewers to be added on the pull request. Teams (GitHub and Gitea) or groups (GitLab) can be added with the "team:" prefix, for example "team:my-team".`)
 	cmd.Flags().IntP("max-reviewers", "M", 0, "If this value is set, reviewers will be randomized.")
+	cmd.Flags().BoolP("default-reviewers", "", false, "Add the default reviewers of each repository to the pull request. Code owners (GitHub and Gitea), approval rules (GitLab) and branch policies (Azure DevOps) are added by the platform itself, while the default reviewers on Bitbucket are looked up.")
+	cmd.Flags().StringSliceP("assignees", "a", nil, "The username of the assignees to be added on the pull request.")
+	cmd.Flags().StringP("milestone", "", "", "The title of a milestone the pull request should be added to. Repositories without the milestone will get a pull request without it.")
+	cmd.Flags().BoolP("draft", "", false, `Create the pull request as a draft. On GitLab the title is prefixed with "Draft:" and on Gitea with "WIP:".`)
+	cmd.Flags().StringSliceP("path-label", "", nil, `Labels that should be added to the pull request if any of the changed files matches a pattern. In the format "pattern=label", where the pattern uses gitignore syntax, for example "Dockerfile=docker".`)
+	cmd.Flags().StringSliceP("gitlab-approver", "", nil, "The username of users that should be required to approve the merge request (GitLab). Requires a GitLab tier with approval rules.")
 	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of concurrent runs.")
+	cmd.Flags().IntP("concurrent-min", "", 0, "If set, the number of concurrent runs is adapted to the load, memory and disk I/O pressure of the system, between this number and --concurrent. Starts at this number and is increased as long as the system can handle more (Linux only).")
+	cmd.Flags().IntP("concurrent-per-host", "", 0, "The maximum number of concurrent runs of repositories on the same host, for example a single GitLab instance when several platforms are used. Zero means no limit other than --concurrent.")
+	cmd.Flags().IntP("concurrent-per-org", "", 0, "The maximum number of concurrent runs of repositories with the same owner, such as an organization, group or user. Zero means no limit other than --concurrent.")
 	cmd.Flags().BoolP("skip-pr", "", false, "Skip pull request and directly push to the branch.")
+	cmd.Flags().BoolP("verify-checks", "", false, "Wait for the checks, such as CI jobs, of each created pull request to finish. Repositories where any check failed are reported as failed, and the result of each check is added to the report.")
+	cmd.Flags().DurationP("verify-timeout", "", 30*time.Minute, "The maximum time to wait for the checks of a pull request when using --verify-checks.")
+	cmd.Flags().BoolP("publish-annotations", "", false, `Publish the annotations the script writes to the file in the ANNOTATIONS_FILE environment variable as a check run on the pushed commit, to show issues that could not be fixed inline. Each line of the file is a JSON object with "path", "line", "message" and "severity" ("notice", "warning" or "failure"). Requires authentication as a GitHub App (GitHub).`)
+	cmd.Flags().BoolP("suggest", "", false, "Suggest the changes in review comments on the open pull request of the branch, for the owners to apply themselves, instead of pushing them. The script is run on the branch of the pull request, and only changes of lines that are part of the pull request can be suggested (GitHub and GitLab).")
+	cmd.Flags().BoolP("skip-equivalent", "", false, "Skip repositories where an open pull request, from another branch, already contains the same changes.")
+	cmd.Flags().BoolP("update-branch", "", false, "If the branch does already exist, update it by merging it with the new changes instead of skipping the repository. No new pull request is created for an updated branch. Requires --git-type=cmd.")
+	cmd.Flags().StringSliceP("conflict-strategy", "", nil, `How conflicts should be resolved when updating an existing branch. In the format "pattern=resolution", where the pattern uses gitignore syntax and resolution is either "ours" (the changes made by the script) or "theirs" (the existing branch), for example "package-lock.json=ours". The first matching pattern is used.`)
+	cmd.Flags().StringP("conflict-resolver", "", "", `A script that is run to resolve conflicts not covered by any conflict strategy. The conflicted files are available in the CONFLICTED_FILES environment variable, separated by newlines. Repositories with remaining conflicts are reported as failed.`)
 	cmd.Flags().BoolP("interactive", "i", false, "Take manual decision before committing any change. Requires git to be installed.")
+	cmd.Flags().BoolP("pick", "", false, "Interactively pick which of the repositories that should be used before the run starts.")
+	cmd.Flags().BoolP("interactive-select", "", false, "The same as --pick.")
+	cmd.Flags().BoolP("sandbox-no-network", "", false, "Run the script without network access. Uses firejail if installed, otherwise a network namespace (Linux only).")
+	cmd.Flags().BoolP("sandbox-read-only", "", false, "Run the script with a read-only filesystem outside of the repository. Requires firejail (Linux only).")
+	cmd.Flags().DurationP("sandbox-cpu-time", "", 0, "The maximum cpu time the script is allowed to use, for example 30s. Uses firejail or prlimit (Linux only).")
+	cmd.Flags().IntP("sandbox-memory", "", 0, "The maximum memory, in megabytes, the script is allowed to use. Uses firejail or prlimit (Linux only).")
+	cmd.Flags().StringArrayP("require", "", nil, `A tool that the script requires, optionally with a version constraint, for example "node>=18" or "yq". The run fails before any repository is changed if it is not installed. Can be used multiple times, or set as a list in the config file.`)
+	cmd.Flags().DurationP("grace-period", "", 0, "The time ongoing scripts are allowed to finish when the run is interrupted, before they are killed. The repositories that have not been started are skipped either way. Zero means that the scripts are only killed if interrupted again.")
 	cmd.Flags().BoolP("dry-run", "d", false, "Run without pushing changes or creating pull requests.")
+	cmd.Flags().BoolP("simulate", "", false, "Do everything a real run would, such as checking for existing branches and updating them, but without forking, pushing or making any other change on the platform. The pull requests that would be created are logged, and previewed in the report.")
 	cmd.Flags().BoolP("fork", "", false, "Fork the repository instead of creating a new branch on the same owner.")
 	cmd.Flags().StringP("fork-owner", "", "", "If set, make the fork to defined one. Default behavior is for the fork to be on the logged in user.")
+	cmd.Flags().StringP("deploy-key-dir", "", "", `A directory with ssh keys used to push instead of the token, named after the repository, for example "my-org/my-repo". Repositories without a key are pushed to with the token (GitHub).`)
+	cmd.Flags().StringP("deploy-key-command", "", "", "A command that prints the path of the ssh key used to push to a repository, instead of the token. The repository is available in the REPOSITORY environment variable. If nothing is printed, the token is used (GitHub).")
+	cmd.Flags().StringP("campaign-id", "", "", "An identifier of the change, added to the footer of the pull request body. It can be used to find the pull requests with other commands. Defaults to the branch name.")
+	cmd.Flags().StringP("campaign-url", "", "", "A link to the configuration or description of the change, added to the footer of the pull request body.")
+	cmd.Flags().BoolP("skip-footer", "", false, "Skip adding a footer with provenance metadata (campaign, version and script hash) to the pull request body.")
+	cmd.Flags().StringP("change-request", "", "", `The number of an approved change request, for example "CHG0030001", that is verified in ServiceNow before anything is changed. It is referenced in the pull request bodies and the report.`)
+	cmd.Flags().StringP("servicenow-url", "", "", "The url of the ServiceNow instance the change request is verified in. It is authenticated with the SERVICENOW_TOKEN environment variable, or SERVICENOW_USERNAME and SERVICENOW_PASSWORD.")
+	cmd.Flags().BoolP("create-issue-on-failure", "", false, "Create an issue, containing the error and the output of the script, in repositories where the run failed.")
+	cmd.Flags().StringP("issue-repo", "", "", `Create the issues of failing repositories in this repository instead, in the format "owner/name".`)
+	cmd.Flags().StringP("report", "", "", "Write a JSON report of the result of each repository to this file. Reports of different runs can be compared with the compare command.")
+	cmd.Flags().IntP("slowest", "", 0, "Print the given number of repositories that took the longest time to run, together with the cpu time and memory used by the script and the size of the clone, when the run has finished. The resources used by each repository are also added to the report.")
+	cmd.Flags().StringSliceP("report-sink", "", nil, `Send the JSON report to this destination as well. Can be a file path, an http(s) url the report is posted to, "s3://bucket/key", "gs://bucket/object" or "bigquery://project/dataset/table". Google Cloud is authenticated with the GOOGLE_OAUTH_ACCESS_TOKEN environment variable, or else gcloud or the metadata server.`)
+	cmd.Flags().StringSliceP("only-repo", "", nil, `Only run on these repositories, in the format "owner/name", out of the ones selected with the platform flags. Can be used to retry repositories that failed.`)
+	cmd.Flags().StringP("from-report", "", "", "Run on the same repositories as an earlier run, by reading them from its report, written with --report. If no organization, group, user, repository or project is set, the repositories in the report are used directly.")
+	cmd.Flags().AddFlagSet(dependsOnFlag())
+	cmd.Flags().StringP("history-file", "", "", `Record the run, and the result and logs of each repository, in this SQLite database. The recorded runs can be listed with the history command, which reads "~/.multi-gitter/history.db" by default. Preferably set in the config file.`)
+	cmd.Flags().StringP("queue", "", "", `Distribute the repositories between several machines through this queue. Can be a directory shared between the machines, "redis://[:password@]host[:port][/db][?key=...]", "nats://[user:password@]host[:port][?stream=...]" or "sqs://sqs.region.amazonaws.com/account/queue[?results=...]".`)
+	cmd.Flags().StringP("queue-role", "", queueRoleCoordinator, `The role of the run when a queue is used. The coordinator enqueues the repositories and waits for them to be run, and writes the reports. Workers run the repositories until the queue is empty. Can be "coordinator" or "worker".`)
+	_ = cmd.RegisterFlagCompletionFunc("queue-role", func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{queueRoleCoordinator, queueRoleWorker}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().DurationP("queue-lease", "", multigitter.DefaultQueueLease, "How long a worker may go without reporting that it is still running a repository, before the repository is given to another worker.")
+	cmd.Flags().StringP("ownership-report", "", "", "Write a report of which owners, defined in the CODEOWNERS file of each repository, own the changed files to this file.")
+	cmd.Flags().StringP("ownership-report-format", "", "json", `The format of the ownership report. Can be "json" or "markdown".`)
+	_ = cmd.RegisterFlagCompletionFunc("ownership-report-format", func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{multigitter.OwnershipFormatJSON, multigitter.OwnershipFormatMarkdown}, cobra.ShellCompDirectiveNoFileComp
+	})
 	cmd.Flags().StringP("author-name", "", "", "Name of the committer. If not set, the global git config setting will be used.")
 	cmd.Flags().StringP("author-email", "", "", "Email of the committer. If not set, the global git config setting will be used.")
+	cmd.Flags().BoolP("author-from-token", "", false, "Use the name and email of the user the token belongs to as the committer. On GitHub, the noreply email of the user, or of the bot user of the GitHub App, will be used.")
 	configureGit(cmd)
 	configurePlatform(cmd)
 	configureLogging(cmd, "-")
 	configureConfig(cmd)
 	cmd.Flags().AddFlagSet(outputFlag())
+	cmd.Flags().AddFlagSet(summaryFlag())
 
 	return cmd
 }
@@ -61,69 +136,91 @@ func run(cmd *cobra.Command, args []string) error {
 
 	branchName, _ := flag.GetString("branch")
 	baseBranchName, _ := flag.GetString("base-branch")
-	prTitle, _ := flag.GetString("pr-title")
-	prBody, _ := flag.GetString("pr-body")
-	commitMessage, _ := flag.GetString("commit-message")
-	reviewers, _ := flag.GetStringSlice("reviewers")
+	prMaxTitleLength, _ := flag.GetInt("pr-max-title-length")
+	prMaxBodyLength, _ := flag.GetInt("pr-max-body-length")
+	prRequiredSections, _ := flag.GetStringSlice("pr-required-section")
+	prForbiddenWords, _ := flag.GetStringSlice("pr-forbidden-word")
 	maxReviewers, _ := flag.GetInt("max-reviewers")
+	assignees, _ := flag.GetStringSlice("assignees")
+	milestone, _ := flag.GetString("milestone")
+	draft, _ := flag.GetBool("draft")
 	concurrent, _ := flag.GetInt("concurrent")
+	concurrentMin, _ := flag.GetInt("concurrent-min")
+	concurrentPerHost, _ := flag.GetInt("concurrent-per-host")
+	concurrentPerOrg, _ := flag.GetInt("concurrent-per-org")
 	skipPullRequest, _ := flag.GetBool("skip-pr")
+	skipEquivalent, _ := flag.GetBool("skip-equivalent")
+	verifyChecks, _ := flag.GetBool("verify-checks")
+	verifyTimeout, _ := flag.GetDuration("verify-timeout")
+	publishAnnotations, _ := flag.GetBool("publish-annotations")
+	suggest, _ := flag.GetBool("suggest")
+	updateBranch, _ := flag.GetBool("update-branch")
 	interactive, _ := flag.GetBool("interactive")
+	strRequirements, _ := flag.GetStringArray("require")
+	gracePeriod, _ := flag.GetDuration("grace-period")
 	dryRun, _ := flag.GetBool("dry-run")
+	simulate, _ := flag.GetBool("simulate")
 	forkMode, _ := flag.GetBool("fork")
 	forkOwner, _ := flag.GetString("fork-owner")
-	authorName, _ := flag.GetString("author-name")
-	authorEmail, _ := flag.GetString("author-email")
-	strOutput, _ := flag.GetString("output")
+	slowest, _ := flag.GetInt("slowest")
+	queueLease, _ := flag.GetDuration("queue-lease")
+	ownershipReportFormat, _ := flag.GetString("ownership-report-format")
+	createIssueOnFailure, _ := flag.GetBool("create-issue-on-failure")
+	issueRepo, _ := flag.GetString("issue-repo")
+
+	if err := verifyRunFlags(flag); err != nil {
+		return err
+	}
 
 	token, err := getToken(flag)
 	if err != nil {
 		return err
 	}
 
-	if concurrent < 1 {
-		return errors.New("concurrent runs can't be less than one")
+	output, report, ownershipReport, err := getRunOutputs(flag)
+	if err != nil {
+		return err
 	}
+	defer closeOutputs(report, ownershipReport)
 
-	output, err := fileOutput(strOutput, os.Stdout)
+	dependencies, err := getDependencies(flag)
 	if err != nil {
 		return err
 	}
 
-	// Set commit message based on pr title and body or the reverse
-	if commitMessage == "" && prTitle == "" {
-		return errors.New("pull request title or commit message must be set")
-	} else if commitMessage == "" {
-		commitMessage = prTitle
-		if prBody != "" {
-			commitMessage += "\n" + prBody
-		}
-	} else if prTitle == "" {
-		split := strings.SplitN(commitMessage, "\n", 2)
-		prTitle = split[0]
-		if prBody == "" && len(split) == 2 {
-			prBody = split[2]
-		}
+	reportSinks, err := getReportSinks(flag, args)
+	if err != nil {
+		return err
 	}
 
-	if skipPullRequest && forkMode {
-		return errors.New("--fork and --skip-pr can't be used at the same time")
+	runQueue, err := getQueue(flag, dependencies)
+	if err != nil {
+		return err
 	}
 
-	if concurrent > 1 && interactive {
-		return errors.New("--concurrent and --interactive can't be used at the same time")
+	patch, patchMessage, err := getPatch(flag, args)
+	if err != nil {
+		return err
 	}
 
-	// Parse commit author data
-	var commitAuthor *domain.CommitAuthor
-	if authorName != "" || authorEmail != "" {
-		if authorName == "" || authorEmail == "" {
-			return errors.New("both author-name and author-email has to be set if the other is set")
-		}
-		commitAuthor = &domain.CommitAuthor{
-			Name:  authorName,
-			Email: authorEmail,
-		}
+	commitMessage, prTitle, prBody, err := getCommitMessage(flag, patchMessage)
+	if err != nil {
+		return err
+	}
+
+	reviewers, pathLabels, prTemplates, err := getPullRequestOptions(flag)
+	if err != nil {
+		return err
+	}
+
+	requirements, err := parseRequirements(strRequirements)
+	if err != nil {
+		return err
+	}
+
+	onlyRepos, err := getOnlyRepositories(flag)
+	if err != nil {
+		return err
 	}
 
 	vc, err := getVersionController(flag, true)
@@ -131,61 +228,757 @@ func run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	commitAuthor, err := getCommitAuthor(flag, vc)
+	if err != nil {
+		return err
+	}
+
 	gitCreator, err := getGitCreator(flag)
 	if err != nil {
 		return err
 	}
 
-	executablePath, arguments, err := parseCommand(flag.Arg(0))
+	executablePath, arguments, err := getScript(flag, patch)
 	if err != nil {
 		return err
 	}
 
-	// Set up signal listening to cancel the context and let started runs finish gracefully
-	ctx, cancel := context.WithCancel(context.Background())
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		fmt.Println("Finishing up ongoing runs. Press CTRL+C again to abort now.")
-		cancel()
-		<-c
-		os.Exit(1)
-	}()
+	conflictStrategies, conflictResolverPath, conflictResolverArguments, err := getConflictResolver(flag)
+	if err != nil {
+		return err
+	}
+
+	deployKeys, err := getDeployKeys(flag)
+	if err != nil {
+		return err
+	}
+
+	provenance, err := getProvenance(flag, executablePath, arguments, patch)
+	if err != nil {
+		return err
+	}
+
+	changeRequest, err := getChangeRequest(flag)
+	if err != nil {
+		return err
+	}
+
+	sandbox, err := getSandbox(flag)
+	if err != nil {
+		return err
+	}
+
+	ctx, killCtx := interruptContexts(gracePeriod)
 
 	runner := &multigitter.Runner{
 		ScriptPath:    executablePath,
 		Arguments:     arguments,
+		Patch:         patch,
 		FeatureBranch: branchName,
 		Token:         token,
 
-		Output: output,
+		Output:      output,
+		Report:      report,
+		ReportSinks: reportSinks,
+		Slowest:     slowest,
+		Summary:     summaryOutput(cmd),
+
+		OnlyRepositories: onlyRepos,
+		QueueLease:       queueLease,
+		Dependencies:     dependencies,
+
+		OwnershipReport:       ownershipReport,
+		OwnershipReportFormat: ownershipReportFormat,
 
 		VersionController: vc,
+		Filter:            filter,
 
 		CommitMessage:    commitMessage,
 		PullRequestTitle: prTitle,
 		PullRequestBody:  prBody,
 		Reviewers:        reviewers,
 		MaxReviewers:     maxReviewers,
+		Assignees:        assignees,
+		Milestone:        milestone,
+		Draft:            draft,
+		PathLabels:       pathLabels,
+		Provenance:       provenance,
 		Interactive:      interactive,
+		Pick:             getPick(flag),
 		DryRun:           dryRun,
+		Simulate:         simulate,
 		Fork:             forkMode,
 		ForkOwner:        forkOwner,
-		SkipPullRequest:  skipPullRequest,
-		CommitAuthor:     commitAuthor,
-		BaseBranch:       baseBranchName,
+		DeployKeys:       deployKeys,
+		PullRequestRules: multigitter.PullRequestRules{
+			MaxTitleLength:   prMaxTitleLength,
+			MaxBodyLength:    prMaxBodyLength,
+			RequiredSections: prRequiredSections,
+			ForbiddenWords:   prForbiddenWords,
+		},
+		FailureIssues: multigitter.FailureIssues{
+			Enabled: createIssueOnFailure,
+			Repo:    issueRepo,
+			Censor:  censor,
+		},
+		SkipPullRequest: skipPullRequest,
+		SkipEquivalent:  skipEquivalent,
+		VerifyChecks:    verifyChecks,
+		VerifyTimeout:   verifyTimeout,
+		UpdateBranch:    updateBranch,
+		CommitAuthor:    commitAuthor,
+		BaseBranch:      baseBranchName,
+
+		PullRequestTemplates: prTemplates,
+		ChangeRequest:        changeRequest,
+		PublishAnnotations:   publishAnnotations,
+		Suggest:              suggest,
+
+		ConflictStrategies:        conflictStrategies,
+		ConflictResolver:          conflictResolverPath,
+		ConflictResolverArguments: conflictResolverArguments,
+
+		Sandbox:      sandbox,
+		Requirements: requirements,
+		KillContext:  killCtx,
 
 		Concurrent: concurrent,
+		ConcurrencyLimits: multigitter.ConcurrencyLimits{
+			PerHost:  concurrentPerHost,
+			PerOwner: concurrentPerOrg,
+			Min:      concurrentMin,
+		},
 
 		CreateGit: gitCreator,
 	}
 
-	err = runner.Run(ctx)
-	if err != nil {
+	// Validate the pull request, and that the script can be run, before any repository is changed
+	if err := runner.ValidatePullRequest(); err != nil {
+		return err
+	}
+	if err := runner.CheckRequirements(); err != nil {
+		return err
+	}
+
+	if err := runWithQueue(ctx, runner, flag, runQueue); err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
 	return nil
 }
+
+// verifyConcurrencyFlags returns an error if the number of concurrent runs is set to something that can't be used
+func verifyConcurrencyFlags(flag *flag.FlagSet) error {
+	concurrent, _ := flag.GetInt("concurrent")
+	concurrentMin, _ := flag.GetInt("concurrent-min")
+	concurrentPerHost, _ := flag.GetInt("concurrent-per-host")
+	concurrentPerOrg, _ := flag.GetInt("concurrent-per-org")
+	interactive, _ := flag.GetBool("interactive")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	if concurrentMin < 0 || concurrentMin > concurrent {
+		return errors.New("--concurrent-min has to be between zero and --concurrent")
+	}
+
+	if concurrentPerHost < 0 || concurrentPerOrg < 0 {
+		return errors.New("concurrent runs per host or organization can't be negative")
+	}
+
+	if concurrent > 1 && interactive {
+		return errors.New("--concurrent and --interactive can't be used at the same time")
+	}
+
+	return nil
+}
+
+// verifyRunFlags returns an error if flags that can't be used together are set
+func verifyRunFlags(flag *flag.FlagSet) error {
+	skipPullRequest, _ := flag.GetBool("skip-pr")
+	forkMode, _ := flag.GetBool("fork")
+	verifyChecks, _ := flag.GetBool("verify-checks")
+	suggest, _ := flag.GetBool("suggest")
+	updateBranch, _ := flag.GetBool("update-branch")
+	dryRun, _ := flag.GetBool("dry-run")
+	simulate, _ := flag.GetBool("simulate")
+	issueRepo, _ := flag.GetString("issue-repo")
+	createIssueOnFailure, _ := flag.GetBool("create-issue-on-failure")
+	slowest, _ := flag.GetInt("slowest")
+	gracePeriod, _ := flag.GetDuration("grace-period")
+	ownershipReportFormat, _ := flag.GetString("ownership-report-format")
+
+	if err := verifyConcurrencyFlags(flag); err != nil {
+		return err
+	}
+
+	if slowest < 0 {
+		return errors.New("--slowest can't be negative")
+	}
+
+	if skipPullRequest && forkMode {
+		return errors.New("--fork and --skip-pr can't be used at the same time")
+	}
+
+	if skipPullRequest && verifyChecks {
+		return errors.New("--verify-checks can't be used together with --skip-pr")
+	}
+
+	if suggest && (skipPullRequest || forkMode || updateBranch || simulate) {
+		return errors.New("--suggest can't be used together with --skip-pr, --fork, --update-branch or --simulate")
+	}
+
+	if dryRun && simulate {
+		return errors.New("--dry-run and --simulate can't be used at the same time")
+	}
+
+	if issueRepo != "" && !createIssueOnFailure {
+		return errors.New("--issue-repo can only be used together with --create-issue-on-failure")
+	}
+
+	if gracePeriod < 0 {
+		return errors.New("--grace-period can't be negative")
+	}
+
+	if ownershipReportFormat != multigitter.OwnershipFormatJSON && ownershipReportFormat != multigitter.OwnershipFormatMarkdown {
+		return fmt.Errorf(`unknown ownership report format "%s"`, ownershipReportFormat)
+	}
+
+	return nil
+}
+
+// getReportSinks gets the sinks the report of the run is sent to, including the local history
+func getReportSinks(flag *flag.FlagSet, args []string) ([]multigitter.ReportSink, error) {
+	reportSinkURLs, _ := flag.GetStringSlice("report-sink")
+	historyFile, _ := flag.GetString("history-file")
+
+	reportSinks := make([]multigitter.ReportSink, len(reportSinkURLs))
+	for i, sinkURL := range reportSinkURLs {
+		var err error
+		reportSinks[i], err = reportsink.Parse(sinkURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if historyFile != "" {
+		branchName, _ := flag.GetString("branch")
+		commitMessage, _ := flag.GetString("commit-message")
+		prTitle, _ := flag.GetString("pr-title")
+		campaignURL, _ := flag.GetString("campaign-url")
+		dryRun, _ := flag.GetBool("dry-run")
+		simulate, _ := flag.GetBool("simulate")
+
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		recorder := history.NewRecorder(expandHome(historyFile), history.Campaign{
+			BranchName:    branchName,
+			CommitMessage: commitMessage,
+			PRTitle:       prTitle,
+			CampaignURL:   campaignURL,
+			DryRun:        dryRun || simulate,
+			Directory:     workingDir,
+			Arguments:     historyArguments(flag, args),
+		})
+		// The logs of each repository are recorded together with its result, censored in the same way as when they are printed
+		recorder.Censor = censor
+		log.AddHook(recorder)
+		reportSinks = append(reportSinks, recorder)
+	}
+
+	return reportSinks, nil
+}
+
+// getQueue gets the queue the run is coordinated through, or nil if the repositories are run without a queue
+func getQueue(flag *flag.FlagSet, dependencies multigitter.Dependencies) (multigitter.Queue, error) {
+	queueURL, _ := flag.GetString("queue")
+	queueRole, _ := flag.GetString("queue-role")
+
+	if queueURL == "" {
+		return nil, nil
+	}
+	if len(dependencies) > 0 {
+		return nil, errors.New("--depends-on can't be used together with --queue")
+	}
+	if queueRole != queueRoleCoordinator && queueRole != queueRoleWorker {
+		return nil, fmt.Errorf(`unknown queue role "%s"`, queueRole)
+	}
+	return queue.Parse(queueURL)
+}
+
+// getPatch gets the patch that is applied instead of running a script, and the commit message of the cherry-picked commit.
+// The patch is empty if a script is run
+func getPatch(flag *flag.FlagSet, args []string) (patch string, commitMessage string, err error) {
+	cherryPick, _ := flag.GetString("cherry-pick")
+	cherryPickRepo, _ := flag.GetString("cherry-pick-repo")
+	patchFile, _ := flag.GetString("patch-file")
+
+	changeSources := 0
+	for _, set := range []bool{len(args) > 0, cherryPick != "", patchFile != ""} {
+		if set {
+			changeSources++
+		}
+	}
+	if changeSources != 1 {
+		return "", "", errors.New("either a script, --cherry-pick or --patch-file has to be set")
+	}
+
+	if cherryPick != "" {
+		return multigitter.CommitPatch(expandHome(cherryPickRepo), cherryPick)
+	}
+	if patchFile != "" {
+		data, err := ioutil.ReadFile(expandHome(patchFile))
+		if err != nil {
+			return "", "", fmt.Errorf("could not read the patch file: %w", err)
+		}
+		return string(data), "", nil
+	}
+	return "", "", nil
+}
+
+// getCommitMessage gets the commit message, pull request title and pull request body.
+// If not set, the commit message is based on the pull request title and body, or the reverse
+func getCommitMessage(flag *flag.FlagSet, patchMessage string) (commitMessage, prTitle, prBody string, err error) {
+	commitMessage, _ = flag.GetString("commit-message")
+	prTitle, _ = flag.GetString("pr-title")
+	prBody, _ = flag.GetString("pr-body")
+	commitTrailers, _ := flag.GetStringArray("commit-trailer")
+
+	// The message of a cherry-picked commit is used if nothing else is set
+	if commitMessage == "" && prTitle == "" {
+		commitMessage = patchMessage
+	}
+
+	if commitMessage == "" && prTitle == "" {
+		return "", "", "", errors.New("pull request title or commit message must be set")
+	} else if commitMessage == "" {
+		commitMessage = prTitle
+		if prBody != "" {
+			commitMessage += "\n" + prBody
+		}
+	} else if prTitle == "" {
+		split := strings.SplitN(commitMessage, "\n", 2)
+		prTitle = split[0]
+		if prBody == "" && len(split) == 2 {
+			prBody = strings.TrimSpace(split[1])
+		}
+	}
+
+	commitMessage, err = addCommitTrailers(commitMessage, commitTrailers)
+	if err != nil {
+		return "", "", "", err
+	}
+	return commitMessage, prTitle, prBody, nil
+}
+
+// getCommitAuthor gets the author of the commits, or nil if the author of the git config should be used
+func getCommitAuthor(flag *flag.FlagSet, vc multigitter.VersionController) (*domain.CommitAuthor, error) {
+	authorName, _ := flag.GetString("author-name")
+	authorEmail, _ := flag.GetString("author-email")
+	authorFromToken, _ := flag.GetBool("author-from-token")
+
+	if authorFromToken {
+		if authorName != "" || authorEmail != "" {
+			return nil, errors.New("--author-from-token can't be used at the same time as author-name or author-email")
+		}
+		if platform, _ := flag.GetString("platform"); strings.Contains(platform, ",") {
+			return nil, errors.New("--author-from-token can't be used with multiple platforms, since each token belongs to a different user")
+		}
+		return getTokenCommitAuthor(vc)
+	}
+
+	if authorName == "" && authorEmail == "" {
+		return nil, nil
+	}
+	if authorName == "" || authorEmail == "" {
+		return nil, errors.New("both author-name and author-email has to be set if the other is set")
+	}
+	return &domain.CommitAuthor{
+		Name:  authorName,
+		Email: authorEmail,
+	}, nil
+}
+
+// getOnlyRepositories gets the names of the only repositories that should be used, from --only-repo or --from-report
+func getOnlyRepositories(flag *flag.FlagSet) ([]string, error) {
+	onlyRepos, _ := flag.GetStringSlice("only-repo")
+
+	fromReport, err := getFromReport(flag)
+	if err != nil {
+		return nil, err
+	}
+	if fromReport == nil {
+		return onlyRepos, nil
+	}
+
+	if len(onlyRepos) > 0 {
+		return nil, errors.New("--from-report and --only-repo can't be used at the same time")
+	}
+	onlyRepos = make([]string, len(fromReport.Repositories))
+	for i, repo := range fromReport.Repositories {
+		onlyRepos[i] = repo.Repository
+	}
+	if err := selectReportRepositories(flag, onlyRepos); err != nil {
+		return nil, err
+	}
+	return onlyRepos, nil
+}
+
+// getConflictResolver gets the strategies for files that conflict when updating branches,
+// and the command that resolves the remaining conflicts
+func getConflictResolver(flag *flag.FlagSet) (strategies []multigitter.ConflictStrategy, path string, arguments []string, err error) {
+	updateBranch, _ := flag.GetBool("update-branch")
+	strConflictStrategies, _ := flag.GetStringSlice("conflict-strategy")
+	conflictResolver, _ := flag.GetString("conflict-resolver")
+
+	if !updateBranch && (len(strConflictStrategies) > 0 || conflictResolver != "") {
+		return nil, "", nil, errors.New("--conflict-strategy and --conflict-resolver can only be used together with --update-branch")
+	}
+
+	strategies, err = parseConflictStrategies(strConflictStrategies)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if conflictResolver != "" {
+		path, arguments, err = parseCommand(conflictResolver)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+	return strategies, path, arguments, nil
+}
+
+// getScript gets the script that is run in each repository, unless a patch is applied instead
+func getScript(flag *flag.FlagSet, patch string) (string, []string, error) {
+	if patch != "" {
+		return "", nil, nil
+	}
+	return parseCommand(flag.Arg(0))
+}
+
+// getPullRequestOptions gets the reviewers, labels and templates of the pull requests
+func getPullRequestOptions(flag *flag.FlagSet) ([]domain.Reviewer, []multigitter.PathLabel, []multigitter.PullRequestTemplate, error) {
+	strReviewers, _ := flag.GetStringSlice("reviewers")
+	strPathLabels, _ := flag.GetStringSlice("path-label")
+	strPRTemplates, _ := flag.GetStringArray("pr-template")
+	defaultReviewers, _ := flag.GetBool("default-reviewers")
+
+	reviewers := make([]domain.Reviewer, len(strReviewers))
+	for i, reviewer := range strReviewers {
+		reviewers[i] = domain.ParseReviewer(reviewer)
+	}
+	if defaultReviewers {
+		reviewers = append(reviewers, domain.DefaultReviewers)
+	}
+
+	pathLabels, err := parsePathLabels(strPathLabels)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	prTemplates, err := readPullRequestTemplates(strPRTemplates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return reviewers, pathLabels, prTemplates, nil
+}
+
+// getRunOutputs gets where the output of the scripts, the report and the ownership report are written
+func getRunOutputs(flag *flag.FlagSet) (output io.Writer, report, ownershipReport io.WriteCloser, err error) {
+	strOutput, _ := flag.GetString("output")
+	reportFile, _ := flag.GetString("report")
+	ownershipReportFile, _ := flag.GetString("ownership-report")
+
+	output, err = fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if reportFile != "" {
+		report, err = fileOutput(reportFile, os.Stdout)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if ownershipReportFile != "" {
+		ownershipReport, err = fileOutput(ownershipReportFile, os.Stdout)
+		if err != nil {
+			closeOutputs(report)
+			return nil, nil, nil, err
+		}
+	}
+
+	return output, report, ownershipReport, nil
+}
+
+// closeOutputs closes the outputs that were opened
+func closeOutputs(outputs ...io.WriteCloser) {
+	for _, output := range outputs {
+		if output != nil {
+			output.Close()
+		}
+	}
+}
+
+// runWithQueue runs the repositories, as a coordinator or a worker if a queue is used
+func runWithQueue(ctx context.Context, runner *multigitter.Runner, flag *flag.FlagSet, runQueue multigitter.Queue) error {
+	queueRole, _ := flag.GetString("queue-role")
+
+	switch {
+	case runQueue == nil:
+		return runner.Run(ctx)
+	case queueRole == queueRoleWorker:
+		return runner.Work(ctx, runQueue)
+	default:
+		return runner.Coordinate(ctx, runQueue)
+	}
+}
+
+// getDeployKeys gets the deploy keys that are used to push to each repository
+func getDeployKeys(flag *flag.FlagSet) (multigitter.DeployKeys, error) {
+	forkMode, _ := flag.GetBool("fork")
+	deployKeyDir, _ := flag.GetString("deploy-key-dir")
+	deployKeyCommand, _ := flag.GetString("deploy-key-command")
+
+	if forkMode && (deployKeyDir != "" || deployKeyCommand != "") {
+		return multigitter.DeployKeys{}, errors.New("--fork can't be used together with deploy keys")
+	}
+
+	deployKeys := multigitter.DeployKeys{
+		Dir: deployKeyDir,
+	}
+	if deployKeyCommand != "" {
+		var err error
+		deployKeys.Command, deployKeys.Arguments, err = parseCommand(deployKeyCommand)
+		if err != nil {
+			return multigitter.DeployKeys{}, err
+		}
+	}
+	return deployKeys, nil
+}
+
+// getProvenance gets the provenance that is added as a footer to pull requests, or nil if no footer should be added
+func getProvenance(flag *flag.FlagSet, executablePath string, arguments []string, patch string) (*multigitter.Provenance, error) {
+	branchName, _ := flag.GetString("branch")
+	campaignID, _ := flag.GetString("campaign-id")
+	campaignURL, _ := flag.GetString("campaign-url")
+	skipFooter, _ := flag.GetBool("skip-footer")
+
+	if skipFooter {
+		return nil, nil
+	}
+
+	if campaignID == "" {
+		campaignID = branchName
+	}
+
+	hash, err := scriptHash(executablePath, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if patch != "" {
+		hash = patchHash(patch)
+	}
+
+	return &multigitter.Provenance{
+		CampaignID: campaignID,
+		Version:    Version,
+		ScriptHash: hash,
+		ConfigURL:  campaignURL,
+	}, nil
+}
+
+// getSandbox gets the restrictions the script is run with
+func getSandbox(flag *flag.FlagSet) (multigitter.Sandbox, error) {
+	sandboxNoNetwork, _ := flag.GetBool("sandbox-no-network")
+	sandboxReadOnly, _ := flag.GetBool("sandbox-read-only")
+	sandboxCPUTime, _ := flag.GetDuration("sandbox-cpu-time")
+	sandboxMemory, _ := flag.GetInt("sandbox-memory")
+
+	if sandboxCPUTime < 0 || sandboxMemory < 0 {
+		return multigitter.Sandbox{}, errors.New("sandbox limits can't be negative")
+	}
+
+	return multigitter.Sandbox{
+		NoNetwork: sandboxNoNetwork,
+		ReadOnly:  sandboxReadOnly,
+		CPUTime:   sandboxCPUTime,
+		Memory:    int64(sandboxMemory) * 1024 * 1024,
+	}, nil
+}
+
+// parseRequirements parses the tools the script requires
+func parseRequirements(strRequirements []string) ([]multigitter.Requirement, error) {
+	requirements := make([]multigitter.Requirement, len(strRequirements))
+	for i, str := range strRequirements {
+		var err error
+		requirements[i], err = multigitter.ParseRequirement(str)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return requirements, nil
+}
+
+// interruptContexts returns a context that is canceled on interrupt, to let started runs finish gracefully,
+// and a context that is canceled to kill the ongoing scripts after the grace period, or when interrupted again.
+// After which the report is written as usual
+func interruptContexts(gracePeriod time.Duration) (ctx context.Context, killCtx context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	killCtx, kill := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-c
+		fmt.Println("Finishing up ongoing runs. Press CTRL+C again to kill the ongoing scripts.")
+		cancel()
+		if gracePeriod > 0 {
+			time.AfterFunc(gracePeriod, kill)
+		}
+		<-c
+		fmt.Println("Killing ongoing scripts. Press CTRL+C again to abort now.")
+		kill()
+		<-c
+		os.Exit(1)
+	}()
+	return ctx, killCtx
+}
+
+func getTokenCommitAuthor(vc multigitter.VersionController) (*domain.CommitAuthor, error) {
+	type currentUserAuthorGetter interface {
+		GetCurrentUserAuthor(ctx context.Context) (domain.CommitAuthor, error)
+	}
+
+	g, ok := vc.(currentUserAuthorGetter)
+	if !ok {
+		return nil, errors.New("the platform does not support --author-from-token")
+	}
+
+	author, err := g.GetCurrentUserAuthor(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not get the author from the token: %w", err)
+	}
+
+	return &author, nil
+}
+
+func parsePathLabels(strPathLabels []string) ([]multigitter.PathLabel, error) {
+	pathLabels := make([]multigitter.PathLabel, len(strPathLabels))
+	for i, str := range strPathLabels {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[0] == "" || split[1] == "" {
+			return nil, fmt.Errorf(`could not parse path label "%s", it should be in the format "pattern=label"`, str)
+		}
+		pathLabels[i] = multigitter.PathLabel{
+			Pattern: split[0],
+			Label:   split[1],
+		}
+	}
+	return pathLabels, nil
+}
+
+func readPullRequestTemplates(strTemplates []string) ([]multigitter.PullRequestTemplate, error) {
+	templates := make([]multigitter.PullRequestTemplate, len(strTemplates))
+	for i, str := range strTemplates {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[1] == "" {
+			return nil, fmt.Errorf(`could not parse pull request template "%s", it should be in the format "owner:name=path" or "topic:name=path"`, str)
+		}
+
+		data, err := ioutil.ReadFile(expandHome(split[1]))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the pull request template: %w", err)
+		}
+
+		templates[i], err = multigitter.ParsePullRequestTemplate(split[0], string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func parseConflictStrategies(strStrategies []string) ([]multigitter.ConflictStrategy, error) {
+	strategies := make([]multigitter.ConflictStrategy, len(strStrategies))
+	for i, str := range strStrategies {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return nil, fmt.Errorf(`could not parse conflict strategy "%s", it should be in the format "pattern=resolution"`, str)
+		}
+
+		resolution, err := domain.ParseConflictResolution(split[1])
+		if err != nil {
+			return nil, err
+		}
+
+		strategies[i] = multigitter.ConflictStrategy{
+			Pattern:    split[0],
+			Resolution: resolution,
+		}
+	}
+	return strategies, nil
+}
+
+// The roles of a run when a queue is used
+const (
+	queueRoleCoordinator = "coordinator"
+	queueRoleWorker      = "worker"
+)
+
+var commitTrailerRegex = regexp.MustCompile(`^[A-Za-z0-9-]+: \S`)
+
+// addCommitTrailers appends trailers to a commit message, separated from the rest of the message by a blank line
+func addCommitTrailers(commitMessage string, trailers []string) (string, error) {
+	if len(trailers) == 0 {
+		return commitMessage, nil
+	}
+
+	for _, trailer := range trailers {
+		if !commitTrailerRegex.MatchString(trailer) {
+			return "", fmt.Errorf(`could not parse commit trailer "%s", it should be in the format "Key: value"`, trailer)
+		}
+	}
+
+	return strings.TrimRight(commitMessage, "\n") + "\n\n" + strings.Join(trailers, "\n"), nil
+}
+
+// getChangeRequest gets the change request that has to be approved in ServiceNow, or nil if none is required
+func getChangeRequest(flag *flag.FlagSet) (*multigitter.ChangeRequest, error) {
+	id, _ := flag.GetString("change-request")
+	serviceNowURL, _ := flag.GetString("servicenow-url")
+
+	if id == "" {
+		return nil, nil
+	}
+	if serviceNowURL == "" {
+		return nil, errors.New("--servicenow-url has to be set to verify the change request")
+	}
+
+	client := servicenow.Client{
+		BaseURL:  serviceNowURL,
+		Token:    os.Getenv("SERVICENOW_TOKEN"),
+		Username: os.Getenv("SERVICENOW_USERNAME"),
+		Password: os.Getenv("SERVICENOW_PASSWORD"),
+	}
+	if client.Token == "" && (client.Username == "" || client.Password == "") {
+		return nil, errors.New("either SERVICENOW_TOKEN, or SERVICENOW_USERNAME and SERVICENOW_PASSWORD, has to be set to verify the change request")
+	}
+
+	return &multigitter.ChangeRequest{
+		ID:       id,
+		Verifier: client,
+	}, nil
+}
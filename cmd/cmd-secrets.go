@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const secretsHelp = `
+This command creates or updates secrets and variables in multiple repositories. On GitHub, Actions secrets and variables are used. On GitLab, secrets are masked CI/CD variables and variables are unmasked CI/CD variables.
+
+The secrets and variables are read from a YAML file. Since the file contains secrets, it should be encrypted, and decrypted with --decrypt-command, which is called with the path of the file as the last argument and should output the decrypted content. For example "sops -d" or "age -d -i key.txt".
+
+Only the names of secrets and variables are ever shown or logged, never their values. Since the values of secrets can't be read, secrets that already exist are always updated. Example of a decrypted file:
+
+secrets:
+  NPM_TOKEN: my-token
+variables:
+  REGISTRY_URL: https://registry.example.com
+`
+
+// SecretsCmd creates or updates secrets and variables in repositories
+func SecretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "secrets",
+		Short:   "Create or update secrets and variables in repositories.",
+		Long:    secretsHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    secrets,
+	}
+
+	cmd.Flags().StringP("secrets-file", "", "", "The YAML file containing the secrets and variables.")
+	cmd.Flags().StringP("decrypt-command", "", "", "The command used to decrypt the secrets file. The path of the file is added as the last argument.")
+	cmd.Flags().StringP("audit-log", "", "", "A file that every change is appended to as a JSON line. The values are never logged.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show which secrets and variables would be created or updated.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func secrets(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	auditLogPath, _ := flag.GetString("audit-log")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	repoSecrets, err := getSecrets(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	var auditLog io.Writer
+	if auditLogPath != "" {
+		file, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return errors.Wrap(err, "could not open the audit log")
+		}
+		defer file.Close()
+		auditLog = file
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	syncer := &multigitter.SecretsSyncer{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output:   output,
+		AuditLog: auditLog,
+
+		Secrets:    repoSecrets,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return syncer.Sync(context.Background())
+}
+
+func getSecrets(flag *flag.FlagSet) (domain.Secrets, error) {
+	secretsFile, _ := flag.GetString("secrets-file")
+	decryptCommand, _ := flag.GetString("decrypt-command")
+	if secretsFile == "" {
+		return domain.Secrets{}, errors.New("no secrets file set")
+	}
+
+	var b []byte
+	if decryptCommand != "" {
+		executablePath, arguments, err := parseCommand(decryptCommand)
+		if err != nil {
+			return domain.Secrets{}, err
+		}
+
+		var stdout bytes.Buffer
+		cmd := exec.Command(executablePath, append(arguments, secretsFile)...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return domain.Secrets{}, errors.Wrap(err, "could not decrypt the secrets file")
+		}
+		b = stdout.Bytes()
+	} else {
+		var err error
+		b, err = ioutil.ReadFile(secretsFile)
+		if err != nil {
+			return domain.Secrets{}, errors.Wrap(err, "could not read the secrets file")
+		}
+	}
+
+	var repoSecrets domain.Secrets
+	if err := yaml.UnmarshalStrict(b, &repoSecrets); err != nil {
+		// The error might contain values from the file, and is therefore not included
+		return domain.Secrets{}, errors.New("could not parse the secrets file")
+	}
+
+	return repoSecrets, nil
+}
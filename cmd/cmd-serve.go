@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/lindell/multi-gitter/internal/apiserver"
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// ServeCmd serves an HTTP API other systems can use to orchestrate multi-gitter
+func ServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve an HTTP API to start runs and follow their progress.",
+		Long: `Serve an HTTP API that other systems can use to start runs, get their status and report, retry repositories and list pull requests.
+All requests have to be authenticated with the token set with --api-token, or the MULTI_GITTER_API_TOKEN environment variable, as a bearer token.
+
+POST /api/runs                  Start a run, with the arguments of the run command in the body: {"arguments": ["-m", "message", "script.sh"]}
+GET  /api/runs                  List all runs
+GET  /api/runs/{id}             Get the status of a run, and its report when it has finished
+GET  /api/runs/{id}/log         Get the log of a run
+POST /api/runs/{id}/retry       Run again in a single repository: {"repository": "owner/name"}
+GET  /api/pull-requests?branch= List the pull requests of a branch
+
+Runs are started with the config file and platform flags of this command, in addition to their own arguments.`,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    serve,
+	}
+
+	cmd.Flags().BoolP("api", "", false, "Serve the HTTP API.")
+	cmd.Flags().StringP("api-token", "", "", "The token requests to the API are authenticated with. Can also be set with the MULTI_GITTER_API_TOKEN environment variable.")
+	cmd.Flags().StringP("address", "", "localhost:8080", "The address the API is served on.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+
+	return cmd
+}
+
+func serve(cmd *cobra.Command, _ []string) error {
+	flag := cmd.Flags()
+
+	api, _ := flag.GetBool("api")
+	apiToken, _ := flag.GetString("api-token")
+	address, _ := flag.GetString("address")
+	configFile, _ := flag.GetString("config")
+
+	if !api {
+		return errors.New("no mode set, use --api to serve the HTTP API")
+	}
+
+	if apiToken == "" {
+		apiToken = os.Getenv("MULTI_GITTER_API_TOKEN")
+	}
+	if apiToken == "" {
+		return errors.New("either the --api-token flag or the MULTI_GITTER_API_TOKEN environment variable has to be set")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// The platform flags that are set are passed on to each run
+	baseArgs, env := platformArgs(flag)
+	if configFile != "" {
+		baseArgs = append(baseArgs, "--config", configFile)
+	}
+
+	// The client is created once, since creating it reads and changes the flags, which is not safe for concurrent requests.
+	// The API can start runs without platform flags, so an error is only returned when pull requests are listed
+	vc, vcErr := getVersionController(flag, true)
+
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-gitter-serve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	server, err := apiserver.New(apiserver.Config{
+		Token: apiToken,
+		Command: func(args ...string) *exec.Cmd {
+			cmd := exec.Command(executable, append(args, baseArgs...)...)
+			cmd.Env = append(os.Environ(), env...)
+			return cmd
+		},
+		PullRequests: func(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+			if vcErr != nil {
+				return nil, vcErr
+			}
+			return vc.GetPullRequests(ctx, branchName)
+		},
+		Dir: dir,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Infof("Serving the API on http://%s", address)
+	return httpServer.ListenAndServe()
+}
+
+// platformArgs returns the platform flags that are set as arguments. The token is instead returned as an environment variable,
+// to not be visible to other processes
+func platformArgs(flags *flag.FlagSet) (args []string, env []string) {
+	platformCmd := &cobra.Command{}
+	configurePlatform(platformCmd)
+
+	platformCmd.Flags().VisitAll(func(platformFlag *flag.Flag) {
+		f := flags.Lookup(platformFlag.Name)
+		if f == nil || !f.Changed {
+			return
+		}
+
+		if f.Name == "token" {
+			platform, _ := flags.GetString("platform")
+			if tokenEnv, ok := platformTokenEnvs[platform]; ok {
+				env = append(env, tokenEnv+"="+f.Value.String())
+				return
+			}
+		}
+
+		args = append(args, flagArgs(f)...)
+	})
+
+	return args, env
+}
+
+// flagArgs returns the arguments that set the flag to its current value
+func flagArgs(f *flag.Flag) []string {
+	if slice, ok := f.Value.(flag.SliceValue); ok {
+		args := make([]string, len(slice.GetSlice()))
+		for i, v := range slice.GetSlice() {
+			args[i] = "--" + f.Name + "=" + v
+		}
+		return args
+	}
+	return []string{"--" + f.Name + "=" + f.Value.String()}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const settingsHelp = `
+This command changes the settings of multiple repositories to match the settings in a YAML file. The difference between the current and the desired settings of each repository is shown, and then applied. Use --dry-run to only show the difference.
+
+Settings that are not set in the file are left unchanged. The branch protection is applied in the same way as with the branch-protection command. Example of a settings file:
+
+topics: [go, cli]
+allow_merge_commit: false
+allow_squash_merge: true
+allow_rebase_merge: true
+delete_branch_on_merge: true
+branch_protection:
+  branch: main # Defaults to the default branch
+  required_approving_reviews: 1
+  required_status_checks: [build, test]
+  enforce_admins: true
+webhooks:
+  - url: https://ci.example.com/hook
+    events: [push, pull_request]
+    content_type: json
+    secret: my-secret
+`
+
+// SettingsCmd changes the settings of repositories
+func SettingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "settings",
+		Short:   "Change the settings of repositories.",
+		Long:    settingsHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    settings,
+	}
+
+	cmd.Flags().StringP("settings-file", "", "", "The YAML file containing the desired settings of the repositories.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the difference between the current and the desired settings.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func settings(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	repoSettings, err := getRepositorySettings(flag)
+	if err != nil {
+		return err
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	updater := multigitter.SettingsUpdater{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Settings:   repoSettings,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return updater.Update(context.Background())
+}
+
+func getRepositorySettings(flag *flag.FlagSet) (domain.RepositorySettings, error) {
+	settingsFile, _ := flag.GetString("settings-file")
+	if settingsFile == "" {
+		return domain.RepositorySettings{}, errors.New("no settings file set")
+	}
+
+	b, err := ioutil.ReadFile(settingsFile)
+	if err != nil {
+		return domain.RepositorySettings{}, errors.Wrap(err, "could not read the settings file")
+	}
+
+	var repoSettings domain.RepositorySettings
+	if err := yaml.UnmarshalStrict(b, &repoSettings); err != nil {
+		return domain.RepositorySettings{}, errors.Wrap(err, "could not parse the settings file")
+	}
+
+	return repoSettings, nil
+}
@@ -20,6 +20,8 @@ func StatusCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringP("branch", "B", "multi-gitter-branch", "The name of the branch where changes are committed.")
+	cmd.Flags().StringP("campaign-id", "", "", "If set, pull requests are found by the campaign id in their footer instead of the branch name.")
+	cmd.Flags().AddFlagSet(fromReportFlag())
 	configurePlatform(cmd)
 	configureLogging(cmd, "-")
 	configureConfig(cmd)
@@ -32,9 +34,20 @@ func status(cmd *cobra.Command, args []string) error {
 	flag := cmd.Flags()
 
 	branchName, _ := flag.GetString("branch")
+	campaignID, _ := flag.GetString("campaign-id")
 	strOutput, _ := flag.GetString("output")
 
-	vc, err := getVersionController(flag, true)
+	report, err := getFromReport(flag)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, report == nil)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
 	if err != nil {
 		return err
 	}
@@ -46,10 +59,13 @@ func status(cmd *cobra.Command, args []string) error {
 
 	statuser := multigitter.Statuser{
 		VersionController: vc,
+		Filter:            filter,
 
 		Output: output,
 
 		FeatureBranch: branchName,
+		CampaignID:    campaignID,
+		Report:        report,
 	}
 
 	err = statuser.Statuses(context.Background())
@@ -57,5 +73,5 @@ func status(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return nil
+	return updateFromReport(flag, report)
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const topicsHelp = `
+This command adds topics to, and removes topics from, all targeted repositories. Other topics of the repositories are left unchanged. On GitLab, topics are the tags of the projects.
+
+The topics that are changed in each repository are shown, and then applied. Use --dry-run to only show the changes.
+`
+
+// TopicsCmd adds and removes topics of repositories
+func TopicsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "topics",
+		Short:   "Add and remove topics of repositories.",
+		Long:    topicsHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    topics,
+	}
+
+	cmd.Flags().StringSliceP("add", "", nil, "Topics that are added to the repositories.")
+	cmd.Flags().StringSliceP("remove", "", nil, "Topics that are removed from the repositories.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the topics that would be changed.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func topics(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	add, _ := flag.GetStringSlice("add")
+	remove, _ := flag.GetStringSlice("remove")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if len(add) == 0 && len(remove) == 0 {
+		return errors.New("no topics to add or remove set")
+	}
+	for _, a := range add {
+		for _, r := range remove {
+			if a == r {
+				return errors.Errorf("the topic %s can't be both added and removed", a)
+			}
+		}
+	}
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	editor := multigitter.TopicEditor{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Add:        add,
+		Remove:     remove,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return editor.Edit(context.Background())
+}
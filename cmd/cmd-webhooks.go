@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const webhooksHelp = `
+This command adds, updates and removes webhooks in multiple repositories. A webhook with the same url as the one set with --url is updated, otherwise it's added. The difference between the current and the desired webhooks of each repository is shown, followed by a summary.
+
+Since the secrets of existing webhooks can't be read, a webhook that only differs in its secret is not updated.
+
+Example of moving webhooks to a new endpoint:
+
+multi-gitter webhooks --url https://ci.example.com/new --event push --event pull_request --remove https://ci.example.com/old
+`
+
+// WebhooksCmd adds, updates and removes webhooks in repositories
+func WebhooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "webhooks",
+		Short:   "Add, update and remove webhooks in repositories.",
+		Long:    webhooksHelp,
+		Args:    cobra.NoArgs,
+		PreRunE: logFlagInit,
+		RunE:    webhooks,
+	}
+
+	cmd.Flags().StringP("url", "", "", "The url of the webhook that is added or updated.")
+	cmd.Flags().StringSliceP("event", "", []string{"push"}, "The events that trigger the webhook.")
+	cmd.Flags().StringP("content-type", "", "json", "The content type of the webhook.")
+	cmd.Flags().StringP("secret", "", "", "The secret of the webhook.")
+	cmd.Flags().StringArrayP("remove", "", nil, "The url of a webhook that is removed. Can be used multiple times.")
+	cmd.Flags().BoolP("dry-run", "d", false, "Only show the difference between the current and the desired webhooks.")
+	cmd.Flags().IntP("concurrent", "C", 1, "The maximum number of repositories that are handled concurrently.")
+	configurePlatform(cmd)
+	configureLogging(cmd, "-")
+	configureConfig(cmd)
+	cmd.Flags().AddFlagSet(outputFlag())
+
+	return cmd
+}
+
+func webhooks(cmd *cobra.Command, args []string) error {
+	flag := cmd.Flags()
+
+	url, _ := flag.GetString("url")
+	events, _ := flag.GetStringSlice("event")
+	contentType, _ := flag.GetString("content-type")
+	secret, _ := flag.GetString("secret")
+	remove, _ := flag.GetStringArray("remove")
+	dryRun, _ := flag.GetBool("dry-run")
+	concurrent, _ := flag.GetInt("concurrent")
+	strOutput, _ := flag.GetString("output")
+
+	if concurrent < 1 {
+		return errors.New("concurrent runs can't be less than one")
+	}
+	if url == "" && len(remove) == 0 {
+		return errors.New("--url or --remove has to be set")
+	}
+	for _, r := range remove {
+		if r == url {
+			return errors.Errorf("the webhook %s can't be both updated and removed", url)
+		}
+	}
+
+	var hooks []domain.Webhook
+	if url != "" {
+		hooks = append(hooks, domain.Webhook{
+			URL:         url,
+			Events:      events,
+			ContentType: contentType,
+			Secret:      secret,
+		})
+	}
+
+	output, err := fileOutput(strOutput, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	vc, err := getVersionController(flag, true)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getRepositoryFilter(flag)
+	if err != nil {
+		return err
+	}
+
+	updater := multigitter.WebhookUpdater{
+		VersionController: vc,
+		Filter:            filter,
+
+		Output: output,
+
+		Webhooks:   hooks,
+		RemoveURLs: remove,
+		DryRun:     dryRun,
+		Concurrent: concurrent,
+	}
+
+	return updater.Update(context.Background())
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -107,3 +110,34 @@ func parseCommandLine(command string) ([]string, error) {
 
 	return args, nil
 }
+
+// scriptHash creates a hash of the executable, its arguments, and the content of any argument that is a file
+func scriptHash(executablePath string, arguments []string) (string, error) {
+	h := sha256.New()
+
+	for _, path := range append([]string{executablePath}, arguments...) {
+		_, _ = h.Write([]byte(path))
+
+		if stat, err := os.Stat(path); err != nil || stat.IsDir() {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not read %s", path)
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", errors.Wrapf(err, "could not read %s", path)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// patchHash returns a hash of the changes applied instead of running a script
+func patchHash(patch string) string {
+	h := sha256.Sum256([]byte(patch))
+	return hex.EncodeToString(h[:])
+}
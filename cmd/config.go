@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -14,22 +15,34 @@ func configureConfig(cmd *cobra.Command) {
 
 func initializeConfig(cmd *cobra.Command) error {
 	// Prioritize reading config files defined with --config
-	if err := initializeDynamicConfig(cmd); err != nil {
+	dynamicConfig, err := initializeDynamicConfig(cmd)
+	if err != nil {
 		return err
 	}
 
 	// Read any config defined in static config files
-	if err := initializeStaticConfig(cmd); err != nil {
+	staticConfig, err := initializeStaticConfig(cmd)
+	if err != nil {
 		return err
 	}
 
+	// Platform profiles are applied last, since the platform itself might be defined in any of the configs.
+	// When multiple platforms are used, each profile is instead applied when the client of that platform is created
+	platformConfigs = []*viper.Viper{dynamicConfig, staticConfig}
+	if platform, err := cmd.Flags().GetString("platform"); err == nil && !strings.Contains(platform, ",") {
+		bindPlatformProfile(cmd.Flags(), platform, platformConfigs...)
+	}
+
 	return nil
 }
 
-func initializeDynamicConfig(cmd *cobra.Command) error {
+// platformConfigs are the configs read for the current command
+var platformConfigs []*viper.Viper
+
+func initializeDynamicConfig(cmd *cobra.Command) (*viper.Viper, error) {
 	configFile, _ := cmd.Flags().GetString("config")
 	if configFile == "" {
-		return nil
+		return nil, nil
 	}
 
 	v := viper.New()
@@ -38,15 +51,15 @@ func initializeDynamicConfig(cmd *cobra.Command) error {
 	v.SetConfigType("yaml")
 
 	if err := v.ReadInConfig(); err != nil {
-		return err
+		return nil, err
 	}
 
 	bindFlags(cmd, v)
 
-	return nil
+	return v, nil
 }
 
-func initializeStaticConfig(cmd *cobra.Command) error {
+func initializeStaticConfig(cmd *cobra.Command) (*viper.Viper, error) {
 	v := viper.New()
 
 	v.SetConfigType("yaml")
@@ -59,13 +72,13 @@ func initializeStaticConfig(cmd *cobra.Command) error {
 	if err := v.ReadInConfig(); err != nil {
 		// It's okay if there isn't a config file
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return err
+			return nil, err
 		}
 	}
 
 	bindFlags(cmd, v)
 
-	return nil
+	return v, nil
 }
 
 func bindFlags(cmd *cobra.Command, v *viper.Viper) {
@@ -85,3 +98,43 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 		}
 	})
 }
+
+// bindPlatformProfile applies the defaults defined for the platform under the "platforms" key.
+// Values in a profile are used if the flag is not set in any other way, except for list values
+// which are merged with the ones already set. Profiles in earlier configs take precedence.
+func bindPlatformProfile(flags *pflag.FlagSet, platform string, configs ...*viper.Viper) {
+	merged := map[string]bool{}
+	for _, v := range configs {
+		if v == nil {
+			continue
+		}
+
+		profile := v.Sub("platforms." + platform)
+		if profile == nil {
+			continue
+		}
+
+		flags.VisitAll(func(f *pflag.Flag) {
+			if !profile.IsSet(f.Name) {
+				return
+			}
+
+			switch val := profile.Get(f.Name).(type) {
+			case []interface{}:
+				if merged[f.Name] {
+					return
+				}
+				merged[f.Name] = true
+
+				for _, v := range val {
+					_ = flags.Set(f.Name, fmt.Sprintf("%v", v))
+				}
+			default:
+				if f.Changed {
+					return
+				}
+				_ = flags.Set(f.Name, fmt.Sprintf("%v", val))
+			}
+		})
+	}
+}
@@ -62,10 +62,11 @@ func logFlagInit(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	log.SetFormatter(&internallog.CensorFormatter{
+	censorFormatter = &internallog.CensorFormatter{
 		CensorItems:         censorItems,
 		UnderlyingFormatter: formatter,
-	})
+	}
+	log.SetFormatter(censorFormatter)
 
 	// Set the output (file)
 	strFile, _ := cmd.Flags().GetString("log-file")
@@ -81,3 +82,26 @@ func logFlagInit(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// censorFormatter is the formatter of the logs, that censors sensitive data before they are printed
+var censorFormatter *internallog.CensorFormatter
+
+// censor removes sensitive data, such as tokens, from a string in the same way as it is removed from the logs
+func censor(s string) string {
+	if censorFormatter == nil {
+		return s
+	}
+	return censorFormatter.Censor(s)
+}
+
+// censorToken censors a token in all following logs. Used for tokens that are created during the run,
+// such as the installation tokens of GitHub Apps
+func censorToken(token string) {
+	if censorFormatter == nil {
+		return
+	}
+	censorFormatter.AddCensorItem(internallog.CensorItem{
+		Sensitive:   token,
+		Replacement: "<TOKEN>",
+	})
+}
@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 
 	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/internal/secretref"
 )
 
 func outputFlag() *flag.FlagSet {
@@ -18,13 +24,152 @@ func outputFlag() *flag.FlagSet {
 	return flags
 }
 
+func summaryFlag() *flag.FlagSet {
+	flags := flag.NewFlagSet("summary", flag.ExitOnError)
+
+	flags.BoolP("no-summary", "", false, "Don't print the table with the outcome of each repository, that is printed to stderr when the command is done.")
+
+	return flags
+}
+
+// summaryOutput returns where the summary table should be written, or nil if it should not be written
+func summaryOutput(cmd *cobra.Command) io.Writer {
+	if noSummary, _ := cmd.Flags().GetBool("no-summary"); noSummary {
+		return nil
+	}
+	return cmd.ErrOrStderr()
+}
+
+func fromReportFlag() *flag.FlagSet {
+	flags := flag.NewFlagSet("from-report", flag.ExitOnError)
+
+	flags.StringP("from-report", "", "", "Use the pull requests in a report, written by the run command with --report, instead of searching for them. No organization, user or repository has to be set.")
+
+	return flags
+}
+
+// getFromReport reads the report defined by the from-report flag, if set
+func getFromReport(flag *flag.FlagSet) (*multigitter.Report, error) {
+	path, _ := flag.GetString("from-report")
+	if path == "" {
+		return nil, nil
+	}
+
+	report, err := readReport(path)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// updateFromReport writes the report defined by the from-report flag back, if repositories in it have been
+// renamed or transferred, to not have to follow them again
+func updateFromReport(flag *flag.FlagSet, report *multigitter.Report) error {
+	if report == nil || !report.Renamed() {
+		return nil
+	}
+
+	path, _ := flag.GetString("from-report")
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "could not update report %s", path)
+	}
+	defer file.Close()
+
+	if err := multigitter.WriteReport(file, *report); err != nil {
+		return errors.Wrapf(err, "could not update report %s", path)
+	}
+	return nil
+}
+
+func dependsOnFlag() *flag.FlagSet {
+	flags := flag.NewFlagSet("depends-on", flag.ExitOnError)
+
+	flags.StringSliceP("depends-on", "", nil, `Declare that the changes in a repository depend on the changes in another repository, in the format "owner/name=owner/dependency". The pull request of the repository is only created, and merged, after the pull request of the dependency has been merged.`)
+
+	return flags
+}
+
+// getDependencies parses the dependencies defined by the depends-on flag
+func getDependencies(flag *flag.FlagSet) (multigitter.Dependencies, error) {
+	strDependencies, _ := flag.GetStringSlice("depends-on")
+
+	dependencies := multigitter.Dependencies{}
+	for _, str := range strDependencies {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[0] == "" || split[1] == "" {
+			return nil, errors.Errorf(`could not parse the dependency "%s", it should be in the format "owner/name=owner/dependency"`, str)
+		}
+		dependencies[split[0]] = append(dependencies[split[0]], split[1])
+	}
+	return dependencies, nil
+}
+
+// getToken gets the token of the platform. A reference to a secret, such as vault://secret/multi-gitter#token,
+// is replaced with the secret fetched from the secret manager
 func getToken(flag *flag.FlagSet) (string, error) {
+	token, err := lookupToken(flag)
+	if err != nil || !secretref.IsReference(token) {
+		return token, err
+	}
+
+	secret, err := secretResolver.Resolve(context.Background(), token)
+	if err != nil {
+		return "", errors.WithMessage(err, "could not get the token")
+	}
+	return secret, nil
+}
+
+// secretResolver fetches the tokens stored in secret managers. It is shared by all clients, so that secrets are only
+// fetched again when they expire
+var secretResolver = &secretref.Resolver{}
+
+func lookupToken(flag *flag.FlagSet) (string, error) {
 	if OverrideVersionController != nil {
 		return "", nil
 	}
 
+	// CodeCommit uses AWS credentials, and plain git remotes and local repositories the credentials of git, instead of a token
+	if platform, _ := flag.GetString("platform"); platform == "codecommit" || platform == "git" || platform == "local" {
+		return "", nil
+	}
+
+	// The job token is set by GitLab CI
+	if platform, _ := flag.GetString("platform"); platform == "gitlab" {
+		if ciJobToken, _ := flag.GetBool("ci-job-token"); ciJobToken {
+			token := os.Getenv("CI_JOB_TOKEN")
+			if token == "" {
+				return "", errors.New("the CI_JOB_TOKEN environment variable has to be set when --ci-job-token is used")
+			}
+			return token, nil
+		}
+	}
+
+	// A GitHub App creates its own installation tokens
+	if platform, _ := flag.GetString("platform"); platform == "github" {
+		if appID, _ := flag.GetInt64("github-app-id"); appID != 0 {
+			return "", nil
+		}
+	}
+
 	token, _ := flag.GetString("token")
 
+	// Plugins are given the token if one is set, but might not need one
+	if platform, _ := flag.GetString("platform"); platform == "plugin" {
+		return token, nil
+	}
+
+	// When multiple platforms are used, each platform gets its own token when its client is created
+	if platform, _ := flag.GetString("platform"); strings.Contains(platform, ",") {
+		return token, nil
+	}
+
+	// Prefer the environment variable of the used platform, which makes it possible to use multiple platforms at once
+	if token == "" {
+		platform, _ := flag.GetString("platform")
+		token = os.Getenv(platformTokenEnvs[platform])
+	}
+
 	if token == "" {
 		if ght := os.Getenv("GITHUB_TOKEN"); ght != "" {
 			token = ght
@@ -32,16 +177,61 @@ func getToken(flag *flag.FlagSet) (string, error) {
 			token = ght
 		} else if ght := os.Getenv("GITEA_TOKEN"); ght != "" {
 			token = ght
+		} else if ght := os.Getenv("BITBUCKET_TOKEN"); ght != "" {
+			token = ght
+		} else if ght := os.Getenv("AZURE_DEVOPS_TOKEN"); ght != "" {
+			token = ght
+		} else if ght := os.Getenv("GERRIT_TOKEN"); ght != "" {
+			token = ght
+		} else if ght := os.Getenv("SOURCEHUT_TOKEN"); ght != "" {
+			token = ght
+		} else if ght := os.Getenv("GOGS_TOKEN"); ght != "" {
+			token = ght
+		}
+	}
+
+	// Tokens stored with the auth command are only used when no other token is set
+	if token == "" {
+		var err error
+		token, err = keyringToken(flag)
+		if err != nil {
+			log.Debugf("Could not get the token from the keychain: %s", err)
 		}
 	}
 
 	if token == "" {
-		return "", errors.New("either the --token flag or the GITHUB_TOKEN environment variable has to be set")
+		return "", errors.New("either the --token flag or the GITHUB_TOKEN environment variable has to be set, or a token has to be stored with the auth command")
 	}
 
 	return token, nil
 }
 
+// platformTokenEnvs are the environment variables that can contain the token of each platform
+var platformTokenEnvs = map[string]string{
+	"github":           "GITHUB_TOKEN",
+	"gitlab":           "GITLAB_TOKEN",
+	"gitea":            "GITEA_TOKEN",
+	"forgejo":          "FORGEJO_TOKEN",
+	"bitbucket":        "BITBUCKET_TOKEN",
+	"bitbucket-server": "BITBUCKET_TOKEN",
+	"azuredevops":      "AZURE_DEVOPS_TOKEN",
+	"gerrit":           "GERRIT_TOKEN",
+	"sourcehut":        "SOURCEHUT_TOKEN",
+	"gogs":             "GOGS_TOKEN",
+}
+
+// expandHome replaces a leading "~" in a path with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
 func getMergeTypes(flag *flag.FlagSet) ([]domain.MergeType, error) {
 	mergeTypeStrs, _ := flag.GetStringSlice("merge-type") // Only used for the merge command
 
@@ -58,6 +248,13 @@ func getMergeTypes(flag *flag.FlagSet) ([]domain.MergeType, error) {
 	return mergeTypes, nil
 }
 
+// getPick returns if the repositories should be picked interactively, with --pick or its alias --interactive-select
+func getPick(flag *flag.FlagSet) bool {
+	pick, _ := flag.GetBool("pick")
+	interactiveSelect, _ := flag.GetBool("interactive-select")
+	return pick || interactiveSelect
+}
+
 // nopWriter is a writer that does nothing
 type nopWriter struct{}
 
@@ -3,12 +3,35 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/lindell/multi-gitter/internal/aws"
 	"github.com/lindell/multi-gitter/internal/http"
 	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/internal/scm/azuredevops"
+	"github.com/lindell/multi-gitter/internal/scm/bitbucket"
+	"github.com/lindell/multi-gitter/internal/scm/bitbucketserver"
+	"github.com/lindell/multi-gitter/internal/scm/codecommit"
+	"github.com/lindell/multi-gitter/internal/scm/composite"
+	"github.com/lindell/multi-gitter/internal/scm/gerrit"
 	"github.com/lindell/multi-gitter/internal/scm/gitea"
 	"github.com/lindell/multi-gitter/internal/scm/github"
 	"github.com/lindell/multi-gitter/internal/scm/gitlab"
+	"github.com/lindell/multi-gitter/internal/scm/gogs"
+	"github.com/lindell/multi-gitter/internal/scm/local"
+	"github.com/lindell/multi-gitter/internal/scm/plugin"
+	"github.com/lindell/multi-gitter/internal/scm/rawgit"
+	"github.com/lindell/multi-gitter/internal/scm/sourcehut"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
@@ -18,18 +41,60 @@ func configurePlatform(cmd *cobra.Command) {
 	flags := cmd.Flags()
 
 	flags.StringP("base-url", "g", "", "Base URL of the (v3) GitHub API, needs to be changed if GitHub enterprise is used. Or the url to a self-hosted GitLab instance.")
-	flags.StringP("token", "T", "", "The GitHub/GitLab personal access token. Can also be set using the GITHUB_TOKEN/GITLAB_TOKEN environment variable.")
+	flags.StringP("token", "T", "", "The GitHub/GitLab personal access token. Can also be set using the GITHUB_TOKEN/GITLAB_TOKEN environment variable. Can be a reference to a secret in HashiCorp Vault, AWS Secrets Manager or GCP Secret Manager, such as vault://secret/multi-gitter#token.")
 
-	flags.StringSliceP("org", "O", nil, "The name of a GitHub organization. All repositories in that organization will be used.")
-	flags.StringSliceP("group", "G", nil, "The name of a GitLab organization. All repositories in that group will be used.")
+	flags.StringSliceP("org", "O", nil, "The name of a GitHub or Azure DevOps organization. All repositories in that organization will be used.")
+	flags.StringSliceP("group", "G", nil, `The name of a GitLab organization. All repositories in that group will be used. Nested subgroups can be targeted with a path pattern, where "*" matches a single group and "**" any number of groups, for example "platform/**/services". Or a prefix of Gerrit project names, all projects starting with it will be used.`)
 	flags.StringSliceP("user", "U", nil, "The name of a user. All repositories owned by that user will be used.")
-	flags.StringSliceP("repo", "R", nil, "The name, including owner of a GitHub repository in the format \"ownerName/repoName\".")
-	flags.StringSliceP("project", "P", nil, "The name, including owner of a GitLab project in the format \"ownerName/repoName\".")
+	flags.StringSliceP("team", "", nil, `The name of a Gitea organization team in the format "orgName/teamName". All repositories the team has access to will be used.`)
+	flags.StringSliceP("repo", "R", nil, "The name, including owner of a GitHub repository in the format \"ownerName/repoName\". Or an Azure DevOps repository in the format \"organization/project/repoName\". Or a sourcehut repository in the format \"~ownerName/repoName\". Or the clone url of a repository when the git platform is used. Use \"-\" to read the repositories from stdin, one on each line.")
+	flags.StringArrayP("code-search", "", nil, `A GitHub code search query, for example "org:my-org filename:Dockerfile node:14". All repositories with code that matches the query will be used. Can be used multiple times.`)
+	flags.StringSliceP("path", "", nil, "The path of an already cloned repository, used with the local platform. Glob patterns, like \"~/src/*\", are supported.")
+	flags.BoolP("push-origin", "", false, "Push the branches created in already cloned repositories to their origin, when the local platform is used.")
+	flags.StringP("repo-file", "", "", `A file with a repository on each line, in the same format as --repo, or --project on GitLab and Gerrit, used together with them. Use "-" to read the repositories from stdin, which can also be done with "--repo -". Empty lines and lines starting with # are ignored.`)
+	flags.StringSliceP("project", "P", nil, "The name, including owner of a GitLab project in the format \"ownerName/repoName\". Or an Azure DevOps project in the format \"organization/project\", all repositories in that project will be used. Or the full name of a Gerrit project.")
+	flags.StringSliceP("language", "", nil, "Only use repositories with this primary language, for example \"Go\". Can be used multiple times. Supported on GitHub, GitLab and Gitea.")
+	flags.StringArrayP("repo-include", "", nil, `Only use repositories with a name, in the format "owner/name", that matches this regular expression. Can be used multiple times.`)
+	flags.StringArrayP("repo-exclude", "", nil, `Don't use repositories with a name, in the format "owner/name", that matches this regular expression, for example "^my-org/legacy-". Can be used multiple times.`)
+	flags.StringP("exclude-from", "", "", `A file with glob patterns, one on each line, of the names of repositories that are not used, in the format "owner/name", for example "my-org/legacy-*". Empty lines and lines starting with # are ignored.`)
+	flags.StringP("default-branch-is", "", "", `Only use repositories with a default branch with this name, or that matches this regular expression, for example "master". The whole name has to match.`)
+	flags.StringArrayP("require-file", "", nil, `Only use repositories where this file exists on the default branch. It is checked through the platform before anything is cloned (GitHub, GitLab and Gitea). Can be used multiple times.`)
+	flags.StringArrayP("require-file-content", "", nil, `Only use repositories where this file exists, with content that matches a regular expression, in the format "path=regex", for example "Dockerfile=FROM node:14". Checked in the same way as --require-file. Can be used multiple times.`)
+	flags.StringSliceP("owned-by", "", nil, `Only use repositories with a CODEOWNERS file that contains this owner, for example "@my-org/platform-team". Checked in the same way as --require-file. Can be used multiple times.`)
+	flags.StringP("template", "", "", `Only use repositories generated from this template repository, in the format "owner/name" (GitHub).`)
+	flags.StringArrayP("property", "", nil, `Only use repositories with this custom property, in the format "name=value", for example "tier=production" (GitHub). Can be used multiple times, repositories have to have all properties.`)
+	flags.BoolP("include-archived", "", false, "Include archived repositories. They are read-only, so the run command reports them as skipped. Supported on GitHub, GitLab and Gitea.")
+	flags.BoolP("skip-forks", "", false, "Skip repositories that are forks. Supported on GitHub, GitLab and Gitea.")
+	flags.BoolP("only-forks", "", false, "Only use repositories that are forks, for example to update the forks of an organization. Supported on GitHub, GitLab and Gitea.")
+	flags.StringP("pushed-after", "", "", `Only use repositories that have been pushed to after this date, in the format "2006-01-02" or RFC 3339. Supported on GitHub, and on GitLab and Gitea, where the last activity is used.`)
+	flags.StringP("pushed-before", "", "", `Only use repositories that have not been pushed to since this date, for example to find dormant repositories. In the same format as --pushed-after.`)
+	flags.StringP("max-repo-size", "", "", `Skip repositories larger than this size, as reported by the platform, before they are cloned. A number of bytes, or with the unit "KB", "MB" or "GB", for example "500MB". Supported on GitHub, GitLab and Gitea.`)
+	flags.StringSliceP("visibility", "", nil, `Only use repositories with this visibility. Can be "public", "private" or "internal". Can be used multiple times. Supported on GitHub, GitLab and Gitea.`)
+	flags.BoolP("skip-disabled", "", true, "Skip disabled repositories, on GitHub, and GitLab projects with the repository feature disabled. Set to false to include them.")
 	flags.BoolP("include-subgroups", "", false, "Include GitLab subgroups when using the --group flag.")
+	flags.StringSliceP("exclude-group", "", nil, `Skip the projects in this GitLab group, and all of its subgroups, when using the --group flag, for example "my-group/experiments". Can be a pattern in the same format as --group.`)
+	flags.BoolP("ci-job-token", "", false, "Authenticate with the CI_JOB_TOKEN of the GitLab CI job multi-gitter runs in, instead of a token. Only projects set with --project can be used, and the base url defaults to the GitLab instance of the job.")
+	flags.StringSliceP("workspace", "", nil, "The name of a Bitbucket Cloud workspace. All repositories in that workspace will be used.")
+	flags.StringSliceP("project-key", "", nil, "The key of a Bitbucket Server project. All repositories in that project will be used.")
+	flags.StringP("region", "", "", "The AWS region of the CodeCommit repositories. Defaults to the region of the AWS profile.")
+	flags.StringP("username", "", "", "The username the token belongs to, if the token is a Bitbucket Cloud app password or a personal Bitbucket Server token. If not set, the token is used as an access token. Required on Gerrit, where the token is the HTTP password of the user.")
 
-	flags.StringP("platform", "p", "github", "The platform that is used. Available values: github, gitlab, gitea.")
+	flags.StringP("as-user", "", "", "Make changes as another user, such as a service account, with the token of an administrator. Uses an impersonation token on GitHub Enterprise Server, which is also used to push. Uses sudo on GitLab and Gitea, where only the API requests, such as creating pull requests, are made as the user, and the commits are still pushed with the token of the administrator.")
+	flags.Int64P("github-app-id", "", 0, "The ID of a GitHub App to authenticate as, instead of using a token. An installation token is created for each organization or user the app is installed on. Requires --github-app-private-key.")
+	flags.StringP("github-app-private-key", "", "", "The path of the PEM encoded private key of the GitHub App set with --github-app-id.")
+
+	flags.StringP("plugin-path", "", "", "The path of the program that implements the platform, used with the plugin platform.")
+	flags.StringSliceP("plugin-arg", "", nil, "An argument given to the plugin program when it is started.")
+
+	flags.StringP("record-http", "", "", "Record all API interactions with the platform into this directory. Credentials are removed from the recording. Can be used to reproduce bugs with --replay-http.")
+	flags.StringP("replay-http", "", "", "Respond to all API requests with interactions previously recorded with --record-http into this directory, instead of contacting the platform.")
+
+	flags.BoolP("cache-repos", "", false, "Cache the listing of repositories on disk, and reuse it in later commands until it is older than --cache-ttl. Only GitHub, GitLab, Gitea, Bitbucket, Azure DevOps, Gerrit and Gogs listings are cached.")
+	flags.DurationP("cache-ttl", "", time.Hour, "How long cached listings of repositories are used, when --cache-repos is used.")
+
+	flags.StringP("platform", "p", "github", "The platform that is used. Available values: github, gitlab, gitea, forgejo, bitbucket, bitbucket-server, azuredevops, codecommit, gerrit, sourcehut, gogs, git, local, plugin. Multiple platforms can be used at once by separating them with a comma.")
 	_ = cmd.RegisterFlagCompletionFunc("platform", func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-		return []string{"github", "gitlab", "gitea"}, cobra.ShellCompDirectiveDefault
+		return []string{"github", "gitlab", "gitea", "forgejo", "bitbucket", "bitbucket-server", "azuredevops", "codecommit", "gerrit", "sourcehut", "gogs", "git", "local", "plugin"}, cobra.ShellCompDirectiveDefault
 	})
 
 	// Autocompletion for organizations
@@ -116,7 +181,21 @@ func getVersionController(flag *flag.FlagSet, verifyFlags bool) (multigitter.Ver
 		return OverrideVersionController, nil
 	}
 
+	if verifyFlags {
+		if err := expandRepositoryLists(flag); err != nil {
+			return nil, err
+		}
+	}
+
 	platform, _ := flag.GetString("platform")
+	if platforms := strings.Split(platform, ","); len(platforms) > 1 {
+		return createCompositeClient(flag, platforms, verifyFlags)
+	}
+
+	if err := verifyPlatformFlags(flag, platform); err != nil {
+		return nil, err
+	}
+
 	switch platform {
 	default:
 		return nil, fmt.Errorf("unknown platform: %s", platform)
@@ -124,8 +203,209 @@ func getVersionController(flag *flag.FlagSet, verifyFlags bool) (multigitter.Ver
 		return createGithubClient(flag, verifyFlags)
 	case "gitlab":
 		return createGitlabClient(flag, verifyFlags)
-	case "gitea":
+	case "gitea", "forgejo":
 		return createGiteaClient(flag, verifyFlags)
+	case "bitbucket":
+		return createBitbucketClient(flag, verifyFlags)
+	case "bitbucket-server":
+		return createBitbucketServerClient(flag, verifyFlags)
+	case "azuredevops":
+		return createAzureDevOpsClient(flag, verifyFlags)
+	case "codecommit":
+		return createCodeCommitClient(flag, verifyFlags)
+	case "gerrit":
+		return createGerritClient(flag, verifyFlags)
+	case "sourcehut":
+		return createSourcehutClient(flag, verifyFlags)
+	case "gogs":
+		return createGogsClient(flag, verifyFlags)
+	case "git":
+		return createRawGitClient(flag, verifyFlags)
+	case "local":
+		return createLocalClient(flag, verifyFlags)
+	case "plugin":
+		return createPluginClient(flag, verifyFlags)
+	}
+}
+
+// createCompositeClient creates a client for each of the platforms, with the profile of that platform applied, and combines them
+func createCompositeClient(flag *flag.FlagSet, platforms []string, verifyFlags bool) (multigitter.VersionController, error) {
+	compositePlatforms := make([]composite.Platform, 0, len(platforms))
+	for _, platform := range platforms {
+		if strings.TrimSpace(platform) == "gerrit" {
+			// Changes are pushed for review, instead of pull requests being created, which can't be combined with other platforms
+			return nil, errors.New("gerrit can't be combined with other platforms")
+		}
+	}
+
+	for _, platform := range platforms {
+		platform = strings.TrimSpace(platform)
+
+		restore := snapshotFlags(flag)
+		_ = flag.Set("platform", platform)
+		bindPlatformProfile(flag, platform, platformConfigs...)
+		vc, err := getVersionController(flag, verifyFlags)
+		token, _ := getToken(flag)
+		restore()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "could not create the %s client", platform)
+		}
+
+		compositePlatforms = append(compositePlatforms, composite.Platform{
+			Name:              platform,
+			VersionController: vc,
+			Token:             token,
+		})
+	}
+
+	return composite.New(compositePlatforms), nil
+}
+
+// expandRepositoryLists adds the repositories in the --repo-file to --repo, or --project on GitLab and Gerrit,
+// and replaces "-" in them with the repositories on stdin.
+// This is done before any client is created, to only read stdin once even if several platforms are used
+func expandRepositoryLists(flags *flag.FlagSet) error {
+	listFlag := repositoryListFlag(flags)
+
+	for _, name := range []string{"repo", "project"} {
+		values, _ := flags.GetStringSlice(name)
+
+		expanded := make([]string, 0, len(values))
+		changed := false
+		for _, value := range values {
+			if value != "-" {
+				expanded = append(expanded, value)
+				continue
+			}
+			stdinRepos, err := readRepositoryList(os.Stdin)
+			if err != nil {
+				return errors.Wrap(err, "could not read the repositories from stdin")
+			}
+			expanded = append(expanded, stdinRepos...)
+			changed = true
+		}
+
+		if repoFile, _ := flags.GetString("repo-file"); repoFile != "" && name == listFlag {
+			fileRepos, err := readRepositoryFile(repoFile)
+			if err != nil {
+				return err
+			}
+			expanded = append(expanded, fileRepos...)
+			changed = true
+			_ = flags.Set("repo-file", "")
+		}
+
+		if changed {
+			if err := flags.Lookup(name).Value.(flag.SliceValue).Replace(expanded); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// repositoryListFlag returns the flag that lists single repositories, in the format "owner/name", on the platform
+func repositoryListFlag(flags *flag.FlagSet) string {
+	if platform, _ := flags.GetString("platform"); platform == "gitlab" || platform == "gerrit" {
+		return "project"
+	}
+	return "repo"
+}
+
+// selectReportRepositories selects the repositories of a report, if no other repositories have been selected
+func selectReportRepositories(flags *flag.FlagSet, names []string) error {
+	for _, name := range []string{"org", "group", "user", "repo", "project"} {
+		if values, _ := flags.GetStringSlice(name); len(values) > 0 {
+			return nil
+		}
+	}
+	return flags.Lookup(repositoryListFlag(flags)).Value.(flag.SliceValue).Replace(names)
+}
+
+// readRepositoryFile reads the repositories in a file, or on stdin if the path is "-"
+func readRepositoryFile(path string) ([]string, error) {
+	r := os.Stdin
+	if path != "-" {
+		f, err := os.Open(expandHome(path))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read the repo file")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	repos, err := readRepositoryList(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the repo file")
+	}
+	return repos, nil
+}
+
+// readExcludeFile reads the glob patterns of the repositories that should not be used
+func readExcludeFile(excludeFile string) ([]string, error) {
+	f, err := os.Open(expandHome(excludeFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the exclude file")
+	}
+	defer f.Close()
+
+	globs, err := readRepositoryList(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the exclude file")
+	}
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, errors.Errorf(`could not parse the pattern "%s" in the exclude file: %s`, glob, err)
+		}
+	}
+	return globs, nil
+}
+
+// readRepositoryList reads a repository from each line. Empty lines and lines starting with # are ignored
+func readRepositoryList(r io.Reader) ([]string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		repos = append(repos, line)
+	}
+	return repos, nil
+}
+
+// snapshotFlags saves the current values of all flags, and returns a function that restores them
+func snapshotFlags(flags *flag.FlagSet) func() {
+	type savedFlag struct {
+		flag    *flag.Flag
+		value   string
+		slice   []string
+		changed bool
+	}
+
+	var saved []savedFlag
+	flags.VisitAll(func(f *flag.Flag) {
+		s := savedFlag{flag: f, value: f.Value.String(), changed: f.Changed}
+		if slice, ok := f.Value.(flag.SliceValue); ok {
+			s.slice = slice.GetSlice()
+		}
+		saved = append(saved, s)
+	})
+
+	return func() {
+		for _, s := range saved {
+			if slice, ok := s.flag.Value.(flag.SliceValue); ok {
+				_ = slice.Replace(s.slice)
+			} else {
+				_ = s.flag.Value.Set(s.value)
+			}
+			s.flag.Changed = s.changed
+		}
 	}
 }
 
@@ -134,13 +414,40 @@ func createGithubClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versi
 	orgs, _ := flag.GetStringSlice("org")
 	users, _ := flag.GetStringSlice("user")
 	repos, _ := flag.GetStringSlice("repo")
+	codeSearches, _ := flag.GetStringArray("code-search")
+	languages, _ := flag.GetStringSlice("language")
+	includeArchived, _ := flag.GetBool("include-archived")
+	skipDisabled, _ := flag.GetBool("skip-disabled")
+	template, _ := flag.GetString("template")
+	strProperties, _ := flag.GetStringArray("property")
 	forkMode, _ := flag.GetBool("fork")
+	asUser, _ := flag.GetString("as-user")
 
-	if verifyFlags && len(orgs) == 0 && len(users) == 0 && len(repos) == 0 {
-		return nil, errors.New("no organization, user or repo set")
+	if verifyFlags && len(orgs) == 0 && len(users) == 0 && len(repos) == 0 && len(codeSearches) == 0 {
+		return nil, errors.New("no organization, user, repo or code search set")
 	}
 
-	token, err := getToken(flag)
+	skipForks, onlyForks, err := getForkFilter(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	pushedAfter, pushedBefore, err := getPushedFilter(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, err := getMaxSize(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilities, err := getVisibilities(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := parseProperties(strProperties)
 	if err != nil {
 		return nil, err
 	}
@@ -158,11 +465,71 @@ func createGithubClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versi
 		return nil, err
 	}
 
-	vc, err := github.New(token, gitBaseURL, http.NewLoggingRoundTripper, github.RepositoryListing{
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	repoListing := github.RepositoryListing{
 		Organizations: orgs,
 		Users:         users,
 		Repositories:  repoRefs,
-	}, mergeTypes, forkMode)
+		CodeSearches:  codeSearches,
+		Languages:     languages,
+
+		IncludeArchived: includeArchived,
+		IncludeDisabled: !skipDisabled,
+
+		SkipForks: skipForks,
+		OnlyForks: onlyForks,
+
+		PushedAfter:  pushedAfter,
+		PushedBefore: pushedBefore,
+
+		MaxSize: maxSize,
+
+		Visibilities: visibilities,
+
+		Template: template,
+
+		Properties: properties,
+	}
+
+	if appID, _ := flag.GetInt64("github-app-id"); appID != 0 {
+		if asUser != "" {
+			return nil, errors.New("--as-user can not be used together with a GitHub App")
+		}
+		privateKeyPath, _ := flag.GetString("github-app-private-key")
+		if privateKeyPath == "" {
+			return nil, errors.New("no github-app-private-key set")
+		}
+		privateKey, err := ioutil.ReadFile(expandHome(privateKeyPath))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read the private key of the GitHub App")
+		}
+
+		return github.NewWithApp(github.AppCredentials{
+			AppID:        appID,
+			PrivateKey:   privateKey,
+			TokenCreated: censorToken,
+		}, gitBaseURL, transportMiddleware, repoListing, mergeTypes, forkMode)
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	if asUser != "" {
+		token, err = github.ImpersonationToken(context.Background(), token, gitBaseURL, transportMiddleware, asUser)
+		if err != nil {
+			return nil, err
+		}
+		// The impersonation token is used instead of the token of the administrator, which is the only one censored so far
+		censorToken(token)
+	}
+
+	vc, err := github.New(token, gitBaseURL, transportMiddleware, repoListing, mergeTypes, forkMode)
 	if err != nil {
 		return nil, err
 	}
@@ -176,16 +543,54 @@ func createGitlabClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versi
 	users, _ := flag.GetStringSlice("user")
 	projects, _ := flag.GetStringSlice("project")
 	includeSubgroups, _ := flag.GetBool("include-subgroups")
+	excludeGroups, _ := flag.GetStringSlice("exclude-group")
+	languages, _ := flag.GetStringSlice("language")
+	includeArchived, _ := flag.GetBool("include-archived")
+	skipDisabled, _ := flag.GetBool("skip-disabled")
+	approvers, _ := flag.GetStringSlice("gitlab-approver") // Only used for the run command
+	ciJobToken, _ := flag.GetBool("ci-job-token")
+	asUser, _ := flag.GetString("as-user")
 
 	if verifyFlags && len(groups) == 0 && len(users) == 0 && len(projects) == 0 {
 		return nil, errors.New("no group user or project set")
 	}
 
+	skipForks, onlyForks, err := getForkFilter(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	pushedAfter, pushedBefore, err := getPushedFilter(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, err := getMaxSize(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilities, err := getVisibilities(flag)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := getToken(flag)
 	if err != nil {
 		return nil, err
 	}
 
+	tokenType := gitlab.TokenTypePersonal
+	if ciJobToken {
+		if asUser != "" {
+			return nil, errors.New("--as-user can not be used together with --ci-job-token")
+		}
+		tokenType = gitlab.TokenTypeJob
+		if gitBaseURL == "" {
+			gitBaseURL = os.Getenv("CI_SERVER_URL")
+		}
+	}
+
 	projRefs := make([]gitlab.ProjectReference, len(projects))
 	for i := range projects {
 		projRefs[i], err = gitlab.ParseProjectReference(projects[i])
@@ -194,12 +599,36 @@ func createGitlabClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versi
 		}
 	}
 
-	vc, err := gitlab.New(token, gitBaseURL, gitlab.RepositoryListing{
-		Groups:   groups,
-		Users:    users,
-		Projects: projRefs,
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := gitlab.New(token, gitBaseURL, transportMiddleware, gitlab.RepositoryListing{
+		Groups:    groups,
+		Users:     users,
+		Projects:  projRefs,
+		Languages: languages,
+
+		ExcludeGroups: excludeGroups,
+
+		IncludeArchived: includeArchived,
+		IncludeDisabled: !skipDisabled,
+
+		SkipForks: skipForks,
+		OnlyForks: onlyForks,
+
+		PushedAfter:  pushedAfter,
+		PushedBefore: pushedBefore,
+
+		MaxSize: maxSize,
+
+		Visibilities: visibilities,
 	}, gitlab.Config{
 		IncludeSubgroups: includeSubgroups,
+		Approvers:        approvers,
+		TokenType:        tokenType,
+		Sudo:             asUser,
 	})
 	if err != nil {
 		return nil, err
@@ -208,16 +637,46 @@ func createGitlabClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versi
 	return vc, nil
 }
 
+// codebergURL is the url of the largest public Forgejo instance, used by default with the forgejo platform
+const codebergURL = "https://codeberg.org"
+
 func createGiteaClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
 	giteaBaseURL, _ := flag.GetString("base-url")
 	orgs, _ := flag.GetStringSlice("org")
 	users, _ := flag.GetStringSlice("user")
+	teams, _ := flag.GetStringSlice("team")
 	repos, _ := flag.GetStringSlice("repo")
+	languages, _ := flag.GetStringSlice("language")
+	includeArchived, _ := flag.GetBool("include-archived")
+	asUser, _ := flag.GetString("as-user")
 
-	if verifyFlags && len(orgs) == 0 && len(users) == 0 && len(repos) == 0 {
-		return nil, errors.New("no organization, user or repository set")
+	if verifyFlags && len(orgs) == 0 && len(users) == 0 && len(teams) == 0 && len(repos) == 0 {
+		return nil, errors.New("no organization, user, team or repository set")
+	}
+
+	skipForks, onlyForks, err := getForkFilter(flag)
+	if err != nil {
+		return nil, err
 	}
 
+	pushedAfter, pushedBefore, err := getPushedFilter(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize, err := getMaxSize(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilities, err := getVisibilities(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	if platform, _ := flag.GetString("platform"); giteaBaseURL == "" && platform == "forgejo" {
+		giteaBaseURL = codebergURL
+	}
 	if giteaBaseURL == "" {
 		return nil, errors.New("no base-url set")
 	}
@@ -227,6 +686,14 @@ func createGiteaClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versio
 		return nil, err
 	}
 
+	teamRefs := make([]gitea.TeamReference, len(teams))
+	for i := range teams {
+		teamRefs[i], err = gitea.ParseTeamReference(teams[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	repoRefs := make([]gitea.RepositoryReference, len(repos))
 	for i := range repos {
 		repoRefs[i], err = gitea.ParseRepositoryReference(repos[i])
@@ -240,10 +707,124 @@ func createGiteaClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versio
 		return nil, err
 	}
 
-	vc, err := gitea.New(token, giteaBaseURL, gitea.RepositoryListing{
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := gitea.New(token, giteaBaseURL, transportMiddleware, gitea.RepositoryListing{
 		Organizations: orgs,
 		Users:         users,
+		Teams:         teamRefs,
 		Repositories:  repoRefs,
+		Languages:     languages,
+
+		IncludeArchived: includeArchived,
+
+		SkipForks: skipForks,
+		OnlyForks: onlyForks,
+
+		PushedAfter:  pushedAfter,
+		PushedBefore: pushedBefore,
+
+		MaxSize: maxSize,
+
+		Visibilities: visibilities,
+	}, mergeTypes)
+	if err != nil {
+		return nil, err
+	}
+	vc.Sudo = asUser
+
+	return vc, nil
+}
+
+func createBitbucketClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	bitbucketBaseURL, _ := flag.GetString("base-url")
+	workspaces, _ := flag.GetStringSlice("workspace")
+	repos, _ := flag.GetStringSlice("repo")
+	username, _ := flag.GetString("username")
+
+	if verifyFlags && len(workspaces) == 0 && len(repos) == 0 {
+		return nil, errors.New("no workspace or repository set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRefs := make([]bitbucket.RepositoryReference, len(repos))
+	for i := range repos {
+		repoRefs[i], err = bitbucket.ParseRepositoryReference(repos[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mergeTypes, err := getMergeTypes(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := bitbucket.New(username, token, bitbucketBaseURL, transportMiddleware, bitbucket.RepositoryListing{
+		Workspaces:   workspaces,
+		Repositories: repoRefs,
+	}, mergeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createBitbucketServerClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	bitbucketBaseURL, _ := flag.GetString("base-url")
+	projects, _ := flag.GetStringSlice("project-key")
+	users, _ := flag.GetStringSlice("user")
+	repos, _ := flag.GetStringSlice("repo")
+	username, _ := flag.GetString("username")
+
+	if verifyFlags && len(projects) == 0 && len(users) == 0 && len(repos) == 0 {
+		return nil, errors.New("no project key, user or repository set")
+	}
+
+	if bitbucketBaseURL == "" {
+		return nil, errors.New("no base-url set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRefs := make([]bitbucketserver.RepositoryReference, len(repos))
+	for i := range repos {
+		repoRefs[i], err = bitbucketserver.ParseRepositoryReference(repos[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mergeTypes, err := getMergeTypes(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := bitbucketserver.New(username, token, bitbucketBaseURL, transportMiddleware, bitbucketserver.RepositoryListing{
+		Projects:     projects,
+		Users:        users,
+		Repositories: repoRefs,
 	}, mergeTypes)
 	if err != nil {
 		return nil, err
@@ -251,3 +832,534 @@ func createGiteaClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.Versio
 
 	return vc, nil
 }
+
+func createAzureDevOpsClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	azureBaseURL, _ := flag.GetString("base-url")
+	orgs, _ := flag.GetStringSlice("org")
+	projects, _ := flag.GetStringSlice("project")
+	repos, _ := flag.GetStringSlice("repo")
+
+	if verifyFlags && len(orgs) == 0 && len(projects) == 0 && len(repos) == 0 {
+		return nil, errors.New("no organization, project or repository set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	projRefs := make([]azuredevops.ProjectReference, len(projects))
+	for i := range projects {
+		projRefs[i], err = azuredevops.ParseProjectReference(projects[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	repoRefs := make([]azuredevops.RepositoryReference, len(repos))
+	for i := range repos {
+		repoRefs[i], err = azuredevops.ParseRepositoryReference(repos[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mergeTypes, err := getMergeTypes(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := azuredevops.New(token, azureBaseURL, transportMiddleware, azuredevops.RepositoryListing{
+		Organizations: orgs,
+		Projects:      projRefs,
+		Repositories:  repoRefs,
+	}, mergeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createCodeCommitClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	endpoint, _ := flag.GetString("base-url")
+	region, _ := flag.GetString("region")
+	repos, _ := flag.GetStringSlice("repo")
+
+	// Credentials are not needed during autocompletion
+	credentials, profileRegion, err := aws.LoadCredentials(context.Background(), "")
+	if err != nil && verifyFlags {
+		return nil, err
+	}
+	if region == "" {
+		region = profileRegion
+	}
+
+	mergeTypes, err := getMergeTypes(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := codecommit.New(credentials, region, endpoint, transportMiddleware, codecommit.RepositoryListing{
+		Repositories: repos,
+	}, mergeTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createGerritClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	gerritBaseURL, _ := flag.GetString("base-url")
+	projects, _ := flag.GetStringSlice("project")
+	prefixes, _ := flag.GetStringSlice("group")
+	username, _ := flag.GetString("username")
+
+	if verifyFlags && len(projects) == 0 && len(prefixes) == 0 {
+		return nil, errors.New("no project or group set")
+	}
+
+	if gerritBaseURL == "" {
+		return nil, errors.New("no base-url set")
+	}
+
+	if username == "" {
+		return nil, errors.New("no username set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := gerrit.New(username, token, gerritBaseURL, transportMiddleware, gerrit.RepositoryListing{
+		Projects: projects,
+		Prefixes: prefixes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createSourcehutClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	sourcehutBaseURL, _ := flag.GetString("base-url")
+	users, _ := flag.GetStringSlice("user")
+	repos, _ := flag.GetStringSlice("repo")
+
+	if verifyFlags && len(users) == 0 && len(repos) == 0 {
+		return nil, errors.New("no user or repo set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRefs := make([]sourcehut.RepositoryReference, len(repos))
+	for i := range repos {
+		repoRefs[i], err = sourcehut.ParseRepositoryReference(repos[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := sourcehut.New(token, sourcehutBaseURL, transportMiddleware, sourcehut.RepositoryListing{
+		Users:        users,
+		Repositories: repoRefs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createGogsClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	gogsBaseURL, _ := flag.GetString("base-url")
+	orgs, _ := flag.GetStringSlice("org")
+	users, _ := flag.GetStringSlice("user")
+	repos, _ := flag.GetStringSlice("repo")
+
+	if verifyFlags && len(orgs) == 0 && len(users) == 0 && len(repos) == 0 {
+		return nil, errors.New("no organization, user or repository set")
+	}
+
+	if gogsBaseURL == "" {
+		return nil, errors.New("no base-url set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRefs := make([]gogs.RepositoryReference, len(repos))
+	for i := range repos {
+		repoRefs[i], err = gogs.ParseRepositoryReference(repos[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transportMiddleware, err := getTransportMiddleware(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := gogs.New(token, gogsBaseURL, transportMiddleware, gogs.RepositoryListing{
+		Organizations: orgs,
+		Users:         users,
+		Repositories:  repoRefs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+func createRawGitClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	repos, _ := flag.GetStringSlice("repo")
+
+	if verifyFlags && len(repos) == 0 {
+		return nil, errors.New("no repo set")
+	}
+
+	return rawgit.New(repos)
+}
+
+func createLocalClient(flag *flag.FlagSet, verifyFlags bool) (multigitter.VersionController, error) {
+	paths, _ := flag.GetStringSlice("path")
+	pushOrigin, _ := flag.GetBool("push-origin")
+
+	if verifyFlags && len(paths) == 0 {
+		return nil, errors.New("no path set")
+	}
+
+	return local.New(paths, pushOrigin)
+}
+
+// platformFlags are the flags that are only supported on some platforms, and the platforms that support them
+var platformFlags = map[string][]string{
+	"language":      {"github", "gitlab", "gitea", "forgejo"},
+	"skip-forks":    {"github", "gitlab", "gitea", "forgejo"},
+	"only-forks":    {"github", "gitlab", "gitea", "forgejo"},
+	"pushed-after":  {"github", "gitlab", "gitea", "forgejo"},
+	"pushed-before": {"github", "gitlab", "gitea", "forgejo"},
+	"max-repo-size": {"github", "gitlab", "gitea", "forgejo"},
+	"visibility":    {"github", "gitlab", "gitea", "forgejo"},
+	"property":      {"github"},
+	"as-user":       {"github", "gitlab", "gitea", "forgejo"},
+}
+
+// verifyPlatformFlags returns an error if a flag is set that the platform does not support,
+// instead of silently ignoring it and using more repositories than intended
+func verifyPlatformFlags(flag *flag.FlagSet, platform string) error {
+	names := make([]string, 0, len(platformFlags))
+	for name := range platformFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flag.Lookup(name)
+		if f == nil || !f.Changed || f.Value.String() == f.DefValue {
+			continue
+		}
+		supported := false
+		for _, p := range platformFlags[name] {
+			supported = supported || p == platform
+		}
+		if !supported {
+			return fmt.Errorf("--%s is not supported on %s", name, platform)
+		}
+	}
+	return nil
+}
+
+// getTransportMiddleware gets the middleware used for all http requests made to the platform
+func getTransportMiddleware(flag *flag.FlagSet) (func(nethttp.RoundTripper) nethttp.RoundTripper, error) {
+	recordDir, _ := flag.GetString("record-http")
+	replayDir, _ := flag.GetString("replay-http")
+
+	if recordDir != "" && replayDir != "" {
+		return nil, errors.New("--record-http and --replay-http can not be used at the same time")
+	}
+
+	middlewares := []func(nethttp.RoundTripper) nethttp.RoundTripper{}
+	switch {
+	case recordDir != "":
+		recorder, err := http.NewRecorder(recordDir)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, recorder.Middleware)
+	case replayDir != "":
+		replayer, err := http.NewReplayer(replayDir)
+		if err != nil {
+			return nil, err
+		}
+		middlewares = append(middlewares, replayer.Middleware)
+	}
+
+	cache, err := getRepositoryCache(flag)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		middlewares = append(middlewares, cache.Middleware)
+	}
+
+	return func(rt nethttp.RoundTripper) nethttp.RoundTripper {
+		for _, middleware := range middlewares {
+			rt = middleware(rt)
+		}
+		return http.NewLoggingRoundTripper(rt)
+	}, nil
+}
+
+// getRepositoryCache gets the on disk cache of repository listings, or nil if it is not used
+func getRepositoryCache(flag *flag.FlagSet) (*http.Cache, error) {
+	cacheRepos, _ := flag.GetBool("cache-repos")
+	cacheTTL, _ := flag.GetDuration("cache-ttl")
+	if !cacheRepos {
+		return nil, nil
+	}
+	if cacheTTL <= 0 {
+		return nil, errors.New("--cache-ttl has to be a positive duration")
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, errors.WithMessage(err, "could not find the cache directory")
+	}
+
+	// Listings are only shared between clients that authenticate in the same way, since they might see different repositories
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+	username, _ := flag.GetString("username")
+	asUser, _ := flag.GetString("as-user")
+	appID, _ := flag.GetInt64("github-app-id")
+	appPrivateKey, _ := flag.GetString("github-app-private-key")
+
+	return http.NewCache(filepath.Join(cacheDir, "multi-gitter", "repositories"), cacheTTL,
+		token, username, asUser, strconv.FormatInt(appID, 10), appPrivateKey,
+	)
+}
+
+func createPluginClient(flag *flag.FlagSet, _ bool) (multigitter.VersionController, error) {
+	pluginPath, _ := flag.GetString("plugin-path")
+	pluginArgs, _ := flag.GetStringSlice("plugin-arg")
+	baseURL, _ := flag.GetString("base-url")
+	orgs, _ := flag.GetStringSlice("org")
+	groups, _ := flag.GetStringSlice("group")
+	users, _ := flag.GetStringSlice("user")
+	repos, _ := flag.GetStringSlice("repo")
+	forkMode, _ := flag.GetBool("fork")
+
+	if pluginPath == "" {
+		return nil, errors.New("no plugin-path set")
+	}
+
+	token, err := getToken(flag)
+	if err != nil {
+		return nil, err
+	}
+
+	return plugin.New(pluginPath, pluginArgs, plugin.Config{
+		Token:         token,
+		BaseURL:       baseURL,
+		Organizations: orgs,
+		Groups:        groups,
+		Users:         users,
+		Repositories:  repos,
+		ForkMode:      forkMode,
+	})
+}
+
+// getRepositoryFilter parses the flags that select which of the repositories, fetched from the platform, are used
+// getForkFilter returns if forks should be skipped, or if only forks should be used
+func getForkFilter(flag *flag.FlagSet) (skipForks, onlyForks bool, err error) {
+	skipForks, _ = flag.GetBool("skip-forks")
+	onlyForks, _ = flag.GetBool("only-forks")
+	if skipForks && onlyForks {
+		return false, false, errors.New("--skip-forks and --only-forks can not be used at the same time")
+	}
+	return skipForks, onlyForks, nil
+}
+
+// getPushedFilter returns the limits of when the repositories should have been pushed to, zero if not set
+func getPushedFilter(flag *flag.FlagSet) (after, before time.Time, err error) {
+	strAfter, _ := flag.GetString("pushed-after")
+	strBefore, _ := flag.GetString("pushed-before")
+
+	if after, err = parseDate(strAfter); err != nil {
+		return time.Time{}, time.Time{}, errors.WithMessage(err, "could not parse --pushed-after")
+	}
+	if before, err = parseDate(strBefore); err != nil {
+		return time.Time{}, time.Time{}, errors.WithMessage(err, "could not parse --pushed-before")
+	}
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return time.Time{}, time.Time{}, errors.New("--pushed-after has to be before --pushed-before")
+	}
+	return after, before, nil
+}
+
+// parseProperties parses custom properties in the format "name=value"
+func parseProperties(strProperties []string) (map[string]string, error) {
+	properties := map[string]string{}
+	for _, str := range strProperties {
+		split := strings.SplitN(str, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return nil, errors.Errorf(`could not parse the property "%s", it should be in the format "name=value"`, str)
+		}
+		properties[split[0]] = split[1]
+	}
+	return properties, nil
+}
+
+func getVisibilities(flag *flag.FlagSet) ([]string, error) {
+	visibilities, _ := flag.GetStringSlice("visibility")
+	for _, visibility := range visibilities {
+		switch strings.ToLower(visibility) {
+		case "public", "private", "internal":
+		default:
+			return nil, errors.Errorf(`unknown visibility "%s", it can be "public", "private" or "internal"`, visibility)
+		}
+	}
+	return visibilities, nil
+}
+
+func getMaxSize(flag *flag.FlagSet) (int64, error) {
+	str, _ := flag.GetString("max-repo-size")
+	size, err := parseSize(str)
+	if err != nil {
+		return 0, errors.WithMessage(err, "could not parse --max-repo-size")
+	}
+	return size, nil
+}
+
+var sizeRegex = regexp.MustCompile(`^(?i)\s*(\d+(?:\.\d+)?)\s*(B|KB|MB|GB)?\s*$`)
+
+// parseSize parses a size, in bytes or with the unit "KB", "MB" or "GB", where each unit is 1024 times the previous
+func parseSize(str string) (int64, error) {
+	if str == "" {
+		return 0, nil
+	}
+	match := sizeRegex.FindStringSubmatch(str)
+	if match == nil {
+		return 0, errors.Errorf(`"%s" is not a size, such as "500MB"`, str)
+	}
+	number, _ := strconv.ParseFloat(match[1], 64)
+	switch strings.ToUpper(match[2]) {
+	case "KB":
+		number *= 1 << 10
+	case "MB":
+		number *= 1 << 20
+	case "GB":
+		number *= 1 << 30
+	}
+	return int64(number), nil
+}
+
+// parseDate parses a date in the format "2006-01-02", or a time in the RFC 3339 format
+func parseDate(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", str); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return time.Time{}, errors.Errorf(`"%s" is not a date in the format "2006-01-02" or RFC 3339`, str)
+	}
+	return date, nil
+}
+
+func getRepositoryFilter(flag *flag.FlagSet) (multigitter.RepositoryFilter, error) {
+	includes, _ := flag.GetStringArray("repo-include")
+	excludes, _ := flag.GetStringArray("repo-exclude")
+	requiredFiles, _ := flag.GetStringArray("require-file")
+	requiredFileContents, _ := flag.GetStringArray("require-file-content")
+	ownedBy, _ := flag.GetStringSlice("owned-by")
+	defaultBranch, _ := flag.GetString("default-branch-is")
+	excludeFrom, _ := flag.GetString("exclude-from")
+
+	var filter multigitter.RepositoryFilter
+	for _, include := range includes {
+		regex, err := regexp.Compile(include)
+		if err != nil {
+			return multigitter.RepositoryFilter{}, errors.Wrap(err, "could not parse --repo-include")
+		}
+		filter.Include = append(filter.Include, regex)
+	}
+	for _, exclude := range excludes {
+		regex, err := regexp.Compile(exclude)
+		if err != nil {
+			return multigitter.RepositoryFilter{}, errors.Wrap(err, "could not parse --repo-exclude")
+		}
+		filter.Exclude = append(filter.Exclude, regex)
+	}
+	if excludeFrom != "" {
+		globs, err := readExcludeFile(excludeFrom)
+		if err != nil {
+			return multigitter.RepositoryFilter{}, err
+		}
+		filter.ExcludeGlobs = globs
+	}
+	if defaultBranch != "" {
+		regex, err := regexp.Compile("^(?:" + defaultBranch + ")$")
+		if err != nil {
+			return multigitter.RepositoryFilter{}, errors.Wrap(err, "could not parse --default-branch-is")
+		}
+		filter.DefaultBranch = regex
+	}
+	for _, path := range requiredFiles {
+		filter.Files = append(filter.Files, multigitter.FileCondition{Path: path})
+	}
+	for _, fileContent := range requiredFileContents {
+		split := strings.SplitN(fileContent, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return multigitter.RepositoryFilter{}, errors.Errorf(`could not parse required file content "%s", it should be in the format "path=regex"`, fileContent)
+		}
+		regex, err := regexp.Compile(split[1])
+		if err != nil {
+			return multigitter.RepositoryFilter{}, errors.Wrap(err, "could not parse --require-file-content")
+		}
+		filter.Files = append(filter.Files, multigitter.FileCondition{Path: split[0], Content: regex})
+	}
+	for _, owner := range ownedBy {
+		// Users and teams are written with an @ in CODEOWNERS, while emails already contain one
+		if !strings.Contains(owner, "@") {
+			owner = "@" + owner
+		}
+		filter.OwnedBy = append(filter.OwnedBy, owner)
+	}
+	return filter, nil
+}
@@ -0,0 +1,328 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// The statuses of a run
+const (
+	RunStatusRunning  = "running"
+	RunStatusFinished = "finished"
+	RunStatusFailed   = "failed"
+)
+
+// Config is the configuration of the API server
+type Config struct {
+	Token string // All requests have to be authenticated with this token as a bearer token
+	// Command creates the command of a run, with the arguments of the run command
+	Command func(args ...string) *exec.Cmd
+	// PullRequests lists the pull requests of a branch
+	PullRequests func(ctx context.Context, branchName string) ([]domain.PullRequest, error)
+	Dir          string // The directory the reports and logs of the runs are stored in
+}
+
+// Server exposes campaign operations over an authenticated HTTP API.
+// Each run is started as a separate process of the run command
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+
+	lock sync.Mutex
+	runs []*run
+}
+
+// New creates a new API server
+func New(config Config) (*Server, error) {
+	if config.Token == "" {
+		return nil, errors.New("the API server requires a token")
+	}
+
+	s := &Server{
+		config: config,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/api/runs", s.handleRuns)
+	s.mux.HandleFunc("/api/runs/", s.handleRun)
+	s.mux.HandleFunc("/api/pull-requests", s.handlePullRequests)
+
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// run is a run started through the API
+type run struct {
+	lock sync.Mutex
+
+	id         int
+	arguments  []string
+	status     string
+	err        string
+	startedAt  time.Time
+	finishedAt time.Time
+	reportPath string
+	logPath    string
+}
+
+// Run is the state of a run, as returned by the API
+type Run struct {
+	ID         int                 `json:"id"`
+	Arguments  []string            `json:"arguments"`
+	Status     string              `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	Report     *multigitter.Report `json:"report,omitempty"` // Only set when the run is finished
+}
+
+// StartRunRequest is the body of a request to start a run
+type StartRunRequest struct {
+	// The arguments of the run command, including the script, for example ["--org", "my-org", "-m", "message", "script.sh"]
+	Arguments []string `json:"arguments"`
+}
+
+// RetryRequest is the body of a request to retry a run in a single repository
+type RetryRequest struct {
+	Repository string `json:"repository"` // The repository to retry, in the format "owner/name"
+}
+
+// PullRequest is a pull request, as returned by the API
+type PullRequest struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"`
+}
+
+func (r *run) state() Run {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	state := Run{
+		ID:        r.id,
+		Arguments: r.arguments,
+		Status:    r.status,
+		Error:     r.err,
+		StartedAt: r.startedAt,
+	}
+	if r.status == RunStatusRunning {
+		return state
+	}
+
+	finishedAt := r.finishedAt
+	state.FinishedAt = &finishedAt
+	if file, err := os.Open(r.reportPath); err == nil {
+		defer file.Close()
+		if report, err := multigitter.ReadReport(file); err == nil {
+			state.Report = &report
+		}
+	}
+	return state
+}
+
+// start starts a new run with the arguments of the run command
+func (s *Server) start(arguments []string) *run {
+	s.lock.Lock()
+	id := len(s.runs) + 1
+	r := &run{
+		id:         id,
+		arguments:  arguments,
+		status:     RunStatusRunning,
+		startedAt:  time.Now(),
+		reportPath: filepath.Join(s.config.Dir, fmt.Sprintf("run-%d-report.json", id)),
+		logPath:    filepath.Join(s.config.Dir, fmt.Sprintf("run-%d.log", id)),
+	}
+	s.runs = append(s.runs, r)
+	s.lock.Unlock()
+
+	args := append([]string{"run"}, arguments...)
+	args = append(args,
+		"--report", r.reportPath,
+		"--log-file", r.logPath,
+		"--output", r.logPath+".output",
+	)
+	cmd := s.config.Command(args...)
+	if err := cmd.Start(); err != nil {
+		r.finish(err)
+		return r
+	}
+
+	log.WithField("run", id).Info("Started run")
+	go func() {
+		r.finish(cmd.Wait())
+		log.WithField("run", id).Info("Run finished")
+	}()
+
+	return r
+}
+
+func (r *run) finish(err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.finishedAt = time.Now()
+	if err != nil {
+		r.status = RunStatusFailed
+		r.err = err.Error()
+		return
+	}
+	r.status = RunStatusFinished
+}
+
+func (s *Server) getRun(id int) *run {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if id < 1 || id > len(s.runs) {
+		return nil
+	}
+	return s.runs[id-1]
+}
+
+// handleRuns handles "GET /api/runs" and "POST /api/runs"
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.lock.Lock()
+		runs := make([]*run, len(s.runs))
+		copy(runs, s.runs)
+		s.lock.Unlock()
+
+		states := make([]Run, len(runs))
+		for i, run := range runs {
+			states[i] = run.state()
+		}
+		writeJSON(w, http.StatusOK, states)
+	case http.MethodPost:
+		var req StartRunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Wrap(err, "could not parse the request"))
+			return
+		}
+		if len(req.Arguments) == 0 {
+			writeError(w, http.StatusBadRequest, errors.New("no arguments set"))
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, s.start(req.Arguments).state())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleRun handles "GET /api/runs/{id}", "GET /api/runs/{id}/log" and "POST /api/runs/{id}/retry"
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/runs/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	run := s.getRun(id)
+	if err != nil || run == nil || len(parts) > 2 {
+		writeError(w, http.StatusNotFound, errors.New("the run does not exist"))
+		return
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, run.state())
+	case action == "log" && r.Method == http.MethodGet:
+		b, err := ioutil.ReadFile(run.logPath)
+		if err != nil && !os.IsNotExist(err) {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(b)
+	case action == "retry" && r.Method == http.MethodPost:
+		var req RetryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Wrap(err, "could not parse the request"))
+			return
+		}
+		if req.Repository == "" {
+			writeError(w, http.StatusBadRequest, errors.New("no repository set"))
+			return
+		}
+
+		retry := s.start(append(append([]string{}, run.arguments...), "--only-repo", req.Repository))
+		writeJSON(w, http.StatusCreated, retry.state())
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// handlePullRequests handles "GET /api/pull-requests?branch={branch}"
+func (s *Server) handlePullRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	branchName := r.URL.Query().Get("branch")
+	if branchName == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no branch set"))
+		return
+	}
+
+	prs, err := s.config.PullRequests(r.Context(), branchName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ret := make([]PullRequest, len(prs))
+	for i, pr := range prs {
+		ret[i] = PullRequest{
+			Name:   pr.String(),
+			Status: pr.Status().String(),
+		}
+		if urler, ok := pr.(interface{ URL() string }); ok {
+			ret[i].URL = urler.URL()
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	writeJSON(w, http.StatusOK, ret)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Errorf("Could not write the response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
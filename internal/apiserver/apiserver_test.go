@@ -0,0 +1,147 @@
+package apiserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/apiserver"
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// TestHelperProcess acts as the run command when started by the tests
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	args = args[1:]
+
+	var reportPath, logPath string
+	var repos []string
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "--report":
+			reportPath = args[i+1]
+		case "--log-file":
+			logPath = args[i+1]
+		case "--only-repo":
+			repos = append(repos, args[i+1])
+		}
+	}
+	if len(repos) == 0 {
+		repos = []string{"owner/a", "owner/b"}
+	}
+
+	var results []string
+	for _, repo := range repos {
+		results = append(results, fmt.Sprintf(`{"repository": %q, "status": "success"}`, repo))
+	}
+	_ = ioutil.WriteFile(logPath, []byte(strings.Join(args, " ")), 0600)
+	_ = ioutil.WriteFile(reportPath, []byte(`{"repositories": [`+strings.Join(results, ",")+`]}`), 0600)
+	os.Exit(0)
+}
+
+type pullRequest struct{}
+
+func (pr pullRequest) String() string                   { return "owner/a #1" }
+func (pr pullRequest) Status() domain.PullRequestStatus { return domain.PullRequestStatusSuccess }
+func (pr pullRequest) RepositoryName() string           { return "owner/a" }
+
+func TestAPIServer(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-api-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	server, err := apiserver.New(apiserver.Config{
+		Token: "secret",
+		Command: func(args ...string) *exec.Cmd {
+			cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestHelperProcess", "--"}, args...)...)
+			cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+			return cmd
+		},
+		PullRequests: func(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+			return []domain.PullRequest{pullRequest{}}, nil
+		},
+		Dir: tmpDir,
+	})
+	require.NoError(t, err)
+
+	request := func(method, path, token, body string) (int, string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	waitForRun := func(id int) apiserver.Run {
+		var run apiserver.Run
+		for i := 0; i < 100; i++ {
+			_, body := request(http.MethodGet, fmt.Sprintf("/api/runs/%d", id), "secret", "")
+			require.NoError(t, json.Unmarshal([]byte(body), &run))
+			if run.Status != apiserver.RunStatusRunning {
+				return run
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatal("the run did not finish")
+		return run
+	}
+
+	code, _ := request(http.MethodGet, "/api/runs", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = request(http.MethodGet, "/api/runs", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := request(http.MethodPost, "/api/runs", "secret", `{"arguments": ["-m", "message", "script.sh"]}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Contains(t, body, `"id":1`)
+
+	run := waitForRun(1)
+	assert.Equal(t, apiserver.RunStatusFinished, run.Status)
+	require.NotNil(t, run.Report)
+	assert.Len(t, run.Report.Repositories, 2)
+
+	code, body = request(http.MethodGet, "/api/runs/1/log", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "run -m message script.sh")
+
+	code, _ = request(http.MethodPost, "/api/runs/1/retry", "secret", `{"repository": "owner/b"}`)
+	require.Equal(t, http.StatusCreated, code)
+	run = waitForRun(2)
+	assert.Equal(t, []string{"-m", "message", "script.sh", "--only-repo", "owner/b"}, run.Arguments)
+	require.NotNil(t, run.Report)
+	require.Len(t, run.Report.Repositories, 1)
+	assert.Equal(t, "owner/b", run.Report.Repositories[0].Repository)
+
+	code, body = request(http.MethodGet, "/api/runs", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+	var runs []apiserver.Run
+	require.NoError(t, json.Unmarshal([]byte(body), &runs))
+	assert.Len(t, runs, 2)
+
+	code, _ = request(http.MethodGet, "/api/runs/3", "secret", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = request(http.MethodGet, "/api/pull-requests?branch=my-branch", "secret", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[{"name": "owner/a #1", "status": "Success"}]`, body)
+}
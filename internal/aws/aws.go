@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+)
+
+// Credentials are AWS credentials
+type Credentials = aws.Credentials
+
+// LoadCredentials loads the AWS credentials and region with the default credential chain of the AWS SDK, in the same way
+// as the AWS CLI. If profile is empty, the AWS_PROFILE environment variable, or else the default profile, is used
+func LoadCredentials(ctx context.Context, profile string) (Credentials, string, error) {
+	var options []func(*config.LoadOptions) error
+	if profile != "" {
+		options = append(options, config.WithSharedConfigProfile(profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return Credentials{}, "", errors.WithMessage(err, "could not load the AWS config")
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return Credentials{}, "", errors.WithMessage(err, "could not find any AWS credentials")
+	}
+	return creds, cfg.Region, nil
+}
+
+// SHA256Hex returns the hex encoded SHA-256 hash of the data
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequest signs the request with AWS Signature Version 4, and adds the X-Amz-Date, X-Amz-Security-Token and Authorization headers
+func SignRequest(ctx context.Context, req *http.Request, body []byte, creds Credentials, region, service string, t time.Time) error {
+	signer := v4.NewSigner(func(options *v4.SignerOptions) {
+		// The path of S3 requests is the name of an object, and is not escaped again
+		options.DisableURIPathEscaping = service == "s3"
+	})
+	return signer.SignHTTP(ctx, creds, req, SHA256Hex(body), service, region, t)
+}
@@ -0,0 +1,377 @@
+package dashboard
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/history"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// Action is done on the pull requests of a run, when its button is pressed
+type Action func(ctx context.Context, run history.Entry) error
+
+// RetryAction makes a run again in some of its repositories
+type RetryAction func(ctx context.Context, run history.Entry, repositories []string) error
+
+// Actions are the actions that can be done from the dashboard. The button of an action is only shown if it is set
+type Actions struct {
+	Merge Action
+	Close Action
+	Retry RetryAction
+}
+
+// Config is the configuration of the dashboard
+type Config struct {
+	Token string // All requests have to be authenticated with this token, as a bearer token or once with the "token" query parameter
+	// The host names the dashboard is reached through. Requests with other Host headers are rejected,
+	// to not let other websites reach the dashboard by pointing their own host name to it
+	AllowedHosts []string
+	Actions      Actions
+}
+
+// tokenCookie is the cookie the token is stored in, after the dashboard has been opened with it in the url
+const tokenCookie = "multi-gitter-dashboard-token"
+
+// Server serves a web interface over the runs recorded in the history database
+type Server struct {
+	db        *history.DB
+	config    Config
+	csrfToken string
+	mux       *http.ServeMux
+}
+
+// New creates a new dashboard server
+func New(db *history.DB, config Config) (*Server, error) {
+	if config.Token == "" {
+		return nil, errors.New("the dashboard requires a token")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		db:        db,
+		config:    config,
+		csrfToken: hex.EncodeToString(b),
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/", s.handleRuns)
+	s.mux.HandleFunc("/runs/", s.handleRun)
+	s.mux.HandleFunc("/repository", s.handleRepository)
+
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowedHost(r.Host) {
+		http.Error(w, "unexpected host", http.StatusMisdirectedRequest)
+		return
+	}
+
+	// The token is moved from the url to a cookie, to be used by the following requests from the browser
+	if token := r.URL.Query().Get("token"); token != "" {
+		if !s.validToken(token) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     tokenCookie,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		query := r.URL.Query()
+		query.Del("token")
+		u := *r.URL
+		u.RawQuery = query.Encode()
+		http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if cookie, err := r.Cookie(tokenCookie); err == nil {
+		token = cookie.Value
+	}
+	if !s.validToken(token) {
+		http.Error(w, "invalid token, open the dashboard through the url it was started with", http.StatusUnauthorized)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) == 1
+}
+
+// allowedHost checks if the Host header of a request is one of the allowed host names
+func (s *Server) allowedHost(hostHeader string) bool {
+	host, _, err := net.SplitHostPort(hostHeader)
+	if err != nil {
+		host = hostHeader
+	}
+	host = strings.Trim(host, "[]")
+
+	for _, allowed := range s.config.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// run is a recorded run
+type run struct {
+	history.Entry
+}
+
+// Count returns the number of repositories with a status
+func (r run) Count(status string) int {
+	count := 0
+	for _, repo := range r.Repositories {
+		if repo.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// failed returns the names of the repositories where the run failed
+func (r run) failed() []string {
+	var names []string
+	for _, repo := range r.Repositories {
+		if repo.Status == multigitter.ReportStatusError {
+			names = append(names, repo.Repository)
+		}
+	}
+	return names
+}
+
+// Retryable returns if the run can be made again
+func (r run) Retryable() bool {
+	return !r.Running() && len(r.Arguments) > 0
+}
+
+// readRuns reads all recorded runs, newest first
+func (s *Server) readRuns(ctx context.Context) ([]run, error) {
+	entries, err := s.db.Runs(ctx, history.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]run, len(entries))
+	for i, entry := range entries {
+		runs[len(entries)-1-i] = run{Entry: entry}
+	}
+	return runs, nil
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	runs, err := s.readRuns(r.Context())
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	s.render(w, "runs", runs)
+}
+
+// handleRun handles "/runs/{id}", the result of a repository in it, "/runs/{id}/repository?name=", and the actions on it, "/runs/{id}/{action}"
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/runs/"), "/")
+	if len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	entry, err := s.db.Run(r.Context(), id)
+	if errors.Is(err, history.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		s.error(w, err)
+		return
+	}
+	current := run{Entry: entry}
+
+	if len(parts) == 1 {
+		s.render(w, "run", struct {
+			Run       run
+			Actions   Actions
+			CSRFToken string
+		}{current, s.config.Actions, s.csrfToken})
+		return
+	}
+
+	switch parts[1] {
+	case "repository":
+		s.handleRunRepository(w, r, current)
+	case "merge":
+		s.handleAction(w, r, current, "merge", s.config.Actions.Merge)
+	case "close":
+		s.handleAction(w, r, current, "close", s.config.Actions.Close)
+	case "retry":
+		s.handleRetry(w, r, current)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleRunRepository shows the result, changes and log of a repository in a run
+func (s *Server) handleRunRepository(w http.ResponseWriter, r *http.Request, current run) {
+	name := r.URL.Query().Get("name")
+
+	var result *multigitter.RepositoryReport
+	for i := range current.Repositories {
+		if current.Repositories[i].Repository == name {
+			result = &current.Repositories[i]
+		}
+	}
+	if result == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	repoLog, err := s.db.Log(r.Context(), current.ID, name)
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	s.render(w, "run-repository", struct {
+		Run       run
+		Result    multigitter.RepositoryReport
+		Log       string
+		Actions   Actions
+		CSRFToken string
+	}{current, *result, repoLog, s.config.Actions, s.csrfToken})
+}
+
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request, current run, name string, action Action) {
+	if action == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.verifyPost(w, r) {
+		return
+	}
+
+	log.WithField("branch", current.BranchName).Infof("Running %s from the dashboard", name)
+	if err := action(r.Context(), current.Entry); err != nil {
+		s.error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/runs/%d", current.ID), http.StatusSeeOther)
+}
+
+// handleRetry makes the run again in a single repository, or in all repositories where it failed if none is set
+func (s *Server) handleRetry(w http.ResponseWriter, r *http.Request, current run) {
+	if s.config.Actions.Retry == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.verifyPost(w, r) {
+		return
+	}
+	if !current.Retryable() {
+		http.Error(w, "the run can not be made again while it is ongoing, or if it was recorded without its arguments", http.StatusBadRequest)
+		return
+	}
+
+	repositories := current.failed()
+	if name := r.PostFormValue("repository"); name != "" {
+		repositories = []string{name}
+	}
+	if len(repositories) == 0 {
+		http.Error(w, "there are no failed repositories to retry", http.StatusBadRequest)
+		return
+	}
+
+	log.WithField("branch", current.BranchName).Infof("Retrying %s from the dashboard", strings.Join(repositories, ", "))
+	if err := s.config.Actions.Retry(r.Context(), current.Entry, repositories); err != nil {
+		s.error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// verifyPost verifies that the request is a post from the dashboard itself, and writes an error if not
+func (s *Server) verifyPost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	// Any website could otherwise make the browser of the user post to the dashboard
+	if subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf_token")), []byte(s.csrfToken)) != 1 {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// handleRepository lists the runs of a single repository
+func (s *Server) handleRepository(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	runs, err := s.readRuns(r.Context())
+	if err != nil {
+		s.error(w, err)
+		return
+	}
+
+	type repositoryRun struct {
+		Run    run
+		Result multigitter.RepositoryReport
+	}
+	var repoRuns []repositoryRun
+	for _, run := range runs {
+		for _, repo := range run.Repositories {
+			if repo.Repository == name {
+				repoRuns = append(repoRuns, repositoryRun{Run: run, Result: repo})
+			}
+		}
+	}
+
+	s.render(w, "repository", struct {
+		Name string
+		Runs []repositoryRun
+	}{name, repoRuns})
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Errorf("Could not render the %s page: %s", name, err)
+	}
+}
+
+func (s *Server) error(w http.ResponseWriter, err error) {
+	log.Error(err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,220 @@
+package dashboard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/dashboard"
+	"github.com/lindell/multi-gitter/internal/history"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+const testToken = "secret-token"
+
+func newTestServer(t *testing.T, actions dashboard.Actions) (*dashboard.Server, string) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-dashboard-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+	historyPath := filepath.Join(tmpDir, "history.db")
+
+	db, err := history.Open(historyPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	server, err := dashboard.New(db, dashboard.Config{
+		Token:        testToken,
+		AllowedHosts: []string{"example.com"},
+		Actions:      actions,
+	})
+	require.NoError(t, err)
+
+	return server, historyPath
+}
+
+func get(server http.Handler, path string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func post(server http.Handler, path string, form url.Values) int {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func csrfToken(t *testing.T, body string) string {
+	token := regexp.MustCompile(`name="csrf_token" value="([0-9a-f]+)"`).FindStringSubmatch(body)
+	require.Len(t, token, 2)
+	return token[1]
+}
+
+func TestDashboard(t *testing.T) {
+	var closed []string
+	var retried []string
+	server, historyPath := newTestServer(t, dashboard.Actions{
+		Close: func(ctx context.Context, run history.Entry) error {
+			closed = append(closed, run.BranchName)
+			return nil
+		},
+		Retry: func(ctx context.Context, run history.Entry, repositories []string) error {
+			retried = append(retried, repositories...)
+			return nil
+		},
+	})
+
+	recorder := history.NewRecorder(historyPath, history.Campaign{
+		BranchName:    "update-deps",
+		CommitMessage: "Update dependencies",
+		Arguments:     []string{"-m", "Update dependencies", "--", "script.sh"},
+	})
+	require.NoError(t, recorder.WriteReport(context.Background(), multigitter.Report{
+		Repositories: []multigitter.RepositoryReport{
+			{Repository: "owner/a", Status: multigitter.ReportStatusSuccess, PullRequest: "owner/a #1", Patch: "-old line\n+new line\n"},
+			{Repository: "owner/b", Status: multigitter.ReportStatusError, Error: "<script> failed"},
+		},
+	}))
+
+	code, body := get(server, "/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `<a href="/runs/1">`)
+	assert.Contains(t, body, "update-deps")
+
+	code, body = get(server, "/runs/1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "owner/a #1")
+	assert.Contains(t, body, "&lt;script&gt; failed")
+	assert.Contains(t, body, "Close pull requests")
+	assert.Contains(t, body, "Retry failed repositories")
+	assert.NotContains(t, body, "Merge pull requests")
+
+	code, body = get(server, "/runs/1/repository?name=owner/a")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "old line\n&#43;new line")
+
+	code, body = get(server, "/repository?name=owner/b")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "update-deps")
+	assert.NotContains(t, body, "owner/a #1")
+
+	code, _ = get(server, "/runs/2")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get(server, "/runs/1/repository?name=owner/c")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	assert.Equal(t, http.StatusForbidden, post(server, "/runs/1/close", url.Values{"csrf_token": {"wrong"}}))
+	assert.Empty(t, closed)
+
+	_, body = get(server, "/runs/1")
+	token := csrfToken(t, body)
+
+	assert.Equal(t, http.StatusSeeOther, post(server, "/runs/1/close", url.Values{"csrf_token": {token}}))
+	assert.Equal(t, []string{"update-deps"}, closed)
+
+	assert.Equal(t, http.StatusNotFound, post(server, "/runs/1/merge", url.Values{"csrf_token": {token}}))
+
+	assert.Equal(t, http.StatusSeeOther, post(server, "/runs/1/retry", url.Values{"csrf_token": {token}}))
+	assert.Equal(t, []string{"owner/b"}, retried)
+	assert.Equal(t, http.StatusSeeOther, post(server, "/runs/1/retry", url.Values{"csrf_token": {token}, "repository": {"owner/a"}}))
+	assert.Equal(t, []string{"owner/b", "owner/a"}, retried)
+}
+
+func TestDashboard_OngoingRun(t *testing.T) {
+	server, historyPath := newTestServer(t, dashboard.Actions{
+		Retry: func(ctx context.Context, run history.Entry, repositories []string) error {
+			return nil
+		},
+	})
+
+	recorder := history.NewRecorder(historyPath, history.Campaign{BranchName: "update-deps", Arguments: []string{"--", "script.sh"}})
+	require.NoError(t, recorder.StartRun(context.Background(), []string{"owner/a", "owner/b"}))
+
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(recorder)
+	logger.WithField("repo", "owner/a").Info("Cloning and running script")
+	require.NoError(t, recorder.RepositoryFinished(context.Background(), multigitter.RepositoryReport{
+		Repository: "owner/a", Status: multigitter.ReportStatusError, Error: "exit status 1",
+	}))
+
+	code, body := get(server, "/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "update-deps (running)")
+	assert.Contains(t, body, `<td class="pending">1</td>`)
+	assert.Contains(t, body, `<td class="error">1</td>`)
+
+	code, body = get(server, "/runs/1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "running")
+	assert.NotContains(t, body, "Retry failed repositories")
+
+	code, body = get(server, "/runs/1/repository?name=owner/a")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "INFO Cloning and running script")
+	assert.NotContains(t, body, "Retry")
+
+	require.NoError(t, recorder.WriteReport(context.Background(), multigitter.Report{
+		Repositories: []multigitter.RepositoryReport{
+			{Repository: "owner/a", Status: multigitter.ReportStatusError, Error: "exit status 1"},
+			{Repository: "owner/b", Status: multigitter.ReportStatusSuccess},
+		},
+	}))
+
+	_, body = get(server, "/runs/1")
+	assert.Contains(t, body, "finished")
+	assert.Contains(t, body, "Retry failed repositories")
+}
+
+func TestDashboard_Authentication(t *testing.T) {
+	server, _ := newTestServer(t, dashboard.Actions{})
+
+	request := func(target, host string, modify func(req *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		if modify != nil {
+			modify(req)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	bearer := func(token string) func(req *http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("/", "example.com", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request("/", "example.com", bearer("wrong")).Code)
+	assert.Equal(t, http.StatusOK, request("/", "example.com:8080", bearer(testToken)).Code)
+	assert.Equal(t, http.StatusUnauthorized, request("/?token=wrong", "example.com", nil).Code)
+
+	// Another host name, that resolves to the dashboard, is rejected even with the token
+	assert.Equal(t, http.StatusMisdirectedRequest, request("/", "attacker.example", bearer(testToken)).Code)
+
+	// Opening the dashboard with the token in the url stores it in a cookie
+	rec := request("/repository?name=owner%2Fa&token="+testToken, "example.com", nil)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/repository?name=owner%2Fa", rec.Header().Get("Location"))
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+
+	rec = request("/", "example.com", func(req *http.Request) { req.AddCookie(cookies[0]) })
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
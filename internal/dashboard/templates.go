@@ -0,0 +1,96 @@
+package dashboard
+
+import (
+	"html/template"
+	"time"
+)
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>multi-gitter</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3em 1em 0.3em 0; vertical-align: top; }
+.success { color: #1a7f37; }
+.no-change { color: #57606a; }
+.error { color: #cf222e; }
+.pending { color: #9a6700; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+form { display: inline; }
+</style>
+</head>
+<body>
+<h1><a href="/">multi-gitter</a></h1>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "runs"}}{{template "header"}}
+<h2>Runs</h2>
+{{if not .}}<p>No runs have been recorded yet.</p>{{else}}
+<table>
+<tr><th>Started</th><th>Branch</th><th>Commit message</th><th>Pending</th><th>Succeeded</th><th>No change</th><th>Failed</th></tr>
+{{range .}}<tr>
+<td><a href="/runs/{{.ID}}">{{time .StartedAt}}</a></td>
+<td>{{.BranchName}}{{if .Running}} (running){{end}}{{if .DryRun}} (dry run){{end}}</td>
+<td>{{.CommitMessage}}</td>
+<td class="pending">{{.Count "pending"}}</td>
+<td class="success">{{.Count "success"}}</td>
+<td class="no-change">{{.Count "no-change"}}</td>
+<td class="error">{{.Count "error"}}</td>
+</tr>{{end}}
+</table>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "run"}}{{template "header"}}
+<h2>{{.Run.BranchName}}: {{.Run.CommitMessage}}{{if .Run.DryRun}} (dry run){{end}}</h2>
+<p>Started {{time .Run.StartedAt}}, {{if .Run.Running}}running{{else}}finished {{time .Run.FinishedAt}}{{end}}{{if .Run.CampaignURL}}, <a href="{{.Run.CampaignURL}}">campaign</a>{{end}}</p>
+{{if .Actions.Merge}}<form method="post" action="/runs/{{.Run.ID}}/merge"><input type="hidden" name="csrf_token" value="{{.CSRFToken}}"><button>Merge pull requests</button></form>{{end}}
+{{if .Actions.Close}}<form method="post" action="/runs/{{.Run.ID}}/close"><input type="hidden" name="csrf_token" value="{{.CSRFToken}}"><button>Close pull requests</button></form>{{end}}
+{{if and .Actions.Retry .Run.Retryable (.Run.Count "error")}}<form method="post" action="/runs/{{.Run.ID}}/retry"><input type="hidden" name="csrf_token" value="{{.CSRFToken}}"><button>Retry failed repositories</button></form>{{end}}
+<table>
+<tr><th>Repository</th><th>Status</th><th>Pull request</th><th>Reason</th></tr>
+{{$run := .Run}}{{range .Run.Repositories}}<tr>
+<td><a href="/runs/{{$run.ID}}/repository?name={{.Repository}}">{{.Repository}}</a></td>
+<td class="{{.Status}}">{{.Status}}</td>
+<td>{{.PullRequest}}</td>
+<td>{{.Error}}{{.Reason}}</td>
+</tr>{{end}}
+</table>
+{{template "footer"}}{{end}}
+
+{{define "run-repository"}}{{template "header"}}
+<h2><a href="/runs/{{.Run.ID}}">{{.Run.BranchName}}</a>: <a href="/repository?name={{.Result.Repository}}">{{.Result.Repository}}</a></h2>
+<p class="{{.Result.Status}}">{{.Result.Status}}{{if .Result.PullRequestURL}}: <a href="{{.Result.PullRequestURL}}">{{.Result.PullRequest}}</a>{{else if .Result.PullRequest}}: {{.Result.PullRequest}}{{end}}</p>
+{{if or .Result.Error .Result.Reason}}<p>{{.Result.Error}}{{.Result.Reason}}</p>{{end}}
+{{if and .Actions.Retry .Run.Retryable}}<form method="post" action="/runs/{{.Run.ID}}/retry"><input type="hidden" name="csrf_token" value="{{.CSRFToken}}"><input type="hidden" name="repository" value="{{.Result.Repository}}"><button>Retry</button></form>{{end}}
+<h3>Changes</h3>
+{{if .Result.Patch}}<pre>{{.Result.Patch}}</pre>{{else}}<p>No changes have been recorded.</p>{{end}}
+<h3>Log</h3>
+{{if .Log}}<pre>{{.Log}}</pre>{{else}}<p>No log has been recorded.</p>{{end}}
+{{template "footer"}}{{end}}
+
+{{define "repository"}}{{template "header"}}
+<h2>{{.Name}}</h2>
+{{if not .Runs}}<p>No runs have been made against this repository.</p>{{else}}
+<table>
+<tr><th>Started</th><th>Branch</th><th>Status</th><th>Pull request</th><th>Reason</th></tr>
+{{range .Runs}}<tr>
+<td><a href="/runs/{{.Run.ID}}/repository?name={{.Result.Repository}}">{{time .Run.StartedAt}}</a></td>
+<td>{{.Run.BranchName}}</td>
+<td class="{{.Result.Status}}">{{.Result.Status}}</td>
+<td>{{.Result.PullRequest}}</td>
+<td>{{.Result.Error}}{{.Result.Reason}}</td>
+</tr>{{end}}
+</table>{{end}}
+{{template "footer"}}{{end}}
+`))
@@ -0,0 +1,9 @@
+package domain
+
+import "fmt"
+
+// CampaignMarker returns a string that is included in the body of all pull requests created for a campaign,
+// to make it possible to find them without relying on the branch name
+func CampaignMarker(campaignID string) string {
+	return fmt.Sprintf("<!-- multi-gitter-campaign: %s -->", campaignID)
+}
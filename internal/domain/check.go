@@ -0,0 +1,79 @@
+package domain
+
+import "fmt"
+
+// CheckStatus is the status of a single check, such as a CI job, of a pull request
+type CheckStatus int
+
+// All CheckStatuses
+const (
+	CheckStatusUnknown CheckStatus = iota
+	CheckStatusPending
+	CheckStatusSuccess
+	CheckStatusFailure
+)
+
+func (s CheckStatus) String() string {
+	switch s {
+	case CheckStatusPending:
+		return "pending"
+	case CheckStatusSuccess:
+		return "success"
+	case CheckStatusFailure:
+		return "failure"
+	}
+	return "unknown"
+}
+
+// Check is the result of a single check of a pull request
+type Check struct {
+	Name   string
+	Status CheckStatus
+}
+
+// AnnotationSeverity is how severe an issue found in a file is
+type AnnotationSeverity int
+
+// All AnnotationSeverities
+const (
+	AnnotationSeverityNotice AnnotationSeverity = iota
+	AnnotationSeverityWarning
+	AnnotationSeverityFailure
+)
+
+func (s AnnotationSeverity) String() string {
+	switch s {
+	case AnnotationSeverityWarning:
+		return "warning"
+	case AnnotationSeverityFailure:
+		return "failure"
+	}
+	return "notice"
+}
+
+// ParseAnnotationSeverity parses a severity, "notice", "warning" or "failure" ("error" is an alias of "failure")
+func ParseAnnotationSeverity(str string) (AnnotationSeverity, error) {
+	switch str {
+	case "notice", "":
+		return AnnotationSeverityNotice, nil
+	case "warning":
+		return AnnotationSeverityWarning, nil
+	case "failure", "error":
+		return AnnotationSeverityFailure, nil
+	}
+	return AnnotationSeverityNotice, fmt.Errorf(`unknown annotation severity "%s"`, str)
+}
+
+// Annotation is an issue on a line of a file, that is shown inline when reviewing the changes
+type Annotation struct {
+	Path     string
+	Line     int
+	Message  string
+	Severity AnnotationSeverity
+}
+
+// CheckRun is a check, with annotations, that is published on a commit
+type CheckRun struct {
+	Name        string
+	Annotations []Annotation
+}
@@ -5,3 +5,9 @@ type CommitAuthor struct {
 	Name  string
 	Email string
 }
+
+// Commit is a commit in a pull request
+type Commit struct {
+	Hash    string
+	Parents int // The number of parents, more than one means that it is a merge commit
+}
@@ -12,4 +12,16 @@ const (
 	NoChangeError    Error = "no data was changed"
 	ExitCodeError    Error = "the program exited with a non zero exit code"
 	BranchExistError Error = "the new branch does already exist"
+	AlreadyDoneError Error = "an open pull request with the same changes does already exist"
+	ConflictError    Error = "the existing branch could not be updated because of unresolved conflicts"
 )
+
+// SkipError is returned when a change is not made to a repository for an expected reason, such as
+// the platform not supporting it, rather than because something failed
+type SkipError struct {
+	Reason string
+}
+
+func (e SkipError) Error() string {
+	return e.Reason
+}
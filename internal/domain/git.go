@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+)
+
 // GitConfig is configration for any git implementation
 type GitConfig struct {
 	// Absolute path to the directory
@@ -7,3 +12,26 @@ type GitConfig struct {
 	// The fetch depth used when cloning, if set to 0, the entire history will be used
 	FetchDepth int
 }
+
+// ConflictResolution is the side that should be used when a file is in conflict
+type ConflictResolution int
+
+// All ConflictResolutions
+const (
+	ConflictResolutionUnknown ConflictResolution = iota
+	// ConflictResolutionOurs uses the version of the file produced by the current run
+	ConflictResolutionOurs
+	// ConflictResolutionTheirs uses the version of the file in the already existing branch
+	ConflictResolutionTheirs
+)
+
+// ParseConflictResolution parses a conflict resolution
+func ParseConflictResolution(str string) (ConflictResolution, error) {
+	switch strings.ToLower(str) {
+	case "ours":
+		return ConflictResolutionOurs, nil
+	case "theirs":
+		return ConflictResolutionTheirs, nil
+	}
+	return ConflictResolutionUnknown, fmt.Errorf(`not a valid conflict resolution: "%s"`, str)
+}
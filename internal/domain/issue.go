@@ -0,0 +1,7 @@
+package domain
+
+// NewIssue is the data needed to create a new issue
+type NewIssue struct {
+	Title string
+	Body  string
+}
@@ -0,0 +1,10 @@
+package domain
+
+// Label is a label that can be added to pull requests and issues
+type Label struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"` // The hex color without a leading "#", for example "d73a4a"
+	Description string `yaml:"description"`
+	// Labels with any of these names are renamed to Name, unless a label with Name already exist
+	PreviousNames []string `yaml:"previous_names"`
+}
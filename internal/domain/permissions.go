@@ -0,0 +1,8 @@
+package domain
+
+// Permissions are the permissions users and teams have to a repository, by their name.
+// The names of the permissions are specific to each platform, for example "push" on GitHub and "developer" on GitLab
+type Permissions struct {
+	Users map[string]string `yaml:"users"`
+	Teams map[string]string `yaml:"teams"` // Teams on GitHub, and groups the project is shared with on GitLab
+}
@@ -12,7 +12,93 @@ type NewPullRequest struct {
 	Head  string
 	Base  string
 
-	Reviewers []string // The username of all reviewers
+	Reviewers []Reviewer
+	Assignees []string // The username of all assignees
+	Labels    []string
+	Milestone string // The title of the milestone
+	Draft     bool
+}
+
+// ReviewerType is the kind of a reviewer
+type ReviewerType int
+
+// All ReviewerTypes
+const (
+	// ReviewerTypeUser is a single user
+	ReviewerTypeUser ReviewerType = iota
+	// ReviewerTypeTeam is a group of users. A team on GitHub and Gitea, and a group on GitLab
+	ReviewerTypeTeam
+	// ReviewerTypeDefault is the default reviewers of the repository. Platforms that add them automatically,
+	// such as code owners on GitHub and Gitea and approval rules on GitLab, do not need to add them
+	ReviewerTypeDefault
+)
+
+const teamReviewerPrefix = "team:"
+
+// Reviewer is a user, or a group of users, that should review a pull request
+type Reviewer struct {
+	Type ReviewerType
+	Name string
+}
+
+// ParseReviewer parses a reviewer, teams are prefixed with "team:"
+func ParseReviewer(str string) Reviewer {
+	if strings.HasPrefix(str, teamReviewerPrefix) {
+		return Reviewer{
+			Type: ReviewerTypeTeam,
+			Name: strings.TrimPrefix(str, teamReviewerPrefix),
+		}
+	}
+	return Reviewer{
+		Type: ReviewerTypeUser,
+		Name: str,
+	}
+}
+
+// DefaultReviewers is the default reviewers of the repository the pull request is created in
+var DefaultReviewers = Reviewer{Type: ReviewerTypeDefault}
+
+func (r Reviewer) String() string {
+	switch r.Type {
+	case ReviewerTypeTeam:
+		return teamReviewerPrefix + r.Name
+	case ReviewerTypeDefault:
+		return "default reviewers"
+	}
+	return r.Name
+}
+
+// SplitReviewers splits reviewers into the names of users and teams
+func SplitReviewers(reviewers []Reviewer) (users []string, teams []string) {
+	for _, r := range reviewers {
+		switch r.Type {
+		case ReviewerTypeUser:
+			users = append(users, r.Name)
+		case ReviewerTypeTeam:
+			teams = append(teams, r.Name)
+		}
+	}
+	return users, teams
+}
+
+// HasDefaultReviewers returns if the default reviewers of the repository should be added
+func HasDefaultReviewers(reviewers []Reviewer) bool {
+	for _, r := range reviewers {
+		if r.Type == ReviewerTypeDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamNames returns the names of teams without their organization, since teams can be defined both with and without
+// the organization as prefix
+func TeamNames(teams []string) []string {
+	names := make([]string, len(teams))
+	for i, team := range teams {
+		names[i] = team[strings.LastIndex(team, "/")+1:]
+	}
+	return names
 }
 
 // PullRequestStatus is the status of a pull request, including statuses of the last commit
@@ -50,6 +136,15 @@ func (s PullRequestStatus) String() string {
 type PullRequest interface {
 	Status() PullRequestStatus
 	String() string
+	// RepositoryName is the full name of the repository the pull request is made to, the same as Repository.FullName
+	RepositoryName() string
+}
+
+// PullRequestDiff is a pull request together with the changes made in it
+type PullRequestDiff struct {
+	PullRequest PullRequest
+	Branch      string // The name of the head branch
+	Diff        string // The changes in the unified diff format
 }
 
 // MergeType is the way a pull request is "merged" into the base branch
@@ -76,6 +171,18 @@ func ParseMergeType(typ string) (MergeType, error) {
 	return MergeTypeUnknown, fmt.Errorf(`not a valid merge type: "%s"`, typ)
 }
 
+func (t MergeType) String() string {
+	switch t {
+	case MergeTypeMerge:
+		return "merge"
+	case MergeTypeRebase:
+		return "rebase"
+	case MergeTypeSquash:
+		return "squash"
+	}
+	return "unknown"
+}
+
 // MergeTypeIntersection calculates the intersection of two merge type slices,
 // The order of the first slice will be preserved
 func MergeTypeIntersection(mergeTypes1, mergeTypes2 []MergeType) []MergeType {
@@ -89,3 +196,18 @@ func MergeTypeIntersection(mergeTypes1, mergeTypes2 []MergeType) []MergeType {
 	}
 	return res
 }
+
+// PullRequestLimits are the maximum number of characters a platform allows in a pull request.
+// Zero means that there is no limit.
+type PullRequestLimits struct {
+	MaxTitleLength int
+	MaxBodyLength  int
+}
+
+// Suggestion is a change of some lines of a file, proposed in a comment on a pull request for the reviewers to apply
+type Suggestion struct {
+	Path        string
+	StartLine   int      // The first line that is replaced, in the file of the pull request
+	EndLine     int      // The last line that is replaced
+	Replacement []string // The lines that replace the lines, none if they are removed
+}
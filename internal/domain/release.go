@@ -0,0 +1,8 @@
+package domain
+
+// Release is a tag, together with the release notes of it
+type Release struct {
+	Tag   string
+	Name  string // The title of the release, the tag is used if empty
+	Notes string
+}
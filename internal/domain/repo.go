@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // Repository contains all information about a git repository
 type Repository interface {
 	URL(token string) string
@@ -7,3 +9,17 @@ type Repository interface {
 	// Returns the full id of the repository, usually ownerName/repoName
 	FullName() string
 }
+
+// Archivable is a repository that can be archived or disabled, which makes it read-only
+type Archivable interface {
+	Repository
+	Archived() bool
+	Disabled() bool
+}
+
+// Fork is a repository that has been forked from another repository
+type Fork interface {
+	Repository
+	// The last time changes were pushed to the fork
+	LastUpdated() time.Time
+}
@@ -0,0 +1,14 @@
+package domain
+
+// Secrets are the secrets and variables that should be set in repositories
+type Secrets struct {
+	Secrets   map[string]string `yaml:"secrets"`
+	Variables map[string]string `yaml:"variables"`
+}
+
+// RepositorySecrets are the secrets and variables that are currently set in a repository.
+// The values of secrets can't be read, so only their names are known
+type RepositorySecrets struct {
+	SecretNames []string
+	Variables   map[string]string
+}
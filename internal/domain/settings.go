@@ -0,0 +1,28 @@
+package domain
+
+// RepositorySettings are the settings of a repository. Settings that are not set are left unchanged
+type RepositorySettings struct {
+	Topics              []string          `yaml:"topics"`
+	AllowMergeCommit    *bool             `yaml:"allow_merge_commit"`
+	AllowSquashMerge    *bool             `yaml:"allow_squash_merge"`
+	AllowRebaseMerge    *bool             `yaml:"allow_rebase_merge"`
+	DeleteBranchOnMerge *bool             `yaml:"delete_branch_on_merge"`
+	BranchProtection    *BranchProtection `yaml:"branch_protection"`
+	Webhooks            []Webhook         `yaml:"webhooks"` // Webhooks are matched by their url, webhooks that are not listed are left unchanged
+}
+
+// BranchProtection is the protection of a branch
+type BranchProtection struct {
+	Branch                   string   `yaml:"branch"` // Defaults to the default branch of the repository
+	RequiredApprovingReviews int      `yaml:"required_approving_reviews"`
+	RequiredStatusChecks     []string `yaml:"required_status_checks"`
+	EnforceAdmins            bool     `yaml:"enforce_admins"`
+}
+
+// Webhook is a webhook of a repository
+type Webhook struct {
+	URL         string   `yaml:"url"`
+	Events      []string `yaml:"events"`
+	ContentType string   `yaml:"content_type"` // "json" or "form", defaults to "json"
+	Secret      string   `yaml:"secret"`       // The secret can't be read back from the platform, and is never compared
+}
@@ -0,0 +1,49 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// metadataTokenURL is the url of the access token of the service account on Google Cloud
+var metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// AccessToken gets an access token of Google Cloud, from the GOOGLE_OAUTH_ACCESS_TOKEN environment variable, gcloud,
+// or else the metadata server of the machine when running on Google Cloud
+func AccessToken(ctx context.Context, client *http.Client) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	if out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output(); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.New("could not get an access token of Google Cloud, either the GOOGLE_OAUTH_ACCESS_TOKEN environment variable has to be set, or gcloud has to be logged in")
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", errors.New("could not get an access token from the metadata server of Google Cloud")
+	}
+	return token.AccessToken, nil
+}
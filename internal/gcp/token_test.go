@@ -0,0 +1,39 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		fmt.Fprint(w, `{"access_token": "metadata-token", "expires_in": 3599, "token_type": "Bearer"}`)
+	}))
+	defer server.Close()
+
+	tokenURL := metadataTokenURL
+	metadataTokenURL = server.URL
+	defer func() { metadataTokenURL = tokenURL }()
+
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "env-token")
+	token, err := AccessToken(context.Background(), server.Client())
+	os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", token)
+
+	if _, err := exec.LookPath("gcloud"); err == nil {
+		t.Skip("the token of gcloud is used before the metadata server")
+	}
+	token, err = AccessToken(context.Background(), server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, "metadata-token", token)
+}
@@ -3,6 +3,7 @@ package cmdgit
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"regexp"
 	"strings"
@@ -81,7 +82,21 @@ func (g *Git) Commit(commitAuthor *domain.CommitAuthor, commitMessage string) er
 	}
 
 	cmd = exec.Command("git", "commit", "--no-verify", "-m", commitMessage)
+	setAuthorEnv(cmd, commitAuthor)
 
+	_, err = g.run(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := g.logDiff(); err != nil {
+		return err
+	}
+
+	return err
+}
+
+func setAuthorEnv(cmd *exec.Cmd, commitAuthor *domain.CommitAuthor) {
 	if commitAuthor != nil {
 		cmd.Env = append(cmd.Env,
 			"GIT_AUTHOR_NAME="+commitAuthor.Name,
@@ -90,17 +105,23 @@ func (g *Git) Commit(commitAuthor *domain.CommitAuthor, commitMessage string) er
 			"GIT_COMMITTER_EMAIL="+commitAuthor.Email,
 		)
 	}
+}
 
-	_, err = g.run(cmd)
+// ChangedFiles returns the files changed in the last commit
+func (g *Git) ChangedFiles() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "HEAD~1", "HEAD")
+	stdOut, err := g.run(cmd)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := g.logDiff(); err != nil {
-		return err
+	var files []string
+	for _, file := range strings.Split(stdOut, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
 	}
-
-	return err
+	return files, nil
 }
 
 func (g *Git) logDiff() error {
@@ -108,17 +129,22 @@ func (g *Git) logDiff() error {
 		return nil
 	}
 
-	cmd := exec.Command("git", "diff", "HEAD~1")
-	stdout, err := g.run(cmd)
+	diff, err := g.LastCommitDiff()
 	if err != nil {
 		return err
 	}
 
-	log.Debug(stdout)
+	log.Debug(diff)
 
 	return nil
 }
 
+// LastCommitDiff returns the diff of the last commit
+func (g *Git) LastCommitDiff() (string, error) {
+	cmd := exec.Command("git", "diff", "HEAD~1")
+	return g.run(cmd)
+}
+
 // BranchExist checks if the new branch exists
 func (g *Git) BranchExist(remoteName, branchName string) (bool, error) {
 	cmd := exec.Command("git", "ls-remote", "-q", "-h")
@@ -136,9 +162,126 @@ func (g *Git) Push(remoteName string) error {
 	return err
 }
 
+// PushRef pushes the committed changes to a ref of the remote, instead of the branch with the same name
+func (g *Git) PushRef(remoteName, ref string) error {
+	cmd := exec.Command("git", "push", "--no-verify", remoteName, "HEAD:"+ref)
+	_, err := g.run(cmd)
+	return err
+}
+
+// PushWithKey pushes the committed changes to the remote, authenticated with an ssh key
+func (g *Git) PushWithKey(remoteName, keyPath string) error {
+	cmd := exec.Command("git", "push", "--no-verify", remoteName, "HEAD")
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("GIT_SSH_COMMAND=ssh -i '%s' -o IdentitiesOnly=yes", strings.ReplaceAll(keyPath, "'", `'\''`)),
+	)
+	_, err := g.run(cmd)
+	return err
+}
+
 // AddRemote adds a new remote
 func (g *Git) AddRemote(name, url string) error {
 	cmd := exec.Command("git", "remote", "add", name, url)
 	_, err := g.run(cmd)
 	return err
 }
+
+// SetRemoteURL changes the url of a remote
+func (g *Git) SetRemoteURL(name, url string) error {
+	cmd := exec.Command("git", "remote", "set-url", name, url)
+	_, err := g.run(cmd)
+	return err
+}
+
+// MergeBranch merges a remote branch into the current branch and returns the files in conflict, if any
+func (g *Git) MergeBranch(commitAuthor *domain.CommitAuthor, remoteName, branchName string) ([]string, error) {
+	args := []string{"fetch", remoteName, fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branchName, remoteName, branchName)}
+
+	// A common ancestor is needed to be able to merge
+	shallow, err := g.run(exec.Command("git", "rev-parse", "--is-shallow-repository"))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(shallow) == "true" {
+		args = append(args, "--unshallow")
+	}
+
+	_, err = g.run(exec.Command("git", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "merge", "--no-edit", "--no-verify", fmt.Sprintf("%s/%s", remoteName, branchName))
+	setAuthorEnv(cmd, commitAuthor)
+	_, mergeErr := g.run(cmd)
+	if mergeErr == nil {
+		return nil, nil
+	}
+
+	conflicts, err := g.conflictedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		return nil, mergeErr
+	}
+	return conflicts, nil
+}
+
+func (g *Git) conflictedFiles() ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	stdOut, err := g.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(stdOut, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// ResolveConflict resolves a conflict in a file by using one side of the merge
+func (g *Git) ResolveConflict(file string, resolution domain.ConflictResolution) error {
+	var side string
+	switch resolution {
+	case domain.ConflictResolutionOurs:
+		side = "--ours"
+	case domain.ConflictResolutionTheirs:
+		side = "--theirs"
+	default:
+		return errors.New("unknown conflict resolution")
+	}
+
+	cmd := exec.Command("git", "checkout", side, "--", file)
+	if _, err := g.run(cmd); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "add", "--", file)
+	_, err := g.run(cmd)
+	return err
+}
+
+// FinishMerge stages all changes and commits the merge
+func (g *Git) FinishMerge(commitAuthor *domain.CommitAuthor) error {
+	cmd := exec.Command("git", "add", "--all")
+	if _, err := g.run(cmd); err != nil {
+		return err
+	}
+
+	cmd = exec.Command("git", "commit", "--no-edit", "--no-verify")
+	setAuthorEnv(cmd, commitAuthor)
+	_, err := g.run(cmd)
+	return err
+}
+
+// AbortMerge aborts an ongoing merge
+func (g *Git) AbortMerge() error {
+	cmd := exec.Command("git", "merge", "--abort")
+	_, err := g.run(cmd)
+	return err
+}
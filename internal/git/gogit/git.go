@@ -12,6 +12,7 @@ import (
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -128,42 +129,112 @@ func (g *Git) Commit(commitAuthor *domain.CommitAuthor, commitMessage string) er
 	return nil
 }
 
+// ChangedFiles returns the files changed in the last commit
+func (g *Git) ChangedFiles() ([]string, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := parentTree.Diff(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		} else {
+			files = append(files, change.From.Name)
+		}
+	}
+	return files, nil
+}
+
 func (g *Git) logDiff(aHash, bHash plumbing.Hash) error {
 	if !log.IsLevelEnabled(log.DebugLevel) {
 		return nil
 	}
 
-	aCommit, err := g.repo.CommitObject(aHash)
+	diff, err := g.diff(aHash, bHash)
 	if err != nil {
 		return err
 	}
+	log.Debug(diff)
+
+	return nil
+}
+
+// LastCommitDiff returns the diff of the last commit
+func (g *Git) LastCommitDiff() (string, error) {
+	head, err := g.repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", err
+	}
+
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return "", err
+	}
+
+	return g.diff(parent.Hash, commit.Hash)
+}
+
+func (g *Git) diff(aHash, bHash plumbing.Hash) (string, error) {
+	aCommit, err := g.repo.CommitObject(aHash)
+	if err != nil {
+		return "", err
+	}
 	aTree, err := aCommit.Tree()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	bCommit, err := g.repo.CommitObject(bHash)
 	if err != nil {
-		return err
+		return "", err
 	}
 	bTree, err := bCommit.Tree()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	patch, err := aTree.Patch(bTree)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	buf := &bytes.Buffer{}
 	err = patch.Encode(buf)
 	if err != nil {
-		return err
+		return "", err
 	}
-	log.Debug(buf.String())
 
-	return nil
+	return buf.String(), nil
 }
 
 // BranchExist checks if the new branch exists
@@ -192,6 +263,32 @@ func (g *Git) Push(remoteName string) error {
 	})
 }
 
+// PushRef pushes the committed changes to a ref of the remote, instead of the branch with the same name
+func (g *Git) PushRef(remoteName, ref string) error {
+	head, err := g.repo.Head()
+	if err != nil {
+		return err
+	}
+
+	return g.repo.Push(&git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(head.Name().String() + ":" + ref)},
+	})
+}
+
+// PushWithKey pushes the committed changes to the remote, authenticated with an ssh key
+func (g *Git) PushWithKey(remoteName, keyPath string) error {
+	auth, err := ssh.NewPublicKeysFromFile("git", keyPath, "")
+	if err != nil {
+		return errors.Wrap(err, "could not read the ssh key")
+	}
+
+	return g.repo.Push(&git.PushOptions{
+		RemoteName: remoteName,
+		Auth:       auth,
+	})
+}
+
 // AddRemote adds a new remote
 func (g *Git) AddRemote(name, url string) error {
 	_, err := g.repo.CreateRemote(&config.RemoteConfig{
@@ -200,3 +297,17 @@ func (g *Git) AddRemote(name, url string) error {
 	})
 	return err
 }
+
+// SetRemoteURL changes the url of a remote
+func (g *Git) SetRemoteURL(name, url string) error {
+	cfg, err := g.repo.Config()
+	if err != nil {
+		return err
+	}
+	remote, ok := cfg.Remotes[name]
+	if !ok {
+		return errors.Errorf("could not find the remote %s", name)
+	}
+	remote.URLs = []string{url}
+	return g.repo.SetConfig(cfg)
+}
@@ -0,0 +1,538 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database driver
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// StatusPending is the status of repositories that have not been run yet
+const StatusPending = "pending"
+
+// Campaign describes what a run was made for
+type Campaign struct {
+	BranchName    string   `json:"branch_name"`
+	CommitMessage string   `json:"commit_message"`
+	PRTitle       string   `json:"pr_title,omitempty"`
+	CampaignURL   string   `json:"campaign_url,omitempty"`
+	DryRun        bool     `json:"dry_run,omitempty"`
+	Directory     string   `json:"directory,omitempty"` // The working directory of the run
+	Arguments     []string `json:"arguments,omitempty"` // The arguments of the run command, without secrets, the run can be made again with
+}
+
+// Entry is a single run stored in the history
+type Entry struct {
+	ID int64 `json:"id"`
+	Campaign
+	StartedAt    time.Time                      `json:"started_at"`
+	FinishedAt   time.Time                      `json:"finished_at"` // Zero while the run is ongoing
+	Repositories []multigitter.RepositoryReport `json:"repositories"`
+}
+
+// Running returns if the run is ongoing
+func (e Entry) Running() bool {
+	return e.FinishedAt.IsZero()
+}
+
+// migrations are run in order on a database, each one only once. The number of run migrations is stored as the user_version of the database
+var migrations = []string{
+	`CREATE TABLE runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		branch_name TEXT NOT NULL,
+		commit_message TEXT NOT NULL,
+		pr_title TEXT NOT NULL,
+		campaign_url TEXT NOT NULL,
+		dry_run BOOLEAN NOT NULL,
+		started_at INTEGER NOT NULL,
+		finished_at INTEGER NOT NULL
+	);
+	CREATE INDEX runs_branch_name ON runs (branch_name);
+	CREATE TABLE repositories (
+		run_id INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+		repository TEXT NOT NULL,
+		status TEXT NOT NULL,
+		report TEXT NOT NULL
+	);
+	CREATE INDEX repositories_run_id ON repositories (run_id);
+	CREATE INDEX repositories_repository ON repositories (repository);`,
+
+	`ALTER TABLE runs ADD COLUMN directory TEXT NOT NULL DEFAULT '';
+	ALTER TABLE runs ADD COLUMN arguments TEXT NOT NULL DEFAULT '[]';
+	ALTER TABLE repositories ADD COLUMN log TEXT NOT NULL DEFAULT '';
+	DROP INDEX repositories_run_id;
+	CREATE UNIQUE INDEX repositories_run_id_repository ON repositories (run_id, repository);`,
+}
+
+// DB is a history database. Multiple processes can use the same database at the same time
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens the history database at the path, and creates it if it does not exist
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "could not create the history directory")
+	}
+
+	// Writes of other processes are waited on, instead of failing directly because the database is locked
+	query := url.Values{"_pragma": {"busy_timeout(10000)", "journal_mode(wal)", "foreign_keys(1)"}, "_txlock": {"immediate"}}
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?"+query.Encode())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open the history database")
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "could not migrate the history database")
+	}
+
+	return &DB{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return errors.Errorf("the database is of version %d, but this version of multi-gitter only supports up to version %d", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Add adds a run to the history, and sets the id of it
+func (db *DB) Add(ctx context.Context, entry *Entry) error {
+	arguments, err := json.Marshal(entry.Arguments)
+	if err != nil {
+		return err
+	}
+	var finishedAt int64
+	if !entry.FinishedAt.IsZero() {
+		finishedAt = entry.FinishedAt.UnixNano()
+	}
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO runs (branch_name, commit_message, pr_title, campaign_url, dry_run, directory, arguments, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.BranchName, entry.CommitMessage, entry.PRTitle, entry.CampaignURL, entry.DryRun, entry.Directory, string(arguments),
+		entry.StartedAt.UnixNano(), finishedAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "could not add the run")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range entry.Repositories {
+		if err := putRepository(ctx, tx, id, repo, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	entry.ID = id
+	return nil
+}
+
+// Update sets the results of repositories in a run, and appends to their logs. The run is marked as finished if finishedAt is set
+func (db *DB) Update(ctx context.Context, runID int64, repos []multigitter.RepositoryReport, logs map[string]string, finishedAt time.Time) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, repo := range repos {
+		if err := putRepository(ctx, tx, runID, repo, logs[repo.Repository]); err != nil {
+			return err
+		}
+	}
+
+	if !finishedAt.IsZero() {
+		if _, err := tx.ExecContext(ctx, "UPDATE runs SET finished_at = ? WHERE id = ?", finishedAt.UnixNano(), runID); err != nil {
+			return errors.Wrap(err, "could not finish the run")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// putRepository sets the result of a repository in a run, and appends to its log
+func putRepository(ctx context.Context, tx *sql.Tx, runID int64, repo multigitter.RepositoryReport, log string) error {
+	report, err := json.Marshal(repo)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO repositories (run_id, repository, status, report, log) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (run_id, repository) DO UPDATE SET status = excluded.status, report = excluded.report, log = log || excluded.log`,
+		runID, repo.Repository, repo.Status, string(report), log,
+	)
+	return errors.Wrapf(err, "could not set the result of %s", repo.Repository)
+}
+
+// Filter limits the runs, and repositories of each run, that are listed
+type Filter struct {
+	Repository string    // Only include this repository, in the format "owner/name"
+	BranchName string    // Only include runs with this branch
+	Since      time.Time // Only include runs started after this time
+}
+
+const runColumns = "id, branch_name, commit_message, pr_title, campaign_url, dry_run, directory, arguments, started_at, finished_at"
+
+// Runs lists the runs matching the filter, oldest first. Runs without any matching repository are not included
+func (db *DB) Runs(ctx context.Context, filter Filter) ([]Entry, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.BranchName != "" {
+		conditions = append(conditions, "branch_name = ?")
+		args = append(args, filter.BranchName)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "started_at >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if filter.Repository != "" {
+		conditions = append(conditions, "id IN (SELECT run_id FROM repositories WHERE repository = ?)")
+		args = append(args, filter.Repository)
+	}
+
+	query := "SELECT " + runColumns + " FROM runs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY started_at, id"
+
+	entries, err := db.queryRuns(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Repositories, err = db.repositories(ctx, entries[i].ID, filter.Repository)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// ErrNotFound is returned when a run, or a repository in it, does not exist
+var ErrNotFound = errors.New("the run could not be found")
+
+// Run gets a single run
+func (db *DB) Run(ctx context.Context, id int64) (Entry, error) {
+	entries, err := db.queryRuns(ctx, "SELECT "+runColumns+" FROM runs WHERE id = ?", id)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, ErrNotFound
+	}
+
+	entry := entries[0]
+	entry.Repositories, err = db.repositories(ctx, id, "")
+	return entry, err
+}
+
+// Log gets the log of a repository in a run
+func (db *DB) Log(ctx context.Context, runID int64, repository string) (string, error) {
+	var log string
+	err := db.db.QueryRowContext(ctx, "SELECT log FROM repositories WHERE run_id = ? AND repository = ?", runID, repository).Scan(&log)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return log, err
+}
+
+func (db *DB) queryRuns(ctx context.Context, query string, args ...interface{}) ([]Entry, error) {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the runs")
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var arguments string
+		var startedAt, finishedAt int64
+		if err := rows.Scan(
+			&entry.ID, &entry.BranchName, &entry.CommitMessage, &entry.PRTitle, &entry.CampaignURL, &entry.DryRun,
+			&entry.Directory, &arguments, &startedAt, &finishedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(arguments), &entry.Arguments); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the arguments of run %d", entry.ID)
+		}
+		entry.StartedAt = time.Unix(0, startedAt)
+		if finishedAt != 0 {
+			entry.FinishedAt = time.Unix(0, finishedAt)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// repositories gets the results of the repositories in a run. All repositories are included if repository is empty
+func (db *DB) repositories(ctx context.Context, runID int64, repository string) ([]multigitter.RepositoryReport, error) {
+	rows, err := db.db.QueryContext(ctx,
+		"SELECT report FROM repositories WHERE run_id = ? AND (? = '' OR repository = ?) ORDER BY rowid",
+		runID, repository, repository,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the repositories of the run")
+	}
+	defer rows.Close()
+
+	var repos []multigitter.RepositoryReport
+	for rows.Next() {
+		var report string
+		if err := rows.Scan(&report); err != nil {
+			return nil, err
+		}
+		var repo multigitter.RepositoryReport
+		if err := json.Unmarshal([]byte(report), &repo); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the result of run %d", runID)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+// Recorder records a run in the history database while it progresses. It is used as the report sink of a run,
+// and as a log hook to record the logs of each repository
+type Recorder struct {
+	Path     string
+	Campaign Campaign
+	// Censor removes sensitive data, such as tokens, from the logs before they are recorded. Log hooks are fired
+	// before the logs are formatted, and are therefore not censored by the formatter
+	Censor func(string) string
+
+	startedAt time.Time
+
+	lock     sync.Mutex
+	db       *DB
+	id       int64
+	logs     map[string]*bytes.Buffer // Logs of each repository that have not been written yet
+	finished bool
+}
+
+// NewRecorder creates a recorder of a run that starts now
+func NewRecorder(path string, campaign Campaign) *Recorder {
+	return &Recorder{
+		Path:      path,
+		Campaign:  campaign,
+		startedAt: time.Now(),
+		logs:      map[string]*bytes.Buffer{},
+	}
+}
+
+func (r *Recorder) String() string {
+	return "history " + r.Path
+}
+
+// StartRun adds the run to the history database, with all repositories pending
+func (r *Recorder) StartRun(ctx context.Context, repositories []string) error {
+	db, err := Open(r.Path)
+	if err != nil {
+		return err
+	}
+
+	entry := Entry{
+		Campaign:     r.Campaign,
+		StartedAt:    r.startedAt,
+		Repositories: make([]multigitter.RepositoryReport, len(repositories)),
+	}
+	for i, repo := range repositories {
+		entry.Repositories[i] = multigitter.RepositoryReport{Repository: repo, Status: StatusPending}
+	}
+	if err := db.Add(ctx, &entry); err != nil {
+		db.Close()
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.db = db
+	r.id = entry.ID
+	return nil
+}
+
+// RepositoryFinished sets the result, and log, of a repository
+func (r *Recorder) RepositoryFinished(ctx context.Context, result multigitter.RepositoryReport) error {
+	r.lock.Lock()
+	db, id := r.db, r.id
+	logs := r.takeLogs(result.Repository)
+	r.lock.Unlock()
+
+	if db == nil {
+		return nil
+	}
+	return db.Update(ctx, id, []multigitter.RepositoryReport{result}, logs, time.Time{})
+}
+
+// WriteReport sets the final results of the run, and marks it as finished
+func (r *Recorder) WriteReport(ctx context.Context, report multigitter.Report) error {
+	r.lock.Lock()
+	db, id := r.db, r.id
+	logs := r.takeLogs()
+	r.db = nil
+	r.finished = true
+	r.lock.Unlock()
+
+	// The run has not been started if the repositories were run by the workers of a queue
+	if db == nil {
+		var err error
+		db, err = Open(r.Path)
+		if err != nil {
+			return err
+		}
+		entry := Entry{Campaign: r.Campaign, StartedAt: r.startedAt}
+		if err := db.Add(ctx, &entry); err != nil {
+			db.Close()
+			return err
+		}
+		id = entry.ID
+	}
+	defer db.Close()
+
+	return db.Update(ctx, id, report.Repositories, logs, time.Now())
+}
+
+// takeLogs returns the logs, that have not been written yet, of the repositories. All are returned if no repository is given.
+// The lock has to be held
+func (r *Recorder) takeLogs(repositories ...string) map[string]string {
+	if len(repositories) == 0 {
+		for repo := range r.logs {
+			repositories = append(repositories, repo)
+		}
+	}
+
+	logs := map[string]string{}
+	for _, repo := range repositories {
+		if buf, ok := r.logs[repo]; ok {
+			logs[repo] = buf.String()
+			delete(r.logs, repo)
+		}
+	}
+	return logs
+}
+
+// Levels returns the log levels that are recorded
+func (r *Recorder) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire records a log entry, if it is about a repository
+func (r *Recorder) Fire(entry *log.Entry) error {
+	repo, ok := entry.Data["repo"].(string)
+	if !ok {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.finished {
+		return nil
+	}
+	buf, ok := r.logs[repo]
+	if !ok {
+		buf = &bytes.Buffer{}
+		r.logs[repo] = buf
+	}
+	message := entry.Message
+	if r.Censor != nil {
+		message = r.Censor(message)
+	}
+	fmt.Fprintf(buf, "%s %s %s\n", entry.Time.Format("15:04:05"), strings.ToUpper(entry.Level.String()), message)
+	return nil
+}
+
+// Write writes a human readable version of the runs
+func Write(w io.Writer, entries []Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No runs found")
+		return
+	}
+
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		state := ""
+		if entry.Running() {
+			state += " (running)"
+		}
+		if entry.DryRun {
+			state += " (dry run)"
+		}
+		fmt.Fprintf(w, "%s %s: %s%s\n", entry.StartedAt.Local().Format("2006-01-02 15:04:05"), entry.BranchName, entry.CommitMessage, state)
+		if entry.CampaignURL != "" {
+			fmt.Fprintf(w, "  campaign: %s\n", entry.CampaignURL)
+		}
+
+		for _, repo := range entry.Repositories {
+			switch {
+			case repo.Error != "":
+				fmt.Fprintf(w, "  %s: %s: %s\n", repo.Repository, repo.Status, repo.Error)
+			case repo.Reason != "":
+				fmt.Fprintf(w, "  %s: %s: %s\n", repo.Repository, repo.Status, repo.Reason)
+			case repo.PullRequest != "":
+				fmt.Fprintf(w, "  %s: %s: %s\n", repo.Repository, repo.Status, repo.PullRequest)
+			default:
+				fmt.Fprintf(w, "  %s: %s\n", repo.Repository, repo.Status)
+			}
+		}
+	}
+}
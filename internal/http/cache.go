@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type listingCacheKey struct{}
+
+// WithListingCache marks all requests made with the context as part of listing repositories,
+// which allows their responses to be cached
+func WithListingCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, listingCacheKey{}, true)
+}
+
+func isListing(ctx context.Context) bool {
+	listing, _ := ctx.Value(listingCacheKey{}).(bool)
+	return listing
+}
+
+// cacheEntry is a cached response, stored as one file per request
+type cacheEntry struct {
+	URL      string           `json:"url"`
+	Time     time.Time        `json:"time"`
+	Response RecordedResponse `json:"response"`
+}
+
+// NewCache creates a new cache that stores the responses of repository listings in the directory. The credentials are
+// everything the client authenticates with, such as the token and the impersonated user, and are part of the key of each
+// response. The headers of the requests can not be used, since the credentials are often added by a later transport
+func NewCache(dir string, ttl time.Duration, credentials ...string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithMessage(err, "could not create the cache directory")
+	}
+
+	hash := sha256.New()
+	for _, credential := range credentials {
+		fmt.Fprintf(hash, "%d:%s\n", len(credential), credential)
+	}
+	return &Cache{
+		dir:         dir,
+		ttl:         ttl,
+		credentials: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// Cache stores the responses of repository listings on disk, so that they can be reused by later runs
+// until they are older than the ttl. Only successful GET requests made with a context marked with
+// WithListingCache are cached. Caches created with different credentials never share responses,
+// and the credentials themselves are never written to disk
+type Cache struct {
+	dir         string
+	ttl         time.Duration
+	credentials string // A hash of the credentials of the client
+}
+
+// Middleware wraps a round tripper so that the responses of repository listings are cached
+func (c *Cache) Middleware(rt http.RoundTripper) http.RoundTripper {
+	return cachingRoundTripper{
+		cache: c,
+		next:  rt,
+	}
+}
+
+// filename gets the file a request is cached in, from the request and the credentials of the cache
+func (c *Cache) filename(req *http.Request) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", req.Method, req.URL.String(), c.credentials)
+	return filepath.Join(c.dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+func (c *Cache) load(filename string) (cacheEntry, bool) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		log.Debugf("Could not parse cached response %s: %s", filename, err)
+		return cacheEntry{}, false
+	}
+	if time.Since(entry.Time) > c.ttl {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *Cache) save(filename string, entry cacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0600)
+}
+
+type cachingRoundTripper struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+func (r cachingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet || !isListing(req.Context()) {
+		return next.RoundTrip(req)
+	}
+
+	filename := r.cache.filename(req)
+	if entry, ok := r.cache.load(filename); ok {
+		log.WithField("url", entry.URL).Debug("Using cached response")
+		return recordedResponse(req, entry.Response), nil
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.cache.save(filename, cacheEntry{
+		URL:  sanitizeURL(req.URL),
+		Time: time.Now(),
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(resp.Header),
+			Body:       string(body),
+		},
+	})
+	if err != nil {
+		log.Warnf("Could not cache response: %s", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,80 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, "response %d to %s %s", calls, r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-gitter-cache-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	do := func(ctx context.Context, cache *internalHTTP.Cache, method, path, token string) string {
+		client := &http.Client{Transport: cache.Middleware(http.DefaultTransport)}
+		req, err := http.NewRequestWithContext(ctx, method, server.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "token "+token)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	cache, err := internalHTTP.NewCache(dir, time.Hour, "secret")
+	require.NoError(t, err)
+	otherCache, err := internalHTTP.NewCache(dir, time.Hour, "other-secret")
+	require.NoError(t, err)
+	listing := internalHTTP.WithListingCache(context.Background())
+
+	assert.Equal(t, "response 1 to GET /repos", do(listing, cache, "GET", "/repos", "secret"))
+	assert.Equal(t, "response 1 to GET /repos", do(listing, cache, "GET", "/repos", "secret"))
+
+	// Other credentials, requests that are not listings and unsuccessful responses are never cached
+	assert.Equal(t, "response 2 to GET /repos", do(listing, otherCache, "GET", "/repos", "other-secret"))
+	assert.Equal(t, "response 3 to GET /repos", do(context.Background(), cache, "GET", "/repos", "secret"))
+	assert.Equal(t, "response 4 to POST /repos", do(listing, cache, "POST", "/repos", "secret"))
+	assert.Equal(t, "response 5 to GET /missing", do(listing, cache, "GET", "/missing", "secret"))
+	assert.Equal(t, "response 6 to GET /missing", do(listing, cache, "GET", "/missing", "secret"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), "secret")
+	}
+
+	// The cache is shared between runs, until it is too old
+	cache, err = internalHTTP.NewCache(dir, time.Hour, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "response 1 to GET /repos", do(listing, cache, "GET", "/repos", "secret"))
+
+	cache, err = internalHTTP.NewCache(dir, time.Nanosecond, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "response 7 to GET /repos", do(listing, cache, "GET", "/repos", "secret"))
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const redacted = "REDACTED"
+
+// Headers and query parameters that might contain credentials, and are never written to disk
+var (
+	sensitiveHeaders = []string{
+		"Authorization",
+		"Private-Token",
+		"Job-Token",
+		"Cookie",
+		"Set-Cookie",
+		"X-Gitea-Otp",
+	}
+	sensitiveQueryParams = []string{
+		"token",
+		"access_token",
+		"private_token",
+	}
+	// Fields of JSON bodies that contain secrets. Fields ending with "_token", "_secret" or "_password" are also redacted.
+	// Ambiguous names, such as "key" and "value", are kept, since other requests are often built from them
+	sensitiveBodyFields = []string{
+		"token",
+		"password",
+		"secret",
+		"private_key",
+		"encrypted_value",
+	}
+	sensitiveBodyFieldSuffixes = []string{
+		"_token",
+		"_secret",
+		"_password",
+	}
+)
+
+// Interaction is a recorded request together with its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a sanitized http request
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a sanitized http response
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+func (r RecordedRequest) key() string {
+	return r.Method + " " + r.URL + "\n" + r.Body
+}
+
+// NewRecorder creates a new recorder that writes all interactions to the directory
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithMessage(err, "could not create the recording directory")
+	}
+	return &Recorder{
+		dir: dir,
+	}, nil
+}
+
+// Recorder records sanitized http interactions into a directory, one file per interaction
+type Recorder struct {
+	dir string
+
+	mutex sync.Mutex
+	count int
+}
+
+// Middleware wraps a round tripper so that all its interactions are recorded
+func (rec *Recorder) Middleware(rt http.RoundTripper) http.RoundTripper {
+	return recordingRoundTripper{
+		recorder: rec,
+		next:     rt,
+	}
+}
+
+func (rec *Recorder) save(interaction Interaction) error {
+	b, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	rec.count++
+	filename := filepath.Join(rec.dir, fmt.Sprintf("%04d.json", rec.count))
+	return ioutil.WriteFile(filename, b, 0600)
+}
+
+type recordingRoundTripper struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (r recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.recorder.save(Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    sanitizeURL(req.URL),
+			Header: sanitizeHeader(req.Header),
+			Body:   sanitizeBody(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(resp.Header),
+			Body:       sanitizeBody(respBody),
+		},
+	})
+	if err != nil {
+		log.Warnf("Could not record http interaction: %s", err)
+	}
+
+	return resp, nil
+}
+
+// NewReplayer creates a new replayer from interactions previously recorded into the directory
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no recorded interactions found in %s", dir)
+	}
+	sort.Strings(files)
+
+	replayer := &Replayer{}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(b, &interaction); err != nil {
+			return nil, errors.WithMessagef(err, "could not parse recorded interaction %s", file)
+		}
+		replayer.interactions = append(replayer.interactions, interaction)
+	}
+	replayer.used = make([]bool, len(replayer.interactions))
+
+	return replayer, nil
+}
+
+// Replayer responds to requests with previously recorded interactions, without any network access.
+// Requests are matched by method, url and body. Every recorded interaction is only used once,
+// in the order they were recorded
+type Replayer struct {
+	interactions []Interaction
+
+	mutex sync.Mutex
+	used  []bool
+}
+
+// Middleware replaces a round tripper with the replayer
+func (rep *Replayer) Middleware(_ http.RoundTripper) http.RoundTripper {
+	return rep
+}
+
+// RoundTrip responds with the first unused recorded interaction matching the request
+func (rep *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    sanitizeURL(req.URL),
+		Body:   sanitizeBody(reqBody),
+	}
+
+	rep.mutex.Lock()
+	defer rep.mutex.Unlock()
+
+	for i, interaction := range rep.interactions {
+		if rep.used[i] || interaction.Request.key() != recorded.key() {
+			continue
+		}
+		rep.used[i] = true
+
+		return recordedResponse(req, interaction.Response), nil
+	}
+
+	return nil, errors.Errorf("no recorded interaction matches %s %s", recorded.Method, recorded.URL)
+}
+
+// recordedResponse creates a response to the request from a recorded response
+func recordedResponse(req *http.Request, resp RecordedResponse) *http.Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
+
+// readBody reads the whole body and replaces it with a new reader with the same content
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	_ = (*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func sanitizeHeader(header http.Header) http.Header {
+	sanitized := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if sanitized.Get(name) != "" {
+			sanitized.Set(name, redacted)
+		}
+	}
+	return sanitized
+}
+
+func sanitizeURL(u *url.URL) string {
+	sanitized := *u
+	query := sanitized.Query()
+	for _, name := range sensitiveQueryParams {
+		if query.Get(name) != "" {
+			query.Set(name, redacted)
+		}
+	}
+	sanitized.RawQuery = query.Encode()
+	sanitized.User = nil
+	return sanitized.String()
+}
+
+// sanitizeBody redacts the fields of a JSON body that might contain secrets. Other bodies are kept as they are
+func sanitizeBody(body []byte) string {
+	var v interface{}
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return string(body)
+	}
+	b, err := json.Marshal(sanitizeJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+func sanitizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if isSensitiveField(name) && value != nil {
+				v[name] = redacted
+			} else {
+				v[name] = sanitizeJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = sanitizeJSON(value)
+		}
+	}
+	return v
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range sensitiveBodyFields {
+		if name == field {
+			return true
+		}
+	}
+	for _, suffix := range sensitiveBodyFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
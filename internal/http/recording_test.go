@@ -0,0 +1,119 @@
+package http_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, "response %d to %s %s", calls, r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-gitter-recording-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := internalHTTP.NewRecorder(dir)
+	require.NoError(t, err)
+	recordClient := &http.Client{Transport: recorder.Middleware(http.DefaultTransport)}
+
+	do := func(client *http.Client, method, path, body string) string {
+		req, err := http.NewRequest(method, server.URL+path+"?access_token=secret", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "token secret")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "response 1 to GET /repos", do(recordClient, "GET", "/repos", ""))
+	assert.Equal(t, "response 2 to POST /pulls", do(recordClient, "POST", "/pulls", `{"title":"test"}`))
+	assert.Equal(t, "response 3 to GET /repos", do(recordClient, "GET", "/repos", ""))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), "secret")
+	}
+
+	replayer, err := internalHTTP.NewReplayer(dir)
+	require.NoError(t, err)
+	replayClient := &http.Client{Transport: replayer.Middleware(http.DefaultTransport)}
+
+	// Requests are matched independently of the token, and the recorded order is kept for identical requests
+	assert.Equal(t, "response 2 to POST /pulls", do(replayClient, "POST", "/pulls", `{"title":"test"}`))
+	assert.Equal(t, "response 1 to GET /repos", do(replayClient, "GET", "/repos", ""))
+	assert.Equal(t, "response 3 to GET /repos", do(replayClient, "GET", "/repos", ""))
+	assert.Equal(t, 3, calls)
+
+	_, err = replayClient.Get(server.URL + "/repos")
+	assert.Error(t, err, "all recorded interactions should already be used")
+}
+
+func TestRecordRedactsBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"key":"PROJ","token":"response-secret","protected":true},{"name":"other","encrypted_value":"encrypted-secret","deploy_token":"deploy-secret"}]`)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-gitter-recording-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := internalHTTP.NewRecorder(dir)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder.Middleware(http.DefaultTransport)}
+
+	body := `{"name":"DEPLOY","encrypted_value":"variable-secret","key_id":"1","variable_type":"env_var"}`
+	resp, err := client.Post(server.URL+"/repos/owner/name/actions/secrets/DEPLOY", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	// The response returned to the client is not changed
+	assert.Contains(t, string(b), "response-secret")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	recording, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(recording), "variable-secret")
+	assert.NotContains(t, string(recording), "response-secret")
+	assert.NotContains(t, string(recording), "encrypted-secret")
+	assert.NotContains(t, string(recording), "deploy-secret")
+	assert.Contains(t, string(recording), "env_var")
+	// Fields that are not always secret, such as the keys of projects, are kept
+	assert.Contains(t, string(recording), `\"key\":\"PROJ\"`)
+
+	// Requests with secrets in the body can still be replayed
+	replayer, err := internalHTTP.NewReplayer(dir)
+	require.NoError(t, err)
+	replayClient := &http.Client{Transport: replayer.Middleware(http.DefaultTransport)}
+	resp, err = replayClient.Post(server.URL+"/repos/owner/name/actions/secrets/DEPLOY", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,27 @@
+package keyring
+
+import (
+	"errors"
+)
+
+// service is the name the secrets are stored under in the keychain of the operating system
+const service = "multi-gitter"
+
+// ErrNotFound is returned when no secret is stored for the account
+var ErrNotFound = errors.New("no secret is stored in the keyring")
+
+// Keyring stores secrets, by the name of an account
+type Keyring interface {
+	// Get gets the secret of the account, or ErrNotFound if there is none
+	Get(account string) (string, error)
+	// Set stores the secret of the account, replacing any existing secret
+	Set(account, secret string) error
+	// Delete removes the secret of the account, or returns ErrNotFound if there is none
+	Delete(account string) error
+}
+
+// System is the keychain of the operating system. The macOS Keychain is used through the security command,
+// the Windows Credential Manager through its API, and the Secret Service, on Linux and other systems, through secret-tool
+var System Keyring = systemKeyring{}
+
+type systemKeyring struct{}
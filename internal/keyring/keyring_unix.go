@@ -0,0 +1,52 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func (systemKeyring) Get(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// secret-tool exits without any output when there is no such secret
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) == 0 {
+			return "", ErrNotFound
+		}
+		return "", secretToolError(err)
+	}
+	return string(out), nil
+}
+
+func (systemKeyring) Set(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", fmt.Sprintf("%s (%s)", service, account), "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", secretToolError(err), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k systemKeyring) Delete(account string) error {
+	// secret-tool does not tell if there was anything to remove
+	if _, err := k.Get(account); err != nil {
+		return err
+	}
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", secretToolError(err), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func secretToolError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return errors.New("secret-tool, which is used to access the Secret Service, is not installed")
+	}
+	return err
+}
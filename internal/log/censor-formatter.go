@@ -3,6 +3,7 @@ package log
 import (
 	"bytes"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -12,6 +13,8 @@ import (
 type CensorFormatter struct {
 	CensorItems         []CensorItem
 	UnderlyingFormatter log.Formatter
+
+	lock sync.RWMutex
 }
 
 // CensorItem is something that should be censored, Sensitive will be replaced with Replacement
@@ -20,9 +23,35 @@ type CensorItem struct {
 	Replacement string
 }
 
+// AddCensorItem censors an item in all following logs, for example a token that is created during the run
+func (f *CensorFormatter) AddCensorItem(item CensorItem) {
+	if item.Sensitive == "" {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.CensorItems = append(f.CensorItems, item)
+}
+
+// Censor replaces all sensitive data in a string
+func (f *CensorFormatter) Censor(s string) string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	for _, item := range f.CensorItems {
+		s = strings.ReplaceAll(s, item.Sensitive, item.Replacement)
+	}
+	return s
+}
+
 // Format censors some data and sends the entry to the underlying formatter
 func (f *CensorFormatter) Format(entry *log.Entry) ([]byte, error) {
-	for _, s := range f.CensorItems {
+	f.lock.RLock()
+	items := f.CensorItems
+	f.lock.RUnlock()
+
+	for _, s := range items {
 		entry.Message = strings.ReplaceAll(entry.Message, s.Sensitive, s.Replacement)
 
 		for key := range entry.Data {
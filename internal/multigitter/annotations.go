@@ -0,0 +1,83 @@
+package multigitter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// CheckRunCreator is a version controller that can publish a check run, with annotations, on the last commit of a branch
+type CheckRunCreator interface {
+	CreateCheckRun(ctx context.Context, repo domain.Repository, branch string, run domain.CheckRun) error
+}
+
+// annotationsCheckName is the name of the check run the annotations of the script are published as
+const annotationsCheckName = "multi-gitter"
+
+// annotationLine is a single line of the annotations file written by the script
+type annotationLine struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// readAnnotations reads the annotations file written by the script, where each line is a JSON object
+// with the path, line, message and severity. No annotations are returned if the file is empty or does not exist
+func readAnnotations(path string) ([]domain.Annotation, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var annotations []domain.Annotation
+	scanner := bufio.NewScanner(f)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var line annotationLine
+		if err := json.Unmarshal([]byte(text), &line); err != nil {
+			return nil, errors.Wrapf(err, "could not parse line %d of the annotations", lineNr)
+		}
+		if line.Path == "" || line.Message == "" {
+			return nil, errors.Errorf("line %d of the annotations is missing a path or message", lineNr)
+		}
+		severity, err := domain.ParseAnnotationSeverity(line.Severity)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse line %d of the annotations", lineNr)
+		}
+
+		annotations = append(annotations, domain.Annotation{
+			Path:     line.Path,
+			Line:     line.Line,
+			Message:  line.Message,
+			Severity: severity,
+		})
+	}
+	return annotations, scanner.Err()
+}
+
+// publishAnnotations publishes the annotations as a check run on the last commit of the branch
+func (r *Runner) publishAnnotations(ctx context.Context, repo domain.Repository, branch string, annotations []domain.Annotation) error {
+	vc, ok := r.VersionController.(CheckRunCreator)
+	if !ok {
+		return errors.New("the platform does not support publishing annotations")
+	}
+
+	return vc.CreateCheckRun(ctx, repo, branch, domain.CheckRun{
+		Name:        annotationsCheckName,
+		Annotations: annotations,
+	})
+}
@@ -0,0 +1,160 @@
+package multigitter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Archiver is a version controller that can archive and unarchive repositories
+type Archiver interface {
+	IsArchived(ctx context.Context, repo domain.Repository) (bool, error)
+	SetArchived(ctx context.Context, repo domain.Repository, archived bool) error
+}
+
+// ArchivedRepositoryLister is a version controller that does not include archived repositories when fetching repositories,
+// but is able to fetch them separately
+type ArchivedRepositoryLister interface {
+	GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error)
+}
+
+// RepositoryArchiver archives, or unarchives, repositories after confirmation
+type RepositoryArchiver struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+	Input  io.Reader // Used to wait for confirmation before any repository is changed
+
+	Unarchive  bool // If set, the repositories are unarchived instead of archived
+	AssumeYes  bool // If set, no confirmation is needed
+	DryRun     bool // If set, the repositories that would be changed are only shown
+	Concurrent int
+}
+
+// Archive archives, or unarchives, all repositories that are not already in the desired state
+func (a RepositoryArchiver) Archive(ctx context.Context) error {
+	archiver, ok := a.VersionController.(Archiver)
+	if !ok {
+		return errors.New("the platform does not support archiving repositories")
+	}
+
+	repos, err := a.getRepositories(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = a.checkRepository(ctx, archiver, repos[i])
+	}, len(repos), a.Concurrent)
+
+	var toChange []int
+	for i, result := range results {
+		if result.err == nil && len(result.changes) > 0 {
+			toChange = append(toChange, i)
+		}
+	}
+
+	if a.DryRun || len(toChange) == 0 {
+		failed := printSettingsResults(a.Output, repos, results)
+		printSettingsSummary(a.Output, results, true)
+		if failed > 0 {
+			return errors.Errorf("could not get the archived state of %d repositories", failed)
+		}
+		return nil
+	}
+
+	if !a.AssumeYes {
+		confirmed, err := a.confirm(repos, toChange)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(a.Output, "Aborted, no repositories were changed")
+			return nil
+		}
+	}
+
+	runInParallel(func(i int) {
+		repo := repos[toChange[i]]
+		if a.Unarchive {
+			log.WithField("repo", repo.FullName()).Info("Unarchiving the repository")
+		} else {
+			log.WithField("repo", repo.FullName()).Info("Archiving the repository")
+		}
+		if err := archiver.SetArchived(ctx, repo, !a.Unarchive); err != nil {
+			results[toChange[i]].err = errors.Wrapf(err, "could not %s the repository", strings.ToLower(a.verb()))
+		}
+	}, len(toChange), a.Concurrent)
+
+	failed := printSettingsResults(a.Output, repos, results)
+	printSettingsSummary(a.Output, results, false)
+	if failed > 0 {
+		return errors.Errorf("could not %s %d repositories", strings.ToLower(a.verb()), failed)
+	}
+	return nil
+}
+
+// getRepositories gets the repositories that might have to be changed. Archived repositories
+// are only fetched separately if they are needed, and not included by the platform
+func (a RepositoryArchiver) getRepositories(ctx context.Context) ([]domain.Repository, error) {
+	if lister, ok := a.VersionController.(ArchivedRepositoryLister); ok && a.Unarchive {
+		repos, err := lister.GetArchivedRepositories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		repos, _ = a.Filter.filterRepositories(repos)
+		return repos, nil
+	}
+	return getRepositories(ctx, a.VersionController, a.Filter)
+}
+
+func (a RepositoryArchiver) checkRepository(ctx context.Context, archiver Archiver, repo domain.Repository) settingsResult {
+	log.WithField("repo", repo.FullName()).Debug("Fetching the archived state")
+	archived, err := archiver.IsArchived(ctx, repo)
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the archived state")}
+	}
+
+	if archived != a.Unarchive {
+		return settingsResult{}
+	}
+	return settingsResult{changes: []SettingChange{{
+		Name:    "archived",
+		Current: strconv.FormatBool(archived),
+		Desired: strconv.FormatBool(!archived),
+	}}}
+}
+
+// confirm lists the repositories that will be changed, and asks for confirmation
+func (a RepositoryArchiver) confirm(repos []domain.Repository, toChange []int) (bool, error) {
+	for _, i := range toChange {
+		fmt.Println(repos[i].FullName())
+	}
+	fmt.Printf("%s %d repositories? [y/N] ", a.verb(), len(toChange))
+
+	answer, err := bufio.NewReader(a.Input).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	fmt.Println()
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+func (a RepositoryArchiver) verb() string {
+	if a.Unarchive {
+		return "Unarchive"
+	}
+	return "Archive"
+}
@@ -0,0 +1,163 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter/query"
+)
+
+// AuditStatus is the classification of a repository in an audit
+type AuditStatus int
+
+// All audit statuses, in the order they are reported
+const (
+	AuditCompliant AuditStatus = iota
+	AuditNonCompliant
+	AuditUnknown
+)
+
+func (s AuditStatus) String() string {
+	switch s {
+	case AuditCompliant:
+		return "compliant"
+	case AuditNonCompliant:
+		return "non-compliant"
+	}
+	return "unknown"
+}
+
+// Auditor classifies repositories, either with a query or with the exit code of a script, without changing anything
+type Auditor struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	// The query that is evaluated in each repository. If not set, the script is used instead
+	Query query.Expression
+
+	// The script is compliant if it exits with 0, non-compliant if it exits with 1, and unknown otherwise
+	ScriptPath string // Must be absolute path
+	Arguments  []string
+
+	Token string
+
+	Output io.Writer
+
+	Concurrent int
+
+	CreateGit func(dir string) Git
+}
+
+type auditResult struct {
+	status AuditStatus
+	err    error // The reason the status is unknown
+}
+
+// Audit classifies all repositories and writes a report
+func (a Auditor) Audit(ctx context.Context) error {
+	repos, err := getRepositories(ctx, a.VersionController, a.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	log.Infof("Auditing %d repositories", len(repos))
+
+	results := make([]auditResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = a.auditRepository(ctx, repos[i])
+	}, len(repos), a.Concurrent)
+
+	a.printReport(repos, results)
+	return nil
+}
+
+func (a Auditor) auditRepository(ctx context.Context, repo domain.Repository) auditResult {
+	if ctx.Err() != nil {
+		return auditResult{status: AuditUnknown, err: errAborted}
+	}
+
+	log := log.WithField("repo", repo.FullName())
+	log.Info("Cloning and auditing")
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-audit-")
+	if err != nil {
+		return auditResult{status: AuditUnknown, err: err}
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cloneURL, err := repositoryURL(ctx, repo, a.Token)
+	if err != nil {
+		return auditResult{status: AuditUnknown, err: err}
+	}
+	if err := a.CreateGit(tmpDir).Clone(cloneURL, repo.DefaultBranch()); err != nil {
+		return auditResult{status: AuditUnknown, err: errors.Wrap(err, "could not clone")}
+	}
+
+	if a.Query != nil {
+		compliant, err := a.Query.Evaluate(tmpDir)
+		if err != nil {
+			return auditResult{status: AuditUnknown, err: errors.Wrap(err, "could not evaluate the query")}
+		}
+		if compliant {
+			return auditResult{status: AuditCompliant}
+		}
+		return auditResult{status: AuditNonCompliant}
+	}
+
+	cmd := exec.Command(a.ScriptPath, a.Arguments...)
+	cmd.Dir = tmpDir
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("REPOSITORY=%s", repo.FullName()),
+	)
+	out, err := cmd.CombinedOutput()
+	log.Debug(string(out))
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return auditResult{status: AuditCompliant}
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return auditResult{status: AuditNonCompliant}
+	}
+	return auditResult{status: AuditUnknown, err: transformExecError(err)}
+}
+
+// printReport writes the repositories grouped by their status, followed by the aggregated numbers
+func (a Auditor) printReport(repos []domain.Repository, results []auditResult) {
+	counts := map[AuditStatus]int{}
+	for _, result := range results {
+		counts[result.status]++
+	}
+
+	for _, status := range []AuditStatus{AuditCompliant, AuditNonCompliant, AuditUnknown} {
+		if counts[status] == 0 {
+			continue
+		}
+		fmt.Fprintf(a.Output, "%s (%d):\n", status, counts[status])
+		for i, result := range results {
+			if result.status != status {
+				continue
+			}
+			if result.err != nil {
+				fmt.Fprintf(a.Output, "  %s: %s\n", repos[i].FullName(), result.err)
+			} else {
+				fmt.Fprintf(a.Output, "  %s\n", repos[i].FullName())
+			}
+		}
+	}
+
+	percentage := 0
+	if len(results) > 0 {
+		percentage = counts[AuditCompliant] * 100 / len(results)
+	}
+	fmt.Fprintf(a.Output, "\n%d compliant, %d non-compliant, %d unknown (%d%% compliant)\n",
+		counts[AuditCompliant], counts[AuditNonCompliant], counts[AuditUnknown], percentage)
+}
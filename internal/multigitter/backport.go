@@ -0,0 +1,135 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// BranchLister is a version controller that can list the branches of a repository
+type BranchLister interface {
+	ListBranches(ctx context.Context, repo domain.Repository) ([]string, error)
+}
+
+// PullRequestPatchGetter is a version controller that can get the changes of a pull request, also after it has been merged
+type PullRequestPatchGetter interface {
+	// GetPullRequestPatch gets the unified diff of the changes of a pull request
+	GetPullRequestPatch(ctx context.Context, pr domain.PullRequest) (string, error)
+}
+
+// Backporter applies the changes of merged pull requests to other branches, such as release branches,
+// with a pull request to each of them
+type Backporter struct {
+	VersionController VersionController
+
+	Branches []string // Patterns, such as "release/*", of the branches the changes are backported to
+
+	// The runner that creates the pull requests with the backported changes, its script is not used.
+	// Its feature branch is used as the prefix of the branches of the backports, and its title is prefixed with the backported branch
+	Runner *Runner
+}
+
+// Backport creates pull requests with the changes of the merged pull requests to each matching branch of their repositories
+func (b Backporter) Backport(ctx context.Context, prs []domain.PullRequest) error {
+	patchGetter, ok := b.VersionController.(PullRequestPatchGetter)
+	if !ok {
+		return errors.New("the platform does not support backporting pull requests")
+	}
+	lister, ok := b.VersionController.(BranchLister)
+	if !ok {
+		return errors.New("the platform does not support backporting pull requests")
+	}
+
+	repos, _, err := b.Runner.getRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	reposByName := map[string]domain.Repository{}
+	for _, repo := range repos {
+		reposByName[repo.FullName()] = repo
+	}
+
+	patches := map[string]string{}
+	targets := map[string][]domain.Repository{} // The repositories to backport to, by the name of the branch
+	for _, pr := range prs {
+		repoName := pr.RepositoryName()
+		logger := log.WithField("pr", pr.String())
+
+		repo, ok := reposByName[repoName]
+		if !ok {
+			logger.Warn("Skipping backporting since the repository could not be found")
+			continue
+		}
+
+		patch, err := patchGetter.GetPullRequestPatch(ctx, pr)
+		if err != nil {
+			return errors.Wrapf(err, "could not get the changes of %s", pr.String())
+		}
+		if strings.TrimSpace(patch) == "" {
+			logger.Info("Skipping backporting since the pull request does not contain any changes")
+			continue
+		}
+		patches[repoName] = patch
+
+		branches, err := lister.ListBranches(ctx, repo)
+		if err != nil {
+			return errors.Wrapf(err, "could not list the branches of %s", repoName)
+		}
+		for _, branch := range branches {
+			if branch != repo.DefaultBranch() && matchesBranch(b.Branches, branch) {
+				targets[branch] = append(targets[branch], repo)
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		log.Info("No branches to backport to")
+		return nil
+	}
+
+	branches := make([]string, 0, len(targets))
+	for branch := range targets {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	for _, branch := range branches {
+		log.Infof("Backporting to %s in %d repositories", branch, len(targets[branch]))
+
+		runner := *b.Runner
+		runner.repositories = targets[branch]
+		runner.OnlyRepositories = nil
+		runner.BaseBranch = branch
+		runner.FeatureBranch = backportBranchName(b.Runner.FeatureBranch, branch)
+		runner.PullRequestTitle = fmt.Sprintf("[%s] %s", branch, b.Runner.PullRequestTitle)
+		runner.changer = func(dir string, repo domain.Repository) error {
+			return applyPatch(dir, patches[repo.FullName()])
+		}
+		if err := runner.Run(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchesBranch checks if the branch matches any of the patterns
+func matchesBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// backportBranchName returns the name of the branch of the backport to a branch
+func backportBranchName(featureBranch, branch string) string {
+	return featureBranch + "-backport-" + strings.ReplaceAll(branch, "/", "-")
+}
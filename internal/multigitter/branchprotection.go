@@ -0,0 +1,113 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// BranchProtector is a version controller that can protect branches
+type BranchProtector interface {
+	// GetBranchProtection gets the current protection of the branch of the rule, nil is returned if the branch is not protected.
+	// A domain.SkipError is returned if the rule can't be applied to the repository
+	GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error)
+	// ProtectBranch makes the protection of the branch match the rule
+	ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error
+}
+
+// BranchProtectionUpdater applies branch protection rules to repositories
+type BranchProtectionUpdater struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Rules      []domain.BranchProtection
+	DryRun     bool // If set, the changes are only shown
+	Concurrent int
+}
+
+// Update shows the difference between the current and desired protection of all repositories, and updates them
+func (b BranchProtectionUpdater) Update(ctx context.Context) error {
+	protector, ok := b.VersionController.(BranchProtector)
+	if !ok {
+		return errors.New("the platform does not support protecting branches")
+	}
+
+	repos, err := getRepositories(ctx, b.VersionController, b.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = b.updateRepository(ctx, protector, repos[i])
+	}, len(repos), b.Concurrent)
+
+	failed := printSettingsResults(b.Output, repos, results)
+	printSettingsSummary(b.Output, results, b.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not protect the branches of %d repositories", failed)
+	}
+	return nil
+}
+
+func (b BranchProtectionUpdater) updateRepository(ctx context.Context, protector BranchProtector, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	// All rules are checked before any is applied, to not leave a repository partially protected when it's skipped
+	var changes []SettingChange
+	var updates []domain.BranchProtection
+	for _, rule := range b.Rules {
+		if rule.Branch == "" {
+			rule.Branch = repo.DefaultBranch()
+		}
+
+		log.WithField("branch", rule.Branch).Debug("Fetching the current branch protection")
+		current, err := protector.GetBranchProtection(ctx, repo, rule)
+		var skipErr domain.SkipError
+		if errors.As(err, &skipErr) {
+			return settingsResult{skipReason: skipErr.Reason}
+		} else if err != nil {
+			return settingsResult{err: errors.Wrapf(err, "could not get the protection of %s", rule.Branch)}
+		}
+
+		if sameBranchProtection(current, &rule) {
+			continue
+		}
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("branch_protection %s", rule.Branch),
+			Current: formatBranchProtection(current),
+			Desired: formatBranchProtection(&rule),
+		})
+		updates = append(updates, rule)
+	}
+
+	if len(changes) == 0 {
+		return settingsResult{}
+	}
+
+	if b.DryRun {
+		log.Info("Skipping protecting branches because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	for _, rule := range updates {
+		log.WithField("branch", rule.Branch).Info("Protecting branch")
+		err := protector.ProtectBranch(ctx, repo, rule)
+		var skipErr domain.SkipError
+		if errors.As(err, &skipErr) {
+			return settingsResult{changes: changes, skipReason: skipErr.Reason}
+		} else if err != nil {
+			return settingsResult{changes: changes, err: errors.Wrapf(err, "could not protect %s", rule.Branch)}
+		}
+	}
+
+	return settingsResult{changes: changes}
+}
@@ -0,0 +1,33 @@
+package multigitter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ChangeRequestVerifier is a change management system, such as ServiceNow, that can verify that a change request is approved
+type ChangeRequestVerifier interface {
+	VerifyChangeRequest(ctx context.Context, id string) error
+}
+
+// ChangeRequest is an approved change request that is required before anything is changed
+type ChangeRequest struct {
+	ID       string
+	Verifier ChangeRequestVerifier
+}
+
+// verify returns an error if the change request is not approved
+func (c ChangeRequest) verify(ctx context.Context) error {
+	if err := c.Verifier.VerifyChangeRequest(ctx, c.ID); err != nil {
+		return errors.WithMessage(err, "the change request could not be verified")
+	}
+	log.WithField("change-request", c.ID).Info("The change request is approved")
+	return nil
+}
+
+// pullRequestText returns the reference to the change request that is added to the body of pull requests
+func (c ChangeRequest) pullRequestText() string {
+	return "\n\nChange request: " + c.ID
+}
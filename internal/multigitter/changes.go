@@ -0,0 +1,45 @@
+package multigitter
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// ChangeVersionController is a version controller, like Gerrit, where commits are reviewed by pushing them to a
+// special ref, instead of creating pull requests from a feature branch
+type ChangeVersionController interface {
+	// ChangeCommitMessage adds what the platform needs to track the change of the feature branch to the commit message
+	ChangeCommitMessage(repo domain.Repository, featureBranch, commitMessage string) string
+	// ChangeRef returns the ref the commit should be pushed to, to create the change or add a new version of it
+	ChangeRef(newPR domain.NewPullRequest) string
+}
+
+// RefPusher is a git implementation that is able to push the current branch to any ref
+type RefPusher interface {
+	PushRef(remoteName, ref string) error
+}
+
+// commitMessage returns the message of the commit made in a repository
+func (r *Runner) commitMessage(repo domain.Repository) string {
+	vc, ok := r.VersionController.(ChangeVersionController)
+	if !ok || r.SkipPullRequest {
+		return r.CommitMessage
+	}
+	return vc.ChangeCommitMessage(repo, r.FeatureBranch, r.CommitMessage)
+}
+
+// pushChange pushes the commit for review, it returns false if the platform does not use changes
+func (r *Runner) pushChange(sourceController Git, remoteName string, newPR domain.NewPullRequest) (bool, error) {
+	vc, ok := r.VersionController.(ChangeVersionController)
+	if !ok || r.SkipPullRequest {
+		return false, nil
+	}
+
+	pusher, ok := sourceController.(RefPusher)
+	if !ok {
+		return false, errors.New("the git implementation does not support pushing for review")
+	}
+
+	return true, pusher.PushRef(remoteName, vc.ChangeRef(newPR))
+}
@@ -0,0 +1,87 @@
+package multigitter
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// PullRequestChecker is a version controller that can get the checks, such as CI jobs, of a pull request
+type PullRequestChecker interface {
+	GetPullRequestChecks(ctx context.Context, pr domain.PullRequest) ([]domain.Check, error)
+}
+
+// checkPollInterval is the time between each fetch of the checks of a pull request
+var checkPollInterval = 30 * time.Second
+
+// noChecksTimeout is the time to wait for any check to be reported, before the pull request is assumed to not have any checks
+var noChecksTimeout = 2 * time.Minute
+
+// verifyChecks waits until all checks of a pull request are done, and returns an error if any of them failed
+func (r *Runner) verifyChecks(ctx context.Context, repo domain.Repository, pr domain.PullRequest) error {
+	vc, ok := r.VersionController.(PullRequestChecker)
+	if !ok {
+		return errors.New("the platform does not support verifying checks")
+	}
+
+	log := log.WithField("repo", repo.FullName())
+	log.Info("Waiting for the checks of the pull request")
+
+	start := time.Now()
+	deadline := start.Add(r.VerifyTimeout)
+	for {
+		checks, err := vc.GetPullRequestChecks(ctx, pr)
+		if err != nil {
+			return errors.Wrap(err, "could not get the checks of the pull request")
+		}
+		if r.report != nil {
+			r.report.setChecks(repo, checks)
+		}
+
+		if len(checks) == 0 && time.Since(start) >= noChecksTimeout {
+			log.Info("No checks were reported for the pull request")
+			return nil
+		}
+
+		if failed := checksWithStatus(checks, domain.CheckStatusFailure); len(failed) > 0 {
+			return errors.Errorf("the checks of the pull request failed: %s", strings.Join(failed, ", "))
+		}
+		// Checks with an unknown status are waited for in the same way as pending checks
+		if len(checks) > 0 && len(checksWithStatus(checks, domain.CheckStatusSuccess)) == len(checks) {
+			log.Info("All checks of the pull request succeeded")
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return errors.Errorf("timed out after %s waiting for the checks of the pull request", r.VerifyTimeout)
+		}
+
+		// The checks are fetched a last time at the deadline
+		wait := checkPollInterval
+		if untilDeadline := time.Until(deadline); untilDeadline < wait {
+			wait = untilDeadline
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("stopped waiting for the checks of the pull request")
+		case <-time.After(wait):
+		}
+	}
+}
+
+// checksWithStatus returns the names of the checks with a specific status
+func checksWithStatus(checks []domain.Check, status domain.CheckStatus) []string {
+	var names []string
+	for _, check := range checks {
+		if check.Status == status {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,132 @@
+package multigitter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// testChecker returns the next set of checks each time they are fetched
+type testChecker struct {
+	VersionController
+	checks [][]domain.Check
+}
+
+func (c *testChecker) GetPullRequestChecks(ctx context.Context, pr domain.PullRequest) ([]domain.Check, error) {
+	checks := c.checks[0]
+	if len(c.checks) > 1 {
+		c.checks = c.checks[1:]
+	}
+	return checks, nil
+}
+
+func TestVerifyChecks(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		checkPollInterval = interval
+		noChecksTimeout = timeout
+	}(checkPollInterval, noChecksTimeout)
+	checkPollInterval = time.Millisecond
+	noChecksTimeout = 10 * time.Millisecond
+
+	repo := testRepository{fullName: "owner/repo"}
+	pending := domain.Check{Name: "build", Status: domain.CheckStatusPending}
+	success := domain.Check{Name: "build", Status: domain.CheckStatusSuccess}
+	failure := domain.Check{Name: "lint", Status: domain.CheckStatusFailure}
+	unknown := domain.Check{Name: "build", Status: domain.CheckStatusUnknown}
+
+	tests := []struct {
+		name    string
+		checks  [][]domain.Check
+		timeout time.Duration
+		err     string
+	}{
+		{
+			name:    "success after pending",
+			checks:  [][]domain.Check{nil, {pending}, {success}},
+			timeout: time.Second,
+		},
+		{
+			name:    "failure",
+			checks:  [][]domain.Check{{pending, failure}},
+			timeout: time.Second,
+			err:     "the checks of the pull request failed: lint",
+		},
+		{
+			name:    "no checks",
+			checks:  [][]domain.Check{nil},
+			timeout: time.Second,
+		},
+		{
+			name:    "timeout",
+			checks:  [][]domain.Check{{pending}},
+			timeout: 5 * time.Millisecond,
+			err:     "timed out after 5ms waiting for the checks of the pull request",
+		},
+		{
+			name:    "success after unknown",
+			checks:  [][]domain.Check{{unknown}, {success}},
+			timeout: time.Second,
+		},
+		{
+			name:    "unknown until timeout",
+			checks:  [][]domain.Check{{unknown}},
+			timeout: 5 * time.Millisecond,
+			err:     "timed out after 5ms waiting for the checks of the pull request",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Runner{
+				VersionController: &testChecker{checks: test.checks},
+				VerifyTimeout:     test.timeout,
+				report:            newReportCollector(),
+			}
+
+			err := r.verifyChecks(context.Background(), repo, nil)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	t.Run("checked at the deadline", func(t *testing.T) {
+		checkPollInterval = 50 * time.Millisecond
+		defer func() { checkPollInterval = time.Millisecond }()
+
+		// The checks are fetched after 0, 50 and 75 milliseconds
+		r := &Runner{
+			VersionController: &testChecker{checks: [][]domain.Check{{pending}, {pending}, {success}}},
+			VerifyTimeout:     75 * time.Millisecond,
+			report:            newReportCollector(),
+		}
+		assert.NoError(t, r.verifyChecks(context.Background(), repo, nil))
+	})
+
+	t.Run("report", func(t *testing.T) {
+		r := &Runner{
+			VersionController: &testChecker{checks: [][]domain.Check{{success, failure}}},
+			VerifyTimeout:     time.Second,
+			report:            newReportCollector(),
+		}
+		require.Error(t, r.verifyChecks(context.Background(), repo, nil))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, r.report.write(buf))
+		report, err := ReadReport(buf)
+		require.NoError(t, err)
+		require.Len(t, report.Repositories, 1)
+		assert.Equal(t, []CheckReport{
+			{Name: "build", Status: "success"},
+			{Name: "lint", Status: "failure"},
+		}, report.Repositories[0].Checks)
+	})
+}
@@ -2,6 +2,8 @@ package multigitter
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/lindell/multi-gitter/internal/domain"
 	log "github.com/sirupsen/logrus"
@@ -10,13 +12,19 @@ import (
 // Closer closes pull requests
 type Closer struct {
 	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
 
 	FeatureBranch string
+	CampaignID    string  // If set, pull requests are found by their campaign instead of the branch name
+	Report        *Report // If set, the pull requests in the report of a run are used instead of searching for them
+	DryRun        bool    // If set, the pull requests that would be closed are only logged
+
+	Summary io.Writer // If set, a table with the outcome of each pull request is written to it when all are closed
 }
 
 // Close closes pull requests
 func (s Closer) Close(ctx context.Context) error {
-	prs, err := s.VersionController.GetPullRequests(ctx, s.FeatureBranch)
+	prs, err := getPullRequests(ctx, s.VersionController, s.FeatureBranch, s.CampaignID, s.Report, s.Filter)
 	if err != nil {
 		return err
 	}
@@ -28,14 +36,28 @@ func (s Closer) Close(ctx context.Context) error {
 		}
 	}
 
+	summary := &summaryCollector{}
+	if s.Summary != nil {
+		defer summary.write(s.Summary)
+	}
+
 	log.Infof("Closing %d pull requests", len(openPRs))
 
 	for _, pr := range openPRs {
+		start := time.Now()
+		if s.DryRun {
+			log.WithField("pr", pr.String()).Infof("Skipping closing because of dry run")
+			summary.add(pullRequestSummaryRow(pr, ReportStatusSkipped, "dry run", start))
+			continue
+		}
+
 		log.WithField("pr", pr.String()).Infof("Closing")
 		err := s.VersionController.ClosePullRequest(ctx, pr)
 		if err != nil {
+			summary.add(pullRequestErrorSummaryRow(pr, err, start))
 			return err
 		}
+		summary.add(pullRequestSummaryRow(pr, "closed", "", start))
 	}
 
 	return nil
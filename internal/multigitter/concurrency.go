@@ -0,0 +1,156 @@
+package multigitter
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// ConcurrencyLimits limits the number of concurrent runs within parts of all repositories, in addition to the total limit
+type ConcurrencyLimits struct {
+	PerHost  int // The maximum number of concurrent runs of repositories on the same host, zero means no limit
+	PerOwner int // The maximum number of concurrent runs of repositories with the same owner (organization, group or user), zero means no limit
+
+	// If set, the total number of concurrent runs is adapted to the load of the system, between this and the maximum number of concurrent runs
+	Min int
+}
+
+// hoster is a repository that knows its host, without creating the credentials of its url
+type hoster interface {
+	Host() string
+}
+
+// repositoryHost returns the host the repository is cloned from, or an empty string if it can not be determined
+func repositoryHost(repo domain.Repository) string {
+	if h, ok := repo.(hoster); ok {
+		return h.Host()
+	}
+
+	u, err := url.Parse(repo.URL(""))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// repositoryOwner returns the first part of the full name of a repository
+func repositoryOwner(repo domain.Repository) string {
+	return strings.SplitN(repo.FullName(), "/", 2)[0]
+}
+
+// runRepositoriesInParallel runs the function for each repository, without running more than maxConcurrent at the time in total,
+// or fewer if the number is adapted to the load of the system, or more than the limits allow on the same host or owner.
+// Repositories that would exceed a limit are skipped over until a run within the same host or owner has finished,
+// which allows repositories of other hosts and owners to run in the meantime
+func runRepositoriesInParallel(fun func(i int), repos []domain.Repository, maxConcurrent int, limits ConcurrencyLimits) {
+	if limits.Min > 0 && limits.Min < maxConcurrent {
+		if _, err := readSystemLoad(); err != nil {
+			log.Warnf("The number of concurrent runs can not be adapted to the load of the system, %d concurrent runs are used: %s", maxConcurrent, err)
+			limits.Min = 0
+		}
+	} else {
+		limits.Min = 0
+	}
+
+	if limits == (ConcurrencyLimits{}) {
+		runInParallel(fun, len(repos), maxConcurrent)
+		return
+	}
+
+	hosts := make([]string, len(repos))
+	owners := make([]string, len(repos))
+	for i, repo := range repos {
+		hosts[i] = repositoryHost(repo)
+		owners[i] = repositoryOwner(repo)
+	}
+
+	var lock sync.Mutex
+	cond := sync.NewCond(&lock)
+	running := 0
+	runningHosts := map[string]int{}
+	runningOwners := map[string]int{}
+
+	concurrent := maxConcurrent
+	if limits.Min > 0 {
+		concurrent = limits.Min
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			ticker := time.NewTicker(adaptInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+
+				load, err := readSystemLoad()
+				if err != nil {
+					continue
+				}
+
+				lock.Lock()
+				if adapted := adaptConcurrency(concurrent, limits.Min, maxConcurrent, load); adapted != concurrent {
+					log.Debugf("Changing the number of concurrent runs from %d to %d", concurrent, adapted)
+					concurrent = adapted
+					cond.Broadcast()
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	canRun := func(i int) bool {
+		return running < concurrent &&
+			(limits.PerHost == 0 || runningHosts[hosts[i]] < limits.PerHost) &&
+			(limits.PerOwner == 0 || runningOwners[owners[i]] < limits.PerOwner)
+	}
+
+	pending := make([]int, len(repos))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(repos))
+	for len(pending) > 0 {
+		lock.Lock()
+		next := -1
+		for next == -1 {
+			for j, i := range pending {
+				if canRun(i) {
+					next = j
+					break
+				}
+			}
+			if next == -1 {
+				cond.Wait()
+			}
+		}
+		i := pending[next]
+		pending = append(pending[:next], pending[next+1:]...)
+		running++
+		runningHosts[hosts[i]]++
+		runningOwners[owners[i]]++
+		lock.Unlock()
+
+		go func(i int) {
+			defer wg.Done()
+			fun(i)
+
+			lock.Lock()
+			running--
+			runningHosts[hosts[i]]--
+			runningOwners[owners[i]]--
+			cond.Broadcast()
+			lock.Unlock()
+		}(i)
+	}
+	wg.Wait()
+}
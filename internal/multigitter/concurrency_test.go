@@ -0,0 +1,91 @@
+package multigitter
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestRunRepositoriesInParallel(t *testing.T) {
+	repos := []domain.Repository{
+		testRepository{fullName: "a/1", url: "https://gitlab.example.com/a/1.git"},
+		testRepository{fullName: "a/2", url: "https://gitlab.example.com/a/2.git"},
+		testRepository{fullName: "b/1", url: "https://gitlab.example.com/b/1.git"},
+		testRepository{fullName: "b/2", url: "https://gitlab.example.com/b/2.git"},
+		testRepository{fullName: "c/1", url: "https://github.com/c/1.git"},
+		testRepository{fullName: "c/2", url: "https://github.com/c/2.git"},
+		testRepository{fullName: "d/1", url: "https://github.com/d/1.git"},
+	}
+
+	var lock sync.Mutex
+	var total, maxTotal int
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	track := func(key string, delta int) {
+		running[key] += delta
+		if running[key] > maxRunning[key] {
+			maxRunning[key] = running[key]
+		}
+	}
+
+	done := map[string]bool{}
+	runRepositoriesInParallel(func(i int) {
+		repo := repos[i]
+		lock.Lock()
+		total++
+		if total > maxTotal {
+			maxTotal = total
+		}
+		track(repositoryHost(repo), 1)
+		track(repositoryOwner(repo), 1)
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		total--
+		track(repositoryHost(repo), -1)
+		track(repositoryOwner(repo), -1)
+		done[repo.FullName()] = true
+		lock.Unlock()
+	}, repos, 4, ConcurrencyLimits{PerHost: 3, PerOwner: 1})
+
+	assert.Len(t, done, len(repos))
+	assert.LessOrEqual(t, maxTotal, 4)
+	assert.LessOrEqual(t, maxRunning["gitlab.example.com"], 3)
+	assert.LessOrEqual(t, maxRunning["github.com"], 3)
+	for _, owner := range []string{"a", "b", "c", "d"} {
+		assert.Equal(t, 1, maxRunning[owner], owner)
+	}
+	// The limit of each owner should not keep repositories of other owners from running
+	assert.Equal(t, 4, maxTotal)
+}
+
+func TestAdaptConcurrency(t *testing.T) {
+	idle := systemLoad{cpu: 0.2, memoryAvailable: 0.8, ioPressure: 1}
+
+	tests := []struct {
+		name     string
+		current  int
+		load     systemLoad
+		expected int
+	}{
+		{name: "idle", current: 2, load: idle, expected: 3},
+		{name: "idle at max", current: 5, load: idle, expected: 5},
+		{name: "high cpu load", current: 3, load: systemLoad{cpu: 1.5, memoryAvailable: 0.8}, expected: 2},
+		{name: "low memory", current: 3, load: systemLoad{cpu: 0.2, memoryAvailable: 0.05}, expected: 2},
+		{name: "high io pressure", current: 3, load: systemLoad{cpu: 0.2, memoryAvailable: 0.8, ioPressure: 40}, expected: 2},
+		{name: "overloaded at min", current: 2, load: systemLoad{cpu: 3}, expected: 2},
+		{name: "between thresholds", current: 3, load: systemLoad{cpu: 0.8, memoryAvailable: 0.8}, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, adaptConcurrency(tt.current, 2, 5, tt.load))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package multigitter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter/logger"
+)
+
+// ConflictStrategy defines how conflicts in files matching the pattern should be resolved
+type ConflictStrategy struct {
+	Pattern    string // A gitignore style pattern
+	Resolution domain.ConflictResolution
+}
+
+// ConflictMerger is a git implementation that is able to merge an existing branch and resolve conflicts
+type ConflictMerger interface {
+	// MergeBranch merges a remote branch into the current branch and returns the files in conflict, if any
+	MergeBranch(commitAuthor *domain.CommitAuthor, remoteName, branchName string) ([]string, error)
+	ResolveConflict(file string, resolution domain.ConflictResolution) error
+	// FinishMerge stages all changes and commits the merge
+	FinishMerge(commitAuthor *domain.CommitAuthor) error
+	AbortMerge() error
+}
+
+// conflictResolution returns the resolution of the first strategy matching the file
+func conflictResolution(strategies []ConflictStrategy, file string) (domain.ConflictResolution, bool) {
+	for _, strategy := range strategies {
+		pattern := gitignore.ParsePattern(strategy.Pattern, nil)
+		if pattern.Match(strings.Split(file, "/"), false) == gitignore.Exclude {
+			return strategy.Resolution, true
+		}
+	}
+	return domain.ConflictResolutionUnknown, false
+}
+
+// updateBranch merges the already existing feature branch into the changes made by the run
+// and resolves any conflicts with the configured strategies and resolver script
+func (r *Runner) updateBranch(sourceController Git, dir string, remoteName string, repo domain.Repository) error {
+	merger, ok := sourceController.(ConflictMerger)
+	if !ok {
+		return errors.New("updating an existing branch is not supported by the git implementation, use --git-type=cmd")
+	}
+
+	log := log.WithField("repo", repo.FullName())
+	log.Info("Updating the existing branch")
+
+	conflicts, err := merger.MergeBranch(r.CommitAuthor, remoteName, r.FeatureBranch)
+	if err != nil {
+		return errors.Wrap(err, "could not merge the existing branch")
+	}
+
+	if err := r.resolveConflicts(merger, dir, repo, conflicts); err != nil {
+		if abortErr := merger.AbortMerge(); abortErr != nil {
+			log.Warnf("Could not abort the merge: %s", abortErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *Runner) resolveConflicts(merger ConflictMerger, dir string, repo domain.Repository, conflicts []string) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	var unresolved []string
+	for _, file := range conflicts {
+		resolution, ok := conflictResolution(r.ConflictStrategies, file)
+		if !ok {
+			unresolved = append(unresolved, file)
+			continue
+		}
+
+		if err := merger.ResolveConflict(file, resolution); err != nil {
+			return errors.Wrapf(err, "could not resolve conflict in %s", file)
+		}
+	}
+
+	if len(unresolved) > 0 && r.ConflictResolver != "" {
+		if err := r.runConflictResolver(dir, repo, unresolved); err != nil {
+			return err
+		}
+
+		stillUnresolved, err := filesWithConflictMarkers(dir, unresolved)
+		if err != nil {
+			return err
+		}
+		unresolved = stillUnresolved
+	}
+
+	if len(unresolved) > 0 {
+		log.WithField("repo", repo.FullName()).Infof("Unresolved conflicts in: %s", strings.Join(unresolved, ", "))
+		return domain.ConflictError
+	}
+
+	return merger.FinishMerge(r.CommitAuthor)
+}
+
+func (r *Runner) runConflictResolver(dir string, repo domain.Repository, files []string) error {
+	cmd := r.Sandbox.command([]string{dir}, r.ConflictResolver, r.ConflictResolverArguments...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("REPOSITORY=%s", repo.FullName()),
+		fmt.Sprintf("CONFLICTED_FILES=%s", strings.Join(files, "\n")),
+	)
+
+	writer := logger.NewLogger(log.WithField("repo", repo.FullName()))
+	defer writer.Close()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(transformExecError(err), "the conflict resolver failed")
+	}
+	return nil
+}
+
+// filesWithConflictMarkers returns the files that still contain conflict markers
+func filesWithConflictMarkers(dir string, files []string) ([]string, error) {
+	var ret []string
+	for _, file := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		content := string(b)
+		if strings.HasPrefix(content, "<<<<<<< ") || strings.Contains(content, "\n<<<<<<< ") {
+			ret = append(ret, file)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,175 @@
+package multigitter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Dependencies are the repositories, in the format "owner/name", that the changes in each repository depend on.
+// The pull request of a repository is only created and merged once the pull requests of its dependencies have been merged
+type Dependencies map[string][]string
+
+// levels sorts the names into levels, where each repository only depend on repositories in earlier levels.
+// Dependencies on repositories that are not part of the names are ignored
+func (d Dependencies) levels(names []string) ([][]string, error) {
+	included := map[string]bool{}
+	for _, name := range names {
+		included[name] = true
+	}
+
+	depths := map[string]int{}
+	visiting := map[string]bool{}
+	var depth func(name string) (int, error)
+	depth = func(name string) (int, error) {
+		if known, ok := depths[name]; ok {
+			return known, nil
+		}
+		if visiting[name] {
+			return 0, errors.Errorf("the dependencies of %s are circular", name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		max := 0
+		for _, dependency := range d[name] {
+			if !included[dependency] {
+				continue
+			}
+			dependencyDepth, err := depth(dependency)
+			if err != nil {
+				return 0, err
+			}
+			if dependencyDepth+1 > max {
+				max = dependencyDepth + 1
+			}
+		}
+		depths[name] = max
+		return max, nil
+	}
+
+	var levels [][]string
+	for _, name := range names {
+		level, err := depth(name)
+		if err != nil {
+			return nil, err
+		}
+		for len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], name)
+	}
+	return levels, nil
+}
+
+// dependencyError is the error of a repository that was not run since a dependency of it is not merged
+type dependencyError struct {
+	dependency string
+	reason     string
+}
+
+func (e *dependencyError) Error() string {
+	return fmt.Sprintf("the pull request of the dependency %s %s", e.dependency, e.reason)
+}
+
+// dependencyTracker keeps track of the state of the dependencies of the repositories in a run
+type dependencyTracker struct {
+	dependencies Dependencies
+	pullRequests map[string]domain.PullRequestStatus // The pull requests of the feature branch, before the run
+
+	lock    sync.Mutex
+	results map[string]dependencyResult // The results of the repositories that have been run
+}
+
+type dependencyResult struct {
+	pullRequestCreated bool
+	err                error
+}
+
+func newDependencyTracker(dependencies Dependencies, prs []domain.PullRequest) *dependencyTracker {
+	t := &dependencyTracker{
+		dependencies: dependencies,
+		pullRequests: map[string]domain.PullRequestStatus{},
+		results:      map[string]dependencyResult{},
+	}
+	for _, pr := range prs {
+		t.pullRequests[pr.RepositoryName()] = pr.Status()
+	}
+	return t
+}
+
+// add records the result of a repository
+func (t *dependencyTracker) add(repoName string, pr domain.PullRequest, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.results[repoName] = dependencyResult{
+		pullRequestCreated: pr != nil,
+		err:                err,
+	}
+}
+
+// check returns an error if any dependency of the repository has a pull request that is not merged
+func (t *dependencyTracker) check(repoName string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, dependency := range t.dependencies[repoName] {
+		if status, ok := t.pullRequests[dependency]; ok {
+			switch status {
+			case domain.PullRequestStatusMerged:
+				continue
+			case domain.PullRequestStatusClosed:
+			default:
+				return &dependencyError{dependency: dependency, reason: "is not merged"}
+			}
+		}
+
+		result, ok := t.results[dependency]
+		switch {
+		case !ok:
+		case result.pullRequestCreated:
+			return &dependencyError{dependency: dependency, reason: "has to be merged first"}
+		case result.err != nil && result.err != domain.NoChangeError:
+			return &dependencyError{dependency: dependency, reason: "could not be created"}
+		}
+	}
+	return nil
+}
+
+// sortPullRequestsByDependencies sorts pull requests so that the pull requests of dependencies come first
+func sortPullRequestsByDependencies(prs []domain.PullRequest, dependencies Dependencies) ([]domain.PullRequest, error) {
+	names := make([]string, len(prs))
+	for i, pr := range prs {
+		names[i] = pr.RepositoryName()
+	}
+
+	levels, err := dependencies.levels(names)
+	if err != nil {
+		return nil, err
+	}
+	levelOf := map[string]int{}
+	for level, levelNames := range levels {
+		for _, name := range levelNames {
+			levelOf[name] = level
+		}
+	}
+
+	order := make([]int, len(prs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return levelOf[names[order[i]]] < levelOf[names[order[j]]]
+	})
+
+	sorted := make([]domain.PullRequest, len(prs))
+	for i, index := range order {
+		sorted[i] = prs[index]
+	}
+	return sorted, nil
+}
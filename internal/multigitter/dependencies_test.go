@@ -0,0 +1,28 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencies_levels(t *testing.T) {
+	dependencies := Dependencies{
+		"owner/app":      {"owner/client", "owner/library"},
+		"owner/client":   {"owner/library"},
+		"owner/consumer": {"owner/not-included"},
+	}
+
+	levels, err := dependencies.levels([]string{"owner/app", "owner/client", "owner/consumer", "owner/library"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"owner/consumer", "owner/library"},
+		{"owner/client"},
+		{"owner/app"},
+	}, levels)
+
+	dependencies["owner/library"] = []string{"owner/app"}
+	_, err = dependencies.levels([]string{"owner/app", "owner/client", "owner/library"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package multigitter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// DeployKeys defines how the ssh key used to push to a repository is found.
+// Repositories without any key are pushed to with the token
+type DeployKeys struct {
+	Dir string // A directory with private keys, named after the repository in the format "owner/repo"
+
+	Command   string // A command that prints the path of the key, with the repository set in the REPOSITORY environment variable. Must be absolute path
+	Arguments []string
+}
+
+func (d DeployKeys) isSet() bool {
+	return d.Dir != "" || d.Command != ""
+}
+
+// DeployKeyPusher is a git implementation that is able to push with an ssh key
+type DeployKeyPusher interface {
+	PushWithKey(remoteName, keyPath string) error
+}
+
+// sshURLer is a repository that can be pushed to with ssh
+type sshURLer interface {
+	SSHURL() string
+}
+
+// keyPath returns the path of the key of a repository, or an empty string if there is none
+func (d DeployKeys) keyPath(repo domain.Repository) (string, error) {
+	if d.Dir != "" {
+		path := filepath.Join(d.Dir, filepath.FromSlash(repo.FullName()))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	if d.Command != "" {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		cmd := exec.Command(d.Command, d.Arguments...)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("REPOSITORY=%s", repo.FullName()),
+		)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return "", errors.Wrapf(transformExecError(err), "the deploy key command failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+
+	return "", nil
+}
+
+// pushWithDeployKey pushes to the repository with its deploy key, it returns false if no key exists for the repository
+func (r *Runner) pushWithDeployKey(sourceController Git, repo domain.Repository) (bool, error) {
+	keyPath, err := r.DeployKeys.keyPath(repo)
+	if err != nil {
+		return false, err
+	}
+	if keyPath == "" {
+		return false, nil
+	}
+
+	urler, ok := repo.(sshURLer)
+	if !ok {
+		return false, errors.New("the platform does not support pushing with deploy keys")
+	}
+	pusher, ok := sourceController.(DeployKeyPusher)
+	if !ok {
+		return false, errors.New("the git implementation does not support pushing with deploy keys")
+	}
+
+	if err := sourceController.AddRemote("deploy-key", urler.SSHURL()); err != nil {
+		return false, err
+	}
+
+	return true, pusher.PushWithKey("deploy-key", keyPath)
+}
@@ -0,0 +1,61 @@
+package multigitter
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepository struct {
+	fullName string
+	url      string
+}
+
+func (r testRepository) URL(token string) string {
+	return r.url
+}
+
+func (r testRepository) DefaultBranch() string {
+	return "main"
+}
+
+func (r testRepository) FullName() string {
+	return r.fullName
+}
+
+func TestDeployKeys_keyPath(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-gitter-deploy-keys-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "owner"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "owner", "with-key"), []byte("key"), 0600))
+
+	// Directory
+	deployKeys := DeployKeys{Dir: dir}
+
+	path, err := deployKeys.keyPath(testRepository{fullName: "owner/with-key"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "owner", "with-key"), path)
+
+	path, err = deployKeys.keyPath(testRepository{fullName: "owner/without-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "", path)
+
+	// Command, used when no key is found in the directory
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo is not available")
+	}
+	deployKeys.Command = echo
+	deployKeys.Arguments = []string{"/keys/from-command"}
+
+	path, err = deployKeys.keyPath(testRepository{fullName: "owner/without-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "/keys/from-command", path)
+}
@@ -0,0 +1,107 @@
+package multigitter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// PullRequestDiffGetter is a version controller that can get the diffs of open pull requests
+type PullRequestDiffGetter interface {
+	// GetOpenPullRequestDiffs gets the unified diffs of all open pull requests in a repository
+	GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error)
+}
+
+// findEquivalentPullRequest finds an open pull request, from another branch, that contains the same changes as the diff
+func findEquivalentPullRequest(ctx context.Context, vc PullRequestDiffGetter, repo domain.Repository, branchName, diff string) (domain.PullRequest, error) {
+	prDiffs, err := vc.GetOpenPullRequestDiffs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := diffHash(diff)
+	for _, prDiff := range prDiffs {
+		if prDiff.Branch == branchName {
+			continue
+		}
+		if diffHash(prDiff.Diff) == hash {
+			return prDiff.PullRequest, nil
+		}
+	}
+	return nil, nil
+}
+
+// diffHash creates a hash of a unified diff that only depends on the changed files and lines,
+// making diffs created by different tools comparable
+func diffHash(diff string) string {
+	var files []string
+	var current *strings.Builder
+	oldLines, newLines := 0, 0 // The lines left of the current hunk
+	for _, line := range strings.Split(diff, "\n") {
+		// Lines within a hunk are only changes or context, even if they look like headers
+		if oldLines > 0 || newLines > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLines--
+				current.WriteString(line + "\n")
+			case strings.HasPrefix(line, "-"):
+				oldLines--
+				current.WriteString(line + "\n")
+			case strings.HasPrefix(line, `\`): // No newline at end of file
+			default:
+				oldLines--
+				newLines--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			if current != nil {
+				files = append(files, current.String())
+			}
+			current = &strings.Builder{}
+			current.WriteString(strings.SplitN(line, "\t", 2)[0] + "\n")
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "+++ "):
+			current.WriteString(strings.SplitN(line, "\t", 2)[0] + "\n")
+		case strings.HasPrefix(line, "@@ "):
+			oldLines, newLines = hunkLines(line)
+		}
+	}
+	if current != nil {
+		files = append(files, current.String())
+	}
+
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		_, _ = h.Write([]byte(f))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hunkLines returns the number of lines of the old and new file in a hunk, from its header
+func hunkLines(header string) (oldLines, newLines int) {
+	matches := hunkHeaderRegex.FindStringSubmatch(header)
+	if matches == nil {
+		return 0, 0
+	}
+	return hunkLineCount(matches[2]), hunkLineCount(matches[3])
+}
+
+// hunkLineCount parses the line count of a hunk header, which is one if it is left out
+func hunkLineCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
@@ -0,0 +1,55 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffHash(t *testing.T) {
+	gitDiff := `diff --git a/a.txt b/a.txt
+index 1e0a7d8..f6ea049 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ unchanged
+-i like apples
++i like bananas
+diff --git a/b.txt b/b.txt
+new file mode 100644
+--- /dev/null
++++ b/b.txt
+@@ -0,0 +1 @@
++new file
+`
+
+	// Files in another order, without git headers and with other context
+	otherDiff := `--- /dev/null
++++ b/b.txt
+@@ -0,0 +1 @@
++new file
+--- a/a.txt
++++ b/a.txt
+@@ -2 +2 @@
+-i like apples
++i like bananas
+`
+
+	differentDiff := `--- a/a.txt
++++ b/a.txt
+@@ -2 +2 @@
+-i like apples
++i like oranges
+`
+
+	assert.Equal(t, diffHash(gitDiff), diffHash(otherDiff))
+	assert.NotEqual(t, diffHash(gitDiff), diffHash(differentDiff))
+}
+
+func Test_diffHash_headerLikeLines(t *testing.T) {
+	// Removed lines starting with "-- " are part of the hunk, and not headers of a new file
+	diff := "--- a/query.sql\n+++ b/query.sql\n@@ -1,2 +1 @@\n SELECT 1;\n--- first\tcomment\n"
+	otherDiff := "--- a/query.sql\n+++ b/query.sql\n@@ -1,2 +1 @@\n SELECT 1;\n--- first\tsecond comment\n"
+
+	assert.NotEqual(t, diffHash(diff), diffHash(otherDiff))
+}
@@ -0,0 +1,106 @@
+package multigitter
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The categories of the errors of repositories, that are stable to be used by machines
+const (
+	ErrorCategoryAuth              = "auth"
+	ErrorCategoryRateLimit         = "rate-limit"
+	ErrorCategoryClone             = "clone"
+	ErrorCategoryScriptExit        = "script-exit"
+	ErrorCategoryPushRejected      = "push-rejected"
+	ErrorCategoryPullRequestCreate = "pr-create"
+	ErrorCategoryProtectedBranch   = "protected-branch"
+	ErrorCategoryOther             = "other"
+)
+
+// stageError is an error from a stage of the run, such as cloning or pushing, that is categorized by the stage
+type stageError struct {
+	category string
+	err      error
+}
+
+func (e *stageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stageError) Cause() error {
+	return e.err
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+// inStage marks the error as happening in the stage of the category, nil is returned if the error is nil
+func inStage(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stageError{
+		category: category,
+		err:      err,
+	}
+}
+
+// Parts of error messages, in lower case, from git and the platforms, that reveal the cause of the error
+var (
+	rateLimitMessages = []string{
+		"rate limit",
+		"too many requests",
+	}
+	authMessages = []string{
+		"authentication required",
+		"authentication failed",
+		"authorization failed",
+		"bad credentials",
+		"401 unauthorized",
+		"invalid username or password",
+		"could not read username",
+		"permission denied (publickey)",
+		"http basic: access denied",
+	}
+	protectedBranchMessages = []string{
+		"protected branch",
+		"protected ref",
+		"gh006",
+	}
+)
+
+// errorCategory returns the category of the error of a repository. The cause, such as an authentication problem,
+// is used if it can be found in the error message, otherwise the stage the error happened in
+func errorCategory(err error) string {
+	var scriptErr *scriptError
+	if errors.As(err, &scriptErr) {
+		return ErrorCategoryScriptExit
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case containsAny(msg, rateLimitMessages):
+		return ErrorCategoryRateLimit
+	case containsAny(msg, authMessages):
+		return ErrorCategoryAuth
+	case containsAny(msg, protectedBranchMessages):
+		return ErrorCategoryProtectedBranch
+	}
+
+	var stageErr *stageError
+	if errors.As(err, &stageErr) {
+		return stageErr.category
+	}
+	return ErrorCategoryOther
+}
+
+func containsAny(str string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(str, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		category string
+	}{
+		{
+			name:     "script",
+			err:      &scriptError{err: errors.New("exit status 1"), output: "401 Unauthorized"},
+			category: ErrorCategoryScriptExit,
+		},
+		{
+			name:     "clone",
+			err:      inStage(ErrorCategoryClone, errors.New("repository not found")),
+			category: ErrorCategoryClone,
+		},
+		{
+			name:     "clone auth",
+			err:      inStage(ErrorCategoryClone, errors.New("authentication required")),
+			category: ErrorCategoryAuth,
+		},
+		{
+			name:     "push rejected",
+			err:      inStage(ErrorCategoryPushRejected, errors.Wrap(errors.New("non-fast-forward update"), "could not push changes")),
+			category: ErrorCategoryPushRejected,
+		},
+		{
+			name:     "protected branch",
+			err:      inStage(ErrorCategoryPushRejected, errors.New("remote: error: GH006: Protected branch update failed for refs/heads/main.")),
+			category: ErrorCategoryProtectedBranch,
+		},
+		{
+			name:     "rate limit",
+			err:      inStage(ErrorCategoryPullRequestCreate, errors.New("403 API rate limit of 5000 still exceeded")),
+			category: ErrorCategoryRateLimit,
+		},
+		{
+			name:     "pull request",
+			err:      inStage(ErrorCategoryPullRequestCreate, errors.New("422 Validation Failed")),
+			category: ErrorCategoryPullRequestCreate,
+		},
+		{
+			name:     "other",
+			err:      errors.New("could not get changed files"),
+			category: ErrorCategoryOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.category, errorCategory(tt.err))
+		})
+	}
+}
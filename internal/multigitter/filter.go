@@ -0,0 +1,251 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// FileGetter is a version controller that can get files from a repository without cloning it
+type FileGetter interface {
+	// GetFile gets the content of a file on the default branch of the repository, exists is false if there is no such file
+	GetFile(ctx context.Context, repo domain.Repository, path string) (content string, exists bool, err error)
+}
+
+// FileCondition is a file that has to exist in a repository for it to be used
+type FileCondition struct {
+	Path    string
+	Content *regexp.Regexp // If set, the content of the file has to match the pattern
+}
+
+// fileCheckConcurrency is the number of repositories that are checked for files at the same time
+const fileCheckConcurrency = 10
+
+// RepositoryFilter selects which of the repositories, fetched from the platform, that are used
+type RepositoryFilter struct {
+	Include []*regexp.Regexp // If set, only repositories with a full name that matches any of the patterns are used
+	Exclude []*regexp.Regexp // Repositories with a full name that matches any of the patterns are not used
+
+	ExcludeGlobs []string // Repositories with a full name that matches any of the glob patterns, such as "my-org/legacy-*", are not used
+
+	DefaultBranch *regexp.Regexp // If set, only repositories with a default branch that matches the pattern are used
+
+	// Files that has to exist in the repositories, checked through the platform before anything is cloned.
+	// Only used when selecting repositories, not pull requests
+	Files []FileCondition
+
+	// If set, only repositories with a CODEOWNERS file that contains any of these owners, such as "@org/team", are used.
+	// Checked in the same way as Files
+	OwnedBy []string
+}
+
+// skippedRepository is a repository that was not used, since it did not pass the filter
+type skippedRepository struct {
+	repo   domain.Repository
+	reason string
+}
+
+// matches checks if a repository, with the full name in the format "owner/name", passes the filter
+func (f RepositoryFilter) matches(name string) bool {
+	return f.mismatch(name) == ""
+}
+
+// mismatch returns why a repository, with the full name in the format "owner/name", does not pass the filter,
+// or an empty string if it does
+func (f RepositoryFilter) mismatch(name string) string {
+	for _, exclude := range f.Exclude {
+		if exclude.MatchString(name) {
+			return fmt.Sprintf("the name matches the excluded pattern %s", exclude)
+		}
+	}
+	for _, glob := range f.ExcludeGlobs {
+		// Names are case insensitive on most platforms
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(name)); ok {
+			return fmt.Sprintf("the name matches the excluded pattern %s", glob)
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return ""
+	}
+	for _, include := range f.Include {
+		if include.MatchString(name) {
+			return ""
+		}
+	}
+	return "the name does not match any included pattern"
+}
+
+// mismatchDefaultBranch returns why the default branch of a repository does not pass the filter, or an empty string if it does
+func (f RepositoryFilter) mismatchDefaultBranch(repo domain.Repository) string {
+	if f.DefaultBranch == nil || f.DefaultBranch.MatchString(repo.DefaultBranch()) {
+		return ""
+	}
+	return fmt.Sprintf("the default branch %s does not match %s", repo.DefaultBranch(), f.DefaultBranch)
+}
+
+func (f RepositoryFilter) isEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.ExcludeGlobs) == 0 && f.DefaultBranch == nil
+}
+
+// getRepositories fetches the repositories from the platform, and removes the ones that does not pass the filter
+func getRepositories(ctx context.Context, vc VersionController, filter RepositoryFilter) ([]domain.Repository, error) {
+	repos, _, err := getFilteredRepositories(ctx, vc, filter)
+	return repos, err
+}
+
+// getFilteredRepositories fetches the repositories from the platform, and separates the ones that does not pass the filter
+func getFilteredRepositories(ctx context.Context, vc VersionController, filter RepositoryFilter) ([]domain.Repository, []skippedRepository, error) {
+	repos, err := vc.GetRepositories(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	repos, skipped := filter.filterRepositories(repos)
+
+	if len(filter.Files) > 0 || len(filter.OwnedBy) > 0 {
+		var skippedFiles []skippedRepository
+		repos, skippedFiles, err = filter.filterFiles(ctx, vc, repos)
+		if err != nil {
+			return nil, nil, err
+		}
+		skipped = append(skipped, skippedFiles...)
+	}
+	return repos, skipped, nil
+}
+
+// filterFiles removes the repositories that does not contain the required files, or are not owned by the required owners
+func (f RepositoryFilter) filterFiles(ctx context.Context, vc VersionController, repos []domain.Repository) ([]domain.Repository, []skippedRepository, error) {
+	fileGetter, ok := vc.(FileGetter)
+	if !ok {
+		return nil, nil, errors.New("the platform does not support checking files in repositories before cloning them")
+	}
+
+	log.Infof("Checking the files of %d repositories", len(repos))
+
+	mismatches := make([]string, len(repos))
+	var lock sync.Mutex
+	var firstErr error
+	runInParallel(func(i int) {
+		mismatch, err := f.mismatchFiles(ctx, fileGetter, repos[i])
+		if err != nil {
+			lock.Lock()
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "could not check the files of %s", repos[i].FullName())
+			}
+			lock.Unlock()
+			return
+		}
+		mismatches[i] = mismatch
+	}, len(repos), fileCheckConcurrency)
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	filtered := make([]domain.Repository, 0, len(repos))
+	var skipped []skippedRepository
+	for i, repo := range repos {
+		if mismatches[i] == "" {
+			filtered = append(filtered, repo)
+		} else {
+			skipped = append(skipped, skippedRepository{repo: repo, reason: mismatches[i]})
+		}
+	}
+	return filtered, skipped, nil
+}
+
+// mismatchFiles returns why the file conditions are not met in the repository, or an empty string if they are
+func (f RepositoryFilter) mismatchFiles(ctx context.Context, fileGetter FileGetter, repo domain.Repository) (string, error) {
+	for _, condition := range f.Files {
+		content, exists, err := fileGetter.GetFile(ctx, repo, condition.Path)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return fmt.Sprintf("the required file %s does not exist", condition.Path), nil
+		}
+		if condition.Content != nil && !condition.Content.MatchString(content) {
+			return fmt.Sprintf("the content of %s does not match %s", condition.Path, condition.Content), nil
+		}
+	}
+
+	if len(f.OwnedBy) > 0 {
+		return f.mismatchOwners(ctx, fileGetter, repo)
+	}
+	return "", nil
+}
+
+// mismatchOwners returns why the CODEOWNERS file of the repository does not contain any of the required owners,
+// or an empty string if it does
+func (f RepositoryFilter) mismatchOwners(ctx context.Context, fileGetter FileGetter, repo domain.Repository) (string, error) {
+	for _, location := range codeOwnersLocations {
+		content, exists, err := fileGetter.GetFile(ctx, repo, filepath.ToSlash(location))
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			continue
+		}
+
+		rules, err := parseCodeOwners(strings.NewReader(content))
+		if err != nil {
+			return "", errors.Wrap(err, "could not read CODEOWNERS")
+		}
+		for _, rule := range rules {
+			for _, owner := range rule.owners {
+				for _, required := range f.OwnedBy {
+					if strings.EqualFold(owner, required) {
+						return "", nil
+					}
+				}
+			}
+		}
+		return fmt.Sprintf("the CODEOWNERS file does not contain %s", strings.Join(f.OwnedBy, " or ")), nil
+	}
+	return "there is no CODEOWNERS file", nil
+}
+
+// filterRepositories separates the repositories that does not pass the filter
+func (f RepositoryFilter) filterRepositories(repos []domain.Repository) ([]domain.Repository, []skippedRepository) {
+	if f.isEmpty() {
+		return repos, nil
+	}
+
+	filtered := make([]domain.Repository, 0, len(repos))
+	var skipped []skippedRepository
+	for _, repo := range repos {
+		mismatch := f.mismatch(repo.FullName())
+		if mismatch == "" {
+			mismatch = f.mismatchDefaultBranch(repo)
+		}
+		if mismatch != "" {
+			skipped = append(skipped, skippedRepository{repo: repo, reason: mismatch})
+		} else {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, skipped
+}
+
+// filterPullRequests removes the pull requests of repositories that does not pass the filter
+func (f RepositoryFilter) filterPullRequests(prs []domain.PullRequest) []domain.PullRequest {
+	if f.isEmpty() {
+		return prs
+	}
+
+	filtered := make([]domain.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if f.matches(pr.RepositoryName()) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
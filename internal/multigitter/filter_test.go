@@ -0,0 +1,31 @@
+package multigitter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryFilter_matches(t *testing.T) {
+	filter := RepositoryFilter{
+		Include: []*regexp.Regexp{regexp.MustCompile(`^my-org/`), regexp.MustCompile(`^other-org/service-`)},
+		Exclude: []*regexp.Regexp{regexp.MustCompile(`/legacy-`)},
+	}
+
+	assert.True(t, filter.matches("my-org/app"))
+	assert.True(t, filter.matches("other-org/service-users"))
+	assert.False(t, filter.matches("other-org/app"))
+	assert.False(t, filter.matches("my-org/legacy-app"))
+
+	assert.True(t, RepositoryFilter{}.matches("any-org/any-repo"))
+
+	globFilter := RepositoryFilter{
+		ExcludeGlobs: []string{"acme/legacy-*", "acme/*-archive"},
+	}
+	assert.True(t, globFilter.matches("acme/service"))
+	assert.True(t, globFilter.matches("other-org/legacy-app"))
+	assert.False(t, globFilter.matches("acme/legacy-app"))
+	assert.False(t, globFilter.matches("Acme/Service-Archive"))
+	assert.Equal(t, "the name matches the excluded pattern acme/*-archive", globFilter.mismatch("acme/service-archive"))
+}
@@ -0,0 +1,107 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// ForkVersionController is a version controller that is able to manage forks
+type ForkVersionController interface {
+	// GetForks gets all forks owned by owner. If owner is empty, the logged in user is used
+	GetForks(ctx context.Context, owner string) ([]domain.Fork, error)
+	// GetForkPullRequests gets all pull requests made from the branch of a fork
+	GetForkPullRequests(ctx context.Context, fork domain.Fork, branchName string) ([]domain.PullRequest, error)
+	// GetOpenForkPullRequests gets all open pull requests made from any branch of a fork
+	GetOpenForkPullRequests(ctx context.Context, fork domain.Fork) ([]domain.PullRequest, error)
+	DeleteRepository(ctx context.Context, repo domain.Repository) error
+}
+
+// ForkPruner deletes forks where all pull requests, made from the feature branch, are closed or merged.
+// Forks with open pull requests from other branches are kept, since deleting the fork would close them
+type ForkPruner struct {
+	VersionController VersionController
+
+	Output io.Writer
+
+	FeatureBranch string
+	ForkOwner     string        // The owner of the forks. If empty, the logged in user is used
+	OlderThan     time.Duration // Only prune forks that has not been updated within this duration
+	DryRun        bool
+}
+
+// Prune deletes forks where all pull requests, made from the feature branch, are closed or merged
+func (p ForkPruner) Prune(ctx context.Context) error {
+	vc, ok := p.VersionController.(ForkVersionController)
+	if !ok {
+		return errors.New("the platform does not support pruning of forks")
+	}
+
+	forks, err := vc.GetForks(ctx, p.ForkOwner)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch forks")
+	}
+
+	log.Infof("Checking %d forks", len(forks))
+
+	for _, fork := range forks {
+		log := log.WithField("repo", fork.FullName())
+
+		if p.OlderThan > 0 && time.Since(fork.LastUpdated()) < p.OlderThan {
+			log.Debug("Skipping fork that was recently updated")
+			continue
+		}
+
+		prs, err := vc.GetForkPullRequests(ctx, fork, p.FeatureBranch)
+		if err != nil {
+			return errors.Wrapf(err, "could not fetch pull requests of %s", fork.FullName())
+		}
+
+		if !allPullRequestsDone(prs) {
+			log.Debug("Skipping fork without finished pull requests")
+			continue
+		}
+
+		openPRs, err := vc.GetOpenForkPullRequests(ctx, fork)
+		if err != nil {
+			return errors.Wrapf(err, "could not fetch open pull requests of %s", fork.FullName())
+		}
+		if len(openPRs) > 0 {
+			log.Infof("Skipping fork with %d open pull requests from other branches", len(openPRs))
+			continue
+		}
+
+		if p.DryRun {
+			log.Info("Skipping deletion of fork because of dry run")
+			fmt.Fprintf(p.Output, "%s\n", fork.FullName())
+			continue
+		}
+
+		log.Info("Deleting fork")
+		if err := vc.DeleteRepository(ctx, fork); err != nil {
+			return errors.Wrapf(err, "could not delete %s", fork.FullName())
+		}
+		fmt.Fprintf(p.Output, "%s\n", fork.FullName())
+	}
+
+	return nil
+}
+
+// allPullRequestsDone checks that there is at least one pull request, and that all of them are closed or merged
+func allPullRequestsDone(prs []domain.PullRequest) bool {
+	if len(prs) == 0 {
+		return false
+	}
+	for _, pr := range prs {
+		if pr.Status() != domain.PullRequestStatusClosed && pr.Status() != domain.PullRequestStatusMerged {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// IssueCreator is a version controller that can create issues
+type IssueCreator interface {
+	// CreateIssue creates an issue in the repository with the name in the format "owner/name"
+	CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error
+}
+
+// FailureIssues defines if, and where, issues should be created for failing repositories
+type FailureIssues struct {
+	Enabled bool
+	Repo    string // If set, all issues are created in this repository, otherwise in the failing repository
+
+	// If set, sensitive data, such as tokens, is removed from the error and script output included in the issues
+	Censor func(string) string
+}
+
+// maxIssueOutput is the maximum number of bytes, from the end of the script output, included in an issue
+const maxIssueOutput = 32 * 1024
+
+// scriptError is an error from running the script, containing the output of it
+type scriptError struct {
+	err    error
+	output string
+}
+
+func (e *scriptError) Error() string {
+	return e.err.Error()
+}
+
+func (e *scriptError) Cause() error {
+	return e.err
+}
+
+func (e *scriptError) Unwrap() error {
+	return e.err
+}
+
+// isFailure checks if an error is something that needs to be taken care of in the repository
+func isFailure(err error) bool {
+	if _, ok := err.(*dependencyError); ok {
+		return false
+	}
+	if isSkip(err) {
+		return false
+	}
+
+	switch err {
+	case nil, domain.NoChangeError, domain.BranchExistError, domain.AlreadyDoneError, errAborted, errKilled, errRejected:
+		return false
+	}
+	return true
+}
+
+// createFailureIssue creates an issue describing why the run failed in a repository
+func (r *Runner) createFailureIssue(ctx context.Context, repo domain.Repository, runErr error) error {
+	vc, ok := r.VersionController.(IssueCreator)
+	if !ok {
+		return errors.New("the platform does not support creating issues")
+	}
+
+	issueRepo := repo.FullName()
+	title := fmt.Sprintf("multi-gitter run %s failed", r.campaignName())
+	if r.FailureIssues.Repo != "" {
+		issueRepo = r.FailureIssues.Repo
+		title = fmt.Sprintf("multi-gitter run %s failed in %s", r.campaignName(), repo.FullName())
+	}
+
+	return vc.CreateIssue(ctx, issueRepo, domain.NewIssue{
+		Title: title,
+		Body:  r.failureIssueBody(repo, runErr),
+	})
+}
+
+func (r *Runner) campaignName() string {
+	if r.Provenance != nil && r.Provenance.CampaignID != "" {
+		return r.Provenance.CampaignID
+	}
+	return r.FeatureBranch
+}
+
+func (r *Runner) failureIssueBody(repo domain.Repository, runErr error) string {
+	censor := r.FailureIssues.Censor
+	if censor == nil {
+		censor = func(s string) string { return s }
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "The multi-gitter run `%s` failed in %s.\n\n", r.campaignName(), repo.FullName())
+	fmt.Fprintf(b, "**Error:** %s\n", censor(runErr.Error()))
+
+	var scriptErr *scriptError
+	if errors.As(runErr, &scriptErr) && scriptErr.output != "" {
+		// The output is censored before it is truncated, so that no part of a sensitive value is kept
+		output := censor(scriptErr.output)
+		if len(output) > maxIssueOutput {
+			output = "...\n" + output[len(output)-maxIssueOutput:]
+		}
+		fmt.Fprintf(b, "\n<details>\n<summary>Script output</summary>\n\n```\n%s\n```\n</details>\n", strings.TrimRight(output, "\n"))
+	}
+
+	if r.Provenance != nil {
+		b.WriteString(r.Provenance.footer())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package multigitter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// IssueFinder is a version controller that can find previously created issues
+type IssueFinder interface {
+	// IssueExists checks if an issue, open or closed, with exactly the title exists in the repository
+	IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error)
+}
+
+// IssueCampaign creates an issue in multiple repositories, instead of a pull request
+type IssueCampaign struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	TitleTemplate string // The title of the issues, as a Go template with the repository as data
+	BodyTemplate  string // The body of the issues, as a Go template with the repository as data
+	DryRun        bool   // If set, the issues that would be created are only shown
+	Concurrent    int
+}
+
+// IssueTemplateData is the data available in the templates of the title and body of issues
+type IssueTemplateData struct {
+	Repository    string // The full name of the repository
+	DefaultBranch string
+}
+
+// Create creates the issue in all repositories that does not already have an issue with the same title.
+// Closed issues are also taken into account, so issues that have been dealt with are not created again
+func (c IssueCampaign) Create(ctx context.Context) error {
+	creator, ok := c.VersionController.(IssueCreator)
+	if !ok {
+		return errors.New("the platform does not support creating issues")
+	}
+	finder, ok := c.VersionController.(IssueFinder)
+	if !ok {
+		return errors.New("the platform does not support finding existing issues")
+	}
+
+	titleTemplate, err := template.New("title").Option("missingkey=error").Parse(c.TitleTemplate)
+	if err != nil {
+		return errors.Wrap(err, "could not parse the issue title template")
+	}
+	bodyTemplate, err := template.New("body").Option("missingkey=error").Parse(c.BodyTemplate)
+	if err != nil {
+		return errors.Wrap(err, "could not parse the issue body template")
+	}
+
+	repos, err := getRepositories(ctx, c.VersionController, c.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = c.createIssue(ctx, creator, finder, titleTemplate, bodyTemplate, repos[i])
+	}, len(repos), c.Concurrent)
+
+	failed := printSettingsResults(c.Output, repos, results)
+	printSettingsSummary(c.Output, results, c.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not create the issue in %d repositories", failed)
+	}
+	return nil
+}
+
+func (c IssueCampaign) createIssue(
+	ctx context.Context,
+	creator IssueCreator,
+	finder IssueFinder,
+	titleTemplate, bodyTemplate *template.Template,
+	repo domain.Repository,
+) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	data := IssueTemplateData{
+		Repository:    repo.FullName(),
+		DefaultBranch: repo.DefaultBranch(),
+	}
+	title := &bytes.Buffer{}
+	if err := titleTemplate.Execute(title, data); err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not create the issue title")}
+	}
+	body := &bytes.Buffer{}
+	if err := bodyTemplate.Execute(body, data); err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not create the issue body")}
+	}
+
+	exists, err := finder.IssueExists(ctx, repo, title.String())
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not check if the issue exists")}
+	}
+	if exists {
+		return settingsResult{}
+	}
+
+	changes := []SettingChange{{
+		Name:    "issue",
+		Current: "none",
+		Desired: title.String(),
+	}}
+
+	if c.DryRun {
+		log.Info("Skipping creating the issue because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	log.Info("Creating the issue")
+	err = creator.CreateIssue(ctx, repo.FullName(), domain.NewIssue{
+		Title: title.String(),
+		Body:  body.String(),
+	})
+	if err != nil {
+		return settingsResult{changes: changes, err: errors.Wrap(err, "could not create the issue")}
+	}
+
+	return settingsResult{changes: changes}
+}
@@ -0,0 +1,39 @@
+package multigitter
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+var errKilled = errors.New("the script was killed since the run was aborted")
+
+// runKillable runs the command, and kills it, together with all processes it has started, if the context is done before it has finished.
+// The command is run in its own process group, which means that it does not receive the interrupt of the terminal and is allowed to finish
+func runKillable(ctx context.Context, cmd *exec.Cmd) error {
+	if ctx == nil {
+		return cmd.Run()
+	}
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+
+	err := cmd.Wait()
+	if err != nil && ctx.Err() != nil {
+		return errKilled
+	}
+	return err
+}
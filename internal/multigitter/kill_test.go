@@ -0,0 +1,49 @@
+package multigitter
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunKillable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the processes started by a script are not killed on Windows")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The sleep started by the shell keeps the output open, so it has to be killed as well for the command to finish
+	cmd := exec.Command("sh", "-c", "sleep 30; echo done")
+	cmd.Stdout = &discardWriter{}
+
+	errs := make(chan error)
+	go func() {
+		errs <- runKillable(ctx, cmd)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, errKilled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command was not killed")
+	}
+
+	require.NoError(t, runKillable(context.Background(), exec.Command("true")))
+}
+
+// discardWriter is a writer that is not a file, which makes the output of a command be copied until all processes have closed it
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
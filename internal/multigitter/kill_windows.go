@@ -0,0 +1,12 @@
+package multigitter
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing, since the processes started by the script can not be killed together with it on Windows
+func setProcessGroup(_ *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,34 @@
+package multigitter
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// PathLabel is a label that should be added to a pull request if any of the changed files matches the pattern
+type PathLabel struct {
+	Pattern string // A gitignore style pattern
+	Label   string
+}
+
+// pathLabels returns all labels which pattern matches any of the changed files
+func pathLabels(pathLabels []PathLabel, changedFiles []string) []string {
+	labels := []string{}
+	added := map[string]bool{}
+	for _, pl := range pathLabels {
+		if added[pl.Label] {
+			continue
+		}
+
+		pattern := gitignore.ParsePattern(pl.Pattern, nil)
+		for _, file := range changedFiles {
+			if pattern.Match(strings.Split(file, "/"), false) == gitignore.Exclude {
+				labels = append(labels, pl.Label)
+				added[pl.Label] = true
+				break
+			}
+		}
+	}
+	return labels
+}
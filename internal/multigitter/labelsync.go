@@ -0,0 +1,159 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// LabelManager is a version controller that can list, create and change the labels of repositories
+type LabelManager interface {
+	GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error)
+	CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error
+	// UpdateLabel changes the label with the current name, including its name
+	UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error
+}
+
+// LabelSyncer makes the labels of repositories match a set of labels
+type LabelSyncer struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Labels     []domain.Label
+	DryRun     bool // If set, the changes are only shown
+	Concurrent int
+}
+
+// labelUpdate is a label that has to be created, if currentName is empty, or updated
+type labelUpdate struct {
+	currentName string
+	label       domain.Label
+}
+
+// Sync shows the difference between the current and desired labels of all repositories, and changes them
+func (s LabelSyncer) Sync(ctx context.Context) error {
+	manager, ok := s.VersionController.(LabelManager)
+	if !ok {
+		return errors.New("the platform does not support changing labels")
+	}
+
+	repos, err := getRepositories(ctx, s.VersionController, s.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = s.syncRepository(ctx, manager, repos[i])
+	}, len(repos), s.Concurrent)
+
+	failed := printSettingsResults(s.Output, repos, results)
+	if failed > 0 {
+		return errors.Errorf("could not sync the labels of %d repositories", failed)
+	}
+	return nil
+}
+
+func (s LabelSyncer) syncRepository(ctx context.Context, manager LabelManager, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	log.Debug("Fetching the current labels")
+	current, err := manager.GetLabels(ctx, repo)
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the current labels")}
+	}
+
+	changes, updates := labelChanges(current, s.Labels)
+	if len(changes) == 0 {
+		return settingsResult{}
+	}
+
+	if s.DryRun {
+		log.Info("Skipping changing the labels because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	for _, update := range updates {
+		if update.currentName == "" {
+			log.WithField("label", update.label.Name).Info("Creating label")
+			err = manager.CreateLabel(ctx, repo, update.label)
+		} else {
+			log.WithField("label", update.currentName).Info("Updating label")
+			err = manager.UpdateLabel(ctx, repo, update.currentName, update.label)
+		}
+		if err != nil {
+			return settingsResult{changes: changes, err: errors.Wrapf(err, "could not change the label %s", update.label.Name)}
+		}
+	}
+
+	return settingsResult{changes: changes}
+}
+
+// labelChanges returns all changes needed to go from the current to the desired labels, and how to make them
+func labelChanges(current, desired []domain.Label) ([]SettingChange, []labelUpdate) {
+	var changes []SettingChange
+	var updates []labelUpdate
+
+	for _, label := range desired {
+		label.Color = normalizeColor(label.Color)
+
+		currentLabel := findLabel(current, label.Name)
+		if currentLabel == nil {
+			for _, previousName := range label.PreviousNames {
+				if currentLabel = findLabel(current, previousName); currentLabel != nil {
+					break
+				}
+			}
+		}
+
+		if currentLabel != nil && formatLabel(currentLabel) == formatLabel(&label) {
+			continue
+		}
+
+		update := labelUpdate{label: label}
+		if currentLabel != nil {
+			update.currentName = currentLabel.Name
+		}
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("label %s", label.Name),
+			Current: formatLabel(currentLabel),
+			Desired: formatLabel(&label),
+		})
+		updates = append(updates, update)
+	}
+
+	return changes, updates
+}
+
+// findLabel finds a label by its name. The name is case insensitive, as it is on most platforms
+func findLabel(labels []domain.Label, name string) *domain.Label {
+	for i := range labels {
+		if strings.EqualFold(labels[i].Name, name) {
+			return &labels[i]
+		}
+	}
+	return nil
+}
+
+func normalizeColor(color string) string {
+	return strings.ToLower(strings.TrimPrefix(color, "#"))
+}
+
+func formatLabel(label *domain.Label) string {
+	if label == nil {
+		return "none"
+	}
+	description := label.Description
+	if description == "" {
+		description = "none"
+	}
+	return fmt.Sprintf("name: %s, color: %s, description: %s", label.Name, normalizeColor(label.Color), description)
+}
@@ -0,0 +1,49 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestLabelChanges(t *testing.T) {
+	current := []domain.Label{
+		{Name: "Bug", Color: "D73A4A"},
+		{Name: "old-name", Color: "ffffff"},
+		{Name: "new-name", Color: "000000"},
+		{Name: "enhancement", Color: "a2eeef"},
+	}
+
+	desired := []domain.Label{
+		{Name: "bug", Color: "#d73a4a"},
+		{Name: "new-name", Color: "000000", PreviousNames: []string{"old-name"}},
+		{Name: "renamed", Color: "a2eeef", PreviousNames: []string{"missing", "enhancement"}},
+		{Name: "created", Color: "cccccc", Description: "A new label"},
+	}
+
+	changes, updates := labelChanges(current, desired)
+	assert.Equal(t, []SettingChange{
+		{
+			Name:    "label bug",
+			Current: "name: Bug, color: d73a4a, description: none",
+			Desired: "name: bug, color: d73a4a, description: none",
+		},
+		{
+			Name:    "label renamed",
+			Current: "name: enhancement, color: a2eeef, description: none",
+			Desired: "name: renamed, color: a2eeef, description: none",
+		},
+		{
+			Name:    "label created",
+			Current: "none",
+			Desired: "name: created, color: cccccc, description: A new label",
+		},
+	}, changes)
+
+	assert.Len(t, updates, 3)
+	assert.Equal(t, "Bug", updates[0].currentName)
+	assert.Equal(t, "enhancement", updates[1].currentName)
+	assert.Equal(t, "", updates[2].currentName)
+}
@@ -0,0 +1,99 @@
+package multigitter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// PullRequestLimiter is a version controller that limits the size of pull requests
+type PullRequestLimiter interface {
+	PullRequestLimits() domain.PullRequestLimits
+}
+
+// PullRequestRules are rules that the title and body of pull requests are validated against before the run starts
+type PullRequestRules struct {
+	MaxTitleLength   int      // If set to zero, only the limit of the platform is used
+	MaxBodyLength    int      // If set to zero, only the limit of the platform is used
+	RequiredSections []string // Headings that must exist in the body
+	ForbiddenWords   []string // Words that may not exist in the title or the body
+}
+
+// ValidatePullRequest validates the pull request title and body against the limits of the platform and the configured rules
+func (r *Runner) ValidatePullRequest() error {
+	if r.SkipPullRequest {
+		return nil
+	}
+
+	rules := r.PullRequestRules
+	if limiter, ok := r.VersionController.(PullRequestLimiter); ok {
+		limits := limiter.PullRequestLimits()
+		rules.MaxTitleLength = minLimit(rules.MaxTitleLength, limits.MaxTitleLength)
+		rules.MaxBodyLength = minLimit(rules.MaxBodyLength, limits.MaxBodyLength)
+	}
+
+	problems := rules.validate(r.PullRequestTitle, r.pullRequestBody(r.PullRequestBody))
+	for _, template := range r.PullRequestTemplates {
+		for _, problem := range rules.validate(template.Title, r.pullRequestBody(template.Body)) {
+			problems = append(problems, fmt.Sprintf("%s: %s", template, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("the pull request is not valid:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func (rules PullRequestRules) validate(title, body string) []string {
+	var problems []string
+
+	if length := utf8.RuneCountInString(title); rules.MaxTitleLength > 0 && length > rules.MaxTitleLength {
+		problems = append(problems, fmt.Sprintf("the title is %d characters long, the maximum is %d", length, rules.MaxTitleLength))
+	}
+	if length := utf8.RuneCountInString(body); rules.MaxBodyLength > 0 && length > rules.MaxBodyLength {
+		problems = append(problems, fmt.Sprintf("the body is %d characters long, the maximum is %d", length, rules.MaxBodyLength))
+	}
+
+	for _, section := range rules.RequiredSections {
+		if !hasSection(body, section) {
+			problems = append(problems, fmt.Sprintf(`the body is missing the section "%s"`, section))
+		}
+	}
+
+	for _, word := range rules.ForbiddenWords {
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+		if re.MatchString(title) || re.MatchString(body) {
+			problems = append(problems, fmt.Sprintf(`the forbidden word "%s" is used`, word))
+		}
+	}
+
+	return problems
+}
+
+// hasSection checks if the body contains a markdown heading with the section name
+func hasSection(body, section string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		heading := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if strings.EqualFold(heading, section) {
+			return true
+		}
+	}
+	return false
+}
+
+// minLimit returns the lowest of two limits, where zero means that there is no limit
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
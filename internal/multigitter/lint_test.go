@@ -0,0 +1,67 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    PullRequestRules
+		title    string
+		body     string
+		problems []string
+	}{
+		{
+			name:  "no rules",
+			title: "Update dependencies",
+			body:  "Some body",
+		},
+		{
+			name:     "too long title",
+			rules:    PullRequestRules{MaxTitleLength: 5},
+			title:    "Update dependencies",
+			problems: []string{"the title is 19 characters long, the maximum is 5"},
+		},
+		{
+			name:     "too long body",
+			rules:    PullRequestRules{MaxBodyLength: 3},
+			title:    "Update",
+			body:     "åäöü",
+			problems: []string{"the body is 4 characters long, the maximum is 3"},
+		},
+		{
+			name:  "required sections",
+			rules: PullRequestRules{RequiredSections: []string{"Motivation", "Testing"}},
+			title: "Update",
+			body:  "## motivation\nBecause\n\nTesting is done",
+			problems: []string{
+				`the body is missing the section "Testing"`,
+			},
+		},
+		{
+			name:  "forbidden words",
+			rules: PullRequestRules{ForbiddenWords: []string{"wip", "hack"}},
+			title: "WIP: Update",
+			body:  "No hacks here",
+			problems: []string{
+				`the forbidden word "wip" is used`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.problems, test.rules.validate(test.title, test.body))
+		})
+	}
+}
+
+func TestMinLimit(t *testing.T) {
+	assert.Equal(t, 0, minLimit(0, 0))
+	assert.Equal(t, 10, minLimit(0, 10))
+	assert.Equal(t, 10, minLimit(10, 0))
+	assert.Equal(t, 5, minLimit(10, 5))
+}
@@ -0,0 +1,45 @@
+package multigitter
+
+import (
+	"time"
+)
+
+// systemLoad is the load of the system, used to adapt the number of concurrent runs
+type systemLoad struct {
+	cpu             float64 // The load average of the last minute, divided by the number of cpus
+	memoryAvailable float64 // The fraction of the memory that is available
+	ioPressure      float64 // The percentage of the last 10 seconds that processes waited for disk I/O
+}
+
+// Thresholds of the system load, within which the number of concurrent runs is kept as it is
+const (
+	maxCPULoad            = 1.0
+	minCPULoad            = 0.7
+	minMemoryAvailable    = 0.1
+	targetMemoryAvailable = 0.2
+	maxIOPressure         = 20
+	targetIOPressure      = 10
+)
+
+// adaptInterval is the time between each adaption of the number of concurrent runs.
+// The load average is calculated over a minute, so changes take a while to be seen
+const adaptInterval = 10 * time.Second
+
+// adaptConcurrency returns the number of concurrent runs, between min and max, that should be used with the system load.
+// The number is decreased by one if the system is overloaded, and increased by one if there is room for more runs
+func adaptConcurrency(current, min, max int, load systemLoad) int {
+	switch {
+	case load.cpu > maxCPULoad || load.memoryAvailable < minMemoryAvailable || load.ioPressure > maxIOPressure:
+		current--
+	case load.cpu < minCPULoad && load.memoryAvailable > targetMemoryAvailable && load.ioPressure < targetIOPressure:
+		current++
+	}
+
+	if current < min {
+		return min
+	}
+	if current > max {
+		return max
+	}
+	return current
+}
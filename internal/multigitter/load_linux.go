@@ -0,0 +1,78 @@
+package multigitter
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// readSystemLoad reads the load of the system from /proc
+func readSystemLoad() (systemLoad, error) {
+	var load systemLoad
+
+	loadavg, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return systemLoad{}, err
+	}
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return systemLoad{}, errors.New("could not parse /proc/loadavg")
+	}
+	avg, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return systemLoad{}, errors.Wrap(err, "could not parse /proc/loadavg")
+	}
+	load.cpu = avg / float64(runtime.NumCPU())
+
+	meminfo, err := readProcValues("/proc/meminfo")
+	if err != nil {
+		return systemLoad{}, err
+	}
+	if meminfo["MemTotal:"] > 0 {
+		load.memoryAvailable = meminfo["MemAvailable:"] / meminfo["MemTotal:"]
+	}
+
+	// Pressure stall information is not available on older kernels, in which case disk I/O is not taken into account
+	if pressure, err := readProcValues("/proc/pressure/io"); err == nil {
+		load.ioPressure = pressure["some avg10"]
+	}
+
+	return load, nil
+}
+
+// readProcValues reads the numeric values of a file in /proc, in the format "Key: value unit" or "key avg10=value ...".
+// The values of the second format are keyed by the first field and the name of the value, for example "some avg10"
+func readProcValues(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]float64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			split := strings.SplitN(field, "=", 2)
+			if len(split) != 2 {
+				if value, err := strconv.ParseFloat(field, 64); err == nil {
+					values[fields[0]] = value
+				}
+				break
+			}
+			if value, err := strconv.ParseFloat(split[1], 64); err == nil {
+				values[fields[0]+" "+split[0]] = value
+			}
+		}
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package multigitter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProcValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multi-gitter-proc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	meminfo := filepath.Join(dir, "meminfo")
+	require.NoError(t, ioutil.WriteFile(meminfo, []byte("MemTotal:        6158152 kB\nMemFree:         2599676 kB\nMemAvailable:    5501364 kB\nHugePages_Total:       0\n"), 0600))
+	values, err := readProcValues(meminfo)
+	require.NoError(t, err)
+	assert.Equal(t, 6158152.0, values["MemTotal:"])
+	assert.Equal(t, 5501364.0, values["MemAvailable:"])
+	assert.Equal(t, 0.0, values["HugePages_Total:"])
+
+	pressure := filepath.Join(dir, "io")
+	require.NoError(t, ioutil.WriteFile(pressure, []byte("some avg10=12.76 avg60=0.76 avg300=0.38 total=42505379\nfull avg10=0.08 avg60=0.12 avg300=0.08 total=17468120\n"), 0600))
+	values, err = readProcValues(pressure)
+	require.NoError(t, err)
+	assert.Equal(t, 12.76, values["some avg10"])
+	assert.Equal(t, 0.08, values["full avg10"])
+}
+
+func TestReadSystemLoad(t *testing.T) {
+	load, err := readSystemLoad()
+	require.NoError(t, err)
+	assert.Greater(t, load.memoryAvailable, 0.0)
+	assert.LessOrEqual(t, load.memoryAvailable, 1.0)
+}
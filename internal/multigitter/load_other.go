@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package multigitter
+
+import (
+	"github.com/pkg/errors"
+)
+
+// readSystemLoad returns an error, since the load of the system is only read on Linux
+func readSystemLoad() (systemLoad, error) {
+	return systemLoad{}, errors.New("the system load can only be read on Linux")
+}
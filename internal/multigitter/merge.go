@@ -2,22 +2,51 @@ package multigitter
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/lindell/multi-gitter/internal/domain"
 )
 
+// MergeTypeMerger is a version controller that can merge a pull request with specific merge types
+type MergeTypeMerger interface {
+	// MergePullRequestWithTypes merges a pull request with the first of the merge types that is allowed in the repository
+	MergePullRequestWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error
+}
+
+// PullRequestCommitGetter is a version controller that can get the commits of a pull request
+type PullRequestCommitGetter interface {
+	GetPullRequestCommits(ctx context.Context, pr domain.PullRequest) ([]domain.Commit, error)
+}
+
 // Merger merges pull requests in an organization
 type Merger struct {
 	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
 
 	FeatureBranch string
+	CampaignID    string  // If set, pull requests are found by their campaign instead of the branch name
+	Report        *Report // If set, the pull requests in the report of a run are used instead of searching for them
+	DryRun        bool    // If set, the pull requests that would be merged are only logged
+
+	MergeTypes         []domain.MergeType            // The merge types in order of preference
+	AutoMergeType      bool                          // If set, the merge type of each pull request is chosen based on its commits
+	MergeTypeOverrides map[string][]domain.MergeType // The merge types used for specific repositories, by the name in the format "owner/name"
+
+	Dependencies Dependencies // Pull requests are merged after, and only if, the pull requests of the repositories they depend on are merged
+
+	Summary io.Writer // If set, a table with the outcome of each pull request is written to it when the merge is done
+
+	Backporter *Backporter // If set, the changes of the pull requests that are merged are backported to other branches
 }
 
 // Merge merges pull requests in an organization
 func (s Merger) Merge(ctx context.Context) error {
-	prs, err := s.VersionController.GetPullRequests(ctx, s.FeatureBranch)
+	prs, err := getPullRequests(ctx, s.VersionController, s.FeatureBranch, s.CampaignID, s.Report, s.Filter)
 	if err != nil {
 		return err
 	}
@@ -29,15 +58,138 @@ func (s Merger) Merge(ctx context.Context) error {
 		}
 	}
 
+	// Unmerged pull requests of dependencies prevent the pull requests that depend on them from being merged
+	unmerged := map[string]bool{}
+	if len(s.Dependencies) > 0 {
+		successPrs, err = sortPullRequestsByDependencies(successPrs, s.Dependencies)
+		if err != nil {
+			return err
+		}
+		for _, pr := range prs {
+			if pr.Status() != domain.PullRequestStatusMerged && pr.Status() != domain.PullRequestStatusClosed {
+				unmerged[pr.RepositoryName()] = true
+			}
+		}
+	}
+
+	summary := &summaryCollector{}
+	if s.Summary != nil {
+		defer summary.write(s.Summary)
+	}
+
 	log.Infof("Merging %d pull requests", len(successPrs))
 
+	merged := make([]domain.PullRequest, 0, len(successPrs))
+
 	for _, pr := range successPrs {
-		log.WithField("pr", pr.String()).Infof("Merging")
-		err := s.VersionController.MergePullRequest(ctx, pr)
+		start := time.Now()
+		mergeTypes, err := s.mergeTypes(ctx, pr)
+		if err != nil {
+			return err
+		}
+
+		logger := log.WithField("pr", pr.String())
+		if mergeTypes != nil {
+			logger = logger.WithField("merge-type", mergeTypes[0].String())
+		}
+
+		var repoName string
+		if len(s.Dependencies) > 0 {
+			repoName = pr.RepositoryName()
+			if dependency := s.unmergedDependency(repoName, unmerged); dependency != "" {
+				logger.Infof("Skipping merging since the pull request of the dependency %s is not merged", dependency)
+				reason := fmt.Sprintf("the pull request of the dependency %s is not merged", dependency)
+				summary.add(pullRequestSummaryRow(pr, ReportStatusSkipped, reason, start))
+				continue
+			}
+		}
+
+		if s.DryRun {
+			logger.Infof("Skipping merging because of dry run")
+			summary.add(pullRequestSummaryRow(pr, ReportStatusSkipped, "dry run", start))
+			delete(unmerged, repoName)
+			continue
+		}
+
+		logger.Infof("Merging")
+		if mergeTypes == nil {
+			err = s.VersionController.MergePullRequest(ctx, pr)
+		} else {
+			err = s.mergeWithTypes(ctx, pr, mergeTypes)
+		}
 		if err != nil {
+			summary.add(pullRequestErrorSummaryRow(pr, err, start))
 			return err
 		}
+		summary.add(pullRequestSummaryRow(pr, "merged", "", start))
+		delete(unmerged, repoName)
+		merged = append(merged, pr)
+	}
+
+	if s.Backporter != nil && len(merged) > 0 {
+		return s.Backporter.Backport(ctx, merged)
 	}
 
 	return nil
 }
+
+// unmergedDependency returns a dependency of the repository that has an unmerged pull request, if any
+func (s Merger) unmergedDependency(repoName string, unmerged map[string]bool) string {
+	for _, dependency := range s.Dependencies[repoName] {
+		if unmerged[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// mergeTypes returns the merge types that should be used for a specific pull request,
+// or nil if the merge types of the version controller should be used
+func (s Merger) mergeTypes(ctx context.Context, pr domain.PullRequest) ([]domain.MergeType, error) {
+	if len(s.MergeTypeOverrides) > 0 {
+		if mergeTypes, ok := s.MergeTypeOverrides[pr.RepositoryName()]; ok {
+			return mergeTypes, nil
+		}
+	}
+
+	if !s.AutoMergeType {
+		return nil, nil
+	}
+
+	vc, ok := s.VersionController.(PullRequestCommitGetter)
+	if !ok {
+		return nil, errors.New("the platform does not support choosing the merge type automatically")
+	}
+
+	commits, err := vc.GetPullRequestCommits(ctx, pr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the commits of %s", pr.String())
+	}
+
+	// A series of commits without any merge commit can be rebased, everything else is squashed
+	preferred := domain.MergeTypeRebase
+	if len(commits) <= 1 {
+		preferred = domain.MergeTypeSquash
+	}
+	for _, commit := range commits {
+		if commit.Parents > 1 {
+			preferred = domain.MergeTypeSquash
+		}
+	}
+
+	mergeTypes := []domain.MergeType{preferred}
+	for _, mt := range s.MergeTypes {
+		if mt != preferred {
+			mergeTypes = append(mergeTypes, mt)
+		}
+	}
+	return mergeTypes, nil
+}
+
+func (s Merger) mergeWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error {
+	vc, ok := s.VersionController.(MergeTypeMerger)
+	if !ok {
+		return errors.New("the platform does not support choosing the merge type of each pull request")
+	}
+	return vc.MergePullRequestWithTypes(ctx, pr, mergeTypes)
+}
@@ -0,0 +1,74 @@
+package multigitter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// branchPullRequest is a pull request that is presented as "owner/name:branch", as on the platforms without pull request numbers
+type branchPullRequest struct {
+	repoName   string
+	branchName string
+}
+
+func (pr branchPullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusSuccess
+}
+
+func (pr branchPullRequest) String() string {
+	return pr.repoName + ":" + pr.branchName
+}
+
+func (pr branchPullRequest) RepositoryName() string {
+	return pr.repoName
+}
+
+// testMerger stores the merge types each pull request was merged with
+type testMerger struct {
+	VersionController
+	prs        []domain.PullRequest
+	mergeTypes map[string]domain.MergeType
+}
+
+func (m *testMerger) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	return m.prs, nil
+}
+
+func (m *testMerger) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	m.mergeTypes[pr.String()] = domain.MergeTypeMerge
+	return nil
+}
+
+func (m *testMerger) MergePullRequestWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error {
+	m.mergeTypes[pr.String()] = mergeTypes[0]
+	return nil
+}
+
+func TestMergeTypeOverrides(t *testing.T) {
+	vc := &testMerger{
+		prs: []domain.PullRequest{
+			branchPullRequest{repoName: "owner/override", branchName: "feature"},
+			branchPullRequest{repoName: "owner/other", branchName: "feature"},
+		},
+		mergeTypes: map[string]domain.MergeType{},
+	}
+
+	err := Merger{
+		VersionController: vc,
+		FeatureBranch:     "feature",
+		MergeTypeOverrides: map[string][]domain.MergeType{
+			"owner/override": {domain.MergeTypeSquash},
+		},
+	}.Merge(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]domain.MergeType{
+		"owner/override:feature": domain.MergeTypeSquash,
+		"owner/other:feature":    domain.MergeTypeMerge,
+	}, vc.mergeTypes)
+}
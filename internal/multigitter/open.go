@@ -0,0 +1,152 @@
+package multigitter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// OpenState is a state that pull requests can be filtered on before being opened
+type OpenState string
+
+// All OpenStates
+const (
+	OpenStateAll          OpenState = "all"
+	OpenStateOpen         OpenState = "open"
+	OpenStateFailedChecks OpenState = "failed-checks"
+	OpenStatePending      OpenState = "pending"
+	OpenStateMerged       OpenState = "merged"
+	OpenStateClosed       OpenState = "closed"
+)
+
+// OpenStates contains all OpenStates that can be used
+var OpenStates = []OpenState{
+	OpenStateAll,
+	OpenStateOpen,
+	OpenStateFailedChecks,
+	OpenStatePending,
+	OpenStateMerged,
+	OpenStateClosed,
+}
+
+// ParseOpenState parses an open state
+func ParseOpenState(str string) (OpenState, error) {
+	for _, state := range OpenStates {
+		if string(state) == str {
+			return state, nil
+		}
+	}
+	return "", errors.Errorf(`unknown state "%s"`, str)
+}
+
+func (s OpenState) matches(status domain.PullRequestStatus) bool {
+	switch s {
+	case OpenStateAll:
+		return true
+	case OpenStateOpen:
+		return status != domain.PullRequestStatusClosed && status != domain.PullRequestStatusMerged
+	case OpenStateFailedChecks:
+		return status == domain.PullRequestStatusError
+	case OpenStatePending:
+		return status == domain.PullRequestStatusPending
+	case OpenStateMerged:
+		return status == domain.PullRequestStatusMerged
+	case OpenStateClosed:
+		return status == domain.PullRequestStatusClosed
+	}
+	return false
+}
+
+// Opener opens pull requests in the browser
+type Opener struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer // The urls are printed here if PrintOnly is set
+	Input  io.Reader // Used to wait for confirmation between batches
+	Prompt io.Writer // The confirmation between batches is asked for here
+
+	FeatureBranch string
+	CampaignID    string  // If set, pull requests are found by their campaign instead of the branch name
+	Report        *Report // If set, the pull requests in the report of a run are used instead of searching for them
+	State         OpenState
+	PrintOnly     bool // If set, the urls will only be printed and not opened
+	BatchSize     int  // The number of pull requests opened before waiting for confirmation, if set to zero, all are opened at once
+
+	openURL func(url string) error // Opens a url, the browser is used if not set
+}
+
+// Open opens pull requests in the browser
+func (o Opener) Open(ctx context.Context) error {
+	prs, err := getPullRequests(ctx, o.VersionController, o.FeatureBranch, o.CampaignID, o.Report, o.Filter)
+	if err != nil {
+		return err
+	}
+
+	urls := []string{}
+	for _, pr := range prs {
+		if !o.State.matches(pr.Status()) {
+			continue
+		}
+
+		u, ok := pr.(urler)
+		if !ok {
+			log.WithField("pr", pr.String()).Warn("Pull request has no url")
+			continue
+		}
+		urls = append(urls, u.URL())
+	}
+
+	if o.PrintOnly {
+		for _, url := range urls {
+			fmt.Fprintln(o.Output, url)
+		}
+		return nil
+	}
+
+	log.Infof("Opening %d pull requests", len(urls))
+
+	openURL := o.openURL
+	if openURL == nil {
+		openURL = openBrowser
+	}
+
+	reader := bufio.NewReader(o.Input)
+	for i, url := range urls {
+		if o.BatchSize > 0 && i > 0 && i%o.BatchSize == 0 {
+			fmt.Fprintf(o.Prompt, "Opened %d of %d pull requests. Press enter to continue.", i, len(urls))
+			if _, err := reader.ReadString('\n'); err != nil {
+				return err
+			}
+		}
+
+		if err := openURL(url); err != nil {
+			return errors.Wrapf(err, "could not open %s", url)
+		}
+	}
+
+	return nil
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,140 @@
+package multigitter
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// urlPullRequest is a pull request with a url that can be opened
+type urlPullRequest struct {
+	repoName string
+	status   domain.PullRequestStatus
+}
+
+func (pr urlPullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr urlPullRequest) String() string {
+	return pr.repoName
+}
+
+func (pr urlPullRequest) RepositoryName() string {
+	return pr.repoName
+}
+
+func (pr urlPullRequest) URL() string {
+	return "https://example.com/" + pr.repoName
+}
+
+// testOpenVC returns the same pull requests for every branch
+type testOpenVC struct {
+	VersionController
+	prs []domain.PullRequest
+}
+
+func (vc testOpenVC) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	return vc.prs, nil
+}
+
+func TestOpen_PrintOnly(t *testing.T) {
+	vc := testOpenVC{
+		prs: []domain.PullRequest{
+			urlPullRequest{repoName: "owner/open", status: domain.PullRequestStatusPending},
+			urlPullRequest{repoName: "owner/merged", status: domain.PullRequestStatusMerged},
+			branchPullRequest{repoName: "owner/no-url", branchName: "feature"},
+		},
+	}
+
+	output := &bytes.Buffer{}
+	err := Opener{
+		VersionController: vc,
+		Output:            output,
+		FeatureBranch:     "feature",
+		State:             OpenStateOpen,
+		PrintOnly:         true,
+		openURL: func(url string) error {
+			t.Errorf("%s should not be opened when only printing", url)
+			return nil
+		},
+	}.Open(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/owner/open\n", output.String())
+}
+
+func TestOpen_Batches(t *testing.T) {
+	vc := testOpenVC{
+		prs: []domain.PullRequest{
+			urlPullRequest{repoName: "owner/repo1", status: domain.PullRequestStatusSuccess},
+			urlPullRequest{repoName: "owner/repo2", status: domain.PullRequestStatusSuccess},
+			urlPullRequest{repoName: "owner/repo3", status: domain.PullRequestStatusSuccess},
+			urlPullRequest{repoName: "owner/repo4", status: domain.PullRequestStatusSuccess},
+			urlPullRequest{repoName: "owner/repo5", status: domain.PullRequestStatusSuccess},
+		},
+	}
+
+	output := &bytes.Buffer{}
+	prompt := &bytes.Buffer{}
+	opened := []string{}
+	err := Opener{
+		VersionController: vc,
+		Output:            output,
+		Input:             strings.NewReader("\n\n"),
+		Prompt:            prompt,
+		FeatureBranch:     "feature",
+		State:             OpenStateAll,
+		BatchSize:         2,
+		openURL: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
+	}.Open(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://example.com/owner/repo1",
+		"https://example.com/owner/repo2",
+		"https://example.com/owner/repo3",
+		"https://example.com/owner/repo4",
+		"https://example.com/owner/repo5",
+	}, opened)
+	assert.Equal(t,
+		"Opened 2 of 5 pull requests. Press enter to continue."+
+			"Opened 4 of 5 pull requests. Press enter to continue.",
+		prompt.String(),
+	)
+	assert.Empty(t, output.String(), "the prompt should not be written to the output")
+}
+
+func TestOpen_BatchesWithoutConfirmation(t *testing.T) {
+	vc := testOpenVC{
+		prs: []domain.PullRequest{
+			urlPullRequest{repoName: "owner/repo1", status: domain.PullRequestStatusSuccess},
+			urlPullRequest{repoName: "owner/repo2", status: domain.PullRequestStatusSuccess},
+		},
+	}
+
+	opened := []string{}
+	err := Opener{
+		VersionController: vc,
+		Input:             strings.NewReader(""),
+		Prompt:            &bytes.Buffer{},
+		FeatureBranch:     "feature",
+		State:             OpenStateAll,
+		BatchSize:         1,
+		openURL: func(url string) error {
+			opened = append(opened, url)
+			return nil
+		},
+	}.Open(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []string{"https://example.com/owner/repo1"}, opened, "no more pull requests should be opened without confirmation")
+}
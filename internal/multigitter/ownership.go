@@ -0,0 +1,226 @@
+package multigitter
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// The formats an ownership report can be written in
+const (
+	OwnershipFormatJSON     = "json"
+	OwnershipFormatMarkdown = "markdown"
+)
+
+// codeOwnersLocations are the locations, in order, where a CODEOWNERS file is searched for
+var codeOwnersLocations = []string{
+	"CODEOWNERS",
+	filepath.Join(".github", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+// OwnershipReport lists, for each owner, the changed files they own
+type OwnershipReport struct {
+	Owners []OwnerReport `json:"owners"`
+}
+
+// OwnerReport is the changes owned by a single owner. Changes without any owner have an empty owner
+type OwnerReport struct {
+	Owner        string            `json:"owner"`
+	Repositories []OwnedRepository `json:"repositories"`
+}
+
+// OwnedRepository is the changed files in a repository owned by an owner
+type OwnedRepository struct {
+	Repository  string   `json:"repository"`
+	PullRequest string   `json:"pull_request,omitempty"`
+	Files       []string `json:"files"`
+}
+
+type codeOwnersRule struct {
+	pattern gitignore.Pattern
+	owners  []string
+}
+
+// readCodeOwners reads the rules of the CODEOWNERS file in a repository, if any exist
+func readCodeOwners(dir string) ([]codeOwnersRule, error) {
+	for _, location := range codeOwnersLocations {
+		f, err := os.Open(filepath.Join(dir, location))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseCodeOwners(f)
+	}
+	return nil, nil
+}
+
+func parseCodeOwners(r io.Reader) ([]codeOwnersRule, error) {
+	var rules []codeOwnersRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		rules = append(rules, codeOwnersRule{
+			pattern: gitignore.ParsePattern(fields[0], nil),
+			owners:  fields[1:],
+		})
+	}
+	return rules, scanner.Err()
+}
+
+// fileOwners returns the owners of a file, the last matching rule takes precedence
+func fileOwners(rules []codeOwnersRule, file string) []string {
+	path := strings.Split(file, "/")
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.Match(path, false) == gitignore.Exclude {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// ownershipCollector collects the owners of changed files in repositories that are run concurrently
+type ownershipCollector struct {
+	lock         sync.Mutex
+	changes      map[string]map[string][]string // repository -> owner -> files
+	pullRequests map[string]string
+}
+
+func newOwnershipCollector() *ownershipCollector {
+	return &ownershipCollector{
+		changes:      map[string]map[string][]string{},
+		pullRequests: map[string]string{},
+	}
+}
+
+// setChangedFiles maps the changed files of a repository to the owners in the CODEOWNERS file of it
+func (oc *ownershipCollector) setChangedFiles(dir string, repo domain.Repository, changedFiles []string) error {
+	rules, err := readCodeOwners(dir)
+	if err != nil {
+		return errors.Wrap(err, "could not read CODEOWNERS")
+	}
+
+	owned := map[string][]string{}
+	for _, file := range changedFiles {
+		owners := fileOwners(rules, file)
+		if len(owners) == 0 {
+			owners = []string{""}
+		}
+		for _, owner := range owners {
+			owned[owner] = append(owned[owner], file)
+		}
+	}
+
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+	oc.changes[repo.FullName()] = owned
+	return nil
+}
+
+// add sets the result of a repository, only the changes of successful repositories are part of the report
+func (oc *ownershipCollector) add(repo domain.Repository, pr domain.PullRequest, err error) {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+
+	if err != nil {
+		delete(oc.changes, repo.FullName())
+	} else if pr != nil {
+		oc.pullRequests[repo.FullName()] = pr.String()
+	}
+}
+
+func (oc *ownershipCollector) report() OwnershipReport {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+
+	owners := map[string]*OwnerReport{}
+	for repo, owned := range oc.changes {
+		for owner, files := range owned {
+			if owners[owner] == nil {
+				owners[owner] = &OwnerReport{Owner: owner}
+			}
+			owners[owner].Repositories = append(owners[owner].Repositories, OwnedRepository{
+				Repository:  repo,
+				PullRequest: oc.pullRequests[repo],
+				Files:       files,
+			})
+		}
+	}
+
+	report := OwnershipReport{
+		Owners: make([]OwnerReport, 0, len(owners)),
+	}
+	for _, owner := range owners {
+		sort.Slice(owner.Repositories, func(i, j int) bool {
+			return owner.Repositories[i].Repository < owner.Repositories[j].Repository
+		})
+		report.Owners = append(report.Owners, *owner)
+	}
+	// Changes without any owner are placed last
+	sort.Slice(report.Owners, func(i, j int) bool {
+		if report.Owners[i].Owner == "" || report.Owners[j].Owner == "" {
+			return report.Owners[j].Owner == ""
+		}
+		return report.Owners[i].Owner < report.Owners[j].Owner
+	})
+	return report
+}
+
+func (oc *ownershipCollector) write(w io.Writer, format string) error {
+	report := oc.report()
+
+	if format == OwnershipFormatMarkdown {
+		return report.writeMarkdown(w)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func (r OwnershipReport) writeMarkdown(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("# Ownership report\n")
+	for _, owner := range r.Owners {
+		if owner.Owner == "" {
+			b.WriteString("\n## Without owner\n\n")
+		} else {
+			fmt.Fprintf(b, "\n## %s\n\n", owner.Owner)
+		}
+
+		for _, repo := range owner.Repositories {
+			if repo.PullRequest != "" {
+				fmt.Fprintf(b, "- %s (%s)\n", repo.Repository, repo.PullRequest)
+			} else {
+				fmt.Fprintf(b, "- %s\n", repo.Repository)
+			}
+			for _, file := range repo.Files {
+				fmt.Fprintf(b, "  - `%s`\n", file)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,27 @@
+package multigitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileOwners(t *testing.T) {
+	rules, err := parseCodeOwners(strings.NewReader(`# Default owners
+*       @org/everyone
+
+/docs/  @org/docs # Documentation
+*.go    @org/gophers @someone
+/build/logs/
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@org/everyone"}, fileOwners(rules, "README.md"))
+	assert.Equal(t, []string{"@org/docs"}, fileOwners(rules, "docs/index.md"))
+	assert.Equal(t, []string{"@org/gophers", "@someone"}, fileOwners(rules, "docs/main.go"))
+	assert.Equal(t, []string{"@org/gophers", "@someone"}, fileOwners(rules, "cmd/root.go"))
+	assert.Empty(t, fileOwners(rules, "build/logs/out.log"))
+	assert.Empty(t, fileOwners(nil, "README.md"))
+}
@@ -0,0 +1,113 @@
+package multigitter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// applyPatch applies a patch, created with git diff, to the repository in the directory
+func applyPatch(dir string, patch string) error {
+	if err := gitApply(dir, patch); err != nil {
+		return errors.WithMessage(err, "could not apply the patch")
+	}
+	return nil
+}
+
+// revertPatch applies the reverse of a patch, created with git diff, to the repository in the directory
+func revertPatch(dir string, patch string) error {
+	if err := gitApply(dir, patch, "--reverse"); err != nil {
+		return errors.WithMessage(err, "could not revert the changes, they might have been changed since")
+	}
+	return nil
+}
+
+func gitApply(dir string, patch string, args ...string) error {
+	cmd := exec.Command("git", append(append([]string{"apply", "--whitespace=nowarn"}, args...), "-")...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch)
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := cmd.Run(); err != nil {
+		return errors.New(strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// CommitPatch returns the changes and the message of a commit. If the commit is a range, in the format "from..to",
+// the changes between the two revisions are returned instead, without any message. The repository can be the path
+// of a local repository, or an url it can be fetched from
+func CommitPatch(repository string, commit string) (patch string, message string, err error) {
+	from, to := "", commit
+	if split := strings.SplitN(commit, "..", 2); len(split) == 2 {
+		from, to = split[0], split[1]
+	}
+
+	dir := repository
+	if stat, err := os.Stat(repository); err != nil || !stat.IsDir() {
+		dir, err = ioutil.TempDir(os.TempDir(), "multi-git-cherry-pick-")
+		if err != nil {
+			return "", "", err
+		}
+		defer os.RemoveAll(dir)
+
+		if _, err := gitOutput(dir, "init", "--quiet"); err != nil {
+			return "", "", err
+		}
+
+		// Only the revisions are fetched, together with the parent of a single commit which is needed to get its changes
+		args := []string{"fetch", "--quiet", "--depth=2", repository, to + ":refs/multi-gitter/to"}
+		if from != "" {
+			args = []string{"fetch", "--quiet", "--depth=1", repository, from + ":refs/multi-gitter/from", to + ":refs/multi-gitter/to"}
+		}
+		if _, err := gitOutput(dir, args...); err != nil {
+			return "", "", errors.WithMessagef(err, "could not fetch %s from %s", commit, repository)
+		}
+		to = "refs/multi-gitter/to"
+		if from != "" {
+			from = "refs/multi-gitter/from"
+		}
+	}
+
+	if from != "" {
+		patch, err = gitOutput(dir, "diff", "--binary", "--no-color", from, to)
+		if err != nil {
+			return "", "", errors.WithMessagef(err, "could not get the changes between %s", commit)
+		}
+		return patch, "", nil
+	}
+
+	patch, err = gitOutput(dir, "show", "--format=", "--binary", "--no-color", to)
+	if err != nil {
+		return "", "", errors.WithMessagef(err, "could not get the changes of %s", commit)
+	}
+	if strings.HasPrefix(patch, "diff --cc") {
+		return "", "", errors.Errorf("%s is a merge commit, which can not be cherry-picked", commit)
+	}
+
+	message, err = gitOutput(dir, "log", "-1", "--format=%B", to)
+	if err != nil {
+		return "", "", errors.WithMessagef(err, "could not get the message of %s", commit)
+	}
+
+	return patch, strings.TrimSpace(message), nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
@@ -0,0 +1,174 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// PermissionManager is a version controller that can list and change the permissions of users and teams to repositories
+type PermissionManager interface {
+	GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error)
+	// SetUserPermission gives a user the permission, or changes the permission the user already has
+	SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error
+	// SetTeamPermission gives a team the permission, or changes the permission the team already has
+	SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error
+	RemoveUser(ctx context.Context, repo domain.Repository, user string) error
+	RemoveTeam(ctx context.Context, repo domain.Repository, team string) error
+}
+
+// PermissionSyncer makes the permissions of users and teams to repositories match a declared set of permissions
+type PermissionSyncer struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Permissions    domain.Permissions
+	RemoveUnlisted bool // If set, users and teams that are not in Permissions are removed from the repositories
+	DryRun         bool // If set, the changes are only shown
+	Concurrent     int
+}
+
+// permissionUpdate is a permission that has to be set, or removed if permission is empty
+type permissionUpdate struct {
+	team       bool
+	name       string
+	permission string
+}
+
+// Sync shows the difference between the current and desired permissions of all repositories, and changes them
+func (s PermissionSyncer) Sync(ctx context.Context) error {
+	manager, ok := s.VersionController.(PermissionManager)
+	if !ok {
+		return errors.New("the platform does not support changing permissions")
+	}
+
+	repos, err := getRepositories(ctx, s.VersionController, s.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = s.syncRepository(ctx, manager, repos[i])
+	}, len(repos), s.Concurrent)
+
+	failed := printSettingsResults(s.Output, repos, results)
+	printSettingsSummary(s.Output, results, s.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not sync the permissions of %d repositories", failed)
+	}
+	return nil
+}
+
+func (s PermissionSyncer) syncRepository(ctx context.Context, manager PermissionManager, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	log.Debug("Fetching the current permissions")
+	current, err := manager.GetPermissions(ctx, repo)
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the current permissions")}
+	}
+
+	userChanges, userUpdates := permissionChanges("user", current.Users, s.Permissions.Users, s.RemoveUnlisted)
+	teamChanges, teamUpdates := permissionChanges("team", current.Teams, s.Permissions.Teams, s.RemoveUnlisted)
+	for i := range teamUpdates {
+		teamUpdates[i].team = true
+	}
+	changes := append(userChanges, teamChanges...)
+	updates := append(userUpdates, teamUpdates...)
+
+	if len(changes) == 0 {
+		return settingsResult{}
+	}
+
+	if s.DryRun {
+		log.Info("Skipping changing the permissions because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	for _, update := range updates {
+		log := log.WithField("name", update.name)
+		switch {
+		case update.team && update.permission == "":
+			log.Info("Removing team")
+			err = manager.RemoveTeam(ctx, repo, update.name)
+		case update.team:
+			log.Info("Setting the permission of team")
+			err = manager.SetTeamPermission(ctx, repo, update.name, update.permission)
+		case update.permission == "":
+			log.Info("Removing user")
+			err = manager.RemoveUser(ctx, repo, update.name)
+		default:
+			log.Info("Setting the permission of user")
+			err = manager.SetUserPermission(ctx, repo, update.name, update.permission)
+		}
+		if err != nil {
+			return settingsResult{changes: changes, err: errors.Wrapf(err, "could not change the permission of %s", update.name)}
+		}
+	}
+
+	return settingsResult{changes: changes}
+}
+
+// permissionChanges returns all changes needed to go from the current to the desired permissions of users or teams.
+// Names are case insensitive, as they are on most platforms
+func permissionChanges(kind string, current, desired map[string]string, removeUnlisted bool) ([]SettingChange, []permissionUpdate) {
+	var changes []SettingChange
+	var updates []permissionUpdate
+
+	for _, name := range sortedKeys(desired) {
+		permission := desired[name]
+		_, currentPermission := findPermission(current, name)
+		if strings.EqualFold(currentPermission, permission) {
+			continue
+		}
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("%s %s", kind, name),
+			Current: formatPermission(currentPermission),
+			Desired: permission,
+		})
+		updates = append(updates, permissionUpdate{name: name, permission: permission})
+	}
+
+	if removeUnlisted {
+		for _, name := range sortedKeys(current) {
+			if desiredName, _ := findPermission(desired, name); desiredName != "" {
+				continue
+			}
+			changes = append(changes, SettingChange{
+				Name:    fmt.Sprintf("%s %s", kind, name),
+				Current: current[name],
+				Desired: "none",
+			})
+			updates = append(updates, permissionUpdate{name: name})
+		}
+	}
+
+	return changes, updates
+}
+
+// findPermission finds the permission of a user or team by a case insensitive name
+func findPermission(permissions map[string]string, name string) (string, string) {
+	for n, permission := range permissions {
+		if strings.EqualFold(n, name) {
+			return n, permission
+		}
+	}
+	return "", ""
+}
+
+func formatPermission(permission string) string {
+	if permission == "" {
+		return "none"
+	}
+	return permission
+}
@@ -0,0 +1,195 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eiannone/keyboard"
+
+	"github.com/lindell/multi-gitter/internal/multigitter/terminal"
+)
+
+// ErrAborted is returned if the user aborted the picking
+var ErrAborted = errors.New("picking was aborted")
+
+// The maximum number of items that are shown at the same time
+const maxVisible = 15
+
+const pickerInfo = "Type to filter. (Up/Down) Move. (Space/Tab) Toggle. (Ctrl+A) Toggle all shown. (Enter) Confirm. (Esc) Abort."
+
+// Pick lets the user interactively select a subset of the items. All items are selected from the start.
+// The indexes of the selected items are returned
+func Pick(out io.Writer, items []string) ([]int, error) {
+	if err := keyboard.Open(); err != nil {
+		return nil, err
+	}
+	defer keyboard.Close()
+
+	p := newPicker(items)
+	renderedLines := 0
+	for {
+		renderedLines = p.render(out, renderedLines)
+
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case keyboard.KeyEnter:
+			return p.selectedIndexes(), nil
+		case keyboard.KeyEsc, keyboard.KeyCtrlC:
+			return nil, ErrAborted
+		default:
+			p.handleKey(char, key)
+		}
+	}
+}
+
+type picker struct {
+	items    []string
+	selected []bool
+	filter   string
+	cursor   int   // The position of the cursor within the filtered items
+	filtered []int // The indexes of items matching the filter
+}
+
+func newPicker(items []string) *picker {
+	p := &picker{
+		items:    items,
+		selected: make([]bool, len(items)),
+	}
+	for i := range p.selected {
+		p.selected[i] = true
+	}
+	p.updateFilter()
+	return p
+}
+
+func (p *picker) handleKey(char rune, key keyboard.Key) {
+	switch key {
+	case keyboard.KeyArrowUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyboard.KeyArrowDown:
+		if p.cursor < len(p.filtered)-1 {
+			p.cursor++
+		}
+	case keyboard.KeySpace, keyboard.KeyTab:
+		if len(p.filtered) > 0 {
+			i := p.filtered[p.cursor]
+			p.selected[i] = !p.selected[i]
+		}
+	case keyboard.KeyCtrlA:
+		p.toggleAllFiltered()
+	case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+		if p.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(p.filter)
+			p.filter = p.filter[:len(p.filter)-size]
+			p.updateFilter()
+		}
+	default:
+		if char != 0 {
+			p.filter += string(char)
+			p.updateFilter()
+		}
+	}
+}
+
+// toggleAllFiltered selects all filtered items, or deselects them if all of them already are selected
+func (p *picker) toggleAllFiltered() {
+	allSelected := true
+	for _, i := range p.filtered {
+		if !p.selected[i] {
+			allSelected = false
+			break
+		}
+	}
+	for _, i := range p.filtered {
+		p.selected[i] = !allSelected
+	}
+}
+
+func (p *picker) updateFilter() {
+	p.filtered = p.filtered[:0]
+	for i, item := range p.items {
+		if fuzzyMatch(p.filter, item) {
+			p.filtered = append(p.filtered, i)
+		}
+	}
+	if p.cursor >= len(p.filtered) {
+		p.cursor = len(p.filtered) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+func (p *picker) selectedIndexes() []int {
+	ret := []int{}
+	for i, selected := range p.selected {
+		if selected {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// render writes the current state of the picker, replacing the previously rendered lines
+func (p *picker) render(out io.Writer, previousLines int) int {
+	b := &strings.Builder{}
+
+	// Move the cursor up and clear everything that was previously rendered
+	if previousLines > 0 {
+		fmt.Fprintf(b, "\033[%dA", previousLines)
+	}
+	b.WriteString("\033[J")
+
+	fmt.Fprintln(b, pickerInfo)
+	fmt.Fprintf(b, "> %s (%d/%d selected)\n", p.filter, len(p.selectedIndexes()), len(p.items))
+	lines := 2
+
+	// Scroll the shown items so that the cursor is always visible
+	start := 0
+	if p.cursor >= maxVisible {
+		start = p.cursor - maxVisible + 1
+	}
+	for pos := start; pos < len(p.filtered) && pos < start+maxVisible; pos++ {
+		i := p.filtered[pos]
+
+		checkbox := "[ ]"
+		if p.selected[i] {
+			checkbox = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", checkbox, p.items[i])
+		if pos == p.cursor {
+			line = terminal.Bold("> " + line)
+		} else {
+			line = "  " + line
+		}
+		fmt.Fprintln(b, line)
+		lines++
+	}
+
+	fmt.Fprint(out, b.String())
+	return lines
+}
+
+// fuzzyMatch checks if all characters in the pattern exist, in order, in the string
+func fuzzyMatch(pattern, str string) bool {
+	pattern = strings.ToLower(pattern)
+	str = strings.ToLower(str)
+
+	for _, c := range pattern {
+		i := strings.IndexRune(str, c)
+		if i == -1 {
+			return false
+		}
+		str = str[i+len(string(c)):]
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/eiannone/keyboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	assert.True(t, fuzzyMatch("", "my-org/my-repo"))
+	assert.True(t, fuzzyMatch("mrepo", "my-org/my-repo"))
+	assert.True(t, fuzzyMatch("ORG/", "my-org/my-repo"))
+	assert.False(t, fuzzyMatch("repoorg", "my-org/my-repo"))
+}
+
+func TestPicker(t *testing.T) {
+	p := newPicker([]string{"org/frontend", "org/backend", "other/frontend"})
+	assert.Equal(t, []int{0, 1, 2}, p.selectedIndexes())
+
+	// Filter and deselect everything shown
+	for _, c := range "front" {
+		p.handleKey(c, 0)
+	}
+	assert.Equal(t, []int{0, 2}, p.filtered)
+	p.handleKey(0, keyboard.KeyCtrlA)
+	assert.Equal(t, []int{1}, p.selectedIndexes())
+
+	// Remove the filter and toggle the second item
+	for range "front" {
+		p.handleKey(0, keyboard.KeyBackspace2)
+	}
+	assert.Equal(t, []int{0, 1, 2}, p.filtered)
+	p.handleKey(0, keyboard.KeyArrowDown)
+	p.handleKey(0, keyboard.KeyArrowDown)
+	p.handleKey(0, keyboard.KeySpace)
+	assert.Equal(t, []int{1, 2}, p.selectedIndexes())
+}
+
+func TestPickerBackspaceMultibyte(t *testing.T) {
+	p := newPicker([]string{"org/café"})
+
+	for _, c := range "cafés" {
+		p.handleKey(c, 0)
+	}
+	assert.Empty(t, p.filtered)
+
+	// The whole character is removed, and not only its last byte
+	p.handleKey(0, keyboard.KeyBackspace2)
+	assert.Equal(t, "café", p.filter)
+	p.handleKey(0, keyboard.KeyBackspace2)
+	assert.Equal(t, "caf", p.filter)
+	assert.Equal(t, []int{0}, p.filtered)
+}
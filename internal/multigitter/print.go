@@ -1,13 +1,17 @@
 package multigitter
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/lindell/multi-gitter/internal/domain"
@@ -17,26 +21,47 @@ import (
 // Printer contains fields to be able to do the print command
 type Printer struct {
 	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
 
 	ScriptPath string // Must be absolute path
 	Arguments  []string
 	Token      string
 
-	Stdout io.Writer
-	Stderr io.Writer
+	Stdout      io.Writer
+	Stderr      io.Writer
+	GroupOutput bool      // If set, the output of each repository is buffered and printed as one block
+	Summary     io.Writer // If set, a table with the outcome of each repository is written to it when all scripts have run
 
 	Concurrent int
+	Pick       bool // If set, the user will be asked to pick which of the repositories that should be used
 
 	CreateGit func(dir string) Git
 }
 
 // Print runs a script for multiple repositories and print the output of each run
 func (r Printer) Print(ctx context.Context) error {
-	repos, err := r.VersionController.GetRepositories(ctx)
+	repos, skipped, err := getFilteredRepositories(ctx, r.VersionController, r.Filter)
 	if err != nil {
 		return err
 	}
 
+	if r.Pick {
+		repos, err = pickRepositories(repos)
+		if err != nil {
+			return errors.Wrap(err, "could not pick repositories")
+		}
+	}
+
+	report := newReportCollector()
+	for _, s := range skipped {
+		report.add(s.repo, nil, domain.SkipError{Reason: s.reason})
+	}
+	defer func() {
+		if r.Summary != nil {
+			writeSummary(r.Summary, reportSummaryRows(report.report()))
+		}
+	}()
+
 	rc := repocounter.NewCounter()
 	defer func() {
 		if info := rc.Info(); info != "" {
@@ -46,9 +71,18 @@ func (r Printer) Print(ctx context.Context) error {
 
 	log.Infof("Running on %d repositories", len(repos))
 
+	outputLock := &sync.Mutex{}
 	runInParallel(func(i int) {
 		logger := log.WithField("repo", repos[i].FullName())
-		err := r.runSingleRepo(ctx, repos[i])
+		start := time.Now()
+		err := r.runSingleRepo(ctx, repos[i], outputLock)
+		if err != errAborted {
+			wallTime := time.Since(start).Seconds()
+			report.setResources(repos[i].FullName(), func(usage *ResourceUsage) {
+				usage.WallTime = wallTime
+			})
+		}
+		report.add(repos[i], nil, err)
 		if err != nil {
 			if err != errAborted {
 				logger.Info(err)
@@ -63,7 +97,7 @@ func (r Printer) Print(ctx context.Context) error {
 	return nil
 }
 
-func (r Printer) runSingleRepo(ctx context.Context, repo domain.Repository) error {
+func (r Printer) runSingleRepo(ctx context.Context, repo domain.Repository, outputLock *sync.Mutex) error {
 	if ctx.Err() != nil {
 		return errAborted
 	}
@@ -79,9 +113,13 @@ func (r Printer) runSingleRepo(ctx context.Context, repo domain.Repository) erro
 
 	sourceController := r.CreateGit(tmpDir)
 
-	err = sourceController.Clone(repo.URL(r.Token), repo.DefaultBranch())
+	cloneURL, err := repositoryURL(ctx, repo, r.Token)
 	if err != nil {
-		return err
+		return inStage(ErrorCategoryClone, err)
+	}
+	err = sourceController.Clone(cloneURL, repo.DefaultBranch())
+	if err != nil {
+		return inStage(ErrorCategoryClone, err)
 	}
 
 	// Run the command that might or might not change the content of the repo
@@ -92,13 +130,47 @@ func (r Printer) runSingleRepo(ctx context.Context, repo domain.Repository) erro
 		fmt.Sprintf("REPOSITORY=%s", repo.FullName()),
 	)
 
-	cmd.Stdout = r.Stdout
-	cmd.Stderr = r.Stderr
+	if !r.GroupOutput {
+		cmd.Stdout = r.Stdout
+		cmd.Stderr = r.Stderr
 
-	err = cmd.Run()
-	if err != nil {
-		return transformExecError(err)
+		err = cmd.Run()
+		if err != nil {
+			return &scriptError{err: transformExecError(err)}
+		}
+		return nil
 	}
 
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	runErr := cmd.Run()
+
+	outputLock.Lock()
+	writeOutputGroup(r.Stdout, repo, stdout.Bytes())
+	writeOutputGroup(r.Stderr, repo, stderr.Bytes())
+	outputLock.Unlock()
+
+	if runErr != nil {
+		return &scriptError{
+			err:    transformExecError(runErr),
+			output: stderr.String(),
+		}
+	}
 	return nil
 }
+
+// writeOutputGroup writes the output of a repository as one block, preceded by a header with the name of the repository
+func writeOutputGroup(w io.Writer, repo domain.Repository, output []byte) {
+	if len(output) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "==> %s <==\n", repo.FullName())
+	_, _ = w.Write(output)
+	if output[len(output)-1] != '\n' {
+		fmt.Fprintln(w)
+	}
+}
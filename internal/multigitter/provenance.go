@@ -0,0 +1,70 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Provenance is metadata about how pull requests were created, that is added as a footer to their body
+type Provenance struct {
+	CampaignID string
+	Version    string // The version of multi-gitter
+	ScriptHash string
+	ConfigURL  string // A link to the configuration of the campaign
+}
+
+func (p Provenance) footer() string {
+	b := &strings.Builder{}
+	b.WriteString("\n\n---\n")
+	b.WriteString(domain.CampaignMarker(p.CampaignID))
+	b.WriteString("\n")
+
+	createdWith := "Created with [multi-gitter](https://github.com/lindell/multi-gitter)"
+	if p.Version != "" {
+		createdWith += " " + p.Version
+	}
+	lines := []string{createdWith, fmt.Sprintf("Campaign: `%s`", p.CampaignID)}
+	if p.ScriptHash != "" {
+		lines = append(lines, fmt.Sprintf("Script hash: `%s`", p.ScriptHash))
+	}
+	if p.ConfigURL != "" {
+		lines = append(lines, fmt.Sprintf("[Campaign configuration](%s)", p.ConfigURL))
+	}
+	b.WriteString(strings.Join(lines, " | "))
+
+	return b.String()
+}
+
+// CampaignPullRequestGetter is a version controller that can find pull requests based on the campaign footer
+type CampaignPullRequestGetter interface {
+	GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error)
+}
+
+// getPullRequests gets the pull requests in a report if set, based on the campaign if set, or otherwise the branch name.
+// Pull requests of repositories that does not pass the filter are removed
+func getPullRequests(ctx context.Context, vc VersionController, branchName, campaignID string, report *Report, filter RepositoryFilter) ([]domain.PullRequest, error) {
+	prs, err := fetchPullRequests(ctx, vc, branchName, campaignID, report)
+	if err != nil {
+		return nil, err
+	}
+	return filter.filterPullRequests(prs), nil
+}
+
+func fetchPullRequests(ctx context.Context, vc VersionController, branchName, campaignID string, report *Report) ([]domain.PullRequest, error) {
+	if report != nil {
+		return getReportPullRequests(ctx, vc, report)
+	}
+
+	if campaignID == "" {
+		return vc.GetPullRequests(ctx, branchName)
+	}
+
+	g, ok := vc.(CampaignPullRequestGetter)
+	if !ok {
+		return nil, fmt.Errorf("the platform does not support finding pull requests by campaign")
+	}
+	return g.GetCampaignPullRequests(ctx, campaignID)
+}
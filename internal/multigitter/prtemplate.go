@@ -0,0 +1,97 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/pkg/errors"
+)
+
+// PullRequestTemplate is a title and body of the pull requests that is used instead of the default ones
+// in repositories with a specific owner or topic, for example to write in the language of a subsidiary
+type PullRequestTemplate struct {
+	Owner string // If set, the template is used in repositories of this owner (organization, group or user)
+	Topic string // If set, the template is used in repositories with this topic
+	Title string
+	Body  string
+}
+
+func (t PullRequestTemplate) String() string {
+	if t.Owner != "" {
+		return fmt.Sprintf(`the template of the owner "%s"`, t.Owner)
+	}
+	return fmt.Sprintf(`the template of the topic "%s"`, t.Topic)
+}
+
+// ParsePullRequestTemplate parses the text of a template, where the first line is the title and the rest is the body.
+// The selector is in the format "owner:name" or "topic:name"
+func ParsePullRequestTemplate(selector, text string) (PullRequestTemplate, error) {
+	var template PullRequestTemplate
+	split := strings.SplitN(selector, ":", 2)
+	if len(split) != 2 || split[1] == "" {
+		return PullRequestTemplate{}, errors.Errorf(`could not parse the template selector "%s", it should be in the format "owner:name" or "topic:name"`, selector)
+	}
+	switch split[0] {
+	case "owner":
+		template.Owner = split[1]
+	case "topic":
+		template.Topic = split[1]
+	default:
+		return PullRequestTemplate{}, errors.Errorf(`unknown template selector "%s", it should be either "owner" or "topic"`, split[0])
+	}
+
+	lines := strings.SplitN(strings.TrimSpace(text), "\n", 2)
+	template.Title = strings.TrimSpace(lines[0])
+	if template.Title == "" {
+		return PullRequestTemplate{}, errors.Errorf("%s has no title", template)
+	}
+	if len(lines) == 2 {
+		template.Body = strings.TrimSpace(lines[1])
+	}
+	return template, nil
+}
+
+// usesTopics checks if any template is selected by the topics of the repositories
+func usesTopics(templates []PullRequestTemplate) bool {
+	for _, template := range templates {
+		if template.Topic != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pullRequestText returns the title and body of the pull request of a repository, from the first template that matches it,
+// or the default title and body if none does
+func (r *Runner) pullRequestText(ctx context.Context, repo domain.Repository) (title, body string, err error) {
+	if len(r.PullRequestTemplates) == 0 {
+		return r.PullRequestTitle, r.PullRequestBody, nil
+	}
+
+	var topics map[string]bool
+	if usesTopics(r.PullRequestTemplates) {
+		manager, ok := r.VersionController.(SettingsManager)
+		if !ok {
+			return "", "", errors.New("the platform does not support selecting pull request templates by topic")
+		}
+		settings, err := manager.GetRepositorySettings(ctx, repo, "")
+		if err != nil {
+			return "", "", errors.Wrap(err, "could not get the topics of the repository")
+		}
+		topics = map[string]bool{}
+		for _, topic := range settings.Topics {
+			topics[strings.ToLower(topic)] = true
+		}
+	}
+
+	owner := repositoryOwner(repo)
+	for _, template := range r.PullRequestTemplates {
+		if (template.Owner != "" && strings.EqualFold(template.Owner, owner)) ||
+			(template.Topic != "" && topics[strings.ToLower(template.Topic)]) {
+			return template.Title, template.Body, nil
+		}
+	}
+	return r.PullRequestTitle, r.PullRequestBody, nil
+}
@@ -0,0 +1,310 @@
+package query
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed query, that can be evaluated against the files of a repository
+type Expression interface {
+	// Evaluate evaluates the expression against the files in the directory
+	Evaluate(dir string) (bool, error)
+}
+
+// Parse parses a query. A query consists of the functions:
+//
+//	exists("path")            true if any file matches the path, which may be a glob pattern
+//	contains("path", "text")  true if any file matching the path contains the text
+//	matches("path", "regex")  true if any file matching the path matches the regular expression
+//
+// combined with the operators "!", "&&", "||" and parentheses
+func Parse(query string) (Expression, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+type tokenType int
+
+const (
+	tokenIdent tokenType = iota
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	typ   tokenType
+	value string
+}
+
+func (t token) String() string {
+	if t.typ == tokenString {
+		return fmt.Sprintf("%q", t.value)
+	}
+	return fmt.Sprintf(`"%s"`, t.value)
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',' || r == '!':
+			tokens = append(tokens, token{typ: tokenOperator, value: string(r)})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf(`expected "%c%c" at position %d`, r, r, i)
+			}
+			tokens = append(tokens, token{typ: tokenOperator, value: string([]rune{r, r})})
+			i += 2
+		case r == '"':
+			value := &strings.Builder{}
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{typ: tokenString, value: value.String()})
+			i++
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokenIdent, value: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// acceptOperator advances past the next token if it is the operator
+func (p *parser) acceptOperator(op string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].typ == tokenOperator && p.tokens[p.pos].value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOperator(op string) error {
+	if !p.acceptOperator(op) {
+		return p.unexpected(fmt.Sprintf(`"%s"`, op))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("expected %s, but the query ended", expected)
+	}
+	return fmt.Errorf("expected %s, got %s", expected, p.tokens[p.pos])
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expression, error) {
+	if p.acceptOperator("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{expr}, nil
+	}
+
+	if p.acceptOperator("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectOperator(")")
+	}
+
+	return p.parseFunction()
+}
+
+func (p *parser) parseFunction() (Expression, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].typ != tokenIdent {
+		return nil, p.unexpected("a function")
+	}
+	name := p.tokens[p.pos].value
+	p.pos++
+
+	if err := p.expectOperator("("); err != nil {
+		return nil, err
+	}
+	var args []string
+	for !p.acceptOperator(")") {
+		if len(args) > 0 {
+			if err := p.expectOperator(","); err != nil {
+				return nil, err
+			}
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].typ != tokenString {
+			return nil, p.unexpected("a string")
+		}
+		args = append(args, p.tokens[p.pos].value)
+		p.pos++
+	}
+
+	return newFunction(name, args)
+}
+
+func newFunction(name string, args []string) (Expression, error) {
+	expectedArgs := map[string]int{"exists": 1, "contains": 2, "matches": 2}
+	count, ok := expectedArgs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	if len(args) != count {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, count, len(args))
+	}
+
+	path := filepath.FromSlash(args[0])
+	if filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+		return nil, fmt.Errorf("the path %s has to be inside the repository", args[0])
+	}
+
+	switch name {
+	case "contains":
+		text := args[1]
+		return fileFunction{path: path, match: func(content []byte) bool {
+			return strings.Contains(string(content), text)
+		}}, nil
+	case "matches":
+		regex, err := regexp.Compile(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the regular expression of matches: %s", err)
+		}
+		return fileFunction{path: path, match: regex.Match}, nil
+	}
+	return fileFunction{path: path}, nil
+}
+
+// fileFunction is true if any file matching the path exists, and has content that matches, if a match function is set
+type fileFunction struct {
+	path  string
+	match func(content []byte) bool
+}
+
+func (f fileFunction) Evaluate(dir string) (bool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, f.path))
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range paths {
+		if f.match == nil {
+			return true, nil
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path) // #nosec G304 The path is checked to be inside the repository
+		if err != nil {
+			return false, err
+		}
+		if f.match(content) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type and struct {
+	left, right Expression
+}
+
+func (e and) Evaluate(dir string) (bool, error) {
+	left, err := e.left.Evaluate(dir)
+	if err != nil || !left {
+		return false, err
+	}
+	return e.right.Evaluate(dir)
+}
+
+type or struct {
+	left, right Expression
+}
+
+func (e or) Evaluate(dir string) (bool, error) {
+	left, err := e.left.Evaluate(dir)
+	if err != nil || left {
+		return left, err
+	}
+	return e.right.Evaluate(dir)
+}
+
+type not struct {
+	expr Expression
+}
+
+func (e not) Evaluate(dir string) (bool, error) {
+	result, err := e.expr.Evaluate(dir)
+	return !result, err
+}
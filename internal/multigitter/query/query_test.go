@@ -0,0 +1,70 @@
+package query
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-query-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM ubuntu:16.04\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/test\n\ngo 1.16\n"), 0600))
+
+	tests := []struct {
+		query    string
+		expected bool
+	}{
+		{`exists("Dockerfile")`, true},
+		{`exists("*.mod")`, true},
+		{`exists("package.json")`, false},
+		{`contains("Dockerfile", "ubuntu:16.04")`, true},
+		{`contains("missing", "ubuntu:16.04")`, false},
+		{`matches("go.mod", "go 1\\.1[0-6]")`, true},
+		{`!exists("Dockerfile")`, false},
+		{`exists("package.json") || exists("go.mod")`, true},
+		{`exists("Dockerfile") && !contains("Dockerfile", "ubuntu:16.04")`, false},
+		{`!(exists("package.json") || exists("yarn.lock")) && exists("go.mod")`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			expr, err := Parse(test.query)
+			require.NoError(t, err)
+			result, err := expr.Evaluate(dir)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`exists("Dockerfile") &`, `expected "&&" at position 21`},
+		{`exists("Dockerfile`, "unterminated string"},
+		{`exists("Dockerfile") exists("go.mod")`, `unexpected "exists"`},
+		{`exists("Dockerfile") &&`, "expected a function, but the query ended"},
+		{`present("Dockerfile")`, "unknown function present"},
+		{`contains("Dockerfile")`, "contains takes 2 arguments, got 1"},
+		{`exists("../other")`, "the path ../other has to be inside the repository"},
+		{`matches("go.mod", "(")`, "could not parse the regular expression of matches: error parsing regexp: missing closing ): `(`"},
+		{`(exists("Dockerfile")`, `expected ")", but the query ended`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := Parse(test.query)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter/repocounter"
+)
+
+// Queue distributes the repositories of a run between workers, that might run on different machines
+type Queue interface {
+	fmt.Stringer
+	// Enqueue replaces all tasks and results in the queue with a task for each of the repositories
+	Enqueue(ctx context.Context, repositories []string) error
+	// Claim claims a repository that no other worker is running, or returns an empty string if there is none.
+	// If the claim is not extended or completed within the lease, the repository is given to another worker
+	Claim(ctx context.Context, lease time.Duration) (string, error)
+	// Extend extends the lease of a claimed repository
+	Extend(ctx context.Context, repository string, lease time.Duration) error
+	// Complete stores the result of a claimed repository
+	Complete(ctx context.Context, result RepositoryReport) error
+	// Pending returns the number of repositories that has not been completed
+	Pending(ctx context.Context) (int, error)
+	// Results returns the results of all completed repositories
+	Results(ctx context.Context) ([]RepositoryReport, error)
+}
+
+// DefaultQueueLease is the lease used if none is set
+const DefaultQueueLease = 5 * time.Minute
+
+// queuePollInterval is how often the queue is checked while waiting for other workers
+var queuePollInterval = time.Second
+
+// Coordinate enqueues the repositories of the run, and waits until workers have run all of them
+func (r *Runner) Coordinate(ctx context.Context, queue Queue) error {
+	repos, skipped, err := r.getRepositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	if r.Pick {
+		repos, err = pickRepositories(repos)
+		if err != nil {
+			return errors.Wrap(err, "could not pick repositories")
+		}
+	}
+
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.FullName()
+	}
+	if err := queue.Enqueue(ctx, names); err != nil {
+		return errors.Wrapf(err, "could not enqueue the repositories to %s", queue)
+	}
+	log.Infof("Enqueued %d repositories to %s", len(names), queue)
+
+	for {
+		pending, err := queue.Pending(ctx)
+		if err != nil {
+			return errors.Wrap(err, "could not get the pending repositories")
+		}
+		if pending == 0 {
+			break
+		}
+		log.Debugf("Waiting for workers to run %d repositories", pending)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(queuePollInterval):
+		}
+	}
+
+	results, err := queue.Results(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get the results")
+	}
+	for _, s := range skipped {
+		results = append(results, RepositoryReport{
+			Repository: s.repo.FullName(),
+			Status:     ReportStatusSkipped,
+			Reason:     s.reason,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Repository < results[j].Repository
+	})
+
+	if info := countResults(repos, results).Info(); info != "" {
+		fmt.Fprint(r.Output, info)
+	}
+	if r.Summary != nil {
+		writeSummary(r.Summary, reportSummaryRows(Report{Repositories: results}))
+	}
+
+	report := Report{Repositories: results}
+	if r.ChangeRequest != nil {
+		report.ChangeRequest = r.ChangeRequest.ID
+	}
+	if r.Report != nil {
+		collector := newReportCollector()
+		collector.changeRequest = report.ChangeRequest
+		for _, result := range results {
+			collector.results[result.Repository] = result
+		}
+		if err := collector.write(r.Report); err != nil {
+			log.Errorf("Could not write the report: %s", err)
+		}
+	}
+	for _, sink := range r.ReportSinks {
+		if err := sink.WriteReport(ctx, report); err != nil {
+			log.Errorf("Could not send the report to %s: %s", sink, err)
+		}
+	}
+
+	return nil
+}
+
+// Work runs repositories claimed from the queue, until all repositories in it have been completed
+func (r *Runner) Work(ctx context.Context, queue Queue) error {
+	if err := r.verifyChangeRequest(ctx); err != nil {
+		return err
+	}
+
+	repos, _, err := r.getRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	reposByName := map[string]domain.Repository{}
+	for _, repo := range repos {
+		reposByName[repo.FullName()] = repo
+	}
+
+	if r.Suggest {
+		r.suggestionPullRequests = &openPullRequests{}
+	}
+
+	// The results sent to the queue are taken from the report
+	r.report = newReportCollector()
+	defer func() {
+		if r.Summary != nil {
+			writeSummary(r.Summary, reportSummaryRows(r.report.report()))
+		}
+	}()
+
+	rc := repocounter.NewCounter()
+	defer func() {
+		if info := rc.Info(); info != "" {
+			fmt.Fprint(r.Output, info)
+		}
+	}()
+
+	var lock sync.Mutex
+	var firstErr error
+	runInParallel(func(int) {
+		if err := r.work(ctx, queue, reposByName, rc); err != nil {
+			lock.Lock()
+			defer lock.Unlock()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}, r.Concurrent, r.Concurrent)
+
+	return firstErr
+}
+
+// work claims and runs repositories, one at the time, until all repositories in the queue have been completed
+func (r *Runner) work(ctx context.Context, queue Queue, repos map[string]domain.Repository, rc *repocounter.Counter) error {
+	lease := r.QueueLease
+	if lease == 0 {
+		lease = DefaultQueueLease
+	}
+
+	for ctx.Err() == nil {
+		name, err := queue.Claim(ctx, lease)
+		if err != nil {
+			return errors.Wrap(err, "could not claim a repository")
+		}
+
+		if name == "" {
+			// Repositories claimed by other workers might be given back if they are not completed in time
+			pending, err := queue.Pending(ctx)
+			if err != nil {
+				return errors.Wrap(err, "could not get the pending repositories")
+			}
+			if pending == 0 {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(queuePollInterval):
+			}
+			continue
+		}
+
+		repo, ok := repos[name]
+		if !ok {
+			log.Errorf("The claimed repository %s could not be found", name)
+			err := queue.Complete(ctx, RepositoryReport{
+				Repository: name,
+				Status:     ReportStatusError,
+				Error:      "the repository could not be found by the worker",
+			})
+			if err != nil {
+				return errors.Wrapf(err, "could not complete %s", name)
+			}
+			continue
+		}
+
+		stop := extendLease(ctx, queue, name, lease)
+		r.runAndRecord(ctx, repo, rc)
+		stop()
+
+		// An aborted repository is left for another worker, once the lease has expired
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := queue.Complete(ctx, r.report.result(name)); err != nil {
+			return errors.Wrapf(err, "could not complete %s", name)
+		}
+	}
+
+	return nil
+}
+
+// extendLease extends the lease of a claimed repository until the returned function is called
+func extendLease(ctx context.Context, queue Queue, repository string, lease time.Duration) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := queue.Extend(ctx, repository, lease); err != nil {
+					log.WithField("repo", repository).Warnf("Could not extend the lease: %s", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// countResults counts the results of a queue, in the same way as the repositories of a local run is counted
+func countResults(repos []domain.Repository, results []RepositoryReport) *repocounter.Counter {
+	reposByName := map[string]domain.Repository{}
+	for _, repo := range repos {
+		reposByName[repo.FullName()] = repo
+	}
+
+	rc := repocounter.NewCounter()
+	for _, result := range results {
+		repo, ok := reposByName[result.Repository]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case result.Status == ReportStatusNoChange:
+			rc.AddError(domain.NoChangeError, repo)
+		case result.Status == ReportStatusError:
+			rc.AddError(errors.New(result.Error), repo)
+		case result.Status == ReportStatusSkipped:
+			rc.AddError(domain.SkipError{Reason: result.Reason}, repo)
+		case result.PullRequest != "":
+			rc.AddSuccessPullRequest(queuedPullRequest{name: result.PullRequest, repository: result.Repository})
+		default:
+			rc.AddSuccessRepositories(repo)
+		}
+	}
+	return rc
+}
+
+// queuedPullRequest is a pull request created by a worker, of which only the name and repository are known
+type queuedPullRequest struct {
+	name       string
+	repository string
+}
+
+func (pr queuedPullRequest) String() string {
+	return pr.name
+}
+
+func (pr queuedPullRequest) RepositoryName() string {
+	return pr.repository
+}
+
+func (pr queuedPullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusUnknown
+}
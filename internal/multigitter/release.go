@@ -0,0 +1,147 @@
+package multigitter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Releaser is a version controller that can create tags and releases
+type Releaser interface {
+	TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error)
+	// CreateRelease creates the tag at the head of the default branch, and a release of the tag
+	CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error
+}
+
+// ReleaseCreator creates the same release in multiple repositories
+type ReleaseCreator struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Tag           string
+	Name          string
+	NotesTemplate string // The notes of the releases, as a Go template with the repository as data
+	MergedBranch  string // If set, only repositories where the latest pull request from the branch is merged are released
+	DryRun        bool   // If set, the releases that would be created are only shown
+	Concurrent    int
+}
+
+// ReleaseTemplateData is the data available in the template of release notes
+type ReleaseTemplateData struct {
+	Repository    string // The full name of the repository
+	DefaultBranch string
+	Tag           string
+}
+
+// Create creates the releases in all repositories that does not already have the tag
+func (c ReleaseCreator) Create(ctx context.Context) error {
+	releaser, ok := c.VersionController.(Releaser)
+	if !ok {
+		return errors.New("the platform does not support creating releases")
+	}
+
+	notesTemplate, err := template.New("notes").Option("missingkey=error").Parse(c.NotesTemplate)
+	if err != nil {
+		return errors.Wrap(err, "could not parse the release notes template")
+	}
+
+	repos, err := getRepositories(ctx, c.VersionController, c.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	var merged map[string]bool
+	if c.MergedBranch != "" {
+		merged, err = c.mergedRepositories(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		if merged != nil && !merged[repos[i].FullName()] {
+			results[i] = settingsResult{skipReason: "no merged pull request from " + c.MergedBranch}
+			return
+		}
+		results[i] = c.createRelease(ctx, releaser, notesTemplate, repos[i])
+	}, len(repos), c.Concurrent)
+
+	failed := printSettingsResults(c.Output, repos, results)
+	printSettingsSummary(c.Output, results, c.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not create the release in %d repositories", failed)
+	}
+	return nil
+}
+
+// mergedRepositories returns the names of all repositories where the latest pull request from the merged branch is merged
+func (c ReleaseCreator) mergedRepositories(ctx context.Context) (map[string]bool, error) {
+	prs, err := c.VersionController.GetPullRequests(ctx, c.MergedBranch)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch pull requests")
+	}
+
+	merged := map[string]bool{}
+	for _, pr := range prs {
+		if pr.Status() != domain.PullRequestStatusMerged {
+			continue
+		}
+		merged[pr.RepositoryName()] = true
+	}
+	return merged, nil
+}
+
+func (c ReleaseCreator) createRelease(ctx context.Context, releaser Releaser, notesTemplate *template.Template, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	exists, err := releaser.TagExists(ctx, repo, c.Tag)
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not check if the tag exists")}
+	}
+	if exists {
+		return settingsResult{}
+	}
+
+	notes := &bytes.Buffer{}
+	err = notesTemplate.Execute(notes, ReleaseTemplateData{
+		Repository:    repo.FullName(),
+		DefaultBranch: repo.DefaultBranch(),
+		Tag:           c.Tag,
+	})
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not create the release notes")}
+	}
+
+	changes := []SettingChange{{
+		Name:    "release",
+		Current: "none",
+		Desired: c.Tag + " at " + repo.DefaultBranch(),
+	}}
+
+	if c.DryRun {
+		log.Info("Skipping creating the release because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	log.Info("Creating the release")
+	err = releaser.CreateRelease(ctx, repo, domain.Release{
+		Tag:   c.Tag,
+		Name:  c.Name,
+		Notes: notes.String(),
+	})
+	if err != nil {
+		return settingsResult{changes: changes, err: errors.Wrap(err, "could not create the release")}
+	}
+
+	return settingsResult{changes: changes}
+}
@@ -0,0 +1,54 @@
+package multigitter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// RemoteURLSetter is a git implementation that is able to change the url of a remote
+type RemoteURLSetter interface {
+	SetRemoteURL(name, url string) error
+}
+
+// expiringURLer is a repository with credentials in its url that expire, such as urls signed at the time they are created
+type expiringURLer interface {
+	URLExpires() bool
+}
+
+// credentialURLer is a repository where the credentials of the url are created when it is requested, which might fail
+type credentialURLer interface {
+	CredentialURL(ctx context.Context, token string) (string, error)
+}
+
+// repositoryURL returns the url the repository is cloned from and pushed to, including the credentials
+func repositoryURL(ctx context.Context, repo domain.Repository, token string) (string, error) {
+	if c, ok := repo.(credentialURLer); ok {
+		u, err := c.CredentialURL(ctx, token)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not get the credentials of %s", repo.FullName())
+		}
+		return u, nil
+	}
+	return repo.URL(token), nil
+}
+
+// refreshRemoteURL sets a new url on the remote if the credentials in the url of the repository expire,
+// since running the script may take longer than the credentials of the url used to clone are valid
+func (r *Runner) refreshRemoteURL(ctx context.Context, sourceController Git, remoteName string, repo domain.Repository) error {
+	if expiring, ok := repo.(expiringURLer); !ok || !expiring.URLExpires() {
+		return nil
+	}
+
+	setter, ok := sourceController.(RemoteURLSetter)
+	if !ok {
+		return errors.New("the git implementation does not support changing the url of remotes")
+	}
+	u, err := repositoryURL(ctx, repo, r.Token)
+	if err != nil {
+		return err
+	}
+	return setter.SetRemoteURL(remoteName, u)
+}
@@ -0,0 +1,76 @@
+package multigitter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiringRepository is a repository with a new url every time it is requested
+type expiringRepository struct {
+	testRepository
+	urls int
+}
+
+func (r *expiringRepository) URL(token string) string {
+	r.urls++
+	return r.testRepository.URL(token)
+}
+
+func (r *expiringRepository) URLExpires() bool {
+	return true
+}
+
+// testRemoteGit records the urls set on remotes
+type testRemoteGit struct {
+	Git
+	remotes map[string]string
+}
+
+func (g *testRemoteGit) SetRemoteURL(name, url string) error {
+	g.remotes[name] = url
+	return nil
+}
+
+func TestRefreshRemoteURL(t *testing.T) {
+	r := &Runner{Token: "token"}
+
+	git := &testRemoteGit{remotes: map[string]string{}}
+	require.NoError(t, r.refreshRemoteURL(context.Background(), git, "origin", testRepository{url: "https://example.com/repo.git"}))
+	assert.Empty(t, git.remotes, "urls that do not expire should not be refreshed")
+
+	repo := &expiringRepository{testRepository: testRepository{url: "https://signed@example.com/repo.git"}}
+	require.NoError(t, r.refreshRemoteURL(context.Background(), git, "origin", repo))
+	assert.Equal(t, map[string]string{"origin": "https://signed@example.com/repo.git"}, git.remotes)
+	assert.Equal(t, 1, repo.urls)
+
+	err := r.refreshRemoteURL(context.Background(), struct{ Git }{}, "origin", repo)
+	assert.EqualError(t, err, "the git implementation does not support changing the url of remotes")
+}
+
+// credentialRepository is a repository where the credentials of the url could not be created
+type credentialRepository struct {
+	expiringRepository
+}
+
+func (r *credentialRepository) CredentialURL(ctx context.Context, token string) (string, error) {
+	return "", errors.New("could not create an installation token")
+}
+
+func TestRepositoryURL(t *testing.T) {
+	u, err := repositoryURL(context.Background(), testRepository{url: "https://example.com/repo.git"}, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/repo.git", u)
+
+	repo := &credentialRepository{expiringRepository{testRepository: testRepository{fullName: "owner/repo"}}}
+	_, err = repositoryURL(context.Background(), repo, "token")
+	assert.EqualError(t, err, "could not get the credentials of owner/repo: could not create an installation token")
+
+	git := &testRemoteGit{remotes: map[string]string{}}
+	err = (&Runner{}).refreshRemoteURL(context.Background(), git, "origin", repo)
+	assert.EqualError(t, err, "could not get the credentials of owner/repo: could not create an installation token")
+	assert.Empty(t, git.remotes)
+}
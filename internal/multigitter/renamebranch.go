@@ -0,0 +1,100 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// DefaultBranchRenamer is a version controller that can rename the default branch of a repository
+type DefaultBranchRenamer interface {
+	// RenameDefaultBranch renames the default branch of a repository. The branch protection is moved
+	// to the new branch, and open pull requests are retargeted to it
+	RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error
+}
+
+// BranchRenamer renames the default branch of repositories
+type BranchRenamer struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	From   string // Only repositories with this default branch are renamed
+	To     string
+	DryRun bool // If set, the repositories that would be renamed are only logged
+
+	// If set, the script of the runner is run on all renamed repositories, to fix references to the old branch.
+	// The old and new names are available to the script in the OLD_BRANCH and NEW_BRANCH environment variables
+	Runner *Runner
+}
+
+// Rename renames the default branch of all repositories
+func (b BranchRenamer) Rename(ctx context.Context) error {
+	renamer, ok := b.VersionController.(DefaultBranchRenamer)
+	if !ok {
+		return errors.New("the platform does not support renaming branches")
+	}
+
+	repos, err := getRepositories(ctx, b.VersionController, b.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	renamed := []domain.Repository{}
+	failed := 0
+	for _, repo := range repos {
+		log := log.WithField("repo", repo.FullName())
+
+		if repo.DefaultBranch() != b.From {
+			log.Debugf("Skipping since the default branch is %s", repo.DefaultBranch())
+			continue
+		}
+
+		if b.DryRun {
+			log.Infof("Skipping renaming %s to %s because of dry run", b.From, b.To)
+			renamed = append(renamed, repo)
+			continue
+		}
+
+		log.Infof("Renaming %s to %s", b.From, b.To)
+		if err := renamer.RenameDefaultBranch(ctx, repo, b.To); err != nil {
+			log.Errorf("Could not rename the default branch: %s", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(b.Output, "%s: renamed %s to %s\n", repo.FullName(), b.From, b.To)
+		renamed = append(renamed, repo)
+	}
+
+	if b.Runner != nil && len(renamed) > 0 {
+		if err := b.fixReferences(ctx, renamed); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("could not rename the default branch of %d repositories", failed)
+	}
+	return nil
+}
+
+// fixReferences runs the script of the runner on the renamed repositories
+func (b BranchRenamer) fixReferences(ctx context.Context, renamed []domain.Repository) error {
+	runner := *b.Runner
+	runner.repositories = renamed
+	runner.DryRun = runner.DryRun || b.DryRun
+	if !runner.DryRun {
+		runner.BaseBranch = b.To
+	}
+	runner.Env = append(runner.Env,
+		fmt.Sprintf("OLD_BRANCH=%s", b.From),
+		fmt.Sprintf("NEW_BRANCH=%s", b.To),
+	)
+	return runner.Run(ctx)
+}
@@ -0,0 +1,369 @@
+package multigitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// The statuses of a repository in a report
+const (
+	ReportStatusSuccess  = "success"
+	ReportStatusNoChange = "no-change"
+	ReportStatusError    = "error"
+	ReportStatusSkipped  = "skipped"
+)
+
+// Report is a machine readable summary of a run
+type Report struct {
+	ChangeRequest string             `json:"change_request,omitempty"` // The approved change request the run was made under
+	Repositories  []RepositoryReport `json:"repositories"`
+
+	renamed bool // If repositories in the report have been renamed or transferred since it was read
+}
+
+// Renamed checks if any repository in the report has been renamed or transferred since the report was read,
+// in which case the report has been updated with the new names
+func (r Report) Renamed() bool {
+	return r.renamed
+}
+
+// RepositoryReport is the result of the run of a single repository
+type RepositoryReport struct {
+	Repository     string        `json:"repository"`
+	Status         string        `json:"status"`
+	Error          string        `json:"error,omitempty"`
+	Reason         string        `json:"reason,omitempty"`         // Why the repository was skipped or not changed
+	ErrorCategory  string        `json:"error_category,omitempty"` // One of the ErrorCategory constants, only set if the run failed
+	PullRequest    string        `json:"pull_request,omitempty"`
+	PullRequestURL string        `json:"pull_request_url,omitempty"`
+	DiffHash       string        `json:"diff_hash,omitempty"` // A hash of the changes, to be able to see if they differ between runs
+	Patch          string        `json:"patch,omitempty"`     // The changes, to be able to roll them back
+	Checks         []CheckReport `json:"checks,omitempty"`
+
+	Resources *ResourceUsage `json:"resources,omitempty"`
+
+	PullRequestPreview *PullRequestPreview `json:"pull_request_preview,omitempty"` // Only set when the run is simulated
+}
+
+// CheckReport is the result of a single check of the created pull request
+type CheckReport struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// reportCollector collects the results of repositories that are run concurrently
+type reportCollector struct {
+	lock          sync.Mutex
+	results       map[string]RepositoryReport
+	changeRequest string
+}
+
+func newReportCollector() *reportCollector {
+	return &reportCollector{
+		results: map[string]RepositoryReport{},
+	}
+}
+
+// setDiff sets the diff of the changes made in a repository
+func (rc *reportCollector) setDiff(repo domain.Repository, diff string) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	result := rc.results[repo.FullName()]
+	result.DiffHash = diffHash(diff)
+	result.Patch = diff
+	rc.results[repo.FullName()] = result
+}
+
+// setChecks sets the latest known result of the checks of the pull request created in a repository
+func (rc *reportCollector) setChecks(repo domain.Repository, checks []domain.Check) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	result := rc.results[repo.FullName()]
+	result.Checks = make([]CheckReport, len(checks))
+	for i, check := range checks {
+		result.Checks[i] = CheckReport{
+			Name:   check.Name,
+			Status: check.Status.String(),
+		}
+	}
+	rc.results[repo.FullName()] = result
+}
+
+func (rc *reportCollector) add(repo domain.Repository, pr domain.PullRequest, err error) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	result := rc.results[repo.FullName()]
+	result.Repository = repo.FullName()
+	if simulated, ok := pr.(simulatedPullRequest); ok {
+		result.PullRequestPreview = newPullRequestPreview(simulated.newPR)
+	} else if pr != nil {
+		result.PullRequest = pr.String()
+		if urler, ok := pr.(urler); ok {
+			result.PullRequestURL = urler.URL()
+		}
+	}
+	switch {
+	case err == domain.NoChangeError:
+		result.Status = ReportStatusNoChange
+		result.Reason = err.Error()
+	case isSkip(err):
+		result.Status = ReportStatusSkipped
+		result.Reason = err.Error()
+	case err != nil:
+		result.Status = ReportStatusError
+		result.Error = err.Error()
+		result.ErrorCategory = errorCategory(err)
+	default:
+		result.Status = ReportStatusSuccess
+	}
+	rc.results[repo.FullName()] = result
+}
+
+// result returns the result of a single repository
+func (rc *reportCollector) result(repoName string) RepositoryReport {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	return rc.results[repoName]
+}
+
+// ReportSink is a destination, such as a storage bucket or a database, that the report of a run is sent to
+type ReportSink interface {
+	fmt.Stringer
+	WriteReport(ctx context.Context, report Report) error
+}
+
+// ProgressSink is a report sink that is also told about the progress of the run, such as a database the run can be followed in
+type ProgressSink interface {
+	ReportSink
+	// StartRun is called with the names of all repositories of the run, before any of them are run
+	StartRun(ctx context.Context, repositories []string) error
+	// RepositoryFinished is called with the result of each repository as soon as it is known
+	RepositoryFinished(ctx context.Context, result RepositoryReport) error
+}
+
+// startProgress tells the progress sinks about the repositories of the run, including the ones that did not pass the filter
+func (r *Runner) startProgress(ctx context.Context, repos []domain.Repository, skipped []skippedRepository) {
+	names := make([]string, 0, len(skipped)+len(repos))
+	for _, s := range skipped {
+		names = append(names, s.repo.FullName())
+	}
+	for _, repo := range repos {
+		names = append(names, repo.FullName())
+	}
+
+	for _, sink := range r.ReportSinks {
+		if progressSink, ok := sink.(ProgressSink); ok {
+			if err := progressSink.StartRun(ctx, names); err != nil {
+				log.Errorf("Could not send the start of the run to %s: %s", sink, err)
+			}
+		}
+	}
+}
+
+// reportProgress sends the result of a repository to the progress sinks
+func (r *Runner) reportProgress(ctx context.Context, repoName string) {
+	if r.report == nil {
+		return
+	}
+	result := r.report.result(repoName)
+	for _, sink := range r.ReportSinks {
+		if progressSink, ok := sink.(ProgressSink); ok {
+			if err := progressSink.RepositoryFinished(ctx, result); err != nil {
+				log.Errorf("Could not send the result of %s to %s: %s", repoName, sink, err)
+			}
+		}
+	}
+}
+
+func (rc *reportCollector) write(w io.Writer) error {
+	return WriteReport(w, rc.report())
+}
+
+// report returns the report of all repositories, sorted by name
+func (rc *reportCollector) report() Report {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	report := Report{
+		ChangeRequest: rc.changeRequest,
+		Repositories:  make([]RepositoryReport, 0, len(rc.results)),
+	}
+	for _, result := range rc.results {
+		report.Repositories = append(report.Repositories, result)
+	}
+	sort.Slice(report.Repositories, func(i, j int) bool {
+		return report.Repositories[i].Repository < report.Repositories[j].Repository
+	})
+	return report
+}
+
+// ReadReport reads a report written by a run
+func ReadReport(r io.Reader) (Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// WriteReport writes a report in the same format as a run
+func WriteReport(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// PullRequestGetter is a version controller that can get a single pull request
+type PullRequestGetter interface {
+	// GetPullRequest gets a pull request in the repository with the name in the format "owner/name"
+	GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error)
+}
+
+// getReportPullRequests gets the current state of the pull requests in a report.
+// Repositories that have been renamed or transferred are updated in the report
+func getReportPullRequests(ctx context.Context, vc VersionController, report *Report) ([]domain.PullRequest, error) {
+	g, ok := vc.(PullRequestGetter)
+	if !ok {
+		return nil, errors.New("the platform does not support getting pull requests from a report")
+	}
+
+	prs := []domain.PullRequest{}
+	for i, repo := range report.Repositories {
+		if repo.PullRequest == "" {
+			continue
+		}
+
+		repoName, number, err := parsePullRequestReference(repo.PullRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		pr, err := g.GetPullRequest(ctx, repoName, number)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the pull request %s", repo.PullRequest)
+		}
+		prs = append(prs, pr)
+
+		// The platforms follow repositories that have been renamed or transferred, the pull request then has the new name
+		newRepoName := pr.RepositoryName()
+		if strings.EqualFold(newRepoName, repoName) {
+			continue
+		}
+		log.Warnf("The repository %s has been renamed or transferred to %s, which will be used instead", repoName, newRepoName)
+		report.Repositories[i].Repository = newRepoName
+		report.Repositories[i].PullRequest = pr.String()
+		if urler, ok := pr.(urler); ok {
+			report.Repositories[i].PullRequestURL = urler.URL()
+		}
+		report.renamed = true
+	}
+	return prs, nil
+}
+
+// parsePullRequestReference parses a pull request in the format "owner/name #number"
+func parsePullRequestReference(str string) (string, int, error) {
+	i := strings.LastIndex(str, " #")
+	if i == -1 {
+		return "", 0, errors.Errorf(`could not parse the pull request "%s"`, str)
+	}
+	number, err := strconv.Atoi(str[i+2:])
+	if err != nil {
+		return "", 0, errors.Errorf(`could not parse the pull request "%s"`, str)
+	}
+	return str[:i], number, nil
+}
+
+// ReportComparison contains the differences between two reports
+type ReportComparison struct {
+	NewlyFailing   []RepositoryReport // Repositories that failed in the new report, but not in the old
+	NewlyFixed     []RepositoryReport // Repositories that failed in the old report, but not in the new
+	NewlyCompliant []RepositoryReport // Repositories that did not need any change in the new report, but did in the old
+	ChangedDiff    []RepositoryReport // Repositories where the changes differ between the reports
+	Added          []RepositoryReport // Repositories that only exist in the new report
+	Removed        []RepositoryReport // Repositories that only exist in the old report
+}
+
+// CompareReports compares an old report with a new one
+func CompareReports(oldReport, newReport Report) ReportComparison {
+	old := map[string]RepositoryReport{}
+	for _, repo := range oldReport.Repositories {
+		old[repo.Repository] = repo
+	}
+
+	var comparison ReportComparison
+	for _, newRepo := range newReport.Repositories {
+		oldRepo, ok := old[newRepo.Repository]
+		if !ok {
+			comparison.Added = append(comparison.Added, newRepo)
+			continue
+		}
+		delete(old, newRepo.Repository)
+
+		switch {
+		case newRepo.Status == ReportStatusError && oldRepo.Status != ReportStatusError:
+			comparison.NewlyFailing = append(comparison.NewlyFailing, newRepo)
+		case newRepo.Status != ReportStatusError && oldRepo.Status == ReportStatusError:
+			comparison.NewlyFixed = append(comparison.NewlyFixed, newRepo)
+		}
+
+		switch {
+		case newRepo.Status == ReportStatusNoChange && oldRepo.Status != ReportStatusNoChange:
+			comparison.NewlyCompliant = append(comparison.NewlyCompliant, newRepo)
+		case newRepo.DiffHash != "" && oldRepo.DiffHash != "" && newRepo.DiffHash != oldRepo.DiffHash:
+			comparison.ChangedDiff = append(comparison.ChangedDiff, newRepo)
+		}
+	}
+
+	for _, repo := range oldReport.Repositories {
+		if _, ok := old[repo.Repository]; ok {
+			comparison.Removed = append(comparison.Removed, repo)
+		}
+	}
+
+	return comparison
+}
+
+// Write writes a human readable version of the comparison
+func (c ReportComparison) Write(w io.Writer) {
+	sections := []struct {
+		title string
+		repos []RepositoryReport
+	}{
+		{"Newly failing repositories", c.NewlyFailing},
+		{"Repositories that no longer fail", c.NewlyFixed},
+		{"Newly compliant repositories", c.NewlyCompliant},
+		{"Repositories with changed diffs", c.ChangedDiff},
+		{"Added repositories", c.Added},
+		{"Removed repositories", c.Removed},
+	}
+
+	for _, section := range sections {
+		if len(section.repos) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s:\n", section.title)
+		for _, repo := range section.repos {
+			if repo.Error != "" && repo.Status == ReportStatusError {
+				fmt.Fprintf(w, "  %s: %s\n", repo.Repository, repo.Error)
+			} else {
+				fmt.Fprintf(w, "  %s\n", repo.Repository)
+			}
+		}
+	}
+}
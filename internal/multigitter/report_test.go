@@ -0,0 +1,60 @@
+package multigitter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareReports(t *testing.T) {
+	oldReport := Report{
+		Repositories: []RepositoryReport{
+			{Repository: "owner/failing", Status: ReportStatusSuccess, DiffHash: "a"},
+			{Repository: "owner/fixed", Status: ReportStatusError, Error: "the program exited with a non zero exit code"},
+			{Repository: "owner/compliant", Status: ReportStatusSuccess, DiffHash: "b"},
+			{Repository: "owner/changed", Status: ReportStatusSuccess, DiffHash: "c"},
+			{Repository: "owner/same", Status: ReportStatusSuccess, DiffHash: "d"},
+			{Repository: "owner/removed", Status: ReportStatusNoChange},
+		},
+	}
+	newReport := Report{
+		Repositories: []RepositoryReport{
+			{Repository: "owner/failing", Status: ReportStatusError, Error: "could not push changes"},
+			{Repository: "owner/fixed", Status: ReportStatusSuccess, DiffHash: "e"},
+			{Repository: "owner/compliant", Status: ReportStatusNoChange},
+			{Repository: "owner/changed", Status: ReportStatusSuccess, DiffHash: "f"},
+			{Repository: "owner/same", Status: ReportStatusSuccess, DiffHash: "d"},
+			{Repository: "owner/added", Status: ReportStatusSuccess, DiffHash: "g"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	CompareReports(oldReport, newReport).Write(buf)
+	assert.Equal(t, `Newly failing repositories:
+  owner/failing: could not push changes
+Repositories that no longer fail:
+  owner/fixed
+Newly compliant repositories:
+  owner/compliant
+Repositories with changed diffs:
+  owner/changed
+Added repositories:
+  owner/added
+Removed repositories:
+  owner/removed
+`, buf.String())
+}
+
+func TestParsePullRequestReference(t *testing.T) {
+	repoName, number, err := parsePullRequestReference("owner/repo #12")
+	assert.NoError(t, err)
+	assert.Equal(t, "owner/repo", repoName)
+	assert.Equal(t, 12, number)
+
+	_, _, err = parsePullRequestReference("owner/repo")
+	assert.Error(t, err)
+
+	_, _, err = parsePullRequestReference("owner/repo #abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package multigitter
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Requirement is a tool that has to be installed for the script to run, optionally with a version constraint
+type Requirement struct {
+	Tool     string // The name, or path, of the executable
+	Operator string // One of ">=", ">", "<=", "<" or "=", empty if any version can be used
+	Version  string // The version the installed version is compared with, for example "18" or "1.21.3"
+}
+
+func (req Requirement) String() string {
+	return req.Tool + req.Operator + req.Version
+}
+
+var requirementRegex = regexp.MustCompile(`^\s*([^<>=\s]+)\s*(?:(>=|<=|>|<|==?)\s*v?(\d+(?:\.\d+)*))?\s*$`)
+
+// ParseRequirement parses a requirement in the format "tool", or "tool" followed by an operator and a version, for example "node>=18"
+func ParseRequirement(str string) (Requirement, error) {
+	match := requirementRegex.FindStringSubmatch(str)
+	if match == nil {
+		return Requirement{}, errors.Errorf(`could not parse requirement "%s", it should be in the format "tool" or "tool>=version"`, str)
+	}
+
+	operator := match[2]
+	if operator == "==" {
+		operator = "="
+	}
+
+	return Requirement{
+		Tool:     match[1],
+		Operator: operator,
+		Version:  match[3],
+	}, nil
+}
+
+// CheckRequirements verifies that all tools the script requires are installed, in the required versions,
+// before any repository is changed
+func (r *Runner) CheckRequirements() error {
+	var problems []string
+	for _, req := range r.Requirements {
+		if err := req.check(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("the requirements of the script are not met:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func (req Requirement) check() error {
+	path, err := exec.LookPath(req.Tool)
+	if err != nil {
+		return errors.Errorf("%s is not installed", req.Tool)
+	}
+
+	if req.Operator == "" {
+		return nil
+	}
+
+	version, err := toolVersion(path)
+	if err != nil {
+		return errors.Errorf("could not determine the version of %s, %s is required", req.Tool, req)
+	}
+
+	if !compareVersions(version, req.Version).satisfies(req.Operator) {
+		return errors.Errorf("%s is installed in version %s, %s is required", req.Tool, version, req)
+	}
+	return nil
+}
+
+var versionRegex = regexp.MustCompile(`\d+(?:\.\d+)+|\d+`)
+
+// toolVersion returns the first version number printed by the tool. Most tools print it with --version,
+// but some, such as go, use a version subcommand instead
+func toolVersion(path string) (string, error) {
+	for _, arg := range []string{"--version", "version"} {
+		output, err := exec.Command(path, arg).CombinedOutput()
+		if err != nil {
+			continue
+		}
+		if version := versionRegex.FindString(string(output)); version != "" {
+			return version, nil
+		}
+	}
+	return "", errors.New("no version found")
+}
+
+type comparison int
+
+func (c comparison) satisfies(operator string) bool {
+	switch operator {
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	case "<":
+		return c < 0
+	default:
+		return c == 0
+	}
+}
+
+// compareVersions compares the dot separated version numbers. Only the parts of the required version are compared,
+// which means that "18.2.1" is equal to "18"
+func compareVersions(version, required string) comparison {
+	versionParts := strings.Split(version, ".")
+	for i, requiredPart := range strings.Split(required, ".") {
+		if i >= len(versionParts) {
+			return -1
+		}
+		v, _ := strconv.Atoi(versionParts[i])
+		req, _ := strconv.Atoi(requiredPart)
+		switch {
+		case v < req:
+			return -1
+		case v > req:
+			return 1
+		}
+	}
+	return 0
+}
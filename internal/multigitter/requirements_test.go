@@ -0,0 +1,57 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequirement(t *testing.T) {
+	tests := []struct {
+		str         string
+		requirement Requirement
+		expectErr   bool
+	}{
+		{str: "yq", requirement: Requirement{Tool: "yq"}},
+		{str: "node>=18", requirement: Requirement{Tool: "node", Operator: ">=", Version: "18"}},
+		{str: "go < 1.22.1", requirement: Requirement{Tool: "go", Operator: "<", Version: "1.22.1"}},
+		{str: "python3==v3.11", requirement: Requirement{Tool: "python3", Operator: "=", Version: "3.11"}},
+		{str: "node>=", expectErr: true},
+		{str: "node>=latest", expectErr: true},
+		{str: "", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			requirement, err := ParseRequirement(tt.str)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.requirement, requirement)
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		version  string
+		operator string
+		required string
+		expected bool
+	}{
+		{"18.2.1", ">=", "18", true},
+		{"18.2.1", "=", "18", true},
+		{"18.2.1", ">", "18", false},
+		{"18.2.1", ">", "18.1", true},
+		{"16.20.0", ">=", "18", false},
+		{"1.9", "<", "1.10", true},
+		{"2", ">=", "2.1", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, compareVersions(tt.version, tt.required).satisfies(tt.operator), "%s %s %s", tt.version, tt.operator, tt.required)
+	}
+}
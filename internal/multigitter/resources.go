@@ -0,0 +1,106 @@
+package multigitter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ResourceUsage is the time and resources used when running a single repository
+type ResourceUsage struct {
+	WallTime         float64 `json:"wall_time_seconds"`                  // The time it took to run the repository, from cloning to creating the pull request
+	ScriptCPUTime    float64 `json:"script_cpu_time_seconds,omitempty"`  // The user and system cpu time used by the script
+	ScriptPeakMemory int64   `json:"script_peak_memory_bytes,omitempty"` // The maximum resident set size of the script, not available on Windows
+	CloneSize        int64   `json:"clone_size_bytes,omitempty"`         // The size of the cloned repository, including the .git directory
+}
+
+// setResources updates the resource usage of a repository
+func (rc *reportCollector) setResources(repoName string, update func(usage *ResourceUsage)) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	result := rc.results[repoName]
+	if result.Resources == nil {
+		result.Resources = &ResourceUsage{}
+	}
+	update(result.Resources)
+	rc.results[repoName] = result
+}
+
+// setScriptUsage records the resources used by a script that has finished
+func (rc *reportCollector) setScriptUsage(repoName string, state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	rc.setResources(repoName, func(usage *ResourceUsage) {
+		usage.ScriptCPUTime = (state.UserTime() + state.SystemTime()).Seconds()
+		usage.ScriptPeakMemory = peakMemory(state)
+	})
+}
+
+// dirSize returns the total size of all files in the directory
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// writeSlowest writes a summary of the repositories that took the longest time to run
+func writeSlowest(w io.Writer, report Report, n int) {
+	repos := make([]RepositoryReport, 0, len(report.Repositories))
+	for _, repo := range report.Repositories {
+		if repo.Resources != nil {
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].Resources.WallTime > repos[j].Resources.WallTime
+	})
+	if len(repos) > n {
+		repos = repos[:n]
+	}
+
+	fmt.Fprintln(w, "Slowest repositories:")
+	for _, repo := range repos {
+		usage := repo.Resources
+		fmt.Fprintf(w, "  %s: %s (script cpu time %s, script peak memory %s, clone size %s)\n",
+			repo.Repository,
+			formatSeconds(usage.WallTime),
+			formatSeconds(usage.ScriptCPUTime),
+			formatBytes(usage.ScriptPeakMemory),
+			formatBytes(usage.CloneSize),
+		)
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
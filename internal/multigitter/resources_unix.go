@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package multigitter
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// peakMemory returns the maximum resident set size, in bytes, of a process that has finished
+func peakMemory(state *os.ProcessState) int64 {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	// The size is in bytes on macOS, and in kilobytes on other systems
+	if runtime.GOOS == "darwin" {
+		return int64(rusage.Maxrss)
+	}
+	return int64(rusage.Maxrss) * 1024
+}
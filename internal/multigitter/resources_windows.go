@@ -0,0 +1,10 @@
+package multigitter
+
+import (
+	"os"
+)
+
+// peakMemory returns zero, since the memory used by a process is not available on Windows
+func peakMemory(_ *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,168 @@
+package multigitter
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Rollbacker reverts the changes of the merged pull requests of an earlier run, or the commits of a campaign
+type Rollbacker struct {
+	VersionController VersionController
+
+	Report Report // The report of the run that should be rolled back, it has to contain the patch of each repository
+
+	// If set, the commits that match are reverted in all repositories, instead of the changes in the report.
+	// This does not require the report, or any other information, of the run that made the commits
+	Commits *CommitMatcher
+
+	// The runner that creates the pull requests with the reverted changes, its script is not used
+	Runner *Runner
+}
+
+// Rollback creates pull requests that revert the changes of all merged pull requests in the report,
+// or of all matching commits
+func (rb Rollbacker) Rollback(ctx context.Context) error {
+	if rb.Commits != nil {
+		runner := *rb.Runner
+		runner.changer = func(dir string, _ domain.Repository) error {
+			return revertCommits(dir, *rb.Commits)
+		}
+		return runner.Run(ctx)
+	}
+
+	prs, err := getReportPullRequests(ctx, rb.VersionController, &rb.Report)
+	if err != nil {
+		return err
+	}
+
+	merged := map[string]bool{}
+	for _, pr := range prs {
+		repoName := pr.RepositoryName()
+
+		if pr.Status() != domain.PullRequestStatusMerged {
+			log.WithField("pr", pr.String()).Infof("Skipping since the pull request is not merged")
+			continue
+		}
+		merged[repoName] = true
+	}
+
+	patches := map[string]string{}
+	names := []string{}
+	for _, repo := range rb.Report.Repositories {
+		if !merged[repo.Repository] {
+			continue
+		}
+		if repo.Patch == "" {
+			log.WithField("repo", repo.Repository).Warn("Skipping since no patch was recorded in the report")
+			continue
+		}
+		patches[repo.Repository] = repo.Patch
+		names = append(names, repo.Repository)
+	}
+
+	if len(names) == 0 {
+		log.Info("No merged pull requests to roll back")
+		return nil
+	}
+
+	runner := *rb.Runner
+	runner.OnlyRepositories = names
+	runner.changer = func(dir string, repo domain.Repository) error {
+		return revertPatch(dir, patches[repo.FullName()])
+	}
+	return runner.Run(ctx)
+}
+
+// CommitMatcher selects commits by their commit message
+type CommitMatcher struct {
+	MessagePattern *regexp.Regexp // If set, the commit message has to match the pattern
+	Trailers       []string       // Trailers, in the format "Key: value", that the commit message has to contain
+}
+
+// matches checks if the commit message matches all conditions
+func (m CommitMatcher) matches(message string) bool {
+	if m.MessagePattern != nil && !m.MessagePattern.MatchString(message) {
+		return false
+	}
+
+	trailers := commitTrailers(message)
+	for _, trailer := range m.Trailers {
+		if !trailers[normalizeTrailer(trailer)] {
+			return false
+		}
+	}
+	return true
+}
+
+// commitTrailers returns the normalized trailers in the last paragraph of a commit message
+func commitTrailers(message string) map[string]bool {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	trailers := map[string]bool{}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		trailers[normalizeTrailer(line)] = true
+	}
+	return trailers
+}
+
+// normalizeTrailer makes the key of a trailer, which is case insensitive, lower case and removes extra whitespace
+func normalizeTrailer(trailer string) string {
+	split := strings.SplitN(trailer, ":", 2)
+	if len(split) != 2 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(split[0])) + ": " + strings.TrimSpace(split[1])
+}
+
+var revertedCommitRegex = regexp.MustCompile(`This reverts commit ([0-9a-f]{40})`)
+
+// revertCommits reverts the changes of all commits that match, in the repository in the directory.
+// Merge commits, and commits that have already been reverted with git revert, are not reverted
+func revertCommits(dir string, matcher CommitMatcher) error {
+	output, err := gitOutput(dir, "log", "--no-merges", "--format=%H%x00%B%x00")
+	if err != nil {
+		return errors.WithMessage(err, "could not list the commits")
+	}
+
+	type commit struct {
+		hash    string
+		message string
+	}
+	var commits []commit
+	reverted := map[string]bool{}
+	fields := strings.Split(output, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		c := commit{
+			hash:    strings.TrimSpace(fields[i]),
+			message: fields[i+1],
+		}
+		commits = append(commits, c)
+		for _, match := range revertedCommitRegex.FindAllStringSubmatch(c.message, -1) {
+			reverted[match[1]] = true
+		}
+	}
+
+	// The commits are listed with the latest first, which is the order they have to be reverted in
+	for _, c := range commits {
+		if reverted[c.hash] || !matcher.matches(c.message) {
+			continue
+		}
+
+		patch, err := gitOutput(dir, "show", "--format=", "--binary", "--no-color", c.hash)
+		if err != nil {
+			return errors.WithMessagef(err, "could not get the changes of the commit %s", c.hash)
+		}
+		if strings.TrimSpace(patch) == "" {
+			continue
+		}
+		if err := revertPatch(dir, patch); err != nil {
+			return errors.WithMessagef(err, "commit %s", c.hash)
+		}
+	}
+	return nil
+}
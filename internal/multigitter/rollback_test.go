@@ -0,0 +1,57 @@
+package multigitter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitMatcher_matches(t *testing.T) {
+	message := "Update dependencies\n\nSome description: not a trailer\n\nCampaign-Id: deps-2021\nSigned-off-by: Someone <someone@example.com>\n"
+
+	tests := []struct {
+		name    string
+		matcher CommitMatcher
+		want    bool
+	}{
+		{
+			name:    "pattern",
+			matcher: CommitMatcher{MessagePattern: regexp.MustCompile(`^Update dep`)},
+			want:    true,
+		},
+		{
+			name:    "pattern mismatch",
+			matcher: CommitMatcher{MessagePattern: regexp.MustCompile(`^Revert`)},
+			want:    false,
+		},
+		{
+			name:    "trailer",
+			matcher: CommitMatcher{Trailers: []string{"campaign-id:  deps-2021"}},
+			want:    true,
+		},
+		{
+			name:    "trailer with other value",
+			matcher: CommitMatcher{Trailers: []string{"Campaign-Id: deps-2022"}},
+			want:    false,
+		},
+		{
+			name:    "not in the last paragraph",
+			matcher: CommitMatcher{Trailers: []string{"Some description: not a trailer"}},
+			want:    false,
+		},
+		{
+			name: "pattern and trailer",
+			matcher: CommitMatcher{
+				MessagePattern: regexp.MustCompile(`dependencies`),
+				Trailers:       []string{"Campaign-Id: deps-2021", "Signed-off-by: Someone <someone@example.com>"},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.matcher.matches(message))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package multigitter
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"os/exec"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/eiannone/keyboard"
 	"github.com/pkg/errors"
@@ -34,32 +36,111 @@ type VersionController interface {
 // Runner contains fields to be able to do the run
 type Runner struct {
 	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
 
 	ScriptPath    string // Must be absolute path
 	Arguments     []string
+	Env           []string // Extra environment variables, in the format "KEY=value", the script is run with
+	Patch         string   // If set, this patch, created with git diff, is applied instead of running the script
 	FeatureBranch string
 	Token         string
 
-	Output io.Writer
+	Output      io.Writer
+	Report      io.Writer    // If set, a JSON report of the result of each repository is written to it
+	ReportSinks []ReportSink // Destinations, other than Report, the report is sent to
+	Slowest     int          // If set, a summary of this number of repositories that took the longest time to run is written to the output
+	Summary     io.Writer    // If set, a table with the outcome of each repository is written to it when the run is done
+
+	OwnershipReport       io.Writer // If set, a report of which CODEOWNERS owns the changed files is written to it
+	OwnershipReportFormat string    // OwnershipFormatJSON or OwnershipFormatMarkdown
 
 	CommitMessage    string
 	PullRequestTitle string
 	PullRequestBody  string
-	Reviewers        []string
+	Reviewers        []domain.Reviewer
 	MaxReviewers     int // If set to zero, all reviewers will be used
+	Assignees        []string
+	Milestone        string // The title of a milestone the pull requests are added to
+	Draft            bool
+	PathLabels       []PathLabel // Labels that are added depending on which files were changed
+	Provenance       *Provenance // If set, a footer with provenance metadata is added to the pull request body
+	PullRequestRules PullRequestRules
 	DryRun           bool
+	Simulate         bool // If set, everything except writes to the platform and pushes is done, and the pull requests that would be created are previewed
 	CommitAuthor     *domain.CommitAuthor
 	BaseBranch       string // The base branch of the PR, use default branch if not set
 
-	Concurrent      int
-	SkipPullRequest bool // If set, the script will run directly on the base-branch without creating any PR
+	PullRequestTemplates []PullRequestTemplate // Titles and bodies used instead of the default ones in the repositories they match, the first matching is used
+
+	ChangeRequest *ChangeRequest // If set, the change request has to be approved before anything is changed, and it is referenced in the pull requests and the report
+
+	Concurrent        int
+	ConcurrencyLimits ConcurrencyLimits // Limits of concurrent runs within the same host or owner, in addition to Concurrent
+	SkipPullRequest   bool              // If set, the script will run directly on the base-branch without creating any PR
 
 	Fork      bool   // If set, create a fork and make the pull request from it
 	ForkOwner string // The owner of the new fork. If empty, the fork should happen on the logged in user
 
+	FailureIssues FailureIssues // Issues created for repositories where the run failed
+
+	DeployKeys DeployKeys // Keys used instead of the token when pushing to repositories
+
+	VerifyChecks  bool          // If set, wait for the checks of each created pull request to finish, and fail the repository if any of them fail
+	VerifyTimeout time.Duration // The maximum time to wait for the checks of a pull request
+
+	PublishAnnotations bool // If set, the annotations the script writes to the file in ANNOTATIONS_FILE are published as a check run on the pushed commit
+
+	Suggest bool // If set, the changes are suggested in review comments on the open pull request of the feature branch, instead of being pushed
+
+	SkipEquivalent bool // If set, skip repositories where an open pull request from another branch contains the same changes
+
+	UpdateBranch              bool               // If set, an already existing feature branch is updated instead of skipped
+	ConflictStrategies        []ConflictStrategy // How conflicts should be resolved when updating an existing branch
+	ConflictResolver          string             // A script that is run to resolve conflicts not covered by any strategy. Must be absolute path
+	ConflictResolverArguments []string
+
+	Sandbox Sandbox // Restrictions the script is run with
+
+	// If set, ongoing scripts are killed when the context is done. The context of the run only stops new repositories from being run
+	KillContext context.Context
+
+	Requirements []Requirement // Tools that have to be installed for the script to run, checked before the run starts
+
 	Interactive bool // If set, interactive mode is activated and the user will be asked to verify every change
+	Pick        bool // If set, the user will be asked to pick which of the repositories that should be used
 
 	CreateGit func(dir string) Git
+
+	OnlyRepositories []string // If set, only the repositories with these names, in the format "owner/name", are used
+
+	Dependencies Dependencies // Repositories are not run until the pull requests of the repositories they depend on are merged
+
+	QueueLease time.Duration // How long a worker may run a repository claimed from a queue, without extending its claim
+
+	repositories []domain.Repository // If set, these repositories are used instead of fetching them from the platform
+
+	changer func(dir string, repo domain.Repository) error // If set, the repository is changed with this function instead of running the script
+
+	report                 *reportCollector
+	ownership              *ownershipCollector
+	dependencies           *dependencyTracker
+	suggestionPullRequests *openPullRequests
+}
+
+// filterRepositoryNames returns the repositories with any of the names
+func filterRepositoryNames(repos []domain.Repository, names []string) []domain.Repository {
+	include := map[string]bool{}
+	for _, name := range names {
+		include[name] = true
+	}
+
+	filtered := make([]domain.Repository, 0, len(names))
+	for _, repo := range repos {
+		if include[repo.FullName()] {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered
 }
 
 var errAborted = errors.New("run was never started because of aborted execution")
@@ -78,58 +159,239 @@ func (pr dryRunPullRequest) String() string {
 	return fmt.Sprintf("%s #0", pr.Repository.FullName())
 }
 
+func (pr dryRunPullRequest) RepositoryName() string {
+	return pr.Repository.FullName()
+}
+
 // Run runs a script for multiple repositories and creates PRs with the changes made
 func (r *Runner) Run(ctx context.Context) error {
+	if err := r.verifyChangeRequest(ctx); err != nil {
+		return err
+	}
+
 	// Fetch all repositories that are are going to be used in the run
-	repos, err := r.VersionController.GetRepositories(ctx)
+	repos, skipped, err := r.getRepositories(ctx)
 	if err != nil {
-		return errors.Wrap(err, "could not fetch repositories")
+		return err
+	}
+
+	if r.Pick {
+		repos, err = pickRepositories(repos)
+		if err != nil {
+			return errors.Wrap(err, "could not pick repositories")
+		}
 	}
 
+	if r.Suggest {
+		r.suggestionPullRequests = &openPullRequests{}
+	}
+
+	defer func() {
+		if r.Summary != nil {
+			writeSummary(r.Summary, reportSummaryRows(r.report.report()))
+		}
+	}()
+
 	// Setting up a "counter" that keeps track of successful and failed runs
 	rc := repocounter.NewCounter()
+	defer func() {
+		if r.Slowest > 0 {
+			writeSlowest(r.Output, r.report.report(), r.Slowest)
+		}
+	}()
 	defer func() {
 		if info := rc.Info(); info != "" {
 			fmt.Fprint(r.Output, info)
 		}
 	}()
 
-	log.Infof("Running on %d repositories", len(repos))
+	for _, restriction := range r.Sandbox.unsupported() {
+		log.Warnf("The sandbox restriction %s is not supported on this system and will not be applied", restriction)
+	}
 
-	runInParallel(func(i int) {
-		logger := log.WithField("repo", repos[i].FullName())
+	if r.Report != nil || len(r.ReportSinks) > 0 || r.Slowest > 0 || r.Summary != nil {
+		r.report = newReportCollector()
+		if r.ChangeRequest != nil {
+			r.report.changeRequest = r.ChangeRequest.ID
+		}
+		defer func() {
+			if r.Report != nil {
+				if err := r.report.write(r.Report); err != nil {
+					log.Errorf("Could not write the report: %s", err)
+				}
+			}
+			// The report is sent even if the run has been canceled
+			for _, sink := range r.ReportSinks {
+				if err := sink.WriteReport(context.Background(), r.report.report()); err != nil {
+					log.Errorf("Could not send the report to %s: %s", sink, err)
+				}
+			}
+		}()
+	}
 
+	r.startProgress(context.Background(), repos, skipped)
+	r.reportSkipped(skipped)
+
+	if r.OwnershipReport != nil {
+		r.ownership = newOwnershipCollector()
 		defer func() {
-			if r := recover(); r != nil {
-				log.Error(r)
-				rc.AddError(errors.New("run paniced"), repos[i])
+			if err := r.ownership.write(r.OwnershipReport, r.OwnershipReportFormat); err != nil {
+				log.Errorf("Could not write the ownership report: %s", err)
 			}
 		}()
+	}
 
-		pr, err := r.runSingleRepo(ctx, repos[i])
+	levels, err := r.dependencyLevels(ctx, repos)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Running on %d repositories", len(repos))
+
+	// Repositories are run after the repositories they depend on
+	for _, level := range levels {
+		runRepositoriesInParallel(func(i int) {
+			r.runAndRecord(ctx, level[i], rc)
+		}, level, r.Concurrent, r.ConcurrencyLimits)
+	}
+
+	return nil
+}
+
+// reportSkipped reports the repositories that did not pass the filter, to be able to tell why they were not changed
+func (r *Runner) reportSkipped(skipped []skippedRepository) {
+	for _, s := range skipped {
+		log.WithField("repo", s.repo.FullName()).Debugf("Skipping since %s", s.reason)
+		if r.report != nil {
+			r.report.add(s.repo, nil, domain.SkipError{Reason: s.reason})
+			r.reportProgress(context.Background(), s.repo.FullName())
+		}
+	}
+}
+
+// dependencyLevels sorts the repositories into levels, where each level only depends on earlier levels
+func (r *Runner) dependencyLevels(ctx context.Context, repos []domain.Repository) ([][]domain.Repository, error) {
+	if len(r.Dependencies) == 0 {
+		return [][]domain.Repository{repos}, nil
+	}
+
+	prs, err := r.VersionController.GetPullRequests(ctx, r.FeatureBranch)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the pull requests of the dependencies")
+	}
+	r.dependencies = newDependencyTracker(r.Dependencies, prs)
+
+	names := make([]string, len(repos))
+	reposByName := map[string]domain.Repository{}
+	for i, repo := range repos {
+		names[i] = repo.FullName()
+		reposByName[repo.FullName()] = repo
+	}
+	nameLevels, err := r.Dependencies.levels(names)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make([][]domain.Repository, len(nameLevels))
+	for i, nameLevel := range nameLevels {
+		for _, name := range nameLevel {
+			levels[i] = append(levels[i], reposByName[name])
+		}
+	}
+	return levels, nil
+}
+
+// getRepositories fetches the repositories that should be used in the run, and the ones that did not pass the filter
+func (r *Runner) getRepositories(ctx context.Context) ([]domain.Repository, []skippedRepository, error) {
+	repos := r.repositories
+	var skipped []skippedRepository
+	if repos == nil {
+		var err error
+		repos, skipped, err = getFilteredRepositories(ctx, r.VersionController, r.Filter)
 		if err != nil {
-			if err != errAborted {
-				logger.Info(err)
-			}
-			rc.AddError(err, repos[i])
+			return nil, nil, errors.Wrap(err, "could not fetch repositories")
+		}
+	}
 
-			if log.IsLevelEnabled(log.TraceLevel) {
-				if stackTrace := getStackTrace(err); stackTrace != "" {
-					log.Trace(stackTrace)
-				}
-			}
+	if len(r.OnlyRepositories) > 0 {
+		repos = filterRepositoryNames(repos, r.OnlyRepositories)
+		skipped = filterSkippedNames(skipped, r.OnlyRepositories)
+	}
 
-			return
+	return repos, skipped, nil
+}
+
+// filterSkippedNames returns the skipped repositories with any of the names
+func filterSkippedNames(skipped []skippedRepository, names []string) []skippedRepository {
+	include := map[string]bool{}
+	for _, name := range names {
+		include[name] = true
+	}
+
+	var filtered []skippedRepository
+	for _, s := range skipped {
+		if include[s.repo.FullName()] {
+			filtered = append(filtered, s)
 		}
+	}
+	return filtered
+}
+
+// runAndRecord runs a single repository, and records the result of it
+func (r *Runner) runAndRecord(ctx context.Context, repo domain.Repository, rc *repocounter.Counter) {
+	logger := log.WithField("repo", repo.FullName())
 
-		if pr != nil {
-			rc.AddSuccessPullRequest(pr)
-		} else {
-			rc.AddSuccessRepositories(repos[i])
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error(r)
+			rc.AddError(errors.New("run paniced"), repo)
 		}
-	}, len(repos), r.Concurrent)
+	}()
 
-	return nil
+	start := time.Now()
+	pr, err := r.runSingleRepo(ctx, repo)
+	if r.report != nil && err != errAborted {
+		wallTime := time.Since(start).Seconds()
+		r.report.setResources(repo.FullName(), func(usage *ResourceUsage) {
+			usage.WallTime = wallTime
+		})
+	}
+	if r.dependencies != nil {
+		r.dependencies.add(repo.FullName(), pr, err)
+	}
+	if r.report != nil {
+		r.report.add(repo, pr, err)
+		r.reportProgress(context.Background(), repo.FullName())
+	}
+	if r.ownership != nil {
+		r.ownership.add(repo, pr, err)
+	}
+	if err != nil {
+		if err != errAborted {
+			logger.Info(err)
+		}
+		rc.AddError(err, repo)
+
+		if r.createsFailureIssues() && isFailure(err) {
+			if err := r.createFailureIssue(ctx, repo, err); err != nil {
+				logger.Errorf("Could not create issue: %s", err)
+			}
+		}
+
+		if log.IsLevelEnabled(log.TraceLevel) {
+			if stackTrace := getStackTrace(err); stackTrace != "" {
+				log.Trace(stackTrace)
+			}
+		}
+
+		return
+	}
+
+	if pr != nil {
+		rc.AddSuccessPullRequest(pr)
+	} else {
+		rc.AddSuccessRepositories(repo)
+	}
 }
 
 func runInParallel(fun func(i int), total int, maxConcurrent int) {
@@ -147,14 +409,79 @@ func runInParallel(fun func(i int), total int, maxConcurrent int) {
 	wg.Wait()
 }
 
-func getReviewers(reviewers []string, maxReviewers int) []string {
+// runScript runs the script in dir. If annotationsPath is set, the script may write annotations to it
+func (r *Runner) runScript(log log.FieldLogger, dir string, repo domain.Repository, annotationsPath string) error {
+	writable := []string{dir}
+	env := []string{fmt.Sprintf("REPOSITORY=%s", repo.FullName())}
+	if annotationsPath != "" {
+		writable = append(writable, annotationsPath)
+		env = append(env, fmt.Sprintf("ANNOTATIONS_FILE=%s", annotationsPath))
+	}
+
+	cmd := r.Sandbox.command(writable, r.ScriptPath, r.Arguments...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Env = append(cmd.Env, r.Env...)
+
+	// Setup logger that transfers stdout and stderr from the run to logs
+	writer := logger.NewLogger(log)
+	defer writer.Close()
+	cmd.Stdout = writer
+	// The output is only kept in memory when it is added to the issues of failed runs
+	var output *bytes.Buffer
+	if r.createsFailureIssues() {
+		output = &bytes.Buffer{}
+		cmd.Stdout = io.MultiWriter(writer, output)
+	}
+	cmd.Stderr = cmd.Stdout
+
+	err := runKillable(r.KillContext, cmd)
+	if r.report != nil {
+		r.report.setScriptUsage(repo.FullName(), cmd.ProcessState)
+	}
+	if err == errKilled {
+		return err
+	} else if err != nil {
+		scriptErr := &scriptError{
+			err: transformExecError(err),
+		}
+		if output != nil {
+			scriptErr.output = output.String()
+		}
+		return scriptErr
+	}
+	return nil
+}
+
+// createsFailureIssues returns if issues are created for the repositories where the run failed
+func (r *Runner) createsFailureIssues() bool {
+	return r.FailureIssues.Enabled && !r.DryRun && !r.Simulate
+}
+
+// getReviewers returns the reviewers of a pull request. If there are more than maxReviewers, a random selection of them is returned.
+// The default reviewers are always kept, and not counted as one of the reviewers
+func getReviewers(reviewers []domain.Reviewer, maxReviewers int) []domain.Reviewer {
 	if maxReviewers == 0 || len(reviewers) <= maxReviewers {
 		return reviewers
 	}
 
-	rand.Shuffle(len(reviewers), func(i, j int) { reviewers[i], reviewers[j] = reviewers[j], reviewers[i] })
+	var selectable []domain.Reviewer
+	for _, reviewer := range reviewers {
+		if reviewer.Type != domain.ReviewerTypeDefault {
+			selectable = append(selectable, reviewer)
+		}
+	}
+	if len(selectable) <= maxReviewers {
+		return reviewers
+	}
 
-	return reviewers[0:maxReviewers]
+	rand.Shuffle(len(selectable), func(i, j int) { selectable[i], selectable[j] = selectable[j], selectable[i] })
+
+	selected := selectable[0:maxReviewers]
+	if domain.HasDefaultReviewers(reviewers) {
+		selected = append(selected, domain.DefaultReviewers)
+	}
+	return selected
 }
 
 func (r *Runner) runSingleRepo(ctx context.Context, repo domain.Repository) (domain.PullRequest, error) {
@@ -162,6 +489,20 @@ func (r *Runner) runSingleRepo(ctx context.Context, repo domain.Repository) (dom
 		return nil, errAborted
 	}
 
+	if r.dependencies != nil {
+		if err := r.dependencies.check(repo.FullName()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkWritable(repo); err != nil {
+		return nil, err
+	}
+
+	if r.Suggest {
+		return r.suggestChanges(ctx, repo)
+	}
+
 	log := log.WithField("repo", repo.FullName())
 	log.Info("Cloning and running script")
 
@@ -171,119 +512,354 @@ func (r *Runner) runSingleRepo(ctx context.Context, repo domain.Repository) (dom
 	}
 	defer os.RemoveAll(tmpDir)
 
-	sourceController := r.CreateGit(tmpDir)
+	sourceController, baseBranch, err := r.clone(ctx, tmpDir, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := r.makeChanges(log, tmpDir, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := r.commitChanges(ctx, sourceController, tmpDir, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Interactive {
+		err = r.interactive(tmpDir, repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.DryRun {
+		log.Info("Skipping pushing changes because of dry run")
+		return dryRunPullRequest{
+			Repository: repo,
+		}, nil
+	}
+
+	prRepo, remoteName, updated, err := r.prepareRemote(ctx, log, sourceController, tmpDir, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	title, body, err := r.pullRequestText(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	newPR := r.newPullRequest(title, body, baseBranch, labels)
+
+	if r.Simulate {
+		return r.simulatePullRequest(repo, updated, newPR), nil
+	}
+
+	log.Info("Pushing changes to remote")
+	if err := r.pushChanges(ctx, sourceController, remoteName, repo, prRepo, newPR); err != nil {
+		return nil, err
+	}
+
+	if len(annotations) > 0 {
+		r.publishScriptAnnotations(ctx, log, prRepo, baseBranch, annotations)
+	}
+
+	// The pull request of an updated branch does already exist
+	if r.SkipPullRequest || updated {
+		return nil, nil
+	}
+
+	log.Info("Creating pull request")
+	return r.createPullRequest(ctx, repo, prRepo, newPR)
+}
+
+// clone clones the repository into dir and changes to the feature branch. The branch the changes are based on is returned
+func (r *Runner) clone(ctx context.Context, dir string, repo domain.Repository) (Git, string, error) {
+	sourceController := r.CreateGit(dir)
 
 	baseBranch := r.BaseBranch
 	if baseBranch == "" {
 		baseBranch = repo.DefaultBranch()
 	}
 
-	err = sourceController.Clone(repo.URL(r.Token), baseBranch)
+	cloneURL, err := repositoryURL(ctx, repo, r.Token)
 	if err != nil {
-		return nil, err
+		return nil, "", inStage(ErrorCategoryClone, err)
+	}
+	err = sourceController.Clone(cloneURL, baseBranch)
+	if err != nil {
+		return nil, "", inStage(ErrorCategoryClone, err)
+	}
+
+	if r.report != nil {
+		size, err := dirSize(dir)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "could not get the size of the cloned repository")
+		}
+		r.report.setResources(repo.FullName(), func(usage *ResourceUsage) {
+			usage.CloneSize = size
+		})
 	}
 
 	// Change the branch to the feature branch
 	if !r.SkipPullRequest {
 		err = sourceController.ChangeBranch(r.FeatureBranch)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	// Run the command that might or might not change the content of the repo
-	// If the command return a non zero exit code, abort.
-	cmd := exec.Command(r.ScriptPath, r.Arguments...)
-	cmd.Dir = tmpDir
-	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("REPOSITORY=%s", repo.FullName()),
-	)
+	return sourceController, baseBranch, nil
+}
 
-	// Setup logger that transfers stdout and stderr from the run to logs
-	writer := logger.NewLogger(log)
-	defer writer.Close()
-	cmd.Stdout = writer
-	cmd.Stderr = writer
+// makeChanges makes the changes in the cloned repository, by running the script, applying a patch or reverting earlier changes.
+// The annotations the script wrote are returned
+func (r *Runner) makeChanges(log log.FieldLogger, dir string, repo domain.Repository) ([]domain.Annotation, error) {
+	// The annotations file is kept outside of the repository, to not be committed.
+	// It is created beforehand, to be writable by the script even if the filesystem is read-only
+	annotationsPath := ""
+	if r.PublishAnnotations {
+		annotationsPath = dir + "-annotations"
+		if err := ioutil.WriteFile(annotationsPath, nil, 0600); err != nil {
+			return nil, errors.Wrap(err, "could not create the annotations file")
+		}
+		defer os.Remove(annotationsPath)
+	}
+
+	var err error
+	if r.changer != nil {
+		err = r.changer(dir, repo)
+	} else if r.Patch != "" {
+		err = applyPatch(dir, r.Patch)
+	} else {
+		err = r.runScript(log, dir, repo, annotationsPath)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	err = cmd.Run()
+	if annotationsPath == "" {
+		return nil, nil
+	}
+	annotations, err := readAnnotations(annotationsPath)
 	if err != nil {
-		return nil, transformExecError(err)
+		return nil, errors.Wrap(err, "could not read the annotations of the script")
 	}
+	return annotations, nil
+}
 
+// commitChanges commits the changes, if there are any. The labels of the changed paths are returned
+func (r *Runner) commitChanges(ctx context.Context, sourceController Git, dir string, repo domain.Repository) ([]string, error) {
 	if changed, err := sourceController.Changes(); err != nil {
 		return nil, err
 	} else if !changed {
 		return nil, domain.NoChangeError
 	}
 
-	err = sourceController.Commit(r.CommitAuthor, r.CommitMessage)
+	err := sourceController.Commit(r.CommitAuthor, r.commitMessage(repo))
 	if err != nil {
 		return nil, err
 	}
 
-	if r.Interactive {
-		err = r.interactive(tmpDir, repo)
+	if r.report != nil {
+		diff, err := sourceController.LastCommitDiff()
 		if err != nil {
+			return nil, errors.Wrap(err, "could not get the diff of the changes")
+		}
+		r.report.setDiff(repo, diff)
+	}
+
+	if r.SkipEquivalent && !r.SkipPullRequest {
+		if err := r.checkEquivalent(ctx, sourceController, repo); err != nil {
 			return nil, err
 		}
 	}
 
-	if r.DryRun {
-		log.Info("Skipping pushing changes because of dry run")
-		return dryRunPullRequest{
-			Repository: repo,
-		}, nil
+	if len(r.PathLabels) == 0 && r.ownership == nil {
+		return nil, nil
 	}
 
+	changedFiles, err := sourceController.ChangedFiles()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get changed files")
+	}
+	if r.ownership != nil {
+		if err := r.ownership.setChangedFiles(dir, repo, changedFiles); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.PathLabels) == 0 {
+		return nil, nil
+	}
+	return pathLabels(r.PathLabels, changedFiles), nil
+}
+
+// prepareRemote forks the repository if needed, and updates the feature branch if it does already exist.
+// The repository the pull request is created from, the name of the remote to push to, and if the branch was updated are returned
+func (r *Runner) prepareRemote(ctx context.Context, log log.FieldLogger, sourceController Git, dir string, repo domain.Repository) (domain.Repository, string, bool, error) {
 	remoteName := "origin"
+	if err := r.refreshRemoteURL(ctx, sourceController, remoteName, repo); err != nil {
+		return nil, "", false, errors.Wrap(err, "could not refresh the url of the repository")
+	}
+
 	var prRepo domain.Repository = repo
-	if r.Fork {
+	if r.Fork && r.Simulate {
+		log.Info("Skipping forking the repository because of simulation")
+	} else if r.Fork {
 		log.Info("Forking repository")
 
+		var err error
 		prRepo, err = r.VersionController.ForkRepository(ctx, repo, r.ForkOwner)
 		if err != nil {
-			return nil, errors.Wrap(err, "could not fork repository")
+			return nil, "", false, errors.Wrap(err, "could not fork repository")
 		}
 
-		err = sourceController.AddRemote("fork", prRepo.URL(r.Token))
+		forkURL, err := repositoryURL(ctx, prRepo, r.Token)
 		if err != nil {
-			return nil, err
+			return nil, "", false, err
+		}
+		err = sourceController.AddRemote("fork", forkURL)
+		if err != nil {
+			return nil, "", false, err
 		}
 		remoteName = "fork"
 	}
 
-	if !r.SkipPullRequest {
-		featureBranchExist, err := sourceController.BranchExist(remoteName, r.FeatureBranch)
+	if r.SkipPullRequest || (r.Fork && r.Simulate) {
+		return prRepo, remoteName, false, nil
+	}
+
+	featureBranchExist, err := sourceController.BranchExist(remoteName, r.FeatureBranch)
+	if err != nil {
+		return nil, "", false, errors.Wrap(err, "could not verify if branch already exist")
+	} else if !featureBranchExist {
+		return prRepo, remoteName, false, nil
+	} else if !r.UpdateBranch {
+		return nil, "", false, domain.BranchExistError
+	}
+
+	if err := r.updateBranch(sourceController, dir, remoteName, repo); err != nil {
+		return nil, "", false, err
+	}
+	return prRepo, remoteName, true, nil
+}
+
+// pushChanges pushes the changes, for review, with a deploy key, or directly to the remote of prRepo
+func (r *Runner) pushChanges(ctx context.Context, sourceController Git, remoteName string, repo, prRepo domain.Repository, newPR domain.NewPullRequest) error {
+	// Updating the branch may have taken a while since the remote was used last
+	if err := r.refreshRemoteURL(ctx, sourceController, remoteName, prRepo); err != nil {
+		return inStage(ErrorCategoryPushRejected, errors.Wrap(err, "could not refresh the url of the repository"))
+	}
+
+	pushed, err := r.pushChange(sourceController, remoteName, newPR)
+	if err != nil {
+		return inStage(ErrorCategoryPushRejected, errors.Wrap(err, "could not push changes for review"))
+	}
+	if !pushed && r.DeployKeys.isSet() {
+		pushed, err = r.pushWithDeployKey(sourceController, repo)
 		if err != nil {
-			return nil, errors.Wrap(err, "could not verify if branch already exist")
-		} else if featureBranchExist {
-			return nil, domain.BranchExistError
+			return inStage(ErrorCategoryPushRejected, errors.Wrap(err, "could not push changes with deploy key"))
 		}
 	}
+	if !pushed {
+		err = sourceController.Push(remoteName)
+		if err != nil {
+			return inStage(ErrorCategoryPushRejected, errors.Wrap(err, "could not push changes"))
+		}
+	}
+	return nil
+}
 
-	log.Info("Pushing changes to remote")
-	err = sourceController.Push(remoteName)
+// publishScriptAnnotations publishes the annotations the script wrote on the pushed branch
+func (r *Runner) publishScriptAnnotations(ctx context.Context, log log.FieldLogger, prRepo domain.Repository, baseBranch string, annotations []domain.Annotation) {
+	branch := r.FeatureBranch
+	if r.SkipPullRequest {
+		branch = baseBranch
+	}
+	log.Infof("Publishing %d annotations", len(annotations))
+	if err := r.publishAnnotations(ctx, prRepo, branch, annotations); err != nil {
+		// The changes are already pushed, so the pull request is created even without the annotations
+		log.Warnf("Could not publish the annotations: %s", err)
+	}
+}
+
+// createPullRequest creates the pull request, and waits for its checks if they should be verified
+func (r *Runner) createPullRequest(ctx context.Context, repo, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	pr, err := r.VersionController.CreatePullRequest(ctx, repo, prRepo, newPR)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not push changes")
+		return nil, inStage(ErrorCategoryPullRequestCreate, err)
 	}
 
-	if r.SkipPullRequest {
-		return nil, nil
+	if r.VerifyChecks {
+		if err := r.verifyChecks(ctx, repo, pr); err != nil {
+			return pr, err
+		}
 	}
 
-	log.Info("Creating pull request")
-	pr, err := r.VersionController.CreatePullRequest(ctx, repo, prRepo, domain.NewPullRequest{
-		Title:     r.PullRequestTitle,
-		Body:      r.PullRequestBody,
+	return pr, nil
+}
+
+// newPullRequest returns the pull request that should be created
+func (r *Runner) newPullRequest(title, body, baseBranch string, labels []string) domain.NewPullRequest {
+	return domain.NewPullRequest{
+		Title:     title,
+		Body:      r.pullRequestBody(body),
 		Head:      r.FeatureBranch,
 		Base:      baseBranch,
 		Reviewers: getReviewers(r.Reviewers, r.MaxReviewers),
-	})
+		Assignees: r.Assignees,
+		Labels:    labels,
+		Milestone: r.Milestone,
+		Draft:     r.Draft,
+	}
+}
+
+// pullRequestBody returns the body of a pull request, including the change request and the provenance footer
+func (r *Runner) pullRequestBody(body string) string {
+	if r.ChangeRequest != nil {
+		body += r.ChangeRequest.pullRequestText()
+	}
+	if r.Provenance == nil {
+		return body
+	}
+	return body + r.Provenance.footer()
+}
+
+// verifyChangeRequest verifies the change request, if one is required, before anything is changed
+func (r *Runner) verifyChangeRequest(ctx context.Context) error {
+	if r.ChangeRequest == nil {
+		return nil
+	}
+	if r.DryRun || r.Simulate {
+		log.Infof("Skipping verifying the change request %s since nothing is changed", r.ChangeRequest.ID)
+		return nil
+	}
+	return r.ChangeRequest.verify(ctx)
+}
+
+// checkEquivalent returns an error if an equivalent pull request does already exist
+func (r *Runner) checkEquivalent(ctx context.Context, sourceController Git, repo domain.Repository) error {
+	vc, ok := r.VersionController.(PullRequestDiffGetter)
+	if !ok {
+		return errors.New("the platform does not support finding equivalent pull requests")
+	}
+
+	diff, err := sourceController.LastCommitDiff()
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "could not get the diff of the changes")
 	}
 
-	return pr, nil
+	pr, err := findEquivalentPullRequest(ctx, vc, repo, r.FeatureBranch, diff)
+	if err != nil {
+		return errors.Wrap(err, "could not search for equivalent pull requests")
+	}
+	if pr != nil {
+		log.WithField("repo", repo.FullName()).Infof("Equivalent pull request found: %s", pr.String())
+		return domain.AlreadyDoneError
+	}
+
+	return nil
 }
 
 var interactiveInfo = `(V)iew changes. (A)ccept or (R)eject`
@@ -0,0 +1,77 @@
+package multigitter
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestRunScriptOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is run with sh")
+	}
+
+	runScript := func(createIssues bool) *scriptError {
+		r := &Runner{
+			ScriptPath:    "sh",
+			Arguments:     []string{"-c", "echo could not change; exit 1"},
+			KillContext:   context.Background(),
+			FailureIssues: FailureIssues{Enabled: createIssues},
+		}
+		err := r.runScript(log.StandardLogger(), t.TempDir(), testRepository{fullName: "owner/repo"}, "")
+
+		var scriptErr *scriptError
+		require.True(t, errors.As(err, &scriptErr))
+		return scriptErr
+	}
+
+	// The output is only kept when it is added to issues
+	assert.Equal(t, "could not change\n", runScript(true).output)
+	assert.Empty(t, runScript(false).output)
+}
+
+func TestFailureIssueBody_Censor(t *testing.T) {
+	r := &Runner{
+		FeatureBranch: "my-branch",
+		FailureIssues: FailureIssues{
+			Enabled: true,
+			Censor: func(s string) string {
+				return strings.ReplaceAll(s, "secret-token", "<TOKEN>")
+			},
+		},
+	}
+	runErr := &scriptError{
+		err:    errors.New("could not push to https://secret-token@example.com/owner/repo.git"),
+		output: "origin\thttps://secret-token@example.com/owner/repo.git (fetch)\n",
+	}
+
+	body := r.failureIssueBody(testRepository{fullName: "owner/repo"}, runErr)
+	assert.NotContains(t, body, "secret-token")
+	assert.Contains(t, body, "could not push to https://<TOKEN>@example.com/owner/repo.git")
+	assert.Contains(t, body, "origin\thttps://<TOKEN>@example.com/owner/repo.git (fetch)")
+}
+
+func TestGetReviewers(t *testing.T) {
+	reviewers := []domain.Reviewer{
+		{Type: domain.ReviewerTypeUser, Name: "user1"},
+		{Type: domain.ReviewerTypeUser, Name: "user2"},
+		domain.DefaultReviewers,
+		{Type: domain.ReviewerTypeTeam, Name: "team1"},
+	}
+
+	assert.Equal(t, reviewers, getReviewers(reviewers, 0))
+	assert.Equal(t, reviewers, getReviewers(reviewers, 3), "the default reviewers should not be counted")
+
+	selected := getReviewers(reviewers, 2)
+	require.Len(t, selected, 3)
+	assert.Equal(t, domain.DefaultReviewers, selected[2], "the default reviewers should always be kept")
+	assert.NotContains(t, selected[:2], domain.DefaultReviewers)
+}
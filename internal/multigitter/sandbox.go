@@ -0,0 +1,31 @@
+package multigitter
+
+import (
+	"os/exec"
+	"time"
+)
+
+// Sandbox contains restrictions that the script is run with.
+// Restrictions that can not be applied on the current system are skipped with a warning
+type Sandbox struct {
+	NoNetwork bool          // Disallow any network access
+	ReadOnly  bool          // Make the filesystem outside of the repository read-only
+	CPUTime   time.Duration // The maximum cpu time the script may use, no limit if zero
+	Memory    int64         // The maximum memory, in bytes, the script may use, no limit if zero
+}
+
+func (s Sandbox) isSet() bool {
+	return s.NoNetwork || s.ReadOnly || s.CPUTime > 0 || s.Memory > 0
+}
+
+// lookPath is used to find the sandboxing tools, it can be overwritten during tests
+var lookPath = exec.LookPath
+
+// cpuSeconds rounds the duration up to whole seconds, since cpu limits are defined in seconds
+func cpuSeconds(d time.Duration) int64 {
+	seconds := int64(d / time.Second)
+	if d%time.Second != 0 {
+		seconds++
+	}
+	return seconds
+}
@@ -0,0 +1,117 @@
+package multigitter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// unsupported returns the restrictions that can not be applied on this system
+func (s Sandbox) unsupported() []string {
+	if _, err := lookPath("firejail"); err == nil {
+		return nil
+	}
+
+	var ret []string
+	if s.NoNetwork && !userNamespacesSupported() {
+		ret = append(ret, "network restriction (requires firejail or unprivileged user namespaces)")
+	}
+	if s.ReadOnly {
+		ret = append(ret, "read-only filesystem (requires firejail)")
+	}
+	if s.CPUTime > 0 || s.Memory > 0 {
+		if _, err := lookPath("prlimit"); err != nil {
+			ret = append(ret, "cpu and memory limits (requires firejail or prlimit)")
+		}
+	}
+	return ret
+}
+
+// command creates a command that runs the script within the sandbox. The script may write to the writable paths,
+// even if the filesystem is read-only. firejail is used if it is installed, otherwise namespaces and prlimit are used when possible
+func (s Sandbox) command(writable []string, name string, args ...string) *exec.Cmd {
+	if !s.isSet() {
+		return exec.Command(name, args...)
+	}
+
+	if firejail, err := lookPath("firejail"); err == nil {
+		return exec.Command(firejail, append(s.firejailArgs(writable), append([]string{"--", name}, args...)...)...)
+	}
+
+	var cmd *exec.Cmd
+	if prlimit, err := lookPath("prlimit"); err == nil && (s.CPUTime > 0 || s.Memory > 0) {
+		cmd = exec.Command(prlimit, append(s.prlimitArgs(), append([]string{"--", name}, args...)...)...)
+	} else {
+		cmd = exec.Command(name, args...)
+	}
+
+	if s.NoNetwork && userNamespacesSupported() {
+		cmd.SysProcAttr = noNetworkAttr()
+	}
+
+	return cmd
+}
+
+// noNetworkAttr creates a new network namespace, without any interfaces, within an unprivileged user namespace
+func noNetworkAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1},
+		},
+	}
+}
+
+var userNamespacesOnce sync.Once
+var userNamespaces bool
+
+// userNamespacesSupported returns if unprivileged user namespaces can be created, which is disabled on some systems.
+// It is probed once, by running a command in a new namespace. It can be overwritten during tests
+var userNamespacesSupported = func() bool {
+	userNamespacesOnce.Do(func() {
+		truePath, err := lookPath("true")
+		if err != nil {
+			return
+		}
+		cmd := exec.Command(truePath)
+		cmd.SysProcAttr = noNetworkAttr()
+		userNamespaces = cmd.Run() == nil
+	})
+	return userNamespaces
+}
+
+func (s Sandbox) firejailArgs(writable []string) []string {
+	args := []string{"--quiet", "--noprofile"}
+	if s.NoNetwork {
+		args = append(args, "--net=none")
+	}
+	if s.ReadOnly {
+		args = append(args, "--read-only=/")
+		for _, path := range writable {
+			args = append(args, fmt.Sprintf("--read-write=%s", path))
+		}
+	}
+	if s.CPUTime > 0 {
+		args = append(args, fmt.Sprintf("--rlimit-cpu=%d", cpuSeconds(s.CPUTime)))
+	}
+	if s.Memory > 0 {
+		args = append(args, fmt.Sprintf("--rlimit-as=%d", s.Memory))
+	}
+	return args
+}
+
+func (s Sandbox) prlimitArgs() []string {
+	var args []string
+	if s.CPUTime > 0 {
+		args = append(args, fmt.Sprintf("--cpu=%d", cpuSeconds(s.CPUTime)))
+	}
+	if s.Memory > 0 {
+		args = append(args, fmt.Sprintf("--as=%d", s.Memory))
+	}
+	return args
+}
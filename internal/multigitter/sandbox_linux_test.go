@@ -0,0 +1,89 @@
+package multigitter
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox_command(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+
+	tests := []struct {
+		name      string
+		sandbox   Sandbox
+		available []string
+		want      []string
+	}{
+		{
+			name:      "no restrictions",
+			sandbox:   Sandbox{},
+			available: []string{"firejail", "prlimit"},
+			want:      []string{"/script", "arg"},
+		},
+		{
+			name: "firejail",
+			sandbox: Sandbox{
+				NoNetwork: true,
+				ReadOnly:  true,
+				CPUTime:   1500 * time.Millisecond,
+				Memory:    1024,
+			},
+			available: []string{"firejail", "prlimit"},
+			want: []string{
+				"/usr/bin/firejail", "--quiet", "--noprofile", "--net=none", "--read-only=/", "--read-write=/repo",
+				"--read-write=/repo-annotations", "--rlimit-cpu=2", "--rlimit-as=1024", "--", "/script", "arg",
+			},
+		},
+		{
+			name: "prlimit",
+			sandbox: Sandbox{
+				CPUTime: 10 * time.Second,
+				Memory:  1024,
+			},
+			available: []string{"prlimit"},
+			want:      []string{"/usr/bin/prlimit", "--cpu=10", "--as=1024", "--", "/script", "arg"},
+		},
+		{
+			name: "nothing available",
+			sandbox: Sandbox{
+				CPUTime: 10 * time.Second,
+			},
+			want: []string{"/script", "arg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				for _, a := range tt.available {
+					if a == file {
+						return "/usr/bin/" + file, nil
+					}
+				}
+				return "", exec.ErrNotFound
+			}
+
+			cmd := tt.sandbox.command([]string{"/repo", "/repo-annotations"}, "/script", "arg")
+			assert.Equal(t, tt.want, cmd.Args)
+		})
+	}
+}
+
+func TestSandbox_withoutUserNamespaces(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+	supported := userNamespacesSupported
+	defer func() { userNamespacesSupported = supported }()
+
+	lookPath = func(file string) (string, error) {
+		return "", exec.ErrNotFound
+	}
+	userNamespacesSupported = func() bool { return false }
+
+	sandbox := Sandbox{NoNetwork: true}
+	assert.Equal(t, []string{"network restriction (requires firejail or unprivileged user namespaces)"}, sandbox.unsupported())
+
+	cmd := sandbox.command([]string{"/repo"}, "/script")
+	assert.Nil(t, cmd.SysProcAttr, "no namespace should be created if it is not supported")
+}
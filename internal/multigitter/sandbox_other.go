@@ -0,0 +1,28 @@
+//go:build !linux
+// +build !linux
+
+package multigitter
+
+import (
+	"os/exec"
+)
+
+// unsupported returns the restrictions that can not be applied on this system
+func (s Sandbox) unsupported() []string {
+	var ret []string
+	if s.NoNetwork {
+		ret = append(ret, "network restriction")
+	}
+	if s.ReadOnly {
+		ret = append(ret, "read-only filesystem")
+	}
+	if s.CPUTime > 0 || s.Memory > 0 {
+		ret = append(ret, "cpu and memory limits")
+	}
+	return ret
+}
+
+// command creates a command that runs the script, sandboxing is not supported on this OS
+func (s Sandbox) command(writable []string, name string, args ...string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
@@ -0,0 +1,203 @@
+package multigitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// SecretsManager is a version controller that can set secrets and variables of repositories
+type SecretsManager interface {
+	// GetSecrets gets the names of all secrets and the names and values of all variables of a repository
+	GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error)
+	// SetSecret creates or updates a secret
+	SetSecret(ctx context.Context, repo domain.Repository, name, value string) error
+	// SetVariable creates or updates a variable
+	SetVariable(ctx context.Context, repo domain.Repository, name, value string) error
+}
+
+// SecretsSyncer creates and updates secrets and variables in repositories
+type SecretsSyncer struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output   io.Writer
+	AuditLog io.Writer // Every change is logged as a JSON line, without the value
+
+	Secrets    domain.Secrets
+	DryRun     bool // If set, the changes are only shown
+	Concurrent int
+
+	auditLock sync.Mutex
+}
+
+// SecretChange is a secret or variable that has to be created or updated
+type SecretChange struct {
+	Kind   string // "secret" or "variable"
+	Name   string
+	Action string // "create" or "update"
+}
+
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Repository string    `json:"repository"`
+	Kind       string    `json:"kind"`
+	Name       string    `json:"name"`
+	Action     string    `json:"action"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type secretsResult struct {
+	changes []SecretChange
+	err     error
+}
+
+// Sync shows which secrets and variables has to be changed in all repositories, and changes them
+func (s *SecretsSyncer) Sync(ctx context.Context) error {
+	manager, ok := s.VersionController.(SecretsManager)
+	if !ok {
+		return errors.New("the platform does not support secrets and variables")
+	}
+
+	repos, err := getRepositories(ctx, s.VersionController, s.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]secretsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = s.syncRepository(ctx, manager, repos[i])
+	}, len(repos), s.Concurrent)
+
+	failed := 0
+	for i, result := range results {
+		switch {
+		case result.err != nil && len(result.changes) == 0:
+			failed++
+			fmt.Fprintf(s.Output, "%s: %s\n", repos[i].FullName(), result.err)
+		case len(result.changes) == 0:
+			fmt.Fprintf(s.Output, "%s: up to date\n", repos[i].FullName())
+		default:
+			fmt.Fprintf(s.Output, "%s:\n", repos[i].FullName())
+			for _, change := range result.changes {
+				fmt.Fprintf(s.Output, "  %s %s %s\n", change.Action, change.Kind, change.Name)
+			}
+			if result.err != nil {
+				failed++
+				fmt.Fprintf(s.Output, "  %s\n", result.err)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("could not sync the secrets of %d repositories", failed)
+	}
+	return nil
+}
+
+func (s *SecretsSyncer) syncRepository(ctx context.Context, manager SecretsManager, repo domain.Repository) secretsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	log.Debug("Fetching the current secrets and variables")
+	current, err := manager.GetSecrets(ctx, repo)
+	if err != nil {
+		return secretsResult{err: errors.Wrap(err, "could not get the current secrets")}
+	}
+
+	changes := secretChanges(current, s.Secrets)
+	if len(changes) == 0 || s.DryRun {
+		return secretsResult{changes: changes}
+	}
+
+	for _, change := range changes {
+		log.WithField("kind", change.Kind).WithField("name", change.Name).Info("Setting value")
+
+		if change.Kind == "secret" {
+			err = manager.SetSecret(ctx, repo, change.Name, s.Secrets.Secrets[change.Name])
+		} else {
+			err = manager.SetVariable(ctx, repo, change.Name, s.Secrets.Variables[change.Name])
+		}
+		s.audit(repo, change, err)
+		if err != nil {
+			return secretsResult{
+				changes: changes,
+				err:     errors.Wrapf(err, "could not %s the %s %s", change.Action, change.Kind, change.Name),
+			}
+		}
+	}
+
+	return secretsResult{changes: changes}
+}
+
+func (s *SecretsSyncer) audit(repo domain.Repository, change SecretChange, err error) {
+	if s.AuditLog == nil {
+		return
+	}
+
+	entry := auditEntry{
+		Time:       time.Now().UTC(),
+		Repository: repo.FullName(),
+		Kind:       change.Kind,
+		Name:       change.Name,
+		Action:     change.Action,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	b, _ := json.Marshal(entry)
+
+	s.auditLock.Lock()
+	defer s.auditLock.Unlock()
+	if _, err := s.AuditLog.Write(append(b, '\n')); err != nil {
+		log.Errorf("Could not write to the audit log: %s", err)
+	}
+}
+
+// secretChanges returns the secrets and variables that has to be created or updated.
+// Since the values of secrets can't be read, existing secrets are always updated
+func secretChanges(current domain.RepositorySecrets, desired domain.Secrets) []SecretChange {
+	var changes []SecretChange
+
+	existingSecrets := map[string]bool{}
+	for _, name := range current.SecretNames {
+		existingSecrets[name] = true
+	}
+	for _, name := range sortedKeys(desired.Secrets) {
+		action := "create"
+		if existingSecrets[name] {
+			action = "update"
+		}
+		changes = append(changes, SecretChange{Kind: "secret", Name: name, Action: action})
+	}
+
+	for _, name := range sortedKeys(desired.Variables) {
+		value, exist := current.Variables[name]
+		switch {
+		case !exist:
+			changes = append(changes, SecretChange{Kind: "variable", Name: name, Action: "create"})
+		case value != desired.Variables[name]:
+			changes = append(changes, SecretChange{Kind: "variable", Name: name, Action: "update"})
+		}
+	}
+
+	return changes
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
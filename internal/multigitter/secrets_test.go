@@ -0,0 +1,39 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestSecretChanges(t *testing.T) {
+	current := domain.RepositorySecrets{
+		SecretNames: []string{"EXISTING_SECRET", "UNMANAGED_SECRET"},
+		Variables: map[string]string{
+			"CHANGED":   "old",
+			"UNCHANGED": "value",
+			"UNMANAGED": "value",
+		},
+	}
+
+	desired := domain.Secrets{
+		Secrets: map[string]string{
+			"NEW_SECRET":      "secret",
+			"EXISTING_SECRET": "secret",
+		},
+		Variables: map[string]string{
+			"CHANGED":   "new",
+			"UNCHANGED": "value",
+			"NEW":       "value",
+		},
+	}
+
+	assert.Equal(t, []SecretChange{
+		{Kind: "secret", Name: "EXISTING_SECRET", Action: "update"},
+		{Kind: "secret", Name: "NEW_SECRET", Action: "create"},
+		{Kind: "variable", Name: "CHANGED", Action: "update"},
+		{Kind: "variable", Name: "NEW", Action: "create"},
+	}, secretChanges(current, desired))
+}
@@ -0,0 +1,282 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// SettingsManager is a version controller that can read and change the settings of repositories
+type SettingsManager interface {
+	// GetRepositorySettings gets the current settings of a repository, including the protection of the branch.
+	// Settings that are not supported by the platform are not set
+	GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error)
+	// UpdateRepositorySettings changes all settings that are set
+	UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error
+}
+
+// SettingsUpdater updates the settings of repositories to match the desired settings
+type SettingsUpdater struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Settings   domain.RepositorySettings
+	DryRun     bool // If set, the changes are only shown
+	Concurrent int
+}
+
+// SettingChange is the difference between the current and the desired value of a setting
+type SettingChange struct {
+	Name    string
+	Current string
+	Desired string
+}
+
+type settingsResult struct {
+	changes    []SettingChange
+	skipReason string // If set, the repository was skipped
+	err        error
+}
+
+// Update shows the difference between the current and desired settings of all repositories, and updates them
+func (s SettingsUpdater) Update(ctx context.Context) error {
+	manager, ok := s.VersionController.(SettingsManager)
+	if !ok {
+		return errors.New("the platform does not support changing repository settings")
+	}
+
+	repos, err := getRepositories(ctx, s.VersionController, s.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = s.updateRepository(ctx, manager, repos[i])
+	}, len(repos), s.Concurrent)
+
+	failed := printSettingsResults(s.Output, repos, results)
+	if failed > 0 {
+		return errors.Errorf("could not update the settings of %d repositories", failed)
+	}
+	return nil
+}
+
+// printSettingsResults prints the changes of each repository, and returns the number of failed repositories
+func printSettingsResults(output io.Writer, repos []domain.Repository, results []settingsResult) int {
+	failed := 0
+	for i, result := range results {
+		switch {
+		case result.err != nil:
+			failed++
+			fmt.Fprintf(output, "%s: %s\n", repos[i].FullName(), result.err)
+		case result.skipReason != "":
+			fmt.Fprintf(output, "%s: skipped, %s\n", repos[i].FullName(), result.skipReason)
+		case len(result.changes) == 0:
+			fmt.Fprintf(output, "%s: up to date\n", repos[i].FullName())
+		default:
+			fmt.Fprintf(output, "%s:\n", repos[i].FullName())
+			for _, change := range result.changes {
+				fmt.Fprintf(output, "  %s: %s -> %s\n", change.Name, change.Current, change.Desired)
+			}
+		}
+	}
+	return failed
+}
+
+// printSettingsSummary prints how many repositories that were changed, up to date, skipped and failed
+func printSettingsSummary(output io.Writer, results []settingsResult, dryRun bool) {
+	var changed, upToDate, skipped, failed int
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			failed++
+		case result.skipReason != "":
+			skipped++
+		case len(result.changes) == 0:
+			upToDate++
+		default:
+			changed++
+		}
+	}
+
+	verb := "changed"
+	if dryRun {
+		verb = "would be changed"
+	}
+	fmt.Fprintf(output, "\n%d %s, %d up to date, ", changed, verb, upToDate)
+	if skipped > 0 {
+		fmt.Fprintf(output, "%d skipped, ", skipped)
+	}
+	fmt.Fprintf(output, "%d failed\n", failed)
+}
+
+func (s SettingsUpdater) updateRepository(ctx context.Context, manager SettingsManager, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	desired := s.Settings
+	if desired.BranchProtection != nil && desired.BranchProtection.Branch == "" {
+		protection := *desired.BranchProtection
+		protection.Branch = repo.DefaultBranch()
+		desired.BranchProtection = &protection
+	}
+
+	protectedBranch := ""
+	if desired.BranchProtection != nil {
+		protectedBranch = desired.BranchProtection.Branch
+	}
+
+	log.Debug("Fetching the current settings")
+	current, err := manager.GetRepositorySettings(ctx, repo, protectedBranch)
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the current settings")}
+	}
+
+	changes, update := settingsChanges(current, desired)
+	if len(changes) == 0 {
+		return settingsResult{}
+	}
+
+	if s.DryRun {
+		log.Info("Skipping updating the settings because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	log.Info("Updating the settings")
+	if err := manager.UpdateRepositorySettings(ctx, repo, update); err != nil {
+		return settingsResult{changes: changes, err: errors.Wrap(err, "could not update the settings")}
+	}
+
+	return settingsResult{changes: changes}
+}
+
+// settingsChanges returns all changes needed to go from the current to the desired settings,
+// and the settings that has to be updated to do so
+func settingsChanges(current, desired domain.RepositorySettings) ([]SettingChange, domain.RepositorySettings) {
+	var changes []SettingChange
+	var update domain.RepositorySettings
+
+	if desired.Topics != nil && !sameStrings(current.Topics, desired.Topics) {
+		changes = append(changes, SettingChange{
+			Name:    "topics",
+			Current: formatStrings(current.Topics),
+			Desired: formatStrings(desired.Topics),
+		})
+		update.Topics = desired.Topics
+	}
+
+	bools := []struct {
+		name             string
+		current, desired *bool
+		update           **bool
+	}{
+		{"allow_merge_commit", current.AllowMergeCommit, desired.AllowMergeCommit, &update.AllowMergeCommit},
+		{"allow_squash_merge", current.AllowSquashMerge, desired.AllowSquashMerge, &update.AllowSquashMerge},
+		{"allow_rebase_merge", current.AllowRebaseMerge, desired.AllowRebaseMerge, &update.AllowRebaseMerge},
+		{"delete_branch_on_merge", current.DeleteBranchOnMerge, desired.DeleteBranchOnMerge, &update.DeleteBranchOnMerge},
+	}
+	for _, b := range bools {
+		if b.desired == nil || (b.current != nil && *b.current == *b.desired) {
+			continue
+		}
+		changes = append(changes, SettingChange{
+			Name:    b.name,
+			Current: formatBool(b.current),
+			Desired: formatBool(b.desired),
+		})
+		*b.update = b.desired
+	}
+
+	if desired.BranchProtection != nil && !sameBranchProtection(current.BranchProtection, desired.BranchProtection) {
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("branch_protection %s", desired.BranchProtection.Branch),
+			Current: formatBranchProtection(current.BranchProtection),
+			Desired: formatBranchProtection(desired.BranchProtection),
+		})
+		update.BranchProtection = desired.BranchProtection
+	}
+
+	for _, webhook := range desired.Webhooks {
+		currentWebhook := findWebhook(current.Webhooks, webhook.URL)
+		if currentWebhook != nil && sameWebhook(*currentWebhook, webhook) {
+			continue
+		}
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("webhook %s", webhook.URL),
+			Current: formatWebhook(currentWebhook),
+			Desired: formatWebhook(&webhook),
+		})
+		update.Webhooks = append(update.Webhooks, webhook)
+	}
+
+	return changes, update
+}
+
+func findWebhook(webhooks []domain.Webhook, url string) *domain.Webhook {
+	for i := range webhooks {
+		if webhooks[i].URL == url {
+			return &webhooks[i]
+		}
+	}
+	return nil
+}
+
+func sameStrings(a, b []string) bool {
+	return formatStrings(a) == formatStrings(b)
+}
+
+func sameBranchProtection(current, desired *domain.BranchProtection) bool {
+	return formatBranchProtection(current) == formatBranchProtection(desired)
+}
+
+func sameWebhook(current, desired domain.Webhook) bool {
+	return formatWebhook(&current) == formatWebhook(&desired)
+}
+
+func formatStrings(strs []string) string {
+	if len(strs) == 0 {
+		return "none"
+	}
+	sorted := append([]string(nil), strs...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+func formatBool(b *bool) string {
+	if b == nil {
+		return "unknown"
+	}
+	return strconv.FormatBool(*b)
+}
+
+func formatBranchProtection(protection *domain.BranchProtection) string {
+	if protection == nil {
+		return "none"
+	}
+	return fmt.Sprintf("required approving reviews: %d, required status checks: %s, enforce admins: %t",
+		protection.RequiredApprovingReviews,
+		formatStrings(protection.RequiredStatusChecks),
+		protection.EnforceAdmins,
+	)
+}
+
+func formatWebhook(webhook *domain.Webhook) string {
+	if webhook == nil {
+		return "none"
+	}
+	contentType := webhook.ContentType
+	if contentType == "" {
+		contentType = "json"
+	}
+	return fmt.Sprintf("events: %s, content type: %s", formatStrings(webhook.Events), contentType)
+}
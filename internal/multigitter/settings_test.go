@@ -0,0 +1,87 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestSettingsChanges(t *testing.T) {
+	current := domain.RepositorySettings{
+		Topics:           []string{"go", "cli"},
+		AllowMergeCommit: boolPtr(true),
+		AllowSquashMerge: boolPtr(true),
+		BranchProtection: &domain.BranchProtection{
+			Branch:                   "main",
+			RequiredApprovingReviews: 1,
+			RequiredStatusChecks:     []string{"test", "build"},
+		},
+		Webhooks: []domain.Webhook{
+			{URL: "https://ci.example.com", Events: []string{"push"}, ContentType: "json"},
+			{URL: "https://chat.example.com", Events: []string{"push"}, ContentType: "json"},
+		},
+	}
+
+	desired := domain.RepositorySettings{
+		Topics:              []string{"cli", "go"},
+		AllowMergeCommit:    boolPtr(false),
+		AllowSquashMerge:    boolPtr(true),
+		DeleteBranchOnMerge: boolPtr(true),
+		BranchProtection: &domain.BranchProtection{
+			Branch:                   "main",
+			RequiredApprovingReviews: 1,
+			RequiredStatusChecks:     []string{"build", "test"},
+		},
+		Webhooks: []domain.Webhook{
+			{URL: "https://ci.example.com", Events: []string{"push", "pull_request"}, Secret: "secret"},
+			{URL: "https://chat.example.com", Events: []string{"push"}, Secret: "secret"},
+			{URL: "https://new.example.com", Events: []string{"push"}},
+		},
+	}
+
+	changes, update := settingsChanges(current, desired)
+
+	assert.Equal(t, []SettingChange{
+		{Name: "allow_merge_commit", Current: "true", Desired: "false"},
+		{Name: "delete_branch_on_merge", Current: "unknown", Desired: "true"},
+		{Name: "webhook https://ci.example.com", Current: "events: push, content type: json", Desired: "events: pull_request, push, content type: json"},
+		{Name: "webhook https://new.example.com", Current: "none", Desired: "events: push, content type: json"},
+	}, changes)
+
+	assert.Equal(t, domain.RepositorySettings{
+		AllowMergeCommit:    boolPtr(false),
+		DeleteBranchOnMerge: boolPtr(true),
+		Webhooks: []domain.Webhook{
+			desired.Webhooks[0],
+			desired.Webhooks[2],
+		},
+	}, update)
+}
+
+func TestSettingsChanges_BranchProtection(t *testing.T) {
+	desired := domain.RepositorySettings{
+		BranchProtection: &domain.BranchProtection{
+			Branch:        "main",
+			EnforceAdmins: true,
+		},
+	}
+
+	changes, update := settingsChanges(domain.RepositorySettings{}, desired)
+	assert.Equal(t, []SettingChange{
+		{
+			Name:    "branch_protection main",
+			Current: "none",
+			Desired: "required approving reviews: 0, required status checks: none, enforce admins: true",
+		},
+	}, changes)
+	assert.Equal(t, desired, update)
+
+	changes, _ = settingsChanges(desired, desired)
+	assert.Empty(t, changes)
+}
@@ -2,9 +2,11 @@ package multigitter
 
 import (
 	"fmt"
+	"os"
 	"syscall"
 
 	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter/picker"
 	"github.com/pkg/errors"
 )
 
@@ -28,6 +30,8 @@ type Git interface {
 	ChangeBranch(branchName string) error
 	Changes() (bool, error)
 	Commit(commitAuthor *domain.CommitAuthor, commitMessage string) error
+	ChangedFiles() ([]string, error) // The files changed in the last commit
+	LastCommitDiff() (string, error)
 	BranchExist(remoteName, branchName string) (bool, error)
 	Push(remoteName string) error
 	AddRemote(name, url string) error
@@ -47,3 +51,51 @@ func getStackTrace(err error) string {
 	}
 	return ""
 }
+
+// pickRepositories lets the user interactively select which of the repositories that should be used.
+// The picker is rendered to stderr, to not mix it with the output of the command
+func pickRepositories(repos []domain.Repository) ([]domain.Repository, error) {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.FullName()
+	}
+
+	indexes, err := picker.Pick(os.Stderr, names)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := make([]domain.Repository, len(indexes))
+	for i, index := range indexes {
+		picked[i] = repos[index]
+	}
+	return picked, nil
+}
+
+// checkWritable returns a domain.SkipError if the repository is archived or disabled, and can't be changed
+func checkWritable(repo domain.Repository) error {
+	archivable, ok := repo.(domain.Archivable)
+	switch {
+	case !ok:
+		return nil
+	case archivable.Archived():
+		return domain.SkipError{Reason: "the repository is archived"}
+	case archivable.Disabled():
+		return domain.SkipError{Reason: "the repository is disabled"}
+	}
+	return nil
+}
+
+// isSkip checks if a repository was skipped for an expected reason, such as a pull request already existing
+func isSkip(err error) bool {
+	switch err {
+	case domain.BranchExistError, domain.AlreadyDoneError:
+		return true
+	}
+	if _, ok := err.(*dependencyError); ok {
+		return true
+	}
+
+	var skipErr domain.SkipError
+	return errors.As(err, &skipErr)
+}
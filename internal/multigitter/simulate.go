@@ -0,0 +1,83 @@
+package multigitter
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// simulatedPullRequest is a pull request that would have been created if the run was not simulated
+type simulatedPullRequest struct {
+	repository domain.Repository
+	newPR      domain.NewPullRequest
+}
+
+func (pr simulatedPullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusUnknown
+}
+
+func (pr simulatedPullRequest) String() string {
+	return fmt.Sprintf("%s (simulated)", pr.repository.FullName())
+}
+
+func (pr simulatedPullRequest) RepositoryName() string {
+	return pr.repository.FullName()
+}
+
+// PullRequestPreview is the content of a pull request that would be created by a run
+type PullRequestPreview struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Head      string   `json:"head"`
+	Base      string   `json:"base"`
+	Reviewers []string `json:"reviewers,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Milestone string   `json:"milestone,omitempty"`
+	Draft     bool     `json:"draft,omitempty"`
+}
+
+func newPullRequestPreview(newPR domain.NewPullRequest) *PullRequestPreview {
+	preview := &PullRequestPreview{
+		Title:     newPR.Title,
+		Body:      newPR.Body,
+		Head:      newPR.Head,
+		Base:      newPR.Base,
+		Assignees: newPR.Assignees,
+		Labels:    newPR.Labels,
+		Milestone: newPR.Milestone,
+		Draft:     newPR.Draft,
+	}
+	for _, reviewer := range newPR.Reviewers {
+		preview.Reviewers = append(preview.Reviewers, reviewer.String())
+	}
+	return preview
+}
+
+// simulatePullRequest returns what would have been the result of pushing and creating the pull request
+func (r *Runner) simulatePullRequest(repo domain.Repository, updated bool, newPR domain.NewPullRequest) domain.PullRequest {
+	logger := log.WithField("repo", repo.FullName())
+
+	if r.SkipPullRequest {
+		logger.Infof("Would push the changes directly to %s", newPR.Base)
+		return nil
+	}
+
+	if updated {
+		logger.Infof("Would push the updated branch %s", newPR.Head)
+		return nil
+	}
+
+	logger.WithFields(log.Fields{
+		"title": newPR.Title,
+		"head":  newPR.Head,
+		"base":  newPR.Base,
+	}).Info("Would push the changes and create a pull request")
+
+	return simulatedPullRequest{
+		repository: repo,
+		newPR:      newPR,
+	}
+}
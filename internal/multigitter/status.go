@@ -11,15 +11,18 @@ import (
 // Statuser checks the statuses of pull requests
 type Statuser struct {
 	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
 
 	Output io.Writer
 
 	FeatureBranch string
+	CampaignID    string  // If set, pull requests are found by their campaign instead of the branch name
+	Report        *Report // If set, the pull requests in the report of a run are used instead of searching for them
 }
 
 // Statuses checks the statuses of pull requests
 func (s Statuser) Statuses(ctx context.Context) error {
-	prs, err := s.VersionController.GetPullRequests(ctx, s.FeatureBranch)
+	prs, err := getPullRequests(ctx, s.VersionController, s.FeatureBranch, s.CampaignID, s.Report, s.Filter)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,223 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// SuggestionCreator is a version controller that can propose changes as suggestions in review comments of a pull request
+type SuggestionCreator interface {
+	CreateSuggestions(ctx context.Context, pr domain.PullRequest, suggestions []domain.Suggestion) error
+}
+
+// openPullRequests are the open pull requests of a branch, by the name of the repository, that are fetched once for all repositories
+type openPullRequests struct {
+	once sync.Once
+	prs  map[string]domain.PullRequest
+	err  error
+}
+
+func (o *openPullRequests) get(ctx context.Context, vc VersionController, branchName string, repo domain.Repository) (domain.PullRequest, error) {
+	o.once.Do(func() {
+		prs, err := vc.GetPullRequests(ctx, branchName)
+		if err != nil {
+			o.err = errors.Wrap(err, "could not get the pull requests to suggest the changes on")
+			return
+		}
+
+		o.prs = map[string]domain.PullRequest{}
+		for _, pr := range prs {
+			if pr.Status() == domain.PullRequestStatusClosed || pr.Status() == domain.PullRequestStatusMerged {
+				continue
+			}
+			o.prs[pr.RepositoryName()] = pr
+		}
+	})
+	return o.prs[repo.FullName()], o.err
+}
+
+// suggestChanges runs the script on the branch of the open pull request of a repository, and suggests the changes
+// in review comments on the pull request, instead of pushing them
+func (r *Runner) suggestChanges(ctx context.Context, repo domain.Repository) (domain.PullRequest, error) {
+	vc, ok := r.VersionController.(SuggestionCreator)
+	if !ok {
+		return nil, errors.New("the platform does not support suggesting changes")
+	}
+
+	pr, err := r.suggestionPullRequests.get(ctx, r.VersionController, r.FeatureBranch, repo)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, domain.SkipError{Reason: "there is no open pull request to suggest the changes on"}
+	}
+
+	log := log.WithField("repo", repo.FullName())
+	log.Info("Cloning and running script")
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-changer-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	sourceController := r.CreateGit(tmpDir)
+	cloneURL, err := repositoryURL(ctx, repo, r.Token)
+	if err != nil {
+		return nil, inStage(ErrorCategoryClone, err)
+	}
+	if err := sourceController.Clone(cloneURL, r.FeatureBranch); err != nil {
+		return nil, inStage(ErrorCategoryClone, err)
+	}
+
+	if r.Patch != "" {
+		err = applyPatch(tmpDir, r.Patch)
+	} else {
+		err = r.runScript(log, tmpDir, repo, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if changed, err := sourceController.Changes(); err != nil {
+		return nil, err
+	} else if !changed {
+		return nil, domain.NoChangeError
+	}
+
+	// The changes are only committed locally, to get the diff of them
+	if err := sourceController.Commit(r.CommitAuthor, r.CommitMessage); err != nil {
+		return nil, err
+	}
+	diff, err := sourceController.LastCommitDiff()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the diff of the changes")
+	}
+	if r.report != nil {
+		r.report.setDiff(repo, diff)
+	}
+
+	suggestions, unsupported := diffSuggestions(diff)
+	for _, change := range unsupported {
+		log.Warnf("The change of %s can not be suggested", change)
+	}
+	if len(suggestions) == 0 {
+		return nil, errors.New("none of the changes can be suggested, since they only add files or add lines to empty files")
+	}
+
+	if r.DryRun {
+		log.Infof("Skipping suggesting %d changes because of dry run", len(suggestions))
+		return pr, nil
+	}
+
+	log.Infof("Suggesting %d changes on %s", len(suggestions), pr.String())
+	if err := vc.CreateSuggestions(ctx, pr, suggestions); err != nil {
+		return nil, errors.Wrap(err, "could not suggest the changes")
+	}
+	return pr, nil
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// diffSuggestions converts each block of changed lines in a unified diff to a suggestion that replaces the lines
+// of the old file. Lines that are only added are suggested together with the line before, or after, them.
+// Changes that can not be suggested, such as new files, are returned as descriptions
+func diffSuggestions(diff string) (suggestions []domain.Suggestion, unsupported []string) {
+	path := ""
+	oldLine := 0
+	inHunk := false
+
+	// The last unchanged line, used to anchor lines that are only added
+	contextLine := 0
+	contextText := ""
+
+	var block *domain.Suggestion
+	flush := func(nextText string, hasNext bool) {
+		if block == nil {
+			return
+		}
+		switch {
+		case block.EndLine >= block.StartLine:
+			suggestions = append(suggestions, *block)
+		case contextLine == block.StartLine-1 && contextLine > 0:
+			block.StartLine, block.EndLine = contextLine, contextLine
+			block.Replacement = append([]string{contextText}, block.Replacement...)
+			suggestions = append(suggestions, *block)
+		case hasNext:
+			block.EndLine = block.StartLine
+			block.Replacement = append(block.Replacement, nextText)
+			suggestions = append(suggestions, *block)
+		default:
+			unsupported = append(unsupported, fmt.Sprintf("line %d of %s", block.StartLine, block.Path))
+		}
+		block = nil
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			flush("", false)
+			inHunk = false
+		case !inHunk && strings.HasPrefix(line, "--- "):
+			path = diffPath(line[4:], "a/")
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			if path == "" {
+				unsupported = append(unsupported, "the new file "+diffPath(line[4:], "b/"))
+			}
+		case strings.HasPrefix(line, "@@"):
+			flush("", false)
+			match := hunkHeaderRegex.FindStringSubmatch(line)
+			if match == nil || path == "" {
+				inHunk = false
+				continue
+			}
+			inHunk = true
+			oldLine, _ = strconv.Atoi(match[1])
+			// A hunk without any old lines starts after the line in the header
+			if match[2] == "0" {
+				oldLine++
+			}
+			contextLine = 0
+		case !inHunk:
+			continue
+		case strings.HasPrefix(line, " "):
+			flush(line[1:], true)
+			contextLine, contextText = oldLine, line[1:]
+			oldLine++
+		case strings.HasPrefix(line, "-"):
+			if block == nil {
+				block = &domain.Suggestion{Path: path, StartLine: oldLine, EndLine: oldLine - 1}
+			}
+			block.EndLine = oldLine
+			oldLine++
+		case strings.HasPrefix(line, "+"):
+			if block == nil {
+				block = &domain.Suggestion{Path: path, StartLine: oldLine, EndLine: oldLine - 1}
+			}
+			block.Replacement = append(block.Replacement, line[1:])
+		}
+	}
+	flush("", false)
+
+	return suggestions, unsupported
+}
+
+// diffPath returns the path of a file in the "---" or "+++" line of a diff, or an empty string if the file does not exist
+func diffPath(str, prefix string) string {
+	str = strings.SplitN(str, "\t", 2)[0]
+	if str == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(str, prefix)
+}
@@ -0,0 +1,60 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestDiffSuggestions(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,7 +1,8 @@
+ package main
+ 
+-import "io/ioutil"
++import "os"
+ 
+ func main() {
++	// Read the file
+ 	ioutil.ReadFile("a")
+ }
+@@ -20,3 +21,2 @@ func other() {
+ 	a := 1
+-	b := 2
+-	c := 3
++	b, c := 2, 3
+diff --git a/empty.txt b/empty.txt
+--- a/empty.txt
++++ b/empty.txt
+@@ -0,0 +1 @@
++first line
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++new
+diff --git a/top.txt b/top.txt
+--- a/top.txt
++++ b/top.txt
+@@ -1,2 +1,3 @@
++added first
+ original
+ second
+\ No newline at end of file
+`
+
+	suggestions, unsupported := diffSuggestions(diff)
+	assert.Equal(t, []domain.Suggestion{
+		{Path: "main.go", StartLine: 3, EndLine: 3, Replacement: []string{`import "os"`}},
+		{Path: "main.go", StartLine: 5, EndLine: 5, Replacement: []string{"func main() {", "	// Read the file"}},
+		{Path: "main.go", StartLine: 21, EndLine: 22, Replacement: []string{"	b, c := 2, 3"}},
+		{Path: "top.txt", StartLine: 1, EndLine: 1, Replacement: []string{"added first", "original"}},
+	}, suggestions)
+	assert.Equal(t, []string{"line 1 of empty.txt", "the new file new.txt"}, unsupported)
+}
@@ -0,0 +1,165 @@
+package multigitter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// summaryRow is the outcome of a single repository, in the summary written when a command is done
+type summaryRow struct {
+	repository string
+	outcome    string
+	category   string // The category of the error, if the outcome is an error
+	detail     string // The reason of the outcome, or the pull request
+	duration   time.Duration
+}
+
+// summaryCollector collects the outcomes of repositories that are handled concurrently
+type summaryCollector struct {
+	lock sync.Mutex
+	rows []summaryRow
+}
+
+func (sc *summaryCollector) add(row summaryRow) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.rows = append(sc.rows, row)
+}
+
+func (sc *summaryCollector) write(w io.Writer) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	writeSummary(w, sc.rows)
+}
+
+// reportSummaryRows returns the summary rows of the repositories in a report
+func reportSummaryRows(report Report) []summaryRow {
+	rows := make([]summaryRow, len(report.Repositories))
+	for i, repo := range report.Repositories {
+		row := summaryRow{
+			repository: repo.Repository,
+			outcome:    repo.Status,
+			category:   repo.ErrorCategory,
+			detail:     repo.Error + repo.Reason, // Only one of them is set
+		}
+		switch {
+		case repo.PullRequestURL != "":
+			row.detail = repo.PullRequestURL
+		case repo.PullRequest != "":
+			row.detail = repo.PullRequest
+		}
+		if repo.Resources != nil {
+			row.duration = time.Duration(repo.Resources.WallTime * float64(time.Second))
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// pullRequestSummaryRow returns the summary row of a pull request, that was handled since the start time.
+// If no reason is given, the pull request is used as the details
+func pullRequestSummaryRow(pr domain.PullRequest, outcome, reason string, start time.Time) summaryRow {
+	repoName := pr.RepositoryName()
+
+	detail := reason
+	if detail == "" {
+		detail = pr.String()
+		if urler, ok := pr.(urler); ok {
+			detail = urler.URL()
+		}
+	}
+
+	return summaryRow{
+		repository: repoName,
+		outcome:    outcome,
+		detail:     detail,
+		duration:   time.Since(start),
+	}
+}
+
+// pullRequestErrorSummaryRow returns the summary row of a pull request that could not be handled
+func pullRequestErrorSummaryRow(pr domain.PullRequest, err error, start time.Time) summaryRow {
+	row := pullRequestSummaryRow(pr, ReportStatusError, err.Error(), start)
+	row.category = errorCategory(err)
+	return row
+}
+
+// writeSummary writes an aligned table with one line per repository, sorted by name,
+// followed by the number of errors in each category
+func writeSummary(w io.Writer, rows []summaryRow) {
+	if len(rows) == 0 {
+		return
+	}
+
+	sorted := make([]summaryRow, len(rows))
+	copy(sorted, rows)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].repository < sorted[j].repository
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tOUTCOME\tDETAILS\tDURATION")
+	for _, row := range sorted {
+		detail := row.detail
+		// Only the first line is used to keep the table compact, the full reason is logged
+		if i := strings.IndexByte(detail, '\n'); i >= 0 {
+			detail = detail[:i]
+		}
+		if detail == "" {
+			detail = "-"
+		}
+		duration := "-"
+		if rounded := row.duration.Round(time.Millisecond); rounded > 0 {
+			duration = rounded.String()
+		}
+		outcome := row.outcome
+		if row.category != "" {
+			outcome = fmt.Sprintf("%s (%s)", outcome, row.category)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", row.repository, outcome, detail, duration)
+	}
+	_ = tw.Flush()
+
+	writeErrorCategories(w, sorted)
+}
+
+// writeErrorCategories writes the number of errors in each category, with the most common first
+func writeErrorCategories(w io.Writer, rows []summaryRow) {
+	counts := map[string]int{}
+	var categories []string
+	for _, row := range rows {
+		if row.category == "" {
+			continue
+		}
+		if counts[row.category] == 0 {
+			categories = append(categories, row.category)
+		}
+		counts[row.category]++
+	}
+	if len(categories) == 0 {
+		return
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if counts[categories[i]] != counts[categories[j]] {
+			return counts[categories[i]] > counts[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+
+	fmt.Fprintln(w, "Errors by category:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, category := range categories {
+		fmt.Fprintf(tw, "  %s\t%d\n", category, counts[category])
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,102 @@
+package multigitter
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// TopicEditor adds and removes topics of repositories, while keeping all other topics
+type TopicEditor struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Add        []string // Topics that are added to all repositories
+	Remove     []string // Topics that are removed from all repositories
+	DryRun     bool     // If set, the changes are only shown
+	Concurrent int
+}
+
+// Edit shows the topics that are added and removed from all repositories, and changes them
+func (e TopicEditor) Edit(ctx context.Context) error {
+	manager, ok := e.VersionController.(SettingsManager)
+	if !ok {
+		return errors.New("the platform does not support changing topics")
+	}
+
+	repos, err := getRepositories(ctx, e.VersionController, e.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = e.editRepository(ctx, manager, repos[i])
+	}, len(repos), e.Concurrent)
+
+	failed := printSettingsResults(e.Output, repos, results)
+	printSettingsSummary(e.Output, results, e.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not change the topics of %d repositories", failed)
+	}
+	return nil
+}
+
+func (e TopicEditor) editRepository(ctx context.Context, manager SettingsManager, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	log.Debug("Fetching the current topics")
+	current, err := manager.GetRepositorySettings(ctx, repo, "")
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the current topics")}
+	}
+
+	topics := editTopics(current.Topics, e.Add, e.Remove)
+	if sameStrings(current.Topics, topics) {
+		return settingsResult{}
+	}
+	changes := []SettingChange{{
+		Name:    "topics",
+		Current: formatStrings(current.Topics),
+		Desired: formatStrings(topics),
+	}}
+
+	if e.DryRun {
+		log.Info("Skipping changing the topics because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	log.Info("Changing the topics")
+	if err := manager.UpdateRepositorySettings(ctx, repo, domain.RepositorySettings{Topics: topics}); err != nil {
+		return settingsResult{changes: changes, err: errors.Wrap(err, "could not change the topics")}
+	}
+
+	return settingsResult{changes: changes}
+}
+
+// editTopics returns the topics with the added topics that does not already exist, and without the removed topics.
+// The result is never nil, since nil topics are not changed
+func editTopics(current, add, remove []string) []string {
+	removed := map[string]bool{}
+	for _, topic := range remove {
+		removed[topic] = true
+	}
+
+	topics := []string{}
+	exists := map[string]bool{}
+	for _, topic := range append(append([]string{}, current...), add...) {
+		if removed[topic] || exists[topic] {
+			continue
+		}
+		exists[topic] = true
+		topics = append(topics, topic)
+	}
+	return topics
+}
@@ -0,0 +1,13 @@
+package multigitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditTopics(t *testing.T) {
+	assert.Equal(t, []string{"go", "cli", "needs-go-1.22"}, editTopics([]string{"go", "deprecated", "cli"}, []string{"needs-go-1.22", "go"}, []string{"deprecated"}))
+	assert.Equal(t, []string{"go"}, editTopics(nil, []string{"go", "go"}, nil))
+	assert.Equal(t, []string{}, editTopics([]string{"go"}, nil, []string{"go"}))
+}
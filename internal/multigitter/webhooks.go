@@ -0,0 +1,111 @@
+package multigitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// WebhookRemover is a version controller that can remove webhooks from repositories
+type WebhookRemover interface {
+	RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error
+}
+
+// WebhookUpdater adds, updates and removes webhooks in repositories
+type WebhookUpdater struct {
+	VersionController VersionController
+	Filter            RepositoryFilter // Only the repositories that pass the filter are used
+
+	Output io.Writer
+
+	Webhooks   []domain.Webhook // Webhooks that are added, or updated if a webhook with the same url exist
+	RemoveURLs []string         // The urls of webhooks that are removed
+	DryRun     bool             // If set, the changes are only shown
+	Concurrent int
+}
+
+// Update shows the difference between the current and desired webhooks of all repositories, and updates them
+func (w WebhookUpdater) Update(ctx context.Context) error {
+	manager, ok := w.VersionController.(SettingsManager)
+	if !ok {
+		return errors.New("the platform does not support changing webhooks")
+	}
+	remover, ok := w.VersionController.(WebhookRemover)
+	if !ok && len(w.RemoveURLs) > 0 {
+		return errors.New("the platform does not support removing webhooks")
+	}
+
+	repos, err := getRepositories(ctx, w.VersionController, w.Filter)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch repositories")
+	}
+
+	results := make([]settingsResult, len(repos))
+	runInParallel(func(i int) {
+		results[i] = w.updateRepository(ctx, manager, remover, repos[i])
+	}, len(repos), w.Concurrent)
+
+	failed := printSettingsResults(w.Output, repos, results)
+	printSettingsSummary(w.Output, results, w.DryRun)
+
+	if failed > 0 {
+		return errors.Errorf("could not update the webhooks of %d repositories", failed)
+	}
+	return nil
+}
+
+func (w WebhookUpdater) updateRepository(ctx context.Context, manager SettingsManager, remover WebhookRemover, repo domain.Repository) settingsResult {
+	log := log.WithField("repo", repo.FullName())
+
+	log.Debug("Fetching the current webhooks")
+	current, err := manager.GetRepositorySettings(ctx, repo, "")
+	if err != nil {
+		return settingsResult{err: errors.Wrap(err, "could not get the current webhooks")}
+	}
+
+	changes, update := settingsChanges(current, domain.RepositorySettings{Webhooks: w.Webhooks})
+
+	var remove []string
+	for _, url := range w.RemoveURLs {
+		currentWebhook := findWebhook(current.Webhooks, url)
+		if currentWebhook == nil {
+			continue
+		}
+		changes = append(changes, SettingChange{
+			Name:    fmt.Sprintf("webhook %s", url),
+			Current: formatWebhook(currentWebhook),
+			Desired: "none",
+		})
+		remove = append(remove, url)
+	}
+
+	if len(changes) == 0 {
+		return settingsResult{}
+	}
+
+	if w.DryRun {
+		log.Info("Skipping updating the webhooks because of dry run")
+		return settingsResult{changes: changes}
+	}
+
+	if len(update.Webhooks) > 0 {
+		log.Info("Updating webhooks")
+		if err := manager.UpdateRepositorySettings(ctx, repo, update); err != nil {
+			return settingsResult{changes: changes, err: errors.Wrap(err, "could not update the webhooks")}
+		}
+	}
+
+	for _, url := range remove {
+		log.WithField("url", url).Info("Removing webhook")
+		if err := remover.RemoveWebhook(ctx, repo, url); err != nil {
+			return settingsResult{changes: changes, err: errors.Wrapf(err, "could not remove the webhook %s", url)}
+		}
+	}
+
+	return settingsResult{changes: changes}
+}
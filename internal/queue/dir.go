@@ -0,0 +1,220 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// dirQueue is a queue stored in a directory, that might be shared between machines. Each repository is an empty file,
+// that is claimed by atomically moving it from the tasks to the claimed directory. The modification time of
+// a claimed file is the time the lease expires
+type dirQueue struct {
+	path string
+}
+
+const (
+	dirTasks   = "tasks"
+	dirClaimed = "claimed"
+	dirResults = "results"
+)
+
+func (q *dirQueue) String() string {
+	return q.path
+}
+
+// fileName returns the name of the file of a repository, the name of a repository contains slashes
+func fileName(repository string) string {
+	return url.PathEscape(repository)
+}
+
+func repositoryName(fileName string) (string, error) {
+	return url.PathUnescape(fileName)
+}
+
+func (q *dirQueue) file(dir, repository string) string {
+	return filepath.Join(q.path, dir, fileName(repository))
+}
+
+func (q *dirQueue) Enqueue(ctx context.Context, repositories []string) error {
+	for _, dir := range []string{dirTasks, dirClaimed, dirResults} {
+		if err := os.RemoveAll(filepath.Join(q.path, dir)); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(q.path, dir), 0700); err != nil {
+			return err
+		}
+	}
+
+	for _, repository := range repositories {
+		if err := ioutil.WriteFile(q.file(dirTasks, repository), nil, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *dirQueue) Claim(ctx context.Context, lease time.Duration) (string, error) {
+	if err := q.requeueExpired(); err != nil {
+		return "", err
+	}
+
+	names, err := readDirNames(filepath.Join(q.path, dirTasks))
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range names {
+		task := filepath.Join(q.path, dirTasks, name)
+		claimed := filepath.Join(q.path, dirClaimed, name)
+
+		// The lease is set before the file is moved, to not be seen as expired by other workers
+		deadline := time.Now().Add(lease)
+		if err := os.Chtimes(task, deadline, deadline); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+
+		// Only one of the workers trying to claim the same file will succeed
+		if err := os.Rename(task, claimed); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+
+		return repositoryName(name)
+	}
+
+	return "", nil
+}
+
+// requeueExpired moves all claimed repositories with an expired lease back to the tasks
+func (q *dirQueue) requeueExpired() error {
+	names, err := readDirNames(filepath.Join(q.path, dirClaimed))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		claimed := filepath.Join(q.path, dirClaimed, name)
+		stat, err := os.Stat(claimed)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if stat.ModTime().After(now) {
+			continue
+		}
+
+		if err := os.Rename(claimed, filepath.Join(q.path, dirTasks, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *dirQueue) Extend(ctx context.Context, repository string, lease time.Duration) error {
+	deadline := time.Now().Add(lease)
+	err := os.Chtimes(q.file(dirClaimed, repository), deadline, deadline)
+	if os.IsNotExist(err) {
+		return errors.Errorf("%s is no longer claimed", repository)
+	}
+	return err
+}
+
+func (q *dirQueue) Complete(ctx context.Context, result multigitter.RepositoryReport) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	// The result is written to a temporary file first, to never be read while only partially written
+	tmpFile, err := ioutil.TempFile(q.path, "result-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), q.file(dirResults, result.Repository)); err != nil {
+		return err
+	}
+
+	// The repository might have been given to another worker, if the lease expired
+	for _, dir := range []string{dirClaimed, dirTasks} {
+		if err := os.Remove(q.file(dir, result.Repository)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *dirQueue) Pending(ctx context.Context) (int, error) {
+	pending := 0
+	for _, dir := range []string{dirTasks, dirClaimed} {
+		names, err := readDirNames(filepath.Join(q.path, dir))
+		if err != nil {
+			return 0, err
+		}
+		pending += len(names)
+	}
+	return pending, nil
+}
+
+func (q *dirQueue) Results(ctx context.Context) ([]multigitter.RepositoryReport, error) {
+	names, err := readDirNames(filepath.Join(q.path, dirResults))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]multigitter.RepositoryReport, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(q.path, dirResults, name))
+		if err != nil {
+			return nil, err
+		}
+		var result multigitter.RepositoryReport
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the result %s", name)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// readDirNames returns the sorted names of the files in a directory. A directory that does not exist is
+// treated as empty, since no run has been enqueued to the queue yet
+func readDirNames(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
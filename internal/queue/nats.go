@@ -0,0 +1,242 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// natsFetchWait is how long a claim waits for a repository to become available
+const natsFetchWait = time.Second
+
+// natsQueue is a queue stored in NATS JetStream. The repositories are published to a stream with work queue retention,
+// and are claimed through a durable pull consumer shared by all workers. A claimed repository that is not acknowledged
+// within the ack wait of the consumer is redelivered to another worker. The results are published to a second stream
+type natsQueue struct {
+	url    string
+	stream string
+
+	lock         sync.Mutex
+	js           nats.JetStreamContext
+	subscription *nats.Subscription
+	claimed      map[string]*nats.Msg
+}
+
+func (q *natsQueue) String() string {
+	return fmt.Sprintf("%s?stream=%s", q.url, q.stream)
+}
+
+func (q *natsQueue) tasksStream() string    { return q.stream + "-tasks" }
+func (q *natsQueue) tasksSubject() string   { return q.stream + ".tasks" }
+func (q *natsQueue) resultsStream() string  { return q.stream + "-results" }
+func (q *natsQueue) resultsSubject() string { return q.stream + ".results" }
+func (q *natsQueue) consumer() string       { return q.stream + "-workers" }
+
+// jetStream connects to NATS, and creates the streams if they do not already exist
+func (q *natsQueue) jetStream(ctx context.Context) (nats.JetStreamContext, error) {
+	if q.js != nil {
+		return q.js, nil
+	}
+
+	conn, err := nats.Connect(q.url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to NATS at %s", q.url)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	streams := []*nats.StreamConfig{
+		{Name: q.tasksStream(), Subjects: []string{q.tasksSubject()}, Retention: nats.WorkQueuePolicy},
+		{Name: q.resultsStream(), Subjects: []string{q.resultsSubject()}},
+	}
+	for _, config := range streams {
+		_, err := js.StreamInfo(config.Name, nats.Context(ctx))
+		if errors.Is(err, nats.ErrStreamNotFound) {
+			_, err = js.AddStream(config, nats.Context(ctx))
+		}
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrapf(err, "could not create the stream %s", config.Name)
+		}
+	}
+
+	q.js = js
+	q.claimed = map[string]*nats.Msg{}
+	return js, nil
+}
+
+func (q *natsQueue) Enqueue(ctx context.Context, repositories []string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	js, err := q.jetStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The consumer is created again by the first claim, with the lease of that claim
+	if q.subscription != nil {
+		_ = q.subscription.Unsubscribe()
+		q.subscription = nil
+	}
+	q.claimed = map[string]*nats.Msg{}
+	err = js.DeleteConsumer(q.tasksStream(), q.consumer(), nats.Context(ctx))
+	if err != nil && !errors.Is(err, nats.ErrConsumerNotFound) {
+		return err
+	}
+
+	for _, stream := range []string{q.tasksStream(), q.resultsStream()} {
+		if err := js.PurgeStream(stream, nats.Context(ctx)); err != nil {
+			return errors.Wrapf(err, "could not purge the stream %s", stream)
+		}
+	}
+
+	for _, repo := range repositories {
+		if _, err := js.Publish(q.tasksSubject(), []byte(repo), nats.Context(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Claim claims the next repository of the stream. The ack wait of the consumer is set to the lease by the first claim
+// after the repositories are enqueued, and is used for all following claims
+func (q *natsQueue) Claim(ctx context.Context, lease time.Duration) (string, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	js, err := q.jetStream(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if q.subscription == nil {
+		q.subscription, err = js.PullSubscribe(q.tasksSubject(), q.consumer(),
+			nats.BindStream(q.tasksStream()),
+			nats.AckExplicit(),
+			nats.AckWait(lease),
+		)
+		if err != nil {
+			return "", errors.Wrap(err, "could not subscribe to the repositories")
+		}
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, natsFetchWait)
+	defer cancel()
+	msgs, err := q.subscription.Fetch(1, nats.Context(fetchCtx))
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	} else if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrTimeout) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	repository := string(msgs[0].Data)
+	q.claimed[repository] = msgs[0]
+	return repository, nil
+}
+
+// Extend resets the ack wait of the claimed repository. The ack wait of the consumer is used instead of the lease
+func (q *natsQueue) Extend(ctx context.Context, repository string, lease time.Duration) error {
+	q.lock.Lock()
+	msg, ok := q.claimed[repository]
+	q.lock.Unlock()
+	if !ok {
+		return errors.Errorf("%s is not claimed", repository)
+	}
+	return msg.InProgress(nats.Context(ctx))
+}
+
+func (q *natsQueue) Complete(ctx context.Context, result multigitter.RepositoryReport) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	js, err := q.jetStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := js.Publish(q.resultsSubject(), data, nats.Context(ctx)); err != nil {
+		return err
+	}
+
+	msg, ok := q.claimed[result.Repository]
+	if !ok {
+		return nil
+	}
+	delete(q.claimed, result.Repository)
+	return msg.AckSync(nats.Context(ctx))
+}
+
+// Pending returns the number of messages in the tasks stream. With work queue retention, a message is removed once it is acknowledged
+func (q *natsQueue) Pending(ctx context.Context) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	js, err := q.jetStream(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := js.StreamInfo(q.tasksStream(), nats.Context(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return int(info.State.Msgs), nil
+}
+
+// Results reads all messages of the results stream. A repository can have several results if its lease expired
+// while it was run, in which case the last one is used
+func (q *natsQueue) Results(ctx context.Context) ([]multigitter.RepositoryReport, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	js, err := q.jetStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := js.StreamInfo(q.resultsStream(), nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []multigitter.RepositoryReport
+	index := map[string]int{}
+	for seq := info.State.FirstSeq; info.State.Msgs > 0 && seq <= info.State.LastSeq; seq++ {
+		msg, err := js.GetMsg(q.resultsStream(), seq, nats.Context(ctx))
+		if errors.Is(err, nats.ErrMsgNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var result multigitter.RepositoryReport
+		if err := json.Unmarshal(msg.Data, &result); err != nil {
+			return nil, errors.Wrap(err, "could not parse a result")
+		}
+		if i, ok := index[result.Repository]; ok {
+			results[i] = result
+			continue
+		}
+		index[result.Repository] = len(results)
+		results = append(results, result)
+	}
+	return results, nil
+}
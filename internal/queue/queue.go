@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+const defaultKey = "multi-gitter"
+
+// Parse creates a queue from its url. The supported urls are:
+//
+//	path or file://path                                             the queue is stored in a directory, shared between the workers
+//	redis://[:password@]host[:port][/db][?key=...]                  the queue is stored in Redis, with keys prefixed by key
+//	nats://[user:password@]host[:port][?stream=...]                 the queue is stored in NATS JetStream, in streams prefixed by stream
+//	sqs://sqs.region.amazonaws.com/account/queue[?results=...]      the queue is stored in an Amazon SQS queue, and the results in
+//	                                                                the results queue, that defaults to the name of the queue with -results appended
+//
+// Use rediss:// to connect to Redis with TLS, and tls:// to connect to NATS with TLS. The AWS credentials are read
+// in the same way as the AWS CLI, and the profile can be set with the profile parameter of an sqs:// url
+func Parse(queueURL string) (multigitter.Queue, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // A single letter is a Windows drive
+		return &dirQueue{path: queueURL}, nil
+	}
+
+	query := u.Query()
+	switch u.Scheme {
+	case "file":
+		return &dirQueue{path: u.Host + u.Path}, nil
+	case "redis", "rediss":
+		key := query.Get("key")
+		if key == "" {
+			key = defaultKey
+		}
+		// The key is not an option of the Redis client
+		query.Del("key")
+		u.RawQuery = query.Encode()
+
+		options, err := redis.ParseURL(u.String())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse the Redis url")
+		}
+		return &redisQueue{client: redis.NewClient(options), key: key}, nil
+	case "nats", "tls":
+		stream := query.Get("stream")
+		if stream == "" {
+			stream = defaultKey
+		}
+		if strings.ContainsAny(stream, ".*> ") {
+			return nil, errors.Errorf("the NATS stream %s can not contain '.', '*', '>' or spaces", stream)
+		}
+		u.RawQuery = ""
+		return &natsQueue{url: u.String(), stream: stream}, nil
+	case "sqs":
+		return parseSQS(u)
+	}
+	return nil, errors.Errorf("unknown queue %s", queueURL)
+}
+
+func parseSQS(u *url.URL) (*sqsQueue, error) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.Errorf("the SQS queue %s should be of the form sqs://sqs.region.amazonaws.com/account/queue", u.String())
+	}
+
+	query := u.Query()
+	results := query.Get("results")
+	if results == "" {
+		results = parts[1] + "-results"
+	}
+
+	// The region is read from the host name, or else from the AWS configuration
+	var region string
+	if hostParts := strings.Split(u.Hostname(), "."); len(hostParts) > 2 && hostParts[0] == "sqs" {
+		region = hostParts[1]
+	}
+
+	endpoint := url.URL{Scheme: "https", Host: u.Host, Path: "/"}
+	tasksURL := endpoint
+	tasksURL.Path = path.Join("/", parts[0], parts[1])
+	resultsURL := endpoint
+	resultsURL.Path = path.Join("/", parts[0], results)
+
+	return &sqsQueue{
+		endpoint:   endpoint.String(),
+		tasksURL:   tasksURL.String(),
+		resultsURL: resultsURL.String(),
+		profile:    query.Get("profile"),
+		region:     region,
+		httpClient: http.DefaultClient,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// redisQueue is a queue stored in Redis. Unclaimed repositories are stored in a list, and are claimed by atomically
+// moving them to another list. The time each lease expires is stored in a hash, next to a hash of all results
+type redisQueue struct {
+	client *redis.Client
+	key    string
+}
+
+func (q *redisQueue) String() string {
+	options := q.client.Options()
+	return fmt.Sprintf("redis://%s/%d?key=%s", options.Addr, options.DB, q.key)
+}
+
+func (q *redisQueue) tasksKey() string   { return q.key + ":tasks" }
+func (q *redisQueue) claimedKey() string { return q.key + ":claimed" }
+func (q *redisQueue) leasesKey() string  { return q.key + ":leases" }
+func (q *redisQueue) resultsKey() string { return q.key + ":results" }
+
+func (q *redisQueue) Enqueue(ctx context.Context, repositories []string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, q.tasksKey(), q.claimedKey(), q.leasesKey(), q.resultsKey())
+		if len(repositories) == 0 {
+			return nil
+		}
+
+		// Repositories are claimed from the end of the list, in the same order as they are enqueued
+		values := make([]interface{}, len(repositories))
+		for i, repo := range repositories {
+			values[i] = repo
+		}
+		pipe.LPush(ctx, q.tasksKey(), values...)
+		return nil
+	})
+	return err
+}
+
+func (q *redisQueue) Claim(ctx context.Context, lease time.Duration) (string, error) {
+	if err := q.requeueExpired(ctx, lease); err != nil {
+		return "", err
+	}
+
+	repository, err := q.client.RPopLPush(ctx, q.tasksKey(), q.claimedKey()).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if err := q.Extend(ctx, repository, lease); err != nil {
+		return "", err
+	}
+	return repository, nil
+}
+
+// requeueExpired moves all claimed repositories with an expired lease back to the tasks
+func (q *redisQueue) requeueExpired(ctx context.Context, lease time.Duration) error {
+	claimed, err := q.client.LRange(ctx, q.claimedKey(), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, repository := range claimed {
+		value, err := q.client.HGet(ctx, q.leasesKey(), repository).Result()
+		if err == redis.Nil {
+			// The worker that claimed the repository has not yet set the lease, or stopped before doing so
+			if err := q.client.HSetNX(ctx, q.leasesKey(), repository, now.Add(lease).UnixNano()).Err(); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		deadline, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse the lease of %s", repository)
+		}
+		if time.Unix(0, deadline).After(now) {
+			continue
+		}
+
+		// Only the worker that removes the repository from the claimed list will requeue it
+		removed, err := q.client.LRem(ctx, q.claimedKey(), 1, repository).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			continue
+		}
+		_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(ctx, q.leasesKey(), repository)
+			pipe.RPush(ctx, q.tasksKey(), repository)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *redisQueue) Extend(ctx context.Context, repository string, lease time.Duration) error {
+	return q.client.HSet(ctx, q.leasesKey(), repository, time.Now().Add(lease).UnixNano()).Err()
+}
+
+func (q *redisQueue) Complete(ctx context.Context, result multigitter.RepositoryReport) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.resultsKey(), result.Repository, data)
+		// The repository might have been given to another worker, if the lease expired
+		pipe.LRem(ctx, q.claimedKey(), 0, result.Repository)
+		pipe.LRem(ctx, q.tasksKey(), 0, result.Repository)
+		pipe.HDel(ctx, q.leasesKey(), result.Repository)
+		return nil
+	})
+	return err
+}
+
+func (q *redisQueue) Pending(ctx context.Context) (int, error) {
+	var tasks, claimed *redis.IntCmd
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		tasks = pipe.LLen(ctx, q.tasksKey())
+		claimed = pipe.LLen(ctx, q.claimedKey())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(tasks.Val() + claimed.Val()), nil
+}
+
+func (q *redisQueue) Results(ctx context.Context) ([]multigitter.RepositoryReport, error) {
+	values, err := q.client.HVals(ctx, q.resultsKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]multigitter.RepositoryReport, 0, len(values))
+	for _, value := range values {
+		var result multigitter.RepositoryReport
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			return nil, errors.Wrap(err, "could not parse a result")
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
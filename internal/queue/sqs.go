@@ -0,0 +1,340 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+const sqsTargetPrefix = "AmazonSQS."
+
+// sqsQueue is a queue stored in two Amazon SQS queues. Each repository is sent as a message to the tasks queue, and is
+// claimed by receiving it, which hides it from other workers for the lease. The results are sent to the results queue.
+//
+// The number of messages in an SQS queue is only approximate. The queue that enqueued the repositories therefore
+// counts the pending repositories from the results it has received, which it keeps in memory
+type sqsQueue struct {
+	endpoint   string
+	tasksURL   string
+	resultsURL string
+	profile    string
+	httpClient *http.Client
+
+	lock        sync.Mutex
+	credentials *aws.Credentials
+	region      string
+	handles     map[string]string
+	enqueued    []string
+	results     map[string]multigitter.RepositoryReport
+}
+
+func (q *sqsQueue) String() string {
+	return q.tasksURL
+}
+
+type sqsMessage struct {
+	ReceiptHandle string
+	Body          string
+}
+
+// sqsError is an error returned by the SQS API
+type sqsError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e sqsError) Error() string {
+	return fmt.Sprintf("sqs responded with status code %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// call calls an action of the SQS API. The input is sent as JSON, and if output is set, the response is decoded into it
+func (q *sqsQueue) call(ctx context.Context, action string, input, output interface{}) error {
+	q.lock.Lock()
+	if q.credentials == nil {
+		creds, region, err := aws.LoadCredentials(ctx, q.profile)
+		if err != nil {
+			q.lock.Unlock()
+			return err
+		}
+		q.credentials = &creds
+		if q.region == "" {
+			q.region = region
+		}
+	}
+	creds, region := *q.credentials, q.region
+	q.lock.Unlock()
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", sqsTargetPrefix+action)
+	if err := aws.SignRequest(ctx, req, body, creds, region, "sqs", time.Now().UTC()); err != nil {
+		return err
+	}
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		// The type is prefixed with the namespace of the service
+		if i := strings.LastIndex(errResp.Type, "#"); i >= 0 {
+			errResp.Type = errResp.Type[i+1:]
+		}
+		return sqsError{
+			StatusCode: resp.StatusCode,
+			Type:       errResp.Type,
+			Message:    errResp.Message,
+		}
+	}
+
+	if output == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, output)
+}
+
+// receive receives up to max messages from a queue, hidden from other receivers for the visibility timeout
+func (q *sqsQueue) receive(ctx context.Context, queueURL string, max int, visibility time.Duration) ([]sqsMessage, error) {
+	var output struct {
+		Messages []sqsMessage
+	}
+	err := q.call(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":            queueURL,
+		"MaxNumberOfMessages": max,
+		"VisibilityTimeout":   visibilitySeconds(visibility),
+		"WaitTimeSeconds":     1, // Short polls do not query all servers, and might not return a message that exists
+	}, &output)
+	return output.Messages, err
+}
+
+func (q *sqsQueue) send(ctx context.Context, queueURL, body string) error {
+	return q.call(ctx, "SendMessage", map[string]interface{}{
+		"QueueUrl":    queueURL,
+		"MessageBody": body,
+	}, nil)
+}
+
+func (q *sqsQueue) delete(ctx context.Context, queueURL, receiptHandle string) error {
+	return q.call(ctx, "DeleteMessage", map[string]interface{}{
+		"QueueUrl":      queueURL,
+		"ReceiptHandle": receiptHandle,
+	}, nil)
+}
+
+// visibilitySeconds converts a lease to a visibility timeout, that has to be whole seconds between zero and twelve hours
+func visibilitySeconds(lease time.Duration) int {
+	seconds := int(math.Ceil(lease.Seconds()))
+	if seconds < 0 {
+		return 0
+	}
+	if seconds > 43200 {
+		return 43200
+	}
+	return seconds
+}
+
+// drain deletes all visible messages of a queue. Messages that have been received by someone else are not deleted
+func (q *sqsQueue) drain(ctx context.Context, queueURL string, handle func(sqsMessage) error) error {
+	for {
+		messages, err := q.receive(ctx, queueURL, 10, time.Minute)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for _, msg := range messages {
+			if handle != nil {
+				if err := handle(msg); err != nil {
+					return err
+				}
+			}
+			if err := q.delete(ctx, queueURL, msg.ReceiptHandle); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Enqueue removes the messages of an earlier run, and sends the repositories to the tasks queue. PurgeQueue is not used,
+// since it might also remove messages sent up to a minute after it is called
+func (q *sqsQueue) Enqueue(ctx context.Context, repositories []string) error {
+	for _, queueURL := range []string{q.tasksURL, q.resultsURL} {
+		if err := q.drain(ctx, queueURL, nil); err != nil {
+			return errors.Wrapf(err, "could not empty %s", queueURL)
+		}
+	}
+
+	q.lock.Lock()
+	q.enqueued = append([]string{}, repositories...)
+	q.results = map[string]multigitter.RepositoryReport{}
+	q.lock.Unlock()
+
+	for _, repo := range repositories {
+		if err := q.send(ctx, q.tasksURL, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *sqsQueue) Claim(ctx context.Context, lease time.Duration) (string, error) {
+	messages, err := q.receive(ctx, q.tasksURL, 1, lease)
+	if err != nil || len(messages) == 0 {
+		return "", err
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.handles == nil {
+		q.handles = map[string]string{}
+	}
+	q.handles[messages[0].Body] = messages[0].ReceiptHandle
+	return messages[0].Body, nil
+}
+
+func (q *sqsQueue) Extend(ctx context.Context, repository string, lease time.Duration) error {
+	q.lock.Lock()
+	handle, ok := q.handles[repository]
+	q.lock.Unlock()
+	if !ok {
+		return errors.Errorf("%s is not claimed", repository)
+	}
+
+	return q.call(ctx, "ChangeMessageVisibility", map[string]interface{}{
+		"QueueUrl":          q.tasksURL,
+		"ReceiptHandle":     handle,
+		"VisibilityTimeout": visibilitySeconds(lease),
+	}, nil)
+}
+
+func (q *sqsQueue) Complete(ctx context.Context, result multigitter.RepositoryReport) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := q.send(ctx, q.resultsURL, string(data)); err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	handle, ok := q.handles[result.Repository]
+	delete(q.handles, result.Repository)
+	q.lock.Unlock()
+	if !ok {
+		return nil
+	}
+	return q.delete(ctx, q.tasksURL, handle)
+}
+
+// receiveResults moves the messages of the results queue to the results kept in memory
+func (q *sqsQueue) receiveResults(ctx context.Context) error {
+	return q.drain(ctx, q.resultsURL, func(msg sqsMessage) error {
+		var result multigitter.RepositoryReport
+		if err := json.Unmarshal([]byte(msg.Body), &result); err != nil {
+			return errors.Wrap(err, "could not parse a result")
+		}
+
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		if q.results == nil {
+			q.results = map[string]multigitter.RepositoryReport{}
+		}
+		q.results[result.Repository] = result
+		return nil
+	})
+}
+
+// Pending returns the number of enqueued repositories without a result. Workers, that did not enqueue the repositories,
+// instead use the approximate number of messages in the tasks queue, including the ones that are currently received
+func (q *sqsQueue) Pending(ctx context.Context) (int, error) {
+	q.lock.Lock()
+	enqueued := q.enqueued
+	q.lock.Unlock()
+
+	if enqueued == nil {
+		var output struct {
+			Attributes map[string]string
+		}
+		err := q.call(ctx, "GetQueueAttributes", map[string]interface{}{
+			"QueueUrl":       q.tasksURL,
+			"AttributeNames": []string{"ApproximateNumberOfMessages", "ApproximateNumberOfMessagesNotVisible"},
+		}, &output)
+		if err != nil {
+			return 0, err
+		}
+
+		pending := 0
+		for _, value := range output.Attributes {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, errors.Wrapf(err, "could not parse the number of messages %s", value)
+			}
+			pending += count
+		}
+		return pending, nil
+	}
+
+	if err := q.receiveResults(ctx); err != nil {
+		return 0, err
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	pending := 0
+	for _, repo := range enqueued {
+		if _, ok := q.results[repo]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (q *sqsQueue) Results(ctx context.Context) ([]multigitter.RepositoryReport, error) {
+	if err := q.receiveResults(ctx); err != nil {
+		return nil, err
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	results := make([]multigitter.RepositoryReport, 0, len(q.results))
+	for _, result := range q.results {
+		results = append(results, result)
+	}
+	return results, nil
+}
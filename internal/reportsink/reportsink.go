@@ -0,0 +1,283 @@
+package reportsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+	"github.com/lindell/multi-gitter/internal/gcp"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+// Parse creates a report sink from its url. The supported urls are:
+//
+//	path or file://path                 the report is written to the file
+//	http://... or https://...           the report is posted as JSON
+//	s3://bucket/key                     the report is uploaded to S3, with the AWS credentials
+//	gs://bucket/object                  the report is uploaded to Google Cloud Storage
+//	bigquery://project/dataset/table    a row for each repository is inserted into the BigQuery table
+//
+// Google Cloud is authenticated with the access token in the GOOGLE_OAUTH_ACCESS_TOKEN environment variable,
+// or else the token of gcloud or the metadata server
+func Parse(sinkURL string) (multigitter.ReportSink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // A single letter is a Windows drive
+		return fileSink{path: sinkURL}, nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return fileSink{path: u.Host + u.Path}, nil
+	case "http", "https":
+		return httpSink{url: sinkURL}, nil
+	case "s3":
+		creds, region, err := aws.LoadCredentials(context.Background(), "")
+		if err != nil {
+			return nil, err
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		return s3Sink{
+			endpoint:    fmt.Sprintf("https://%s.s3.%s.amazonaws.com", u.Host, region),
+			key:         strings.TrimPrefix(u.Path, "/"),
+			credentials: creds,
+			region:      region,
+		}, nil
+	case "gs":
+		return gcsSink{
+			endpoint: "https://storage.googleapis.com",
+			bucket:   u.Host,
+			object:   strings.TrimPrefix(u.Path, "/"),
+		}, nil
+	case "bigquery":
+		split := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(split) != 2 {
+			return nil, errors.Errorf("could not parse the BigQuery table %s, the format is bigquery://project/dataset/table", sinkURL)
+		}
+		return bigQuerySink{
+			endpoint: "https://bigquery.googleapis.com",
+			project:  u.Host,
+			dataset:  split[0],
+			table:    split[1],
+		}, nil
+	}
+	return nil, errors.Errorf("unknown report sink %s", sinkURL)
+}
+
+func marshalReport(report multigitter.Report) ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// send sends a request, and returns an error if the response is not successful. If result is set, the response is decoded into it
+func send(req *http.Request, result interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("responded with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body, result)
+}
+
+type fileSink struct {
+	path string
+}
+
+func (s fileSink) String() string {
+	return s.path
+}
+
+func (s fileSink) WriteReport(ctx context.Context, report multigitter.Report) error {
+	data, err := marshalReport(report)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, append(data, '\n'), 0600)
+}
+
+type httpSink struct {
+	url string
+}
+
+func (s httpSink) String() string {
+	return s.url
+}
+
+func (s httpSink) WriteReport(ctx context.Context, report multigitter.Report) error {
+	data, err := marshalReport(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(req, nil)
+}
+
+type s3Sink struct {
+	endpoint    string
+	key         string
+	credentials aws.Credentials
+	region      string
+}
+
+func (s s3Sink) String() string {
+	return s.endpoint + "/" + s.key
+}
+
+func (s s3Sink) WriteReport(ctx context.Context, report multigitter.Report) error {
+	data, err := marshalReport(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.endpoint+"/"+s.key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Content-Sha256", aws.SHA256Hex(data))
+	if err := aws.SignRequest(ctx, req, data, s.credentials, s.region, "s3", time.Now().UTC()); err != nil {
+		return err
+	}
+	return send(req, nil)
+}
+
+type gcsSink struct {
+	endpoint string
+	bucket   string
+	object   string
+}
+
+func (s gcsSink) String() string {
+	return fmt.Sprintf("gs://%s/%s", s.bucket, s.object)
+}
+
+func (s gcsSink) WriteReport(ctx context.Context, report multigitter.Report) error {
+	token, err := gcp.AccessToken(ctx, http.DefaultClient)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalReport(report)
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", s.endpoint, url.PathEscape(s.bucket), url.QueryEscape(s.object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return send(req, nil)
+}
+
+type bigQuerySink struct {
+	endpoint string
+	project  string
+	dataset  string
+	table    string
+}
+
+func (s bigQuerySink) String() string {
+	return fmt.Sprintf("bigquery://%s/%s/%s", s.project, s.dataset, s.table)
+}
+
+// bigQueryRow is a row inserted into BigQuery, the table has to have these columns
+type bigQueryRow struct {
+	ReportedAt  string `json:"reported_at"`
+	Repository  string `json:"repository"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	PullRequest string `json:"pull_request,omitempty"`
+	DiffHash    string `json:"diff_hash,omitempty"`
+}
+
+func (s bigQuerySink) WriteReport(ctx context.Context, report multigitter.Report) error {
+	token, err := gcp.AccessToken(ctx, http.DefaultClient)
+	if err != nil {
+		return err
+	}
+
+	type insertRow struct {
+		InsertID string      `json:"insertId"` // Used by BigQuery to not insert the same row twice if the request is retried
+		JSON     bigQueryRow `json:"json"`
+	}
+	reportedAt := time.Now().UTC().Format(time.RFC3339)
+	rows := make([]insertRow, len(report.Repositories))
+	for i, repo := range report.Repositories {
+		rows[i] = insertRow{
+			InsertID: reportedAt + "/" + repo.Repository,
+			JSON: bigQueryRow{
+				ReportedAt:  reportedAt,
+				Repository:  repo.Repository,
+				Status:      repo.Status,
+				Error:       repo.Error,
+				PullRequest: repo.PullRequest,
+				DiffHash:    repo.DiffHash,
+			},
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"rows": rows})
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables/%s/insertAll",
+		s.endpoint, url.PathEscape(s.project), url.PathEscape(s.dataset), url.PathEscape(s.table))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// Rows that could not be inserted are returned with a successful status code
+	var result struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if err := send(req, &result); err != nil {
+		return err
+	}
+	if len(result.InsertErrors) > 0 {
+		insertErr := result.InsertErrors[0]
+		if insertErr.Index >= len(rows) || len(insertErr.Errors) == 0 {
+			return errors.Errorf("could not insert %d rows", len(result.InsertErrors))
+		}
+		return errors.Errorf("could not insert %d rows, the row of %s failed with: %s",
+			len(result.InsertErrors), rows[insertErr.Index].JSON.Repository, insertErr.Errors[0].Message)
+	}
+	return nil
+}
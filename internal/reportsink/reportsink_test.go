@@ -0,0 +1,143 @@
+package reportsink
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+)
+
+var report = multigitter.Report{
+	Repositories: []multigitter.RepositoryReport{
+		{Repository: "owner/a", Status: multigitter.ReportStatusSuccess, PullRequest: "owner/a #1"},
+		{Repository: "owner/b", Status: multigitter.ReportStatusError, Error: "exit status 1"},
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected multigitter.ReportSink
+	}{
+		{"report.json", fileSink{path: "report.json"}},
+		{"file:///tmp/report.json", fileSink{path: "/tmp/report.json"}},
+		{"https://example.com/reports", httpSink{url: "https://example.com/reports"}},
+		{"gs://bucket/path/report.json", gcsSink{endpoint: "https://storage.googleapis.com", bucket: "bucket", object: "path/report.json"}},
+		{"bigquery://project/dataset/table", bigQuerySink{endpoint: "https://bigquery.googleapis.com", project: "project", dataset: "dataset", table: "table"}},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			sink, err := Parse(test.url)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, sink)
+		})
+	}
+
+	_, err := Parse("bigquery://project/table")
+	assert.EqualError(t, err, "could not parse the BigQuery table bigquery://project/table, the format is bigquery://project/dataset/table")
+	_, err = Parse("ftp://example.com/report.json")
+	assert.EqualError(t, err, "unknown report sink ftp://example.com/report.json")
+}
+
+func TestFileSink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-report-sink-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "report.json")
+	require.NoError(t, fileSink{path: path}.WriteReport(context.Background(), report))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	written, err := multigitter.ReadReport(f)
+	require.NoError(t, err)
+	assert.Equal(t, report, written)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received multigitter.Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	require.NoError(t, httpSink{url: server.URL}.WriteReport(context.Background(), report))
+	assert.Equal(t, report, received)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("forbidden\n"))
+	})
+	err := httpSink{url: server.URL}.WriteReport(context.Background(), report)
+	assert.EqualError(t, err, "responded with status code 403: forbidden")
+}
+
+func TestS3Sink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/reports/report.json", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+	}))
+	defer server.Close()
+
+	sink := s3Sink{
+		endpoint:    server.URL,
+		key:         "reports/report.json",
+		credentials: aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		region:      "eu-west-1",
+	}
+	require.NoError(t, sink.WriteReport(context.Background(), report))
+}
+
+func TestBigQuerySink(t *testing.T) {
+	os.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "google-token")
+	defer os.Unsetenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+
+	var rows []map[string]interface{}
+	response := `{}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bigquery/v2/projects/project/datasets/dataset/tables/table/insertAll", r.URL.Path)
+		assert.Equal(t, "Bearer google-token", r.Header.Get("Authorization"))
+
+		var body struct {
+			Rows []struct {
+				JSON map[string]interface{} `json:"json"`
+			} `json:"rows"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		rows = nil
+		for _, row := range body.Rows {
+			rows = append(rows, row.JSON)
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	sink := bigQuerySink{endpoint: server.URL, project: "project", dataset: "dataset", table: "table"}
+	require.NoError(t, sink.WriteReport(context.Background(), report))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "owner/a", rows[0]["repository"])
+	assert.Equal(t, "owner/a #1", rows[0]["pull_request"])
+	assert.Equal(t, "exit status 1", rows[1]["error"])
+	assert.NotEmpty(t, rows[1]["reported_at"])
+
+	response = `{"insertErrors": [{"index": 1, "errors": [{"message": "no such field: error"}]}]}`
+	err := sink.WriteReport(context.Background(), report)
+	assert.EqualError(t, err, "could not insert 1 rows, the row of owner/b failed with: no such field: error")
+}
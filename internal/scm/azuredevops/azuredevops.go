@@ -0,0 +1,655 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+)
+
+const defaultBaseURL = "https://dev.azure.com"
+
+// New create a new Azure DevOps client. The token is a personal access token, and baseURL is
+// the url of the collection if Azure DevOps Server is used
+func New(
+	token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+) (*AzureDevOps, error) {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	// Identities are handled by a separate service in Azure DevOps Services
+	identityURL := baseURL
+	if u.Host == "dev.azure.com" {
+		u.Host = "vssps.dev.azure.com"
+		identityURL = u.String()
+	}
+
+	return &AzureDevOps{
+		RepositoryListing: repoListing,
+
+		baseURL:     baseURL,
+		identityURL: identityURL,
+		token:       token,
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+
+		MergeTypes: mergeTypes,
+	}, nil
+}
+
+// AzureDevOps contain Azure DevOps configuration
+type AzureDevOps struct {
+	RepositoryListing
+
+	baseURL     string
+	identityURL string
+	token       string
+	httpClient  *http.Client
+
+	MergeTypes []domain.MergeType
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Organizations []string
+	Projects      []ProjectReference
+	Repositories  []RepositoryReference
+}
+
+// ProjectReference contains information to be able to reference a project
+type ProjectReference struct {
+	Organization string
+	Project      string
+}
+
+// ParseProjectReference parses a project reference from the format "organization/project"
+func ParseProjectReference(val string) (ProjectReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 2 {
+		return ProjectReference{}, fmt.Errorf("could not parse project reference: %s", val)
+	}
+	return ProjectReference{
+		Organization: split[0],
+		Project:      split[1],
+	}, nil
+}
+
+// RepositoryReference contains information to be able to reference a repository
+type RepositoryReference struct {
+	Organization string
+	Project      string
+	Name         string
+}
+
+// ParseRepositoryReference parses a repository reference from the format "organization/project/repository"
+func ParseRepositoryReference(val string) (RepositoryReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 3 {
+		return RepositoryReference{}, fmt.Errorf("could not parse repository reference: %s", val)
+	}
+	return RepositoryReference{
+		Organization: split[0],
+		Project:      split[1],
+		Name:         split[2],
+	}, nil
+}
+
+type repository struct {
+	url           url.URL
+	webURL        string
+	organization  string
+	projectID     string
+	project       string
+	id            string
+	name          string
+	defaultBranch string
+}
+
+func (r repository) URL(token string) string {
+	// Personal access tokens can be used with any username
+	r.url.User = url.UserPassword("pat", token)
+	return r.url.String()
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("%s/%s/%s", r.organization, r.project, r.name)
+}
+
+type pullRequest struct {
+	organization string
+	project      string
+	repoID       string
+	repoName     string
+	branchName   string
+	prProject    string
+	prRepoID     string
+	id           int
+	webURL       string
+	status       domain.PullRequestStatus
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s/%s/%s #%d", pr.organization, pr.project, pr.repoName, pr.id)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s/%s", pr.organization, pr.project, pr.repoName)
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr pullRequest) URL() string {
+	return pr.webURL
+}
+
+type adoProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type adoRepository struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	DefaultBranch string     `json:"defaultBranch"`
+	RemoteURL     string     `json:"remoteUrl"`
+	WebURL        string     `json:"webUrl"`
+	IsDisabled    bool       `json:"isDisabled"`
+	Project       adoProject `json:"project"`
+}
+
+type adoPullRequest struct {
+	PullRequestID         int    `json:"pullRequestId"`
+	Status                string `json:"status"`
+	SourceRefName         string `json:"sourceRefName"`
+	LastMergeSourceCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeSourceCommit"`
+	ForkSource *struct {
+		Repository adoRepository `json:"repository"`
+	} `json:"forkSource"`
+}
+
+// organizationRepository is a repository together with the organization it belongs to, which is not part of the repository itself
+type organizationRepository struct {
+	organization string
+	adoRepository
+}
+
+// GetRepositories fetches repositories from all sources (organizations/projects/specific repo)
+func (a *AzureDevOps) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := a.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.IsDisabled {
+			log.Debugf("Skipping %s/%s since it's disabled", repo.Project.Name, repo.Name)
+			continue
+		}
+		if repo.DefaultBranch == "" {
+			log.Debugf("Skipping %s/%s since it's empty", repo.Project.Name, repo.Name)
+			continue
+		}
+
+		convertedRepo, err := convertRepository(repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+
+	return repos, nil
+}
+
+func (a *AzureDevOps) getRepositories(ctx context.Context) ([]organizationRepository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos := []organizationRepository{}
+
+	projects := append([]ProjectReference{}, a.Projects...)
+	for _, org := range a.Organizations {
+		orgProjects, err := a.getProjects(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range orgProjects {
+			projects = append(projects, ProjectReference{
+				Organization: org,
+				Project:      project.Name,
+			})
+		}
+	}
+
+	for _, project := range projects {
+		var page struct {
+			Value []adoRepository `json:"value"`
+		}
+		path := fmt.Sprintf("%s/%s/_apis/git/repositories", url.PathEscape(project.Organization), url.PathEscape(project.Project))
+		if err := a.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, repo := range page.Value {
+			allRepos = append(allRepos, organizationRepository{organization: project.Organization, adoRepository: repo})
+		}
+	}
+
+	for _, ref := range a.Repositories {
+		var repo adoRepository
+		if err := a.do(ctx, http.MethodGet, repoPath(ref.Organization, ref.Project, ref.Name), nil, &repo); err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, organizationRepository{organization: ref.Organization, adoRepository: repo})
+	}
+
+	// Remove duplicate repos
+	repoMap := map[string]organizationRepository{}
+	for _, repo := range allRepos {
+		repoMap[repo.ID] = repo
+	}
+	allRepos = make([]organizationRepository, 0, len(repoMap))
+	for _, repo := range repoMap {
+		allRepos = append(allRepos, repo)
+	}
+	sort.Slice(allRepos, func(i, j int) bool {
+		return fullName(allRepos[i]) < fullName(allRepos[j])
+	})
+
+	return allRepos, nil
+}
+
+// getProjects gets all projects of an organization
+func (a *AzureDevOps) getProjects(ctx context.Context, org string) ([]adoProject, error) {
+	const pageSize = 100
+
+	var projects []adoProject
+	for skip := 0; ; skip += pageSize {
+		var page struct {
+			Value []adoProject `json:"value"`
+		}
+		path := fmt.Sprintf("%s/_apis/projects?$top=%d&$skip=%d", url.PathEscape(org), pageSize, skip)
+		if err := a.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, errors.Wrapf(err, "could not list the projects of %s", org)
+		}
+		projects = append(projects, page.Value...)
+
+		if len(page.Value) < pageSize {
+			return projects, nil
+		}
+	}
+}
+
+func fullName(repo organizationRepository) string {
+	return fmt.Sprintf("%s/%s/%s", repo.organization, repo.Project.Name, repo.Name)
+}
+
+// repoPath is the path of a repository, the repository can be referenced by either its name or id
+func repoPath(org, project, repo string) string {
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s", url.PathEscape(org), url.PathEscape(project), url.PathEscape(repo))
+}
+
+// CreatePullRequest creates a pull request
+func (a *AzureDevOps) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+	prR := prRepo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on Azure DevOps")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on Azure DevOps")
+	}
+
+	// Both users and teams are identities, which are added as reviewers in the same way.
+	// The default reviewers are added by the branch policies when the pull request is created
+	users, teams := domain.SplitReviewers(newPR.Reviewers)
+	reviewers := []map[string]string{}
+	for _, reviewer := range append(users, teams...) {
+		id, err := a.getIdentityID(ctx, r.organization, reviewer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not find the reviewer %s", reviewer)
+		}
+		reviewers = append(reviewers, map[string]string{"id": id})
+	}
+
+	labels := make([]map[string]string, len(newPR.Labels))
+	for i, label := range newPR.Labels {
+		labels[i] = map[string]string{"name": label}
+	}
+
+	body := map[string]interface{}{
+		"sourceRefName": "refs/heads/" + newPR.Head,
+		"targetRefName": "refs/heads/" + newPR.Base,
+		"title":         newPR.Title,
+		"description":   newPR.Body,
+		"isDraft":       newPR.Draft,
+		"reviewers":     reviewers,
+		"labels":        labels,
+	}
+	if prR.id != r.id {
+		body["forkSource"] = map[string]interface{}{
+			"repository": map[string]string{"id": prR.id},
+		}
+	}
+
+	var pr adoPullRequest
+	if err := a.do(ctx, http.MethodPost, repoPath(r.organization, r.projectID, r.id)+"/pullrequests", body, &pr); err != nil {
+		return nil, errors.Wrap(err, "could not create pull request")
+	}
+
+	return pullRequest{
+		organization: r.organization,
+		project:      r.project,
+		repoID:       r.id,
+		repoName:     r.name,
+		branchName:   newPR.Head,
+		prProject:    prR.projectID,
+		prRepoID:     prR.id,
+		id:           pr.PullRequestID,
+		webURL:       fmt.Sprintf("%s/pullrequest/%d", r.webURL, pr.PullRequestID),
+	}, nil
+}
+
+// getIdentityID gets the id of a user or group, by its email, name or display name
+func (a *AzureDevOps) getIdentityID(ctx context.Context, org, name string) (string, error) {
+	query := url.Values{}
+	query.Set("searchFilter", "General")
+	query.Set("filterValue", name)
+	query.Set("queryMembership", "None")
+
+	var identities struct {
+		Value []struct {
+			ID string `json:"id"`
+		} `json:"value"`
+	}
+	path := fmt.Sprintf("%s/%s/_apis/identities?%s", strings.TrimSuffix(a.identityURL, "/"), url.PathEscape(org), query.Encode())
+	if err := a.do(ctx, http.MethodGet, path, nil, &identities); err != nil {
+		return "", err
+	}
+
+	if len(identities.Value) != 1 {
+		return "", errors.Errorf("found %d identities matching the name", len(identities.Value))
+	}
+	return identities.Value[0].ID, nil
+}
+
+// GetPullRequests gets all pull requests of with a specific branch
+func (a *AzureDevOps) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := a.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		if repo.IsDisabled {
+			continue
+		}
+
+		pr, err := a.getPullRequest(ctx, repo, branchName)
+		if err != nil {
+			return nil, err
+		}
+		if pr == nil {
+			continue
+		}
+
+		status, err := a.pullRequestStatus(ctx, repo, *pr)
+		if err != nil {
+			return nil, err
+		}
+
+		prProject, prRepoID := repo.Project.ID, repo.ID
+		if pr.ForkSource != nil {
+			prProject, prRepoID = pr.ForkSource.Repository.Project.ID, pr.ForkSource.Repository.ID
+		}
+
+		prs = append(prs, pullRequest{
+			organization: repo.organization,
+			project:      repo.Project.Name,
+			repoID:       repo.ID,
+			repoName:     repo.Name,
+			branchName:   branchName,
+			prProject:    prProject,
+			prRepoID:     prRepoID,
+			id:           pr.PullRequestID,
+			webURL:       fmt.Sprintf("%s/pullrequest/%d", repo.WebURL, pr.PullRequestID),
+			status:       status,
+		})
+	}
+
+	return prs, nil
+}
+
+// getPullRequest gets the latest pull request from the branch, if no such pull request exist nil is returned
+func (a *AzureDevOps) getPullRequest(ctx context.Context, repo organizationRepository, branchName string) (*adoPullRequest, error) {
+	query := url.Values{}
+	query.Set("searchCriteria.sourceRefName", "refs/heads/"+branchName)
+	query.Set("searchCriteria.status", "all")
+	query.Set("$top", "1")
+
+	var page struct {
+		Value []adoPullRequest `json:"value"`
+	}
+	path := repoPath(repo.organization, repo.Project.ID, repo.ID) + "/pullrequests?" + query.Encode()
+	if err := a.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+
+	if len(page.Value) == 0 {
+		return nil, nil
+	}
+	return &page.Value[0], nil
+}
+
+func (a *AzureDevOps) pullRequestStatus(ctx context.Context, repo organizationRepository, pr adoPullRequest) (domain.PullRequestStatus, error) {
+	switch pr.Status {
+	case "completed":
+		return domain.PullRequestStatusMerged, nil
+	case "abandoned":
+		return domain.PullRequestStatusClosed, nil
+	}
+
+	var statuses struct {
+		Value []struct {
+			State   string `json:"state"`
+			Context struct {
+				Genre string `json:"genre"`
+				Name  string `json:"name"`
+			} `json:"context"`
+		} `json:"value"`
+	}
+	path := fmt.Sprintf("%s/pullrequests/%d/statuses", repoPath(repo.organization, repo.Project.ID, repo.ID), pr.PullRequestID)
+	if err := a.do(ctx, http.MethodGet, path, nil, &statuses); err != nil {
+		return domain.PullRequestStatusUnknown, err
+	}
+
+	// Statuses are posted again for new iterations of the pull request, the last one of each context is the current
+	states := map[string]string{}
+	for _, status := range statuses.Value {
+		states[status.Context.Genre+"/"+status.Context.Name] = status.State
+	}
+
+	return combinedStatus(states), nil
+}
+
+// combinedStatus combines the states of all statuses into the status of a pull request
+func combinedStatus(states map[string]string) domain.PullRequestStatus {
+	status := domain.PullRequestStatusSuccess
+	for _, state := range states {
+		switch state {
+		case "failed", "error":
+			return domain.PullRequestStatusError
+		case "pending", "notSet":
+			status = domain.PullRequestStatusPending
+		}
+	}
+	return status
+}
+
+// MergePullRequest completes a pull request
+func (a *AzureDevOps) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return a.MergePullRequestWithTypes(ctx, pullReq, a.MergeTypes)
+}
+
+// MergePullRequestWithTypes completes a pull request with the first of the merge types, and deletes the source branch.
+// If the merge type is not allowed by the policies of the branch, Azure DevOps responds with an error
+func (a *AzureDevOps) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
+	pr := pullReq.(pullRequest)
+
+	adoPR, err := a.getPullRequestByID(ctx, pr)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", pr.String())
+	}
+
+	completionOptions := map[string]interface{}{
+		"deleteSourceBranch": true,
+	}
+	if len(mergeTypes) > 0 {
+		completionOptions["mergeStrategy"] = mergeTypeAzureDevOpsName[mergeTypes[0]]
+	}
+
+	path := fmt.Sprintf("%s/pullrequests/%d", repoPath(pr.organization, pr.project, pr.repoID), pr.id)
+	err = a.do(ctx, http.MethodPatch, path, map[string]interface{}{
+		"status": "completed",
+		"lastMergeSourceCommit": map[string]string{
+			"commitId": adoPR.LastMergeSourceCommit.CommitID,
+		},
+		"completionOptions": completionOptions,
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not complete %s", pr.String())
+	}
+
+	return nil
+}
+
+func (a *AzureDevOps) getPullRequestByID(ctx context.Context, pr pullRequest) (adoPullRequest, error) {
+	var adoPR adoPullRequest
+	path := fmt.Sprintf("%s/pullrequests/%d", repoPath(pr.organization, pr.project, pr.repoID), pr.id)
+	err := a.do(ctx, http.MethodGet, path, nil, &adoPR)
+	return adoPR, err
+}
+
+// ClosePullRequest abandons a pull request and deletes its source branch
+func (a *AzureDevOps) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	adoPR, err := a.getPullRequestByID(ctx, pr)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", pr.String())
+	}
+
+	path := fmt.Sprintf("%s/pullrequests/%d", repoPath(pr.organization, pr.project, pr.repoID), pr.id)
+	if err := a.do(ctx, http.MethodPatch, path, map[string]interface{}{"status": "abandoned"}, nil); err != nil {
+		return errors.Wrapf(err, "could not abandon %s", pr.String())
+	}
+
+	// A branch is deleted by updating it to the zero commit
+	var result struct {
+		Value []struct {
+			Success       bool   `json:"success"`
+			CustomMessage string `json:"customMessage"`
+		} `json:"value"`
+	}
+	err = a.do(ctx, http.MethodPost, repoPath(pr.organization, pr.prProject, pr.prRepoID)+"/refs", []map[string]string{{
+		"name":        "refs/heads/" + pr.branchName,
+		"oldObjectId": adoPR.LastMergeSourceCommit.CommitID,
+		"newObjectId": "0000000000000000000000000000000000000000",
+	}}, &result)
+	if err == nil && len(result.Value) > 0 && !result.Value[0].Success {
+		err = errors.New(result.Value[0].CustomMessage)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not delete branch after abandoning %s", pr.String())
+	}
+
+	return nil
+}
+
+// ForkRepository forks a repository into another project of the same organization. newOwner is the name of the project,
+// and has to be set since there is no personal project in Azure DevOps
+func (a *AzureDevOps) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	r := repo.(repository)
+
+	if newOwner == "" {
+		return nil, errors.New("the project to fork to has to be set on Azure DevOps")
+	}
+
+	var existingRepo adoRepository
+	err := a.do(ctx, http.MethodGet, repoPath(r.organization, newOwner, r.name), nil, &existingRepo)
+	if err == nil {
+		existingRepo.DefaultBranch = "refs/heads/" + r.defaultBranch
+		return convertRepository(organizationRepository{organization: r.organization, adoRepository: existingRepo})
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	var project adoProject
+	path := fmt.Sprintf("%s/_apis/projects/%s", url.PathEscape(r.organization), url.PathEscape(newOwner))
+	if err := a.do(ctx, http.MethodGet, path, nil, &project); err != nil {
+		return nil, errors.Wrapf(err, "could not get the project %s", newOwner)
+	}
+
+	var createdRepo adoRepository
+	path = fmt.Sprintf("%s/%s/_apis/git/repositories", url.PathEscape(r.organization), url.PathEscape(project.ID))
+	err = a.do(ctx, http.MethodPost, path, map[string]interface{}{
+		"name":    r.name,
+		"project": map[string]string{"id": project.ID},
+		"parentRepository": map[string]interface{}{
+			"id":      r.id,
+			"project": map[string]string{"id": r.projectID},
+		},
+	}, &createdRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	createdRepo.DefaultBranch = "refs/heads/" + r.defaultBranch
+	return convertRepository(organizationRepository{organization: r.organization, adoRepository: createdRepo})
+}
+
+func convertRepository(repo organizationRepository) (repository, error) {
+	u, err := url.Parse(repo.RemoteURL)
+	if err != nil {
+		return repository{}, err
+	}
+	// The remote url contains the organization as the username, which is replaced when the url is used
+	u.User = nil
+
+	return repository{
+		url:           *u,
+		webURL:        repo.WebURL,
+		organization:  repo.organization,
+		projectID:     repo.Project.ID,
+		project:       repo.Project.Name,
+		id:            repo.ID,
+		name:          repo.Name,
+		defaultBranch: strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"),
+	}, nil
+}
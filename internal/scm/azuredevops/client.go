@@ -0,0 +1,103 @@
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const apiVersion = "6.0"
+
+// apiError is an error returned by the Azure DevOps API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("azure devops responded with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("azure devops responded with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do makes a request to the Azure DevOps API. The path is relative to the base url, or absolute if it starts with a scheme.
+// If body is set, it is sent as JSON, and if result is set, the response is decoded into it
+func (a *AzureDevOps) do(ctx context.Context, method, path string, body, result interface{}) error {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = strings.TrimSuffix(a.baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+	separator := "?"
+	if strings.Contains(u, "?") {
+		separator = "&"
+	}
+	u += separator + "api-version=" + apiVersion
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Personal access tokens are used as the password, with any username
+	req.SetBasicAuth("", a.token)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Unauthenticated requests are responded to with a sign in page instead of an error
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo {
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Message:    "the token is not valid",
+		}
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Message:    errResp.Message,
+		}
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
@@ -0,0 +1,10 @@
+package azuredevops
+
+import "github.com/lindell/multi-gitter/internal/domain"
+
+// maps merge types to the merge strategies in the azure devops api
+var mergeTypeAzureDevOpsName = map[domain.MergeType]string{
+	domain.MergeTypeMerge:  "noFastForward",
+	domain.MergeTypeRebase: "rebase",
+	domain.MergeTypeSquash: "squash",
+}
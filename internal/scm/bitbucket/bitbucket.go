@@ -0,0 +1,588 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+)
+
+const defaultBaseURL = "https://api.bitbucket.org/2.0"
+
+// New create a new Bitbucket Cloud client. If username is set, the token is used as an app password,
+// otherwise it's used as an access token
+func New(
+	username, token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+) (*Bitbucket, error) {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	return &Bitbucket{
+		RepositoryListing: repoListing,
+
+		baseURL:  baseURL,
+		username: username,
+		token:    token,
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+
+		MergeTypes: mergeTypes,
+	}, nil
+}
+
+// Bitbucket contain Bitbucket Cloud configuration
+type Bitbucket struct {
+	RepositoryListing
+
+	baseURL    string
+	username   string
+	token      string
+	httpClient *http.Client
+
+	MergeTypes []domain.MergeType
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Workspaces   []string
+	Repositories []RepositoryReference
+}
+
+// RepositoryReference contains information to be able to reference a repository
+type RepositoryReference struct {
+	Workspace string
+	Slug      string
+}
+
+// ParseRepositoryReference parses a repository reference from the format "workspace/repoSlug"
+func ParseRepositoryReference(val string) (RepositoryReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 2 {
+		return RepositoryReference{}, fmt.Errorf("could not parse repository reference: %s", val)
+	}
+	return RepositoryReference{
+		Workspace: split[0],
+		Slug:      split[1],
+	}, nil
+}
+
+type repository struct {
+	url           url.URL
+	username      string
+	workspace     string
+	slug          string
+	defaultBranch string
+}
+
+func (r repository) URL(token string) string {
+	// App passwords are used together with the username, and access tokens with the x-token-auth user
+	username := r.username
+	if username == "" {
+		username = "x-token-auth"
+	}
+	r.url.User = url.UserPassword(username, token)
+	return r.url.String()
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("%s/%s", r.workspace, r.slug)
+}
+
+type pullRequest struct {
+	workspace   string
+	repoSlug    string
+	branchName  string
+	prWorkspace string
+	prRepoSlug  string
+	id          int
+	webURL      string
+	status      domain.PullRequestStatus
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s/%s #%d", pr.workspace, pr.repoSlug, pr.id)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.workspace, pr.repoSlug)
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr pullRequest) URL() string {
+	return pr.webURL
+}
+
+type bbRepository struct {
+	UUID       string `json:"uuid"`
+	FullName   string `json:"full_name"`
+	Slug       string `json:"slug"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Workspace struct {
+		Slug string `json:"slug"`
+	} `json:"workspace"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bbPullRequest struct {
+	ID     int    `json:"id"`
+	State  string `json:"state"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	} `json:"source"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// GetRepositories fetches repositories from all sources (workspaces/specific repo)
+func (b *Bitbucket) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := b.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.MainBranch == nil {
+			log.Debugf("Skipping %s since it's empty", repo.FullName)
+			continue
+		}
+
+		convertedRepo, err := b.convertRepository(repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+
+	return repos, nil
+}
+
+func (b *Bitbucket) getRepositories(ctx context.Context) ([]bbRepository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos := []bbRepository{}
+
+	for _, workspace := range b.Workspaces {
+		err := b.getAll(ctx, fmt.Sprintf("repositories/%s?pagelen=100", url.PathEscape(workspace)), func(values json.RawMessage) error {
+			var repos []bbRepository
+			if err := json.Unmarshal(values, &repos); err != nil {
+				return err
+			}
+			allRepos = append(allRepos, repos...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ref := range b.Repositories {
+		var repo bbRepository
+		if err := b.do(ctx, http.MethodGet, repoPath(ref.Workspace, ref.Slug), nil, &repo); err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repo)
+	}
+
+	// Remove duplicate repos
+	repoMap := map[string]bbRepository{}
+	for _, repo := range allRepos {
+		repoMap[repo.UUID] = repo
+	}
+	allRepos = make([]bbRepository, 0, len(repoMap))
+	for _, repo := range repoMap {
+		allRepos = append(allRepos, repo)
+	}
+	sort.Slice(allRepos, func(i, j int) bool {
+		return allRepos[i].FullName < allRepos[j].FullName
+	})
+
+	return allRepos, nil
+}
+
+func repoPath(workspace, slug string) string {
+	return fmt.Sprintf("repositories/%s/%s", url.PathEscape(workspace), url.PathEscape(slug))
+}
+
+// CreatePullRequest creates a pull request
+func (b *Bitbucket) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+	prR := prRepo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on Bitbucket Cloud")
+	}
+	if len(newPR.Labels) > 0 {
+		logger.Warn("Labels are not supported on Bitbucket Cloud")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on Bitbucket Cloud")
+	}
+
+	users, teams := domain.SplitReviewers(newPR.Reviewers)
+	if len(teams) > 0 {
+		logger.Warn("Team reviewers are not supported on Bitbucket Cloud")
+	}
+	reviewers := make([]map[string]string, len(users))
+	for i, user := range users {
+		reviewers[i] = reviewerReference(user)
+	}
+
+	// Default reviewers are only added automatically to pull requests created in the web interface
+	if domain.HasDefaultReviewers(newPR.Reviewers) {
+		defaultReviewers, err := b.getDefaultReviewers(ctx, r, users)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get the default reviewers")
+		}
+		reviewers = append(reviewers, defaultReviewers...)
+	}
+
+	body := map[string]interface{}{
+		"title":       newPR.Title,
+		"description": newPR.Body,
+		"source": map[string]interface{}{
+			"branch": map[string]string{
+				"name": newPR.Head,
+			},
+			"repository": map[string]string{
+				"full_name": prR.FullName(),
+			},
+		},
+		"destination": map[string]interface{}{
+			"branch": map[string]string{
+				"name": newPR.Base,
+			},
+		},
+		"reviewers":           reviewers,
+		"close_source_branch": true,
+		"draft":               newPR.Draft,
+	}
+
+	var pr bbPullRequest
+	if err := b.do(ctx, http.MethodPost, repoPath(r.workspace, r.slug)+"/pullrequests", body, &pr); err != nil {
+		return nil, errors.Wrap(err, "could not create pull request")
+	}
+
+	return pullRequest{
+		workspace:   r.workspace,
+		repoSlug:    r.slug,
+		branchName:  newPR.Head,
+		prWorkspace: prR.workspace,
+		prRepoSlug:  prR.slug,
+		id:          pr.ID,
+		webURL:      pr.Links.HTML.Href,
+	}, nil
+}
+
+// getDefaultReviewers gets the default reviewers of a repository, except the current user, who can't review its own pull request,
+// and the users that are already reviewers
+func (b *Bitbucket) getDefaultReviewers(ctx context.Context, r repository, users []string) ([]map[string]string, error) {
+	var currentUser bbUser
+	if err := b.do(ctx, http.MethodGet, "user", nil, &currentUser); err != nil {
+		return nil, errors.Wrap(err, "could not get the current user")
+	}
+
+	var reviewers []map[string]string
+	err := b.getAll(ctx, repoPath(r.workspace, r.slug)+"/effective-default-reviewers?pagelen=100", func(values json.RawMessage) error {
+		var defaultReviewers []struct {
+			User bbUser `json:"user"`
+		}
+		if err := json.Unmarshal(values, &defaultReviewers); err != nil {
+			return err
+		}
+		for _, reviewer := range defaultReviewers {
+			if reviewer.User.UUID == currentUser.UUID || containsUser(users, reviewer.User) {
+				continue
+			}
+			reviewers = append(reviewers, map[string]string{"uuid": reviewer.User.UUID})
+		}
+		return nil
+	})
+	return reviewers, err
+}
+
+type bbUser struct {
+	UUID      string `json:"uuid"`
+	AccountID string `json:"account_id"`
+}
+
+// containsUser returns if the user is referenced by its uuid or account id
+func containsUser(users []string, user bbUser) bool {
+	for _, u := range users {
+		if u == user.UUID || u == user.AccountID {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewerReference references a user either by its uuid, in the format "{uuid}", or its account id
+func reviewerReference(user string) map[string]string {
+	if strings.HasPrefix(user, "{") {
+		return map[string]string{"uuid": user}
+	}
+	return map[string]string{"account_id": user}
+}
+
+// GetPullRequests gets all pull requests of with a specific branch
+func (b *Bitbucket) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := b.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		pr, err := b.getPullRequest(ctx, repo, branchName)
+		if err != nil {
+			return nil, err
+		}
+		if pr == nil {
+			continue
+		}
+
+		status, err := b.pullRequestStatus(ctx, repo, *pr)
+		if err != nil {
+			return nil, err
+		}
+
+		prWorkspace, prRepoSlug := splitFullName(pr.Source.Repository.FullName)
+		prs = append(prs, pullRequest{
+			workspace:   repo.Workspace.Slug,
+			repoSlug:    repo.Slug,
+			branchName:  branchName,
+			prWorkspace: prWorkspace,
+			prRepoSlug:  prRepoSlug,
+			id:          pr.ID,
+			webURL:      pr.Links.HTML.Href,
+			status:      status,
+		})
+	}
+
+	return prs, nil
+}
+
+// getPullRequest gets the latest pull request with the branch as source, if no such pull request exist nil is returned
+func (b *Bitbucket) getPullRequest(ctx context.Context, repo bbRepository, branchName string) (*bbPullRequest, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf(`source.branch.name = "%s"`, branchName))
+	query.Set("sort", "-updated_on")
+	query.Set("pagelen", "1")
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED", "SUPERSEDED"} {
+		query.Add("state", state)
+	}
+
+	var page struct {
+		Values []bbPullRequest `json:"values"`
+	}
+	path := repoPath(repo.Workspace.Slug, repo.Slug) + "/pullrequests?" + query.Encode()
+	if err := b.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return &page.Values[0], nil
+}
+
+func (b *Bitbucket) pullRequestStatus(ctx context.Context, repo bbRepository, pr bbPullRequest) (domain.PullRequestStatus, error) {
+	switch pr.State {
+	case "MERGED":
+		return domain.PullRequestStatusMerged, nil
+	case "DECLINED", "SUPERSEDED":
+		return domain.PullRequestStatusClosed, nil
+	}
+
+	var states []string
+	path := fmt.Sprintf("%s/pullrequests/%d/statuses?pagelen=100", repoPath(repo.Workspace.Slug, repo.Slug), pr.ID)
+	err := b.getAll(ctx, path, func(values json.RawMessage) error {
+		var statuses []struct {
+			State string `json:"state"`
+		}
+		if err := json.Unmarshal(values, &statuses); err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			states = append(states, status.State)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.PullRequestStatusUnknown, err
+	}
+
+	return combinedStatus(states), nil
+}
+
+// combinedStatus combines the states of all commit statuses into the status of a pull request
+func combinedStatus(states []string) domain.PullRequestStatus {
+	status := domain.PullRequestStatusSuccess
+	for _, state := range states {
+		switch state {
+		case "FAILED", "STOPPED":
+			return domain.PullRequestStatusError
+		case "INPROGRESS":
+			status = domain.PullRequestStatusPending
+		}
+	}
+	return status
+}
+
+// MergePullRequest merges a pull request
+func (b *Bitbucket) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return b.MergePullRequestWithTypes(ctx, pullReq, b.MergeTypes)
+}
+
+// MergePullRequestWithTypes merges a pull request with the first of the merge types.
+// Which merge types that are allowed in a repository can't be read from Bitbucket Cloud
+func (b *Bitbucket) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
+	pr := pullReq.(pullRequest)
+
+	body := map[string]interface{}{
+		"close_source_branch": true,
+	}
+	if len(mergeTypes) > 0 {
+		body["merge_strategy"] = mergeTypeBitbucketName[mergeTypes[0]]
+	}
+
+	path := fmt.Sprintf("%s/pullrequests/%d/merge", repoPath(pr.workspace, pr.repoSlug), pr.id)
+	if err := b.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		return errors.Wrapf(err, "could not merge %s", pr.String())
+	}
+
+	return nil
+}
+
+// ClosePullRequest closes a pull request
+func (b *Bitbucket) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	path := fmt.Sprintf("%s/pullrequests/%d/decline", repoPath(pr.workspace, pr.repoSlug), pr.id)
+	if err := b.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return errors.Wrapf(err, "could not close %s", pr.String())
+	}
+
+	path = fmt.Sprintf("%s/refs/branches/%s", repoPath(pr.prWorkspace, pr.prRepoSlug), url.PathEscape(pr.branchName))
+	if err := b.do(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		return errors.Wrapf(err, "could not delete branch after closing %s", pr.String())
+	}
+
+	return nil
+}
+
+// ForkRepository forks a repository. If newOwner is empty, fork to the workspace of the logged in user
+func (b *Bitbucket) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	r := repo.(repository)
+
+	forkTo := newOwner
+	if forkTo == "" {
+		var user struct {
+			Username string `json:"username"`
+		}
+		if err := b.do(ctx, http.MethodGet, "user", nil, &user); err != nil {
+			return nil, errors.Wrap(err, "could not get the current user")
+		}
+		forkTo = user.Username
+	}
+
+	var existingRepo bbRepository
+	err := b.do(ctx, http.MethodGet, repoPath(forkTo, r.slug), nil, &existingRepo)
+	if err == nil {
+		return b.convertRepository(existingRepo)
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	var createdRepo bbRepository
+	err = b.do(ctx, http.MethodPost, repoPath(r.workspace, r.slug)+"/forks", map[string]interface{}{
+		"workspace": map[string]string{
+			"slug": forkTo,
+		},
+	}, &createdRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.convertRepository(createdRepo)
+}
+
+func (b *Bitbucket) convertRepository(repo bbRepository) (repository, error) {
+	var cloneURL string
+	for _, link := range repo.Links.Clone {
+		if link.Name == "https" {
+			cloneURL = link.Href
+		}
+	}
+	if cloneURL == "" {
+		return repository{}, errors.Errorf("could not find a https clone url of %s", repo.FullName)
+	}
+
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return repository{}, err
+	}
+	// The clone url contains the username, which is replaced when the url is used
+	u.User = nil
+
+	defaultBranch := ""
+	if repo.MainBranch != nil {
+		defaultBranch = repo.MainBranch.Name
+	}
+
+	return repository{
+		url:           *u,
+		username:      b.username,
+		workspace:     repo.Workspace.Slug,
+		slug:          repo.Slug,
+		defaultBranch: defaultBranch,
+	}, nil
+}
+
+func splitFullName(fullName string) (string, string) {
+	split := strings.SplitN(fullName, "/", 2)
+	if len(split) != 2 {
+		return fullName, ""
+	}
+	return split[0], split[1]
+}
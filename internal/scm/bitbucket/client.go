@@ -0,0 +1,112 @@
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// apiError is an error returned by the Bitbucket API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bitbucket responded with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("bitbucket responded with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do makes a request to the Bitbucket API. The path can either be relative to the base url or a full url.
+// If body is set, it is sent as JSON, and if result is set, the response is decoded into it
+func (b *Bitbucket) do(ctx context.Context, method, path string, body, result interface{}) error {
+	u := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		u = strings.TrimSuffix(b.baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Message:    errResp.Error.Message,
+		}
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// getAll gets all values of a paginated resource. each is called with every page of values
+func (b *Bitbucket) getAll(ctx context.Context, path string, each func(values json.RawMessage) error) error {
+	next := path
+	for next != "" {
+		var page struct {
+			Values json.RawMessage `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if err := b.do(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return err
+		}
+		if err := each(page.Values); err != nil {
+			return err
+		}
+		next = page.Next
+	}
+	return nil
+}
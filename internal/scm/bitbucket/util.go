@@ -0,0 +1,10 @@
+package bitbucket
+
+import "github.com/lindell/multi-gitter/internal/domain"
+
+// maps merge types to what they are called in the bitbucket api
+var mergeTypeBitbucketName = map[domain.MergeType]string{
+	domain.MergeTypeMerge:  "merge_commit",
+	domain.MergeTypeRebase: "fast_forward",
+	domain.MergeTypeSquash: "squash",
+}
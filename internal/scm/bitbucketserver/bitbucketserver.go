@@ -0,0 +1,625 @@
+package bitbucketserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+)
+
+// New create a new Bitbucket Server (or Data Center) client. If username is set, the token is used
+// together with it as a password, otherwise it's used as an HTTP access token
+func New(
+	username, token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+) (*BitbucketServer, error) {
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	return &BitbucketServer{
+		RepositoryListing: repoListing,
+
+		baseURL:  baseURL,
+		username: username,
+		token:    token,
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+
+		MergeTypes: mergeTypes,
+	}, nil
+}
+
+// BitbucketServer contain Bitbucket Server configuration
+type BitbucketServer struct {
+	RepositoryListing
+
+	baseURL    string
+	username   string
+	token      string
+	httpClient *http.Client
+
+	MergeTypes []domain.MergeType
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Projects     []string // The keys of projects
+	Users        []string
+	Repositories []RepositoryReference
+}
+
+// RepositoryReference contains information to be able to reference a repository
+type RepositoryReference struct {
+	ProjectKey string
+	Slug       string
+}
+
+// ParseRepositoryReference parses a repository reference from the format "projectKey/repoSlug"
+func ParseRepositoryReference(val string) (RepositoryReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 2 {
+		return RepositoryReference{}, fmt.Errorf("could not parse repository reference: %s", val)
+	}
+	return RepositoryReference{
+		ProjectKey: split[0],
+		Slug:       split[1],
+	}, nil
+}
+
+type repository struct {
+	url           url.URL
+	username      string
+	id            int
+	projectKey    string
+	slug          string
+	defaultBranch string
+}
+
+func (r repository) URL(token string) string {
+	// Tokens are used together with the username, and HTTP access tokens of projects and repositories with the x-token-auth user
+	username := r.username
+	if username == "" {
+		username = "x-token-auth"
+	}
+	r.url.User = url.UserPassword(username, token)
+	return r.url.String()
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("%s/%s", r.projectKey, r.slug)
+}
+
+type pullRequest struct {
+	projectKey   string
+	repoSlug     string
+	branchName   string
+	prProjectKey string
+	prRepoSlug   string
+	id           int
+	webURL       string
+	status       domain.PullRequestStatus
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s/%s #%d", pr.projectKey, pr.repoSlug, pr.id)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.projectKey, pr.repoSlug)
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr pullRequest) URL() string {
+	return pr.webURL
+}
+
+type bbsRepository struct {
+	ID      int    `json:"id"`
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Links struct {
+		Clone []struct {
+			Href string `json:"href"`
+			Name string `json:"name"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bbsRef struct {
+	ID           string        `json:"id"`
+	DisplayID    string        `json:"displayId"`
+	LatestCommit string        `json:"latestCommit"`
+	Repository   bbsRepository `json:"repository"`
+}
+
+type bbsPullRequest struct {
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+	State   string `json:"state"`
+	FromRef bbsRef `json:"fromRef"`
+	Links   struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (pr bbsPullRequest) webURL() string {
+	if len(pr.Links.Self) == 0 {
+		return ""
+	}
+	return pr.Links.Self[0].Href
+}
+
+// GetRepositories fetches repositories from all sources (projects/users/specific repo)
+func (b *BitbucketServer) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := b.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		defaultBranch, err := b.getDefaultBranch(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		if defaultBranch == "" {
+			log.Debugf("Skipping %s/%s since it's empty", repo.Project.Key, repo.Slug)
+			continue
+		}
+
+		convertedRepo, err := b.convertRepository(repo, defaultBranch)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+
+	return repos, nil
+}
+
+func (b *BitbucketServer) getRepositories(ctx context.Context) ([]bbsRepository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos := []bbsRepository{}
+
+	addPage := func(values json.RawMessage) error {
+		var repos []bbsRepository
+		if err := json.Unmarshal(values, &repos); err != nil {
+			return err
+		}
+		allRepos = append(allRepos, repos...)
+		return nil
+	}
+
+	for _, project := range b.Projects {
+		if err := b.getAll(ctx, fmt.Sprintf("rest/api/1.0/projects/%s/repos", url.PathEscape(project)), addPage); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, user := range b.Users {
+		if err := b.getAll(ctx, fmt.Sprintf("rest/api/1.0/users/%s/repos", url.PathEscape(user)), addPage); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, ref := range b.Repositories {
+		var repo bbsRepository
+		if err := b.do(ctx, http.MethodGet, repoPath(ref.ProjectKey, ref.Slug), nil, &repo); err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repo)
+	}
+
+	// Remove duplicate repos
+	repoMap := map[int]bbsRepository{}
+	for _, repo := range allRepos {
+		repoMap[repo.ID] = repo
+	}
+	allRepos = make([]bbsRepository, 0, len(repoMap))
+	for _, repo := range repoMap {
+		allRepos = append(allRepos, repo)
+	}
+	sort.Slice(allRepos, func(i, j int) bool {
+		return allRepos[i].ID < allRepos[j].ID
+	})
+
+	return allRepos, nil
+}
+
+// getDefaultBranch gets the name of the default branch of a repository, or an empty string if the repository is empty
+func (b *BitbucketServer) getDefaultBranch(ctx context.Context, repo bbsRepository) (string, error) {
+	var ref bbsRef
+	err := b.do(ctx, http.MethodGet, repoPath(repo.Project.Key, repo.Slug)+"/branches/default", nil, &ref)
+	if isNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "could not get the default branch of %s/%s", repo.Project.Key, repo.Slug)
+	}
+	return ref.DisplayID, nil
+}
+
+func repoPath(projectKey, slug string) string {
+	return fmt.Sprintf("rest/api/1.0/projects/%s/repos/%s", url.PathEscape(projectKey), url.PathEscape(slug))
+}
+
+func repoRef(r repository) map[string]interface{} {
+	return map[string]interface{}{
+		"slug": r.slug,
+		"project": map[string]string{
+			"key": r.projectKey,
+		},
+	}
+}
+
+// CreatePullRequest creates a pull request
+func (b *BitbucketServer) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+	prR := prRepo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on Bitbucket Server")
+	}
+	if len(newPR.Labels) > 0 {
+		logger.Warn("Labels are not supported on Bitbucket Server")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on Bitbucket Server")
+	}
+
+	users, teams := domain.SplitReviewers(newPR.Reviewers)
+	if len(teams) > 0 {
+		logger.Warn("Team reviewers are not supported on Bitbucket Server")
+	}
+	// Default reviewers are only added automatically to pull requests created in the web interface
+	if domain.HasDefaultReviewers(newPR.Reviewers) {
+		defaultReviewers, err := b.getDefaultReviewers(ctx, r, prR, newPR)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get the default reviewers")
+		}
+		users = appendMissing(users, defaultReviewers...)
+	}
+	reviewers := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		reviewers[i] = map[string]interface{}{
+			"user": map[string]string{
+				"name": user,
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"title":       newPR.Title,
+		"description": newPR.Body,
+		"fromRef": map[string]interface{}{
+			"id":         "refs/heads/" + newPR.Head,
+			"repository": repoRef(prR),
+		},
+		"toRef": map[string]interface{}{
+			"id":         "refs/heads/" + newPR.Base,
+			"repository": repoRef(r),
+		},
+		"reviewers": reviewers,
+		"draft":     newPR.Draft,
+	}
+
+	var pr bbsPullRequest
+	if err := b.do(ctx, http.MethodPost, repoPath(r.projectKey, r.slug)+"/pull-requests", body, &pr); err != nil {
+		return nil, errors.Wrap(err, "could not create pull request")
+	}
+
+	return pullRequest{
+		projectKey:   r.projectKey,
+		repoSlug:     r.slug,
+		branchName:   newPR.Head,
+		prProjectKey: prR.projectKey,
+		prRepoSlug:   prR.slug,
+		id:           pr.ID,
+		webURL:       pr.webURL(),
+	}, nil
+}
+
+// getDefaultReviewers gets the names of the default reviewers of a pull request, which depends on its branches.
+// The current user is left out, since the author of a pull request can't review it
+func (b *BitbucketServer) getDefaultReviewers(ctx context.Context, repo, prRepo repository, newPR domain.NewPullRequest) ([]string, error) {
+	query := url.Values{}
+	query.Set("sourceRepoId", strconv.Itoa(prRepo.id))
+	query.Set("targetRepoId", strconv.Itoa(repo.id))
+	query.Set("sourceRefId", "refs/heads/"+newPR.Head)
+	query.Set("targetRefId", "refs/heads/"+newPR.Base)
+
+	var users []struct {
+		Name string `json:"name"`
+	}
+	path := fmt.Sprintf("rest/default-reviewers/1.0/projects/%s/repos/%s/reviewers?%s", url.PathEscape(repo.projectKey), url.PathEscape(repo.slug), query.Encode())
+	if err := b.do(ctx, http.MethodGet, path, nil, &users); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, user := range users {
+		if user.Name != b.username {
+			names = append(names, user.Name)
+		}
+	}
+	return names, nil
+}
+
+// appendMissing appends the values that are not already in the slice
+func appendMissing(slice []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, s := range slice {
+			if s == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
+
+// GetPullRequests gets all pull requests of with a specific branch
+func (b *BitbucketServer) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := b.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		pr, err := b.getPullRequest(ctx, repo, branchName)
+		if err != nil {
+			return nil, err
+		}
+		if pr == nil {
+			continue
+		}
+
+		status, err := b.pullRequestStatus(ctx, *pr)
+		if err != nil {
+			return nil, err
+		}
+
+		prs = append(prs, pullRequest{
+			projectKey:   repo.Project.Key,
+			repoSlug:     repo.Slug,
+			branchName:   branchName,
+			prProjectKey: pr.FromRef.Repository.Project.Key,
+			prRepoSlug:   pr.FromRef.Repository.Slug,
+			id:           pr.ID,
+			webURL:       pr.webURL(),
+			status:       status,
+		})
+	}
+
+	return prs, nil
+}
+
+// getPullRequest gets the latest pull request from the branch, if no such pull request exist nil is returned
+func (b *BitbucketServer) getPullRequest(ctx context.Context, repo bbsRepository, branchName string) (*bbsPullRequest, error) {
+	// With the outgoing direction, pull requests are matched by their source branch
+	query := url.Values{}
+	query.Set("state", "ALL")
+	query.Set("direction", "OUTGOING")
+	query.Set("at", "refs/heads/"+branchName)
+	query.Set("order", "NEWEST")
+	query.Set("limit", "1")
+
+	var page struct {
+		Values []bbsPullRequest `json:"values"`
+	}
+	path := repoPath(repo.Project.Key, repo.Slug) + "/pull-requests?" + query.Encode()
+	if err := b.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	return &page.Values[0], nil
+}
+
+func (b *BitbucketServer) pullRequestStatus(ctx context.Context, pr bbsPullRequest) (domain.PullRequestStatus, error) {
+	switch pr.State {
+	case "MERGED":
+		return domain.PullRequestStatusMerged, nil
+	case "DECLINED":
+		return domain.PullRequestStatusClosed, nil
+	}
+
+	var states []string
+	path := fmt.Sprintf("rest/build-status/1.0/commits/%s", url.PathEscape(pr.FromRef.LatestCommit))
+	err := b.getAll(ctx, path, func(values json.RawMessage) error {
+		var statuses []struct {
+			State string `json:"state"`
+		}
+		if err := json.Unmarshal(values, &statuses); err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			states = append(states, status.State)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.PullRequestStatusUnknown, err
+	}
+
+	return combinedStatus(states), nil
+}
+
+// combinedStatus combines the states of all build statuses into the status of a pull request
+func combinedStatus(states []string) domain.PullRequestStatus {
+	status := domain.PullRequestStatusSuccess
+	for _, state := range states {
+		switch state {
+		case "FAILED":
+			return domain.PullRequestStatusError
+		case "INPROGRESS":
+			status = domain.PullRequestStatusPending
+		}
+	}
+	return status
+}
+
+// getPullRequestVersion gets the current version of a pull request, which is needed to change it
+func (b *BitbucketServer) getPullRequestVersion(ctx context.Context, pr pullRequest) (int, error) {
+	var bbsPR bbsPullRequest
+	path := fmt.Sprintf("%s/pull-requests/%d", repoPath(pr.projectKey, pr.repoSlug), pr.id)
+	if err := b.do(ctx, http.MethodGet, path, nil, &bbsPR); err != nil {
+		return 0, err
+	}
+	return bbsPR.Version, nil
+}
+
+// MergePullRequest merges a pull request
+func (b *BitbucketServer) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return b.MergePullRequestWithTypes(ctx, pullReq, b.MergeTypes)
+}
+
+// MergePullRequestWithTypes merges a pull request with the first of the merge types. If the merge type
+// is not allowed in the repository, Bitbucket Server responds with an error
+func (b *BitbucketServer) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
+	pr := pullReq.(pullRequest)
+
+	version, err := b.getPullRequestVersion(ctx, pr)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", pr.String())
+	}
+
+	body := map[string]interface{}{}
+	if len(mergeTypes) > 0 {
+		body["strategyId"] = mergeTypeBitbucketServerName[mergeTypes[0]]
+	}
+
+	path := fmt.Sprintf("%s/pull-requests/%d/merge?version=%d", repoPath(pr.projectKey, pr.repoSlug), pr.id, version)
+	if err := b.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		return errors.Wrapf(err, "could not merge %s", pr.String())
+	}
+
+	if err := b.deleteBranch(ctx, pr); err != nil {
+		return errors.Wrapf(err, "could not delete branch after merging %s", pr.String())
+	}
+
+	return nil
+}
+
+// ClosePullRequest closes (declines) a pull request
+func (b *BitbucketServer) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	version, err := b.getPullRequestVersion(ctx, pr)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", pr.String())
+	}
+
+	path := fmt.Sprintf("%s/pull-requests/%d/decline?version=%d", repoPath(pr.projectKey, pr.repoSlug), pr.id, version)
+	if err := b.do(ctx, http.MethodPost, path, map[string]interface{}{}, nil); err != nil {
+		return errors.Wrapf(err, "could not close %s", pr.String())
+	}
+
+	if err := b.deleteBranch(ctx, pr); err != nil {
+		return errors.Wrapf(err, "could not delete branch after closing %s", pr.String())
+	}
+
+	return nil
+}
+
+func (b *BitbucketServer) deleteBranch(ctx context.Context, pr pullRequest) error {
+	path := fmt.Sprintf("rest/branch-utils/1.0/projects/%s/repos/%s/branches",
+		url.PathEscape(pr.prProjectKey), url.PathEscape(pr.prRepoSlug))
+	return b.do(ctx, http.MethodDelete, path, map[string]interface{}{
+		"name": "refs/heads/" + pr.branchName,
+	}, nil)
+}
+
+// ForkRepository forks a repository. If newOwner is empty, fork to the personal project of the logged in user
+func (b *BitbucketServer) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	r := repo.(repository)
+
+	forkTo := newOwner
+	if forkTo == "" {
+		if b.username == "" {
+			return nil, errors.New("a username has to be set to fork to the personal project of the user")
+		}
+		forkTo = "~" + b.username
+	}
+
+	var existingRepo bbsRepository
+	err := b.do(ctx, http.MethodGet, repoPath(forkTo, r.slug), nil, &existingRepo)
+	if err == nil {
+		return b.convertRepository(existingRepo, r.defaultBranch)
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	var createdRepo bbsRepository
+	err = b.do(ctx, http.MethodPost, repoPath(r.projectKey, r.slug), map[string]interface{}{
+		"project": map[string]string{
+			"key": forkTo,
+		},
+	}, &createdRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.convertRepository(createdRepo, r.defaultBranch)
+}
+
+func (b *BitbucketServer) convertRepository(repo bbsRepository, defaultBranch string) (repository, error) {
+	var cloneURL string
+	for _, link := range repo.Links.Clone {
+		if link.Name == "http" || link.Name == "https" {
+			cloneURL = link.Href
+		}
+	}
+	if cloneURL == "" {
+		return repository{}, errors.Errorf("could not find a http clone url of %s/%s", repo.Project.Key, repo.Slug)
+	}
+
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return repository{}, err
+	}
+	// The clone url contains the username, which is replaced when the url is used
+	u.User = nil
+
+	return repository{
+		url:           *u,
+		username:      b.username,
+		id:            repo.ID,
+		projectKey:    repo.Project.Key,
+		slug:          repo.Slug,
+		defaultBranch: defaultBranch,
+	}, nil
+}
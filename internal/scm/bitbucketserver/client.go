@@ -0,0 +1,121 @@
+package bitbucketserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// apiError is an error returned by the Bitbucket Server API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bitbucket server responded with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("bitbucket server responded with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do makes a request to the Bitbucket Server API. The path is relative to the base url.
+// If body is set, it is sent as JSON, and if result is set, the response is decoded into it
+func (b *BitbucketServer) do(ctx context.Context, method, path string, body, result interface{}) error {
+	u := strings.TrimSuffix(b.baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		messages := make([]string, len(errResp.Errors))
+		for i, e := range errResp.Errors {
+			messages[i] = e.Message
+		}
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.Join(messages, ", "),
+		}
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// getAll gets all values of a paged resource. each is called with every page of values
+func (b *BitbucketServer) getAll(ctx context.Context, path string, each func(values json.RawMessage) error) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	start := 0
+	for {
+		var page struct {
+			Values        json.RawMessage `json:"values"`
+			IsLastPage    bool            `json:"isLastPage"`
+			NextPageStart int             `json:"nextPageStart"`
+		}
+		if err := b.do(ctx, http.MethodGet, fmt.Sprintf("%s%slimit=100&start=%d", path, separator, start), nil, &page); err != nil {
+			return err
+		}
+		if err := each(page.Values); err != nil {
+			return err
+		}
+		if page.IsLastPage {
+			return nil
+		}
+		start = page.NextPageStart
+	}
+}
@@ -0,0 +1,10 @@
+package bitbucketserver
+
+import "github.com/lindell/multi-gitter/internal/domain"
+
+// maps merge types to the ids of the merge strategies in the bitbucket server api
+var mergeTypeBitbucketServerName = map[domain.MergeType]string{
+	domain.MergeTypeMerge:  "no-ff",
+	domain.MergeTypeRebase: "rebase-ff-only",
+	domain.MergeTypeSquash: "squash",
+}
@@ -0,0 +1,85 @@
+package codecommit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+)
+
+const targetPrefix = "CodeCommit_20150413."
+
+// apiError is an error returned by the CodeCommit API
+type apiError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("codecommit responded with status code %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+func isErrorType(err error, errorType string) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.Type == errorType
+}
+
+// call calls an action of the CodeCommit API. The input is sent as JSON, and if output is set, the response is decoded into it
+func (c *CodeCommit) call(ctx context.Context, action string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetPrefix+action)
+	if err := aws.SignRequest(ctx, req, body, c.credentials, c.region, serviceName, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		// The type can be prefixed with the namespace of the service
+		if i := strings.LastIndex(errResp.Type, "#"); i >= 0 {
+			errResp.Type = errResp.Type[i+1:]
+		}
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Type:       errResp.Type,
+			Message:    errResp.Message,
+		}
+	}
+
+	if output == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, output)
+}
@@ -0,0 +1,498 @@
+package codecommit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// New create a new AWS CodeCommit client. If endpoint is empty, the public endpoint of the region is used
+func New(
+	credentials aws.Credentials,
+	region, endpoint string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+) (*CodeCommit, error) {
+	if region == "" {
+		return nil, errors.New("no AWS region set")
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://codecommit.%s.amazonaws.com/", region)
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, errors.Wrap(err, "could not parse the endpoint")
+	}
+
+	return &CodeCommit{
+		RepositoryListing: repoListing,
+
+		credentials: credentials,
+		region:      region,
+		endpoint:    endpoint,
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+
+		MergeTypes: mergeTypes,
+	}, nil
+}
+
+// CodeCommit contain AWS CodeCommit configuration
+type CodeCommit struct {
+	RepositoryListing
+
+	credentials aws.Credentials
+	region      string
+	endpoint    string
+	httpClient  *http.Client
+
+	MergeTypes []domain.MergeType
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Repositories []string // The names of repositories, if empty all repositories of the account in the region are used
+}
+
+type repository struct {
+	url           url.URL
+	credentials   aws.Credentials
+	region        string
+	accountID     string
+	name          string
+	defaultBranch string
+}
+
+// URL returns the HTTPS url with credentials signed with the AWS credentials, the token is not used.
+// The credentials expire, so a new url is set on the remote before it is pushed to
+func (r repository) URL(token string) string {
+	username, password := gitCredentials(r.credentials, r.region, r.url.Host, r.url.Path, time.Now().UTC())
+	r.url.User = url.UserPassword(username, password)
+	return r.url.String()
+}
+
+// URLExpires returns true, since the credentials of the url are only valid for a short time after it is created
+func (r repository) URLExpires() bool {
+	return true
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("%s/%s", r.accountID, r.name)
+}
+
+type pullRequest struct {
+	region     string
+	accountID  string
+	repoName   string
+	branchName string
+	id         string
+	status     domain.PullRequestStatus
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s #%s", pr.repoName, pr.id)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.accountID, pr.repoName)
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr pullRequest) URL() string {
+	return fmt.Sprintf("https://%[1]s.console.aws.amazon.com/codesuite/codecommit/repositories/%[2]s/pull-requests/%[3]s?region=%[1]s",
+		pr.region, url.PathEscape(pr.repoName), pr.id)
+}
+
+type ccRepository struct {
+	AccountID      string `json:"accountId"`
+	RepositoryName string `json:"repositoryName"`
+	DefaultBranch  string `json:"defaultBranch"`
+	CloneURLHTTP   string `json:"cloneUrlHttp"`
+}
+
+type ccPullRequest struct {
+	PullRequestID      string `json:"pullRequestId"`
+	PullRequestStatus  string `json:"pullRequestStatus"`
+	RevisionID         string `json:"revisionId"`
+	PullRequestTargets []struct {
+		RepositoryName  string `json:"repositoryName"`
+		SourceReference string `json:"sourceReference"`
+		SourceCommit    string `json:"sourceCommit"`
+		MergeMetadata   struct {
+			IsMerged bool `json:"isMerged"`
+		} `json:"mergeMetadata"`
+	} `json:"pullRequestTargets"`
+}
+
+// GetRepositories fetches the repositories
+func (c *CodeCommit) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := c.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.DefaultBranch == "" {
+			log.Debugf("Skipping %s since it's empty", repo.RepositoryName)
+			continue
+		}
+
+		convertedRepo, err := c.convertRepository(repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+
+	return repos, nil
+}
+
+func (c *CodeCommit) getRepositories(ctx context.Context) ([]ccRepository, error) {
+	names := c.Repositories
+	if len(names) == 0 {
+		var err error
+		names, err = c.listRepositoryNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Repositories are fetched in batches, since the listing does not include any details
+	const batchSize = 25
+	var repos []ccRepository
+	for start := 0; start < len(names); start += batchSize {
+		end := start + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		var output struct {
+			Repositories         []ccRepository `json:"repositories"`
+			RepositoriesNotFound []string       `json:"repositoriesNotFound"`
+		}
+		err := c.call(ctx, "BatchGetRepositories", map[string]interface{}{
+			"repositoryNames": names[start:end],
+		}, &output)
+		if err != nil {
+			return nil, err
+		}
+		if len(output.RepositoriesNotFound) > 0 {
+			return nil, errors.Errorf("could not find the repositories: %v", output.RepositoriesNotFound)
+		}
+		repos = append(repos, output.Repositories...)
+	}
+
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].RepositoryName < repos[j].RepositoryName
+	})
+
+	return repos, nil
+}
+
+func (c *CodeCommit) listRepositoryNames(ctx context.Context) ([]string, error) {
+	var names []string
+	nextToken := ""
+	for {
+		input := map[string]interface{}{}
+		if nextToken != "" {
+			input["nextToken"] = nextToken
+		}
+
+		var output struct {
+			Repositories []struct {
+				RepositoryName string `json:"repositoryName"`
+			} `json:"repositories"`
+			NextToken string `json:"nextToken"`
+		}
+		if err := c.call(ctx, "ListRepositories", input, &output); err != nil {
+			return nil, errors.Wrap(err, "could not list repositories")
+		}
+		for _, repo := range output.Repositories {
+			names = append(names, repo.RepositoryName)
+		}
+
+		if output.NextToken == "" {
+			return names, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+// CreatePullRequest creates a pull request
+func (c *CodeCommit) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Reviewers) > 0 {
+		logger.Warn("Reviewers are not supported on CodeCommit, use approval rule templates instead")
+	}
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on CodeCommit")
+	}
+	if len(newPR.Labels) > 0 {
+		logger.Warn("Labels are not supported on CodeCommit")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on CodeCommit")
+	}
+	if newPR.Draft {
+		logger.Warn("Draft pull requests are not supported on CodeCommit")
+	}
+
+	var output struct {
+		PullRequest ccPullRequest `json:"pullRequest"`
+	}
+	err := c.call(ctx, "CreatePullRequest", map[string]interface{}{
+		"title":       newPR.Title,
+		"description": newPR.Body,
+		"targets": []map[string]string{{
+			"repositoryName":       r.name,
+			"sourceReference":      newPR.Head,
+			"destinationReference": newPR.Base,
+		}},
+	}, &output)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create pull request")
+	}
+
+	return pullRequest{
+		region:     c.region,
+		accountID:  r.accountID,
+		repoName:   r.name,
+		branchName: newPR.Head,
+		id:         output.PullRequest.PullRequestID,
+	}, nil
+}
+
+// GetPullRequests gets all pull requests of with a specific branch
+func (c *CodeCommit) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := c.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		pr, err := c.getPullRequest(ctx, repo.RepositoryName, branchName)
+		if err != nil {
+			return nil, err
+		}
+		if pr == nil {
+			continue
+		}
+
+		status, err := c.pullRequestStatus(ctx, *pr)
+		if err != nil {
+			return nil, err
+		}
+
+		prs = append(prs, pullRequest{
+			region:     c.region,
+			accountID:  repo.AccountID,
+			repoName:   repo.RepositoryName,
+			branchName: branchName,
+			id:         pr.PullRequestID,
+			status:     status,
+		})
+	}
+
+	return prs, nil
+}
+
+// getPullRequest gets the latest pull request from the branch, if no such pull request exist nil is returned.
+// Pull requests can't be listed by their source branch, so open pull requests are searched before closed ones
+func (c *CodeCommit) getPullRequest(ctx context.Context, repoName, branchName string) (*ccPullRequest, error) {
+	for _, status := range []string{"OPEN", "CLOSED"} {
+		ids, err := c.listPullRequestIDs(ctx, repoName, status)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			pr, err := c.getPullRequestByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if len(pr.PullRequestTargets) > 0 && pr.PullRequestTargets[0].SourceReference == "refs/heads/"+branchName {
+				return &pr, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *CodeCommit) listPullRequestIDs(ctx context.Context, repoName, status string) ([]string, error) {
+	var ids []string
+	nextToken := ""
+	for {
+		input := map[string]interface{}{
+			"repositoryName":    repoName,
+			"pullRequestStatus": status,
+		}
+		if nextToken != "" {
+			input["nextToken"] = nextToken
+		}
+
+		var output struct {
+			PullRequestIDs []string `json:"pullRequestIds"`
+			NextToken      string   `json:"nextToken"`
+		}
+		if err := c.call(ctx, "ListPullRequests", input, &output); err != nil {
+			return nil, errors.Wrapf(err, "could not list the pull requests of %s", repoName)
+		}
+		ids = append(ids, output.PullRequestIDs...)
+
+		if output.NextToken == "" {
+			return ids, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+func (c *CodeCommit) getPullRequestByID(ctx context.Context, id string) (ccPullRequest, error) {
+	var output struct {
+		PullRequest ccPullRequest `json:"pullRequest"`
+	}
+	err := c.call(ctx, "GetPullRequest", map[string]string{
+		"pullRequestId": id,
+	}, &output)
+	return output.PullRequest, err
+}
+
+// pullRequestStatus gets the status of a pull request. CodeCommit has no statuses of commits,
+// so open pull requests are pending until all approval rules are fulfilled
+func (c *CodeCommit) pullRequestStatus(ctx context.Context, pr ccPullRequest) (domain.PullRequestStatus, error) {
+	if len(pr.PullRequestTargets) > 0 && pr.PullRequestTargets[0].MergeMetadata.IsMerged {
+		return domain.PullRequestStatusMerged, nil
+	}
+	if pr.PullRequestStatus == "CLOSED" {
+		return domain.PullRequestStatusClosed, nil
+	}
+
+	var output struct {
+		Evaluation struct {
+			Approved   bool `json:"approved"`
+			Overridden bool `json:"overridden"`
+		} `json:"evaluation"`
+	}
+	err := c.call(ctx, "EvaluatePullRequestApprovalRules", map[string]string{
+		"pullRequestId": pr.PullRequestID,
+		"revisionId":    pr.RevisionID,
+	}, &output)
+	if err != nil {
+		return domain.PullRequestStatusUnknown, err
+	}
+
+	if output.Evaluation.Approved || output.Evaluation.Overridden {
+		return domain.PullRequestStatusSuccess, nil
+	}
+	return domain.PullRequestStatusPending, nil
+}
+
+// MergePullRequest merges a pull request
+func (c *CodeCommit) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return c.MergePullRequestWithTypes(ctx, pullReq, c.MergeTypes)
+}
+
+// MergePullRequestWithTypes merges a pull request with the first of the merge types, and deletes the source branch
+func (c *CodeCommit) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
+	pr := pullReq.(pullRequest)
+
+	ccPR, err := c.getPullRequestByID(ctx, pr.id)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", pr.String())
+	}
+	if len(ccPR.PullRequestTargets) == 0 {
+		return errors.Errorf("%s has no target", pr.String())
+	}
+
+	action := mergeTypeCodeCommitAction[domain.MergeTypeMerge]
+	if len(mergeTypes) > 0 {
+		action = mergeTypeCodeCommitAction[mergeTypes[0]]
+	}
+
+	err = c.call(ctx, action, map[string]string{
+		"pullRequestId":  pr.id,
+		"repositoryName": pr.repoName,
+		"sourceCommitId": ccPR.PullRequestTargets[0].SourceCommit,
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not merge %s", pr.String())
+	}
+
+	if err := c.deleteBranch(ctx, pr); err != nil {
+		return errors.Wrapf(err, "could not delete branch after merging %s", pr.String())
+	}
+
+	return nil
+}
+
+// ClosePullRequest closes a pull request and deletes its source branch
+func (c *CodeCommit) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	err := c.call(ctx, "UpdatePullRequestStatus", map[string]string{
+		"pullRequestId":     pr.id,
+		"pullRequestStatus": "CLOSED",
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not close %s", pr.String())
+	}
+
+	if err := c.deleteBranch(ctx, pr); err != nil {
+		return errors.Wrapf(err, "could not delete branch after closing %s", pr.String())
+	}
+
+	return nil
+}
+
+func (c *CodeCommit) deleteBranch(ctx context.Context, pr pullRequest) error {
+	err := c.call(ctx, "DeleteBranch", map[string]string{
+		"repositoryName": pr.repoName,
+		"branchName":     pr.branchName,
+	}, nil)
+	if isErrorType(err, "BranchDoesNotExistException") {
+		return nil
+	}
+	return err
+}
+
+// ForkRepository is not supported on CodeCommit
+func (c *CodeCommit) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on CodeCommit")
+}
+
+func (c *CodeCommit) convertRepository(repo ccRepository) (repository, error) {
+	u, err := url.Parse(repo.CloneURLHTTP)
+	if err != nil {
+		return repository{}, err
+	}
+
+	return repository{
+		url:           *u,
+		credentials:   c.credentials,
+		region:        c.region,
+		accountID:     repo.AccountID,
+		name:          repo.RepositoryName,
+		defaultBranch: repo.DefaultBranch,
+	}, nil
+}
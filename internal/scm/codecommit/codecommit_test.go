@@ -0,0 +1,127 @@
+package codecommit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/scm/codecommit"
+)
+
+func noMiddleware(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+var credentials = aws.Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "secret",
+	SessionToken:    "session/token",
+}
+
+// newServer creates a server that responds to CodeCommit actions, and records the input of them
+func newServer(t *testing.T, responses map[string]string, inputs map[string][]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/codecommit/aws4_request, SignedHeaders=content-length;content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=[0-9a-f]{64}$`,
+			r.Header.Get("Authorization"))
+		assert.Equal(t, "session/token", r.Header.Get("X-Amz-Security-Token"))
+
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "CodeCommit_20150413.")
+		body, _ := ioutil.ReadAll(r.Body)
+		var input map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &input))
+		inputs[action] = append(inputs[action], input)
+
+		response, ok := responses[action]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"__type": "UnknownOperationException", "message": "%s"}`, action)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+}
+
+func TestGetRepositories(t *testing.T) {
+	inputs := map[string][]map[string]interface{}{}
+	server := newServer(t, map[string]string{
+		"ListRepositories": `{"repositories": [{"repositoryName": "repo-b"}, {"repositoryName": "empty"}, {"repositoryName": "repo-a"}]}`,
+		"BatchGetRepositories": `{"repositories": [
+			{"accountId": "123456789012", "repositoryName": "repo-b", "defaultBranch": "main", "cloneUrlHttp": "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/repo-b"},
+			{"accountId": "123456789012", "repositoryName": "empty", "cloneUrlHttp": "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/empty"},
+			{"accountId": "123456789012", "repositoryName": "repo-a", "defaultBranch": "master", "cloneUrlHttp": "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/repo-a"}
+		]}`,
+	}, inputs)
+	defer server.Close()
+
+	cc, err := codecommit.New(credentials, "eu-west-1", server.URL, noMiddleware, codecommit.RepositoryListing{}, nil)
+	require.NoError(t, err)
+
+	repos, err := cc.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, []interface{}{"repo-b", "empty", "repo-a"}, inputs["BatchGetRepositories"][0]["repositoryNames"])
+
+	assert.Equal(t, "123456789012/repo-a", repos[0].FullName())
+	assert.Equal(t, "master", repos[0].DefaultBranch())
+	assert.Equal(t, "123456789012/repo-b", repos[1].FullName())
+
+	// The credentials used by git are signed with the AWS credentials
+	u, err := url.Parse(repos[0].URL(""))
+	require.NoError(t, err)
+	assert.Equal(t, "git-codecommit.eu-west-1.amazonaws.com", u.Host)
+	assert.Equal(t, "AKIDEXAMPLE%session/token", u.User.Username())
+	password, _ := u.User.Password()
+	assert.True(t, regexp.MustCompile(`^\d{8}T\d{6}Z[0-9a-f]{64}$`).MatchString(password), password)
+}
+
+func TestGetAndMergePullRequests(t *testing.T) {
+	inputs := map[string][]map[string]interface{}{}
+	server := newServer(t, map[string]string{
+		"BatchGetRepositories": `{"repositories": [
+			{"accountId": "123456789012", "repositoryName": "repo", "defaultBranch": "main", "cloneUrlHttp": "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/repo"}
+		]}`,
+		"ListPullRequests": `{"pullRequestIds": ["2"]}`,
+		"GetPullRequest": `{"pullRequest": {
+			"pullRequestId": "2",
+			"pullRequestStatus": "OPEN",
+			"revisionId": "rev",
+			"pullRequestTargets": [{"repositoryName": "repo", "sourceReference": "refs/heads/my-branch", "sourceCommit": "abc123"}]
+		}}`,
+		"EvaluatePullRequestApprovalRules": `{"evaluation": {"approved": false, "overridden": false}}`,
+		"MergePullRequestBySquash":         `{}`,
+		"DeleteBranch":                     `{}`,
+	}, inputs)
+	defer server.Close()
+
+	cc, err := codecommit.New(credentials, "eu-west-1", server.URL, noMiddleware, codecommit.RepositoryListing{
+		Repositories: []string{"repo"},
+	}, []domain.MergeType{domain.MergeTypeSquash})
+	require.NoError(t, err)
+
+	prs, err := cc.GetPullRequests(context.Background(), "my-branch")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, "repo #2", prs[0].String())
+	assert.Equal(t, domain.PullRequestStatusPending, prs[0].Status())
+	assert.Equal(t, "OPEN", inputs["ListPullRequests"][0]["pullRequestStatus"])
+
+	require.NoError(t, cc.MergePullRequest(context.Background(), prs[0]))
+	assert.Equal(t, map[string]interface{}{
+		"pullRequestId":  "2",
+		"repositoryName": "repo",
+		"sourceCommitId": "abc123",
+	}, inputs["MergePullRequestBySquash"][0])
+	assert.Equal(t, "my-branch", inputs["DeleteBranch"][0]["branchName"])
+}
@@ -0,0 +1,47 @@
+package codecommit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+)
+
+const serviceName = "codecommit"
+
+// gitCredentials returns the username and password used to clone and push over HTTPS, with the same signing
+// as the AWS CLI credential helper and git-remote-codecommit. The password is only valid for a limited time.
+// The AWS SDK only signs http requests, and the password is the signature of a "GIT" request that is never sent
+func gitCredentials(creds aws.Credentials, region, host, path string, t time.Time) (string, string) {
+	timestamp := t.Format("20060102T150405")
+	date := t.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, serviceName)
+	canonicalRequest := fmt.Sprintf("GIT\n%s\n\nhost:%s\n\nhost\n", path, host)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		aws.SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, serviceName, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	username := creds.AccessKeyID
+	if creds.SessionToken != "" {
+		username += "%" + creds.SessionToken
+	}
+	return username, timestamp + "Z" + hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
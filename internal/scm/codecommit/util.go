@@ -0,0 +1,10 @@
+package codecommit
+
+import "github.com/lindell/multi-gitter/internal/domain"
+
+// maps merge types to the names of the merge actions in the codecommit api
+var mergeTypeCodeCommitAction = map[domain.MergeType]string{
+	domain.MergeTypeMerge:  "MergePullRequestByThreeWay",
+	domain.MergeTypeRebase: "MergePullRequestByFastForward",
+	domain.MergeTypeSquash: "MergePullRequestBySquash",
+}
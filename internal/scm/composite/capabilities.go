@@ -0,0 +1,652 @@
+package composite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// The optional features of platforms. Each operation is made on the platform the repository, or pull request,
+// belongs to, and fails if that platform does not support it
+
+type releaser interface {
+	TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error)
+	CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error
+}
+
+type issueCreator interface {
+	CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error
+}
+
+type issueFinder interface {
+	IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error)
+}
+
+type secretsManager interface {
+	GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error)
+	SetSecret(ctx context.Context, repo domain.Repository, name, value string) error
+	SetVariable(ctx context.Context, repo domain.Repository, name, value string) error
+}
+
+type permissionManager interface {
+	GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error)
+	SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error
+	SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error
+	RemoveUser(ctx context.Context, repo domain.Repository, user string) error
+	RemoveTeam(ctx context.Context, repo domain.Repository, team string) error
+}
+
+type defaultBranchRenamer interface {
+	RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error
+}
+
+type fileGetter interface {
+	GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error)
+}
+
+type pullRequestGetter interface {
+	GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error)
+}
+
+type pullRequestDiffGetter interface {
+	GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error)
+}
+
+type pullRequestCommitGetter interface {
+	GetPullRequestCommits(ctx context.Context, pr domain.PullRequest) ([]domain.Commit, error)
+}
+
+type mergeTypeMerger interface {
+	MergePullRequestWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error
+}
+
+type settingsManager interface {
+	GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error)
+	UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error
+}
+
+type checkRunCreator interface {
+	CreateCheckRun(ctx context.Context, repo domain.Repository, branch string, run domain.CheckRun) error
+}
+
+type campaignPullRequestGetter interface {
+	GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error)
+}
+
+type pullRequestChecker interface {
+	GetPullRequestChecks(ctx context.Context, pr domain.PullRequest) ([]domain.Check, error)
+}
+
+type branchProtector interface {
+	GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error)
+	ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error
+}
+
+type webhookRemover interface {
+	RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error
+}
+
+type suggestionCreator interface {
+	CreateSuggestions(ctx context.Context, pr domain.PullRequest, suggestions []domain.Suggestion) error
+}
+
+type archiver interface {
+	IsArchived(ctx context.Context, repo domain.Repository) (bool, error)
+	SetArchived(ctx context.Context, repo domain.Repository, archived bool) error
+}
+
+type archivedRepositoryLister interface {
+	GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error)
+}
+
+type labelManager interface {
+	GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error)
+	CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error
+	UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error
+}
+
+type pullRequestPatchGetter interface {
+	GetPullRequestPatch(ctx context.Context, pr domain.PullRequest) (string, error)
+}
+
+type branchLister interface {
+	ListBranches(ctx context.Context, repo domain.Repository) ([]string, error)
+}
+
+func unsupported(platform Platform, feature string) error {
+	return errors.Errorf("%s does not support %s", platform.Name, feature)
+}
+
+// repositoryPlatform returns the platform of a repository, and the repository as it is known by that platform
+func (c *Composite) repositoryPlatform(repo domain.Repository) (Platform, domain.Repository, error) {
+	r, err := unwrapRepository(repo)
+	if err != nil {
+		return Platform{}, nil, err
+	}
+	return c.platforms[r.platform], r.Repository, nil
+}
+
+// pullRequestPlatform returns the platform of a pull request, and the pull request as it is known by that platform
+func (c *Composite) pullRequestPlatform(pr domain.PullRequest) (Platform, domain.PullRequest, error) {
+	p, ok := pr.(pullRequest)
+	if !ok {
+		return Platform{}, nil, errors.Errorf("the pull request %s does not belong to any platform", pr.String())
+	}
+	return c.platforms[p.platform], p.PullRequest, nil
+}
+
+// namedPlatform returns the platform of a repository only known by its name. The name is looked up in the repositories
+// of all platforms, and if it is not one of them, the only platform that supports the feature is used
+func (c *Composite) namedPlatform(repoName string, supports func(VersionController) bool) (int, error) {
+	c.namesLock.Lock()
+	i, ok := c.names[repoName]
+	c.namesLock.Unlock()
+	if ok && i >= 0 {
+		return i, nil
+	}
+	if ok {
+		return 0, errors.Errorf("the repository %s exists on several platforms", repoName)
+	}
+
+	platform := -1
+	for i, p := range c.platforms {
+		if !supports(p.VersionController) {
+			continue
+		}
+		if platform >= 0 {
+			return 0, errors.Errorf("could not tell which platform the repository %s belongs to", repoName)
+		}
+		platform = i
+	}
+	if platform < 0 {
+		return 0, errors.Errorf("none of the platforms can be used with the repository %s", repoName)
+	}
+	return platform, nil
+}
+
+// TagExists checks if the tag exists in the repository
+func (c *Composite) TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return false, err
+	}
+	vc, ok := platform.VersionController.(releaser)
+	if !ok {
+		return false, unsupported(platform, "creating releases")
+	}
+	return vc.TagExists(ctx, r, tag)
+}
+
+// CreateRelease creates a release in the repository
+func (c *Composite) CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(releaser)
+	if !ok {
+		return unsupported(platform, "creating releases")
+	}
+	return vc.CreateRelease(ctx, r, release)
+}
+
+// CreateIssue creates an issue in the repository with the name in the format "owner/name"
+func (c *Composite) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	i, err := c.namedPlatform(repoName, func(vc VersionController) bool {
+		_, ok := vc.(issueCreator)
+		return ok
+	})
+	if err != nil {
+		return err
+	}
+	vc, ok := c.platforms[i].VersionController.(issueCreator)
+	if !ok {
+		return unsupported(c.platforms[i], "creating issues")
+	}
+	return vc.CreateIssue(ctx, repoName, issue)
+}
+
+// IssueExists checks if an issue with the title exists in the repository
+func (c *Composite) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return false, err
+	}
+	vc, ok := platform.VersionController.(issueFinder)
+	if !ok {
+		return false, unsupported(platform, "finding existing issues")
+	}
+	return vc.IssueExists(ctx, r, title)
+}
+
+// GetSecrets gets the secrets and variables of the repository
+func (c *Composite) GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return domain.RepositorySecrets{}, err
+	}
+	vc, ok := platform.VersionController.(secretsManager)
+	if !ok {
+		return domain.RepositorySecrets{}, unsupported(platform, "managing secrets")
+	}
+	return vc.GetSecrets(ctx, r)
+}
+
+// SetSecret creates or updates a secret of the repository
+func (c *Composite) SetSecret(ctx context.Context, repo domain.Repository, name, value string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(secretsManager)
+	if !ok {
+		return unsupported(platform, "managing secrets")
+	}
+	return vc.SetSecret(ctx, r, name, value)
+}
+
+// SetVariable creates or updates a variable of the repository
+func (c *Composite) SetVariable(ctx context.Context, repo domain.Repository, name, value string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(secretsManager)
+	if !ok {
+		return unsupported(platform, "managing secrets")
+	}
+	return vc.SetVariable(ctx, r, name, value)
+}
+
+// GetPermissions gets the permissions of the repository
+func (c *Composite) GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return domain.Permissions{}, err
+	}
+	vc, ok := platform.VersionController.(permissionManager)
+	if !ok {
+		return domain.Permissions{}, unsupported(platform, "managing permissions")
+	}
+	return vc.GetPermissions(ctx, r)
+}
+
+// SetUserPermission gives a user the permission in the repository
+func (c *Composite) SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(permissionManager)
+	if !ok {
+		return unsupported(platform, "managing permissions")
+	}
+	return vc.SetUserPermission(ctx, r, user, permission)
+}
+
+// SetTeamPermission gives a team the permission in the repository
+func (c *Composite) SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(permissionManager)
+	if !ok {
+		return unsupported(platform, "managing permissions")
+	}
+	return vc.SetTeamPermission(ctx, r, team, permission)
+}
+
+// RemoveUser removes the permission of a user in the repository
+func (c *Composite) RemoveUser(ctx context.Context, repo domain.Repository, user string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(permissionManager)
+	if !ok {
+		return unsupported(platform, "managing permissions")
+	}
+	return vc.RemoveUser(ctx, r, user)
+}
+
+// RemoveTeam removes the permission of a team in the repository
+func (c *Composite) RemoveTeam(ctx context.Context, repo domain.Repository, team string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(permissionManager)
+	if !ok {
+		return unsupported(platform, "managing permissions")
+	}
+	return vc.RemoveTeam(ctx, r, team)
+}
+
+// RenameDefaultBranch renames the default branch of the repository
+func (c *Composite) RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(defaultBranchRenamer)
+	if !ok {
+		return unsupported(platform, "renaming the default branch")
+	}
+	return vc.RenameDefaultBranch(ctx, r, newName)
+}
+
+// GetFile gets the content of a file on the default branch of the repository
+func (c *Composite) GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return "", false, err
+	}
+	vc, ok := platform.VersionController.(fileGetter)
+	if !ok {
+		return "", false, unsupported(platform, "checking files in repositories before cloning them")
+	}
+	return vc.GetFile(ctx, r, path)
+}
+
+// GetPullRequest gets a pull request in the repository with the name in the format "owner/name"
+func (c *Composite) GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error) {
+	i, err := c.namedPlatform(repoName, func(vc VersionController) bool {
+		_, ok := vc.(pullRequestGetter)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := c.platforms[i].VersionController.(pullRequestGetter)
+	if !ok {
+		return nil, unsupported(c.platforms[i], "getting pull requests from a report")
+	}
+	pr, err := vc.GetPullRequest(ctx, repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequest{PullRequest: pr, platform: i}, nil
+}
+
+// GetOpenPullRequestDiffs gets the diffs of all open pull requests in the repository
+func (c *Composite) GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(pullRequestDiffGetter)
+	if !ok {
+		return nil, unsupported(platform, "finding equivalent pull requests")
+	}
+	return vc.GetOpenPullRequestDiffs(ctx, r)
+}
+
+// GetPullRequestCommits gets the commits of the pull request
+func (c *Composite) GetPullRequestCommits(ctx context.Context, pr domain.PullRequest) ([]domain.Commit, error) {
+	platform, p, err := c.pullRequestPlatform(pr)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(pullRequestCommitGetter)
+	if !ok {
+		return nil, unsupported(platform, "choosing the merge type automatically")
+	}
+	return vc.GetPullRequestCommits(ctx, p)
+}
+
+// MergePullRequestWithTypes merges the pull request with the first of the merge types that is allowed
+func (c *Composite) MergePullRequestWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error {
+	platform, p, err := c.pullRequestPlatform(pr)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(mergeTypeMerger)
+	if !ok {
+		return unsupported(platform, "choosing the merge type of each pull request")
+	}
+	return vc.MergePullRequestWithTypes(ctx, p, mergeTypes)
+}
+
+// GetRepositorySettings gets the settings of the repository
+func (c *Composite) GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+	vc, ok := platform.VersionController.(settingsManager)
+	if !ok {
+		return domain.RepositorySettings{}, unsupported(platform, "repository settings")
+	}
+	return vc.GetRepositorySettings(ctx, r, protectedBranch)
+}
+
+// UpdateRepositorySettings changes the settings of the repository
+func (c *Composite) UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(settingsManager)
+	if !ok {
+		return unsupported(platform, "repository settings")
+	}
+	return vc.UpdateRepositorySettings(ctx, r, settings)
+}
+
+// CreateCheckRun creates a check run on the last commit of the branch
+func (c *Composite) CreateCheckRun(ctx context.Context, repo domain.Repository, branch string, run domain.CheckRun) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(checkRunCreator)
+	if !ok {
+		return unsupported(platform, "publishing annotations")
+	}
+	return vc.CreateCheckRun(ctx, r, branch, run)
+}
+
+// GetCampaignPullRequests gets the pull requests of the campaign on all platforms that support it
+func (c *Composite) GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error) {
+	prs := []domain.PullRequest{}
+	supported := false
+	for i, platform := range c.platforms {
+		vc, ok := platform.VersionController.(campaignPullRequestGetter)
+		if !ok {
+			continue
+		}
+		supported = true
+
+		platformPRs, err := vc.GetCampaignPullRequests(ctx, campaignID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the pull requests of %s", platform.Name)
+		}
+		for _, pr := range platformPRs {
+			prs = append(prs, pullRequest{PullRequest: pr, platform: i})
+		}
+	}
+	if !supported {
+		return nil, errors.New("none of the platforms support finding pull requests by campaign")
+	}
+	return prs, nil
+}
+
+// GetPullRequestChecks gets the checks of the pull request
+func (c *Composite) GetPullRequestChecks(ctx context.Context, pr domain.PullRequest) ([]domain.Check, error) {
+	platform, p, err := c.pullRequestPlatform(pr)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(pullRequestChecker)
+	if !ok {
+		return nil, unsupported(platform, "verifying checks")
+	}
+	return vc.GetPullRequestChecks(ctx, p)
+}
+
+// GetBranchProtection gets the current protection of the branch of the rule
+func (c *Composite) GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(branchProtector)
+	if !ok {
+		return nil, unsupported(platform, "protecting branches")
+	}
+	return vc.GetBranchProtection(ctx, r, rule)
+}
+
+// ProtectBranch makes the protection of the branch match the rule
+func (c *Composite) ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(branchProtector)
+	if !ok {
+		return unsupported(platform, "protecting branches")
+	}
+	return vc.ProtectBranch(ctx, r, rule)
+}
+
+// RemoveWebhook removes the webhook with the url from the repository
+func (c *Composite) RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(webhookRemover)
+	if !ok {
+		return unsupported(platform, "removing webhooks")
+	}
+	return vc.RemoveWebhook(ctx, r, url)
+}
+
+// CreateSuggestions suggests changes in review comments on the pull request
+func (c *Composite) CreateSuggestions(ctx context.Context, pr domain.PullRequest, suggestions []domain.Suggestion) error {
+	platform, p, err := c.pullRequestPlatform(pr)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(suggestionCreator)
+	if !ok {
+		return unsupported(platform, "suggesting changes")
+	}
+	return vc.CreateSuggestions(ctx, p, suggestions)
+}
+
+// IsArchived checks if the repository is archived
+func (c *Composite) IsArchived(ctx context.Context, repo domain.Repository) (bool, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return false, err
+	}
+	vc, ok := platform.VersionController.(archiver)
+	if !ok {
+		return false, unsupported(platform, "archiving repositories")
+	}
+	return vc.IsArchived(ctx, r)
+}
+
+// SetArchived archives, or unarchives, the repository
+func (c *Composite) SetArchived(ctx context.Context, repo domain.Repository, archived bool) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(archiver)
+	if !ok {
+		return unsupported(platform, "archiving repositories")
+	}
+	return vc.SetArchived(ctx, r, archived)
+}
+
+// GetArchivedRepositories gets the archived repositories of all platforms. Platforms that can't list them separately
+// include the archived repositories in their other repositories
+func (c *Composite) GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error) {
+	var repos []domain.Repository
+	for i, platform := range c.platforms {
+		get := platform.VersionController.GetRepositories
+		if lister, ok := platform.VersionController.(archivedRepositoryLister); ok {
+			get = lister.GetArchivedRepositories
+		}
+
+		platformRepos, err := get(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the repositories of %s", platform.Name)
+		}
+		for _, repo := range platformRepos {
+			repos = append(repos, c.wrapRepository(repo, i))
+		}
+	}
+	return repos, nil
+}
+
+// GetLabels gets the labels of the repository
+func (c *Composite) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(labelManager)
+	if !ok {
+		return nil, unsupported(platform, "managing labels")
+	}
+	return vc.GetLabels(ctx, r)
+}
+
+// CreateLabel creates a label in the repository
+func (c *Composite) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(labelManager)
+	if !ok {
+		return unsupported(platform, "managing labels")
+	}
+	return vc.CreateLabel(ctx, r, label)
+}
+
+// UpdateLabel changes the label with the current name in the repository
+func (c *Composite) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return err
+	}
+	vc, ok := platform.VersionController.(labelManager)
+	if !ok {
+		return unsupported(platform, "managing labels")
+	}
+	return vc.UpdateLabel(ctx, r, currentName, label)
+}
+
+// GetPullRequestPatch gets the diff of the changes of the pull request
+func (c *Composite) GetPullRequestPatch(ctx context.Context, pr domain.PullRequest) (string, error) {
+	platform, p, err := c.pullRequestPlatform(pr)
+	if err != nil {
+		return "", err
+	}
+	vc, ok := platform.VersionController.(pullRequestPatchGetter)
+	if !ok {
+		return "", unsupported(platform, "backporting pull requests")
+	}
+	return vc.GetPullRequestPatch(ctx, p)
+}
+
+// ListBranches lists the branches of the repository
+func (c *Composite) ListBranches(ctx context.Context, repo domain.Repository) ([]string, error) {
+	platform, r, err := c.repositoryPlatform(repo)
+	if err != nil {
+		return nil, err
+	}
+	vc, ok := platform.VersionController.(branchLister)
+	if !ok {
+		return nil, unsupported(platform, "listing branches")
+	}
+	return vc.ListBranches(ctx, r)
+}
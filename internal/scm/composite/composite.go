@@ -0,0 +1,257 @@
+package composite
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// VersionController is a platform that is combined with others
+type VersionController interface {
+	GetRepositories(ctx context.Context) ([]domain.Repository, error)
+	CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error)
+	GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error)
+	MergePullRequest(ctx context.Context, pr domain.PullRequest) error
+	ClosePullRequest(ctx context.Context, pr domain.PullRequest) error
+	ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error)
+}
+
+// Platform is one of the combined platforms
+type Platform struct {
+	Name              string
+	VersionController VersionController
+	// Token is used to clone the repositories of the platform, instead of the token given when cloning
+	Token string
+}
+
+// New creates a version controller that combines the repositories of multiple platforms.
+// All operations on a repository, or pull request, are made on the platform it belongs to
+func New(platforms []Platform) *Composite {
+	return &Composite{
+		platforms: platforms,
+		names:     map[string]int{},
+	}
+}
+
+// Composite combines multiple platforms
+type Composite struct {
+	platforms []Platform
+
+	// The platform of each listed repository by its name, used by operations that only get the name of a repository.
+	// Names that exist on several platforms are set to -1
+	names     map[string]int
+	namesLock sync.Mutex
+}
+
+// The optional features of the repositories of platforms
+type credentialURLer interface {
+	CredentialURL(ctx context.Context, token string) (string, error)
+}
+
+type expiringURLer interface {
+	URLExpires() bool
+}
+
+type hoster interface {
+	Host() string
+}
+
+// repository is a repository of one of the platforms
+type repository struct {
+	domain.Repository
+	platform int
+	token    string
+}
+
+// URL returns the clone url of the repository, with the token of its platform if it has one
+func (r repository) URL(token string) string {
+	if r.token != "" {
+		token = r.token
+	}
+	return r.Repository.URL(token)
+}
+
+// CredentialURL returns the clone url of the repository, or an error if its platform could not create the credentials
+func (r repository) CredentialURL(ctx context.Context, token string) (string, error) {
+	if r.token != "" {
+		token = r.token
+	}
+	if c, ok := r.Repository.(credentialURLer); ok {
+		return c.CredentialURL(ctx, token)
+	}
+	return r.Repository.URL(token), nil
+}
+
+// URLExpires returns if the credentials of the url expire, if its platform can tell
+func (r repository) URLExpires() bool {
+	expiring, ok := r.Repository.(expiringURLer)
+	return ok && expiring.URLExpires()
+}
+
+// Host returns the host of the repository without creating credentials, if its platform can tell
+func (r repository) Host() string {
+	if h, ok := r.Repository.(hoster); ok {
+		return h.Host()
+	}
+	u, err := url.Parse(r.Repository.URL(""))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Archived returns if the repository is archived, if its platform can tell
+func (r repository) Archived() bool {
+	archivable, ok := r.Repository.(domain.Archivable)
+	return ok && archivable.Archived()
+}
+
+// Disabled returns if the repository is disabled, if its platform can tell
+func (r repository) Disabled() bool {
+	archivable, ok := r.Repository.(domain.Archivable)
+	return ok && archivable.Disabled()
+}
+
+// pullRequest is a pull request of one of the platforms
+type pullRequest struct {
+	domain.PullRequest
+	platform int
+}
+
+// URL returns the url of the pull request, if the platform has one
+func (pr pullRequest) URL() string {
+	if u, ok := pr.PullRequest.(interface{ URL() string }); ok {
+		return u.URL()
+	}
+	return ""
+}
+
+func unwrapRepository(repo domain.Repository) (repository, error) {
+	r, ok := repo.(repository)
+	if !ok {
+		return repository{}, errors.Errorf("the repository %s does not belong to any platform", repo.FullName())
+	}
+	return r, nil
+}
+
+// GetRepositories gets the repositories of all platforms
+func (c *Composite) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	var repos []domain.Repository
+	for i, platform := range c.platforms {
+		platformRepos, err := platform.VersionController.GetRepositories(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the repositories of %s", platform.Name)
+		}
+		for _, repo := range platformRepos {
+			repos = append(repos, c.wrapRepository(repo, i))
+		}
+	}
+	return repos, nil
+}
+
+// wrapRepository wraps a repository of a platform, and remembers which platform it belongs to
+func (c *Composite) wrapRepository(repo domain.Repository, platform int) repository {
+	c.namesLock.Lock()
+	defer c.namesLock.Unlock()
+	if existing, ok := c.names[repo.FullName()]; ok && existing != platform {
+		c.names[repo.FullName()] = -1
+	} else {
+		c.names[repo.FullName()] = platform
+	}
+
+	return repository{Repository: repo, platform: platform, token: c.platforms[platform].Token}
+}
+
+// CreatePullRequest creates a pull request on the platform of the repository
+func (c *Composite) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r, err := unwrapRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	prR, err := unwrapRepository(prRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := c.platforms[r.platform].VersionController.CreatePullRequest(ctx, r.Repository, prR.Repository, newPR)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequest{PullRequest: pr, platform: r.platform}, nil
+}
+
+// GetPullRequests gets the pull requests of all platforms
+func (c *Composite) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	prs := []domain.PullRequest{}
+	for i, platform := range c.platforms {
+		platformPRs, err := platform.VersionController.GetPullRequests(ctx, branchName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the pull requests of %s", platform.Name)
+		}
+		for _, pr := range platformPRs {
+			prs = append(prs, pullRequest{PullRequest: pr, platform: i})
+		}
+	}
+	return prs, nil
+}
+
+// MergePullRequest merges a pull request on the platform it belongs to
+func (c *Composite) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	p, ok := pr.(pullRequest)
+	if !ok {
+		return errors.Errorf("the pull request %s does not belong to any platform", pr.String())
+	}
+	return c.platforms[p.platform].VersionController.MergePullRequest(ctx, p.PullRequest)
+}
+
+// ClosePullRequest closes a pull request on the platform it belongs to
+func (c *Composite) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	p, ok := pr.(pullRequest)
+	if !ok {
+		return errors.Errorf("the pull request %s does not belong to any platform", pr.String())
+	}
+	return c.platforms[p.platform].VersionController.ClosePullRequest(ctx, p.PullRequest)
+}
+
+// ForkRepository forks a repository on the platform it belongs to
+func (c *Composite) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	r, err := unwrapRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	fork, err := c.platforms[r.platform].VersionController.ForkRepository(ctx, r.Repository, newOwner)
+	if err != nil {
+		return nil, err
+	}
+	return repository{Repository: fork, platform: r.platform, token: r.token}, nil
+}
+
+// PullRequestLimits returns the strictest limits of all platforms
+func (c *Composite) PullRequestLimits() domain.PullRequestLimits {
+	var limits domain.PullRequestLimits
+	for _, platform := range c.platforms {
+		limiter, ok := platform.VersionController.(interface {
+			PullRequestLimits() domain.PullRequestLimits
+		})
+		if !ok {
+			continue
+		}
+		platformLimits := limiter.PullRequestLimits()
+		limits.MaxTitleLength = minLimit(limits.MaxTitleLength, platformLimits.MaxTitleLength)
+		limits.MaxBodyLength = minLimit(limits.MaxBodyLength, platformLimits.MaxBodyLength)
+	}
+	return limits
+}
+
+// minLimit returns the lowest of two limits, where zero means no limit
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
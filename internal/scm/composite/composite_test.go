@@ -0,0 +1,143 @@
+package composite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/internal/scm/composite"
+)
+
+// All optional features are forwarded to the platforms
+var (
+	_ multigitter.Releaser                  = &composite.Composite{}
+	_ multigitter.IssueCreator              = &composite.Composite{}
+	_ multigitter.IssueFinder               = &composite.Composite{}
+	_ multigitter.SecretsManager            = &composite.Composite{}
+	_ multigitter.PermissionManager         = &composite.Composite{}
+	_ multigitter.DefaultBranchRenamer      = &composite.Composite{}
+	_ multigitter.FileGetter                = &composite.Composite{}
+	_ multigitter.PullRequestGetter         = &composite.Composite{}
+	_ multigitter.PullRequestDiffGetter     = &composite.Composite{}
+	_ multigitter.PullRequestCommitGetter   = &composite.Composite{}
+	_ multigitter.MergeTypeMerger           = &composite.Composite{}
+	_ multigitter.SettingsManager           = &composite.Composite{}
+	_ multigitter.CheckRunCreator           = &composite.Composite{}
+	_ multigitter.CampaignPullRequestGetter = &composite.Composite{}
+	_ multigitter.PullRequestChecker        = &composite.Composite{}
+	_ multigitter.BranchProtector           = &composite.Composite{}
+	_ multigitter.WebhookRemover            = &composite.Composite{}
+	_ multigitter.SuggestionCreator         = &composite.Composite{}
+	_ multigitter.Archiver                  = &composite.Composite{}
+	_ multigitter.ArchivedRepositoryLister  = &composite.Composite{}
+	_ multigitter.LabelManager              = &composite.Composite{}
+	_ multigitter.PullRequestPatchGetter    = &composite.Composite{}
+	_ multigitter.BranchLister              = &composite.Composite{}
+	_ multigitter.PullRequestLimiter        = &composite.Composite{}
+)
+
+type repository struct {
+	name string
+}
+
+func (r repository) URL(token string) string {
+	return "https://example.com/" + r.name
+}
+
+func (r repository) DefaultBranch() string {
+	return "main"
+}
+
+func (r repository) FullName() string {
+	return r.name
+}
+
+// platform is a platform without any optional features
+type platform struct {
+	repos []domain.Repository
+}
+
+func (p *platform) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	return p.repos, nil
+}
+
+func (p *platform) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	return nil, nil
+}
+
+func (p *platform) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	return nil, nil
+}
+
+func (p *platform) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return nil
+}
+
+func (p *platform) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return nil
+}
+
+func (p *platform) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return repo, nil
+}
+
+// labelPlatform is a platform that manages labels and creates issues
+type labelPlatform struct {
+	platform
+	labels map[string][]domain.Label
+	issues []string
+}
+
+func (p *labelPlatform) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	return p.labels[repo.(repository).name], nil
+}
+
+func (p *labelPlatform) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	p.labels[repo.(repository).name] = append(p.labels[repo.(repository).name], label)
+	return nil
+}
+
+func (p *labelPlatform) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	return nil
+}
+
+func (p *labelPlatform) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	p.issues = append(p.issues, repoName)
+	return nil
+}
+
+func TestOptionalFeatures(t *testing.T) {
+	ctx := context.Background()
+	labels := &labelPlatform{
+		platform: platform{repos: []domain.Repository{repository{name: "owner/labels"}}},
+		labels:   map[string][]domain.Label{},
+	}
+	plain := &platform{repos: []domain.Repository{repository{name: "owner/plain"}}}
+
+	c := composite.New([]composite.Platform{
+		{Name: "github", VersionController: labels},
+		{Name: "git", VersionController: plain},
+	})
+	repos, err := c.GetRepositories(ctx)
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+
+	// The operation is made on the platform of the repository, with the repository of that platform
+	require.NoError(t, c.CreateLabel(ctx, repos[0], domain.Label{Name: "bug"}))
+	got, err := c.GetLabels(ctx, repos[0])
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Label{{Name: "bug"}}, got)
+
+	_, err = c.GetLabels(ctx, repos[1])
+	assert.EqualError(t, err, "git does not support managing labels")
+
+	// Repositories only known by their name are looked up, or else made on the only platform that supports the operation
+	require.NoError(t, c.CreateIssue(ctx, "owner/labels", domain.NewIssue{Title: "failed"}))
+	require.NoError(t, c.CreateIssue(ctx, "owner/issues", domain.NewIssue{Title: "failed"}))
+	assert.Equal(t, []string{"owner/labels", "owner/issues"}, labels.issues)
+	assert.EqualError(t, c.CreateIssue(ctx, "owner/plain", domain.NewIssue{Title: "failed"}), "git does not support creating issues")
+}
@@ -0,0 +1,85 @@
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Gerrit prefixes all JSON responses with this to prevent cross site script inclusion
+const xssiPrefix = ")]}'"
+
+// apiError is an error returned by the Gerrit API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("gerrit responded with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("gerrit responded with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do makes an authenticated request to the Gerrit API. The path is relative to the authenticated endpoints of the server.
+// If body is set, it is sent as JSON, and if result is set, the response is decoded into it
+func (g *Gerrit) do(ctx context.Context, method, path string, body, result interface{}) error {
+	u := g.baseURL + "/a/" + strings.TrimPrefix(path, "/")
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(g.username, g.token)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Errors are returned as plain text
+	if resp.StatusCode >= 300 {
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Message:    strings.TrimSpace(string(respBody)),
+		}
+	}
+
+	respBody = bytes.TrimPrefix(respBody, []byte(xssiPrefix))
+	if result == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
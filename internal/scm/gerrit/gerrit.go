@@ -0,0 +1,383 @@
+package gerrit
+
+import (
+	"context"
+	"crypto/sha1" // #nosec G505 Used to create deterministic Change-Ids, not for security
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+)
+
+// New create a new Gerrit client
+func New(
+	username, token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+) (*Gerrit, error) {
+	if baseURL == "" {
+		return nil, errors.New("no base url set")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	return &Gerrit{
+		RepositoryListing: repoListing,
+
+		username: username,
+		token:    token,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+	}, nil
+}
+
+// Gerrit contain Gerrit configuration
+type Gerrit struct {
+	RepositoryListing
+
+	username   string
+	token      string // The HTTP password of the user
+	baseURL    string
+	httpClient *http.Client
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Projects []string // The full names of projects
+	Prefixes []string // All projects with names starting with any of the prefixes are used
+}
+
+type repository struct {
+	url           url.URL
+	username      string
+	name          string
+	defaultBranch string
+}
+
+// URL returns the url of the authenticated git endpoint, with the HTTP password of the user
+func (r repository) URL(token string) string {
+	r.url.User = url.UserPassword(r.username, token)
+	return r.url.String()
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return r.name
+}
+
+type pullRequest struct {
+	baseURL string
+	project string
+	number  int
+	status  domain.PullRequestStatus
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s #%d", pr.project, pr.number)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return pr.project
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return pr.status
+}
+
+func (pr pullRequest) URL() string {
+	return fmt.Sprintf("%s/c/%s/+/%d", pr.baseURL, pr.project, pr.number)
+}
+
+// id is the identifier of the change in the API
+func (pr pullRequest) id() string {
+	return url.PathEscape(fmt.Sprintf("%s~%d", pr.project, pr.number))
+}
+
+type projectInfo struct {
+	State string `json:"state"`
+}
+
+type changeInfo struct {
+	Project     string `json:"project"`
+	Status      string `json:"status"`
+	Number      int    `json:"_number"`
+	Submittable bool   `json:"submittable"`
+	MoreChanges bool   `json:"_more_changes"`
+	Labels      map[string]struct {
+		Rejected *struct{} `json:"rejected"`
+		Blocking bool      `json:"blocking"`
+	} `json:"labels"`
+}
+
+// GetRepositories fetches the projects
+func (g *Gerrit) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	names, err := g.getProjectNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]domain.Repository, 0, len(names))
+	for _, name := range names {
+		var head string
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("projects/%s/HEAD", url.PathEscape(name)), nil, &head); err != nil {
+			return nil, errors.Wrapf(err, "could not get the default branch of %s", name)
+		}
+		if !strings.HasPrefix(head, "refs/heads/") {
+			log.Debugf("Skipping %s since it has no default branch", name)
+			continue
+		}
+
+		repo, err := g.convertProject(name, strings.TrimPrefix(head, "refs/heads/"))
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+
+	return repos, nil
+}
+
+// getProjectNames gets the names of all active projects, sorted by name
+func (g *Gerrit) getProjectNames(ctx context.Context) ([]string, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	nameMap := map[string]bool{}
+
+	for _, name := range g.Projects {
+		var project projectInfo
+		err := g.do(ctx, http.MethodGet, "projects/"+url.PathEscape(name), nil, &project)
+		if isNotFound(err) {
+			return nil, errors.Errorf("could not find the project %s", name)
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not get the project %s", name)
+		}
+		if project.State != "" && project.State != "ACTIVE" {
+			log.Debugf("Skipping %s since it is not active", name)
+			continue
+		}
+		nameMap[name] = true
+	}
+
+	for _, prefix := range g.Prefixes {
+		var projects map[string]projectInfo
+		if err := g.do(ctx, http.MethodGet, "projects/?type=CODE&p="+url.QueryEscape(prefix), nil, &projects); err != nil {
+			return nil, errors.Wrapf(err, "could not list the projects starting with %s", prefix)
+		}
+		for name, project := range projects {
+			if project.State != "" && project.State != "ACTIVE" {
+				continue
+			}
+			nameMap[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(nameMap))
+	for name := range nameMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ChangeID returns the Change-Id used for the change of a feature branch in a project. The same id
+// is used every time, which makes new commits from the same feature branch new versions of the change
+func ChangeID(project, featureBranch string) string {
+	return fmt.Sprintf("I%x", sha1.Sum([]byte(project+"\x00"+featureBranch))) // #nosec G401
+}
+
+// ChangeCommitMessage adds the Change-Id footer, which Gerrit uses to track the change, to the commit message
+func (g *Gerrit) ChangeCommitMessage(repo domain.Repository, featureBranch, commitMessage string) string {
+	return fmt.Sprintf("%s\n\nChange-Id: %s\n", strings.TrimRight(commitMessage, "\n"), ChangeID(repo.FullName(), featureBranch))
+}
+
+// ChangeRef returns the magic ref that creates a change for the base branch when pushed to.
+// The feature branch is set as the topic of the change, which is used to find it again
+func (g *Gerrit) ChangeRef(newPR domain.NewPullRequest) string {
+	options := []string{"topic=" + newPR.Head}
+	for _, label := range newPR.Labels {
+		options = append(options, "hashtag="+label)
+	}
+	if newPR.Draft {
+		options = append(options, "wip")
+	}
+	return fmt.Sprintf("refs/for/%s%%%s", newPR.Base, strings.Join(options, ","))
+}
+
+// CreatePullRequest finds the change created when the commit was pushed, and adds the reviewers and description to it
+func (g *Gerrit) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on Gerrit")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on Gerrit")
+	}
+
+	changeID := ChangeID(r.name, newPR.Head)
+	changes, err := g.queryChanges(ctx, fmt.Sprintf("change:%s project:%q branch:%q", changeID, r.name, newPR.Base))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find the change")
+	}
+	if len(changes) == 0 {
+		return nil, errors.Errorf("could not find the change %s after pushing it", changeID)
+	}
+	pr := g.convertChange(changes[0])
+
+	if domain.HasDefaultReviewers(newPR.Reviewers) {
+		log.WithField("repo", r.FullName()).Warn("Default reviewers are not supported on Gerrit")
+	}
+
+	// Users and groups are added the same way
+	users, groups := domain.SplitReviewers(newPR.Reviewers)
+	for _, reviewer := range append(users, groups...) {
+		err := g.do(ctx, http.MethodPost, fmt.Sprintf("changes/%s/reviewers", pr.id()), map[string]string{
+			"reviewer": reviewer,
+		}, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not add the reviewer %s", reviewer)
+		}
+	}
+
+	// The description of the change is the commit message, so the body is added as a comment
+	if newPR.Body != "" {
+		err := g.do(ctx, http.MethodPost, fmt.Sprintf("changes/%s/revisions/current/review", pr.id()), map[string]string{
+			"message": newPR.Body,
+		}, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not add the description")
+		}
+	}
+
+	return pr, nil
+}
+
+// GetPullRequests gets the latest change in each project with the feature branch as topic
+func (g *Gerrit) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	names, err := g.getProjectNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := g.queryChanges(ctx, fmt.Sprintf("topic:%q", branchName))
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes are sorted by when they were last updated, the latest first
+	latest := map[string]changeInfo{}
+	for _, change := range changes {
+		if _, ok := latest[change.Project]; !ok {
+			latest[change.Project] = change
+		}
+	}
+
+	prs := []domain.PullRequest{}
+	for _, name := range names {
+		if change, ok := latest[name]; ok {
+			prs = append(prs, g.convertChange(change))
+		}
+	}
+
+	return prs, nil
+}
+
+// queryChanges gets all changes matching the query, including their labels and if they are submittable
+func (g *Gerrit) queryChanges(ctx context.Context, query string) ([]changeInfo, error) {
+	var changes []changeInfo
+	for {
+		var page []changeInfo
+		path := fmt.Sprintf("changes/?q=%s&o=LABELS&o=SUBMITTABLE&n=100&S=%d", url.QueryEscape(query), len(changes))
+		if err := g.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, errors.Wrap(err, "could not query changes")
+		}
+		changes = append(changes, page...)
+
+		if len(page) == 0 || !page[len(page)-1].MoreChanges {
+			return changes, nil
+		}
+	}
+}
+
+// changeStatus maps the state of a change to a pull request status. Open changes that are rejected
+// by any label, for example by a failed verification, are seen as failed
+func changeStatus(change changeInfo) domain.PullRequestStatus {
+	switch change.Status {
+	case "MERGED":
+		return domain.PullRequestStatusMerged
+	case "ABANDONED":
+		return domain.PullRequestStatusClosed
+	}
+
+	for _, label := range change.Labels {
+		if label.Rejected != nil || label.Blocking {
+			return domain.PullRequestStatusError
+		}
+	}
+	if change.Submittable {
+		return domain.PullRequestStatusSuccess
+	}
+	return domain.PullRequestStatusPending
+}
+
+// MergePullRequest submits a change, how it is merged is decided by the submit type of the project
+func (g *Gerrit) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("changes/%s/submit", pr.id()), nil, nil); err != nil {
+		return errors.Wrapf(err, "could not submit %s", pr.String())
+	}
+	return nil
+}
+
+// ClosePullRequest abandons a change
+func (g *Gerrit) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	pr := pullReq.(pullRequest)
+
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("changes/%s/abandon", pr.id()), nil, nil); err != nil {
+		return errors.Wrapf(err, "could not abandon %s", pr.String())
+	}
+	return nil
+}
+
+// ForkRepository is not supported on Gerrit
+func (g *Gerrit) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on Gerrit")
+}
+
+func (g *Gerrit) convertProject(name, defaultBranch string) (repository, error) {
+	u, err := url.Parse(g.baseURL + "/a/" + name)
+	if err != nil {
+		return repository{}, err
+	}
+
+	return repository{
+		url:           *u,
+		username:      g.username,
+		name:          name,
+		defaultBranch: defaultBranch,
+	}, nil
+}
+
+func (g *Gerrit) convertChange(change changeInfo) pullRequest {
+	return pullRequest{
+		baseURL: g.baseURL,
+		project: change.Project,
+		number:  change.Number,
+		status:  changeStatus(change),
+	}
+}
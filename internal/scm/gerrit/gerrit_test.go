@@ -0,0 +1,137 @@
+package gerrit_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/scm/gerrit"
+)
+
+func noMiddleware(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+// newServer creates a server that responds with the response of the request uri, and records all requests
+func newServer(t *testing.T, responses map[string]string, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "http-password", password)
+
+		key := r.Method + " " + r.URL.RequestURI()
+		*requests = append(*requests, key)
+
+		response, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Not found")
+			return
+		}
+		fmt.Fprintf(w, ")]}'\n%s", response)
+	}))
+}
+
+func TestGetRepositories(t *testing.T) {
+	var requests []string
+	server := newServer(t, map[string]string{
+		"GET /a/projects/?type=CODE&p=platform%2F": `{
+			"platform/service-b": {"id": "platform%2Fservice-b", "state": "ACTIVE"},
+			"platform/service-a": {"id": "platform%2Fservice-a", "state": "ACTIVE"},
+			"platform/retired": {"id": "platform%2Fretired", "state": "READ_ONLY"}
+		}`,
+		"GET /a/projects/tools":                     `{"id": "tools", "state": "ACTIVE"}`,
+		"GET /a/projects/platform%2Fservice-a/HEAD": `"refs/heads/main"`,
+		"GET /a/projects/platform%2Fservice-b/HEAD": `"refs/heads/master"`,
+		"GET /a/projects/tools/HEAD":                `"refs/heads/main"`,
+	}, &requests)
+	defer server.Close()
+
+	g, err := gerrit.New("user", "http-password", server.URL+"/", noMiddleware, gerrit.RepositoryListing{
+		Projects: []string{"tools"},
+		Prefixes: []string{"platform/"},
+	})
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 3)
+
+	assert.Equal(t, "platform/service-a", repos[0].FullName())
+	assert.Equal(t, "main", repos[0].DefaultBranch())
+	assert.Equal(t, "platform/service-b", repos[1].FullName())
+	assert.Equal(t, "master", repos[1].DefaultBranch())
+	assert.Equal(t, "tools", repos[2].FullName())
+	assert.Equal(t, fmt.Sprintf("http://user:http-password@%s/a/tools", server.Listener.Addr()), repos[2].URL("http-password"))
+
+	g, err = gerrit.New("user", "http-password", server.URL, noMiddleware, gerrit.RepositoryListing{
+		Projects: []string{"missing"},
+	})
+	require.NoError(t, err)
+	_, err = g.GetRepositories(context.Background())
+	assert.EqualError(t, err, "could not find the project missing")
+}
+
+func TestChanges(t *testing.T) {
+	g, err := gerrit.New("user", "http-password", "https://gerrit.example.com", noMiddleware, gerrit.RepositoryListing{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "refs/for/main%topic=my-branch,hashtag=dependencies,wip", g.ChangeRef(domain.NewPullRequest{
+		Head:   "my-branch",
+		Base:   "main",
+		Labels: []string{"dependencies"},
+		Draft:  true,
+	}))
+
+	changeID := gerrit.ChangeID("tools", "my-branch")
+	assert.Regexp(t, "^I[0-9a-f]{40}$", changeID)
+	assert.Equal(t, changeID, gerrit.ChangeID("tools", "my-branch"))
+	assert.NotEqual(t, changeID, gerrit.ChangeID("tools", "other-branch"))
+}
+
+func TestGetAndMergePullRequests(t *testing.T) {
+	var requests []string
+	server := newServer(t, map[string]string{
+		"GET /a/projects/?type=CODE&p=platform%2F": `{
+			"platform/a": {"state": "ACTIVE"},
+			"platform/b": {"state": "ACTIVE"},
+			"platform/c": {"state": "ACTIVE"}
+		}`,
+		"GET /a/changes/?q=topic%3A%22my-branch%22&o=LABELS&o=SUBMITTABLE&n=100&S=0": `[
+			{"project": "platform/a", "status": "NEW", "_number": 12, "submittable": true, "labels": {"Code-Review": {"approved": {}}}},
+			{"project": "platform/b", "status": "NEW", "_number": 11, "labels": {"Verified": {"rejected": {}}}},
+			{"project": "platform/a", "status": "ABANDONED", "_number": 10},
+			{"project": "other/project", "status": "NEW", "_number": 9, "_more_changes": true}
+		]`,
+		"GET /a/changes/?q=topic%3A%22my-branch%22&o=LABELS&o=SUBMITTABLE&n=100&S=4": `[
+			{"project": "platform/c", "status": "MERGED", "_number": 8}
+		]`,
+		"POST /a/changes/platform%2Fa~12/submit": `{}`,
+	}, &requests)
+	defer server.Close()
+
+	g, err := gerrit.New("user", "http-password", server.URL, noMiddleware, gerrit.RepositoryListing{
+		Prefixes: []string{"platform/"},
+	})
+	require.NoError(t, err)
+
+	prs, err := g.GetPullRequests(context.Background(), "my-branch")
+	require.NoError(t, err)
+	require.Len(t, prs, 3)
+
+	assert.Equal(t, "platform/a #12", prs[0].String())
+	assert.Equal(t, domain.PullRequestStatusSuccess, prs[0].Status())
+	assert.Equal(t, "platform/b #11", prs[1].String())
+	assert.Equal(t, domain.PullRequestStatusError, prs[1].Status())
+	assert.Equal(t, "platform/c #8", prs[2].String())
+	assert.Equal(t, domain.PullRequestStatusMerged, prs[2].Status())
+
+	require.NoError(t, g.MergePullRequest(context.Background(), prs[0]))
+	assert.Equal(t, "POST /a/changes/platform%2Fa~12/submit", requests[len(requests)-1])
+}
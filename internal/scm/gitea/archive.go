@@ -0,0 +1,53 @@
+package gitea
+
+import (
+	"context"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetArchivedRepositories fetches the archived repositories from all sources, which are not included by GetRepositories
+func (g *Gitea) GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := g.getAllRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []domain.Repository{}
+	for _, repo := range allRepos {
+		if !repo.Archived {
+			continue
+		}
+
+		convertedRepo, err := convertRepository(repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+
+	return repos, nil
+}
+
+// IsArchived checks if a repository is archived
+func (g *Gitea) IsArchived(ctx context.Context, repo domain.Repository) (bool, error) {
+	r := repo.(repository)
+
+	giteaRepo, _, err := g.giteaClient(ctx).GetRepo(r.ownerName, r.name)
+	if err != nil {
+		return false, err
+	}
+	return giteaRepo.Archived, nil
+}
+
+// SetArchived archives or unarchives a repository
+func (g *Gitea) SetArchived(ctx context.Context, repo domain.Repository, archived bool) error {
+	r := repo.(repository)
+
+	_, _, err := g.giteaClient(ctx).EditRepo(r.ownerName, r.name, gitea.EditRepoOption{
+		Archived: &archived,
+	})
+	return err
+}
@@ -0,0 +1,33 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetBranchProtection gets the protection of a branch, nil is returned if the branch is not protected
+func (g *Gitea) GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error) {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	if rule.EnforceAdmins {
+		return nil, domain.SkipError{Reason: "enforcing branch protection for admins is not supported on Gitea"}
+	}
+
+	_, resp, err := client.GetRepoBranch(r.ownerName, r.name, rule.Branch)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, domain.SkipError{Reason: fmt.Sprintf("the branch %s does not exist", rule.Branch)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return g.getBranchProtection(client, r, rule.Branch)
+}
+
+// ProtectBranch creates or updates the protection of a branch
+func (g *Gitea) ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error {
+	return g.updateBranchProtection(g.giteaClient(ctx), repo.(repository), rule)
+}
@@ -0,0 +1,21 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetFile gets the content of a file on the default branch of a repository, without cloning it
+func (g *Gitea) GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error) {
+	r := repo.(repository)
+
+	content, resp, err := g.giteaClient(ctx).GetFile(r.ownerName, r.name, r.defaultBranch, path)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(content), true, nil
+}
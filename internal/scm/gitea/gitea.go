@@ -2,44 +2,95 @@ package gitea
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"code.gitea.io/sdk/gitea"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/lindell/multi-gitter/internal/domain"
 	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+	"github.com/lindell/multi-gitter/internal/scm/listing"
 )
 
 // New create a new Gitea client
-func New(token, baseURL string, repoListing RepositoryListing, mergeTypes []domain.MergeType) (*Gitea, error) {
+func New(
+	token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+) (*Gitea, error) {
 	gitea := &Gitea{
 		RepositoryListing: repoListing,
 
-		baseURL: baseURL,
-		token:   token,
+		baseURL:             baseURL,
+		token:               token,
+		transportMiddleware: transportMiddleware,
 
 		MergeTypes: mergeTypes,
 	}
 
 	// Initialize the gitea client to ensure no error will occur when running a function
-	_, err := gitea.giteaClientErr(context.Background())
+	client, err := gitea.giteaClientErr(context.Background())
+	if err != nil {
+		return nil, err
+	}
 
-	return gitea, err
+	gitea.detectServer(context.Background(), client)
+
+	return gitea, nil
+}
+
+// defaultPageSize is the maximum number of items per page, if the server does not tell otherwise
+const defaultPageSize = 50
+
+// detectServer detects if the server is Forgejo, a fork of Gitea, and how many items can be fetched per page
+func (g *Gitea) detectServer(ctx context.Context, client *gitea.Client) {
+	g.pageSize = defaultPageSize
+	settings, _, err := client.GetGlobalAPISettings()
+	if err != nil {
+		log.Debugf("Could not get the API settings, using %d items per page: %s", defaultPageSize, err)
+	} else if settings.MaxResponseItems > 0 {
+		g.pageSize = settings.MaxResponseItems
+	}
+
+	// Only Forgejo has its own version endpoint, next to the endpoints it shares with Gitea
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(g.baseURL, "/")+"/api/forgejo/v1/version", nil)
+	if err != nil {
+		return
+	}
+	resp, err := (&http.Client{Transport: g.transportMiddleware(http.DefaultTransport)}).Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&version)
+	log.Debugf("Detected Forgejo %s", version.Version)
+	g.forgejo = true
 }
 
 func (g *Gitea) giteaClientErr(ctx context.Context) (*gitea.Client, error) {
 	client, err := gitea.NewClient(
 		g.baseURL,
 		gitea.SetHTTPClient(&http.Client{
-			Transport: internalHTTP.LoggingRoundTripper{},
+			Transport: g.transportMiddleware(http.DefaultTransport),
 		}),
 		gitea.SetToken(g.token),
 		gitea.SetContext(ctx),
+		gitea.SetSudo(g.Sudo),
 	)
 	return client, err
 }
@@ -53,19 +104,57 @@ func (g *Gitea) giteaClient(ctx context.Context) *gitea.Client {
 type Gitea struct {
 	RepositoryListing
 
-	baseURL string
-	token   string
+	baseURL             string
+	token               string
+	transportMiddleware func(http.RoundTripper) http.RoundTripper
 
 	currentUser *gitea.User
 
+	forgejo  bool // If the server is Forgejo, a fork of Gitea with some extensions
+	pageSize int  // The maximum number of items the server returns per page
+
 	MergeTypes []domain.MergeType
+
+	Sudo string // The user all requests are made as, which requires an administrator token
 }
 
 // RepositoryListing contains information about which repositories that should be fetched
 type RepositoryListing struct {
 	Organizations []string
 	Users         []string
+	Teams         []TeamReference
 	Repositories  []RepositoryReference
+	Languages     []string // If set, only repositories with one of these primary languages are used
+
+	IncludeArchived bool // Include archived repositories, which are read-only
+
+	SkipForks bool // Skip repositories that are forks
+	OnlyForks bool // Only use repositories that are forks
+
+	PushedAfter  time.Time // If set, only repositories that have been updated after this time are used
+	PushedBefore time.Time // If set, only repositories that have not been updated since this time are used
+
+	MaxSize int64 // If set, only repositories with a size, in bytes, of at most this are used
+
+	Visibilities []string // If set, only repositories with one of these visibilities, "public", "private" or "internal", are used
+}
+
+// TeamReference contains information to be able to reference a team of an organization
+type TeamReference struct {
+	OrganizationName string
+	Name             string
+}
+
+// ParseTeamReference parses a team reference from the format "orgName/teamName"
+func ParseTeamReference(val string) (TeamReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 2 {
+		return TeamReference{}, fmt.Errorf("could not parse team reference: %s", val)
+	}
+	return TeamReference{
+		OrganizationName: split[0],
+		Name:             split[1],
+	}, nil
 }
 
 // RepositoryReference contains information to be able to reference a repository
@@ -91,6 +180,7 @@ type repository struct {
 	name          string
 	ownerName     string
 	defaultBranch string
+	archived      bool
 }
 
 func (r repository) URL(token string) string {
@@ -107,6 +197,15 @@ func (r repository) FullName() string {
 	return fmt.Sprintf("%s/%s", r.ownerName, r.name)
 }
 
+func (r repository) Archived() bool {
+	return r.archived
+}
+
+// Disabled always returns false, since repositories can't be disabled on Gitea
+func (r repository) Disabled() bool {
+	return false
+}
+
 type pullRequest struct {
 	ownerName   string
 	repoName    string
@@ -122,6 +221,10 @@ func (pr pullRequest) String() string {
 	return fmt.Sprintf("%s/%s #%d", pr.ownerName, pr.repoName, pr.index)
 }
 
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.ownerName, pr.repoName)
+}
+
 func (pr pullRequest) Status() domain.PullRequestStatus {
 	return pr.status
 }
@@ -150,6 +253,43 @@ func (g *Gitea) GetRepositories(ctx context.Context) ([]domain.Repository, error
 }
 
 func (g *Gitea) getRepositories(ctx context.Context) ([]*gitea.Repository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos, err := g.getAllRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*gitea.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.Archived && !g.IncludeArchived {
+			continue
+		}
+		if (repo.Fork && g.SkipForks) || (!repo.Fork && g.OnlyForks) {
+			continue
+		}
+		if !listing.PushedWithin(g.PushedAfter, g.PushedBefore, repo.Updated) {
+			continue
+		}
+		// The size is reported in kilobytes
+		if !listing.WithinSize(g.MaxSize, int64(repo.Size)*1024) {
+			continue
+		}
+		if !listing.MatchesVisibility(g.Visibilities, repositoryVisibility(repo)) {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+
+	if len(g.Languages) > 0 {
+		return g.filterLanguages(ctx, repos)
+	}
+
+	return repos, nil
+}
+
+// getAllRepositories fetches repositories from all sources, including archived repositories
+func (g *Gitea) getAllRepositories(ctx context.Context) ([]*gitea.Repository, error) {
 	allRepos := []*gitea.Repository{}
 
 	for _, group := range g.Organizations {
@@ -168,6 +308,14 @@ func (g *Gitea) getRepositories(ctx context.Context) ([]*gitea.Repository, error
 		allRepos = append(allRepos, repos...)
 	}
 
+	for _, team := range g.Teams {
+		repos, err := g.getTeamRepositories(ctx, team)
+		if err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+	}
+
 	for _, repo := range g.Repositories {
 		repo, err := g.getRepository(ctx, repo)
 		if err != nil {
@@ -192,13 +340,55 @@ func (g *Gitea) getRepositories(ctx context.Context) ([]*gitea.Repository, error
 	return allRepos, nil
 }
 
+// filterLanguages removes the repositories that does not have one of the languages as their primary language
+func (g *Gitea) filterLanguages(ctx context.Context, repos []*gitea.Repository) ([]*gitea.Repository, error) {
+	filtered := make([]*gitea.Repository, 0, len(repos))
+	for _, repo := range repos {
+		languages, _, err := g.giteaClient(ctx).GetRepoLanguages(repo.Owner.UserName, repo.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the languages of %s", repo.FullName)
+		}
+		if listing.MatchesLanguage(g.Languages, primaryLanguage(languages)) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, nil
+}
+
 func (g *Gitea) getGroupRepositories(ctx context.Context, groupName string) ([]*gitea.Repository, error) {
 	var allRepos []*gitea.Repository
 	for i := 1; ; i++ {
 		repos, _, err := g.giteaClient(ctx).ListOrgRepos(groupName, gitea.ListOrgReposOptions{
 			ListOptions: gitea.ListOptions{
 				Page:     i,
-				PageSize: 100,
+				PageSize: g.pageSize,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		allRepos = append(allRepos, repos...)
+
+		if len(repos) < g.pageSize {
+			break
+		}
+	}
+	return allRepos, nil
+}
+
+func (g *Gitea) getTeamRepositories(ctx context.Context, teamRef TeamReference) ([]*gitea.Repository, error) {
+	team, err := g.getTeam(ctx, teamRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var allRepos []*gitea.Repository
+	for i := 1; ; i++ {
+		repos, _, err := g.giteaClient(ctx).ListTeamRepositories(team.ID, gitea.ListTeamRepositoriesOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     i,
+				PageSize: g.pageSize,
 			},
 		})
 		if err != nil {
@@ -207,18 +397,50 @@ func (g *Gitea) getGroupRepositories(ctx context.Context, groupName string) ([]*
 
 		allRepos = append(allRepos, repos...)
 
-		if len(repos) < 100 {
+		if len(repos) < g.pageSize {
 			break
 		}
 	}
 	return allRepos, nil
 }
 
+// getTeam finds a team of an organization by its name
+func (g *Gitea) getTeam(ctx context.Context, teamRef TeamReference) (*gitea.Team, error) {
+	for i := 1; ; i++ {
+		teams, _, err := g.giteaClient(ctx).ListOrgTeams(teamRef.OrganizationName, gitea.ListTeamsOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     i,
+				PageSize: g.pageSize,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, team := range teams {
+			if strings.EqualFold(team.Name, teamRef.Name) {
+				return team, nil
+			}
+		}
+
+		if len(teams) < g.pageSize {
+			return nil, errors.Errorf("could not find the team %s in the organization %s", teamRef.Name, teamRef.OrganizationName)
+		}
+	}
+}
+
 func (g *Gitea) getRepository(ctx context.Context, repoRef RepositoryReference) (*gitea.Repository, error) {
 	repo, _, err := g.giteaClient(ctx).GetRepo(repoRef.OwnerName, repoRef.Name)
 	if err != nil {
 		return nil, err
 	}
+
+	// Gitea redirects requests to repositories that have been renamed or transferred
+	name := fmt.Sprintf("%s/%s", repoRef.OwnerName, repoRef.Name)
+	if !strings.EqualFold(repo.FullName, name) {
+		log.Warnf("The repository %s has been renamed or transferred to %s, which will be used instead", name, repo.FullName)
+	}
+
 	return repo, err
 }
 
@@ -228,7 +450,7 @@ func (g *Gitea) getUserRepositories(ctx context.Context, username string) ([]*gi
 		repos, _, err := g.giteaClient(ctx).ListUserRepos(username, gitea.ListReposOptions{
 			ListOptions: gitea.ListOptions{
 				Page:     i,
-				PageSize: 100,
+				PageSize: g.pageSize,
 			},
 		})
 		if err != nil {
@@ -237,13 +459,20 @@ func (g *Gitea) getUserRepositories(ctx context.Context, username string) ([]*gi
 
 		allRepos = append(allRepos, repos...)
 
-		if len(repos) < 100 {
+		if len(repos) < g.pageSize {
 			break
 		}
 	}
 	return allRepos, nil
 }
 
+// PullRequestLimits returns the maximum length of the title of pull requests, the body is not limited
+func (g *Gitea) PullRequestLimits() domain.PullRequestLimits {
+	return domain.PullRequestLimits{
+		MaxTitleLength: 255,
+	}
+}
+
 // CreatePullRequest creates a pull request
 func (g *Gitea) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
 	r := repo.(repository)
@@ -251,18 +480,39 @@ func (g *Gitea) CreatePullRequest(ctx context.Context, repo domain.Repository, p
 
 	head := fmt.Sprintf("%s:%s", prR.ownerName, newPR.Head)
 
+	labelIDs, err := g.getLabelIDs(ctx, r, newPR.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get labels")
+	}
+
+	milestoneID, err := g.getMilestoneID(ctx, r, newPR.Milestone)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get milestone")
+	}
+
+	// Gitea and Forgejo consider pull requests with a "WIP:" prefix as drafts
+	title := newPR.Title
+	if newPR.Draft {
+		title = "WIP: " + title
+	}
+
 	pr, _, err := g.giteaClient(ctx).CreatePullRequest(r.ownerName, r.name, gitea.CreatePullRequestOption{
-		Head:  head,
-		Base:  newPR.Base,
-		Title: newPR.Title,
-		Body:  newPR.Body,
+		Head:      head,
+		Base:      newPR.Base,
+		Title:     title,
+		Body:      newPR.Body,
+		Assignees: newPR.Assignees,
+		Labels:    labelIDs,
+		Milestone: milestoneID,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create pull request")
 	}
 
+	users, teams := domain.SplitReviewers(newPR.Reviewers)
 	_, err = g.giteaClient(ctx).CreateReviewRequests(r.ownerName, r.name, pr.Index, gitea.PullReviewRequestOptions{
-		Reviewers: newPR.Reviewers,
+		Reviewers:     users,
+		TeamReviewers: domain.TeamNames(teams),
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "could not add reviewer to pull request")
@@ -279,6 +529,59 @@ func (g *Gitea) CreatePullRequest(ctx context.Context, repo domain.Repository, p
 	}, nil
 }
 
+// getLabelIDs gets the ids of labels by their names, labels that does not exist in the repository are skipped
+func (g *Gitea) getLabelIDs(ctx context.Context, repo repository, names []string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	labelIDs := map[string]int64{}
+	for i := 1; ; i++ {
+		labels, _, err := g.giteaClient(ctx).ListRepoLabels(repo.ownerName, repo.name, gitea.ListLabelsOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     i,
+				PageSize: g.pageSize,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			labelIDs[label.Name] = label.ID
+		}
+		if len(labels) < g.pageSize {
+			break
+		}
+	}
+
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		id, ok := labelIDs[name]
+		if !ok {
+			log.WithField("repo", repo.FullName()).Warnf("Could not find the label %s", name)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// getMilestoneID gets the id of a milestone by its name, if the milestone does not exist zero is returned
+func (g *Gitea) getMilestoneID(ctx context.Context, repo repository, name string) (int64, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	milestone, resp, err := g.giteaClient(ctx).GetMilestoneByName(repo.ownerName, repo.name, name)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		log.WithField("repo", repo.FullName()).Warnf("Could not find the milestone %s", name)
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return milestone.ID, nil
+}
+
 // GetPullRequests gets all pull requests of with a specific branch
 func (g *Gitea) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
 	repos, err := g.getRepositories(ctx)
@@ -316,6 +619,122 @@ func (g *Gitea) GetPullRequests(ctx context.Context, branchName string) ([]domai
 	return prs, nil
 }
 
+// GetPullRequest gets a pull request in the repository with the name in the format "owner/name"
+func (g *Gitea) GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error) {
+	repoRef, err := ParseRepositoryReference(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, _, err := g.giteaClient(ctx).GetRepo(repoRef.OwnerName, repoRef.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := g.giteaClient(ctx).GetPullRequest(repoRef.OwnerName, repoRef.Name, int64(number))
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := g.pullRequestStatus(ctx, repo, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return pullRequest{
+		repoName:    repo.Name,
+		ownerName:   repo.Owner.UserName,
+		branchName:  pr.Head.Name,
+		prOwnerName: pr.Head.Repository.Owner.UserName,
+		prRepoName:  pr.Head.Repository.Name,
+		status:      status,
+		index:       pr.Index,
+		webURL:      pr.HTMLURL,
+	}, nil
+}
+
+// GetCampaignPullRequests gets the latest pull request in each repository that was created within a campaign
+func (g *Gitea) GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error) {
+	marker := domain.CampaignMarker(campaignID)
+
+	repos, err := g.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		repoPRs, _, err := g.giteaClient(ctx).ListRepoPullRequests(repo.Owner.UserName, repo.Name, gitea.ListPullRequestsOptions{
+			State: "all",
+			Sort:  "recentupdate",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range repoPRs {
+			if !strings.Contains(pr.Body, marker) {
+				continue
+			}
+
+			status, err := g.pullRequestStatus(ctx, repo, pr)
+			if err != nil {
+				return nil, err
+			}
+
+			prs = append(prs, pullRequest{
+				repoName:    repo.Name,
+				ownerName:   repo.Owner.UserName,
+				branchName:  pr.Head.Name,
+				prOwnerName: pr.Head.Repository.Owner.UserName,
+				prRepoName:  pr.Head.Repository.Name,
+				status:      status,
+				index:       pr.Index,
+				webURL:      pr.HTMLURL,
+			})
+			break
+		}
+	}
+
+	return prs, nil
+}
+
+// GetOpenPullRequestDiffs gets the diffs of all open pull requests in a repository
+func (g *Gitea) GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error) {
+	r := repo.(repository)
+
+	prs, _, err := g.giteaClient(ctx).ListRepoPullRequests(r.ownerName, r.name, gitea.ListPullRequestsOptions{
+		State: gitea.StateOpen,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]domain.PullRequestDiff, len(prs))
+	for i, pr := range prs {
+		diff, _, err := g.giteaClient(ctx).GetPullRequestDiff(r.ownerName, r.name, pr.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		diffs[i] = domain.PullRequestDiff{
+			PullRequest: pullRequest{
+				repoName:    r.name,
+				ownerName:   r.ownerName,
+				branchName:  pr.Head.Name,
+				prOwnerName: pr.Head.Repository.Owner.UserName,
+				prRepoName:  pr.Head.Repository.Name,
+				index:       pr.Index,
+				webURL:      pr.HTMLURL,
+			},
+			Branch: pr.Head.Name,
+			Diff:   string(diff),
+		}
+	}
+
+	return diffs, nil
+}
+
 func (g *Gitea) getPullRequest(ctx context.Context, branchName string, repo *gitea.Repository) (*gitea.PullRequest, error) {
 	// We would like to be able to search for a pr with a specific head here, but current (2021-04-24), that option does not exist in the API
 	prs, _, err := g.giteaClient(ctx).ListRepoPullRequests(repo.Owner.UserName, repo.Name, gitea.ListPullRequestsOptions{
@@ -364,8 +783,49 @@ func (g *Gitea) pullRequestStatus(ctx context.Context, repo *gitea.Repository, p
 	return domain.PullRequestStatusUnknown, nil
 }
 
+// GetPullRequestChecks gets the commit statuses of the last commit of a pull request
+func (g *Gitea) GetPullRequestChecks(ctx context.Context, pullReq domain.PullRequest) ([]domain.Check, error) {
+	pr := pullReq.(pullRequest)
+
+	giteaPR, _, err := g.giteaClient(ctx).GetPullRequest(pr.ownerName, pr.repoName, pr.index)
+	if err != nil {
+		return nil, err
+	}
+
+	status, _, err := g.giteaClient(ctx).GetCombinedStatus(pr.ownerName, pr.repoName, giteaPR.Head.Sha)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]domain.Check, len(status.Statuses))
+	for i, s := range status.Statuses {
+		checks[i] = domain.Check{
+			Name:   s.Context,
+			Status: commitStatusToCheckStatus(s.State),
+		}
+	}
+	return checks, nil
+}
+
+func commitStatusToCheckStatus(state gitea.StatusState) domain.CheckStatus {
+	switch state {
+	case gitea.StatusPending:
+		return domain.CheckStatusPending
+	case gitea.StatusSuccess, gitea.StatusWarning:
+		return domain.CheckStatusSuccess
+	case gitea.StatusError, gitea.StatusFailure:
+		return domain.CheckStatusFailure
+	}
+	return domain.CheckStatusUnknown
+}
+
 // MergePullRequest merges a pull request
 func (g *Gitea) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return g.MergePullRequestWithTypes(ctx, pullReq, g.MergeTypes)
+}
+
+// MergePullRequestWithTypes merges a pull request with the first of the merge types that is allowed in the repository
+func (g *Gitea) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
 	pr := pullReq.(pullRequest)
 
 	repo, _, err := g.giteaClient(ctx).GetRepo(pr.ownerName, pr.repoName)
@@ -374,7 +834,7 @@ func (g *Gitea) MergePullRequest(ctx context.Context, pullReq domain.PullRequest
 	}
 
 	// Filter out all merge types to only the allowed ones, but keep the order of the ones left
-	mergeTypes := domain.MergeTypeIntersection(g.MergeTypes, repoMergeTypes(repo))
+	mergeTypes = domain.MergeTypeIntersection(mergeTypes, repoMergeTypes(repo))
 	if len(mergeTypes) == 0 {
 		return errors.New("none of the configured merge types was permitted")
 	}
@@ -426,6 +886,23 @@ func (g *Gitea) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest
 	return nil
 }
 
+// CreateIssue creates an issue in the repository with the name in the format "owner/name"
+func (g *Gitea) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	ref, err := ParseRepositoryReference(repoName)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = g.giteaClient(ctx).CreateIssue(ref.OwnerName, ref.Name, gitea.CreateIssueOption{
+		Title: issue.Title,
+		Body:  issue.Body,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not create issue in %s", repoName)
+	}
+	return nil
+}
+
 // ForkRepository forks a repository. If newOwner is empty, fork on the logged in user
 func (g *Gitea) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
 	r := repo.(repository)
@@ -457,6 +934,111 @@ func (g *Gitea) ForkRepository(ctx context.Context, repo domain.Repository, newO
 	return convertRepository(createdRepo)
 }
 
+// RenameDefaultBranch renames the default branch of a repository. Gitea can't rename branches,
+// so a new branch is created, with the same protection, before the old one is deleted
+func (g *Gitea) RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error {
+	r := repo.(repository)
+	oldName := r.defaultBranch
+	client := g.giteaClient(ctx)
+
+	_, _, err := client.CreateBranch(r.ownerName, r.name, gitea.CreateBranchOption{
+		BranchName:    newName,
+		OldBranchName: oldName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create the new branch")
+	}
+
+	protection, resp, err := client.GetBranchProtection(r.ownerName, r.name, oldName)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return errors.Wrap(err, "could not get the branch protection")
+	}
+	if err == nil {
+		_, _, err = client.CreateBranchProtection(r.ownerName, r.name, gitea.CreateBranchProtectionOption{
+			BranchName:                    newName,
+			EnablePush:                    protection.EnablePush,
+			EnablePushWhitelist:           protection.EnablePushWhitelist,
+			PushWhitelistUsernames:        protection.PushWhitelistUsernames,
+			PushWhitelistTeams:            protection.PushWhitelistTeams,
+			PushWhitelistDeployKeys:       protection.PushWhitelistDeployKeys,
+			EnableMergeWhitelist:          protection.EnableMergeWhitelist,
+			MergeWhitelistUsernames:       protection.MergeWhitelistUsernames,
+			MergeWhitelistTeams:           protection.MergeWhitelistTeams,
+			EnableStatusCheck:             protection.EnableStatusCheck,
+			StatusCheckContexts:           protection.StatusCheckContexts,
+			RequiredApprovals:             protection.RequiredApprovals,
+			EnableApprovalsWhitelist:      protection.EnableApprovalsWhitelist,
+			ApprovalsWhitelistUsernames:   protection.ApprovalsWhitelistUsernames,
+			ApprovalsWhitelistTeams:       protection.ApprovalsWhitelistTeams,
+			BlockOnRejectedReviews:        protection.BlockOnRejectedReviews,
+			BlockOnOfficialReviewRequests: protection.BlockOnOfficialReviewRequests,
+			BlockOnOutdatedBranch:         protection.BlockOnOutdatedBranch,
+			DismissStaleApprovals:         protection.DismissStaleApprovals,
+			RequireSignedCommits:          protection.RequireSignedCommits,
+			ProtectedFilePatterns:         protection.ProtectedFilePatterns,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not protect the new branch")
+		}
+	}
+
+	_, _, err = client.EditRepo(r.ownerName, r.name, gitea.EditRepoOption{
+		DefaultBranch: &newName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not change the default branch")
+	}
+
+	for page := 1; ; page++ {
+		prs, _, err := client.ListRepoPullRequests(r.ownerName, r.name, gitea.ListPullRequestsOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     page,
+				PageSize: g.pageSize,
+			},
+			State: gitea.StateOpen,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not list pull requests")
+		}
+
+		for _, pr := range prs {
+			if pr.Base == nil || pr.Base.Ref != oldName {
+				continue
+			}
+			// The title and body are sent as well, since they would otherwise be cleared
+			_, _, err := client.EditPullRequest(r.ownerName, r.name, pr.Index, gitea.EditPullRequestOption{
+				Title: pr.Title,
+				Body:  pr.Body,
+				Base:  newName,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "could not retarget %s/%s#%d", r.ownerName, r.name, pr.Index)
+			}
+		}
+
+		if len(prs) < g.pageSize {
+			break
+		}
+	}
+
+	if protection != nil {
+		_, err = client.DeleteBranchProtection(r.ownerName, r.name, oldName)
+		if err != nil {
+			return errors.Wrap(err, "could not remove the protection of the old branch")
+		}
+	}
+
+	deleted, _, err := client.DeleteRepoBranch(r.ownerName, r.name, oldName)
+	if err != nil {
+		return errors.Wrap(err, "could not delete the old branch")
+	}
+	if !deleted {
+		return errors.New("could not delete the old branch")
+	}
+
+	return nil
+}
+
 func (g *Gitea) getUser(ctx context.Context) (*gitea.User, error) {
 	if g.currentUser != nil {
 		return g.currentUser, nil
@@ -471,6 +1053,24 @@ func (g *Gitea) getUser(ctx context.Context) (*gitea.User, error) {
 	return user, nil
 }
 
+// GetCurrentUserAuthor gets the name and email of the logged in user, to be used as commit author
+func (g *Gitea) GetCurrentUserAuthor(ctx context.Context) (domain.CommitAuthor, error) {
+	user, err := g.getUser(ctx)
+	if err != nil {
+		return domain.CommitAuthor{}, err
+	}
+
+	name := user.FullName
+	if name == "" {
+		name = user.UserName
+	}
+
+	return domain.CommitAuthor{
+		Name:  name,
+		Email: user.Email,
+	}, nil
+}
+
 func convertRepository(repo *gitea.Repository) (repository, error) {
 	u, err := url.Parse(repo.CloneURL)
 	if err != nil {
@@ -482,5 +1082,6 @@ func convertRepository(repo *gitea.Repository) (repository, error) {
 		name:          repo.Name,
 		ownerName:     repo.Owner.UserName,
 		defaultBranch: repo.DefaultBranch,
+		archived:      repo.Archived,
 	}, nil
 }
@@ -0,0 +1,232 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noMiddleware(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+func newTestServer(t *testing.T, forgejo bool) *httptest.Server {
+	repos := []map[string]interface{}{}
+	for i := 1; i <= 3; i++ {
+		repos = append(repos, map[string]interface{}{
+			"id":             i,
+			"name":           fmt.Sprintf("repo-%d", i),
+			"full_name":      fmt.Sprintf("org/repo-%d", i),
+			"owner":          map[string]interface{}{"login": "org"},
+			"clone_url":      fmt.Sprintf("https://codeberg.example.com/org/repo-%d.git", i),
+			"default_branch": "main",
+			"permissions":    map[string]bool{"pull": true, "push": true},
+		})
+	}
+
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+
+	// The server returns at most two items per page, no matter how many are requested
+	page := func(r *http.Request, items []map[string]interface{}) []map[string]interface{} {
+		p, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start := (p - 1) * 2
+		if start >= len(items) {
+			return []map[string]interface{}{}
+		}
+		end := start + 2
+		if end > len(items) {
+			end = len(items)
+		}
+		return items[start:end]
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"version": "1.21.0"})
+	})
+	mux.HandleFunc("/api/v1/settings/api", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]int{"max_response_items": 2, "default_paging_num": 2})
+	})
+	if forgejo {
+		mux.HandleFunc("/api/forgejo/v1/version", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]string{"version": "7.0.0+gitea-1.21.0"})
+		})
+	}
+	mux.HandleFunc("/api/v1/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page(r, repos))
+	})
+	mux.HandleFunc("/api/v1/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page(r, []map[string]interface{}{
+			{"id": 10, "name": "Owners"},
+			{"id": 11, "name": "Developers"},
+			{"id": 12, "name": "Maintainers"},
+		}))
+	})
+	mux.HandleFunc("/api/v1/teams/12/repos", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, page(r, repos[1:]))
+	})
+	repoLanguages := []map[string]int64{
+		{"Go": 1000, "Shell": 200},
+		{"Python": 3000, "Go": 100},
+		{"Shell": 50, "Go": 500},
+	}
+	for i, languages := range repoLanguages {
+		languages := languages
+		mux.HandleFunc(fmt.Sprintf("/api/v1/repos/org/repo-%d/languages", i+1), func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, languages)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetRepositoriesPagination(t *testing.T) {
+	server := newTestServer(t, false)
+
+	g, err := New("token", server.URL, noMiddleware, RepositoryListing{Organizations: []string{"org"}}, nil)
+	require.NoError(t, err)
+	assert.False(t, g.forgejo)
+	assert.Equal(t, 2, g.pageSize)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 3)
+	assert.Equal(t, "org/repo-3", repos[2].FullName())
+}
+
+func TestForgejo(t *testing.T) {
+	server := newTestServer(t, true)
+
+	g, err := New("token", server.URL, noMiddleware, RepositoryListing{}, nil)
+	require.NoError(t, err)
+	assert.True(t, g.forgejo)
+	assert.Equal(t, "forgejo", g.hookType())
+}
+
+func TestGetRepositoriesTeam(t *testing.T) {
+	server := newTestServer(t, false)
+
+	team, err := ParseTeamReference("org/maintainers")
+	require.NoError(t, err)
+	g, err := New("token", server.URL, noMiddleware, RepositoryListing{Teams: []TeamReference{team}}, nil)
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "org/repo-2", repos[0].FullName())
+	assert.Equal(t, "org/repo-3", repos[1].FullName())
+
+	_, err = ParseTeamReference("maintainers")
+	assert.Error(t, err)
+}
+
+func TestGetRepositoriesTeamDefaultPageSize(t *testing.T) {
+	repos := []map[string]interface{}{}
+	for i := 1; i <= 60; i++ {
+		repos = append(repos, map[string]interface{}{
+			"id":          i,
+			"name":        fmt.Sprintf("repo-%d", i),
+			"full_name":   fmt.Sprintf("org/repo-%d", i),
+			"owner":       map[string]interface{}{"login": "org"},
+			"permissions": map[string]bool{"pull": true, "push": true},
+		})
+	}
+
+	// The server does not expose its API settings, and returns at most 50 items per page, the default of Gitea
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/version", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{"version": "1.21.0"}))
+	})
+	mux.HandleFunc("/api/v1/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode([]map[string]interface{}{{"id": 12, "name": "Maintainers"}}))
+	})
+	mux.HandleFunc("/api/v1/teams/12/repos", func(w http.ResponseWriter, r *http.Request) {
+		p, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start, end := (p-1)*50, p*50
+		if start > len(repos) {
+			start = len(repos)
+		}
+		if end > len(repos) {
+			end = len(repos)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(repos[start:end]))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	team, err := ParseTeamReference("org/maintainers")
+	require.NoError(t, err)
+	g, err := New("token", server.URL, noMiddleware, RepositoryListing{Teams: []TeamReference{team}}, nil)
+	require.NoError(t, err)
+
+	allRepos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, allRepos, 60)
+	assert.Equal(t, "org/repo-60", allRepos[59].FullName())
+
+	missingTeam, err := ParseTeamReference("org/missing")
+	require.NoError(t, err)
+	g, err = New("token", server.URL, noMiddleware, RepositoryListing{Teams: []TeamReference{missingTeam}}, nil)
+	require.NoError(t, err)
+	_, err = g.GetRepositories(context.Background())
+	assert.EqualError(t, err, "could not find the team missing in the organization org")
+}
+
+func TestGetRepositoriesLanguage(t *testing.T) {
+	server := newTestServer(t, false)
+
+	g, err := New("token", server.URL, noMiddleware, RepositoryListing{
+		Organizations: []string{"org"},
+		Languages:     []string{"go"},
+	}, nil)
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "org/repo-1", repos[0].FullName())
+	assert.Equal(t, "org/repo-3", repos[1].FullName())
+}
+
+func TestSudo(t *testing.T) {
+	server := newTestServer(t, false)
+
+	var sudoUsers []string
+	middleware := func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sudoUsers = append(sudoUsers, req.Header.Get("Sudo"))
+			return rt.RoundTrip(req)
+		})
+	}
+
+	g, err := New("token", server.URL, middleware, RepositoryListing{Organizations: []string{"org"}}, nil)
+	require.NoError(t, err)
+	g.Sudo = "service-bot"
+	sudoUsers = nil
+
+	_, err = g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, sudoUsers)
+	for _, user := range sudoUsers {
+		assert.Equal(t, "service-bot", user)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
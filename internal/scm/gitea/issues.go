@@ -0,0 +1,36 @@
+package gitea
+
+import (
+	"context"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// IssueExists checks if an issue, open or closed, with exactly the title exists in the repository
+func (g *Gitea) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	for page := 1; ; page++ {
+		issues, _, err := client.ListRepoIssues(r.ownerName, r.name, gitea.ListIssueOption{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: g.pageSize},
+			State:       gitea.StateAll,
+			Type:        gitea.IssueTypeIssue,
+			KeyWord:     title,
+		})
+		if err != nil {
+			return false, err
+		}
+		// The keyword search is not exact, so the titles have to be compared
+		for _, issue := range issues {
+			if issue.Title == title {
+				return true, nil
+			}
+		}
+		if len(issues) < g.pageSize {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gitea
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetLabels gets all labels of a repository
+func (g *Gitea) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	r := repo.(repository)
+
+	giteaLabels, err := g.listLabels(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]domain.Label, len(giteaLabels))
+	for i, label := range giteaLabels {
+		labels[i] = domain.Label{
+			Name:        label.Name,
+			Color:       strings.TrimPrefix(label.Color, "#"),
+			Description: label.Description,
+		}
+	}
+	return labels, nil
+}
+
+func (g *Gitea) listLabels(ctx context.Context, r repository) ([]*gitea.Label, error) {
+	var labels []*gitea.Label
+	for i := 1; ; i++ {
+		pageLabels, _, err := g.giteaClient(ctx).ListRepoLabels(r.ownerName, r.name, gitea.ListLabelsOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     i,
+				PageSize: g.pageSize,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, pageLabels...)
+		if len(pageLabels) < g.pageSize {
+			return labels, nil
+		}
+	}
+}
+
+// CreateLabel creates a label in a repository
+func (g *Gitea) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	r := repo.(repository)
+
+	_, _, err := g.giteaClient(ctx).CreateLabel(r.ownerName, r.name, gitea.CreateLabelOption{
+		Name:        label.Name,
+		Color:       "#" + label.Color,
+		Description: label.Description,
+	})
+	return err
+}
+
+// UpdateLabel changes the name, color and description of a label
+func (g *Gitea) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	r := repo.(repository)
+
+	labels, err := g.listLabels(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range labels {
+		if !strings.EqualFold(l.Name, currentName) {
+			continue
+		}
+
+		color := "#" + label.Color
+		_, _, err := g.giteaClient(ctx).EditLabel(r.ownerName, r.name, l.ID, gitea.EditLabelOption{
+			Name:        &label.Name,
+			Color:       &color,
+			Description: &label.Description,
+		})
+		return err
+	}
+
+	return errors.Errorf("could not find the label %s", currentName)
+}
@@ -0,0 +1,46 @@
+package gitea
+
+import (
+	"context"
+
+	"code.gitea.io/sdk/gitea"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// TagExists checks if a tag exists in the repository
+func (g *Gitea) TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error) {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	// There is no way to get a single tag
+	for page := 1; ; page++ {
+		tags, _, err := client.ListRepoTags(r.ownerName, r.name, gitea.ListRepoTagsOptions{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: g.pageSize},
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, t := range tags {
+			if t.Name == tag {
+				return true, nil
+			}
+		}
+		if len(tags) < g.pageSize {
+			return false, nil
+		}
+	}
+}
+
+// CreateRelease creates a release, which creates the tag at the head of the default branch
+func (g *Gitea) CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error {
+	r := repo.(repository)
+
+	_, _, err := g.giteaClient(ctx).CreateRelease(r.ownerName, r.name, gitea.CreateReleaseOption{
+		TagName: release.Tag,
+		Target:  r.defaultBranch,
+		Title:   release.Name,
+		Note:    release.Notes,
+	})
+	return err
+}
@@ -0,0 +1,247 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+
+	"code.gitea.io/sdk/gitea"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetRepositorySettings gets the current settings of a repository, including the protection of the branch
+func (g *Gitea) GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error) {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	giteaRepo, _, err := client.GetRepo(r.ownerName, r.name)
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+
+	topics, _, err := client.ListRepoTopics(r.ownerName, r.name, gitea.ListRepoTopicsOptions{})
+	if err != nil {
+		return domain.RepositorySettings{}, errors.Wrap(err, "could not list topics")
+	}
+
+	settings := domain.RepositorySettings{
+		Topics:           topics,
+		AllowMergeCommit: &giteaRepo.AllowMerge,
+		AllowSquashMerge: &giteaRepo.AllowSquash,
+		AllowRebaseMerge: &giteaRepo.AllowRebase,
+	}
+
+	if protectedBranch != "" {
+		settings.BranchProtection, err = g.getBranchProtection(client, r, protectedBranch)
+		if err != nil {
+			return domain.RepositorySettings{}, err
+		}
+	}
+
+	hooks, err := g.getHooks(client, r)
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+	for _, hook := range hooks {
+		settings.Webhooks = append(settings.Webhooks, domain.Webhook{
+			URL:         hook.Config["url"],
+			Events:      hook.Events,
+			ContentType: hook.Config["content_type"],
+		})
+	}
+
+	return settings, nil
+}
+
+// getBranchProtection gets the protection of a branch, nil is returned if the branch is not protected
+func (g *Gitea) getBranchProtection(client *gitea.Client, r repository, branch string) (*domain.BranchProtection, error) {
+	protection, resp, err := client.GetBranchProtection(r.ownerName, r.name, branch)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not get the branch protection")
+	}
+
+	branchProtection := &domain.BranchProtection{
+		Branch:                   branch,
+		RequiredApprovingReviews: int(protection.RequiredApprovals),
+	}
+	if protection.EnableStatusCheck {
+		branchProtection.RequiredStatusChecks = protection.StatusCheckContexts
+	}
+	return branchProtection, nil
+}
+
+func (g *Gitea) getHooks(client *gitea.Client, r repository) ([]*gitea.Hook, error) {
+	var hooks []*gitea.Hook
+	for page := 1; ; page++ {
+		pageHooks, _, err := client.ListRepoHooks(r.ownerName, r.name, gitea.ListHooksOptions{
+			ListOptions: gitea.ListOptions{
+				Page:     page,
+				PageSize: g.pageSize,
+			},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list webhooks")
+		}
+		hooks = append(hooks, pageHooks...)
+
+		if len(pageHooks) < g.pageSize {
+			return hooks, nil
+		}
+	}
+}
+
+// hookType returns the type of webhooks that sends the native payload of the server
+func (g *Gitea) hookType() string {
+	if g.forgejo {
+		return "forgejo"
+	}
+	return "gitea"
+}
+
+// UpdateRepositorySettings changes all settings that are set
+func (g *Gitea) UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	if settings.DeleteBranchOnMerge != nil {
+		return errors.New("deleting branches on merge is not supported on Gitea")
+	}
+	if settings.BranchProtection != nil && settings.BranchProtection.EnforceAdmins {
+		return errors.New("enforcing branch protection for admins is not supported on Gitea")
+	}
+
+	if settings.Topics != nil {
+		_, err := client.SetRepoTopics(r.ownerName, r.name, settings.Topics)
+		if err != nil {
+			return errors.Wrap(err, "could not change topics")
+		}
+	}
+
+	if settings.AllowMergeCommit != nil || settings.AllowSquashMerge != nil || settings.AllowRebaseMerge != nil {
+		_, _, err := client.EditRepo(r.ownerName, r.name, gitea.EditRepoOption{
+			AllowMerge:  settings.AllowMergeCommit,
+			AllowSquash: settings.AllowSquashMerge,
+			AllowRebase: settings.AllowRebaseMerge,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not change merge options")
+		}
+	}
+
+	if settings.BranchProtection != nil {
+		if err := g.updateBranchProtection(client, r, *settings.BranchProtection); err != nil {
+			return err
+		}
+	}
+
+	if len(settings.Webhooks) > 0 {
+		if err := g.updateHooks(client, r, settings.Webhooks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Gitea) updateBranchProtection(client *gitea.Client, r repository, protection domain.BranchProtection) error {
+	enableStatusCheck := len(protection.RequiredStatusChecks) > 0
+	requiredApprovals := int64(protection.RequiredApprovingReviews)
+
+	_, resp, err := client.GetBranchProtection(r.ownerName, r.name, protection.Branch)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return errors.Wrap(err, "could not get the branch protection")
+	}
+
+	if err == nil {
+		_, _, err = client.EditBranchProtection(r.ownerName, r.name, protection.Branch, gitea.EditBranchProtectionOption{
+			EnableStatusCheck:   &enableStatusCheck,
+			StatusCheckContexts: protection.RequiredStatusChecks,
+			RequiredApprovals:   &requiredApprovals,
+		})
+	} else {
+		_, _, err = client.CreateBranchProtection(r.ownerName, r.name, gitea.CreateBranchProtectionOption{
+			BranchName:          protection.Branch,
+			EnableStatusCheck:   enableStatusCheck,
+			StatusCheckContexts: protection.RequiredStatusChecks,
+			RequiredApprovals:   requiredApprovals,
+		})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not protect the %s branch", protection.Branch)
+	}
+	return nil
+}
+
+func (g *Gitea) updateHooks(client *gitea.Client, r repository, webhooks []domain.Webhook) error {
+	existing, err := g.getHooks(client, r)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		contentType := webhook.ContentType
+		if contentType == "" {
+			contentType = "json"
+		}
+		config := map[string]string{
+			"url":          webhook.URL,
+			"content_type": contentType,
+		}
+		if webhook.Secret != "" {
+			config["secret"] = webhook.Secret
+		}
+
+		var id int64
+		for _, hook := range existing {
+			if hook.Config["url"] == webhook.URL {
+				id = hook.ID
+			}
+		}
+
+		if id == 0 {
+			_, _, err = client.CreateRepoHook(r.ownerName, r.name, gitea.CreateHookOption{
+				Type:   g.hookType(),
+				Config: config,
+				Events: webhook.Events,
+				Active: true,
+			})
+		} else {
+			active := true
+			_, err = client.EditRepoHook(r.ownerName, r.name, id, gitea.EditHookOption{
+				Config: config,
+				Events: webhook.Events,
+				Active: &active,
+			})
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not update the webhook %s", webhook.URL)
+		}
+	}
+
+	return nil
+}
+
+// RemoveWebhook removes all webhooks with the url from a repository
+func (g *Gitea) RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error {
+	r := repo.(repository)
+	client := g.giteaClient(ctx)
+
+	hooks, err := g.getHooks(client, r)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		if hook.Config["url"] != url {
+			continue
+		}
+		if _, err := client.DeleteRepoHook(r.ownerName, r.name, hook.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -18,7 +18,7 @@ func repoMergeTypes(repo *gitea.Repository) []domain.MergeType {
 	if repo.AllowMerge {
 		ret = append(ret, domain.MergeTypeMerge)
 	}
-	if repo.AllowMerge {
+	if repo.AllowRebase {
 		ret = append(ret, domain.MergeTypeRebase)
 	}
 	if repo.AllowSquash {
@@ -26,3 +26,25 @@ func repoMergeTypes(repo *gitea.Repository) []domain.MergeType {
 	}
 	return ret
 }
+
+// primaryLanguage returns the language with the most code, from the number of bytes of each language
+func primaryLanguage(languages map[string]int64) string {
+	primary := ""
+	for language, size := range languages {
+		if size > languages[primary] || (size == languages[primary] && language < primary) {
+			primary = language
+		}
+	}
+	return primary
+}
+
+// repositoryVisibility returns the visibility of a repository, internal repositories are visible to all signed in users
+func repositoryVisibility(repo *gitea.Repository) string {
+	switch {
+	case repo.Private:
+		return "private"
+	case repo.Internal:
+		return "internal"
+	}
+	return "public"
+}
@@ -0,0 +1,278 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// AppCredentials are used to authenticate as the installations of a GitHub App, instead of with a token
+type AppCredentials struct {
+	AppID      int64
+	PrivateKey []byte // The PEM encoded private key of the app
+
+	// TokenCreated is called with each installation token that is created, for example to censor it in the logs
+	TokenCreated func(token string)
+}
+
+// tokenRefreshMargin is how long before an installation token expires that a new one is created
+const tokenRefreshMargin = 5 * time.Minute
+
+// appAuth creates installation tokens for each owner the app is installed on.
+// The tokens are cached, and replaced before they expire during long runs
+type appAuth struct {
+	appID        int64
+	key          *rsa.PrivateKey
+	appClient    *github.Client // Authenticated as the app itself
+	defaultOwner string         // Used for requests that do not belong to any owner
+
+	lock          sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]*github.InstallationToken
+	tokenCreated  func(token string)
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("the private key of the GitHub App is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the private key of the GitHub App")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key of the GitHub App is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// jwt creates a JSON Web Token that authenticates as the app
+func (a *appAuth) jwt() (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(), // Allow for some clock drift
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// token returns a valid installation token for the owner
+func (a *appAuth) token(ctx context.Context, owner string) (string, error) {
+	if owner == "" {
+		owner = a.defaultOwner
+	}
+	owner = strings.ToLower(owner)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	installationID, ok := a.installations[owner]
+	if !ok {
+		var err error
+		installationID, err = a.findInstallation(ctx, owner)
+		if err != nil {
+			return "", err
+		}
+		a.installations[owner] = installationID
+	}
+
+	if token, ok := a.tokens[installationID]; ok && time.Until(token.GetExpiresAt()) > tokenRefreshMargin {
+		return token.GetToken(), nil
+	}
+
+	token, _, err := a.appClient.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not create an installation token for %s", owner)
+	}
+	a.tokens[installationID] = token
+	if a.tokenCreated != nil {
+		a.tokenCreated(token.GetToken())
+	}
+
+	return token.GetToken(), nil
+}
+
+// findInstallation finds the installation of the app on an organization or user
+func (a *appAuth) findInstallation(ctx context.Context, owner string) (int64, error) {
+	installation, resp, err := a.appClient.Apps.FindOrganizationInstallation(ctx, owner)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		installation, _, err = a.appClient.Apps.FindUserInstallation(ctx, owner)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not find the installation of the GitHub App on %s", owner)
+	}
+	return installation.GetID(), nil
+}
+
+// jwtTransport authenticates all requests as the app
+type jwtTransport struct {
+	auth *appAuth
+	base http.RoundTripper
+}
+
+func (t jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.auth.jwt()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	return t.base.RoundTrip(req)
+}
+
+// appBotUser gets the bot user the commits of the app are attributed to, named after the slug of the app
+func (g Github) appBotUser(ctx context.Context) (*github.User, error) {
+	app, _, err := g.app.appClient.Apps.Get(ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the GitHub App")
+	}
+
+	user, _, err := g.ghClient.Users.Get(ctx, app.GetSlug()+"[bot]")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the bot user of the GitHub App %s", app.GetSlug())
+	}
+	return user, nil
+}
+
+// installationTransport authenticates all requests with the installation token of the owner the request is made to
+type installationTransport struct {
+	auth       *appAuth
+	base       http.RoundTripper
+	pathPrefix string // The path of the API, "/api/v3/" on GitHub Enterprise Server
+}
+
+func (t installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.auth.token(req.Context(), t.owner(req.URL.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	return t.base.RoundTrip(req)
+}
+
+// owner returns the owner a request is made to, from paths like "/repos/{owner}/...", "/orgs/{owner}/..." and "/users/{owner}/..."
+func (t installationTransport) owner(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, t.pathPrefix), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	switch parts[0] {
+	case "repos", "orgs", "users":
+		// Apps can't be installed on bots, such as the bot user of the app itself
+		if strings.HasSuffix(parts[1], "[bot]") {
+			return ""
+		}
+		return parts[1]
+	}
+	return ""
+}
+
+// NewWithApp creates a new Github client that is authenticated as the installations of a GitHub App
+func NewWithApp(
+	app AppCredentials,
+	baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+	mergeTypes []domain.MergeType,
+	forkMode bool,
+) (*Github, error) {
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &appAuth{
+		appID:         app.AppID,
+		key:           key,
+		defaultOwner:  repoListing.defaultOwner(),
+		installations: map[string]int64{},
+		tokens:        map[int64]*github.InstallationToken{},
+		tokenCreated:  app.TokenCreated,
+	}
+
+	auth.appClient, err = newClient(baseURL, &http.Client{
+		Transport: transportMiddleware(jwtTransport{auth: auth, base: http.DefaultTransport}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(baseURL, &http.Client{
+		Transport: transportMiddleware(installationTransport{
+			auth:       auth,
+			base:       http.DefaultTransport,
+			pathPrefix: auth.appClient.BaseURL.Path,
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGithub(client, baseURL, repoListing, mergeTypes, forkMode)
+	g.app = auth
+	return g, nil
+}
+
+// defaultOwner returns the first owner of the listing, used for requests that do not belong to any owner
+func (rl RepositoryListing) defaultOwner() string {
+	switch {
+	case len(rl.Organizations) > 0:
+		return rl.Organizations[0]
+	case len(rl.Users) > 0:
+		return rl.Users[0]
+	case len(rl.Repositories) > 0:
+		return rl.Repositories[0].OwnerName
+	}
+	return ""
+}
+
+// cloneCredentials returns the credentials used to clone and push to a repository of the owner
+func (a *appAuth) cloneCredentials(ctx context.Context, owner string) (*url.Userinfo, error) {
+	token, err := a.token(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return url.UserPassword("x-access-token", token), nil
+}
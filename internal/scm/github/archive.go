@@ -0,0 +1,53 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetArchivedRepositories fetches the archived repositories from all sources, which are not included by GetRepositories
+func (g Github) GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos, err := g.getAllRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []domain.Repository{}
+	for _, r := range allRepos {
+		if !r.GetArchived() || r.GetDisabled() || !r.GetPermissions()["admin"] {
+			continue
+		}
+
+		newRepo, err := g.convertRepo(r)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, newRepo)
+	}
+
+	return repos, nil
+}
+
+// IsArchived checks if a repository is archived
+func (g Github) IsArchived(ctx context.Context, repo domain.Repository) (bool, error) {
+	r := repo.(repository)
+
+	ghRepo, _, err := g.ghClient.Repositories.Get(ctx, r.ownerName, r.name)
+	if err != nil {
+		return false, err
+	}
+	return ghRepo.GetArchived(), nil
+}
+
+// SetArchived archives or unarchives a repository
+func (g Github) SetArchived(ctx context.Context, repo domain.Repository, archived bool) error {
+	r := repo.(repository)
+
+	_, _, err := g.ghClient.Repositories.Edit(ctx, r.ownerName, r.name, &github.Repository{
+		Archived: &archived,
+	})
+	return err
+}
@@ -0,0 +1,42 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetPullRequestPatch gets the unified diff of the changes of a pull request, also after it has been merged
+func (g Github) GetPullRequestPatch(ctx context.Context, pullReq domain.PullRequest) (string, error) {
+	pr := pullReq.(pullRequest)
+
+	diff, _, err := g.ghClient.PullRequests.GetRaw(ctx, pr.ownerName, pr.repoName, pr.number, github.RawOptions{Type: github.Diff})
+	return diff, err
+}
+
+// ListBranches lists the names of all branches of a repository
+func (g Github) ListBranches(ctx context.Context, repo domain.Repository) ([]string, error) {
+	r := repo.(repository)
+
+	var branches []string
+	for i := 1; ; i++ {
+		repoBranches, _, err := g.ghClient.Repositories.ListBranches(ctx, r.ownerName, r.name, &github.BranchListOptions{
+			ListOptions: github.ListOptions{
+				Page:    i,
+				PerPage: 100,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range repoBranches {
+			branches = append(branches, b.GetName())
+		}
+		if len(repoBranches) < 100 {
+			break
+		}
+	}
+	return branches, nil
+}
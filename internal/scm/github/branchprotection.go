@@ -0,0 +1,209 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// The id of the admin repository role, used to let admins bypass rulesets
+const adminRepositoryRoleID = 5
+
+// ruleset is a repository ruleset, which is not supported by the GitHub client
+type ruleset struct {
+	ID           int64         `json:"id,omitempty"`
+	Name         string        `json:"name"`
+	Target       string        `json:"target"`
+	Enforcement  string        `json:"enforcement"`
+	BypassActors []bypassActor `json:"bypass_actors"`
+	Conditions   rulesetConds  `json:"conditions"`
+	Rules        []rulesetRule `json:"rules"`
+}
+
+type bypassActor struct {
+	ActorID    int64  `json:"actor_id"`
+	ActorType  string `json:"actor_type"`
+	BypassMode string `json:"bypass_mode"`
+}
+
+type rulesetConds struct {
+	RefName struct {
+		Include []string `json:"include"`
+		Exclude []string `json:"exclude"`
+	} `json:"ref_name"`
+}
+
+type rulesetRule struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+func rulesetName(branch string) string {
+	return fmt.Sprintf("multi-gitter: %s", branch)
+}
+
+// GetBranchProtection gets the protection of a branch, from the ruleset managed by multi-gitter
+func (g Github) GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error) {
+	r := repo.(repository)
+
+	_, resp, err := g.ghClient.Repositories.GetBranch(ctx, r.ownerName, r.name, rule.Branch, false)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, domain.SkipError{Reason: fmt.Sprintf("the branch %s does not exist", rule.Branch)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return g.getBranchProtection(ctx, r, rule.Branch)
+}
+
+// getBranchProtection gets the protection of a branch from the ruleset managed by multi-gitter, nil is returned if
+// the branch is not protected by it. The same ruleset is used by both the branch-protection and settings commands
+func (g Github) getBranchProtection(ctx context.Context, r repository, branch string) (*domain.BranchProtection, error) {
+	rs, err := g.getRuleset(ctx, r, rulesetName(branch))
+	if err != nil || rs == nil {
+		return nil, err
+	}
+
+	protection := &domain.BranchProtection{
+		Branch:        branch,
+		EnforceAdmins: len(rs.BypassActors) == 0,
+	}
+	for _, rsRule := range rs.Rules {
+		switch rsRule.Type {
+		case "pull_request":
+			count, _ := rsRule.Parameters["required_approving_review_count"].(float64)
+			protection.RequiredApprovingReviews = int(count)
+		case "required_status_checks":
+			checks, _ := rsRule.Parameters["required_status_checks"].([]interface{})
+			for _, check := range checks {
+				check, _ := check.(map[string]interface{})
+				name, _ := check["context"].(string)
+				protection.RequiredStatusChecks = append(protection.RequiredStatusChecks, name)
+			}
+		}
+	}
+	return protection, nil
+}
+
+// getRuleset gets the ruleset with the name, nil is returned if no such ruleset exist
+func (g Github) getRuleset(ctx context.Context, r repository, name string) (*ruleset, error) {
+	if !g.supports(ctx, rulesetsVersion) {
+		return nil, g.unsupportedError(ctx, "rulesets", rulesetsVersion)
+	}
+
+	for page := 1; ; page++ {
+		req, err := g.ghClient.NewRequest("GET", fmt.Sprintf("repos/%s/%s/rulesets?per_page=100&page=%d", r.ownerName, r.name, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var rulesets []ruleset
+		resp, err := g.ghClient.Do(ctx, req, &rulesets)
+		if err != nil {
+			return nil, rulesetError(resp, err)
+		}
+
+		for _, rs := range rulesets {
+			if rs.Name != name {
+				continue
+			}
+
+			// The rules are only included when a single ruleset is fetched
+			req, err := g.ghClient.NewRequest("GET", fmt.Sprintf("repos/%s/%s/rulesets/%d", r.ownerName, r.name, rs.ID), nil)
+			if err != nil {
+				return nil, err
+			}
+			var fullRuleset ruleset
+			if resp, err := g.ghClient.Do(ctx, req, &fullRuleset); err != nil {
+				return nil, rulesetError(resp, err)
+			}
+			return &fullRuleset, nil
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+	}
+}
+
+// ProtectBranch creates or updates the ruleset managed by multi-gitter for the branch
+func (g Github) ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error {
+	r := repo.(repository)
+
+	name := rulesetName(rule.Branch)
+	existing, err := g.getRuleset(ctx, r, name)
+	if err != nil {
+		return err
+	}
+
+	rs := ruleset{
+		Name:         name,
+		Target:       "branch",
+		Enforcement:  "active",
+		BypassActors: []bypassActor{},
+		Rules:        []rulesetRule{},
+	}
+	rs.Conditions.RefName.Include = []string{"refs/heads/" + rule.Branch}
+	rs.Conditions.RefName.Exclude = []string{}
+
+	if !rule.EnforceAdmins {
+		rs.BypassActors = append(rs.BypassActors, bypassActor{
+			ActorID:    adminRepositoryRoleID,
+			ActorType:  "RepositoryRole",
+			BypassMode: "always",
+		})
+	}
+	if rule.RequiredApprovingReviews > 0 {
+		rs.Rules = append(rs.Rules, rulesetRule{
+			Type: "pull_request",
+			Parameters: map[string]interface{}{
+				"required_approving_review_count":   rule.RequiredApprovingReviews,
+				"dismiss_stale_reviews_on_push":     false,
+				"require_code_owner_review":         false,
+				"require_last_push_approval":        false,
+				"required_review_thread_resolution": false,
+			},
+		})
+	}
+	if len(rule.RequiredStatusChecks) > 0 {
+		checks := make([]map[string]string, len(rule.RequiredStatusChecks))
+		for i, check := range rule.RequiredStatusChecks {
+			checks[i] = map[string]string{"context": check}
+		}
+		rs.Rules = append(rs.Rules, rulesetRule{
+			Type: "required_status_checks",
+			Parameters: map[string]interface{}{
+				"required_status_checks":               checks,
+				"strict_required_status_checks_policy": false,
+			},
+		})
+	}
+
+	method, u := "POST", fmt.Sprintf("repos/%s/%s/rulesets", r.ownerName, r.name)
+	if existing != nil {
+		method, u = "PUT", fmt.Sprintf("repos/%s/%s/rulesets/%d", r.ownerName, r.name, existing.ID)
+	}
+
+	req, err := g.ghClient.NewRequest(method, u, rs)
+	if err != nil {
+		return err
+	}
+	resp, err := g.ghClient.Do(ctx, req, nil)
+	return rulesetError(resp, err)
+}
+
+// rulesetError converts errors caused by rulesets not being available in the repository into skip errors
+func rulesetError(resp *github.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+	if resp != nil && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound) {
+		return domain.SkipError{Reason: fmt.Sprintf("rulesets are not available: %s", errors.Cause(err))}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// maxAnnotationsPerRequest is the maximum number of annotations that can be sent in each request of a check run
+const maxAnnotationsPerRequest = 50
+
+// CreateCheckRun publishes a completed check run, with annotations, on the last commit of a branch.
+// Check runs can only be created when authenticated as a GitHub App
+func (g Github) CreateCheckRun(ctx context.Context, repo domain.Repository, branch string, run domain.CheckRun) error {
+	r := repo.(repository)
+
+	ref, _, err := g.ghClient.Git.GetRef(ctx, r.ownerName, r.name, "heads/"+branch)
+	if err != nil {
+		return err
+	}
+
+	conclusion := "neutral"
+	for _, annotation := range run.Annotations {
+		if annotation.Severity == domain.AnnotationSeverityFailure {
+			conclusion = "failure"
+		}
+	}
+	title := fmt.Sprintf("%d annotations", len(run.Annotations))
+	output := func(annotations []domain.Annotation) *github.CheckRunOutput {
+		return &github.CheckRunOutput{
+			Title:       &title,
+			Summary:     &title,
+			Annotations: convertAnnotations(annotations),
+		}
+	}
+
+	first := run.Annotations
+	if len(first) > maxAnnotationsPerRequest {
+		first = first[:maxAnnotationsPerRequest]
+	}
+	checkRun, _, err := g.ghClient.Checks.CreateCheckRun(ctx, r.ownerName, r.name, github.CreateCheckRunOptions{
+		Name:       run.Name,
+		HeadSHA:    ref.GetObject().GetSHA(),
+		Status:     github.String("completed"),
+		Conclusion: &conclusion,
+		Output:     output(first),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The rest of the annotations are added by updating the check run
+	for i := maxAnnotationsPerRequest; i < len(run.Annotations); i += maxAnnotationsPerRequest {
+		end := i + maxAnnotationsPerRequest
+		if end > len(run.Annotations) {
+			end = len(run.Annotations)
+		}
+		_, _, err := g.ghClient.Checks.UpdateCheckRun(ctx, r.ownerName, r.name, checkRun.GetID(), github.UpdateCheckRunOptions{
+			Name:   run.Name,
+			Output: output(run.Annotations[i:end]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func convertAnnotations(annotations []domain.Annotation) []*github.CheckRunAnnotation {
+	ret := make([]*github.CheckRunAnnotation, len(annotations))
+	for i, annotation := range annotations {
+		// Annotations without a line are shown on the first line of the file
+		line := annotation.Line
+		if line < 1 {
+			line = 1
+		}
+		ret[i] = &github.CheckRunAnnotation{
+			Path:            github.String(annotation.Path),
+			StartLine:       github.Int(line),
+			EndLine:         github.Int(line),
+			AnnotationLevel: github.String(annotation.Severity.String()),
+			Message:         github.String(annotation.Message),
+		}
+	}
+	return ret
+}
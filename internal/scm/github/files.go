@@ -0,0 +1,49 @@
+package github
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetFile gets the content of a file on the default branch of a repository, without cloning it
+func (g Github) GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error) {
+	r := repo.(repository)
+
+	opts := &github.RepositoryContentGetOptions{Ref: r.defaultBranch}
+	file, _, resp, err := g.ghClient.Repositories.GetContents(ctx, r.ownerName, r.name, path, opts)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	// The path is a directory
+	if file == nil {
+		return "", true, nil
+	}
+
+	// The content of files larger than 1 MB is not included, and has to be downloaded separately
+	if file.GetEncoding() == "none" {
+		reader, _, err := g.ghClient.Repositories.DownloadContents(ctx, r.ownerName, r.name, path, opts)
+		if err != nil {
+			return "", false, err
+		}
+		defer reader.Close()
+		content, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return "", false, err
+		}
+		return string(content), true, nil
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+func TestIsHeadNotFoundError(t *testing.T) {
+	response := func(statusCode int, errs ...github.Error) error {
+		return &github.ErrorResponse{
+			Response: &http.Response{StatusCode: statusCode},
+			Errors:   errs,
+		}
+	}
+
+	assert.True(t, isHeadNotFoundError(response(http.StatusNotFound)))
+	assert.True(t, isHeadNotFoundError(response(http.StatusUnprocessableEntity, github.Error{Field: "head", Code: "invalid"})))
+	assert.False(t, isHeadNotFoundError(response(http.StatusUnprocessableEntity, github.Error{Field: "base", Code: "invalid"})))
+	assert.False(t, isHeadNotFoundError(response(http.StatusUnprocessableEntity, github.Error{Field: "head", Code: "missing_field"})))
+	assert.False(t, isHeadNotFoundError(response(http.StatusInternalServerError)))
+	assert.False(t, isHeadNotFoundError(&github.ErrorResponse{}))
+	assert.False(t, isHeadNotFoundError(context.Canceled))
+}
+
+// forkPullRequestServer responds that the head of the pull request can not be found, until it has been requested failures times
+func forkPullRequestServer(t *testing.T, failures int) (*Github, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/test-org/test1/pulls", r.URL.Path)
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message":"Validation Failed","errors":[{"resource":"PullRequest","field":"head","code":"invalid"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number":1,"html_url":"https://github.com/test-org/test1/pull/1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	delay := forkPullRequestDelay
+	forkPullRequestDelay = time.Millisecond
+	t.Cleanup(func() { forkPullRequestDelay = delay })
+
+	return newGithub(client, "", RepositoryListing{}, []domain.MergeType{domain.MergeTypeMerge}, true), &requests
+}
+
+func TestCreatePullRequest_ForkRetry(t *testing.T) {
+	repo := repository{ownerName: "test-org", name: "test1"}
+	fork := repository{ownerName: "test-user", name: "test1"}
+	newPR := domain.NewPullRequest{Title: "title", Head: "branch", Base: "master"}
+
+	t.Run("head found after a retry", func(t *testing.T) {
+		gh, requests := forkPullRequestServer(t, 1)
+
+		pr, err := gh.createPullRequest(context.Background(), repo, fork, newPR)
+		require.NoError(t, err)
+		assert.Equal(t, 1, pr.GetNumber())
+		assert.Equal(t, 2, *requests)
+	})
+
+	t.Run("head never found", func(t *testing.T) {
+		gh, requests := forkPullRequestServer(t, forkPullRequestAttempts)
+
+		_, err := gh.createPullRequest(context.Background(), repo, fork, newPR)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the branch test-user:branch could still not be found after 5 attempts")
+		assert.Equal(t, forkPullRequestAttempts, *requests)
+	})
+
+	t.Run("no retries without a fork", func(t *testing.T) {
+		gh, requests := forkPullRequestServer(t, 1)
+
+		_, err := gh.createPullRequest(context.Background(), repo, repo, newPR)
+		require.Error(t, err)
+		assert.Equal(t, 1, *requests)
+	})
+}
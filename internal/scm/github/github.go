@@ -15,6 +15,8 @@ import (
 	"golang.org/x/oauth2"
 
 	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+	"github.com/lindell/multi-gitter/internal/scm/listing"
 )
 
 // New create a new Github client
@@ -33,15 +35,26 @@ func New(
 	tc := oauth2.NewClient(ctx, ts)
 	tc.Transport = transportMiddleware(tc.Transport)
 
-	var client *github.Client
+	client, err := newClient(baseURL, tc)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGithub(client, baseURL, repoListing, mergeTypes, forkMode), nil
+}
+
+// newClient creates a client of github.com, or of GitHub Enterprise Server if a base url is set
+func newClient(baseURL string, httpClient *http.Client) (*github.Client, error) {
 	if baseURL != "" {
-		var err error
-		client, err = github.NewEnterpriseClient(baseURL, "", tc)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		client = github.NewClient(tc)
+		return github.NewEnterpriseClient(baseURL, "", httpClient)
+	}
+	return github.NewClient(httpClient), nil
+}
+
+func newGithub(client *github.Client, baseURL string, repoListing RepositoryListing, mergeTypes []domain.MergeType, forkMode bool) *Github {
+	var server *serverInfo
+	if baseURL != "" {
+		server = &serverInfo{}
 	}
 
 	return &Github{
@@ -49,7 +62,8 @@ func New(
 		MergeTypes:        mergeTypes,
 		Fork:              forkMode,
 		ghClient:          client,
-	}, nil
+		server:            server,
+	}
 }
 
 // Github contain github configuration
@@ -62,6 +76,8 @@ type Github struct {
 	Fork bool
 
 	ghClient *github.Client
+	server   *serverInfo // Only set when using GitHub Enterprise Server
+	app      *appAuth    // Only set when authenticated as a GitHub App
 }
 
 // RepositoryListing contains information about which repositories that should be fetched
@@ -69,6 +85,25 @@ type RepositoryListing struct {
 	Organizations []string
 	Users         []string
 	Repositories  []RepositoryReference
+	CodeSearches  []string // Code search queries, all repositories with code that matches any of them are used
+	Languages     []string // If set, only repositories with one of these primary languages are used
+
+	IncludeArchived bool // Include archived repositories, which are read-only
+	IncludeDisabled bool // Include disabled repositories, which can not be accessed
+
+	SkipForks bool // Skip repositories that are forks
+	OnlyForks bool // Only use repositories that are forks
+
+	PushedAfter  time.Time // If set, only repositories that have been pushed to after this time are used
+	PushedBefore time.Time // If set, only repositories that have not been pushed to since this time are used
+
+	MaxSize int64 // If set, only repositories with a size, in bytes, of at most this are used
+
+	Visibilities []string // If set, only repositories with one of these visibilities, "public", "private" or "internal", are used
+
+	Template string // If set, only repositories generated from this template repository, in the format "owner/name", are used
+
+	Properties map[string]string // If set, only repositories with all of these custom properties, by name, with the values are used
 }
 
 // RepositoryReference contains information to be able to reference a repository
@@ -84,15 +119,54 @@ func (rr RepositoryReference) String() string {
 
 type repository struct {
 	url           url.URL
+	sshURL        string
 	name          string
 	ownerName     string
 	defaultBranch string
+	archived      bool
+	disabled      bool
+	app           *appAuth
 }
 
 func (r repository) URL(token string) string {
+	u, err := r.CredentialURL(context.Background(), token)
+	if err != nil {
+		log.Errorf("Could not get the credentials of %s: %s", r.FullName(), err)
+		return r.url.String()
+	}
+	return u
+}
+
+// CredentialURL returns the clone url with the credentials, or an error if the credentials could not be created
+func (r repository) CredentialURL(ctx context.Context, token string) (string, error) {
+	// Set the installation token of the owner when authenticated as a GitHub App
+	if r.app != nil {
+		creds, err := r.app.cloneCredentials(ctx, r.ownerName)
+		if err != nil {
+			return "", err
+		}
+		r.url.User = creds
+		return r.url.String(), nil
+	}
+
 	// Set the token as https://TOKEN@url
 	r.url.User = url.User(token)
-	return r.url.String()
+	return r.url.String(), nil
+}
+
+// URLExpires returns true when authenticated as a GitHub App, since installation tokens are only valid for an hour
+func (r repository) URLExpires() bool {
+	return r.app != nil
+}
+
+// Host returns the host of the clone url, without creating any credentials
+func (r repository) Host() string {
+	return r.url.Hostname()
+}
+
+// SSHURL returns the url used when pushing with an ssh key
+func (r repository) SSHURL() string {
+	return r.sshURL
 }
 
 func (r repository) DefaultBranch() string {
@@ -103,6 +177,14 @@ func (r repository) FullName() string {
 	return fmt.Sprintf("%s/%s", r.ownerName, r.name)
 }
 
+func (r repository) Archived() bool {
+	return r.archived
+}
+
+func (r repository) Disabled() bool {
+	return r.disabled
+}
+
 type pullRequest struct {
 	ownerName   string
 	repoName    string
@@ -118,6 +200,10 @@ func (pr pullRequest) String() string {
 	return fmt.Sprintf("%s/%s #%d", pr.ownerName, pr.repoName, pr.number)
 }
 
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.ownerName, pr.repoName)
+}
+
 func (pr pullRequest) Status() domain.PullRequestStatus {
 	return pr.status
 }
@@ -149,7 +235,7 @@ func (g Github) GetRepositories(ctx context.Context) ([]domain.Repository, error
 	for _, r := range allRepos {
 		permissions := r.GetPermissions()
 
-		if r.GetArchived() || r.GetDisabled() || !permissions["pull"] {
+		if !permissions["pull"] {
 			continue
 		}
 		// The user needs push permissions or have defined that the pr should be on a fork
@@ -157,7 +243,7 @@ func (g Github) GetRepositories(ctx context.Context) ([]domain.Repository, error
 			continue
 		}
 
-		newRepo, err := convertRepo(r)
+		newRepo, err := g.convertRepo(r)
 		if err != nil {
 			return nil, err
 		}
@@ -169,6 +255,54 @@ func (g Github) GetRepositories(ctx context.Context) ([]domain.Repository, error
 }
 
 func (g Github) getRepositories(ctx context.Context) ([]*github.Repository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos, err := g.getAllRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]*github.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if (repo.GetArchived() && !g.IncludeArchived) || (repo.GetDisabled() && !g.IncludeDisabled) {
+			continue
+		}
+		if (repo.GetFork() && g.SkipForks) || (!repo.GetFork() && g.OnlyForks) {
+			continue
+		}
+		if !listing.MatchesLanguage(g.Languages, repo.GetLanguage()) {
+			continue
+		}
+		if !listing.PushedWithin(g.PushedAfter, g.PushedBefore, repo.GetPushedAt().Time) {
+			continue
+		}
+		// The size is reported in kilobytes
+		if !listing.WithinSize(g.MaxSize, int64(repo.GetSize())*1024) {
+			continue
+		}
+		if !listing.MatchesVisibility(g.Visibilities, repositoryVisibility(repo)) {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+
+	if g.Template != "" {
+		var err error
+		repos, err = g.filterTemplate(ctx, repos)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(g.Properties) > 0 {
+		return g.filterProperties(ctx, repos)
+	}
+
+	return repos, nil
+}
+
+// getAllRepositories fetches repositories from all sources, including archived and disabled repositories
+func (g Github) getAllRepositories(ctx context.Context) ([]*github.Repository, error) {
 	allRepos := []*github.Repository{}
 
 	for _, org := range g.Organizations {
@@ -195,6 +329,14 @@ func (g Github) getRepositories(ctx context.Context) ([]*github.Repository, erro
 		allRepos = append(allRepos, repo)
 	}
 
+	for _, query := range g.CodeSearches {
+		repos, err := g.getCodeSearchRepositories(ctx, query)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not search for code matching %s", query)
+		}
+		allRepos = append(allRepos, repos...)
+	}
+
 	// Remove duplicate repos
 	repoMap := map[string]*github.Repository{}
 	for _, repo := range allRepos {
@@ -202,9 +344,6 @@ func (g Github) getRepositories(ctx context.Context) ([]*github.Repository, erro
 	}
 	allRepos = make([]*github.Repository, 0, len(repoMap))
 	for _, repo := range repoMap {
-		if repo.GetArchived() || repo.GetDisabled() {
-			continue
-		}
 		allRepos = append(allRepos, repo)
 	}
 	sort.Slice(allRepos, func(i, j int) bool {
@@ -265,9 +404,23 @@ func (g Github) getRepository(ctx context.Context, repoRef RepositoryReference)
 	if err != nil {
 		return nil, err
 	}
+
+	// GitHub redirects requests to repositories that have been renamed or transferred
+	if !strings.EqualFold(repo.GetFullName(), repoRef.String()) {
+		log.Warnf("The repository %s has been renamed or transferred to %s, which will be used instead", repoRef.String(), repo.GetFullName())
+	}
+
 	return repo, nil
 }
 
+// PullRequestLimits returns the maximum length of the title and body of pull requests
+func (g Github) PullRequestLimits() domain.PullRequestLimits {
+	return domain.PullRequestLimits{
+		MaxTitleLength: 256,
+		MaxBodyLength:  65536,
+	}
+}
+
 // CreatePullRequest creates a pull request
 func (g Github) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
 	r := repo.(repository)
@@ -275,41 +428,135 @@ func (g Github) CreatePullRequest(ctx context.Context, repo domain.Repository, p
 
 	pr, err := g.createPullRequest(ctx, r, prR, newPR)
 	if err != nil {
-		return nil, err
+		return nil, g.versionError(ctx, err)
 	}
 
 	if err := g.addReviewers(ctx, r, newPR, pr); err != nil {
-		return nil, err
+		return nil, g.versionError(ctx, err)
+	}
+
+	if err := g.addAssignees(ctx, r, newPR, pr); err != nil {
+		return nil, g.versionError(ctx, err)
+	}
+
+	if err := g.addLabels(ctx, r, newPR, pr); err != nil {
+		return nil, g.versionError(ctx, err)
+	}
+
+	if err := g.setMilestone(ctx, r, newPR, pr); err != nil {
+		return nil, g.versionError(ctx, err)
 	}
 
 	return convertPullRequest(pr), nil
 }
 
+// The number of attempts and the time between them, when creating a pull request from a fork that is not yet ready
+var (
+	forkPullRequestAttempts = 5
+	forkPullRequestDelay    = time.Second * 3
+)
+
 func (g Github) createPullRequest(ctx context.Context, repo repository, prRepo repository, newPR domain.NewPullRequest) (*github.PullRequest, error) {
 	head := fmt.Sprintf("%s:%s", prRepo.ownerName, newPR.Head)
-	pr, _, err := g.ghClient.PullRequests.Create(ctx, repo.ownerName, repo.name, &github.NewPullRequest{
-		Title: &newPR.Title,
-		Body:  &newPR.Body,
-		Head:  &head,
-		Base:  &newPR.Base,
-	})
-	if err != nil {
-		return nil, err
+	isFork := repo.FullName() != prRepo.FullName()
+
+	if newPR.Draft && !g.supports(ctx, draftPullRequestsVersion) {
+		log.WithField("repo", repo.FullName()).Warnf("Draft pull requests are not supported by GitHub Enterprise Server %s, creating a regular pull request",
+			g.enterpriseVersion(ctx))
+		newPR.Draft = false
 	}
 
-	return pr, nil
+	for i := 1; ; i++ {
+		pr, _, err := g.ghClient.PullRequests.Create(ctx, repo.ownerName, repo.name, &github.NewPullRequest{
+			Title: &newPR.Title,
+			Body:  &newPR.Body,
+			Head:  &head,
+			Base:  &newPR.Base,
+			Draft: &newPR.Draft,
+		})
+		if err == nil {
+			return pr, nil
+		}
+
+		// When using forks, the fork or the pushed branch might not have propagated yet
+		if !isFork || !isHeadNotFoundError(err) {
+			return nil, err
+		}
+		if i >= forkPullRequestAttempts {
+			return nil, errors.Wrapf(err, "the branch %s could still not be found after %d attempts", head, i)
+		}
+
+		log.WithField("repo", repo.FullName()).Debugf("The fork branch %s is not ready yet, retrying", head)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(forkPullRequestDelay):
+		}
+	}
 }
 
 func (g Github) addReviewers(ctx context.Context, repo repository, newPR domain.NewPullRequest, createdPR *github.PullRequest) error {
 	if len(newPR.Reviewers) == 0 {
 		return nil
 	}
+	users, teams := domain.SplitReviewers(newPR.Reviewers)
+	if len(teams) > 0 && !g.supports(ctx, teamReviewersVersion) {
+		log.WithField("repo", repo.FullName()).Warnf("Team reviewers are not supported by GitHub Enterprise Server %s, skipping %s",
+			g.enterpriseVersion(ctx), strings.Join(teams, ", "))
+		teams = nil
+		if len(users) == 0 {
+			return nil
+		}
+	}
 	_, _, err := g.ghClient.PullRequests.RequestReviewers(ctx, repo.ownerName, repo.name, createdPR.GetNumber(), github.ReviewersRequest{
-		Reviewers: newPR.Reviewers,
+		Reviewers:     users,
+		TeamReviewers: domain.TeamNames(teams),
 	})
 	return err
 }
 
+func (g Github) addAssignees(ctx context.Context, repo repository, newPR domain.NewPullRequest, createdPR *github.PullRequest) error {
+	if len(newPR.Assignees) == 0 {
+		return nil
+	}
+	_, _, err := g.ghClient.Issues.AddAssignees(ctx, repo.ownerName, repo.name, createdPR.GetNumber(), newPR.Assignees)
+	return err
+}
+
+func (g Github) addLabels(ctx context.Context, repo repository, newPR domain.NewPullRequest, createdPR *github.PullRequest) error {
+	if len(newPR.Labels) == 0 {
+		return nil
+	}
+	_, _, err := g.ghClient.Issues.AddLabelsToIssue(ctx, repo.ownerName, repo.name, createdPR.GetNumber(), newPR.Labels)
+	return err
+}
+
+func (g Github) setMilestone(ctx context.Context, repo repository, newPR domain.NewPullRequest, createdPR *github.PullRequest) error {
+	if newPR.Milestone == "" {
+		return nil
+	}
+
+	milestones, _, err := g.ghClient.Issues.ListMilestones(ctx, repo.ownerName, repo.name, &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.GetTitle() == newPR.Milestone {
+			_, _, err := g.ghClient.Issues.Edit(ctx, repo.ownerName, repo.name, createdPR.GetNumber(), &github.IssueRequest{
+				Milestone: milestone.Number,
+			})
+			return err
+		}
+	}
+
+	log.WithField("repo", repo.FullName()).Warnf("Could not find the milestone %s", newPR.Milestone)
+	return nil
+}
+
 // GetPullRequests gets all pull requests of with a specific branch
 func (g Github) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
 	// TODO: If this is implemented with the GitHub v4 graphql api, it would be much faster
@@ -354,8 +601,178 @@ func (g Github) GetPullRequests(ctx context.Context, branchName string) ([]domai
 	return prStatuses, nil
 }
 
+// GetPullRequest gets a pull request in the repository with the name in the format "owner/name"
+func (g Github) GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error) {
+	repoRef, err := ParseRepositoryReference(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := g.ghClient.PullRequests.Get(ctx, repoRef.OwnerName, repoRef.Name, number)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := g.getPrStatus(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	localPR := convertPullRequest(pr)
+	localPR.status = status
+	return localPR, nil
+}
+
+// GetCampaignPullRequests gets the latest pull request in each repository that was created within a campaign
+func (g Github) GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error) {
+	marker := domain.CampaignMarker(campaignID)
+
+	repos, err := g.getRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prStatuses := []domain.PullRequest{}
+	for _, r := range repos {
+		repoOwner := r.GetOwner().GetLogin()
+		repoName := r.GetName()
+		log := log.WithField("repo", fmt.Sprintf("%s/%s", repoOwner, repoName))
+		log.Debug("Fetching latest campaign pull request")
+
+		// Only the latest pull requests are searched, campaign pull requests are not expected to be old
+		prs, _, err := g.ghClient.PullRequests.List(ctx, repoOwner, repoName, &github.PullRequestListOptions{
+			State:     "all",
+			Direction: "desc",
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range prs {
+			if !strings.Contains(pr.GetBody(), marker) {
+				continue
+			}
+
+			status, err := g.getPrStatus(ctx, pr)
+			if err != nil {
+				return nil, err
+			}
+
+			localPR := convertPullRequest(pr)
+			localPR.status = status
+			prStatuses = append(prStatuses, localPR)
+			break
+		}
+	}
+
+	return prStatuses, nil
+}
+
+// GetOpenPullRequestDiffs gets the diffs of all open pull requests in a repository
+func (g Github) GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error) {
+	r := repo.(repository)
+
+	prs, _, err := g.ghClient.PullRequests.List(ctx, r.ownerName, r.name, &github.PullRequestListOptions{
+		State: "open",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]domain.PullRequestDiff, len(prs))
+	for i, pr := range prs {
+		diff, _, err := g.ghClient.PullRequests.GetRaw(ctx, r.ownerName, r.name, pr.GetNumber(), github.RawOptions{Type: github.Diff})
+		if err != nil {
+			return nil, err
+		}
+
+		diffs[i] = domain.PullRequestDiff{
+			PullRequest: convertPullRequest(pr),
+			Branch:      pr.GetHead().GetRef(),
+			Diff:        diff,
+		}
+	}
+
+	return diffs, nil
+}
+
+// GetPullRequestChecks gets the commit statuses and check runs of the last commit of a pull request
+func (g Github) GetPullRequestChecks(ctx context.Context, pullReq domain.PullRequest) ([]domain.Check, error) {
+	pr := pullReq.(pullRequest)
+
+	ghPR, _, err := g.ghClient.PullRequests.Get(ctx, pr.ownerName, pr.repoName, pr.number)
+	if err != nil {
+		return nil, err
+	}
+	sha := ghPR.GetHead().GetSHA()
+
+	combinedStatus, _, err := g.ghClient.Repositories.GetCombinedStatus(ctx, pr.ownerName, pr.repoName, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []domain.Check
+	for _, status := range combinedStatus.Statuses {
+		checks = append(checks, domain.Check{
+			Name:   status.GetContext(),
+			Status: commitStatusToCheckStatus(status.GetState()),
+		})
+	}
+
+	checkRuns, _, err := g.ghClient.Checks.ListCheckRunsForRef(ctx, pr.ownerName, pr.repoName, sha, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range checkRuns.CheckRuns {
+		checks = append(checks, domain.Check{
+			Name:   run.GetName(),
+			Status: checkRunToCheckStatus(run),
+		})
+	}
+
+	return checks, nil
+}
+
+func commitStatusToCheckStatus(state string) domain.CheckStatus {
+	switch state {
+	case "pending":
+		return domain.CheckStatusPending
+	case "success":
+		return domain.CheckStatusSuccess
+	case "failure", "error":
+		return domain.CheckStatusFailure
+	}
+	return domain.CheckStatusUnknown
+}
+
+func checkRunToCheckStatus(run *github.CheckRun) domain.CheckStatus {
+	if run.GetStatus() != "completed" {
+		return domain.CheckStatusPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return domain.CheckStatusSuccess
+	case "failure", "cancelled", "timed_out", "action_required":
+		return domain.CheckStatusFailure
+	}
+	return domain.CheckStatusUnknown
+}
+
 // MergePullRequest merges a pull request
 func (g Github) MergePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
+	return g.MergePullRequestWithTypes(ctx, pullReq, g.MergeTypes)
+}
+
+// MergePullRequestWithTypes merges a pull request with the first of the merge types that is allowed in the repository
+func (g Github) MergePullRequestWithTypes(ctx context.Context, pullReq domain.PullRequest, mergeTypes []domain.MergeType) error {
 	pr := pullReq.(pullRequest)
 
 	// We need to fetch the repo again since no AllowXMerge is present in listings of repositories
@@ -365,7 +782,7 @@ func (g Github) MergePullRequest(ctx context.Context, pullReq domain.PullRequest
 	}
 
 	// Filter out all merge types to only the allowed ones, but keep the order of the ones left
-	mergeTypes := domain.MergeTypeIntersection(g.MergeTypes, repoMergeTypes(repo))
+	mergeTypes = domain.MergeTypeIntersection(mergeTypes, repoMergeTypes(repo))
 	if len(mergeTypes) == 0 {
 		return errors.New("none of the configured merge types was permitted")
 	}
@@ -374,13 +791,39 @@ func (g Github) MergePullRequest(ctx context.Context, pullReq domain.PullRequest
 		MergeMethod: mergeTypeGhName[mergeTypes[0]],
 	})
 	if err != nil {
-		return err
+		return g.versionError(ctx, err)
 	}
 
 	_, err = g.ghClient.Git.DeleteRef(ctx, pr.prOwnerName, pr.prRepoName, fmt.Sprintf("heads/%s", pr.branchName))
 	return err
 }
 
+// GetPullRequestCommits gets the commits of a pull request
+func (g Github) GetPullRequestCommits(ctx context.Context, pullReq domain.PullRequest) ([]domain.Commit, error) {
+	pr := pullReq.(pullRequest)
+
+	var commits []domain.Commit
+	for i := 1; ; i++ {
+		repoCommits, _, err := g.ghClient.PullRequests.ListCommits(ctx, pr.ownerName, pr.repoName, pr.number, &github.ListOptions{
+			Page:    i,
+			PerPage: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range repoCommits {
+			commits = append(commits, domain.Commit{
+				Hash:    c.GetSHA(),
+				Parents: len(c.Parents),
+			})
+		}
+		if len(repoCommits) < 100 {
+			break
+		}
+	}
+	return commits, nil
+}
+
 // ClosePullRequest closes a pull request
 func (g Github) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest) error {
 	pr := pullReq.(pullRequest)
@@ -396,6 +839,20 @@ func (g Github) ClosePullRequest(ctx context.Context, pullReq domain.PullRequest
 	return err
 }
 
+// CreateIssue creates an issue in the repository with the name in the format "owner/name"
+func (g Github) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	ref, err := ParseRepositoryReference(repoName)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = g.ghClient.Issues.Create(ctx, ref.OwnerName, ref.Name, &github.IssueRequest{
+		Title: &issue.Title,
+		Body:  &issue.Body,
+	})
+	return err
+}
+
 // ForkRepository forks a repository. If newOwner is empty, fork on the logged in user
 func (g Github) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
 	r := repo.(repository)
@@ -418,13 +875,212 @@ func (g Github) ForkRepository(ctx context.Context, repo domain.Repository, newO
 				continue
 			}
 			// The fork does now exist
-			return convertRepo(repo)
+			return g.convertRepo(repo)
 		}
 
 		return nil, errors.New("time waiting for fork to complete was exceeded")
 	}
 
-	return convertRepo(createdRepo)
+	return g.convertRepo(createdRepo)
+}
+
+type fork struct {
+	repository
+	parentOwnerName string
+	parentName      string
+	lastUpdated     time.Time
+}
+
+func (f fork) LastUpdated() time.Time {
+	return f.lastUpdated
+}
+
+// GetForks gets all forks owned by owner. If owner is empty, the logged in user is used
+func (g Github) GetForks(ctx context.Context, owner string) ([]domain.Fork, error) {
+	// Only organizations can be listed as organizations, the repositories of users are listed by their owner
+	isOrg := false
+	if owner != "" {
+		user, _, err := g.ghClient.Users.Get(ctx, owner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the fork owner %s", owner)
+		}
+		isOrg = user.GetType() == "Organization"
+	}
+
+	var repos []*github.Repository
+	for i := 1; ; i++ {
+		var rr []*github.Repository
+		var err error
+		switch {
+		case owner == "":
+			rr, _, err = g.ghClient.Repositories.List(ctx, "", &github.RepositoryListOptions{
+				Affiliation: "owner",
+				ListOptions: github.ListOptions{Page: i, PerPage: 100},
+			})
+		case !isOrg:
+			rr, _, err = g.ghClient.Repositories.List(ctx, owner, &github.RepositoryListOptions{
+				Type:        "owner",
+				ListOptions: github.ListOptions{Page: i, PerPage: 100},
+			})
+		default:
+			rr, _, err = g.ghClient.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{
+				Type:        "forks",
+				ListOptions: github.ListOptions{Page: i, PerPage: 100},
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, rr...)
+		if len(rr) != 100 {
+			break
+		}
+	}
+
+	forks := []domain.Fork{}
+	for _, r := range repos {
+		if !r.GetFork() {
+			continue
+		}
+
+		// The parent is not included when listing repositories
+		repo, _, err := g.ghClient.Repositories.Get(ctx, r.GetOwner().GetLogin(), r.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		convertedRepo, err := g.convertRepo(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		forks = append(forks, fork{
+			repository:      convertedRepo,
+			parentOwnerName: repo.GetParent().GetOwner().GetLogin(),
+			parentName:      repo.GetParent().GetName(),
+			lastUpdated:     repo.GetPushedAt().Time,
+		})
+	}
+
+	return forks, nil
+}
+
+// GetForkPullRequests gets all pull requests made from the branch of a fork
+func (g Github) GetForkPullRequests(ctx context.Context, forkRepo domain.Fork, branchName string) ([]domain.PullRequest, error) {
+	f := forkRepo.(fork)
+
+	prs, _, err := g.ghClient.PullRequests.List(ctx, f.parentOwnerName, f.parentName, &github.PullRequestListOptions{
+		Head:  fmt.Sprintf("%s:%s", f.ownerName, branchName),
+		State: "all",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]domain.PullRequest, len(prs))
+	for i, pr := range prs {
+		status, err := g.getPrStatus(ctx, pr)
+		if err != nil {
+			return nil, err
+		}
+
+		localPR := convertPullRequest(pr)
+		localPR.status = status
+		ret[i] = localPR
+	}
+
+	return ret, nil
+}
+
+// GetOpenForkPullRequests gets all open pull requests made from any branch of a fork to its parent.
+// The status of the pull requests is not fetched
+func (g Github) GetOpenForkPullRequests(ctx context.Context, forkRepo domain.Fork) ([]domain.PullRequest, error) {
+	f := forkRepo.(fork)
+
+	ret := []domain.PullRequest{}
+	for i := 1; ; i++ {
+		prs, _, err := g.ghClient.PullRequests.List(ctx, f.parentOwnerName, f.parentName, &github.PullRequestListOptions{
+			State:       "open",
+			ListOptions: github.ListOptions{Page: i, PerPage: 100},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range prs {
+			if strings.EqualFold(pr.GetHead().GetRepo().GetFullName(), f.FullName()) {
+				ret = append(ret, convertPullRequest(pr))
+			}
+		}
+
+		if len(prs) != 100 {
+			return ret, nil
+		}
+	}
+}
+
+// DeleteRepository deletes a repository
+func (g Github) DeleteRepository(ctx context.Context, repo domain.Repository) error {
+	var r repository
+	switch repo := repo.(type) {
+	case fork:
+		r = repo.repository
+	case repository:
+		r = repo
+	default:
+		return errors.New("unknown repository type")
+	}
+
+	_, err := g.ghClient.Repositories.Delete(ctx, r.ownerName, r.name)
+	return err
+}
+
+// RenameDefaultBranch renames the default branch of a repository. GitHub moves the branch protection
+// and retargets open pull requests automatically
+func (g Github) RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error {
+	r := repo.(repository)
+
+	u := fmt.Sprintf("repos/%s/%s/branches/%s/rename", r.ownerName, r.name, url.PathEscape(r.defaultBranch))
+	req, err := g.ghClient.NewRequest("POST", u, map[string]string{
+		"new_name": newName,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = g.ghClient.Do(ctx, req, nil)
+	return err
+}
+
+// GetCurrentUserAuthor gets the name and noreply email of the logged in user, to be used as commit author
+func (g Github) GetCurrentUserAuthor(ctx context.Context) (domain.CommitAuthor, error) {
+	var user *github.User
+	var err error
+	if g.app != nil {
+		// Installation tokens can't get the authenticated user, the commits are instead made by the bot user of the app
+		user, err = g.appBotUser(ctx)
+	} else {
+		user, _, err = g.ghClient.Users.Get(ctx, "")
+	}
+	if err != nil {
+		return domain.CommitAuthor{}, err
+	}
+
+	name := user.GetName()
+	if name == "" {
+		name = user.GetLogin()
+	}
+
+	// GitHub attributes commits made with the noreply email to the user, no matter the email privacy settings
+	host := g.ghClient.BaseURL.Hostname()
+	if host == "api.github.com" {
+		host = "github.com"
+	}
+
+	return domain.CommitAuthor{
+		Name:  name,
+		Email: fmt.Sprintf("%d+%s@users.noreply.%s", user.GetID(), user.GetLogin(), host),
+	}, nil
 }
 
 // GetAutocompleteOrganizations gets organizations for autocompletion
@@ -485,7 +1141,7 @@ func (g Github) GetAutocompleteRepositories(ctx context.Context, str string) ([]
 	return ret, nil
 }
 
-func convertRepo(r *github.Repository) (repository, error) {
+func (g Github) convertRepo(r *github.Repository) (repository, error) {
 	u, err := url.Parse(r.GetCloneURL())
 	if err != nil {
 		return repository{}, errors.Wrap(err, "could not parse github clone error")
@@ -493,9 +1149,13 @@ func convertRepo(r *github.Repository) (repository, error) {
 
 	return repository{
 		url:           *u,
+		sshURL:        r.GetSSHURL(),
 		name:          r.GetName(),
 		ownerName:     r.GetOwner().GetLogin(),
 		defaultBranch: r.GetDefaultBranch(),
+		archived:      r.GetArchived(),
+		disabled:      r.GetDisabled(),
+		app:           g.app,
 	}, nil
 }
 
@@ -3,10 +3,12 @@ package github_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lindell/multi-gitter/internal/domain"
 	"github.com/lindell/multi-gitter/internal/scm/github"
@@ -55,6 +57,7 @@ func Test_GetRepositories(t *testing.T) {
 						"site_admin": false
 					},
 					"html_url": "https://github.com/test-org/test1",
+					"language": "Python",
 					"archived": false,
 					"disabled": false,
 					"default_branch": "master",
@@ -63,7 +66,9 @@ func Test_GetRepositories(t *testing.T) {
 						"push": true,
 						"pull": true
 					},
-					"created_at": "2020-01-01T16:49:16Z"
+					"created_at": "2020-01-01T16:49:16Z",
+					"pushed_at": "2020-06-01T10:00:00Z",
+					"size": 5242880
 				}
 			]`,
 			"/repos/test-org/test1": `{
@@ -87,18 +92,85 @@ func Test_GetRepositories(t *testing.T) {
 				},
 				"created_at": "2020-01-02T16:49:16Z"
 			}`,
+			"/repos/lindell/test2": `{
+				"id": 3,
+				"name": "test2",
+				"full_name": "lindell/test2",
+				"owner": {
+					"login": "lindell",
+					"type": "User"
+				},
+				"template_repository": {
+					"id": 5,
+					"name": "template",
+					"full_name": "test-org/template"
+				}
+			}`,
+			"/repos/test-org/test1/properties/values": `[
+				{
+					"property_name": "tier",
+					"value": "production"
+				},
+				{
+					"property_name": "teams",
+					"value": ["platform", "payments"]
+				}
+			]`,
+			"/repos/lindell/test2/properties/values": `[
+				{
+					"property_name": "tier",
+					"value": "development"
+				},
+				{
+					"property_name": "teams",
+					"value": null
+				}
+			]`,
+			"/search/code": `{
+				"total_count": 2,
+				"incomplete_results": false,
+				"items": [
+					{
+						"name": "Dockerfile",
+						"path": "Dockerfile",
+						"repository": {
+							"id": 1,
+							"name": "test1",
+							"full_name": "test-org/test1",
+							"owner": {
+								"login": "test-org"
+							}
+						}
+					},
+					{
+						"name": "Dockerfile",
+						"path": "build/Dockerfile",
+						"repository": {
+							"id": 1,
+							"name": "test1",
+							"full_name": "test-org/test1",
+							"owner": {
+								"login": "test-org"
+							}
+						}
+					}
+				]
+			}`,
 			"/users/test-user/repos": `[
 				{
 					"id": 3,
 					"name": "test2",
 					"full_name": "lindell/test2",
 					"private": false,
+					"visibility": "internal",
 					"owner": {
 						"login": "lindell",
 						"type": "User",
 						"site_admin": false
 					},
 					"html_url": "https://github.com/lindell/test2",
+					"language": "Go",
+					"fork": true,
 					"archived": false,
 					"disabled": false,
 					"default_branch": "main",
@@ -107,7 +179,30 @@ func Test_GetRepositories(t *testing.T) {
 						"push": true,
 						"pull": true
 					},
-					"created_at": "2020-01-03T16:49:16Z"
+					"created_at": "2020-01-03T16:49:16Z",
+					"pushed_at": "2023-03-01T10:00:00Z",
+					"size": 1024
+				},
+				{
+					"id": 4,
+					"name": "archived",
+					"full_name": "lindell/archived",
+					"private": false,
+					"owner": {
+						"login": "lindell",
+						"type": "User",
+						"site_admin": false
+					},
+					"html_url": "https://github.com/lindell/archived",
+					"archived": true,
+					"disabled": false,
+					"default_branch": "main",
+					"permissions": {
+						"admin": true,
+						"push": true,
+						"pull": true
+					},
+					"created_at": "2020-01-04T16:49:16Z"
 				}
 			]`,
 		},
@@ -163,6 +258,195 @@ func Test_GetRepositories(t *testing.T) {
 		}
 	}
 
+	// Archived
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Users:           []string{"test-user"},
+			IncludeArchived: true,
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 2) {
+			assert.Equal(t, "lindell/archived", repos[1].FullName())
+			assert.True(t, repos[1].(domain.Archivable).Archived())
+		}
+	}
+
+	// Skip forks
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			SkipForks:     true,
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "test-org/test1", repos[0].FullName())
+		}
+	}
+
+	// Only forks
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			OnlyForks:     true,
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+
+	// Pushed after
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			PushedAfter:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+
+	// Pushed before
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			PushedBefore:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "test-org/test1", repos[0].FullName())
+		}
+	}
+
+	// Max size
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			MaxSize:       1 << 30,
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+
+	// Visibility
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			Visibilities:  []string{"Internal"},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			Visibilities:  []string{"public", "private"},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "test-org/test1", repos[0].FullName())
+		}
+	}
+
+	// Template
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			Template:      "Test-Org/Template",
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+
+	// Custom properties
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			Properties:    map[string]string{"tier": "production", "teams": "payments"},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "test-org/test1", repos[0].FullName())
+		}
+	}
+
+	// Code search
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			CodeSearches: []string{"org:test-org filename:Dockerfile node:14"},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "test-org/test1", repos[0].FullName())
+			assert.Equal(t, "master", repos[0].DefaultBranch())
+		}
+	}
+
+	// Language
+	{
+		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+			Organizations: []string{"test-org"},
+			Users:         []string{"test-user"},
+			Languages:     []string{"go"},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, repos, 1) {
+			assert.Equal(t, "lindell/test2", repos[0].FullName())
+		}
+	}
+
 	// Multiple
 	{
 		gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
@@ -187,3 +471,434 @@ func Test_GetRepositories(t *testing.T) {
 		}
 	}
 }
+
+func Test_CreatePullRequest_EnterpriseVersion(t *testing.T) {
+	transport := testTransport{
+		pathBodies: map[string]string{
+			"/api/v3/meta": `{
+				"verifiable_password_authentication": true,
+				"installed_version": "2.12.4"
+			}`,
+			"/api/v3/repos/test-org/test1": `{
+				"id": 2,
+				"name": "test1",
+				"full_name": "test-org/test1",
+				"private": false,
+				"owner": {
+					"login": "test-org",
+					"type": "Organization",
+					"site_admin": false
+				},
+				"html_url": "https://ghe.example.com/test-org/test1",
+				"archived": false,
+				"disabled": false,
+				"default_branch": "master",
+				"permissions": {
+					"admin": true,
+					"push": true,
+					"pull": true
+				},
+				"created_at": "2020-01-02T16:49:16Z"
+			}`,
+			"/api/v3/repos/test-org/test1/pulls": `{
+				"number": 1,
+				"html_url": "https://ghe.example.com/test-org/test1/pull/1",
+				"head": {
+					"ref": "branch",
+					"user": {
+						"login": "test-org"
+					},
+					"repo": {
+						"name": "test1",
+						"owner": {
+							"login": "test-org"
+						}
+					}
+				},
+				"base": {
+					"user": {
+						"login": "test-org"
+					},
+					"repo": {
+						"name": "test1",
+						"owner": {
+							"login": "test-org"
+						}
+					}
+				}
+			}`,
+		},
+	}
+
+	gh, err := github.New("", "https://ghe.example.com/api/v3/", transport.Wrapper, github.RepositoryListing{
+		Repositories: []github.RepositoryReference{
+			{
+				OwnerName: "test-org",
+				Name:      "test1",
+			},
+		},
+	}, []domain.MergeType{domain.MergeTypeMerge}, false)
+	require.NoError(t, err)
+
+	repos, err := gh.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+
+	// Team reviewers are not supported by the server version, and should be skipped without any request being made
+	pr, err := gh.CreatePullRequest(context.Background(), repos[0], repos[0], domain.NewPullRequest{
+		Title: "title",
+		Head:  "branch",
+		Base:  "master",
+		Reviewers: []domain.Reviewer{
+			{Type: domain.ReviewerTypeTeam, Name: "team"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-org/test1 #1", pr.String())
+}
+
+// enterpriseTransport responds with the version header of GitHub Enterprise Server, and stores the bodies of the requests
+type enterpriseTransport struct {
+	testTransport
+	version string
+	bodies  map[string]string
+}
+
+func (et enterpriseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		et.bodies[req.URL.Path] = string(body)
+	}
+	resp, err := et.testTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set("X-GitHub-Enterprise-Version", et.version)
+	return resp, nil
+}
+
+func (et enterpriseTransport) Wrapper(http.RoundTripper) http.RoundTripper {
+	return et
+}
+
+func Test_EnterpriseVersionFeatures(t *testing.T) {
+	newClient := func(t *testing.T, version string) (*github.Github, domain.Repository, enterpriseTransport) {
+		transport := enterpriseTransport{
+			testTransport: testTransport{
+				pathBodies: map[string]string{
+					"/api/v3/meta": `{
+						"verifiable_password_authentication": true
+					}`,
+					"/api/v3/repos/test-org/test1": `{
+						"id": 2,
+						"name": "test1",
+						"full_name": "test-org/test1",
+						"owner": {
+							"login": "test-org",
+							"type": "Organization"
+						},
+						"default_branch": "master",
+						"permissions": {
+							"push": true,
+							"pull": true
+						}
+					}`,
+					"/api/v3/repos/test-org/test1/pulls": `{
+						"number": 1,
+						"base": {
+							"user": {
+								"login": "test-org"
+							},
+							"repo": {
+								"name": "test1"
+							}
+						}
+					}`,
+				},
+			},
+			version: version,
+			bodies:  map[string]string{},
+		}
+
+		gh, err := github.New("", "https://ghe.example.com/api/v3/", transport.Wrapper, github.RepositoryListing{
+			Repositories: []github.RepositoryReference{
+				{
+					OwnerName: "test-org",
+					Name:      "test1",
+				},
+			},
+		}, []domain.MergeType{domain.MergeTypeMerge}, false)
+		require.NoError(t, err)
+
+		repos, err := gh.GetRepositories(context.Background())
+		require.NoError(t, err)
+		require.Len(t, repos, 1)
+		return gh, repos[0], transport
+	}
+
+	newPR := domain.NewPullRequest{
+		Title: "title",
+		Head:  "branch",
+		Base:  "master",
+		Draft: true,
+	}
+
+	t.Run("3.8", func(t *testing.T) {
+		gh, repo, transport := newClient(t, "3.8.0")
+
+		_, err := gh.CreatePullRequest(context.Background(), repo, repo, newPR)
+		require.NoError(t, err)
+		assert.Contains(t, transport.bodies["/api/v3/repos/test-org/test1/pulls"], `"draft":true`)
+
+		// Rulesets are not supported, and the repository should be skipped without any request being made
+		err = gh.ProtectBranch(context.Background(), repo, domain.BranchProtection{Branch: "master"})
+		assert.EqualError(t, err, "rulesets are not supported by GitHub Enterprise Server 3.8, 3.11 or later is required")
+		assert.IsType(t, domain.SkipError{}, err)
+	})
+
+	t.Run("2.16", func(t *testing.T) {
+		gh, repo, transport := newClient(t, "2.16.3")
+
+		// Draft pull requests are not supported, and a regular pull request should be created instead
+		_, err := gh.CreatePullRequest(context.Background(), repo, repo, newPR)
+		require.NoError(t, err)
+		assert.Contains(t, transport.bodies["/api/v3/repos/test-org/test1/pulls"], `"draft":false`)
+	})
+}
+
+// redirectTransport redirects requests of some paths, the same way GitHub does with renamed repositories
+type redirectTransport struct {
+	testTransport
+	redirects map[string]string
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	location, ok := rt.redirects[req.URL.Path]
+	if !ok {
+		return rt.testTransport.RoundTrip(req)
+	}
+	header := make(http.Header)
+	header.Set("Location", location)
+	return &http.Response{
+		Status:     "301 Moved Permanently",
+		StatusCode: http.StatusMovedPermanently,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+		Header:     header,
+	}, nil
+}
+
+func (rt redirectTransport) Wrapper(http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+func Test_GetPullRequest_Renamed(t *testing.T) {
+	transport := redirectTransport{
+		testTransport: testTransport{
+			pathBodies: map[string]string{
+				"/repositories/1/pulls/2": `{
+					"number": 2,
+					"html_url": "https://github.com/new-org/new-name/pull/2",
+					"merged_at": "2021-01-01T00:00:00Z",
+					"head": {
+						"ref": "custom-branch-name",
+						"user": {
+							"login": "new-org"
+						},
+						"repo": {
+							"name": "new-name"
+						}
+					},
+					"base": {
+						"ref": "master",
+						"user": {
+							"login": "new-org"
+						},
+						"repo": {
+							"name": "new-name",
+							"full_name": "new-org/new-name"
+						}
+					}
+				}`,
+			},
+		},
+		redirects: map[string]string{
+			"/repos/old-org/old-name/pulls/2": "https://api.github.com/repositories/1/pulls/2",
+		},
+	}
+
+	gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{}, []domain.MergeType{domain.MergeTypeMerge}, false)
+	require.NoError(t, err)
+
+	// The pull request has the new name of the repository, which is used to update reports
+	pr, err := gh.GetPullRequest(context.Background(), "old-org/old-name", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "new-org/new-name #2", pr.String())
+	assert.Equal(t, domain.PullRequestStatusMerged, pr.Status())
+}
+
+func Test_ImpersonationToken(t *testing.T) {
+	transport := testTransport{
+		pathBodies: map[string]string{
+			"/api/v3/admin/users/service-bot/authorizations": `{
+				"id": 1,
+				"token": "impersonation-token",
+				"scopes": ["repo", "read:org", "workflow"]
+			}`,
+		},
+	}
+
+	token, err := github.ImpersonationToken(context.Background(), "admin-token", "https://ghe.example.com/api/v3/", transport.Wrapper, "service-bot")
+	require.NoError(t, err)
+	assert.Equal(t, "impersonation-token", token)
+
+	_, err = github.ImpersonationToken(context.Background(), "admin-token", "", transport.Wrapper, "service-bot")
+	assert.EqualError(t, err, "impersonating a user is only supported on GitHub Enterprise Server")
+}
+
+func Test_GetRepositorySettings_BranchProtection(t *testing.T) {
+	transport := testTransport{
+		pathBodies: map[string]string{
+			"/repos/test-org/test1": `{
+				"id": 1,
+				"name": "test1",
+				"full_name": "test-org/test1",
+				"owner": {
+					"login": "test-org",
+					"type": "Organization"
+				},
+				"default_branch": "main",
+				"permissions": {
+					"push": true,
+					"pull": true
+				}
+			}`,
+			"/repos/test-org/test1/rulesets": `[
+				{
+					"id": 7,
+					"name": "multi-gitter: main"
+				}
+			]`,
+			"/repos/test-org/test1/rulesets/7": `{
+				"id": 7,
+				"name": "multi-gitter: main",
+				"bypass_actors": [],
+				"rules": [
+					{
+						"type": "pull_request",
+						"parameters": {
+							"required_approving_review_count": 2
+						}
+					},
+					{
+						"type": "required_status_checks",
+						"parameters": {
+							"required_status_checks": [
+								{
+									"context": "build"
+								}
+							]
+						}
+					}
+				]
+			}`,
+			"/repos/test-org/test1/hooks": `[]`,
+			"/repos/test-org/test1/branches/main": `{
+				"name": "main"
+			}`,
+		},
+	}
+
+	gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{
+		Repositories: []github.RepositoryReference{
+			{
+				OwnerName: "test-org",
+				Name:      "test1",
+			},
+		},
+	}, []domain.MergeType{domain.MergeTypeMerge}, false)
+	require.NoError(t, err)
+
+	repos, err := gh.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+
+	// The settings command reads the same ruleset as the branch-protection command
+	settings, err := gh.GetRepositorySettings(context.Background(), repos[0], "main")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.BranchProtection{
+		Branch:                   "main",
+		RequiredApprovingReviews: 2,
+		RequiredStatusChecks:     []string{"build"},
+		EnforceAdmins:            true,
+	}, settings.BranchProtection)
+
+	protection, err := gh.GetBranchProtection(context.Background(), repos[0], domain.BranchProtection{Branch: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, settings.BranchProtection, protection)
+}
+
+func Test_GetForks(t *testing.T) {
+	forkRepo := func(owner string) string {
+		return fmt.Sprintf(`{
+			"name": "test1",
+			"full_name": "%[1]s/test1",
+			"fork": true,
+			"owner": {
+				"login": "%[1]s"
+			},
+			"html_url": "https://github.com/%[1]s/test1",
+			"default_branch": "master",
+			"permissions": {
+				"admin": true,
+				"push": true,
+				"pull": true
+			},
+			"pushed_at": "2021-01-01T00:00:00Z",
+			"parent": {
+				"name": "test1",
+				"owner": {
+					"login": "upstream-org"
+				}
+			}
+		}`, owner)
+	}
+
+	transport := testTransport{
+		pathBodies: map[string]string{
+			"/users/test-user":       `{"login": "test-user", "type": "User"}`,
+			"/users/test-user/repos": "[" + forkRepo("test-user") + "]",
+			"/repos/test-user/test1": forkRepo("test-user"),
+			"/users/test-org":        `{"login": "test-org", "type": "Organization"}`,
+			"/orgs/test-org/repos":   "[" + forkRepo("test-org") + "]",
+			"/repos/test-org/test1":  forkRepo("test-org"),
+			"/repos/upstream-org/test1/pulls": `[
+				{"number": 1, "base": {"user": {"login": "upstream-org"}, "repo": {"name": "test1"}}, "head": {"ref": "other-branch", "repo": {"full_name": "test-user/test1"}}},
+				{"number": 2, "base": {"user": {"login": "upstream-org"}, "repo": {"name": "test1"}}, "head": {"ref": "other-branch", "repo": {"full_name": "someone-else/test1"}}}
+			]`,
+		},
+	}
+
+	gh, err := github.New("", "", transport.Wrapper, github.RepositoryListing{}, []domain.MergeType{domain.MergeTypeMerge}, true)
+	require.NoError(t, err)
+
+	// The forks of users can not be listed as the repositories of an organization
+	forks, err := gh.GetForks(context.Background(), "test-user")
+	require.NoError(t, err)
+	require.Len(t, forks, 1)
+	assert.Equal(t, "test-user/test1", forks[0].FullName())
+
+	// Only the pull requests made from the fork are included
+	prs, err := gh.GetOpenForkPullRequests(context.Background(), forks[0])
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, "upstream-org/test1 #1", prs[0].String())
+
+	forks, err = gh.GetForks(context.Background(), "test-org")
+	require.NoError(t, err)
+	require.Len(t, forks, 1)
+	assert.Equal(t, "test-org/test1", forks[0].FullName())
+}
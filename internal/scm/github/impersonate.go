@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// impersonationScopes are the scopes of impersonation tokens, which allows listing, cloning and changing
+// repositories, including their workflows
+var impersonationScopes = []string{"repo", "read:org", "workflow"}
+
+// ImpersonationToken gets a token that acts as another user, by using the token of a site administrator of GitHub Enterprise Server.
+// If the user already has an impersonation token with the same scopes, it is reused
+func ImpersonationToken(
+	ctx context.Context,
+	adminToken string,
+	baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	username string,
+) (string, error) {
+	if baseURL == "" {
+		return "", errors.New("impersonating a user is only supported on GitHub Enterprise Server")
+	}
+
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: adminToken},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	tc.Transport = transportMiddleware(tc.Transport)
+
+	client, err := newClient(baseURL, tc)
+	if err != nil {
+		return "", err
+	}
+
+	auth, _, err := client.Admin.CreateUserImpersonation(ctx, username, &github.ImpersonateUserOptions{
+		Scopes: impersonationScopes,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "could not impersonate %s", username)
+	}
+	return auth.GetToken(), nil
+}
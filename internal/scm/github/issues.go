@@ -0,0 +1,37 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// IssueExists checks if an issue, open or closed, with exactly the title exists in the repository
+func (g Github) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	r := repo.(repository)
+
+	opts := &github.IssueListByRepoOptions{
+		State: "all",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		issues, resp, err := g.ghClient.Issues.ListByRepo(ctx, r.ownerName, r.name, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, issue := range issues {
+			// Pull requests are also listed as issues
+			if !issue.IsPullRequest() && issue.GetTitle() == title {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetLabels gets all labels of a repository
+func (g Github) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	r := repo.(repository)
+
+	var labels []domain.Label
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		ghLabels, resp, err := g.ghClient.Issues.ListLabels(ctx, r.ownerName, r.name, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range ghLabels {
+			labels = append(labels, domain.Label{
+				Name:        label.GetName(),
+				Color:       label.GetColor(),
+				Description: label.GetDescription(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CreateLabel creates a label in a repository
+func (g Github) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	r := repo.(repository)
+
+	_, _, err := g.ghClient.Issues.CreateLabel(ctx, r.ownerName, r.name, &github.Label{
+		Name:        &label.Name,
+		Color:       &label.Color,
+		Description: &label.Description,
+	})
+	return err
+}
+
+// UpdateLabel changes the name, color and description of a label
+func (g Github) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	r := repo.(repository)
+
+	_, _, err := g.ghClient.Issues.EditLabel(ctx, r.ownerName, r.name, currentName, &github.Label{
+		Name:        &label.Name,
+		Color:       &label.Color,
+		Description: &label.Description,
+	})
+	return err
+}
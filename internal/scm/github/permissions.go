@@ -0,0 +1,180 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// All repository permissions, from the highest to the lowest
+var permissionOrder = []string{"admin", "maintain", "push", "triage", "pull"}
+
+// Invitations use other names of some permissions
+var invitationPermissionNames = map[string]string{
+	"read":  "pull",
+	"write": "push",
+}
+
+// GetPermissions gets the permissions of direct collaborators, including pending invitations, and teams of a repository
+func (g Github) GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error) {
+	r := repo.(repository)
+
+	permissions := domain.Permissions{
+		Users: map[string]string{},
+		Teams: map[string]string{},
+	}
+
+	collaboratorOpts := &github.ListCollaboratorsOptions{
+		Affiliation: "direct",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		users, resp, err := g.ghClient.Repositories.ListCollaborators(ctx, r.ownerName, r.name, collaboratorOpts)
+		if err != nil {
+			return domain.Permissions{}, errors.Wrap(err, "could not list collaborators")
+		}
+		for _, user := range users {
+			permissions.Users[user.GetLogin()] = highestPermission(user.Permissions)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		collaboratorOpts.Page = resp.NextPage
+	}
+
+	invitations, err := g.getInvitations(ctx, r)
+	if err != nil {
+		return domain.Permissions{}, err
+	}
+	for _, invitation := range invitations {
+		permission := invitation.GetPermissions()
+		if name, ok := invitationPermissionNames[permission]; ok {
+			permission = name
+		}
+		permissions.Users[invitation.GetInvitee().GetLogin()] = permission
+	}
+
+	teamOpts := &github.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := g.ghClient.Repositories.ListTeams(ctx, r.ownerName, r.name, teamOpts)
+		if err != nil {
+			return domain.Permissions{}, errors.Wrap(err, "could not list teams")
+		}
+		for _, team := range teams {
+			permission := highestPermission(team.Permissions)
+			if permission == "" {
+				permission = team.GetPermission()
+			}
+			permissions.Teams[team.GetSlug()] = permission
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		teamOpts.Page = resp.NextPage
+	}
+
+	return permissions, nil
+}
+
+func highestPermission(permissions map[string]bool) string {
+	for _, permission := range permissionOrder {
+		if permissions[permission] {
+			return permission
+		}
+	}
+	return ""
+}
+
+func (g Github) getInvitations(ctx context.Context, r repository) ([]*github.RepositoryInvitation, error) {
+	var invitations []*github.RepositoryInvitation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		pageInvitations, resp, err := g.ghClient.Repositories.ListInvitations(ctx, r.ownerName, r.name, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list invitations")
+		}
+		invitations = append(invitations, pageInvitations...)
+		if resp.NextPage == 0 {
+			return invitations, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// findInvitation finds the pending invitation of a user, nil is returned if there is none
+func (g Github) findInvitation(ctx context.Context, r repository, user string) (*github.RepositoryInvitation, error) {
+	invitations, err := g.getInvitations(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, invitation := range invitations {
+		if strings.EqualFold(invitation.GetInvitee().GetLogin(), user) {
+			return invitation, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetUserPermission invites a user to the repository with the permission, or changes the permission of a collaborator or pending invitation
+func (g Github) SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error {
+	r := repo.(repository)
+
+	invitation, err := g.findInvitation(ctx, r, user)
+	if err != nil {
+		return err
+	}
+	if invitation != nil {
+		invitationPermission := permission
+		for name, p := range invitationPermissionNames {
+			if p == permission {
+				invitationPermission = name
+			}
+		}
+		_, _, err := g.ghClient.Repositories.UpdateInvitation(ctx, r.ownerName, r.name, invitation.GetID(), invitationPermission)
+		return err
+	}
+
+	_, _, err = g.ghClient.Repositories.AddCollaborator(ctx, r.ownerName, r.name, user, &github.RepositoryAddCollaboratorOptions{
+		Permission: permission,
+	})
+	return err
+}
+
+// SetTeamPermission gives a team of the organization that owns the repository the permission
+func (g Github) SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error {
+	r := repo.(repository)
+
+	_, err := g.ghClient.Teams.AddTeamRepoBySlug(ctx, r.ownerName, team, r.ownerName, r.name, &github.TeamAddTeamRepoOptions{
+		Permission: permission,
+	})
+	return err
+}
+
+// RemoveUser removes a collaborator, or the pending invitation of the user
+func (g Github) RemoveUser(ctx context.Context, repo domain.Repository, user string) error {
+	r := repo.(repository)
+
+	invitation, err := g.findInvitation(ctx, r, user)
+	if err != nil {
+		return err
+	}
+	if invitation != nil {
+		_, err := g.ghClient.Repositories.DeleteInvitation(ctx, r.ownerName, r.name, invitation.GetID())
+		return err
+	}
+
+	_, err = g.ghClient.Repositories.RemoveCollaborator(ctx, r.ownerName, r.name, user)
+	return err
+}
+
+// RemoveTeam removes the access of a team to the repository
+func (g Github) RemoveTeam(ctx context.Context, repo domain.Repository, team string) error {
+	r := repo.(repository)
+
+	_, err := g.ghClient.Teams.RemoveTeamRepoBySlug(ctx, r.ownerName, team, r.ownerName, r.name)
+	return err
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+)
+
+// propertyValue is the value of a custom property of a repository
+type propertyValue struct {
+	PropertyName string          `json:"property_name"`
+	Value        json.RawMessage `json:"value"`
+}
+
+// values returns the values of the property, multi select properties can have several values
+func (p propertyValue) values() []string {
+	var value string
+	if err := json.Unmarshal(p.Value, &value); err == nil {
+		return []string{value}
+	}
+	var values []string
+	_ = json.Unmarshal(p.Value, &values)
+	return values
+}
+
+// filterProperties removes the repositories that does not have all of the custom properties.
+// The custom properties of a repository are not included when listing repositories, so they have to be fetched for each repository
+func (g Github) filterProperties(ctx context.Context, repos []*github.Repository) ([]*github.Repository, error) {
+	filtered := make([]*github.Repository, 0, len(repos))
+	for _, repo := range repos {
+		properties, err := g.getProperties(ctx, repo.GetOwner().GetLogin(), repo.GetName())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the custom properties of %s", repo.GetFullName())
+		}
+
+		if matchesProperties(g.Properties, properties) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, nil
+}
+
+func (g Github) getProperties(ctx context.Context, owner, name string) (map[string][]string, error) {
+	req, err := g.ghClient.NewRequest("GET", fmt.Sprintf("repos/%s/%s/properties/values", owner, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	var values []propertyValue
+	if _, err := g.ghClient.Do(ctx, req, &values); err != nil {
+		return nil, err
+	}
+
+	properties := map[string][]string{}
+	for _, value := range values {
+		properties[value.PropertyName] = value.values()
+	}
+	return properties, nil
+}
+
+// matchesProperties checks if the properties of a repository contains all required properties and values
+func matchesProperties(required map[string]string, properties map[string][]string) bool {
+	for name, requiredValue := range required {
+		found := false
+		for _, value := range properties[name] {
+			if value == requiredValue {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
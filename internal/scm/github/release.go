@@ -0,0 +1,36 @@
+package github
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// TagExists checks if a tag exists in the repository
+func (g Github) TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error) {
+	r := repo.(repository)
+
+	_, resp, err := g.ghClient.Git.GetRef(ctx, r.ownerName, r.name, "tags/"+tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateRelease creates a release, which creates the tag at the head of the default branch
+func (g Github) CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error {
+	r := repo.(repository)
+
+	_, _, err := g.ghClient.Repositories.CreateRelease(ctx, r.ownerName, r.name, &github.RepositoryRelease{
+		TagName:         &release.Tag,
+		TargetCommitish: &r.defaultBranch,
+		Name:            &release.Name,
+		Body:            &release.Notes,
+	})
+	return err
+}
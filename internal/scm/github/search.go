@@ -0,0 +1,61 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxCodeSearchResults is the maximum number of results the code search API returns for a single query
+const maxCodeSearchResults = 1000
+
+// getCodeSearchRepositories fetches the repositories that contain code matching the code search query.
+// The repositories in the search results are incomplete, so each repository has to be fetched
+func (g Github) getCodeSearchRepositories(ctx context.Context, query string) ([]*github.Repository, error) {
+	var names []RepositoryReference
+	seen := map[string]bool{}
+	fetched := 0
+	for i := 1; ; i++ {
+		result, _, err := g.ghClient.Search.Code(ctx, query, &github.SearchOptions{
+			ListOptions: github.ListOptions{
+				Page:    i,
+				PerPage: 100,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, code := range result.CodeResults {
+			repo := code.GetRepository()
+			if seen[repo.GetFullName()] {
+				continue
+			}
+			seen[repo.GetFullName()] = true
+			names = append(names, RepositoryReference{
+				OwnerName: repo.GetOwner().GetLogin(),
+				Name:      repo.GetName(),
+			})
+		}
+
+		fetched += len(result.CodeResults)
+		if len(result.CodeResults) != 100 || fetched >= result.GetTotal() || fetched >= maxCodeSearchResults {
+			if result.GetIncompleteResults() || result.GetTotal() > fetched {
+				log.WithField("query", query).Warnf("The code search matched %d files, but only %d could be fetched. Narrow the query to not miss any repository", result.GetTotal(), fetched)
+			}
+			break
+		}
+	}
+
+	repos := make([]*github.Repository, 0, len(names))
+	for _, name := range names {
+		repo, err := g.getRepository(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get information about %s", name.String())
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetSecrets gets the names of all Actions secrets and the names and values of all Actions variables
+func (g Github) GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error) {
+	r := repo.(repository)
+
+	ret := domain.RepositorySecrets{
+		Variables: map[string]string{},
+	}
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		secrets, resp, err := g.ghClient.Actions.ListRepoSecrets(ctx, r.ownerName, r.name, opts)
+		if err != nil {
+			return domain.RepositorySecrets{}, errors.Wrap(err, "could not list secrets")
+		}
+		for _, secret := range secrets.Secrets {
+			ret.SecretNames = append(ret.SecretNames, secret.Name)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// Variables are not supported by the GitHub client
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("repos/%s/%s/actions/variables?per_page=100&page=%d", r.ownerName, r.name, page)
+		req, err := g.ghClient.NewRequest("GET", u, nil)
+		if err != nil {
+			return domain.RepositorySecrets{}, err
+		}
+
+		var variables struct {
+			Variables []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"variables"`
+		}
+		resp, err := g.ghClient.Do(ctx, req, &variables)
+		if err != nil {
+			return domain.RepositorySecrets{}, errors.Wrap(err, "could not list variables")
+		}
+		for _, variable := range variables.Variables {
+			ret.Variables[variable.Name] = variable.Value
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+// SetSecret creates or updates an Actions secret. The value is encrypted with the public key of the repository
+func (g Github) SetSecret(ctx context.Context, repo domain.Repository, name, value string) error {
+	r := repo.(repository)
+
+	key, _, err := g.ghClient.Actions.GetRepoPublicKey(ctx, r.ownerName, r.name)
+	if err != nil {
+		return errors.Wrap(err, "could not get the public key of the repository")
+	}
+
+	encryptedValue, err := encryptSecret(key.GetKey(), value)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.ghClient.Actions.CreateOrUpdateRepoSecret(ctx, r.ownerName, r.name, &github.EncryptedSecret{
+		Name:           name,
+		KeyID:          key.GetKeyID(),
+		EncryptedValue: encryptedValue,
+	})
+	return err
+}
+
+// encryptSecret encrypts a value with a base64 encoded public key, the way GitHub expects secrets to be encrypted
+func encryptSecret(publicKey, value string) (string, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decode the public key")
+	}
+	if len(decodedKey) != 32 {
+		return "", errors.Errorf("the public key has an unexpected length of %d", len(decodedKey))
+	}
+
+	var recipient [32]byte
+	copy(recipient[:], decodedKey)
+
+	encrypted, err := box.SealAnonymous(nil, []byte(value), &recipient, rand.Reader)
+	if err != nil {
+		return "", errors.Wrap(err, "could not encrypt the secret")
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// SetVariable creates or updates an Actions variable
+func (g Github) SetVariable(ctx context.Context, repo domain.Repository, name, value string) error {
+	r := repo.(repository)
+
+	body := map[string]string{
+		"name":  name,
+		"value": value,
+	}
+
+	u := fmt.Sprintf("repos/%s/%s/actions/variables/%s", r.ownerName, r.name, url.PathEscape(name))
+	req, err := g.ghClient.NewRequest("PATCH", u, body)
+	if err != nil {
+		return err
+	}
+	resp, err := g.ghClient.Do(ctx, req, nil)
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	// The variable does not exist yet
+	u = fmt.Sprintf("repos/%s/%s/actions/variables", r.ownerName, r.name)
+	req, err = g.ghClient.NewRequest("POST", u, body)
+	if err != nil {
+		return err
+	}
+	_, err = g.ghClient.Do(ctx, req, nil)
+	return err
+}
@@ -0,0 +1,174 @@
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetRepositorySettings gets the current settings of a repository, including the protection of the branch
+func (g Github) GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error) {
+	r := repo.(repository)
+
+	ghRepo, _, err := g.ghClient.Repositories.Get(ctx, r.ownerName, r.name)
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+
+	settings := domain.RepositorySettings{
+		Topics:              ghRepo.Topics,
+		AllowMergeCommit:    ghRepo.AllowMergeCommit,
+		AllowSquashMerge:    ghRepo.AllowSquashMerge,
+		AllowRebaseMerge:    ghRepo.AllowRebaseMerge,
+		DeleteBranchOnMerge: ghRepo.DeleteBranchOnMerge,
+	}
+
+	if protectedBranch != "" {
+		settings.BranchProtection, err = g.getBranchProtection(ctx, r, protectedBranch)
+		if err != nil {
+			return domain.RepositorySettings{}, err
+		}
+	}
+
+	hooks, err := g.getHooks(ctx, r)
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+	for _, hook := range hooks {
+		url, _ := hook.Config["url"].(string)
+		contentType, _ := hook.Config["content_type"].(string)
+		settings.Webhooks = append(settings.Webhooks, domain.Webhook{
+			URL:         url,
+			Events:      hook.Events,
+			ContentType: contentType,
+		})
+	}
+
+	return settings, nil
+}
+
+func (g Github) getHooks(ctx context.Context, r repository) ([]*github.Hook, error) {
+	var hooks []*github.Hook
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		page, resp, err := g.ghClient.Repositories.ListHooks(ctx, r.ownerName, r.name, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list webhooks")
+		}
+		hooks = append(hooks, page...)
+
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// UpdateRepositorySettings changes all settings that are set
+func (g Github) UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error {
+	r := repo.(repository)
+
+	if settings.Topics != nil {
+		_, _, err := g.ghClient.Repositories.ReplaceAllTopics(ctx, r.ownerName, r.name, settings.Topics)
+		if err != nil {
+			return errors.Wrap(err, "could not change topics")
+		}
+	}
+
+	if settings.AllowMergeCommit != nil || settings.AllowSquashMerge != nil ||
+		settings.AllowRebaseMerge != nil || settings.DeleteBranchOnMerge != nil {
+		_, _, err := g.ghClient.Repositories.Edit(ctx, r.ownerName, r.name, &github.Repository{
+			AllowMergeCommit:    settings.AllowMergeCommit,
+			AllowSquashMerge:    settings.AllowSquashMerge,
+			AllowRebaseMerge:    settings.AllowRebaseMerge,
+			DeleteBranchOnMerge: settings.DeleteBranchOnMerge,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not change merge options")
+		}
+	}
+
+	if settings.BranchProtection != nil {
+		if err := g.ProtectBranch(ctx, r, *settings.BranchProtection); err != nil {
+			return errors.Wrapf(err, "could not protect the %s branch", settings.BranchProtection.Branch)
+		}
+	}
+
+	if len(settings.Webhooks) > 0 {
+		if err := g.updateHooks(ctx, r, settings.Webhooks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g Github) updateHooks(ctx context.Context, r repository, webhooks []domain.Webhook) error {
+	existing, err := g.getHooks(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		contentType := webhook.ContentType
+		if contentType == "" {
+			contentType = "json"
+		}
+		config := map[string]interface{}{
+			"url":          webhook.URL,
+			"content_type": contentType,
+		}
+		if webhook.Secret != "" {
+			config["secret"] = webhook.Secret
+		}
+		hook := &github.Hook{
+			Config: config,
+			Events: webhook.Events,
+			Active: github.Bool(true),
+		}
+
+		var id int64
+		for _, e := range existing {
+			if url, _ := e.Config["url"].(string); url == webhook.URL {
+				id = e.GetID()
+			}
+		}
+
+		if id == 0 {
+			_, _, err = g.ghClient.Repositories.CreateHook(ctx, r.ownerName, r.name, hook)
+		} else {
+			_, _, err = g.ghClient.Repositories.EditHook(ctx, r.ownerName, r.name, id, hook)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not update the webhook %s", webhook.URL)
+		}
+	}
+
+	return nil
+}
+
+// RemoveWebhook removes all webhooks with the url from a repository
+func (g Github) RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error {
+	r := repo.(repository)
+
+	hooks, err := g.getHooks(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		if hookURL, _ := hook.Config["url"].(string); hookURL != url {
+			continue
+		}
+		if _, err := g.ghClient.Repositories.DeleteHook(ctx, r.ownerName, r.name, hook.GetID()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
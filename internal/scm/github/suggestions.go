@@ -0,0 +1,48 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// CreateSuggestions creates a review of a pull request, with a suggested change in a comment for each suggestion.
+// The lines of the suggestions have to be part of the changes of the pull request
+func (g Github) CreateSuggestions(ctx context.Context, pullReq domain.PullRequest, suggestions []domain.Suggestion) error {
+	pr := pullReq.(pullRequest)
+
+	comments := make([]*github.DraftReviewComment, len(suggestions))
+	for i, suggestion := range suggestions {
+		comment := &github.DraftReviewComment{
+			Path: github.String(suggestion.Path),
+			Body: github.String(suggestionBody(suggestion)),
+			Line: github.Int(suggestion.EndLine),
+			Side: github.String("RIGHT"),
+		}
+		if suggestion.StartLine != suggestion.EndLine {
+			comment.StartLine = github.Int(suggestion.StartLine)
+			comment.StartSide = github.String("RIGHT")
+		}
+		comments[i] = comment
+	}
+
+	_, _, err := g.ghClient.PullRequests.CreateReview(ctx, pr.ownerName, pr.repoName, pr.number, &github.PullRequestReviewRequest{
+		Event:    github.String("COMMENT"),
+		Comments: comments,
+	})
+	return err
+}
+
+// suggestionBody returns a comment with a suggestion block, that replaces the commented lines when applied
+func suggestionBody(suggestion domain.Suggestion) string {
+	var body strings.Builder
+	body.WriteString("```suggestion\n")
+	for _, line := range suggestion.Replacement {
+		body.WriteString(line + "\n")
+	}
+	body.WriteString("```")
+	return body.String()
+}
@@ -0,0 +1,30 @@
+package github
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+)
+
+// filterTemplate removes the repositories that were not generated from the template repository.
+// The template of a repository is not included when listing repositories, so each repository has to be fetched
+func (g Github) filterTemplate(ctx context.Context, repos []*github.Repository) ([]*github.Repository, error) {
+	filtered := make([]*github.Repository, 0, len(repos))
+	for _, repo := range repos {
+		template := repo.GetTemplateRepository()
+		if template == nil {
+			fullRepo, _, err := g.ghClient.Repositories.Get(ctx, repo.GetOwner().GetLogin(), repo.GetName())
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get the template of %s", repo.GetFullName())
+			}
+			template = fullRepo.GetTemplateRepository()
+		}
+
+		if strings.EqualFold(template.GetFullName(), g.Template) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, nil
+}
@@ -1,6 +1,8 @@
 package github
 
 import (
+	"net/http"
+
 	"github.com/google/go-github/v38/github"
 	"github.com/lindell/multi-gitter/internal/domain"
 )
@@ -26,3 +28,35 @@ func repoMergeTypes(repo *github.Repository) []domain.MergeType {
 	}
 	return ret
 }
+
+// isHeadNotFoundError checks if an error from creating a pull request was caused by the head not (yet) existing
+func isHeadNotFoundError(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	if !ok || errResp.Response == nil {
+		return false
+	}
+
+	switch errResp.Response.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusUnprocessableEntity:
+		for _, e := range errResp.Errors {
+			if e.Field == "head" && e.Code == "invalid" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repositoryVisibility returns the visibility of a repository. Internal repositories are only reported as such
+// by newer versions of GitHub Enterprise Server, older versions report them as private
+func repositoryVisibility(repo *github.Repository) string {
+	if visibility := repo.GetVisibility(); visibility != "" {
+		return visibility
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
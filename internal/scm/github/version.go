@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// enterpriseVersion is the version of a GitHub Enterprise Server instance
+type enterpriseVersion struct {
+	major int
+	minor int
+}
+
+func (v enterpriseVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v enterpriseVersion) atLeast(other enterpriseVersion) bool {
+	return v.major > other.major || (v.major == other.major && v.minor >= other.minor)
+}
+
+// parseEnterpriseVersion parses versions such as "3.8.2"
+func parseEnterpriseVersion(str string) (enterpriseVersion, error) {
+	split := strings.SplitN(str, ".", 3)
+	if len(split) < 2 {
+		return enterpriseVersion{}, fmt.Errorf("could not parse version: %s", str)
+	}
+
+	major, err := strconv.Atoi(split[0])
+	if err != nil {
+		return enterpriseVersion{}, fmt.Errorf("could not parse version: %s", str)
+	}
+	minor, err := strconv.Atoi(split[1])
+	if err != nil {
+		return enterpriseVersion{}, fmt.Errorf("could not parse version: %s", str)
+	}
+
+	return enterpriseVersion{major: major, minor: minor}, nil
+}
+
+// The first GitHub Enterprise Server versions that support a feature
+var (
+	teamReviewersVersion     = enterpriseVersion{major: 2, minor: 13}
+	draftPullRequestsVersion = enterpriseVersion{major: 2, minor: 17}
+	rulesetsVersion          = enterpriseVersion{major: 3, minor: 11}
+)
+
+// serverInfo contains the lazily fetched information about the server
+type serverInfo struct {
+	once    sync.Once
+	version *enterpriseVersion // Nil if not using GitHub Enterprise Server, or if the version could not be determined
+}
+
+// enterpriseVersion returns the version of GitHub Enterprise Server, or nil if it is not used or unknown
+func (g Github) enterpriseVersion(ctx context.Context) *enterpriseVersion {
+	if g.server == nil {
+		return nil
+	}
+
+	g.server.once.Do(func() {
+		version, err := g.fetchEnterpriseVersion(ctx)
+		if err != nil {
+			log.Warnf("Could not determine the version of GitHub Enterprise Server, all features will be used: %s", err)
+			return
+		}
+		log.Debugf("Using GitHub Enterprise Server %s", version)
+		g.server.version = &version
+	})
+
+	return g.server.version
+}
+
+func (g Github) fetchEnterpriseVersion(ctx context.Context) (enterpriseVersion, error) {
+	req, err := g.ghClient.NewRequest(http.MethodGet, "meta", nil)
+	if err != nil {
+		return enterpriseVersion{}, err
+	}
+
+	var meta struct {
+		InstalledVersion string `json:"installed_version"`
+	}
+	resp, err := g.ghClient.Do(ctx, req, &meta)
+	if err != nil {
+		return enterpriseVersion{}, err
+	}
+
+	// All responses of GitHub Enterprise Server contain its version, while the meta endpoint only has it on some versions
+	if version := resp.Header.Get("X-GitHub-Enterprise-Version"); version != "" {
+		return parseEnterpriseVersion(version)
+	}
+	if meta.InstalledVersion == "" {
+		return enterpriseVersion{}, errors.New("no version was returned")
+	}
+
+	return parseEnterpriseVersion(meta.InstalledVersion)
+}
+
+// supports checks if the server supports a feature
+func (g Github) supports(ctx context.Context, minVersion enterpriseVersion) bool {
+	version := g.enterpriseVersion(ctx)
+	return version == nil || version.atLeast(minVersion)
+}
+
+// unsupportedError is returned when a feature is not supported by the version of GitHub Enterprise Server.
+// The repository is skipped, since other repositories are not expected to be handled differently
+func (g Github) unsupportedError(ctx context.Context, feature string, minVersion enterpriseVersion) error {
+	return domain.SkipError{
+		Reason: fmt.Sprintf("%s are not supported by GitHub Enterprise Server %s, %s or later is required", feature, g.enterpriseVersion(ctx), minVersion),
+	}
+}
+
+// versionError adds information about the server version to errors caused by validation failures,
+// which is what older versions of GitHub Enterprise Server respond with when a feature is not supported
+func (g Github) versionError(ctx context.Context, err error) error {
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil || respErr.Response.StatusCode != http.StatusUnprocessableEntity {
+		return err
+	}
+
+	version := g.enterpriseVersion(ctx)
+	if version == nil {
+		return err
+	}
+
+	return fmt.Errorf("%w (GitHub Enterprise Server %s might not support the used features)", err, version)
+}
@@ -0,0 +1,56 @@
+package gitlab
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetArchivedRepositories fetches the archived projects from all sources, which are not included by GetRepositories
+func (g *Gitlab) GetArchivedRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allProjects, err := g.getAllProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []domain.Repository{}
+	for _, project := range allProjects {
+		if !project.Archived || isDisabled(project) {
+			continue
+		}
+
+		repo, err := g.convertProject(project)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+
+	return repos, nil
+}
+
+// IsArchived checks if a project is archived
+func (g *Gitlab) IsArchived(ctx context.Context, repo domain.Repository) (bool, error) {
+	r := repo.(repository)
+
+	project, _, err := g.glClient.Projects.GetProject(r.pid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	return project.Archived, nil
+}
+
+// SetArchived archives or unarchives a project
+func (g *Gitlab) SetArchived(ctx context.Context, repo domain.Repository, archived bool) error {
+	r := repo.(repository)
+
+	var err error
+	if archived {
+		_, _, err = g.glClient.Projects.ArchiveProject(r.pid, gitlab.WithContext(ctx))
+	} else {
+		_, _, err = g.glClient.Projects.UnarchiveProject(r.pid, gitlab.WithContext(ctx))
+	}
+	return err
+}
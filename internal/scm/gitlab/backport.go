@@ -0,0 +1,45 @@
+package gitlab
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetPullRequestPatch gets the unified diff of the changes of a merge request, also after it has been merged
+func (g *Gitlab) GetPullRequestPatch(ctx context.Context, pullReq domain.PullRequest) (string, error) {
+	pr := pullReq.(pullRequest)
+
+	changes, _, err := g.glClient.MergeRequests.GetMergeRequestChanges(pr.targetPID, pr.iid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return mergeRequestDiff(changes), nil
+}
+
+// ListBranches lists the names of all branches of a repository
+func (g *Gitlab) ListBranches(ctx context.Context, repo domain.Repository) ([]string, error) {
+	r := repo.(repository)
+
+	var branches []string
+	for i := 1; ; i++ {
+		projectBranches, _, err := g.glClient.Branches.ListBranches(r.pid, &gitlab.ListBranchesOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    i,
+				PerPage: 100,
+			},
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range projectBranches {
+			branches = append(branches, b.Name)
+		}
+		if len(projectBranches) < 100 {
+			break
+		}
+	}
+	return branches, nil
+}
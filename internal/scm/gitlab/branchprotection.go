@@ -0,0 +1,74 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetBranchProtection gets the protection of a branch. The number of required approvals is configured for the whole project on GitLab
+func (g *Gitlab) GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error) {
+	r := repo.(repository)
+
+	if len(rule.RequiredStatusChecks) > 0 {
+		return nil, domain.SkipError{Reason: "required status checks are not supported on GitLab"}
+	}
+	if rule.EnforceAdmins {
+		return nil, domain.SkipError{Reason: "enforcing branch protection for admins is not supported on GitLab"}
+	}
+
+	_, resp, err := g.glClient.Branches.GetBranch(r.pid, rule.Branch, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, domain.SkipError{Reason: fmt.Sprintf("the branch %s does not exist", rule.Branch)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, resp, err = g.glClient.ProtectedBranches.GetProtectedBranch(r.pid, rule.Branch, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get the protected branch: %w", err)
+	}
+
+	approvals, _, err := g.glClient.Projects.GetApprovalConfiguration(r.pid, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not get the approval configuration: %w", err)
+	}
+
+	return &domain.BranchProtection{
+		Branch:                   rule.Branch,
+		RequiredApprovingReviews: approvals.ApprovalsBeforeMerge,
+	}, nil
+}
+
+// ProtectBranch protects the branch if it's not already protected, and sets the number of required approvals of the project
+func (g *Gitlab) ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error {
+	r := repo.(repository)
+
+	_, resp, err := g.glClient.ProtectedBranches.GetProtectedBranch(r.pid, rule.Branch, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		_, _, err = g.glClient.ProtectedBranches.ProtectRepositoryBranches(r.pid, &gitlab.ProtectRepositoryBranchesOptions{
+			Name:             &rule.Branch,
+			PushAccessLevel:  gitlab.AccessLevel(gitlab.MaintainerPermissions),
+			MergeAccessLevel: gitlab.AccessLevel(gitlab.DeveloperPermissions),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("could not protect the %s branch: %w", rule.Branch, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("could not get the protected branch: %w", err)
+	}
+
+	_, _, err = g.glClient.Projects.ChangeApprovalConfiguration(r.pid, &gitlab.ChangeApprovalConfigurationOptions{
+		ApprovalsBeforeMerge: &rule.RequiredApprovingReviews,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not change the number of required approvals: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetFile gets the content of a file on the default branch of a project, without cloning it
+func (g *Gitlab) GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error) {
+	r := repo.(repository)
+
+	content, resp, err := g.glClient.RepositoryFiles.GetRawFile(r.pid, path, &gitlab.GetRawFileOptions{
+		Ref: &r.defaultBranch,
+	}, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(content), true, nil
+}
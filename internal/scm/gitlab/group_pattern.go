@@ -0,0 +1,70 @@
+package gitlab
+
+import (
+	"path"
+	"strings"
+)
+
+// isGroupPattern checks if a group contains any wildcards
+func isGroupPattern(group string) bool {
+	return strings.ContainsAny(group, "*?[")
+}
+
+// groupPatternRoot returns the longest group path of a pattern that does not contain any wildcards
+func groupPatternRoot(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if isGroupPattern(segment) {
+			return strings.Join(segments[:i], "/")
+		}
+	}
+	return pattern
+}
+
+// matchGroupPattern checks if a group path matches a pattern.
+// A "*" matches any part of a single group, and "**" matches any number of nested subgroups
+func matchGroupPattern(pattern, groupPath string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(groupPath, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// inExcludedGroup checks if a group path, or any of its parent groups, matches any of the excluded groups or patterns
+func inExcludedGroup(excludes []string, groupPath string) bool {
+	groupPath = strings.ToLower(groupPath)
+	for _, exclude := range excludes {
+		exclude = strings.ToLower(strings.Trim(exclude, "/"))
+		for path := groupPath; path != ""; {
+			if matchGroupPattern(exclude, path) {
+				return true
+			}
+			i := strings.LastIndex(path, "/")
+			if i < 0 {
+				break
+			}
+			path = path[:i]
+		}
+	}
+	return false
+}
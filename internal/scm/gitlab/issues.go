@@ -0,0 +1,38 @@
+package gitlab
+
+import (
+	"context"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// IssueExists checks if an issue, open or closed, with exactly the title exists in the project
+func (g *Gitlab) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	r := repo.(repository)
+
+	opts := &gitlab.ListProjectIssuesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+		Search: &title,
+		In:     gitlab.String("title"),
+	}
+	for {
+		issues, resp, err := g.glClient.Issues.ListProjectIssues(r.pid, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return false, err
+		}
+		// The search is not exact, so the titles have to be compared
+		for _, issue := range issues {
+			if issue.Title == title {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
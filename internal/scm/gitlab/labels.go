@@ -0,0 +1,70 @@
+package gitlab
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetLabels gets all labels of a project, labels inherited from groups are not included
+func (g *Gitlab) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	r := repo.(repository)
+
+	var labels []domain.Label
+	for i := 1; ; i++ {
+		glLabels, _, err := g.glClient.Labels.ListLabels(r.pid, &gitlab.ListLabelsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: 100,
+				Page:    i,
+			},
+			IncludeAncestorGroups: gitlab.Bool(false),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, label := range glLabels {
+			labels = append(labels, domain.Label{
+				Name:        label.Name,
+				Color:       strings.TrimPrefix(label.Color, "#"),
+				Description: label.Description,
+			})
+		}
+
+		if len(glLabels) < 100 {
+			return labels, nil
+		}
+	}
+}
+
+// CreateLabel creates a label in a project
+func (g *Gitlab) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	r := repo.(repository)
+
+	_, _, err := g.glClient.Labels.CreateLabel(r.pid, &gitlab.CreateLabelOptions{
+		Name:        &label.Name,
+		Color:       gitlab.String("#" + label.Color),
+		Description: &label.Description,
+	}, gitlab.WithContext(ctx))
+	return err
+}
+
+// UpdateLabel changes the name, color and description of a label
+func (g *Gitlab) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	r := repo.(repository)
+
+	opts := &gitlab.UpdateLabelOptions{
+		Name:        &currentName,
+		Color:       gitlab.String("#" + label.Color),
+		Description: &label.Description,
+	}
+	if label.Name != currentName {
+		opts.NewName = &label.Name
+	}
+
+	_, _, err := g.glClient.Labels.UpdateLabel(r.pid, opts, gitlab.WithContext(ctx))
+	return err
+}
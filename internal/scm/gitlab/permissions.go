@@ -0,0 +1,154 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// The names of the access levels that can be given to members and groups
+var accessLevelNames = map[gitlab.AccessLevelValue]string{
+	gitlab.GuestPermissions:      "guest",
+	gitlab.ReporterPermissions:   "reporter",
+	gitlab.DeveloperPermissions:  "developer",
+	gitlab.MaintainerPermissions: "maintainer",
+	gitlab.OwnerPermissions:      "owner",
+}
+
+func parseAccessLevel(permission string) (gitlab.AccessLevelValue, error) {
+	for level, name := range accessLevelNames {
+		if name == permission {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown access level %q, available levels are guest, reporter, developer, maintainer and owner", permission)
+}
+
+// GetPermissions gets the access levels of the direct members of a project, and of the groups the project is shared with
+func (g *Gitlab) GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error) {
+	r := repo.(repository)
+
+	permissions := domain.Permissions{
+		Users: map[string]string{},
+		Teams: map[string]string{},
+	}
+
+	opts := &gitlab.ListProjectMembersOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	}
+	for {
+		members, resp, err := g.glClient.ProjectMembers.ListProjectMembers(r.pid, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return domain.Permissions{}, fmt.Errorf("could not list members: %w", err)
+		}
+		for _, member := range members {
+			permissions.Users[member.Username] = accessLevelNames[member.AccessLevel]
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	project, _, err := g.glClient.Projects.GetProject(r.pid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return domain.Permissions{}, err
+	}
+	for _, sharedGroup := range project.SharedWithGroups {
+		// Only the name of the group is included, which is not unique
+		group, _, err := g.glClient.Groups.GetGroup(sharedGroup.GroupID, gitlab.WithContext(ctx))
+		if err != nil {
+			return domain.Permissions{}, fmt.Errorf("could not get the group %s: %w", sharedGroup.GroupName, err)
+		}
+		permissions.Teams[group.FullPath] = accessLevelNames[gitlab.AccessLevelValue(sharedGroup.GroupAccessLevel)]
+	}
+
+	return permissions, nil
+}
+
+// SetUserPermission adds a user as a member of the project, or changes the access level of the member
+func (g *Gitlab) SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error {
+	r := repo.(repository)
+
+	accessLevel, err := parseAccessLevel(permission)
+	if err != nil {
+		return err
+	}
+
+	userIDs, err := g.getUserIDs(ctx, []string{user})
+	if err != nil {
+		return err
+	}
+
+	_, resp, err := g.glClient.ProjectMembers.GetProjectMember(r.pid, userIDs[0], gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		_, _, err = g.glClient.ProjectMembers.AddProjectMember(r.pid, &gitlab.AddProjectMemberOptions{
+			UserID:      userIDs[0],
+			AccessLevel: &accessLevel,
+		}, gitlab.WithContext(ctx))
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	_, _, err = g.glClient.ProjectMembers.EditProjectMember(r.pid, userIDs[0], &gitlab.EditProjectMemberOptions{
+		AccessLevel: &accessLevel,
+	}, gitlab.WithContext(ctx))
+	return err
+}
+
+// SetTeamPermission shares the project with a group, the access level is changed by sharing the project again
+func (g *Gitlab) SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error {
+	r := repo.(repository)
+
+	accessLevel, err := parseAccessLevel(permission)
+	if err != nil {
+		return err
+	}
+
+	group, _, err := g.glClient.Groups.GetGroup(team, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not get the group: %w", err)
+	}
+
+	resp, err := g.glClient.Projects.DeleteSharedProjectFromGroup(r.pid, group.ID, gitlab.WithContext(ctx))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return err
+	}
+
+	_, err = g.glClient.Projects.ShareProjectWithGroup(r.pid, &gitlab.ShareWithGroupOptions{
+		GroupID:     &group.ID,
+		GroupAccess: &accessLevel,
+	}, gitlab.WithContext(ctx))
+	return err
+}
+
+// RemoveUser removes a member of the project
+func (g *Gitlab) RemoveUser(ctx context.Context, repo domain.Repository, user string) error {
+	r := repo.(repository)
+
+	userIDs, err := g.getUserIDs(ctx, []string{user})
+	if err != nil {
+		return err
+	}
+
+	_, err = g.glClient.ProjectMembers.DeleteProjectMember(r.pid, userIDs[0], gitlab.WithContext(ctx))
+	return err
+}
+
+// RemoveTeam stops sharing the project with a group
+func (g *Gitlab) RemoveTeam(ctx context.Context, repo domain.Repository, team string) error {
+	r := repo.(repository)
+
+	group, _, err := g.glClient.Groups.GetGroup(team, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not get the group: %w", err)
+	}
+
+	_, err = g.glClient.Projects.DeleteSharedProjectFromGroup(r.pid, group.ID, gitlab.WithContext(ctx))
+	return err
+}
@@ -0,0 +1,36 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// TagExists checks if a tag exists in the project
+func (g *Gitlab) TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error) {
+	r := repo.(repository)
+
+	_, resp, err := g.glClient.Tags.GetTag(r.pid, tag, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateRelease creates a release, which creates the tag at the head of the default branch
+func (g *Gitlab) CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error {
+	r := repo.(repository)
+
+	_, _, err := g.glClient.Releases.CreateRelease(r.pid, &gitlab.CreateReleaseOptions{
+		Name:        &release.Name,
+		TagName:     &release.Tag,
+		Description: &release.Notes,
+		Ref:         &r.defaultBranch,
+	}, gitlab.WithContext(ctx))
+	return err
+}
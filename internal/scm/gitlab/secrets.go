@@ -0,0 +1,76 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetSecrets gets the names of all masked CI/CD variables, which are treated as secrets,
+// and the names and values of all other CI/CD variables
+func (g *Gitlab) GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error) {
+	r := repo.(repository)
+
+	ret := domain.RepositorySecrets{
+		Variables: map[string]string{},
+	}
+	for i := 1; ; i++ {
+		variables, _, err := g.glClient.ProjectVariables.ListVariables(r.pid, &gitlab.ListProjectVariablesOptions{
+			PerPage: 100,
+			Page:    i,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return domain.RepositorySecrets{}, fmt.Errorf("could not list variables: %w", err)
+		}
+
+		for _, variable := range variables {
+			if variable.Masked {
+				ret.SecretNames = append(ret.SecretNames, variable.Key)
+			} else {
+				ret.Variables[variable.Key] = variable.Value
+			}
+		}
+
+		if len(variables) < 100 {
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+// SetSecret creates or updates a masked CI/CD variable
+func (g *Gitlab) SetSecret(ctx context.Context, repo domain.Repository, name, value string) error {
+	return g.setVariable(ctx, repo.(repository), name, value, true)
+}
+
+// SetVariable creates or updates a CI/CD variable
+func (g *Gitlab) SetVariable(ctx context.Context, repo domain.Repository, name, value string) error {
+	return g.setVariable(ctx, repo.(repository), name, value, false)
+}
+
+func (g *Gitlab) setVariable(ctx context.Context, r repository, name, value string, masked bool) error {
+	_, resp, err := g.glClient.ProjectVariables.GetVariable(r.pid, name, gitlab.WithContext(ctx))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return err
+	}
+
+	if err == nil {
+		_, _, err = g.glClient.ProjectVariables.UpdateVariable(r.pid, name, &gitlab.UpdateProjectVariableOptions{
+			Value:  &value,
+			Masked: &masked,
+		}, gitlab.WithContext(ctx))
+		return err
+	}
+
+	_, _, err = g.glClient.ProjectVariables.CreateVariable(r.pid, &gitlab.CreateProjectVariableOptions{
+		Key:    &name,
+		Value:  &value,
+		Masked: &masked,
+	}, gitlab.WithContext(ctx))
+	return err
+}
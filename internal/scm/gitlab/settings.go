@@ -0,0 +1,197 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// GetRepositorySettings gets the current settings of a project. Only topics and webhooks are supported on GitLab
+func (g *Gitlab) GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error) {
+	r := repo.(repository)
+
+	project, _, err := g.glClient.Projects.GetProject(r.pid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return domain.RepositorySettings{}, err
+	}
+
+	settings := domain.RepositorySettings{
+		Topics: project.TagList,
+	}
+
+	hooks, _, err := g.glClient.Projects.ListProjectHooks(r.pid, &gitlab.ListProjectHooksOptions{
+		PerPage: 100,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return domain.RepositorySettings{}, fmt.Errorf("could not list webhooks: %w", err)
+	}
+	for _, hook := range hooks {
+		settings.Webhooks = append(settings.Webhooks, domain.Webhook{
+			URL:         hook.URL,
+			Events:      hookEvents(hook),
+			ContentType: "json",
+		})
+	}
+
+	return settings, nil
+}
+
+// UpdateRepositorySettings changes all settings that are set. Only topics and webhooks are supported on GitLab
+func (g *Gitlab) UpdateRepositorySettings(ctx context.Context, repo domain.Repository, settings domain.RepositorySettings) error {
+	r := repo.(repository)
+
+	if settings.AllowMergeCommit != nil || settings.AllowSquashMerge != nil ||
+		settings.AllowRebaseMerge != nil || settings.DeleteBranchOnMerge != nil {
+		return fmt.Errorf("merge options are not supported on GitLab")
+	}
+	if settings.BranchProtection != nil {
+		return fmt.Errorf("branch protection is not supported on GitLab")
+	}
+
+	if settings.Topics != nil {
+		_, _, err := g.glClient.Projects.EditProject(r.pid, &gitlab.EditProjectOptions{
+			TagList: &settings.Topics,
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("could not change topics: %w", err)
+		}
+	}
+
+	if len(settings.Webhooks) > 0 {
+		if err := g.updateHooks(ctx, r, settings.Webhooks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Gitlab) updateHooks(ctx context.Context, r repository, webhooks []domain.Webhook) error {
+	existing, _, err := g.glClient.Projects.ListProjectHooks(r.pid, &gitlab.ListProjectHooksOptions{
+		PerPage: 100,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not list webhooks: %w", err)
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.ContentType != "" && webhook.ContentType != "json" {
+			return fmt.Errorf("the content type %s is not supported on GitLab", webhook.ContentType)
+		}
+
+		events, err := newHookEvents(webhook.Events)
+		if err != nil {
+			return err
+		}
+
+		var token *string
+		if webhook.Secret != "" {
+			token = &webhook.Secret
+		}
+
+		id := 0
+		for _, hook := range existing {
+			if hook.URL == webhook.URL {
+				id = hook.ID
+			}
+		}
+
+		if id == 0 {
+			_, _, err = g.glClient.Projects.AddProjectHook(r.pid, &gitlab.AddProjectHookOptions{
+				URL:                 &webhook.URL,
+				Token:               token,
+				PushEvents:          events["push"],
+				TagPushEvents:       events["tag_push"],
+				IssuesEvents:        events["issues"],
+				MergeRequestsEvents: events["merge_requests"],
+				NoteEvents:          events["note"],
+				JobEvents:           events["job"],
+				PipelineEvents:      events["pipeline"],
+				ReleasesEvents:      events["releases"],
+			}, gitlab.WithContext(ctx))
+		} else {
+			_, _, err = g.glClient.Projects.EditProjectHook(r.pid, id, &gitlab.EditProjectHookOptions{
+				URL:                 &webhook.URL,
+				Token:               token,
+				PushEvents:          events["push"],
+				TagPushEvents:       events["tag_push"],
+				IssuesEvents:        events["issues"],
+				MergeRequestsEvents: events["merge_requests"],
+				NoteEvents:          events["note"],
+				JobEvents:           events["job"],
+				PipelineEvents:      events["pipeline"],
+				ReleasesEvents:      events["releases"],
+			}, gitlab.WithContext(ctx))
+		}
+		if err != nil {
+			return fmt.Errorf("could not update the webhook %s: %w", webhook.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// The names of all events a project hook can be triggered by
+var hookEventNames = []string{"push", "tag_push", "issues", "merge_requests", "note", "job", "pipeline", "releases"}
+
+// hookEvents returns the names of the events that trigger a project hook
+func hookEvents(hook *gitlab.ProjectHook) []string {
+	enabled := map[string]bool{
+		"push":           hook.PushEvents,
+		"tag_push":       hook.TagPushEvents,
+		"issues":         hook.IssuesEvents,
+		"merge_requests": hook.MergeRequestsEvents,
+		"note":           hook.NoteEvents,
+		"job":            hook.JobEvents,
+		"pipeline":       hook.PipelineEvents,
+		"releases":       hook.ReleasesEvents,
+	}
+	var events []string
+	for _, name := range hookEventNames {
+		if enabled[name] {
+			events = append(events, name)
+		}
+	}
+	return events
+}
+
+// newHookEvents returns if each event should trigger a project hook
+func newHookEvents(events []string) (map[string]*bool, error) {
+	ret := map[string]*bool{}
+	for _, name := range hookEventNames {
+		ret[name] = gitlab.Bool(false)
+	}
+	for _, event := range events {
+		if _, ok := ret[event]; !ok {
+			return nil, fmt.Errorf("unknown webhook event %s, available events are: %v", event, hookEventNames)
+		}
+		ret[event] = gitlab.Bool(true)
+	}
+	return ret, nil
+}
+
+// RemoveWebhook removes all webhooks with the url from a project
+func (g *Gitlab) RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error {
+	r := repo.(repository)
+
+	hooks, _, err := g.glClient.Projects.ListProjectHooks(r.pid, &gitlab.ListProjectHooksOptions{
+		PerPage: 100,
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("could not list webhooks: %w", err)
+	}
+
+	for _, hook := range hooks {
+		if hook.URL != url {
+			continue
+		}
+		if _, err := g.glClient.Projects.DeleteProjectHook(r.pid, hook.ID, gitlab.WithContext(ctx)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// CreateSuggestions creates a thread on a merge request, with a suggested change, for each suggestion.
+// The lines of the suggestions have to be part of the changes of the merge request
+func (g *Gitlab) CreateSuggestions(ctx context.Context, pullReq domain.PullRequest, suggestions []domain.Suggestion) error {
+	pr := pullReq.(pullRequest)
+
+	mr, _, err := g.glClient.MergeRequests.GetMergeRequest(pr.targetPID, pr.iid, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, suggestion := range suggestions {
+		_, _, err := g.glClient.Discussions.CreateMergeRequestDiscussion(pr.targetPID, pr.iid, &gitlab.CreateMergeRequestDiscussionOptions{
+			Body: gitlab.String(suggestionBody(suggestion)),
+			Position: &gitlab.NotePosition{
+				BaseSHA:      mr.DiffRefs.BaseSha,
+				StartSHA:     mr.DiffRefs.StartSha,
+				HeadSHA:      mr.DiffRefs.HeadSha,
+				PositionType: "text",
+				OldPath:      suggestion.Path,
+				NewPath:      suggestion.Path,
+				NewLine:      suggestion.EndLine,
+			},
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("could not suggest the change of line %d of %s: %w", suggestion.StartLine, suggestion.Path, err)
+		}
+	}
+	return nil
+}
+
+// suggestionBody returns a comment with a suggestion block, that replaces the commented line,
+// and the lines above it that are part of the suggestion, when applied
+func suggestionBody(suggestion domain.Suggestion) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "```suggestion:-%d+0\n", suggestion.EndLine-suggestion.StartLine)
+	for _, line := range suggestion.Replacement {
+		body.WriteString(line + "\n")
+	}
+	body.WriteString("```")
+	return body.String()
+}
@@ -0,0 +1,326 @@
+package gogs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	internalHTTP "github.com/lindell/multi-gitter/internal/http"
+)
+
+// New create a new Gogs client
+func New(
+	token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+) (*Gogs, error) {
+	if baseURL == "" {
+		return nil, errors.New("no base url set")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	return &Gogs{
+		RepositoryListing: repoListing,
+
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+	}, nil
+}
+
+// Gogs contain Gogs configuration
+type Gogs struct {
+	RepositoryListing
+
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Organizations []string
+	Users         []string
+	Repositories  []RepositoryReference
+}
+
+// RepositoryReference contains information to be able to reference a repository
+type RepositoryReference struct {
+	OwnerName string
+	Name      string
+}
+
+// ParseRepositoryReference parses a repository reference from the format "ownerName/repoName"
+func ParseRepositoryReference(val string) (RepositoryReference, error) {
+	split := strings.Split(val, "/")
+	if len(split) != 2 {
+		return RepositoryReference{}, fmt.Errorf("could not parse repository reference: %s", val)
+	}
+	return RepositoryReference{
+		OwnerName: split[0],
+		Name:      split[1],
+	}, nil
+}
+
+type repository struct {
+	url           url.URL
+	htmlURL       string
+	name          string
+	ownerName     string
+	defaultBranch string
+}
+
+// URL returns the clone url with the token as the username, which Gogs accepts together with the "x-oauth-basic" password
+func (r repository) URL(token string) string {
+	r.url.User = url.UserPassword(token, "x-oauth-basic")
+	return r.url.String()
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("%s/%s", r.ownerName, r.name)
+}
+
+// pullRequest is a pushed branch. Gogs has no API for pull requests, so they have to be opened from the compare page
+type pullRequest struct {
+	ownerName  string
+	repoName   string
+	branchName string
+	compareURL string
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s/%s:%s", pr.ownerName, pr.repoName, pr.branchName)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("%s/%s", pr.ownerName, pr.repoName)
+}
+
+// Status is always pending, since the state of pull requests can't be fetched from Gogs
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusPending
+}
+
+func (pr pullRequest) URL() string {
+	return pr.compareURL
+}
+
+type gogsRepository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		UserName string `json:"username"`
+	} `json:"owner"`
+	FullName      string `json:"full_name"`
+	Empty         bool   `json:"empty"`
+	CloneURL      string `json:"clone_url"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// GetRepositories fetches repositories from all sources (organizations/users/specific repositories)
+func (g *Gogs) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	ctx = internalHTTP.WithListingCache(ctx)
+
+	allRepos := map[string]gogsRepository{}
+
+	for _, org := range g.Organizations {
+		var repos []gogsRepository
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("orgs/%s/repos", url.PathEscape(org)), nil, &repos); err != nil {
+			return nil, errors.Wrapf(err, "could not get the repositories of %s", org)
+		}
+		for _, repo := range repos {
+			allRepos[repo.FullName] = repo
+		}
+	}
+
+	for _, user := range g.Users {
+		var repos []gogsRepository
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("users/%s/repos", url.PathEscape(user)), nil, &repos); err != nil {
+			return nil, errors.Wrapf(err, "could not get the repositories of %s", user)
+		}
+		for _, repo := range repos {
+			allRepos[repo.FullName] = repo
+		}
+	}
+
+	for _, ref := range g.Repositories {
+		var repo gogsRepository
+		err := g.do(ctx, http.MethodGet, fmt.Sprintf("repos/%s/%s", url.PathEscape(ref.OwnerName), url.PathEscape(ref.Name)), nil, &repo)
+		if isNotFound(err) {
+			return nil, errors.Errorf("could not find the repository %s/%s", ref.OwnerName, ref.Name)
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not get information about %s/%s", ref.OwnerName, ref.Name)
+		}
+		allRepos[repo.FullName] = repo
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.Empty {
+			log.Debugf("Skipping %s since it's empty", repo.FullName)
+			continue
+		}
+		convertedRepo, err := convertRepository(repo)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, convertedRepo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].FullName() < repos[j].FullName()
+	})
+
+	return repos, nil
+}
+
+// CreatePullRequest does not create anything, since Gogs has no API for pull requests.
+// Instead, the url where the pull request can be opened is logged
+func (g *Gogs) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+	prR := prRepo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if newPR.Draft {
+		logger.Warn("Draft pull requests are not supported on Gogs")
+	}
+	if len(newPR.Reviewers) > 0 {
+		logger.Warn("Reviewers are not supported on Gogs")
+	}
+	if len(newPR.Assignees) > 0 || len(newPR.Labels) > 0 || newPR.Milestone != "" {
+		logger.Warn("Assignees, labels and milestones have to be set when opening the pull request on Gogs")
+	}
+
+	head := newPR.Head
+	if prR.ownerName != r.ownerName {
+		head = fmt.Sprintf("%s:%s", prR.ownerName, newPR.Head)
+	}
+	pr := pullRequest{
+		ownerName:  r.ownerName,
+		repoName:   r.name,
+		branchName: newPR.Head,
+		compareURL: fmt.Sprintf("%s/compare/%s...%s", r.htmlURL, newPR.Base, head),
+	}
+	logger.Infof("The branch has been pushed, open the pull request at: %s", pr.compareURL)
+
+	return pr, nil
+}
+
+// GetPullRequests gets the pushed feature branches of all repositories
+func (g *Gogs) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := g.GetRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		r := repo.(repository)
+
+		path := fmt.Sprintf("repos/%s/%s/branches/%s", url.PathEscape(r.ownerName), url.PathEscape(r.name), url.PathEscape(branchName))
+		err := g.do(ctx, http.MethodGet, path, nil, nil)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not get the branch of %s", r.FullName())
+		}
+
+		prs = append(prs, pullRequest{
+			ownerName:  r.ownerName,
+			repoName:   r.name,
+			branchName: branchName,
+			compareURL: fmt.Sprintf("%s/compare/%s...%s", r.htmlURL, r.defaultBranch, branchName),
+		})
+	}
+
+	return prs, nil
+}
+
+// MergePullRequest is not supported, since Gogs has no API for pull requests
+func (g *Gogs) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("merging is not supported on Gogs, since it has no API for pull requests")
+}
+
+// ClosePullRequest is not supported, since Gogs has no API for pull requests
+func (g *Gogs) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("closing is not supported on Gogs, since it has no API for pull requests")
+}
+
+// ForkRepository is not supported on Gogs
+func (g *Gogs) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on Gogs")
+}
+
+// CreateIssue creates an issue in the repository with the name in the format "owner/name"
+func (g *Gogs) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	ref, err := ParseRepositoryReference(repoName)
+	if err != nil {
+		return err
+	}
+
+	err = g.do(ctx, http.MethodPost, fmt.Sprintf("repos/%s/%s/issues", url.PathEscape(ref.OwnerName), url.PathEscape(ref.Name)), map[string]string{
+		"title": issue.Title,
+		"body":  issue.Body,
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not create issue in %s", repoName)
+	}
+	return nil
+}
+
+func convertRepository(repo gogsRepository) (repository, error) {
+	u, err := url.Parse(repo.CloneURL)
+	if err != nil {
+		return repository{}, err
+	}
+
+	return repository{
+		url:           *u,
+		htmlURL:       strings.TrimSuffix(repo.HTMLURL, "/"),
+		name:          repo.Name,
+		ownerName:     repo.Owner.UserName,
+		defaultBranch: repo.DefaultBranch,
+	}, nil
+}
+
+// IssueExists checks if an issue, open or closed, with exactly the title exists in the repository
+func (g *Gogs) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	r := repo.(repository)
+
+	for _, state := range []string{"open", "closed"} {
+		// The page size is set by the server, so pages are fetched until an empty one is returned
+		for page := 1; ; page++ {
+			var issues []struct {
+				Title       string    `json:"title"`
+				PullRequest *struct{} `json:"pull_request"`
+			}
+			path := fmt.Sprintf("repos/%s/%s/issues?state=%s&page=%d", url.PathEscape(r.ownerName), url.PathEscape(r.name), state, page)
+			if err := g.do(ctx, http.MethodGet, path, nil, &issues); err != nil {
+				return false, err
+			}
+			if len(issues) == 0 {
+				break
+			}
+			for _, issue := range issues {
+				if issue.PullRequest == nil && issue.Title == title {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
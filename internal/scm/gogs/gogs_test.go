@@ -0,0 +1,116 @@
+package gogs_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/scm/gogs"
+)
+
+func noMiddleware(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+// newServer creates a server that responds with the response of the request uri, the host of the server is set in place of "{host}"
+func newServer(t *testing.T, responses map[string]string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+
+		response, ok := responses[r.Method+" "+r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, strings.ReplaceAll(response, "{host}", server.Listener.Addr().String()))
+	}))
+	return server
+}
+
+func repoJSON(owner, name string, empty bool) string {
+	return fmt.Sprintf(`{
+		"name": %q,
+		"owner": {"username": %q},
+		"full_name": "%s/%s",
+		"empty": %t,
+		"clone_url": "http://{host}/%s/%s.git",
+		"html_url": "http://{host}/%s/%s",
+		"default_branch": "master"
+	}`, name, owner, owner, name, empty, owner, name, owner, name)
+}
+
+func TestGetRepositories(t *testing.T) {
+	server := newServer(t, map[string]string{
+		"GET /api/v1/orgs/org/repos":   "[" + repoJSON("org", "b", false) + "," + repoJSON("org", "a", false) + "," + repoJSON("org", "empty", true) + "]",
+		"GET /api/v1/users/user/repos": "[" + repoJSON("user", "c", false) + "]",
+		"GET /api/v1/repos/org/a":      repoJSON("org", "a", false),
+	})
+	defer server.Close()
+
+	g, err := gogs.New("secret", server.URL+"/", noMiddleware, gogs.RepositoryListing{
+		Organizations: []string{"org"},
+		Users:         []string{"user"},
+		Repositories:  []gogs.RepositoryReference{{OwnerName: "org", Name: "a"}},
+	})
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 3)
+
+	assert.Equal(t, "org/a", repos[0].FullName())
+	assert.Equal(t, "master", repos[0].DefaultBranch())
+	assert.Equal(t, "org/b", repos[1].FullName())
+	assert.Equal(t, "user/c", repos[2].FullName())
+	assert.Equal(t, fmt.Sprintf("http://secret:x-oauth-basic@%s/user/c.git", server.Listener.Addr()), repos[2].URL("secret"))
+
+	g, err = gogs.New("secret", server.URL, noMiddleware, gogs.RepositoryListing{
+		Repositories: []gogs.RepositoryReference{{OwnerName: "org", Name: "missing"}},
+	})
+	require.NoError(t, err)
+	_, err = g.GetRepositories(context.Background())
+	assert.EqualError(t, err, "could not find the repository org/missing")
+}
+
+func TestPullRequests(t *testing.T) {
+	server := newServer(t, map[string]string{
+		"GET /api/v1/orgs/org/repos":                 "[" + repoJSON("org", "a", false) + "," + repoJSON("org", "b", false) + "]",
+		"GET /api/v1/repos/org/b/branches/my-branch": `{"name": "my-branch"}`,
+	})
+	defer server.Close()
+
+	g, err := gogs.New("secret", server.URL, noMiddleware, gogs.RepositoryListing{
+		Organizations: []string{"org"},
+	})
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+
+	pr, err := g.CreatePullRequest(context.Background(), repos[0], repos[0], domain.NewPullRequest{
+		Title: "title",
+		Head:  "my-branch",
+		Base:  "master",
+		Draft: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "org/a:my-branch", pr.String())
+	assert.Equal(t, fmt.Sprintf("http://%s/org/a/compare/master...my-branch", server.Listener.Addr()), pr.(interface{ URL() string }).URL())
+
+	prs, err := g.GetPullRequests(context.Background(), "my-branch")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, "org/b:my-branch", prs[0].String())
+	assert.Equal(t, domain.PullRequestStatusPending, prs[0].Status())
+
+	assert.Error(t, g.MergePullRequest(context.Background(), prs[0]))
+}
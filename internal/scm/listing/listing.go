@@ -0,0 +1,46 @@
+// Package listing contains the filters that are shared by the platforms when listing repositories
+package listing
+
+import (
+	"strings"
+	"time"
+)
+
+// MatchesLanguage checks if the language is one of the languages, or if no languages are set
+func MatchesLanguage(languages []string, language string) bool {
+	return matchesAny(languages, language)
+}
+
+// MatchesVisibility checks if the visibility is one of the visibilities, or if no visibilities are set
+func MatchesVisibility(visibilities []string, visibility string) bool {
+	return matchesAny(visibilities, visibility)
+}
+
+// matchesAny checks if the value case-insensitively equals one of the values, or if no values are set
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// PushedWithin checks if the time of the last push is within the limits, a zero limit is not used
+func PushedWithin(after, before, pushed time.Time) bool {
+	if !after.IsZero() && !pushed.After(after) {
+		return false
+	}
+	if !before.IsZero() && !pushed.Before(before) {
+		return false
+	}
+	return true
+}
+
+// WithinSize checks if the size of a repository, in bytes, is at most the maximum size, a zero maximum is not used
+func WithinSize(maxSize, size int64) bool {
+	return maxSize == 0 || size <= maxSize
+}
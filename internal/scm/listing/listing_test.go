@@ -0,0 +1,39 @@
+package listing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesLanguage(t *testing.T) {
+	assert.True(t, MatchesLanguage(nil, "Go"))
+	assert.True(t, MatchesLanguage([]string{"java", "go"}, "Go"))
+	assert.False(t, MatchesLanguage([]string{"java"}, "Go"))
+	assert.False(t, MatchesLanguage([]string{"java"}, ""))
+}
+
+func TestMatchesVisibility(t *testing.T) {
+	assert.True(t, MatchesVisibility(nil, "private"))
+	assert.True(t, MatchesVisibility([]string{"internal", "private"}, "Private"))
+	assert.False(t, MatchesVisibility([]string{"public"}, "internal"))
+}
+
+func TestPushedWithin(t *testing.T) {
+	after := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	pushed := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, PushedWithin(time.Time{}, time.Time{}, pushed))
+	assert.True(t, PushedWithin(after, before, pushed))
+	assert.False(t, PushedWithin(pushed, time.Time{}, after))
+	assert.False(t, PushedWithin(time.Time{}, after, pushed))
+	assert.False(t, PushedWithin(after, time.Time{}, after), "the limits are exclusive")
+}
+
+func TestWithinSize(t *testing.T) {
+	assert.True(t, WithinSize(0, 1<<40))
+	assert.True(t, WithinSize(1000, 1000))
+	assert.False(t, WithinSize(1000, 1001))
+}
@@ -0,0 +1,202 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// New create a new client for already cloned repositories. The paths may be glob patterns, and
+// a leading "~" is replaced with the home directory
+func New(paths []string, pushOrigin bool) (*Local, error) {
+	home, _ := os.UserHomeDir()
+
+	var dirs []string
+	for _, path := range paths {
+		if home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+			path = filepath.Join(home, path[1:])
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse the path %s", path)
+		}
+		dirs = append(dirs, matches...)
+	}
+
+	return &Local{
+		dirs:       dirs,
+		pushOrigin: pushOrigin,
+	}, nil
+}
+
+// Local uses repositories that are already cloned to the filesystem. Changes are pushed as branches
+// to the cloned repositories, and optionally further to their origin
+type Local struct {
+	dirs       []string
+	pushOrigin bool
+}
+
+type repository struct {
+	path          string // The absolute path of the repository
+	defaultBranch string
+}
+
+// URL returns the path of the repository, which is used to clone it
+func (r repository) URL(token string) string {
+	return r.path
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+// FullName returns the name of the directory of the repository
+func (r repository) FullName() string {
+	return filepath.Base(r.path)
+}
+
+// pullRequest is a branch in a cloned repository, which has to be merged without the help of multi-gitter
+type pullRequest struct {
+	repoName   string
+	branchName string
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s:%s", pr.repoName, pr.branchName)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return pr.repoName
+}
+
+// Status is always pending, since there is no way to know what happens to the branch
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusPending
+}
+
+// GetRepositories gets all repositories among the directories, with the branch they have checked out as the default branch
+func (l *Local) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	names := map[string]string{}
+	repos := []domain.Repository{}
+	for _, dir := range l.dirs {
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		repo, err := git.PlainOpen(path)
+		if err == git.ErrRepositoryNotExists {
+			log.Debugf("Skipping %s since it's not a git repository", path)
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not open %s", path)
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			log.Debugf("Skipping %s since it has no checked out branch", path)
+			continue
+		}
+		if !head.Name().IsBranch() {
+			log.Debugf("Skipping %s since it has no checked out branch", path)
+			continue
+		}
+
+		r := repository{
+			path:          path,
+			defaultBranch: head.Name().Short(),
+		}
+		if other, ok := names[r.FullName()]; ok && other != path {
+			return nil, errors.Errorf("the repositories %s and %s have the same directory name", other, path)
+		} else if ok {
+			continue
+		}
+		names[r.FullName()] = path
+		repos = append(repos, r)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].FullName() < repos[j].FullName()
+	})
+
+	return repos, nil
+}
+
+// CreatePullRequest does not create anything, since the branch has already been pushed to the cloned repository.
+// If enabled, the branch is pushed further to the origin of the cloned repository
+func (l *Local) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if l.pushOrigin {
+		// The git binary is used to make use of the credentials configured for the repository
+		cmd := exec.CommandContext(ctx, "git", "push", "origin", "refs/heads/"+newPR.Head)
+		cmd.Dir = r.path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, errors.Errorf("could not push to origin: %s", strings.TrimSpace(string(out)))
+		}
+		logger.Infof("The branch %s has been pushed to origin", newPR.Head)
+	} else {
+		logger.Infof("The branch %s has been created in %s", newPR.Head, r.path)
+	}
+
+	return pullRequest{
+		repoName:   r.FullName(),
+		branchName: newPR.Head,
+	}, nil
+}
+
+// GetPullRequests gets the repositories that has the branch
+func (l *Local) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := l.GetRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		r := repo.(repository)
+
+		gitRepo, err := git.PlainOpen(r.path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open %s", r.path)
+		}
+		_, err = gitRepo.Reference(plumbing.NewBranchReferenceName(branchName), false)
+		if err == plumbing.ErrReferenceNotFound {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "could not get the branch of %s", r.FullName())
+		}
+
+		prs = append(prs, pullRequest{
+			repoName:   r.FullName(),
+			branchName: branchName,
+		})
+	}
+	return prs, nil
+}
+
+// MergePullRequest is not supported on already cloned repositories
+func (l *Local) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("merging is not supported on local repositories")
+}
+
+// ClosePullRequest is not supported on already cloned repositories
+func (l *Local) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("closing is not supported on local repositories")
+}
+
+// ForkRepository is not supported on already cloned repositories
+func (l *Local) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on local repositories")
+}
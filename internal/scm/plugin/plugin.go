@@ -0,0 +1,311 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// Config is sent to the plugin when it is started, with the values of the platform flags
+type Config struct {
+	Token         string   `json:"token"`
+	BaseURL       string   `json:"base_url"`
+	Organizations []string `json:"organizations"`
+	Groups        []string `json:"groups"`
+	Users         []string `json:"users"`
+	Repositories  []string `json:"repositories"`
+	ForkMode      bool     `json:"fork_mode"`
+}
+
+// Repository is a repository as sent to and from the plugin
+type Repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	// The url the repository is cloned from and pushed to, including any credentials
+	CloneURL string `json:"clone_url"`
+	// Data is any data the plugin needs to identify the repository, it is sent back unchanged
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// PullRequest is a pull request as sent to and from the plugin
+type PullRequest struct {
+	// Name is how the pull request is presented, usually "owner/name #number"
+	Name string `json:"name"`
+	// Repository is the full name of the repository the pull request is made to. If not set, it is taken from the name
+	Repository string `json:"repository,omitempty"`
+	// Status is one of "unknown", "success", "pending", "error", "merged" and "closed"
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"`
+	// Data is any data the plugin needs to identify the pull request, it is sent back unchanged
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// NewPullRequest is the data of a pull request that should be created
+type NewPullRequest struct {
+	Title            string   `json:"title"`
+	Body             string   `json:"body"`
+	Head             string   `json:"head"`
+	Base             string   `json:"base"`
+	Reviewers        []string `json:"reviewers"`
+	TeamReviewers    []string `json:"team_reviewers"`
+	DefaultReviewers bool     `json:"default_reviewers"` // If the default reviewers of the repository should be added
+	Assignees        []string `json:"assignees"`
+	Labels           []string `json:"labels"`
+	Milestone        string   `json:"milestone"`
+	Draft            bool     `json:"draft"`
+}
+
+// CreatePullRequestArgs are the arguments of Plugin.CreatePullRequest
+type CreatePullRequestArgs struct {
+	Repository            Repository     `json:"repository"`
+	PullRequestRepository Repository     `json:"pull_request_repository"` // The repository the branch was pushed to, differs in fork mode
+	PullRequest           NewPullRequest `json:"pull_request"`
+}
+
+// GetPullRequestsArgs are the arguments of Plugin.GetPullRequests
+type GetPullRequestsArgs struct {
+	BranchName string `json:"branch_name"`
+}
+
+// ForkRepositoryArgs are the arguments of Plugin.ForkRepository
+type ForkRepositoryArgs struct {
+	Repository Repository `json:"repository"`
+	NewOwner   string     `json:"new_owner"`
+}
+
+// Empty is used as the arguments, or result, of methods without any
+type Empty struct{}
+
+// New starts the plugin and sends the config to it.
+//
+// The plugin communicates with JSON-RPC 1.0 over its stdin and stdout, and should exit when its stdin is closed.
+// Anything written to stderr is logged. The methods the plugin has to implement are:
+//
+//	Plugin.Configure(Config) Empty
+//	Plugin.GetRepositories(Empty) []Repository
+//	Plugin.CreatePullRequest(CreatePullRequestArgs) PullRequest
+//	Plugin.GetPullRequests(GetPullRequestsArgs) []PullRequest
+//	Plugin.MergePullRequest(PullRequest) Empty
+//	Plugin.ClosePullRequest(PullRequest) Empty
+//	Plugin.ForkRepository(ForkRepositoryArgs) Repository
+func New(path string, args []string, config Config) (*Plugin, error) {
+	cmd := exec.Command(path, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = log.StandardLogger().WriterLevel(log.DebugLevel)
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "could not start the plugin")
+	}
+
+	p := &Plugin{
+		client: jsonrpc.NewClient(pipe{
+			Reader: stdout,
+			Writer: stdin,
+			Closer: stdin,
+		}),
+	}
+
+	if err := p.call(context.Background(), "Configure", config, &Empty{}); err != nil {
+		_ = cmd.Process.Kill()
+		return nil, errors.WithMessage(err, "could not configure the plugin")
+	}
+
+	return p, nil
+}
+
+// Plugin is a platform implemented by an external program
+type Plugin struct {
+	client *rpc.Client
+}
+
+// pipe combines the stdout and stdin of the plugin into a single connection
+type pipe struct {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// call calls a method of the plugin, and stops waiting for the result if the context is cancelled
+func (p *Plugin) call(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	call := p.client.Go("Plugin."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		if call.Error != nil {
+			return errors.Wrapf(call.Error, "the plugin failed to %s", method)
+		}
+		return nil
+	}
+}
+
+// repository is a repository of the plugin
+type repository struct {
+	repo Repository
+}
+
+func (r repository) URL(token string) string {
+	return r.repo.CloneURL
+}
+
+func (r repository) DefaultBranch() string {
+	return r.repo.DefaultBranch
+}
+
+func (r repository) FullName() string {
+	return r.repo.FullName
+}
+
+// pullRequest is a pull request of the plugin
+type pullRequest struct {
+	pr PullRequest
+}
+
+func (pr pullRequest) String() string {
+	return pr.pr.Name
+}
+
+func (pr pullRequest) RepositoryName() string {
+	if pr.pr.Repository != "" {
+		return pr.pr.Repository
+	}
+	// Plugins that do not set the repository usually name the pull requests "owner/name #number"
+	return strings.SplitN(pr.pr.Name, " #", 2)[0]
+}
+
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	switch pr.pr.Status {
+	case "success":
+		return domain.PullRequestStatusSuccess
+	case "pending":
+		return domain.PullRequestStatusPending
+	case "error":
+		return domain.PullRequestStatusError
+	case "merged":
+		return domain.PullRequestStatusMerged
+	case "closed":
+		return domain.PullRequestStatusClosed
+	}
+	return domain.PullRequestStatusUnknown
+}
+
+// URL returns the url of the pull request
+func (pr pullRequest) URL() string {
+	return pr.pr.URL
+}
+
+func toRepository(repo domain.Repository) Repository {
+	if r, ok := repo.(repository); ok {
+		return r.repo
+	}
+	return Repository{
+		FullName:      repo.FullName(),
+		DefaultBranch: repo.DefaultBranch(),
+		CloneURL:      repo.URL(""),
+	}
+}
+
+func toPullRequest(pr domain.PullRequest) PullRequest {
+	if p, ok := pr.(pullRequest); ok {
+		return p.pr
+	}
+	return PullRequest{
+		Name: pr.String(),
+	}
+}
+
+// GetRepositories gets the repositories from the plugin
+func (p *Plugin) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	var repos []Repository
+	if err := p.call(ctx, "GetRepositories", Empty{}, &repos); err != nil {
+		return nil, err
+	}
+
+	ret := make([]domain.Repository, len(repos))
+	for i, repo := range repos {
+		ret[i] = repository{repo}
+	}
+	return ret, nil
+}
+
+// CreatePullRequest creates a pull request with the plugin
+func (p *Plugin) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	reviewers, teamReviewers := domain.SplitReviewers(newPR.Reviewers)
+
+	var pr PullRequest
+	err := p.call(ctx, "CreatePullRequest", CreatePullRequestArgs{
+		Repository:            toRepository(repo),
+		PullRequestRepository: toRepository(prRepo),
+		PullRequest: NewPullRequest{
+			Title:            newPR.Title,
+			Body:             newPR.Body,
+			Head:             newPR.Head,
+			Base:             newPR.Base,
+			Reviewers:        reviewers,
+			TeamReviewers:    teamReviewers,
+			DefaultReviewers: domain.HasDefaultReviewers(newPR.Reviewers),
+			Assignees:        newPR.Assignees,
+			Labels:           newPR.Labels,
+			Milestone:        newPR.Milestone,
+			Draft:            newPR.Draft,
+		},
+	}, &pr)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequest{pr}, nil
+}
+
+// GetPullRequests gets the pull requests with a specific branch name from the plugin
+func (p *Plugin) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	var prs []PullRequest
+	if err := p.call(ctx, "GetPullRequests", GetPullRequestsArgs{BranchName: branchName}, &prs); err != nil {
+		return nil, err
+	}
+
+	ret := make([]domain.PullRequest, len(prs))
+	for i, pr := range prs {
+		ret[i] = pullRequest{pr}
+	}
+	return ret, nil
+}
+
+// MergePullRequest merges a pull request with the plugin
+func (p *Plugin) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return p.call(ctx, "MergePullRequest", toPullRequest(pr), &Empty{})
+}
+
+// ClosePullRequest closes a pull request with the plugin
+func (p *Plugin) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return p.call(ctx, "ClosePullRequest", toPullRequest(pr), &Empty{})
+}
+
+// ForkRepository forks a repository with the plugin
+func (p *Plugin) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	var fork Repository
+	err := p.call(ctx, "ForkRepository", ForkRepositoryArgs{
+		Repository: toRepository(repo),
+		NewOwner:   newOwner,
+	}, &fork)
+	if err != nil {
+		return nil, err
+	}
+	return repository{fork}, nil
+}
@@ -0,0 +1,182 @@
+package rawgit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// New create a new client for git remotes without any API. Credentials are expected to be part of the urls,
+// or be handled by ssh or the credential helpers of git
+func New(cloneURLs []string) (*RawGit, error) {
+	remotes := make([]remote, len(cloneURLs))
+	for i, cloneURL := range cloneURLs {
+		endpoint, err := transport.NewEndpoint(cloneURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse the url %s", cloneURL)
+		}
+		name := strings.TrimSuffix(strings.Trim(endpoint.Path, "/"), ".git")
+		if name == "" {
+			return nil, errors.Errorf("could not find the name of the repository in %s", cloneURL)
+		}
+		remotes[i] = remote{
+			url:  cloneURL,
+			name: name,
+		}
+	}
+
+	return &RawGit{
+		remotes: remotes,
+	}, nil
+}
+
+// RawGit pushes branches to git remotes, without creating any pull requests
+type RawGit struct {
+	remotes []remote
+}
+
+type remote struct {
+	url  string
+	name string // The path of the url, without any ".git" suffix
+}
+
+type repository struct {
+	url           string
+	name          string
+	defaultBranch string
+}
+
+// URL returns the url as it was configured, since there is no token
+func (r repository) URL(token string) string {
+	return r.url
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return r.name
+}
+
+// pullRequest is a pushed branch, which has to be merged without the help of multi-gitter
+type pullRequest struct {
+	repoName   string
+	branchName string
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("%s:%s", pr.repoName, pr.branchName)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return pr.repoName
+}
+
+// Status is always pending, since there is no way to know what happens to the pushed branch
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusPending
+}
+
+// GetRepositories gets the default branch of all remotes
+func (g *RawGit) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	repos := make([]domain.Repository, 0, len(g.remotes))
+	for _, r := range g.remotes {
+		refs, err := listRefs(ctx, r.url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list the branches of %s", r.name)
+		}
+
+		defaultBranch := ""
+		for _, ref := range refs {
+			if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+				defaultBranch = ref.Target().Short()
+			}
+		}
+		if defaultBranch == "" {
+			log.Debugf("Skipping %s since it has no default branch", r.name)
+			continue
+		}
+
+		repos = append(repos, repository{
+			url:           r.url,
+			name:          r.name,
+			defaultBranch: defaultBranch,
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].FullName() < repos[j].FullName()
+	})
+
+	return repos, nil
+}
+
+// CreatePullRequest does not create anything, since there is no API. The branch has already been pushed
+func (g *RawGit) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	log.WithField("repo", repo.FullName()).Infof("The branch %s has been pushed", newPR.Head)
+
+	return pullRequest{
+		repoName:   repo.FullName(),
+		branchName: newPR.Head,
+	}, nil
+}
+
+// GetPullRequests gets the pushed feature branches of all remotes
+func (g *RawGit) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	prs := []domain.PullRequest{}
+	for _, r := range g.remotes {
+		refs, err := listRefs(ctx, r.url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list the branches of %s", r.name)
+		}
+
+		for _, ref := range refs {
+			if ref.Name() == plumbing.NewBranchReferenceName(branchName) {
+				prs = append(prs, pullRequest{
+					repoName:   r.name,
+					branchName: branchName,
+				})
+			}
+		}
+	}
+	return prs, nil
+}
+
+// MergePullRequest is not supported, since there is no API
+func (g *RawGit) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("merging is not supported on plain git remotes")
+}
+
+// ClosePullRequest is not supported, since there is no API
+func (g *RawGit) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("closing is not supported on plain git remotes")
+}
+
+// ForkRepository is not supported, since there is no API
+func (g *RawGit) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on plain git remotes")
+}
+
+// listRefs lists the references of a remote, without cloning it
+func listRefs(ctx context.Context, url string) ([]*plumbing.Reference, error) {
+	r := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	refs, err := r.ListContext(ctx, &git.ListOptions{})
+	if err == transport.ErrEmptyRemoteRepository {
+		return nil, nil
+	}
+	return refs, err
+}
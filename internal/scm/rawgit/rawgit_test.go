@@ -0,0 +1,68 @@
+package rawgit_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/scm/rawgit"
+)
+
+// createRepo creates a repository with a single commit on the main branch, and the branches
+func createRepo(t *testing.T, path string, branches ...string) {
+	repo, err := git.PlainInit(path, false)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(path, "README.md"), []byte("hello"), 0600))
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("README.md")
+	require.NoError(t, err)
+	hash, err := wt.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	for _, branch := range branches {
+		require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), hash)))
+	}
+}
+
+func TestRawGit(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-rawgit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	createRepo(t, filepath.Join(tmpDir, "b"))
+	createRepo(t, filepath.Join(tmpDir, "a"), "my-branch")
+
+	g, err := rawgit.New([]string{filepath.Join(tmpDir, "b"), filepath.Join(tmpDir, "a")})
+	require.NoError(t, err)
+
+	repos, err := g.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "a", filepath.Base(repos[0].FullName()))
+	assert.Equal(t, "main", repos[0].DefaultBranch())
+	assert.Equal(t, filepath.Join(tmpDir, "a"), repos[0].URL(""))
+
+	prs, err := g.GetPullRequests(context.Background(), "my-branch")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, repos[0].FullName()+":my-branch", prs[0].String())
+	assert.Equal(t, domain.PullRequestStatusPending, prs[0].Status())
+
+	_, err = rawgit.New([]string{"https://example.com/"})
+	assert.EqualError(t, err, "could not find the name of the repository in https://example.com/")
+}
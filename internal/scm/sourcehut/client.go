@@ -0,0 +1,78 @@
+package sourcehut
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// apiError is an error returned by the sourcehut GraphQL API
+type apiError struct {
+	StatusCode int
+	Messages   []string
+}
+
+func (e apiError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("sourcehut responded with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("sourcehut responded with status code %d: %s", e.StatusCode, strings.Join(e.Messages, ", "))
+}
+
+// query makes a query to the GraphQL API of git.sr.ht, and decodes the data of the response into result
+func (s *Sourcehut) query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/query", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	_ = json.Unmarshal(respBody, &response)
+
+	// Errors can be returned together with a successful status code
+	if resp.StatusCode >= 300 || len(response.Errors) > 0 {
+		messages := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			messages[i] = e.Message
+		}
+		return apiError{
+			StatusCode: resp.StatusCode,
+			Messages:   messages,
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, result)
+}
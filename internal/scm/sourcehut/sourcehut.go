@@ -0,0 +1,323 @@
+package sourcehut
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+)
+
+// New create a new sourcehut client. If baseURL is empty, git.sr.ht is used
+func New(
+	token, baseURL string,
+	transportMiddleware func(http.RoundTripper) http.RoundTripper,
+	repoListing RepositoryListing,
+) (*Sourcehut, error) {
+	if baseURL == "" {
+		baseURL = "https://git.sr.ht"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the base url")
+	}
+
+	return &Sourcehut{
+		RepositoryListing: repoListing,
+
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		sshHost: u.Hostname(),
+		httpClient: &http.Client{
+			Transport: transportMiddleware(http.DefaultTransport),
+		},
+	}, nil
+}
+
+// Sourcehut contain sourcehut configuration
+type Sourcehut struct {
+	RepositoryListing
+
+	token      string
+	baseURL    string
+	sshHost    string
+	httpClient *http.Client
+}
+
+// RepositoryListing contains information about which repositories that should be fetched
+type RepositoryListing struct {
+	Users        []string
+	Repositories []RepositoryReference
+}
+
+// RepositoryReference contains information to be able to reference a repository
+type RepositoryReference struct {
+	OwnerName string // The name of the user, without the "~" prefix
+	Name      string
+}
+
+// ParseRepositoryReference parses a repository reference from the format "~ownerName/repoName"
+func ParseRepositoryReference(val string) (RepositoryReference, error) {
+	split := strings.Split(strings.TrimPrefix(val, "~"), "/")
+	if len(split) != 2 {
+		return RepositoryReference{}, fmt.Errorf("could not parse repository reference: %s", val)
+	}
+	return RepositoryReference{
+		OwnerName: split[0],
+		Name:      split[1],
+	}, nil
+}
+
+type repository struct {
+	sshHost       string
+	ownerName     string
+	name          string
+	defaultBranch string
+}
+
+// URL returns the ssh url of the repository, since sourcehut does not support pushing over https
+func (r repository) URL(token string) string {
+	return fmt.Sprintf("git@%s:~%s/%s", r.sshHost, r.ownerName, r.name)
+}
+
+func (r repository) DefaultBranch() string {
+	return r.defaultBranch
+}
+
+func (r repository) FullName() string {
+	return fmt.Sprintf("~%s/%s", r.ownerName, r.name)
+}
+
+// pullRequest is a pushed branch, which is submitted as a patchset to a mailing list
+type pullRequest struct {
+	baseURL    string
+	ownerName  string
+	repoName   string
+	branchName string
+}
+
+func (pr pullRequest) String() string {
+	return fmt.Sprintf("~%s/%s:%s", pr.ownerName, pr.repoName, pr.branchName)
+}
+
+func (pr pullRequest) RepositoryName() string {
+	return fmt.Sprintf("~%s/%s", pr.ownerName, pr.repoName)
+}
+
+// Status is always pending, since it is not possible to know when the patches have been applied
+func (pr pullRequest) Status() domain.PullRequestStatus {
+	return domain.PullRequestStatusPending
+}
+
+func (pr pullRequest) URL() string {
+	return fmt.Sprintf("%s/~%s/%s/log/%s", pr.baseURL, pr.ownerName, pr.repoName, pr.branchName)
+}
+
+const repositoryFields = `name HEAD { name } owner { canonicalName }`
+
+type srhtRepository struct {
+	Name string `json:"name"`
+	HEAD *struct {
+		Name string `json:"name"`
+	} `json:"HEAD"`
+	Owner struct {
+		CanonicalName string `json:"canonicalName"`
+	} `json:"owner"`
+}
+
+// GetRepositories fetches repositories from all users and specific repositories
+func (s *Sourcehut) GetRepositories(ctx context.Context) ([]domain.Repository, error) {
+	allRepos := map[string]srhtRepository{}
+
+	for _, user := range s.Users {
+		repos, err := s.getUserRepositories(ctx, strings.TrimPrefix(user, "~"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the repositories of %s", user)
+		}
+		for _, repo := range repos {
+			allRepos[repo.Owner.CanonicalName+"/"+repo.Name] = repo
+		}
+	}
+
+	for _, ref := range s.Repositories {
+		repo, err := s.getRepository(ctx, ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get information about ~%s/%s", ref.OwnerName, ref.Name)
+		}
+		allRepos[repo.Owner.CanonicalName+"/"+repo.Name] = repo
+	}
+
+	repos := make([]domain.Repository, 0, len(allRepos))
+	for _, repo := range allRepos {
+		if repo.HEAD == nil {
+			log.Debugf("Skipping %s/%s since it's empty", repo.Owner.CanonicalName, repo.Name)
+			continue
+		}
+		repos = append(repos, repository{
+			sshHost:       s.sshHost,
+			ownerName:     strings.TrimPrefix(repo.Owner.CanonicalName, "~"),
+			name:          repo.Name,
+			defaultBranch: strings.TrimPrefix(repo.HEAD.Name, "refs/heads/"),
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].FullName() < repos[j].FullName()
+	})
+
+	return repos, nil
+}
+
+func (s *Sourcehut) getUserRepositories(ctx context.Context, username string) ([]srhtRepository, error) {
+	var repos []srhtRepository
+	var cursor *string
+	for {
+		var data struct {
+			User *struct {
+				Repositories struct {
+					Results []srhtRepository `json:"results"`
+					Cursor  *string          `json:"cursor"`
+				} `json:"repositories"`
+			} `json:"user"`
+		}
+		err := s.query(ctx, `query($username: String!, $cursor: Cursor) {
+			user(username: $username) { repositories(cursor: $cursor) { results { `+repositoryFields+` } cursor } }
+		}`, map[string]interface{}{
+			"username": username,
+			"cursor":   cursor,
+		}, &data)
+		if err != nil {
+			return nil, err
+		}
+		if data.User == nil {
+			return nil, errors.Errorf("could not find the user %s", username)
+		}
+
+		repos = append(repos, data.User.Repositories.Results...)
+		if data.User.Repositories.Cursor == nil {
+			return repos, nil
+		}
+		cursor = data.User.Repositories.Cursor
+	}
+}
+
+func (s *Sourcehut) getRepository(ctx context.Context, ref RepositoryReference) (srhtRepository, error) {
+	var data struct {
+		User *struct {
+			Repository *srhtRepository `json:"repository"`
+		} `json:"user"`
+	}
+	err := s.query(ctx, `query($username: String!, $name: String!) {
+		user(username: $username) { repository(name: $name) { `+repositoryFields+` } }
+	}`, map[string]interface{}{
+		"username": ref.OwnerName,
+		"name":     ref.Name,
+	}, &data)
+	if err != nil {
+		return srhtRepository{}, err
+	}
+	if data.User == nil || data.User.Repository == nil {
+		return srhtRepository{}, errors.New("the repository does not exist")
+	}
+	return *data.User.Repository, nil
+}
+
+// CreatePullRequest does not create anything, since changes are submitted as patches to mailing lists on sourcehut.
+// Instead, the instructions to submit the pushed branch as a patchset are logged
+func (s *Sourcehut) CreatePullRequest(ctx context.Context, repo domain.Repository, prRepo domain.Repository, newPR domain.NewPullRequest) (domain.PullRequest, error) {
+	r := repo.(repository)
+
+	logger := log.WithField("repo", r.FullName())
+	if len(newPR.Assignees) > 0 {
+		logger.Warn("Assignees are not supported on sourcehut")
+	}
+	if len(newPR.Labels) > 0 {
+		logger.Warn("Labels are not supported on sourcehut")
+	}
+	if newPR.Milestone != "" {
+		logger.Warn("Milestones are not supported on sourcehut")
+	}
+	if domain.HasDefaultReviewers(newPR.Reviewers) {
+		logger.Warn("Default reviewers are not supported on sourcehut")
+	}
+
+	// Reviewers are added as recipients of the patches
+	command := fmt.Sprintf("git send-email --to=<mailing list> origin/%s..%s", newPR.Base, newPR.Head)
+	users, _ := domain.SplitReviewers(newPR.Reviewers)
+	for _, user := range users {
+		command += " --cc=" + user
+	}
+	logger.Infof("The branch has been pushed, submit it to the mailing list of the project with: %s", command)
+
+	return pullRequest{
+		baseURL:    s.baseURL,
+		ownerName:  r.ownerName,
+		repoName:   r.name,
+		branchName: newPR.Head,
+	}, nil
+}
+
+// GetPullRequests gets the pushed branches of all repositories
+func (s *Sourcehut) GetPullRequests(ctx context.Context, branchName string) ([]domain.PullRequest, error) {
+	repos, err := s.GetRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []domain.PullRequest{}
+	for _, repo := range repos {
+		r := repo.(repository)
+
+		var data struct {
+			User struct {
+				Repository struct {
+					Commit *struct {
+						ID string `json:"id"`
+					} `json:"revparse_single"`
+				} `json:"repository"`
+			} `json:"user"`
+		}
+		err := s.query(ctx, `query($username: String!, $name: String!, $revspec: String!) {
+			user(username: $username) { repository(name: $name) { revparse_single(revspec: $revspec) { id } } }
+		}`, map[string]interface{}{
+			"username": r.ownerName,
+			"name":     r.name,
+			"revspec":  "refs/heads/" + branchName,
+		}, &data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get the branch of %s", r.FullName())
+		}
+		if data.User.Repository.Commit == nil {
+			continue
+		}
+
+		prs = append(prs, pullRequest{
+			baseURL:    s.baseURL,
+			ownerName:  r.ownerName,
+			repoName:   r.name,
+			branchName: branchName,
+		})
+	}
+
+	return prs, nil
+}
+
+// MergePullRequest is not supported, since patches are applied by the maintainers of the project
+func (s *Sourcehut) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("merging is not supported on sourcehut, patches are applied by the maintainers of the project")
+}
+
+// ClosePullRequest is not supported, since patches are submitted to mailing lists
+func (s *Sourcehut) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
+	return errors.New("closing is not supported on sourcehut, patches are submitted to mailing lists")
+}
+
+// ForkRepository is not supported on sourcehut
+func (s *Sourcehut) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
+	return nil, errors.New("forking is not supported on sourcehut")
+}
@@ -0,0 +1,115 @@
+package sourcehut_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/internal/scm/sourcehut"
+)
+
+func noMiddleware(rt http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+// newServer creates a GraphQL server that responds with the result of the handler
+func newServer(t *testing.T, handler func(query string, variables map[string]interface{}) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/query", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		fmt.Fprint(w, handler(body.Query, body.Variables))
+	}))
+}
+
+func TestGetRepositories(t *testing.T) {
+	server := newServer(t, func(query string, variables map[string]interface{}) string {
+		switch {
+		case variables["username"] == "missing":
+			return `{"data": {"user": null}, "errors": [{"message": "not found"}]}`
+		case strings.Contains(query, "repositories(cursor: $cursor)") && variables["cursor"] == nil:
+			return `{"data": {"user": {"repositories": {"results": [
+				{"name": "b", "HEAD": {"name": "refs/heads/main"}, "owner": {"canonicalName": "~alice"}},
+				{"name": "empty", "HEAD": null, "owner": {"canonicalName": "~alice"}}
+			], "cursor": "next"}}}}`
+		case strings.Contains(query, "repositories(cursor: $cursor)"):
+			return `{"data": {"user": {"repositories": {"results": [
+				{"name": "a", "HEAD": {"name": "refs/heads/master"}, "owner": {"canonicalName": "~alice"}}
+			], "cursor": null}}}}`
+		case variables["username"] == "bob":
+			return `{"data": {"user": {"repository": {"name": "tool", "HEAD": {"name": "refs/heads/trunk"}, "owner": {"canonicalName": "~bob"}}}}}`
+		}
+		return `{"data": {"user": null}}`
+	})
+	defer server.Close()
+
+	repoRef, err := sourcehut.ParseRepositoryReference("~bob/tool")
+	require.NoError(t, err)
+
+	s, err := sourcehut.New("token", server.URL, noMiddleware, sourcehut.RepositoryListing{
+		Users:        []string{"~alice"},
+		Repositories: []sourcehut.RepositoryReference{repoRef},
+	})
+	require.NoError(t, err)
+
+	repos, err := s.GetRepositories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, repos, 3)
+
+	assert.Equal(t, "~alice/a", repos[0].FullName())
+	assert.Equal(t, "master", repos[0].DefaultBranch())
+	assert.Equal(t, "~alice/b", repos[1].FullName())
+	assert.Equal(t, "~bob/tool", repos[2].FullName())
+	assert.Equal(t, "trunk", repos[2].DefaultBranch())
+	assert.Equal(t, "git@127.0.0.1:~bob/tool", repos[2].URL("token"))
+
+	s, err = sourcehut.New("token", server.URL, noMiddleware, sourcehut.RepositoryListing{
+		Users: []string{"missing"},
+	})
+	require.NoError(t, err)
+	_, err = s.GetRepositories(context.Background())
+	assert.EqualError(t, err, "could not get the repositories of missing: sourcehut responded with status code 200: not found")
+}
+
+func TestGetPullRequests(t *testing.T) {
+	server := newServer(t, func(query string, variables map[string]interface{}) string {
+		switch {
+		case strings.Contains(query, "repositories(cursor: $cursor)"):
+			return `{"data": {"user": {"repositories": {"results": [
+				{"name": "a", "HEAD": {"name": "refs/heads/master"}, "owner": {"canonicalName": "~alice"}},
+				{"name": "b", "HEAD": {"name": "refs/heads/master"}, "owner": {"canonicalName": "~alice"}}
+			], "cursor": null}}}}`
+		case variables["name"] == "a":
+			assert.Equal(t, "refs/heads/my-branch", variables["revspec"])
+			return `{"data": {"user": {"repository": {"revparse_single": {"id": "abc123"}}}}}`
+		}
+		return `{"data": {"user": {"repository": {"revparse_single": null}}}}`
+	})
+	defer server.Close()
+
+	s, err := sourcehut.New("token", server.URL, noMiddleware, sourcehut.RepositoryListing{
+		Users: []string{"alice"},
+	})
+	require.NoError(t, err)
+
+	prs, err := s.GetPullRequests(context.Background(), "my-branch")
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, "~alice/a:my-branch", prs[0].String())
+	assert.Equal(t, domain.PullRequestStatusPending, prs[0].Status())
+
+	assert.Error(t, s.MergePullRequest(context.Background(), prs[0]))
+}
@@ -0,0 +1,91 @@
+package secretref
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+)
+
+// awsSecretsManager gets the string of a secret from AWS Secrets Manager, by its name or ARN
+func (r *Resolver) awsSecretsManager(ctx context.Context, secretID string) (string, error) {
+	creds, region, err := r.awsCredentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	// The region of a secret referenced by its ARN, such as arn:aws:secretsmanager:eu-west-1:123456789012:secret:name,
+	// is part of the ARN
+	if parts := strings.Split(secretID, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	if region == "" {
+		return "", errors.New("no AWS region set")
+	}
+
+	endpoint := r.AWSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if err := aws.SignRequest(ctx, req, body, creds, region, "secretsmanager", time.Now().UTC()); err != nil {
+		return "", err
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var secretValue struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &secretValue); err != nil {
+		return "", errors.Wrap(err, "could not parse the response of AWS Secrets Manager")
+	}
+	if secretValue.SecretString == nil {
+		return "", errors.New("only secrets stored as a string are supported")
+	}
+	return *secretValue.SecretString, nil
+}
+
+func (r *Resolver) awsCredentials(ctx context.Context) (aws.Credentials, string, error) {
+	if r.AWSCredentials != nil {
+		return *r.AWSCredentials, r.AWSRegion, nil
+	}
+
+	creds, region, err := aws.LoadCredentials(ctx, "")
+	if err != nil {
+		return aws.Credentials{}, "", err
+	}
+	if r.AWSRegion != "" {
+		region = r.AWSRegion
+	}
+	return creds, region, nil
+}
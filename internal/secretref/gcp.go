@@ -0,0 +1,77 @@
+package secretref
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/gcp"
+)
+
+// gcpSecretManager gets the string of a secret from GCP Secret Manager, by its resource name, such as
+// "projects/my-project/secrets/my-secret". The latest version is used unless a version is part of the name
+func (r *Resolver) gcpSecretManager(ctx context.Context, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if !strings.HasPrefix(name, "projects/") {
+		return "", errors.Errorf("%s is not the name of a secret, such as projects/my-project/secrets/my-secret", name)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := r.gcpAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := r.GCPEndpoint
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return "", errors.Wrap(err, "could not parse the response of GCP Secret Manager")
+	}
+	data, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decode the secret")
+	}
+	return string(data), nil
+}
+
+// gcpAccessToken gets an access token of Google Cloud, unless one is set on the resolver
+func (r *Resolver) gcpAccessToken(ctx context.Context) (string, error) {
+	if r.GCPAccessToken != "" {
+		return r.GCPAccessToken, nil
+	}
+	return gcp.AccessToken(ctx, r.httpClient())
+}
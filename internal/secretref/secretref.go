@@ -0,0 +1,173 @@
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+)
+
+// The schemes of the references to secrets
+const (
+	schemeVault = "vault://"
+	schemeAWS   = "aws-sm://"
+	schemeGCP   = "gcp-sm://"
+)
+
+// defaultTTL is how long a secret without an expiry is reused before it is fetched again,
+// which makes long running commands pick up rotated secrets
+const defaultTTL = 5 * time.Minute
+
+// refreshMargin is how long before a secret expires that it is fetched again
+const refreshMargin = time.Minute
+
+// IsReference checks if a value is a reference to a secret, such as "vault://secret/multi-gitter#token",
+// instead of the secret itself
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, schemeVault) ||
+		strings.HasPrefix(value, schemeAWS) ||
+		strings.HasPrefix(value, schemeGCP)
+}
+
+// Resolver fetches secrets from HashiCorp Vault, AWS Secrets Manager and GCP Secret Manager.
+// The secrets are cached until they expire. Unset fields are read from the same environment variables
+// and files as the CLIs of each service
+type Resolver struct {
+	HTTPClient *http.Client
+
+	VaultAddress   string // VAULT_ADDR
+	VaultToken     string // VAULT_TOKEN, or ~/.vault-token
+	VaultNamespace string // VAULT_NAMESPACE
+
+	AWSCredentials *aws.Credentials
+	AWSRegion      string
+	AWSEndpoint    string // Defaults to https://secretsmanager.{region}.amazonaws.com/
+
+	GCPAccessToken string // GOOGLE_OAUTH_ACCESS_TOKEN, or else the token of gcloud or the metadata server
+	GCPEndpoint    string // Defaults to https://secretmanager.googleapis.com/
+
+	lock    sync.Mutex
+	secrets map[string]secret
+}
+
+type secret struct {
+	value   string
+	expires time.Time
+}
+
+// Resolve returns the secret a reference points to. The part after "#" selects a key of a secret that contains
+// multiple values, such as the keys of a secret in Vault, or a secret containing a JSON object
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if s, ok := r.secrets[ref]; ok && time.Until(s.expires) > refreshMargin {
+		return s.value, nil
+	}
+
+	location, key := ref, ""
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		location, key = ref[:i], ref[i+1:]
+	}
+
+	var values map[string]interface{}
+	var raw string
+	var ttl time.Duration
+	var err error
+	switch {
+	case strings.HasPrefix(location, schemeVault):
+		values, ttl, err = r.vault(ctx, strings.TrimPrefix(location, schemeVault))
+	case strings.HasPrefix(location, schemeAWS):
+		raw, err = r.awsSecretsManager(ctx, strings.TrimPrefix(location, schemeAWS))
+	case strings.HasPrefix(location, schemeGCP):
+		raw, err = r.gcpSecretManager(ctx, strings.TrimPrefix(location, schemeGCP))
+	default:
+		return "", errors.Errorf("%s is not a reference to a secret", ref)
+	}
+	if err != nil {
+		return "", errors.WithMessagef(err, "could not get the secret %s", location)
+	}
+
+	// Secrets of AWS and GCP are plain strings, that might contain a JSON object with multiple values
+	if values == nil {
+		if key == "" {
+			values = map[string]interface{}{"": raw}
+		} else if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return "", errors.Errorf("the secret %s is not a JSON object, so the key %s can not be selected", location, key)
+		}
+	}
+
+	value, err := selectValue(values, key)
+	if err != nil {
+		return "", errors.WithMessagef(err, "the secret %s", location)
+	}
+
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if r.secrets == nil {
+		r.secrets = map[string]secret{}
+	}
+	r.secrets[ref] = secret{
+		value:   value,
+		expires: time.Now().Add(ttl),
+	}
+
+	return value, nil
+}
+
+// selectValue selects the value with the key, or the only value if no key is set
+func selectValue(values map[string]interface{}, key string) (string, error) {
+	if key == "" {
+		if len(values) != 1 {
+			keys := make([]string, 0, len(values))
+			for k := range values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return "", errors.Errorf("contains multiple values, one has to be selected with #key, available keys: %s", strings.Join(keys, ", "))
+		}
+		for k := range values {
+			key = k
+		}
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return "", errors.Errorf("does not contain the key %s", key)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return "", errors.Errorf("the value of the key %s is not a string", key)
+}
+
+func (r *Resolver) httpClient() *http.Client {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// apiError is an unsuccessful response from the API of a secret manager
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("responded with status code %d: %s", e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
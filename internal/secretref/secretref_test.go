@@ -0,0 +1,114 @@
+package secretref
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lindell/multi-gitter/internal/aws"
+)
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("vault://secret/multi-gitter#token"))
+	assert.True(t, IsReference("aws-sm://multi-gitter"))
+	assert.True(t, IsReference("gcp-sm://projects/my-project/secrets/multi-gitter"))
+	assert.False(t, IsReference("ghp_0123456789"))
+	assert.False(t, IsReference("https://example.com"))
+}
+
+func TestResolve(t *testing.T) {
+	vaultCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/multi-gitter":
+			vaultCalls++
+			assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"lease_duration":0,"data":{"data":{"token":"kv-token","other":"value"},"metadata":{"version":1}}}`))
+		case "/v1/github/token/multi-gitter":
+			vaultCalls++
+			_, _ = w.Write([]byte(`{"lease_duration":30,"data":{"token":"leased-token"}}`))
+		case "/":
+			assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+			body, _ := ioutil.ReadAll(r.Body)
+			var input map[string]string
+			require.NoError(t, json.Unmarshal(body, &input))
+			assert.Equal(t, "arn:aws:secretsmanager:eu-west-1:123456789012:secret:multi-gitter", input["SecretId"])
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"token":"aws-token"}`})
+		case "/v1/projects/my-project/secrets/multi-gitter/versions/latest:access":
+			assert.Equal(t, "Bearer gcp-token", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("gcp-secret"))},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	resolver := &Resolver{
+		VaultAddress:   server.URL,
+		VaultToken:     "vault-token",
+		AWSCredentials: &aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"},
+		AWSRegion:      "us-east-1",
+		AWSEndpoint:    server.URL + "/",
+		GCPAccessToken: "gcp-token",
+		GCPEndpoint:    server.URL,
+	}
+	ctx := context.Background()
+
+	resolve := func(ref string) string {
+		value, err := resolver.Resolve(ctx, ref)
+		require.NoError(t, err)
+		return value
+	}
+
+	// Secrets of KV version 2 are cached, since they do not expire
+	assert.Equal(t, "kv-token", resolve("vault://secret/multi-gitter#token"))
+	assert.Equal(t, "kv-token", resolve("vault://secret/multi-gitter#token"))
+	assert.Equal(t, 1, vaultCalls)
+
+	// Leased secrets are fetched again when they are about to expire
+	assert.Equal(t, "leased-token", resolve("vault://github/token/multi-gitter"))
+	assert.Equal(t, "leased-token", resolve("vault://github/token/multi-gitter"))
+	assert.Equal(t, 3, vaultCalls)
+
+	assert.Equal(t, "aws-token", resolve("aws-sm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:multi-gitter#token"))
+	assert.Equal(t, "gcp-secret", resolve("gcp-sm://projects/my-project/secrets/multi-gitter"))
+
+	_, err := resolver.Resolve(ctx, "vault://secret/multi-gitter")
+	assert.EqualError(t, err, "the secret vault://secret/multi-gitter: contains multiple values, one has to be selected with #key, available keys: other, token")
+	_, err = resolver.Resolve(ctx, "vault://secret/multi-gitter#missing")
+	assert.EqualError(t, err, "the secret vault://secret/multi-gitter: does not contain the key missing")
+	_, err = resolver.Resolve(ctx, "vault://secret/missing#token")
+	assert.EqualError(t, err, `could not get the secret vault://secret/missing: responded with status code 404: {"errors":[]}`)
+	_, err = resolver.Resolve(ctx, "gcp-sm://projects/my-project/secrets/multi-gitter#token")
+	assert.EqualError(t, err, "the secret gcp-sm://projects/my-project/secrets/multi-gitter is not a JSON object, so the key token can not be selected")
+}
+
+func TestSecretExpiry(t *testing.T) {
+	resolver := &Resolver{
+		secrets: map[string]secret{
+			"vault://secret/valid#token":    {value: "valid", expires: time.Now().Add(time.Hour)},
+			"vault://secret/expiring#token": {value: "expiring", expires: time.Now().Add(refreshMargin / 2)},
+		},
+		VaultAddress: "http://127.0.0.1:0",
+		VaultToken:   "vault-token",
+	}
+
+	value, err := resolver.Resolve(context.Background(), "vault://secret/valid#token")
+	require.NoError(t, err)
+	assert.Equal(t, "valid", value)
+
+	_, err = resolver.Resolve(context.Background(), "vault://secret/expiring#token")
+	assert.Error(t, err, "a secret that is about to expire should be fetched again")
+}
@@ -0,0 +1,130 @@
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// vault reads a secret from Vault. Paths of the KV version 2 engine can be written in the same way as with
+// "vault kv get", such as "secret/multi-gitter", and secrets of other engines with their full path.
+// The values of the secret are returned together with how long they are valid, if they expire
+func (r *Resolver) vault(ctx context.Context, path string) (map[string]interface{}, time.Duration, error) {
+	path = strings.Trim(path, "/")
+
+	// Try the path of KV version 2 first, since it is the default engine
+	paths := []string{path}
+	if mount, rest := splitMount(path); rest != "" && !strings.HasPrefix(rest, "data/") {
+		paths = []string{mount + "/data/" + rest, path}
+	}
+
+	var resp vaultResponse
+	var err error
+	for _, p := range paths {
+		resp, err = r.vaultRead(ctx, p)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		// Secrets of KV version 2 are nested together with their metadata
+		if _, rest := splitMount(p); strings.HasPrefix(rest, "data/") {
+			if data, ok := resp.Data["data"].(map[string]interface{}); ok {
+				resp.Data = data
+			}
+		}
+		return resp.Data, time.Duration(resp.LeaseDuration) * time.Second, nil
+	}
+	return nil, 0, err
+}
+
+func (r *Resolver) vaultRead(ctx context.Context, path string) (vaultResponse, error) {
+	address := r.VaultAddress
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return vaultResponse{}, errors.New("the VAULT_ADDR environment variable has to be set")
+	}
+	token, err := r.vaultToken()
+	if err != nil {
+		return vaultResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return vaultResponse{}, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	namespace := r.VaultNamespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return vaultResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return vaultResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return vaultResponse{}, apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var vaultResp vaultResponse
+	if err := json.Unmarshal(body, &vaultResp); err != nil {
+		return vaultResponse{}, errors.Wrap(err, "could not parse the response of Vault")
+	}
+	return vaultResp, nil
+}
+
+// vaultToken gets the token of Vault, in the same way as the Vault CLI
+func (r *Resolver) vaultToken() (string, error) {
+	if r.VaultToken != "" {
+		return r.VaultToken, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+	if os.IsNotExist(err) {
+		return "", errors.New("either the VAULT_TOKEN environment variable has to be set, or a token stored with vault login")
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// splitMount splits a path into the mount of the secrets engine and the rest of the path
+func splitMount(path string) (string, string) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) < 2 {
+		return path, ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,100 @@
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The states of a change request that can no longer be implemented
+const (
+	stateClosed   = "3"
+	stateCanceled = "4"
+)
+
+// Client verifies change requests through the Table API of a ServiceNow instance.
+// It is authenticated with a token if set, and otherwise with the username and password
+type Client struct {
+	BaseURL  string // The url of the instance, for example https://my-company.service-now.com
+	Token    string
+	Username string
+	Password string
+
+	HTTPClient *http.Client
+}
+
+type changeRequest struct {
+	Number   string `json:"number"`
+	Approval string `json:"approval"`
+	State    string `json:"state"`
+}
+
+// VerifyChangeRequest returns an error if the change request, with a number such as "CHG0030001",
+// does not exist, is not approved, or has been closed or canceled
+func (c Client) VerifyChangeRequest(ctx context.Context, number string) error {
+	cr, err := c.getChangeRequest(ctx, number)
+	if err != nil {
+		return errors.WithMessagef(err, "could not get the change request %s from ServiceNow", number)
+	}
+
+	if cr.State == stateClosed || cr.State == stateCanceled {
+		return errors.Errorf("the change request %s is closed", number)
+	}
+	if cr.Approval != "approved" {
+		return errors.Errorf("the change request %s is not approved, its approval is %s", number, cr.Approval)
+	}
+	return nil
+}
+
+func (c Client) getChangeRequest(ctx context.Context, number string) (changeRequest, error) {
+	query := url.Values{}
+	query.Set("sysparm_query", "number="+number)
+	query.Set("sysparm_fields", "number,approval,state")
+	query.Set("sysparm_limit", "1")
+	u := strings.TrimSuffix(c.BaseURL, "/") + "/api/now/table/change_request?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return changeRequest{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return changeRequest{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return changeRequest{}, err
+	}
+	if resp.StatusCode >= 300 {
+		return changeRequest{}, errors.Errorf("responded with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Result []changeRequest `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return changeRequest{}, err
+	}
+	if len(result.Result) == 0 {
+		return changeRequest{}, errors.New("the change request does not exist")
+	}
+	return result.Result[0], nil
+}
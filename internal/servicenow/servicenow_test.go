@@ -0,0 +1,47 @@
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChangeRequest(t *testing.T) {
+	changeRequests := map[string]changeRequest{
+		"CHG0000001": {Number: "CHG0000001", Approval: "approved", State: "-1"},
+		"CHG0000002": {Number: "CHG0000002", Approval: "requested", State: "-3"},
+		"CHG0000003": {Number: "CHG0000003", Approval: "approved", State: "3"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "user" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"User Not Authenticated"}}`))
+			return
+		}
+		assert.Equal(t, "/api/now/table/change_request", r.URL.Path)
+
+		var result []changeRequest
+		number := r.URL.Query().Get("sysparm_query")[len("number="):]
+		if cr, ok := changeRequests[number]; ok {
+			result = append(result, cr)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	client := Client{BaseURL: server.URL + "/", Username: "user", Password: "password"}
+	ctx := context.Background()
+
+	assert.NoError(t, client.VerifyChangeRequest(ctx, "CHG0000001"))
+	assert.EqualError(t, client.VerifyChangeRequest(ctx, "CHG0000002"), "the change request CHG0000002 is not approved, its approval is requested")
+	assert.EqualError(t, client.VerifyChangeRequest(ctx, "CHG0000003"), "the change request CHG0000003 is closed")
+	assert.EqualError(t, client.VerifyChangeRequest(ctx, "CHG0000004"), "could not get the change request CHG0000004 from ServiceNow: the change request does not exist")
+
+	client.Password = "wrong"
+	assert.EqualError(t, client.VerifyChangeRequest(ctx, "CHG0000001"), `could not get the change request CHG0000001 from ServiceNow: responded with status code 401: {"error":{"message":"User Not Authenticated"}}`)
+}
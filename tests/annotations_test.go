@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishAnnotations(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "should-change", "i like apples"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-annotations-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--publish-annotations",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, map[string][]domain.CheckRun{
+		"owner/should-change:custom-branch-name": {
+			{
+				Name: "multi-gitter",
+				Annotations: []domain.Annotation{
+					{
+						Path:     "test.txt",
+						Line:     1,
+						Message:  "bananas are not apples",
+						Severity: domain.AnnotationSeverityWarning,
+					},
+				},
+			},
+		},
+	}, vcMock.CheckRuns)
+}
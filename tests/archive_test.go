@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "active", "i like apples"),
+			createRepo(t, "owner", "already-archived", "i like apples"),
+		},
+		Archived: map[string]bool{
+			"owner/already-archived": true,
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-archive-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"archive",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/active:
+  archived: false -> true
+owner/already-archived: up to date
+
+1 would be changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.False(t, vcMock.Archived["owner/active"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")), "--yes"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/active:
+  archived: false -> true
+owner/already-archived: up to date
+
+1 changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "out.txt"))
+	assert.True(t, vcMock.Archived["owner/active"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "unarchive.txt")), "--yes", "--unarchive"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/active:
+  archived: true -> false
+owner/already-archived:
+  archived: true -> false
+
+2 changed, 0 up to date, 0 failed
+`, readFile(t, tmpDir, "unarchive.txt"))
+	assert.False(t, vcMock.Archived["owner/active"])
+	assert.False(t, vcMock.Archived["owner/already-archived"])
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchivedSkipped(t *testing.T) {
+	changeRepo := createRepo(t, "owner", "should-change", "i like apples")
+	archivedRepo := createRepo(t, "owner", "archived", "i like apples")
+	archivedRepo.IsArchived = true
+	disabledRepo := createRepo(t, "owner", "disabled", "i like apples")
+	disabledRepo.IsDisabled = true
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{changeRepo, archivedRepo, disabledRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-archived-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--report", filepath.ToSlash(filepath.Join(tmpDir, "report.json")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--create-issue-on-failure",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "should-change", vcMock.PullRequests[0].RepoName)
+	assert.Len(t, vcMock.Issues, 0)
+	assert.False(t, branchExist(t, archivedRepo.Path, "custom-branch-name"))
+
+	out := readFile(t, tmpDir, "out.txt")
+	assert.Contains(t, out, "The repository is archived:\n  owner/archived\n")
+	assert.Contains(t, out, "The repository is disabled:\n  owner/disabled\n")
+
+	report := readFile(t, tmpDir, "report.json")
+	assert.Contains(t, report, `"status": "skipped"`)
+}
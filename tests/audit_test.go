@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditQuery(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "apples", "i like apples"),
+			createRepo(t, "owner", "bananas", "i like bananas"),
+			createRepo(t, "owner", "apples-too", "i like apples as well"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"audit",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--query", `exists("test.txt") && !contains("test.txt", "bananas")`,
+	})
+	require.NoError(t, command.Execute())
+
+	assert.Equal(t, `compliant (2):
+  owner/apples
+  owner/apples-too
+non-compliant (1):
+  owner/bananas
+
+2 compliant, 1 non-compliant, 0 unknown (66% compliant)
+`, readFile(t, tmpDir, "out.txt"))
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/keyring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(account string) (string, error) {
+	secret, ok := k[account]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeyring) Set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k memoryKeyring) Delete(account string) error {
+	if _, ok := k[account]; !ok {
+		return keyring.ErrNotFound
+	}
+	delete(k, account)
+	return nil
+}
+
+func TestAuth(t *testing.T) {
+	keys := memoryKeyring{}
+	cmd.OverrideKeyring = keys
+	defer func() { cmd.OverrideKeyring = nil }()
+
+	out := &bytes.Buffer{}
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"auth", "set"})
+	command.SetIn(strings.NewReader("github-token\n"))
+	command.SetOut(out)
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "The token of github is stored in the keychain\n", out.String())
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"auth", "set", "-p", "gitlab", "-g", "https://gitlab.example.com/"})
+	command.SetIn(strings.NewReader("gitlab-token"))
+	command.SetOut(&bytes.Buffer{})
+	require.NoError(t, command.Execute())
+
+	assert.Equal(t, memoryKeyring{
+		"github":                            "github-token",
+		"gitlab https://gitlab.example.com": "gitlab-token",
+	}, keys)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"auth", "remove"})
+	command.SetOut(&bytes.Buffer{})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, memoryKeyring{
+		"gitlab https://gitlab.example.com": "gitlab-token",
+	}, keys)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"auth", "remove"})
+	command.SetOut(&bytes.Buffer{})
+	command.SetErr(&bytes.Buffer{})
+	assert.EqualError(t, command.Execute(), "no token of github is stored in the keychain")
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackport(t *testing.T) {
+	repo := createRepo(t, "owner", "should-change", "i like apples")
+	for _, branch := range []string{"release/1.0", "release/2.0", "other"} {
+		changeBranch(t, repo.Path, branch, true)
+		changeBranch(t, repo.Path, "master", false)
+	}
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-backport-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.PullRequests, 1)
+
+	vcMock.SetPRStatus("should-change", "custom-branch-name", domain.PullRequestStatusSuccess)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"merge",
+		"--output", filepath.Join(tmpDir, "merge-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"--backport-to", "release/*",
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 3)
+	assert.Equal(t, domain.PullRequestStatusMerged, vcMock.PullRequests[0].PRStatus)
+	backports := []struct {
+		base string
+		head string
+	}{
+		{base: "release/1.0", head: "custom-branch-name-backport-release-1.0"},
+		{base: "release/2.0", head: "custom-branch-name-backport-release-2.0"},
+	}
+	for i, backport := range backports {
+		pr := vcMock.PullRequests[i+1]
+		assert.Equal(t, backport.base, pr.Base)
+		assert.Equal(t, backport.head, pr.Head)
+		assert.Equal(t, "["+backport.base+"] Backport changes made by multi-gitter", pr.Title)
+
+		changeBranch(t, repo.Path, pr.Head, false)
+		assert.Equal(t, "i like bananas", readTestFile(t, repo.Path))
+	}
+
+	changeBranch(t, repo.Path, "other", false)
+	assert.Equal(t, "i like apples", readTestFile(t, repo.Path))
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchProtection(t *testing.T) {
+	missingBranch := createRepo(t, "owner", "missing-branch", "i like apples")
+	missingBranch.DefaultBranchName = "main"
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "unprotected", "i like apples"),
+			createRepo(t, "owner", "protected", "i like apples"),
+			missingBranch,
+		},
+		BranchProtections: map[string]map[string]domain.BranchProtection{
+			"owner/protected": {
+				"master": {Branch: "master", RequiredApprovingReviews: 2, RequiredStatusChecks: []string{"build"}},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-branch-protection-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	require.NoError(t, ioutil.WriteFile(rulesFile, []byte(`
+- required_approving_reviews: 2
+  required_status_checks: [build]
+`), 0600))
+
+	args := []string{"branch-protection",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--rules-file", filepath.ToSlash(rulesFile),
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/unprotected:
+  branch_protection master: none -> required approving reviews: 2, required status checks: build, enforce admins: false
+owner/protected: up to date
+owner/missing-branch: skipped, the branch main does not exist
+
+1 would be changed, 1 up to date, 1 skipped, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Empty(t, vcMock.BranchProtections["owner/unprotected"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, domain.BranchProtection{
+		Branch:                   "master",
+		RequiredApprovingReviews: 2,
+		RequiredStatusChecks:     []string{"build"},
+	}, vcMock.BranchProtections["owner/unprotected"]["master"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "rerun.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/unprotected: up to date
+owner/protected: up to date
+owner/missing-branch: skipped, the branch main does not exist
+
+0 changed, 2 up to date, 1 skipped, 0 failed
+`, readFile(t, tmpDir, "rerun.txt"))
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheRepos_Tokens(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	// Only the admin token can see the private repository
+	listings := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/v3/repos/org/") {
+			name := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/org/")
+			fmt.Fprintf(w, `{"name":%q,"full_name":"org/%s","owner":{"login":"org"},"archived":false}`, name, name)
+			return
+		}
+		if r.URL.Path != "/api/v3/orgs/org/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		listings++
+
+		repos := []string{"public"}
+		if r.Header.Get("Authorization") == "Bearer admin-token" {
+			repos = append(repos, "private")
+		}
+		fmt.Fprint(w, "[")
+		for i, name := range repos {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"name":%q,"full_name":"org/%s","owner":{"login":"org"},"default_branch":"main","permissions":{"pull":true,"push":true,"admin":true}}`, name, name)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer server.Close()
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-cache-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// The cache is stored in the cache directory of the user
+	for _, env := range []string{"XDG_CACHE_HOME", "HOME"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Setenv(env, tmpDir)
+	}
+
+	list := func(token, output string) string {
+		command := cmd.RootCmd()
+		command.SetArgs([]string{"archive",
+			"--platform", "github",
+			"--base-url", server.URL,
+			"--token", token,
+			"--org", "org",
+			"--cache-repos",
+			"--dry-run",
+			"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+			"--output", filepath.ToSlash(filepath.Join(tmpDir, output)),
+		})
+		require.NoError(t, command.Execute())
+		return readFile(t, tmpDir, output)
+	}
+
+	admin := list("admin-token", "admin.txt")
+	assert.Contains(t, admin, "org/private")
+
+	// Another token does not get the cached listing of the admin token
+	user := list("user-token", "user.txt")
+	assert.Contains(t, user, "org/public")
+	assert.NotContains(t, user, "org/private")
+	assert.Equal(t, 2, listings)
+
+	// But the listing of the same token is cached
+	assert.Contains(t, list("admin-token", "admin-again.txt"), "org/private")
+	assert.Equal(t, 2, listings)
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeRequest(t *testing.T) {
+	serviceNow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("sysparm_query") {
+		case "number=CHG0000001":
+			_, _ = w.Write([]byte(`{"result":[{"number":"CHG0000001","approval":"approved","state":"-1"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"result":[]}`))
+		}
+	}))
+	defer serviceNow.Close()
+
+	os.Setenv("SERVICENOW_TOKEN", "token")
+	defer os.Unsetenv("SERVICENOW_TOKEN")
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-change-request-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "should-change", "i like apples"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--report", filepath.Join(tmpDir, "report.json"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--skip-footer",
+		"--change-request", "CHG0000001",
+		"--servicenow-url", serviceNow.URL,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.True(t, strings.HasSuffix(vcMock.PullRequests[0].Body, "\n\nChange request: CHG0000001"))
+	assert.Contains(t, readFile(t, tmpDir, "report.json"), `"change_request": "CHG0000001"`)
+}
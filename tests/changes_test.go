@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeVersionController is a mock of a platform, like Gerrit, where commits are pushed for review to a special ref
+type changeVersionController struct {
+	*vcmock.VersionController
+}
+
+func (vc changeVersionController) ChangeCommitMessage(repo domain.Repository, featureBranch, commitMessage string) string {
+	return fmt.Sprintf("%s\n\nChange-Id: %s/%s\n", commitMessage, repo.FullName(), featureBranch)
+}
+
+func (vc changeVersionController) ChangeRef(newPR domain.NewPullRequest) string {
+	return fmt.Sprintf("refs/for/%s%%topic=%s", newPR.Base, newPR.Head)
+}
+
+func TestPushForReview(t *testing.T) {
+	for _, gitBackend := range gitBackends {
+		t.Run(string(gitBackend), func(t *testing.T) {
+			vcMock := &vcmock.VersionController{}
+			defer vcMock.Clean()
+			cmd.OverrideVersionController = changeVersionController{vcMock}
+
+			tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-changes-")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			workingDir, err := os.Getwd()
+			require.NoError(t, err)
+
+			repo := createRepo(t, "owner", "should-change", "i like apples")
+			vcMock.AddRepository(repo)
+
+			command := cmd.RootCmd()
+			command.SetArgs([]string{"run",
+				"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+				"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+				"--git-type", string(gitBackend),
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "test",
+				filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+			})
+			require.NoError(t, command.Execute())
+
+			// The commit is pushed to the special ref instead of the feature branch
+			assert.False(t, branchExist(t, repo.Path, "custom-branch-name"))
+
+			gitRepo, err := git.PlainOpen(repo.Path)
+			require.NoError(t, err)
+			ref, err := gitRepo.Reference(plumbing.ReferenceName("refs/for/master%topic=custom-branch-name"), false)
+			require.NoError(t, err)
+			commit, err := gitRepo.CommitObject(ref.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, "test\n\nChange-Id: owner/should-change/custom-branch-name\n", commit.Message)
+
+			require.Len(t, vcMock.PullRequests, 1)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCherryPick(t *testing.T) {
+	templateRepo := createRepo(t, "owner", "template", "i like apples")
+	changeTestFile(t, templateRepo.Path, "i like bananas", "Switch fruit\n\nApples are out of season")
+	commit := branchCommit(t, templateRepo.Path, "master")
+	addFile(t, templateRepo.Path, "template.txt", "only in the template", "Later change")
+
+	downstreamRepo := createRepo(t, "owner", "downstream", "i like apples")
+	addFile(t, downstreamRepo.Path, "other.txt", "only downstream", "Downstream change")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{downstreamRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-cherry-pick-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "cherry-pick-branch",
+		"--cherry-pick", commit.Hash.String(),
+		"--cherry-pick-repo", templateRepo.Path,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "Switch fruit", vcMock.PullRequests[0].Title)
+	assert.True(t, strings.HasPrefix(vcMock.PullRequests[0].Body, "Apples are out of season\n"))
+
+	changeBranch(t, downstreamRepo.Path, "cherry-pick-branch", false)
+	assert.Equal(t, "i like bananas", readTestFile(t, downstreamRepo.Path))
+	assert.Equal(t, "only downstream", readFile(t, downstreamRepo.Path, "other.txt"))
+	assert.False(t, fileExist(t, downstreamRepo.Path, "template.txt"))
+}
+
+func TestPatchFile(t *testing.T) {
+	repo := createRepo(t, "owner", "repo", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-patch-file-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	patch := `diff --git a/test.txt b/test.txt
+--- a/test.txt
++++ b/test.txt
+@@ -1 +1 @@
+-i like apples
+\ No newline at end of file
++i like bananas
+\ No newline at end of file
+`
+	patchPath := filepath.Join(tmpDir, "change.patch")
+	require.NoError(t, ioutil.WriteFile(patchPath, []byte(patch), 0600))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "patch-branch",
+		"-m", "Apply patch",
+		"--patch-file", filepath.ToSlash(patchPath),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	changeBranch(t, repo.Path, "patch-branch", false)
+	assert.Equal(t, "i like bananas", readTestFile(t, repo.Path))
+}
+
+func TestCherryPickRange(t *testing.T) {
+	templateRepo := createRepo(t, "owner", "template", "i like apples")
+	from := branchCommit(t, templateRepo.Path, "master")
+	changeTestFile(t, templateRepo.Path, "i like bananas", "Switch fruit")
+	addFile(t, templateRepo.Path, "template.txt", "new in the template", "Add file")
+	to := branchCommit(t, templateRepo.Path, "master")
+
+	downstreamRepo := createRepo(t, "owner", "downstream", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{downstreamRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-cherry-pick-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "template-sync",
+		"-m", "Sync with template",
+		"--cherry-pick", from.Hash.String() + ".." + to.Hash.String(),
+		"--cherry-pick-repo", templateRepo.URL(""),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	changeBranch(t, downstreamRepo.Path, "template-sync", false)
+	assert.Equal(t, "i like bananas", readTestFile(t, downstreamRepo.Path))
+	assert.Equal(t, "new in the template", readFile(t, downstreamRepo.Path, "template.txt"))
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareReports(t *testing.T) {
+	changeRepo := createRepo(t, "owner", "should-change", "i like apples")
+	laterCompliantRepo := createRepo(t, "owner", "later-compliant", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{changeRepo, laterCompliantRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-compare-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	oldReportFile := filepath.Join(tmpDir, "old.json")
+	newReportFile := filepath.Join(tmpDir, "new.json")
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "first-branch",
+		"-m", "test",
+		"--report", oldReportFile,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	// The change is made manually in one of the repositories
+	changeTestFile(t, laterCompliantRepo.Path, "i like bananas", "manual change")
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "second-branch",
+		"-m", "test",
+		"--report", newReportFile,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	newReport, err := ioutil.ReadFile(newReportFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(newReport), `"repository": "owner/later-compliant",
+      "status": "no-change"`)
+
+	compareOutFile := filepath.Join(tmpDir, "compare.txt")
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"compare",
+		"--output", compareOutFile,
+		oldReportFile,
+		newReportFile,
+	})
+	require.NoError(t, command.Execute())
+
+	compareOut, err := ioutil.ReadFile(compareOutFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Newly compliant repositories:\n  owner/later-compliant\n", string(compareOut))
+}
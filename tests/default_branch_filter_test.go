@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBranchFilter(t *testing.T) {
+	migrated := createRepo(t, "owner", "migrated", "i like apples")
+	migrated.DefaultBranchName = "main"
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "should-change", "i like apples"),
+			migrated,
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-default-branch-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--default-branch-is", "mas.*",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "should-change", vcMock.PullRequests[0].RepoName)
+}
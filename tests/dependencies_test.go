@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencies(t *testing.T) {
+	libraryRepo := createRepo(t, "owner", "library", "i like apples")
+	consumerRepo := createRepo(t, "owner", "consumer", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{consumerRepo, libraryRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-dependencies-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--depends-on", "owner/consumer=owner/library",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	// Only the library is changed, the consumer has to wait until the pull request of the library is merged
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "library", vcMock.PullRequests[0].RepoName)
+	assert.False(t, branchExist(t, consumerRepo.Path, "custom-branch-name"))
+	assert.Contains(t, readFile(t, tmpDir, "out.txt"), "The pull request of the dependency owner/library has to be merged first:\n  owner/consumer\n")
+
+	// A pull request of the consumer, created in an earlier run, is not merged before the library
+	vcMock.PullRequests = append(vcMock.PullRequests, vcmock.PullRequest{
+		PRStatus:       domain.PullRequestStatusSuccess,
+		PRNumber:       2,
+		Repository:     consumerRepo,
+		NewPullRequest: domain.NewPullRequest{Head: "custom-branch-name"},
+	})
+
+	merge := func() {
+		command := cmd.RootCmd()
+		command.SetArgs([]string{"merge",
+			"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+			"--depends-on", "owner/consumer=owner/library",
+			"-B", "custom-branch-name",
+		})
+		require.NoError(t, command.Execute())
+	}
+
+	merge()
+	assert.Equal(t, domain.PullRequestStatusPending, vcMock.PullRequests[0].PRStatus)
+	assert.Equal(t, domain.PullRequestStatusSuccess, vcMock.PullRequests[1].PRStatus)
+
+	vcMock.SetPRStatus("library", "custom-branch-name", domain.PullRequestStatusSuccess)
+	merge()
+	assert.Equal(t, domain.PullRequestStatusMerged, vcMock.PullRequests[0].PRStatus)
+	assert.Equal(t, domain.PullRequestStatusMerged, vcMock.PullRequests[1].PRStatus)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForksPrune(t *testing.T) {
+	mergedRepo := createRepo(t, "owner", "merged", "i like apples")
+	openRepo := createRepo(t, "owner", "open", "i like apples")
+	recentRepo := createRepo(t, "owner", "recent", "i like apples")
+	sharedRepo := createRepo(t, "owner", "shared", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{mergedRepo, openRepo, recentRepo, sharedRepo},
+		Forks: []vcmock.Fork{
+			{Repository: createRepo(t, "bot", "merged", "i like apples"), Parent: mergedRepo, Updated: time.Now().Add(-48 * time.Hour)},
+			{Repository: createRepo(t, "bot", "open", "i like apples"), Parent: openRepo, Updated: time.Now().Add(-48 * time.Hour)},
+			{Repository: createRepo(t, "bot", "recent", "i like apples"), Parent: recentRepo, Updated: time.Now()},
+			{Repository: createRepo(t, "bot", "shared", "i like apples"), Parent: sharedRepo, Updated: time.Now().Add(-48 * time.Hour)},
+		},
+		PullRequests: []vcmock.PullRequest{
+			{PRStatus: domain.PullRequestStatusMerged, PRNumber: 1, Repository: mergedRepo, NewPullRequest: domain.NewPullRequest{Head: "fork-branch"}},
+			{PRStatus: domain.PullRequestStatusPending, PRNumber: 2, Repository: openRepo, NewPullRequest: domain.NewPullRequest{Head: "fork-branch"}},
+			{PRStatus: domain.PullRequestStatusClosed, PRNumber: 3, Repository: recentRepo, NewPullRequest: domain.NewPullRequest{Head: "fork-branch"}},
+			// The fork is still used by the open pull request of another campaign
+			{PRStatus: domain.PullRequestStatusMerged, PRNumber: 4, Repository: sharedRepo, NewPullRequest: domain.NewPullRequest{Head: "fork-branch"}},
+			{PRStatus: domain.PullRequestStatusPending, PRNumber: 5, Repository: sharedRepo, NewPullRequest: domain.NewPullRequest{Head: "other-branch"}},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-forks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	outFile := filepath.Join(tmpDir, "out.txt")
+
+	// A dry run should not delete anything
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"forks", "prune",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(outFile),
+		"--fork-owner", "bot",
+		"--branch", "fork-branch",
+		"--older-than", "24h",
+		"--dry-run",
+	})
+	require.NoError(t, command.Execute())
+
+	outData, err := ioutil.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "bot/merged\n", string(outData))
+	assert.Len(t, vcMock.Forks, 4)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"forks", "prune",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(outFile),
+		"--fork-owner", "bot",
+		"--branch", "fork-branch",
+		"--older-than", "24h",
+	})
+	require.NoError(t, command.Execute())
+
+	outData, err = ioutil.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "bot/merged\n", string(outData))
+	require.Len(t, vcMock.Forks, 3)
+	assert.Equal(t, "bot/open", vcMock.Forks[0].FullName())
+	assert.Equal(t, "bot/recent", vcMock.Forks[1].FullName())
+	assert.Equal(t, "bot/shared", vcMock.Forks[2].FullName())
+}
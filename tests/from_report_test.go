@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromReport(t *testing.T) {
+	repo := createRepo(t, "owner", "should-change", "i like apples")
+	unrelatedRepo := createRepo(t, "owner", "unrelated", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo},
+	}
+	defer vcMock.Clean()
+	defer unrelatedRepo.Delete()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-from-report-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	reportFile := filepath.Join(tmpDir, "report.json")
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		"--report", reportFile,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.PullRequests, 1)
+
+	// An unrelated pull request, that happens to use the same branch name, should not be touched
+	vcMock.PullRequests = append(vcMock.PullRequests, vcmock.PullRequest{
+		PRStatus:   domain.PullRequestStatusSuccess,
+		PRNumber:   42,
+		Repository: unrelatedRepo,
+		NewPullRequest: domain.NewPullRequest{
+			Head: "custom-branch-name",
+		},
+	})
+	vcMock.SetPRStatus("should-change", "custom-branch-name", domain.PullRequestStatusSuccess)
+
+	statusFile := filepath.Join(tmpDir, "status.txt")
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"status",
+		"--output", statusFile,
+		"--from-report", reportFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "owner/should-change #1: Success\n", readFile(t, tmpDir, "status.txt"))
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"merge",
+		"--log-file", filepath.Join(tmpDir, "merge-log.txt"),
+		"--from-report", reportFile,
+		"--dry-run",
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, domain.PullRequestStatusSuccess, vcMock.PullRequests[0].PRStatus)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"merge",
+		"--log-file", filepath.Join(tmpDir, "merge-log.txt"),
+		"--from-report", reportFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, domain.PullRequestStatusMerged, vcMock.PullRequests[0].PRStatus)
+	assert.Equal(t, domain.PullRequestStatusSuccess, vcMock.PullRequests[1].PRStatus)
+
+	// A new run on the same repositories, even if more repositories are selected
+	newRepo := createRepo(t, "owner", "new", "i like apples")
+	vcMock.AddRepository(newRepo)
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "rerun-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "follow-up-branch",
+		"-m", "test",
+		"--from-report", reportFile,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.PullRequests, 3)
+	assert.Equal(t, "should-change", vcMock.PullRequests[2].RepoName)
+	assert.Equal(t, "follow-up-branch", vcMock.PullRequests[2].Head)
+	assert.False(t, branchExist(t, newRepo.Path, "follow-up-branch"))
+}
+
+func TestFromReportRenamed(t *testing.T) {
+	repo := createRepo(t, "new-owner", "new-name", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo},
+		PullRequests: []vcmock.PullRequest{
+			{
+				PRStatus:   domain.PullRequestStatusSuccess,
+				PRNumber:   1,
+				Repository: repo,
+				NewPullRequest: domain.NewPullRequest{
+					Head: "custom-branch-name",
+				},
+			},
+		},
+		Redirects: map[string]string{
+			"owner/old-name": "new-owner/new-name",
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-from-report-renamed-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	reportFile := filepath.Join(tmpDir, "report.json")
+	require.NoError(t, ioutil.WriteFile(reportFile, []byte(`{
+  "repositories": [
+    {
+      "repository": "owner/old-name",
+      "status": "success",
+      "pull_request": "owner/old-name #1"
+    }
+  ]
+}
+`), 0600))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"status",
+		"--output", filepath.Join(tmpDir, "status.txt"),
+		"--from-report", reportFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "new-owner/new-name #1: Success\n", readFile(t, tmpDir, "status.txt"))
+
+	// The report is updated with the new name of the repository
+	report := readFile(t, tmpDir, "report.json")
+	assert.Contains(t, report, `"repository": "new-owner/new-name"`)
+	assert.Contains(t, report, `"pull_request": "new-owner/new-name #1"`)
+	assert.NotContains(t, report, "old-name")
+
+	// The updated report can be used as it is
+	vcMock.Redirects = nil
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"merge",
+		"--log-file", filepath.Join(tmpDir, "merge-log.txt"),
+		"--from-report", reportFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, domain.PullRequestStatusMerged, vcMock.PullRequests[0].PRStatus)
+}
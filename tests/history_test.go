@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/history"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	changeRepo := createRepo(t, "owner", "should-change", "i like apples")
+	unchangedRepo := createRepo(t, "owner", "should-not-change", "i like oranges")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{changeRepo, unchangedRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-history-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	historyFile := filepath.ToSlash(filepath.Join(tmpDir, "history.db"))
+
+	for _, branch := range []string{"first-branch", "second-branch"} {
+		command := cmd.RootCmd()
+		command.SetArgs([]string{"run",
+			"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+			"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+			"--history-file", historyFile,
+			"--author-name", "Test Author",
+			"--author-email", "test@example.com",
+			"-B", branch,
+			"-m", "custom message",
+			filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+		})
+		require.NoError(t, command.Execute())
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"history",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "history.txt")),
+		"--history-file", historyFile,
+		"--repo", "owner/should-change",
+	})
+	require.NoError(t, command.Execute())
+
+	out := readFile(t, tmpDir, "history.txt")
+	assert.Contains(t, out, "first-branch: custom message\n  owner/should-change: success: owner/should-change #1\n")
+	assert.Contains(t, out, "second-branch: custom message\n  owner/should-change: success: owner/should-change #2\n")
+	assert.NotContains(t, out, "should-not-change")
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"history",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "history.txt")),
+		"--history-file", historyFile,
+		"--branch", "second-branch",
+	})
+	require.NoError(t, command.Execute())
+
+	out = readFile(t, tmpDir, "history.txt")
+	assert.NotContains(t, out, "first-branch")
+	assert.Contains(t, out, "  owner/should-not-change: no-change: no data was changed\n")
+
+	db, err := history.Open(historyFile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	entries, err := db.Runs(context.Background(), history.Filter{BranchName: "first-branch"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.False(t, entries[0].Running())
+	assert.Equal(t, workingDir, entries[0].Directory)
+	assert.Contains(t, entries[0].Arguments, "--branch=first-branch")
+	assert.NotContains(t, entries[0].Arguments, "--history-file="+historyFile)
+	assert.Equal(t, filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)), entries[0].Arguments[len(entries[0].Arguments)-1])
+
+	repoLog, err := db.Log(context.Background(), entries[0].ID, "owner/should-change")
+	require.NoError(t, err)
+	assert.Contains(t, repoLog, "Pushing changes to remote")
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssue(t *testing.T) {
+	first := createRepo(t, "owner", "first", "i like apples")
+	notified := createRepo(t, "owner", "notified", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{first, notified},
+		Issues: []vcmock.Issue{
+			{RepoName: "owner/notified", NewIssue: domain.NewIssue{Title: "Deprecation of owner/notified"}},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-issue-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"issue",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--title", "Deprecation of {{.Repository}}",
+		"--body", "Please migrate {{.DefaultBranch}}",
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/first:
+  issue: none -> Deprecation of owner/first
+owner/notified: up to date
+
+1 would be changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	require.Len(t, vcMock.Issues, 1)
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.Issues, 2)
+	assert.Equal(t, "owner/first", vcMock.Issues[1].RepoName)
+	assert.Equal(t, "Deprecation of owner/first", vcMock.Issues[1].Title)
+	assert.Equal(t, "Please migrate master", vcMock.Issues[1].Body)
+
+	// Running again does not create any duplicates
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Len(t, vcMock.Issues, 2)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabels(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "outdated", "i like apples"),
+			createRepo(t, "owner", "up-to-date", "i like apples"),
+		},
+		Labels: map[string][]domain.Label{
+			"owner/outdated": {
+				{Name: "defect", Color: "ff0000"},
+				{Name: "unmanaged", Color: "000000"},
+			},
+			"owner/up-to-date": {
+				{Name: "bug", Color: "d73a4a", Description: "Something isn't working"},
+				{Name: "dependencies", Color: "0366d6"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-labels-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	labelsFile := filepath.Join(tmpDir, "labels.yaml")
+	require.NoError(t, ioutil.WriteFile(labelsFile, []byte(`
+- name: bug
+  color: "#D73A4A"
+  description: Something isn't working
+  previous_names: [defect]
+- name: dependencies
+  color: 0366d6
+`), 0600))
+
+	args := []string{"labels",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--labels-file", filepath.ToSlash(labelsFile),
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/outdated:
+  label bug: name: defect, color: ff0000, description: none -> name: bug, color: d73a4a, description: Something isn't working
+  label dependencies: none -> name: dependencies, color: 0366d6, description: none
+owner/up-to-date: up to date
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, "defect", vcMock.Labels["owner/outdated"][0].Name)
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, []domain.Label{
+		{Name: "bug", Color: "d73a4a", Description: "Something isn't working"},
+		{Name: "unmanaged", Color: "000000"},
+		{Name: "dependencies", Color: "0366d6"},
+	}, vcMock.Labels["owner/outdated"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "rerun.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "owner/outdated: up to date\nowner/up-to-date: up to date\n", readFile(t, tmpDir, "rerun.txt"))
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalPlatform(t *testing.T) {
+	for _, gitBackend := range gitBackends {
+		t.Run(string(gitBackend), func(t *testing.T) {
+			cmd.OverrideVersionController = nil
+
+			tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-local-")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			workingDir, err := os.Getwd()
+			require.NoError(t, err)
+
+			repo := createRepo(t, "owner", "should-change", "i like apples")
+			defer os.RemoveAll(repo.Path)
+
+			command := cmd.RootCmd()
+			command.SetArgs([]string{"run",
+				"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+				"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+				"--platform", "local",
+				"--path", filepath.ToSlash(repo.Path),
+				"--git-type", string(gitBackend),
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "test",
+				filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+			})
+			require.NoError(t, command.Execute())
+
+			assert.True(t, branchExist(t, repo.Path, "custom-branch-name"))
+			// The checked out branch of the repository is left as it is
+			assert.Equal(t, "i like apples", readTestFile(t, repo.Path))
+			assert.Contains(t, readFile(t, tmpDir, "out.txt"), "Repositories with a successful run:")
+		})
+	}
+}
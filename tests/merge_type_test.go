@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoMergeType(t *testing.T) {
+	seriesRepo := createRepo(t, "owner", "series", "i like apples")
+	singleRepo := createRepo(t, "owner", "single", "i like apples")
+	overrideRepo := createRepo(t, "owner", "override", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{seriesRepo, singleRepo, overrideRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-merge-type-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.PullRequests, 3)
+
+	// Add another commit to one of the pull requests, to make it a series of commits
+	for _, repo := range []vcmock.Repository{seriesRepo, overrideRepo} {
+		changeBranch(t, repo.Path, "custom-branch-name", false)
+		addFile(t, repo.Path, "another-file.txt", "content", "another commit")
+		changeBranch(t, repo.Path, "master", false)
+	}
+
+	for _, repo := range vcMock.Repositories {
+		vcMock.SetPRStatus(repo.RepoName, "custom-branch-name", domain.PullRequestStatusSuccess)
+	}
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"merge",
+		"--log-file", filepath.Join(tmpDir, "merge-log.txt"),
+		"-B", "custom-branch-name",
+		"--auto-merge-type",
+		"--merge-type-override", "owner/override=merge",
+	})
+	require.NoError(t, command.Execute())
+
+	mergeTypes := map[string]domain.MergeType{}
+	for _, pr := range vcMock.PullRequests {
+		assert.Equal(t, domain.PullRequestStatusMerged, pr.PRStatus)
+		mergeTypes[pr.Repository.RepoName] = pr.MergeType
+	}
+	assert.Equal(t, map[string]domain.MergeType{
+		"series":   domain.MergeTypeRebase,
+		"single":   domain.MergeTypeSquash,
+		"override": domain.MergeTypeMerge,
+	}, mergeTypes)
+}
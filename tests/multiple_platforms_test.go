@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiplePlatforms(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-platforms-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	remoteRepo := createRepo(t, "owner", "remote-repo", "i like apples")
+	defer os.RemoveAll(remoteRepo.Path)
+	localRepo := createRepo(t, "owner", "local-repo", "i like apples")
+	defer os.RemoveAll(localRepo.Path)
+
+	// Each platform only uses the repositories defined in its own profile
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(fmt.Sprintf(`platform: git,local
+platforms:
+  git:
+    repo:
+      - %q
+  local:
+    path:
+      - %q
+`, "file://"+filepath.ToSlash(remoteRepo.Path), filepath.ToSlash(localRepo.Path))), 0600))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--config", configPath,
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	assert.True(t, branchExist(t, remoteRepo.Path, "custom-branch-name"))
+	assert.True(t, branchExist(t, localRepo.Path, "custom-branch-name"))
+
+	out := readFile(t, tmpDir, "out.txt")
+	assert.Contains(t, out, strings.TrimSuffix(filepath.Base(remoteRepo.Path), ".git"))
+	assert.Contains(t, out, filepath.Base(localRepo.Path))
+}
+
+func TestMultiplePlatforms_Unsupported(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-platforms-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	run := func(args ...string) error {
+		command := cmd.RootCmd()
+		command.SetArgs(append([]string{"run",
+			"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+			"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+			"-B", "custom-branch-name",
+			"-m", "test",
+		}, append(args, "echo")...))
+		return command.Execute()
+	}
+
+	assert.EqualError(t, run("--platform", "git,gerrit"), "gerrit can't be combined with other platforms")
+	repo := createRepo(t, "owner", "repo", "i like apples")
+	defer os.RemoveAll(repo.Path)
+	assert.EqualError(t, run("--platform", "git,local", "--repo", "file://"+filepath.ToSlash(repo.Path), "--path", filepath.ToSlash(repo.Path), "--author-from-token"),
+		"--author-from-token can't be used with multiple platforms, since each token belongs to a different user")
+}
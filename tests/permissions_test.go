@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissions(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "drifted", "i like apples"),
+			createRepo(t, "owner", "up-to-date", "i like apples"),
+		},
+		Permissions: map[string]domain.Permissions{
+			"owner/drifted": {
+				Users: map[string]string{"alice": "push", "former-employee": "admin"},
+				Teams: map[string]string{"backend": "admin"},
+			},
+			"owner/up-to-date": {
+				Users: map[string]string{"Alice": "admin"},
+				Teams: map[string]string{"backend": "push"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-permissions-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	permissionsFile := filepath.Join(tmpDir, "permissions.yaml")
+	require.NoError(t, ioutil.WriteFile(permissionsFile, []byte(`
+users:
+  alice: admin
+teams:
+  backend: push
+`), 0600))
+
+	args := []string{"permissions",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--permissions-file", filepath.ToSlash(permissionsFile),
+	}
+
+	// Without --remove-unlisted, users that are not in the file are left unchanged
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/drifted:
+  user alice: push -> admin
+  team backend: admin -> push
+owner/up-to-date: up to date
+
+1 would be changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, "push", vcMock.Permissions["owner/drifted"].Users["alice"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")), "--remove-unlisted"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/drifted:
+  user alice: push -> admin
+  user former-employee: admin -> none
+  team backend: admin -> push
+owner/up-to-date: up to date
+
+1 changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "out.txt"))
+	assert.Equal(t, domain.Permissions{
+		Users: map[string]string{"alice": "admin"},
+		Teams: map[string]string{"backend": "push"},
+	}, vcMock.Permissions["owner/drifted"])
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginPlatform(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-plugin-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	repo := createRepo(t, "owner", "should-change", "i like apples")
+	defer os.RemoveAll(repo.Path)
+
+	platformArgs := []string{
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--platform", "plugin",
+		"--plugin-path", "go",
+		"--plugin-arg", "run",
+		"--plugin-arg", filepath.ToSlash(filepath.Join(workingDir, "scripts/plugin/main.go")),
+		"--plugin-arg", filepath.ToSlash(filepath.Join(tmpDir, "state.json")),
+		"--repo", filepath.ToSlash(repo.Path),
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append([]string{"run",
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	}, platformArgs...))
+	require.NoError(t, command.Execute())
+
+	assert.True(t, branchExist(t, repo.Path, "custom-branch-name"))
+	assert.Contains(t, readFile(t, tmpDir, "out.txt"), "Repositories with a successful run:")
+
+	command = cmd.RootCmd()
+	command.SetArgs(append([]string{"status",
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "status.txt")),
+		"-B", "custom-branch-name",
+	}, platformArgs...))
+	require.NoError(t, command.Execute())
+
+	status := readFile(t, tmpDir, "status.txt")
+	assert.Contains(t, status, "owner/"+filepath.Base(repo.Path)+" #1")
+	assert.Contains(t, status, ": Pending\n")
+
+	command = cmd.RootCmd()
+	command.SetArgs(append([]string{"close",
+		"-B", "custom-branch-name",
+	}, platformArgs...))
+	require.NoError(t, command.Execute())
+	assert.Contains(t, readFile(t, tmpDir, "state.json"), `"status":"closed"`)
+}
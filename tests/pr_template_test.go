@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRequestTemplates(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "default", "i like apples"),
+			createRepo(t, "owner", "topic", "i like apples"),
+			createRepo(t, "subsidiary", "owner", "i like apples"),
+		},
+		Settings: map[string]domain.RepositorySettings{
+			"owner/topic": {
+				Topics: []string{"team-a"},
+			},
+			"subsidiary/owner": {
+				Topics: []string{"team-a"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-pr-template-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	ownerTemplate := filepath.Join(tmpDir, "owner.md")
+	require.NoError(t, ioutil.WriteFile(ownerTemplate, []byte("Byt ut äpplen\n\nÄpplen byts ut mot bananer.\n"), 0600))
+	topicTemplate := filepath.Join(tmpDir, "topic.md")
+	require.NoError(t, ioutil.WriteFile(topicTemplate, []byte("Team A: replace apples\n"), 0600))
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-t", "Replace apples",
+		"-b", "Apples are replaced with bananas.",
+		"--skip-footer",
+		"--pr-template", "owner:Subsidiary=" + ownerTemplate,
+		"--pr-template", "topic:team-a=" + topicTemplate,
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 3)
+	prs := map[string]vcmock.PullRequest{}
+	for _, pr := range vcMock.PullRequests {
+		prs[pr.Repository.FullName()] = pr
+	}
+
+	assert.Equal(t, "Replace apples", prs["owner/default"].Title)
+	assert.Equal(t, "Apples are replaced with bananas.", prs["owner/default"].Body)
+
+	assert.Equal(t, "Team A: replace apples", prs["owner/topic"].Title)
+	assert.Equal(t, "", prs["owner/topic"].Body)
+
+	// The owner template is used since it is the first that matches
+	assert.Equal(t, "Byt ut äpplen", prs["subsidiary/owner"].Title)
+	assert.Equal(t, "Äpplen byts ut mot bananer.", prs["subsidiary/owner"].Body)
+}
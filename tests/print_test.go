@@ -55,3 +55,44 @@ func TestPrint(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "I LIKE APPLES\nI LIKE MY APPLE\nI LIKE ORANGES\n", string(errOutData))
 }
+
+func TestPrintGroupOutput(t *testing.T) {
+	vcMock := &vcmock.VersionController{}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-run-")
+	assert.NoError(t, err)
+
+	workingDir, err := os.Getwd()
+	assert.NoError(t, err)
+
+	vcMock.AddRepository(createRepo(t, "owner", "test-1", "i like apples"))
+	vcMock.AddRepository(createRepo(t, "owner", "test-2", "i like my apple"))
+
+	outFile := filepath.Join(tmpDir, "out.txt")
+	errOutFile := filepath.Join(tmpDir, "err-out.txt")
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"print",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "print-log.txt")),
+		"--output", filepath.ToSlash(outFile),
+		"--error-output", filepath.ToSlash(errOutFile),
+		"--group-output",
+		"--concurrent", "2",
+		fmt.Sprintf(`go run %s`, filepath.ToSlash(filepath.Join(workingDir, "scripts/printer/main.go"))),
+	})
+	err = command.Execute()
+	assert.NoError(t, err)
+
+	outData, err := ioutil.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Len(t, outData, len("==> owner/test-1 <==\ni like apples\n==> owner/test-2 <==\ni like my apple\n"))
+	assert.Contains(t, string(outData), "==> owner/test-1 <==\ni like apples\n")
+	assert.Contains(t, string(outData), "==> owner/test-2 <==\ni like my apple\n")
+
+	errOutData, err := ioutil.ReadFile(errOutFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(errOutData), "==> owner/test-1 <==\nI LIKE APPLES\n")
+	assert.Contains(t, string(errOutData), "==> owner/test-2 <==\nI LIKE MY APPLE\n")
+}
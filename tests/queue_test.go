@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	changeRepo := createRepo(t, "owner", "should-change", "i like apples")
+	unchangedRepo := createRepo(t, "owner", "should-not-change", "i like oranges")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{changeRepo, unchangedRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-queue-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	queueDir := filepath.ToSlash(filepath.Join(tmpDir, "queue"))
+	runArgs := func(role, name string) []string {
+		return []string{"run",
+			"--log-file", filepath.ToSlash(filepath.Join(tmpDir, name+"-log.txt")),
+			"--output", filepath.ToSlash(filepath.Join(tmpDir, name+"-out.txt")),
+			"--queue", queueDir,
+			"--queue-role", role,
+			"--author-name", "Test Author",
+			"--author-email", "test@example.com",
+			"-B", "custom-branch-name",
+			"-m", "custom message",
+			filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+		}
+	}
+
+	coordinator := cmd.RootCmd()
+	coordinator.SetArgs(append(runArgs("coordinator", "coordinator"),
+		"--report", filepath.ToSlash(filepath.Join(tmpDir, "report.json")),
+	))
+	coordinatorErr := make(chan error)
+	go func() {
+		coordinatorErr <- coordinator.Execute()
+	}()
+
+	// The worker stops at once if the coordinator has not yet enqueued the repositories
+	require.Eventually(t, func() bool {
+		files, _ := ioutil.ReadDir(filepath.Join(tmpDir, "queue", "tasks"))
+		return len(files) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+
+	worker := cmd.RootCmd()
+	worker.SetArgs(runArgs("worker", "worker"))
+	require.NoError(t, worker.Execute())
+
+	select {
+	case err := <-coordinatorErr:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("the coordinator did not finish")
+	}
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "custom-branch-name", vcMock.PullRequests[0].Head)
+	assert.True(t, branchExist(t, changeRepo.Path, "custom-branch-name"))
+
+	workerOut := readFile(t, tmpDir, "worker-out.txt")
+	assert.Contains(t, workerOut, "owner/should-change #1")
+
+	coordinatorOut := readFile(t, tmpDir, "coordinator-out.txt")
+	assert.Contains(t, coordinatorOut, "No data was changed:\n  owner/should-not-change\n")
+	assert.Contains(t, coordinatorOut, "Repositories with a successful run:\n  owner/should-change #1\n")
+
+	report := readFile(t, tmpDir, "report.json")
+	assert.Contains(t, report, `"pull_request": "owner/should-change #1"`)
+	assert.Contains(t, report, `"status": "no-change"`)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelease(t *testing.T) {
+	merged := createRepo(t, "owner", "merged", "i like apples")
+	open := createRepo(t, "owner", "open", "i like apples")
+	released := createRepo(t, "owner", "released", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{merged, open, released},
+		PullRequests: []vcmock.PullRequest{
+			{PRStatus: domain.PullRequestStatusMerged, PRNumber: 1, Repository: merged, NewPullRequest: domain.NewPullRequest{Head: "campaign"}},
+			{PRStatus: domain.PullRequestStatusPending, PRNumber: 2, Repository: open, NewPullRequest: domain.NewPullRequest{Head: "campaign"}},
+			{PRStatus: domain.PullRequestStatusMerged, PRNumber: 3, Repository: released, NewPullRequest: domain.NewPullRequest{Head: "campaign"}},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+	require.NoError(t, vcMock.CreateRelease(context.Background(), released, domain.Release{Tag: "v1.0.0"}))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-release-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"release",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--tag", "v1.0.0",
+		"--notes", "Release {{.Tag}} of {{.Repository}} from {{.DefaultBranch}}",
+		"--merged-branch", "campaign",
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/merged:
+  release: none -> v1.0.0 at master
+owner/open: skipped, no merged pull request from campaign
+owner/released: up to date
+
+1 would be changed, 1 up to date, 1 skipped, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Empty(t, vcMock.Releases["owner/merged"])
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, []domain.Release{{
+		Tag:   "v1.0.0",
+		Name:  "v1.0.0",
+		Notes: "Release v1.0.0 of owner/merged from master",
+	}}, vcMock.Releases["owner/merged"])
+	assert.Empty(t, vcMock.Releases["owner/open"])
+	assert.Len(t, vcMock.Releases["owner/released"], 1)
+}
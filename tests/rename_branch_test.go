@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameBranch(t *testing.T) {
+	repo := createRepo(t, "owner", "should-rename", "i like apples")
+	renamedRepo := createRepo(t, "owner", "already-renamed", "i like apples")
+	renamedRepo.DefaultBranchName = "main"
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo, renamedRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-rename-branch-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"rename-branch",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--from", "master",
+		"--to", "main",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	assert.Equal(t, "main", vcMock.Repositories[0].DefaultBranch())
+	assert.True(t, branchExist(t, repo.Path, "main"))
+	assert.False(t, branchExist(t, repo.Path, "master"))
+
+	// The repository that already had another default branch should not be touched
+	assert.True(t, branchExist(t, renamedRepo.Path, "master"))
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "owner/should-rename", vcMock.PullRequests[0].Repository.FullName())
+	assert.Equal(t, "main", vcMock.PullRequests[0].Base)
+	assert.Equal(t, "multi-gitter-rename-branch", vcMock.PullRequests[0].Head)
+	assert.Equal(t, "Update references to the renamed main branch", vcMock.PullRequests[0].Title)
+
+	changeBranch(t, repo.Path, "multi-gitter-rename-branch", false)
+	assert.Equal(t, "i like bananas", readTestFile(t, repo.Path))
+
+	assert.Contains(t, readFile(t, tmpDir, "out.txt"), "owner/should-rename: renamed master to main\n")
+}
+
+func TestRenameBranchFailure(t *testing.T) {
+	repo := createRepo(t, "owner", "should-rename", "i like apples")
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-rename-branch-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			{
+				OwnerName: "owner",
+				RepoName:  "broken",
+				Path:      filepath.Join(tmpDir, "does-not-exist"),
+			},
+			repo,
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"rename-branch",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--from", "master",
+		"--to", "main",
+	})
+	err = command.Execute()
+	assert.EqualError(t, err, "could not rename the default branch of 1 repositories")
+
+	// The other repositories are still renamed
+	assert.True(t, branchExist(t, repo.Path, "main"))
+	assert.Equal(t, "owner/should-rename: renamed master to main\n", readFile(t, tmpDir, "out.txt"))
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/multigitter/terminal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests that run against real platform implementations, with api interactions replayed from testdata/replay.
+// New cases can be created from bug reports by running multi-gitter with --record-http
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		recording string
+		args      []string
+		expected  string
+	}{
+		{
+			name:      "github status",
+			recording: "github-status",
+			args: []string{"status",
+				"--platform", "github",
+				"--token", "replayed",
+				"--repo", "owner/repo",
+			},
+			expected: terminal.Link("owner/repo #42", "https://github.com/owner/repo/pull/42") + ": Pending\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd.OverrideVersionController = nil
+
+			tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-replay-")
+			require.NoError(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			command := cmd.RootCmd()
+			command.SetArgs(append(test.args,
+				"--replay-http", filepath.Join("testdata", "replay", test.recording),
+				"--output", filepath.Join(tmpDir, "out.txt"),
+			))
+			require.NoError(t, command.Execute())
+
+			assert.Equal(t, test.expected, readFile(t, tmpDir, "out.txt"))
+		})
+	}
+}
+
+func TestRecordAndReplay_BitbucketServer(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	// The project key of the listed repository is used in the following requests, and must survive the recording
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects/PROJ/repos":
+			fmt.Fprint(w, `{"values": [{"id": 1, "slug": "repo-1", "project": {"key": "PROJ"}}], "isLastPage": true}`)
+		case "/rest/api/1.0/projects/PROJ/repos/repo-1/pull-requests":
+			fmt.Fprint(w, `{"values": [{
+				"id": 7,
+				"version": 3,
+				"state": "OPEN",
+				"fromRef": {"displayId": "my-branch", "latestCommit": "abc123", "repository": {"slug": "repo-1", "project": {"key": "PROJ"}}},
+				"links": {"self": [{"href": "https://bitbucket.example.com/projects/PROJ/repos/repo-1/pull-requests/7"}]}
+			}]}`)
+		case "/rest/build-status/1.0/commits/abc123":
+			fmt.Fprint(w, `{"values": [{"state": "SUCCESSFUL"}], "isLastPage": true}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-replay-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	recording := filepath.Join(tmpDir, "recording")
+
+	status := func(httpFlag, output string) string {
+		command := cmd.RootCmd()
+		command.SetArgs([]string{"status",
+			"--platform", "bitbucket-server",
+			"--base-url", server.URL,
+			"--username", "user",
+			"--token", "bitbucket-secret",
+			"--project-key", "PROJ",
+			"--branch", "my-branch",
+			httpFlag, recording,
+			"--output", filepath.Join(tmpDir, output),
+		})
+		require.NoError(t, command.Execute())
+		return readFile(t, tmpDir, output)
+	}
+
+	recorded := status("--record-http", "recorded.txt")
+	assert.Contains(t, recorded, "PROJ/repo-1 #7")
+
+	// The replay is done without the server
+	server.Close()
+	assert.Equal(t, recorded, status("--replay-http", "replayed.txt"))
+
+	files, err := filepath.Glob(filepath.Join(recording, "*.json"))
+	require.NoError(t, err)
+	for _, file := range files {
+		assert.NotContains(t, readFile(t, recording, filepath.Base(file)), "bitbucket-secret")
+	}
+}
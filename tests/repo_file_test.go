@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoFile(t *testing.T) {
+	cmd.OverrideVersionController = nil
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-repo-file-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	fileRepo := createRepo(t, "owner", "from-file", "i like apples")
+	defer os.RemoveAll(fileRepo.Path)
+	stdinRepo := createRepo(t, "owner", "from-stdin", "i like apples")
+	defer os.RemoveAll(stdinRepo.Path)
+	unlistedRepo := createRepo(t, "owner", "unlisted", "i like apples")
+	defer os.RemoveAll(unlistedRepo.Path)
+
+	repoFile := filepath.Join(tmpDir, "repos.txt")
+	require.NoError(t, ioutil.WriteFile(repoFile, []byte("# Repositories from the inventory\n\n"+fileRepo.URL("")+"\n"), 0600))
+
+	// The repositories on stdin are read by --repo -
+	stdinReader, stdinWriter, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = stdinWriter.WriteString(stdinRepo.URL("") + "\n")
+	require.NoError(t, err)
+	require.NoError(t, stdinWriter.Close())
+	defer func(stdin *os.File) { os.Stdin = stdin }(os.Stdin)
+	os.Stdin = stdinReader
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--platform", "git",
+		"--repo-file", repoFile,
+		"--repo", "-",
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	assert.True(t, branchExist(t, fileRepo.Path, "custom-branch-name"))
+	assert.True(t, branchExist(t, stdinRepo.Path, "custom-branch-name"))
+	assert.False(t, branchExist(t, unlistedRepo.Path, "custom-branch-name"))
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryFilter(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "service-a", "i like apples"),
+			createRepo(t, "owner", "service-b", "i like apples"),
+			createRepo(t, "owner", "legacy-service", "i like apples"),
+			createRepo(t, "other-owner", "service-c", "i like apples"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-filter-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--repo-include", "^owner/",
+		"--repo-exclude", "/legacy-",
+		"--repo-exclude", "-b$",
+		"--report", filepath.ToSlash(filepath.Join(tmpDir, "report.json")),
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "service-a", vcMock.PullRequests[0].RepoName)
+
+	// The repositories that were filtered out are reported with the reason
+	f, err := os.Open(filepath.Join(tmpDir, "report.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	report, err := multigitter.ReadReport(f)
+	require.NoError(t, err)
+	require.Len(t, report.Repositories, 4)
+	assert.Equal(t, multigitter.RepositoryReport{
+		Repository: "other-owner/service-c",
+		Status:     multigitter.ReportStatusSkipped,
+		Reason:     "the name does not match any included pattern",
+	}, report.Repositories[0])
+	assert.Equal(t, multigitter.ReportStatusSkipped, report.Repositories[1].Status)
+	assert.Equal(t, "the name matches the excluded pattern /legacy-", report.Repositories[1].Reason)
+	assert.Equal(t, multigitter.ReportStatusSuccess, report.Repositories[2].Status)
+	assert.Equal(t, "the name matches the excluded pattern -b$", report.Repositories[3].Reason)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"status",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "status.txt")),
+		"--repo-exclude", "service-a",
+		"-B", "custom-branch-name",
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "", readFile(t, tmpDir, "status.txt"))
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--repo-include", "(",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	assert.Error(t, command.Execute())
+}
+
+func TestRequireFile(t *testing.T) {
+	node14Repo := createRepo(t, "owner", "node-14", "i like apples")
+	addFile(t, node14Repo.Path, "Dockerfile", "FROM node:14\n", "add dockerfile")
+	node20Repo := createRepo(t, "owner", "node-20", "i like apples")
+	addFile(t, node20Repo.Path, "Dockerfile", "FROM node:20\n", "add dockerfile")
+	noDockerfileRepo := createRepo(t, "owner", "no-dockerfile", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{node14Repo, node20Repo, noDockerfileRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-require-file-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--require-file", fileName,
+		"--require-file-content", `Dockerfile=FROM node:1[0-4]\b`,
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "node-14", vcMock.PullRequests[0].RepoName)
+	assert.False(t, branchExist(t, node20Repo.Path, "custom-branch-name"))
+	assert.False(t, branchExist(t, noDockerfileRepo.Path, "custom-branch-name"))
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--require-file-content", "Dockerfile",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	assert.Error(t, command.Execute())
+}
+
+func TestOwnedBy(t *testing.T) {
+	platformRepo := createRepo(t, "owner", "platform", "i like apples")
+	require.NoError(t, os.MkdirAll(filepath.Join(platformRepo.Path, ".github"), 0755))
+	addFile(t, platformRepo.Path, ".github/CODEOWNERS", "# Owners\n* @acme/Platform-Team @someone\n", "add codeowners")
+	frontendRepo := createRepo(t, "owner", "frontend", "i like apples")
+	addFile(t, frontendRepo.Path, "CODEOWNERS", "*.js @acme/frontend-team\n", "add codeowners")
+	noOwnersRepo := createRepo(t, "owner", "no-owners", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{platformRepo, frontendRepo, noOwnersRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-owned-by-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--owned-by", "acme/platform-team",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "platform", vcMock.PullRequests[0].RepoName)
+	assert.False(t, branchExist(t, frontendRepo.Path, "custom-branch-name"))
+	assert.False(t, branchExist(t, noOwnersRepo.Path, "custom-branch-name"))
+}
+
+func TestExcludeFrom(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "service-a", "i like apples"),
+			createRepo(t, "owner", "legacy-service", "i like apples"),
+			createRepo(t, "owner", "service-archive", "i like apples"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-exclude-from-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	excludeFile := filepath.Join(tmpDir, "exclusions.txt")
+	require.NoError(t, ioutil.WriteFile(excludeFile, []byte("# Maintained by the platform team\nowner/legacy-*\n\nowner/*-archive\n"), 0600))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"--exclude-from", excludeFile,
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "service-a", vcMock.PullRequests[0].RepoName)
+}
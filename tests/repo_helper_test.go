@@ -174,3 +174,16 @@ func fileExist(t *testing.T, basePath string, fn string) bool {
 	require.NoError(t, err)
 	return true
 }
+
+func branchCommit(t *testing.T, path string, branchName string) *object.Commit {
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branchName), false)
+	require.NoError(t, err)
+
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+
+	return commit
+}
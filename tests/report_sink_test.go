@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSinks(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "should-change", "i like apples"),
+			createRepo(t, "owner", "should-not-change", "i like oranges"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-report-sink-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	var posted multigitter.Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+	}))
+	defer server.Close()
+
+	// The sinks are configured in the config file of the campaign
+	reportFile := filepath.Join(tmpDir, "report.json")
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(fmt.Sprintf("report-sink:\n  - %s\n  - %s\n", reportFile, server.URL)), 0600))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--config", configFile,
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "test",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, posted.Repositories, 2)
+	assert.Equal(t, "owner/should-change", posted.Repositories[0].Repository)
+	assert.Equal(t, multigitter.ReportStatusSuccess, posted.Repositories[0].Status)
+	assert.Equal(t, multigitter.ReportStatusNoChange, posted.Repositories[1].Status)
+
+	f, err := os.Open(reportFile)
+	require.NoError(t, err)
+	defer f.Close()
+	written, err := multigitter.ReadReport(f)
+	require.NoError(t, err)
+	assert.Equal(t, posted, written)
+}
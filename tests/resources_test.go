@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceUsage(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "should-change", "i like apples"),
+			createRepo(t, "owner", "should-not-change", "i like oranges"),
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-resources-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	reportFile := filepath.Join(tmpDir, "report.json")
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--report", reportFile,
+		"--slowest", "1",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	f, err := os.Open(reportFile)
+	require.NoError(t, err)
+	defer f.Close()
+	report, err := multigitter.ReadReport(f)
+	require.NoError(t, err)
+
+	require.Len(t, report.Repositories, 2)
+	for _, repo := range report.Repositories {
+		require.NotNil(t, repo.Resources, repo.Repository)
+		assert.Greater(t, repo.Resources.WallTime, 0.0)
+		assert.Greater(t, repo.Resources.CloneSize, int64(0))
+		if runtime.GOOS != "windows" {
+			assert.Greater(t, repo.Resources.ScriptPeakMemory, int64(0))
+		}
+	}
+
+	out := readFile(t, tmpDir, "out.txt")
+	assert.Contains(t, out, "Slowest repositories:\n  owner/should")
+	assert.Regexp(t, `\(script cpu time [0-9.]+m?s, script peak memory [^,]+, clone size [0-9.]+ KiB\)\n$`, out)
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	mergedRepo := createRepo(t, "owner", "merged", "i like apples")
+	openRepo := createRepo(t, "owner", "open", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{mergedRepo, openRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-rollback-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	reportPath := filepath.ToSlash(filepath.Join(tmpDir, "report.json"))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--report", reportPath,
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath)),
+	})
+	require.NoError(t, command.Execute())
+	require.Len(t, vcMock.PullRequests, 2)
+
+	// Merge one of the pull requests, followed by another change in the same repository
+	vcMock.SetPRStatus("merged", "custom-branch-name", domain.PullRequestStatusMerged)
+	changeTestFile(t, mergedRepo.Path, "i like bananas", "merged pull request")
+	addFile(t, mergedRepo.Path, "other.txt", "other change", "later change")
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"rollback",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--from-report", reportPath,
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 3)
+	assert.Equal(t, "merged", vcMock.PullRequests[2].RepoName)
+	assert.Equal(t, "multi-gitter-rollback", vcMock.PullRequests[2].Head)
+	assert.Equal(t, "Revert changes made by multi-gitter", vcMock.PullRequests[2].Title)
+	assert.False(t, branchExist(t, openRepo.Path, "multi-gitter-rollback"))
+
+	changeBranch(t, mergedRepo.Path, "multi-gitter-rollback", false)
+	assert.Equal(t, "i like apples", readTestFile(t, mergedRepo.Path))
+	assert.Equal(t, "other change", readFile(t, mergedRepo.Path, "other.txt"))
+}
+
+func TestRollbackCommits(t *testing.T) {
+	campaignRepo := createRepo(t, "owner", "campaign", "i like apples")
+	changeTestFile(t, campaignRepo.Path, "i like bananas", "Change fruit\n\nCampaign-Id: fruit")
+	addFile(t, campaignRepo.Path, "other.txt", "other change", "Later change")
+	otherRepo := createRepo(t, "owner", "other", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{campaignRepo, otherRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-rollback-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"rollback",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--commit-trailer", "campaign-id: fruit",
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Equal(t, "campaign", vcMock.PullRequests[0].RepoName)
+	assert.False(t, branchExist(t, otherRepo.Path, "multi-gitter-rollback"))
+
+	changeBranch(t, campaignRepo.Path, "multi-gitter-rollback", false)
+	assert.Equal(t, "i like apples", readTestFile(t, campaignRepo.Path))
+	assert.Equal(t, "other change", readFile(t, campaignRepo.Path, "other.txt"))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"flag"
 	"io/ioutil"
+	"os"
 	"time"
 )
 
@@ -29,4 +30,12 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+
+	if annotationsFile := os.Getenv("ANNOTATIONS_FILE"); annotationsFile != "" {
+		annotation := `{"path": "test.txt", "line": 1, "message": "bananas are not apples", "severity": "warning"}` + "\n"
+		err = ioutil.WriteFile(annotationsFile, []byte(annotation), 0600)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+)
+
+// Decrypts (base64 decodes) the file given as the first argument
+func main() {
+	data, err := ioutil.ReadFile(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		panic(err)
+	}
+
+	_, _ = os.Stdout.Write(decoded)
+}
@@ -0,0 +1,131 @@
+// A plugin that uses local directories as repositories, and stores the created pull requests in a file
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+
+	"github.com/lindell/multi-gitter/internal/scm/plugin"
+)
+
+// Plugin implements the methods called by multi-gitter
+type Plugin struct {
+	config    plugin.Config
+	stateFile string
+}
+
+// Configure is called when the plugin is started
+func (p *Plugin) Configure(config plugin.Config, _ *plugin.Empty) error {
+	p.config = config
+	return nil
+}
+
+// GetRepositories returns the directories set with --repo
+func (p *Plugin) GetRepositories(_ plugin.Empty, reply *[]plugin.Repository) error {
+	*reply = []plugin.Repository{}
+	for _, path := range p.config.Repositories {
+		*reply = append(*reply, plugin.Repository{
+			FullName:      "owner/" + filepath.Base(path),
+			DefaultBranch: "master",
+			CloneURL:      path,
+		})
+	}
+	return nil
+}
+
+// CreatePullRequest stores the pull request
+func (p *Plugin) CreatePullRequest(args plugin.CreatePullRequestArgs, reply *plugin.PullRequest) error {
+	prs, err := p.readPullRequests()
+	if err != nil {
+		return err
+	}
+
+	*reply = plugin.PullRequest{
+		Name:       fmt.Sprintf("%s #%d", args.Repository.FullName, len(prs)+1),
+		Repository: args.Repository.FullName,
+		Status:     "pending",
+		Data:       json.RawMessage(fmt.Sprintf("%q", args.PullRequest.Head)),
+	}
+	return p.writePullRequests(append(prs, *reply))
+}
+
+// GetPullRequests returns the stored pull requests of the branch
+func (p *Plugin) GetPullRequests(args plugin.GetPullRequestsArgs, reply *[]plugin.PullRequest) error {
+	prs, err := p.readPullRequests()
+	if err != nil {
+		return err
+	}
+
+	*reply = []plugin.PullRequest{}
+	for _, pr := range prs {
+		if string(pr.Data) == fmt.Sprintf("%q", args.BranchName) {
+			*reply = append(*reply, pr)
+		}
+	}
+	return nil
+}
+
+// MergePullRequest marks the pull request as merged
+func (p *Plugin) MergePullRequest(pr plugin.PullRequest, _ *plugin.Empty) error {
+	return p.setStatus(pr, "merged")
+}
+
+// ClosePullRequest marks the pull request as closed
+func (p *Plugin) ClosePullRequest(pr plugin.PullRequest, _ *plugin.Empty) error {
+	return p.setStatus(pr, "closed")
+}
+
+// ForkRepository is not supported
+func (p *Plugin) ForkRepository(_ plugin.ForkRepositoryArgs, _ *plugin.Repository) error {
+	return fmt.Errorf("forking is not supported")
+}
+
+func (p *Plugin) setStatus(pr plugin.PullRequest, status string) error {
+	prs, err := p.readPullRequests()
+	if err != nil {
+		return err
+	}
+	for i := range prs {
+		if prs[i].Name == pr.Name {
+			prs[i].Status = status
+		}
+	}
+	return p.writePullRequests(prs)
+}
+
+func (p *Plugin) readPullRequests() ([]plugin.PullRequest, error) {
+	var prs []plugin.PullRequest
+	b, err := ioutil.ReadFile(p.stateFile)
+	if os.IsNotExist(err) {
+		return prs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return prs, json.Unmarshal(b, &prs)
+}
+
+func (p *Plugin) writePullRequests(prs []plugin.PullRequest) error {
+	b, err := json.Marshal(prs)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.stateFile, b, 0600)
+}
+
+type stdio struct {
+	io.Reader
+	io.WriteCloser
+}
+
+func main() {
+	if err := rpc.RegisterName("Plugin", &Plugin{stateFile: os.Args[1]}); err != nil {
+		panic(err)
+	}
+	rpc.ServeCodec(jsonrpc.NewServerCodec(stdio{os.Stdin, os.Stdout}))
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "new", "i like apples"),
+			createRepo(t, "owner", "existing", "i like apples"),
+		},
+		Secrets: map[string]domain.Secrets{
+			"owner/existing": {
+				Secrets:   map[string]string{"NPM_TOKEN": "old-token"},
+				Variables: map[string]string{"REGISTRY_URL": "https://registry.example.com"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-secrets-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+
+	secretsFile := filepath.Join(tmpDir, "secrets.enc")
+	content := base64.StdEncoding.EncodeToString([]byte(`
+secrets:
+  NPM_TOKEN: new-token
+variables:
+  REGISTRY_URL: https://registry.example.com
+`))
+	require.NoError(t, ioutil.WriteFile(secretsFile, []byte(content), 0600))
+	decryptCommand := fmt.Sprintf(`go run %s`, filepath.ToSlash(filepath.Join(workingDir, "scripts/decrypter/main.go")))
+
+	expectedOutput := `owner/new:
+  create secret NPM_TOKEN
+  create variable REGISTRY_URL
+owner/existing:
+  update secret NPM_TOKEN
+`
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"secrets",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")),
+		"--secrets-file", filepath.ToSlash(secretsFile),
+		"--decrypt-command", decryptCommand,
+		"--dry-run",
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, expectedOutput, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, "old-token", vcMock.Secrets["owner/existing"].Secrets["NPM_TOKEN"])
+	assert.NotContains(t, vcMock.Secrets, "owner/new")
+
+	auditLog := filepath.Join(tmpDir, "audit.log")
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"secrets",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt")),
+		"--secrets-file", filepath.ToSlash(secretsFile),
+		"--decrypt-command", decryptCommand,
+		"--audit-log", filepath.ToSlash(auditLog),
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, expectedOutput, readFile(t, tmpDir, "out.txt"))
+	assert.Equal(t, "new-token", vcMock.Secrets["owner/existing"].Secrets["NPM_TOKEN"])
+	assert.Equal(t, "new-token", vcMock.Secrets["owner/new"].Secrets["NPM_TOKEN"])
+	assert.Equal(t, "https://registry.example.com", vcMock.Secrets["owner/new"].Variables["REGISTRY_URL"])
+
+	// The audit log should contain every change, but never the values
+	audit := readFile(t, tmpDir, "audit.log")
+	assert.Equal(t, 3, strings.Count(audit, "\n"))
+	assert.Contains(t, audit, `"repository":"owner/new","kind":"secret","name":"NPM_TOKEN","action":"create"`)
+	assert.Contains(t, audit, `"repository":"owner/existing","kind":"secret","name":"NPM_TOKEN","action":"update"`)
+	assert.NotContains(t, audit, "new-token")
+	assert.NotContains(t, audit, "registry.example.com")
+
+	// Log files should never contain any values
+	assert.NotContains(t, readFile(t, tmpDir, "log.txt"), "new-token")
+}
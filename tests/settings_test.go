@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "outdated", "i like apples"),
+			createRepo(t, "owner", "up-to-date", "i like apples"),
+		},
+		Settings: map[string]domain.RepositorySettings{
+			"owner/outdated": {
+				Topics: []string{"go"},
+			},
+			"owner/up-to-date": {
+				Topics: []string{"cli", "go"},
+				BranchProtection: &domain.BranchProtection{
+					Branch:                   "master",
+					RequiredApprovingReviews: 1,
+				},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-settings-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	settingsFile := filepath.Join(tmpDir, "settings.yaml")
+	require.NoError(t, ioutil.WriteFile(settingsFile, []byte(`
+topics: [go, cli]
+branch_protection:
+  required_approving_reviews: 1
+`), 0600))
+
+	expectedOutput := `owner/outdated:
+  topics: go -> cli, go
+  branch_protection master: none -> required approving reviews: 1, required status checks: none, enforce admins: false
+owner/up-to-date: up to date
+`
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"settings",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "dry-run.txt"),
+		"--settings-file", settingsFile,
+		"--dry-run",
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, expectedOutput, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, []string{"go"}, vcMock.Settings["owner/outdated"].Topics)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"settings",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--settings-file", settingsFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, expectedOutput, readFile(t, tmpDir, "out.txt"))
+	assert.Equal(t, []string{"go", "cli"}, vcMock.Settings["owner/outdated"].Topics)
+	assert.Equal(t, 1, vcMock.Settings["owner/outdated"].BranchProtection.RequiredApprovingReviews)
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"settings",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "rerun.txt"),
+		"--settings-file", settingsFile,
+	})
+	require.NoError(t, command.Execute())
+	assert.Equal(t, "owner/outdated: up to date\nowner/up-to-date: up to date\n", readFile(t, tmpDir, "rerun.txt"))
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/multigitter"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+	repo := createRepo(t, "owner", "should-change", "i like apples")
+	existingBranchRepo := createRepo(t, "owner", "existing-branch", "i like apples")
+	changeBranch(t, existingBranchRepo.Path, "custom-branch-name", true)
+	changeBranch(t, existingBranchRepo.Path, "master", false)
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{repo, existingBranchRepo},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-simulate-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	reportFile := filepath.Join(tmpDir, "report.json")
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--path-label", "*=simulated",
+		"--skip-footer",
+		"--report", reportFile,
+		"--simulate",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	require.Len(t, vcMock.PullRequests, 0)
+	assert.False(t, branchExist(t, repo.Path, "custom-branch-name"))
+
+	f, err := os.Open(reportFile)
+	require.NoError(t, err)
+	defer f.Close()
+	report, err := multigitter.ReadReport(f)
+	require.NoError(t, err)
+
+	require.Len(t, report.Repositories, 2)
+	assert.Equal(t, "owner/existing-branch", report.Repositories[0].Repository)
+	assert.Equal(t, multigitter.ReportStatusSkipped, report.Repositories[0].Status)
+	assert.Equal(t, "the new branch does already exist", report.Repositories[0].Reason)
+	assert.Nil(t, report.Repositories[0].PullRequestPreview)
+
+	assert.Equal(t, "owner/should-change", report.Repositories[1].Repository)
+	assert.Equal(t, multigitter.ReportStatusSuccess, report.Repositories[1].Status)
+	assert.Empty(t, report.Repositories[1].PullRequest)
+	assert.Equal(t, &multigitter.PullRequestPreview{
+		Title:  "custom message",
+		Head:   "custom-branch-name",
+		Base:   "master",
+		Labels: []string{"simulated"},
+	}, report.Repositories[1].PullRequestPreview)
+
+	assert.Contains(t, readFile(t, tmpDir, "out.txt"), "owner/should-change (simulated)")
+}
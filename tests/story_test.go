@@ -166,3 +166,66 @@ Repositories with a successful run:
 	require.NoError(t, err)
 	assert.Equal(t, "owner/should-change #1: Merged\nowner/should-change-2 #2: Closed\n", string(afterCloseStatusOutData))
 }
+
+// TestCampaignStory tests that pull requests can be found by their campaign instead of the branch name
+func TestCampaignStory(t *testing.T) {
+	vcMock := &vcmock.VersionController{}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-run-")
+	defer os.RemoveAll(tmpDir)
+	assert.NoError(t, err)
+
+	workingDir, err := os.Getwd()
+	assert.NoError(t, err)
+
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	vcMock.AddRepository(createRepo(t, "owner", "should-change", "i like apples"))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--output", filepath.Join(tmpDir, "run-log.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"--campaign-id", "my-campaign",
+		"--campaign-url", "https://example.com/my-campaign",
+		"-m", "test",
+		changerBinaryPath,
+	})
+	err = command.Execute()
+	assert.NoError(t, err)
+
+	require.Len(t, vcMock.PullRequests, 1)
+	assert.Contains(t, vcMock.PullRequests[0].Body, "<!-- multi-gitter-campaign: my-campaign -->")
+	assert.Contains(t, vcMock.PullRequests[0].Body, "[Campaign configuration](https://example.com/my-campaign)")
+	assert.Contains(t, vcMock.PullRequests[0].Body, "Script hash: `")
+
+	statusOutFile := filepath.Join(tmpDir, "status-log.txt")
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"status",
+		"--output", statusOutFile,
+		"--campaign-id", "my-campaign",
+	})
+	err = command.Execute()
+	assert.NoError(t, err)
+
+	statusOutData, err := ioutil.ReadFile(statusOutFile)
+	require.NoError(t, err)
+	assert.Equal(t, "owner/should-change #1: Pending\n", string(statusOutData))
+
+	command = cmd.RootCmd()
+	command.SetArgs([]string{"status",
+		"--output", statusOutFile,
+		"--campaign-id", "other-campaign",
+	})
+	err = command.Execute()
+	assert.NoError(t, err)
+
+	statusOutData, err = ioutil.ReadFile(statusOutFile)
+	require.NoError(t, err)
+	assert.Equal(t, "", string(statusOutData))
+}
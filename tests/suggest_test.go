@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	openRepo := createRepo(t, "owner", "open", "i like apples\ni like pears")
+	changeBranch(t, openRepo.Path, "custom-branch-name", true)
+	changeTestFile(t, openRepo.Path, "i like apples\ni like pears\n", "existing change")
+	changeBranch(t, openRepo.Path, "master", false)
+
+	noPRRepo := createRepo(t, "owner", "no-pr", "i like apples")
+
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{openRepo, noPRRepo},
+		PullRequests: []vcmock.PullRequest{
+			{
+				PRStatus:       domain.PullRequestStatusPending,
+				PRNumber:       1,
+				Repository:     openRepo,
+				NewPullRequest: domain.NewPullRequest{Head: "custom-branch-name"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-suggest-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
+
+	command := cmd.RootCmd()
+	command.SetArgs([]string{"run",
+		"--log-file", filepath.Join(tmpDir, "log.txt"),
+		"--output", filepath.Join(tmpDir, "out.txt"),
+		"--author-name", "Test Author",
+		"--author-email", "test@example.com",
+		"-B", "custom-branch-name",
+		"-m", "custom message",
+		"--suggest",
+		changerBinaryPath,
+	})
+	require.NoError(t, command.Execute())
+
+	// No changes are pushed, and no pull requests are created
+	require.Len(t, vcMock.PullRequests, 1)
+	changeBranch(t, openRepo.Path, "custom-branch-name", false)
+	assert.Equal(t, "i like apples\ni like pears\n", readTestFile(t, openRepo.Path))
+	assert.False(t, branchExist(t, noPRRepo.Path, "custom-branch-name"))
+
+	assert.Equal(t, map[string][]domain.Suggestion{
+		"owner/open": {
+			{Path: "test.txt", StartLine: 1, EndLine: 1, Replacement: []string{"i like bananas"}},
+		},
+	}, vcMock.Suggestions)
+
+	out := readFile(t, tmpDir, "out.txt")
+	assert.Contains(t, out, "owner/open #1")
+	assert.Contains(t, out, "owner/no-pr")
+}
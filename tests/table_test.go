@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
 	"github.com/lindell/multi-gitter/tests/vcmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,6 +74,9 @@ func TestTable(t *testing.T) {
 
 	changerBinaryPath := filepath.ToSlash(filepath.Join(workingDir, changerBinaryPath))
 
+	ownershipReportPath := filepath.Join(os.TempDir(), "multi-gitter-test-ownership-report.md")
+	defer os.Remove(ownershipReportPath)
+
 	tests := []struct {
 		name        string
 		gitBackends []gitBackend                                 // If set, use only the specified git backends, otherwise use all
@@ -119,6 +123,103 @@ func TestTable(t *testing.T) {
 			},
 		},
 
+		{
+			name: "summary",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+						createRepo(t, "owner", "should-not-change", "i like oranges"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				assert.Regexp(t, `REPOSITORY +OUTCOME +DETAILS +DURATION
+owner/should-change +success +owner/should-change #1 +[0-9.]+m?s
+owner/should-not-change +no-change +no data was changed +[0-9.]+m?s
+`, runData.cmdOut)
+			},
+		},
+
+		{
+			name: "no summary",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				"--no-summary",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.NotContains(t, runData.cmdOut, "REPOSITORY")
+			},
+		},
+
+		{
+			name: "summary with error category",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-fail", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				fmt.Sprintf("go run %s", filepath.ToSlash(filepath.Join(workingDir, "scripts/failing/main.go"))),
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				assert.Regexp(t, `owner/should-fail +error \(script-exit\) +exit status 1 +[0-9.]+m?s
+Errors by category:
+  script-exit  1
+`, runData.cmdOut)
+			},
+		},
+
+		{
+			name: "only repo",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+						createRepo(t, "owner", "should-not-be-used", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--only-repo", "owner/should-change",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, "should-change", vcMock.PullRequests[0].RepoName)
+				assert.Contains(t, runData.logOut, "Running on 1 repositories")
+			},
+		},
 		{
 			name: "with go run",
 			vcCreate: func(t *testing.T) *vcmock.VersionController {
@@ -249,14 +350,16 @@ func TestTable(t *testing.T) {
 				"--author-name", "Test Author",
 				"--author-email", "test@example.com",
 				"-m", "custom message",
-				"-r", "reviewer1,reviewer2",
+				"-r", "reviewer1,reviewer2,team:team1",
 				changerBinaryPath,
 			},
 			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
 				require.Len(t, vcMock.PullRequests, 1)
-				assert.Len(t, vcMock.PullRequests[0].Reviewers, 2)
-				assert.Contains(t, vcMock.PullRequests[0].Reviewers, "reviewer1")
-				assert.Contains(t, vcMock.PullRequests[0].Reviewers, "reviewer2")
+				assert.Equal(t, []domain.Reviewer{
+					{Type: domain.ReviewerTypeUser, Name: "reviewer1"},
+					{Type: domain.ReviewerTypeUser, Name: "reviewer2"},
+					{Type: domain.ReviewerTypeTeam, Name: "team1"},
+				}, vcMock.PullRequests[0].Reviewers)
 			},
 		},
 
@@ -284,6 +387,441 @@ func TestTable(t *testing.T) {
 			},
 		},
 
+		{
+			name: "assignees",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				"-r", "reviewer1",
+				"-a", "assignee1,assignee2",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, []domain.Reviewer{{Type: domain.ReviewerTypeUser, Name: "reviewer1"}}, vcMock.PullRequests[0].Reviewers)
+				assert.Equal(t, []string{"assignee1", "assignee2"}, vcMock.PullRequests[0].Assignees)
+			},
+		},
+
+		{
+			name: "path labels",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				"--path-label", "*.txt=text",
+				"--path-label", "Dockerfile=docker",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, []string{"text"}, vcMock.PullRequests[0].Labels)
+			},
+		},
+
+		{
+			name: "author from token",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-from-token",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+
+				commit := branchCommit(t, vcMock.Repositories[0].Path, "custom-branch-name")
+				assert.Equal(t, "Token User", commit.Author.Name)
+				assert.Equal(t, "token-user@example.com", commit.Author.Email)
+			},
+		},
+
+		{
+			name: "commit trailers",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--commit-trailer", "Campaign-Id: my-campaign",
+				"--commit-trailer", "Ticket: ABC-123, ABC-124",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, "custom message", vcMock.PullRequests[0].Title)
+
+				commit := branchCommit(t, vcMock.Repositories[0].Path, "custom-branch-name")
+				assert.Equal(t, "custom message\n\nCampaign-Id: my-campaign\nTicket: ABC-123, ABC-124", strings.TrimSpace(commit.Message))
+			},
+		},
+
+		{
+			name: "skip footer",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-m", "custom message",
+				"--pr-body", "custom body",
+				"--skip-footer",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, "custom body", vcMock.PullRequests[0].Body)
+			},
+		},
+
+		{
+			name: "skip equivalent",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				repo := createRepo(t, "owner", "already-changed", "i like apples")
+				changeBranch(t, repo.Path, "manual-branch", true)
+				changeTestFile(t, repo.Path, "i like bananas", "manual change")
+				changeBranch(t, repo.Path, "master", false)
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						repo,
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+					PullRequests: []vcmock.PullRequest{
+						{
+							PRStatus:   domain.PullRequestStatusPending,
+							PRNumber:   1,
+							Repository: repo,
+							NewPullRequest: domain.NewPullRequest{
+								Head: "manual-branch",
+								Base: "master",
+							},
+						},
+					},
+					PRNumber: 1,
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--skip-equivalent",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 2)
+				assert.Equal(t, "owner/should-change", vcMock.PullRequests[1].FullName())
+
+				assert.Equal(t, `An open pull request with the same changes does already exist:
+  owner/already-changed
+Repositories with a successful run:
+  owner/should-change #2
+`, runData.out)
+			},
+		},
+
+		{
+			name:        "update branch with conflict strategy",
+			gitBackends: []gitBackend{gitBackendCmd},
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				repo := createRepo(t, "owner", "should-change", "i like apples")
+				changeBranch(t, repo.Path, "custom-branch-name", true)
+				changeTestFile(t, repo.Path, "i like pears", "old change")
+				addFile(t, repo.Path, "other.txt", "keep me", "other change")
+				changeBranch(t, repo.Path, "master", false)
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						repo,
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--update-branch",
+				"--conflict-strategy", "test.txt=ours",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 0)
+				assert.Equal(t, `Repositories with a successful run:
+  owner/should-change
+`, runData.out)
+
+				changeBranch(t, vcMock.Repositories[0].Path, "custom-branch-name", false)
+				assert.Equal(t, "i like bananas", readTestFile(t, vcMock.Repositories[0].Path))
+				assert.Equal(t, "keep me", readFile(t, vcMock.Repositories[0].Path, "other.txt"))
+			},
+		},
+
+		{
+			name:        "update branch with unresolved conflict",
+			gitBackends: []gitBackend{gitBackendCmd},
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				repo := createRepo(t, "owner", "should-change", "i like apples")
+				changeBranch(t, repo.Path, "custom-branch-name", true)
+				changeTestFile(t, repo.Path, "i like pears", "old change")
+				changeBranch(t, repo.Path, "master", false)
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						repo,
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--update-branch",
+				"--conflict-strategy", "*.lock=theirs",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 0)
+				assert.Equal(t, `The existing branch could not be updated because of unresolved conflicts:
+  owner/should-change
+`, runData.out)
+
+				changeBranch(t, vcMock.Repositories[0].Path, "custom-branch-name", false)
+				assert.Equal(t, "i like pears", readTestFile(t, vcMock.Repositories[0].Path))
+			},
+		},
+
+		{
+			name: "create issue on failure",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--create-issue-on-failure",
+				"--issue-repo", "owner/central",
+				fmt.Sprintf("go run %s", filepath.ToSlash(filepath.Join(workingDir, "scripts/failing/main.go"))),
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 0)
+				require.Len(t, vcMock.Issues, 1)
+				assert.Equal(t, "owner/central", vcMock.Issues[0].RepoName)
+				assert.Equal(t, "multi-gitter run custom-branch-name failed in owner/should-change", vcMock.Issues[0].Title)
+				assert.Contains(t, vcMock.Issues[0].Body, "**Error:** exit status 1")
+				assert.Contains(t, vcMock.Issues[0].Body, "could not find the file to change")
+				assert.Contains(t, vcMock.Issues[0].Body, "<!-- multi-gitter-campaign: custom-branch-name -->")
+			},
+		},
+
+		{
+			name: "invalid pull request",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message that is too long",
+				"--pr-max-title-length", "10",
+				"--pr-forbidden-word", "message",
+				changerBinaryPath,
+			},
+			expectErr: true,
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 0)
+				assert.Contains(t, runData.cmdOut, "the title is 31 characters long, the maximum is 10")
+				assert.Contains(t, runData.cmdOut, `the forbidden word "message" is used`)
+			},
+		},
+
+		{
+			name: "missing requirement",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--require", "git>=1",
+				"--require", "git<1",
+				"--require", "multi-gitter-missing-tool",
+				changerBinaryPath,
+			},
+			expectErr: true,
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 0)
+				assert.NotContains(t, runData.cmdOut, "git>=1")
+				assert.Regexp(t, `git is installed in version [0-9.]+, git<1 is required`, runData.cmdOut)
+				assert.Contains(t, runData.cmdOut, "multi-gitter-missing-tool is not installed")
+			},
+		},
+
+		{
+			name: "verify checks",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "passing", "i like apples"),
+						createRepo(t, "owner", "failing", "i like apples"),
+					},
+					Checks: map[string][]domain.Check{
+						"owner/passing": {{Name: "build", Status: domain.CheckStatusSuccess}},
+						"owner/failing": {
+							{Name: "build", Status: domain.CheckStatusSuccess},
+							{Name: "lint", Status: domain.CheckStatusFailure},
+						},
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--verify-checks",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 2)
+				assert.Contains(t, runData.out, "The checks of the pull request failed: lint:\n  owner/failing\n")
+				assert.Contains(t, runData.out, "Repositories with a successful run:\n  owner/passing #")
+			},
+		},
+
+		{
+			name: "ownership report",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				owned := createRepo(t, "owner", "owned", "i like apples")
+				addFile(t, owned.Path, "CODEOWNERS", "* @owner/team\n*.txt @owner/text-team @someone\n", "add codeowners")
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						owned,
+						createRepo(t, "owner", "unowned", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--ownership-report", ownershipReportPath,
+				"--ownership-report-format", "markdown",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 2)
+				report := readFile(t, filepath.Dir(ownershipReportPath), filepath.Base(ownershipReportPath))
+				assert.Equal(t, `# Ownership report
+
+## @owner/text-team
+
+- owner/owned (owner/owned #1)
+  - `+"`test.txt`"+`
+
+## @someone
+
+- owner/owned (owner/owned #1)
+  - `+"`test.txt`"+`
+
+## Without owner
+
+- owner/unowned (owner/unowned #2)
+  - `+"`test.txt`"+`
+`, report)
+			},
+		},
+
+		{
+			name: "draft with milestone",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"-B", "custom-branch-name",
+				"-m", "custom message",
+				"--draft",
+				"--milestone", "v1.0",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.True(t, vcMock.PullRequests[0].Draft)
+				assert.Equal(t, "v1.0", vcMock.PullRequests[0].Milestone)
+			},
+		},
+
 		{
 			name: "dry run",
 			vcCreate: func(t *testing.T) *vcmock.VersionController {
@@ -662,6 +1200,33 @@ Repositories with a successful run:
 `, runData.out)
 			},
 		},
+
+		{
+			name: "platform profile in config file",
+			vcCreate: func(t *testing.T) *vcmock.VersionController {
+				return &vcmock.VersionController{
+					Repositories: []vcmock.Repository{
+						createRepo(t, "owner", "should-change", "i like apples"),
+					},
+				}
+			},
+			args: []string{
+				"run",
+				"--author-name", "Test Author",
+				"--author-email", "test@example.com",
+				"--config", "test-platform-config.yaml",
+				changerBinaryPath,
+			},
+			verify: func(t *testing.T, vcMock *vcmock.VersionController, runData runData) {
+				require.Len(t, vcMock.PullRequests, 1)
+				assert.Equal(t, "github-branch-name", vcMock.PullRequests[0].Head)
+				assert.Equal(t, "config-message", vcMock.PullRequests[0].Title)
+				assert.Equal(t, []domain.Reviewer{
+					{Type: domain.ReviewerTypeUser, Name: "config-reviewer"},
+					{Type: domain.ReviewerTypeUser, Name: "github-reviewer"},
+				}, vcMock.PullRequests[0].Reviewers)
+			},
+		},
 	}
 
 	for _, gitBackend := range gitBackends {
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopics(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "outdated", "i like apples"),
+			createRepo(t, "owner", "up-to-date", "i like apples"),
+		},
+		Settings: map[string]domain.RepositorySettings{
+			"owner/outdated": {
+				Topics: []string{"go", "needs-go-1.21"},
+			},
+			"owner/up-to-date": {
+				Topics: []string{"needs-go-1.22"},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-topics-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"topics",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--add", "needs-go-1.22",
+		"--remove", "needs-go-1.21",
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/outdated:
+  topics: go, needs-go-1.21 -> go, needs-go-1.22
+owner/up-to-date: up to date
+
+1 would be changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, []string{"go", "needs-go-1.21"}, vcMock.Settings["owner/outdated"].Topics)
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, []string{"go", "needs-go-1.22"}, vcMock.Settings["owner/outdated"].Topics)
+	assert.Equal(t, []string{"needs-go-1.22"}, vcMock.Settings["owner/up-to-date"].Topics)
+}
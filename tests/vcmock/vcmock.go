@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/lindell/multi-gitter/internal/domain"
 )
 
@@ -18,6 +22,26 @@ type VersionController struct {
 	PRNumber     int
 	Repositories []Repository
 	PullRequests []PullRequest
+	Forks        []Fork
+	Issues       []Issue
+	Checks       map[string][]domain.Check            // The checks of pull requests, by the full name of the repository
+	CheckRuns    map[string][]domain.CheckRun         // The published check runs, by the full name of the repository and the branch, in the format "owner/name:branch"
+	Suggestions  map[string][]domain.Suggestion       // The suggested changes on pull requests, by the full name of the repository
+	Settings     map[string]domain.RepositorySettings // The settings of repositories, by the full name of the repository
+	Secrets      map[string]domain.Secrets            // The secrets and variables of repositories, by the full name of the repository
+	Labels       map[string][]domain.Label            // The labels of repositories, by the full name of the repository
+	Permissions  map[string]domain.Permissions        // The permissions of users and teams to repositories, by the full name of the repository
+	Archived     map[string]bool                      // If repositories are archived, by the full name of the repository
+	Releases     map[string][]domain.Release          // The created releases, by the full name of the repository
+	Redirects    map[string]string                    // The new full names of renamed or transferred repositories, by their old full name
+
+	BranchProtections map[string]map[string]domain.BranchProtection // The protected branches of repositories, by the full name of the repository and the branch name
+}
+
+// Issue is a mock issue
+type Issue struct {
+	RepoName string
+	domain.NewIssue
 }
 
 // GetRepositories returns mock repositories
@@ -56,6 +80,114 @@ func (vc *VersionController) GetPullRequests(ctx context.Context, branchName str
 	return ret, nil
 }
 
+// GetPullRequest gets a mock pull request by its repository and number
+func (vc *VersionController) GetPullRequest(ctx context.Context, repoName string, number int) (domain.PullRequest, error) {
+	if newName, ok := vc.Redirects[repoName]; ok {
+		repoName = newName
+	}
+	for _, pr := range vc.PullRequests {
+		if pr.Repository.FullName() == repoName && pr.PRNumber == number {
+			return pr, nil
+		}
+	}
+	return nil, errors.New("could not find pull request")
+}
+
+// GetCampaignPullRequests gets mock pull requests that contains the campaign marker
+func (vc *VersionController) GetCampaignPullRequests(ctx context.Context, campaignID string) ([]domain.PullRequest, error) {
+	marker := domain.CampaignMarker(campaignID)
+
+	ret := make([]domain.PullRequest, 0, len(vc.PullRequests))
+	for _, pr := range vc.PullRequests {
+		if strings.Contains(pr.NewPullRequest.Body, marker) {
+			ret = append(ret, pr)
+		}
+	}
+	return ret, nil
+}
+
+// GetOpenPullRequestDiffs gets the diffs of the open mock pull requests, based on the branches in the repository
+func (vc *VersionController) GetOpenPullRequestDiffs(ctx context.Context, repo domain.Repository) ([]domain.PullRequestDiff, error) {
+	r := repo.(Repository)
+
+	ret := []domain.PullRequestDiff{}
+	for _, pr := range vc.PullRequests {
+		if pr.Repository.FullName() != r.FullName() ||
+			pr.PRStatus == domain.PullRequestStatusClosed || pr.PRStatus == domain.PullRequestStatusMerged {
+			continue
+		}
+
+		diff, err := branchDiff(r.Path, pr.Base, pr.Head)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, domain.PullRequestDiff{
+			PullRequest: pr,
+			Branch:      pr.Head,
+			Diff:        diff,
+		})
+	}
+	return ret, nil
+}
+
+func branchDiff(path, baseBranch, headBranch string) (string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	var trees []*object.Tree
+	for _, branch := range []string{baseBranch, headBranch} {
+		ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), false)
+		if err != nil {
+			return "", err
+		}
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return "", err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return "", err
+		}
+		trees = append(trees, tree)
+	}
+
+	patch, err := trees[0].Patch(trees[1])
+	if err != nil {
+		return "", err
+	}
+	return patch.String(), nil
+}
+
+// GetPullRequestPatch gets the diff between the base and head branch of a mock pull request
+func (vc *VersionController) GetPullRequestPatch(ctx context.Context, pr domain.PullRequest) (string, error) {
+	pullRequest := pr.(PullRequest)
+	return branchDiff(pullRequest.Repository.Path, pullRequest.Base, pullRequest.Head)
+}
+
+// ListBranches lists the branches of a mock repository
+func (vc *VersionController) ListBranches(ctx context.Context, repo domain.Repository) ([]string, error) {
+	r := repo.(Repository)
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := gitRepo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref.Name().Short())
+		return nil
+	})
+	return branches, err
+}
+
 // MergePullRequest sets the status of a mock pull requests to merged
 func (vc *VersionController) MergePullRequest(ctx context.Context, pr domain.PullRequest) error {
 	pullRequest := pr.(PullRequest)
@@ -68,6 +200,78 @@ func (vc *VersionController) MergePullRequest(ctx context.Context, pr domain.Pul
 	return errors.New("could not find pull request")
 }
 
+// GetPullRequestChecks returns the mock checks of the repository of a pull request
+func (vc *VersionController) GetPullRequestChecks(ctx context.Context, pr domain.PullRequest) ([]domain.Check, error) {
+	pullRequest := pr.(PullRequest)
+	return vc.Checks[pullRequest.Repository.FullName()], nil
+}
+
+// CreateCheckRun saves a published check run of a branch
+func (vc *VersionController) CreateCheckRun(ctx context.Context, repo domain.Repository, branch string, run domain.CheckRun) error {
+	if vc.CheckRuns == nil {
+		vc.CheckRuns = map[string][]domain.CheckRun{}
+	}
+	key := repo.FullName() + ":" + branch
+	vc.CheckRuns[key] = append(vc.CheckRuns[key], run)
+	return nil
+}
+
+// CreateSuggestions saves the suggested changes on a mock pull request
+func (vc *VersionController) CreateSuggestions(ctx context.Context, pr domain.PullRequest, suggestions []domain.Suggestion) error {
+	if vc.Suggestions == nil {
+		vc.Suggestions = map[string][]domain.Suggestion{}
+	}
+	repoName := pr.(PullRequest).Repository.FullName()
+	vc.Suggestions[repoName] = append(vc.Suggestions[repoName], suggestions...)
+	return nil
+}
+
+// MergePullRequestWithTypes sets the status of a mock pull requests to merged, with the first merge type
+func (vc *VersionController) MergePullRequestWithTypes(ctx context.Context, pr domain.PullRequest, mergeTypes []domain.MergeType) error {
+	pullRequest := pr.(PullRequest)
+	for i := range vc.PullRequests {
+		if vc.PullRequests[i].Repository.FullName() == pullRequest.Repository.FullName() {
+			vc.PullRequests[i].PRStatus = domain.PullRequestStatusMerged
+			vc.PullRequests[i].MergeType = mergeTypes[0]
+			return nil
+		}
+	}
+	return errors.New("could not find pull request")
+}
+
+// GetPullRequestCommits gets the commits on the head branch of a mock pull request that are not on the base branch
+func (vc *VersionController) GetPullRequestCommits(ctx context.Context, pr domain.PullRequest) ([]domain.Commit, error) {
+	pullRequest := pr.(PullRequest)
+
+	repo, err := git.PlainOpen(pullRequest.Repository.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseRef, err := repo.Reference(plumbing.NewBranchReferenceName(pullRequest.Base), false)
+	if err != nil {
+		return nil, err
+	}
+	headRef, err := repo.Reference(plumbing.NewBranchReferenceName(pullRequest.Head), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []domain.Commit
+	commit, err := repo.CommitObject(headRef.Hash())
+	for err == nil && commit.Hash != baseRef.Hash() {
+		commits = append(commits, domain.Commit{
+			Hash:    commit.Hash.String(),
+			Parents: commit.NumParents(),
+		})
+		if commit.NumParents() == 0 {
+			break
+		}
+		commit, err = commit.Parent(0)
+	}
+	return commits, err
+}
+
 // ClosePullRequest sets the status of a mock pull requests to closed
 func (vc *VersionController) ClosePullRequest(ctx context.Context, pr domain.PullRequest) error {
 	pullRequest := pr.(PullRequest)
@@ -109,6 +313,33 @@ func (vc *VersionController) GetAutocompleteRepositories(ctx context.Context, st
 	return []string{"static-repo", str}, nil
 }
 
+// GetCurrentUserAuthor returns a static mock author
+func (vc *VersionController) GetCurrentUserAuthor(ctx context.Context) (domain.CommitAuthor, error) {
+	return domain.CommitAuthor{
+		Name:  "Token User",
+		Email: "token-user@example.com",
+	}, nil
+}
+
+// CreateIssue creates a mock issue
+func (vc *VersionController) CreateIssue(ctx context.Context, repoName string, issue domain.NewIssue) error {
+	vc.Issues = append(vc.Issues, Issue{
+		RepoName: repoName,
+		NewIssue: issue,
+	})
+	return nil
+}
+
+// IssueExists checks if a mock issue with the title exists in the repository
+func (vc *VersionController) IssueExists(ctx context.Context, repo domain.Repository, title string) (bool, error) {
+	for _, issue := range vc.Issues {
+		if issue.RepoName == repo.FullName() && issue.Title == title {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ForkRepository forks a repository
 func (vc *VersionController) ForkRepository(ctx context.Context, repo domain.Repository, newOwner string) (domain.Repository, error) {
 	r := repo.(Repository)
@@ -126,11 +357,419 @@ func (vc *VersionController) ForkRepository(ctx context.Context, repo domain.Rep
 		return nil, err
 	}
 
-	return Repository{
+	forkedRepo := Repository{
 		OwnerName: newOwner,
 		RepoName:  r.RepoName,
 		Path:      newPath,
-	}, nil
+	}
+	vc.Forks = append(vc.Forks, Fork{
+		Repository: forkedRepo,
+		Parent:     r,
+		Updated:    time.Now(),
+	})
+
+	return forkedRepo, nil
+}
+
+// GetForks gets all mock forks owned by owner
+func (vc *VersionController) GetForks(ctx context.Context, owner string) ([]domain.Fork, error) {
+	if owner == "" {
+		owner = "default-owner"
+	}
+
+	ret := []domain.Fork{}
+	for _, fork := range vc.Forks {
+		if fork.OwnerName == owner {
+			ret = append(ret, fork)
+		}
+	}
+	return ret, nil
+}
+
+// GetForkPullRequests gets all mock pull requests made to the parent of a fork
+func (vc *VersionController) GetForkPullRequests(ctx context.Context, fork domain.Fork, branchName string) ([]domain.PullRequest, error) {
+	f := fork.(Fork)
+
+	ret := []domain.PullRequest{}
+	for _, pr := range vc.PullRequests {
+		if pr.Repository.FullName() == f.Parent.FullName() && pr.Head == branchName {
+			ret = append(ret, pr)
+		}
+	}
+	return ret, nil
+}
+
+// GetOpenForkPullRequests gets all open mock pull requests made to the parent of a fork, from any branch
+func (vc *VersionController) GetOpenForkPullRequests(ctx context.Context, fork domain.Fork) ([]domain.PullRequest, error) {
+	f := fork.(Fork)
+
+	ret := []domain.PullRequest{}
+	for _, pr := range vc.PullRequests {
+		if pr.Repository.FullName() == f.Parent.FullName() &&
+			pr.PRStatus != domain.PullRequestStatusClosed && pr.PRStatus != domain.PullRequestStatusMerged {
+			ret = append(ret, pr)
+		}
+	}
+	return ret, nil
+}
+
+// DeleteRepository deletes a mock fork
+func (vc *VersionController) DeleteRepository(ctx context.Context, repo domain.Repository) error {
+	for i := range vc.Forks {
+		if vc.Forks[i].FullName() == repo.FullName() {
+			vc.Forks[i].Delete()
+			vc.Forks = append(vc.Forks[:i], vc.Forks[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("could not find repository")
+}
+
+// RenameDefaultBranch renames the default branch of a mock repository and retargets its pull requests
+func (vc *VersionController) RenameDefaultBranch(ctx context.Context, repo domain.Repository, newName string) error {
+	r := repo.(Repository)
+	oldName := r.DefaultBranch()
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return err
+	}
+
+	ref, err := gitRepo.Reference(plumbing.NewBranchReferenceName(oldName), true)
+	if err != nil {
+		return err
+	}
+	if err := gitRepo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(newName), ref.Hash())); err != nil {
+		return err
+	}
+	if err := gitRepo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(newName))); err != nil {
+		return err
+	}
+	if err := gitRepo.Storer.RemoveReference(plumbing.NewBranchReferenceName(oldName)); err != nil {
+		return err
+	}
+
+	for i := range vc.Repositories {
+		if vc.Repositories[i].FullName() == r.FullName() {
+			vc.Repositories[i].DefaultBranchName = newName
+		}
+	}
+	for i := range vc.PullRequests {
+		if vc.PullRequests[i].Repository.FullName() == r.FullName() && vc.PullRequests[i].Base == oldName {
+			vc.PullRequests[i].Base = newName
+		}
+	}
+
+	return nil
+}
+
+// GetRepositorySettings gets the settings of a mock repository
+func (vc *VersionController) GetRepositorySettings(ctx context.Context, repo domain.Repository, protectedBranch string) (domain.RepositorySettings, error) {
+	settings := vc.Settings[repo.FullName()]
+	if settings.BranchProtection != nil && settings.BranchProtection.Branch != protectedBranch {
+		settings.BranchProtection = nil
+	}
+	return settings, nil
+}
+
+// UpdateRepositorySettings changes all set settings of a mock repository
+func (vc *VersionController) UpdateRepositorySettings(ctx context.Context, repo domain.Repository, update domain.RepositorySettings) error {
+	if vc.Settings == nil {
+		vc.Settings = map[string]domain.RepositorySettings{}
+	}
+
+	settings := vc.Settings[repo.FullName()]
+	if update.Topics != nil {
+		settings.Topics = update.Topics
+	}
+	if update.AllowMergeCommit != nil {
+		settings.AllowMergeCommit = update.AllowMergeCommit
+	}
+	if update.AllowSquashMerge != nil {
+		settings.AllowSquashMerge = update.AllowSquashMerge
+	}
+	if update.AllowRebaseMerge != nil {
+		settings.AllowRebaseMerge = update.AllowRebaseMerge
+	}
+	if update.DeleteBranchOnMerge != nil {
+		settings.DeleteBranchOnMerge = update.DeleteBranchOnMerge
+	}
+	if update.BranchProtection != nil {
+		settings.BranchProtection = update.BranchProtection
+	}
+	for _, webhook := range update.Webhooks {
+		webhook.Secret = ""
+		replaced := false
+		for i := range settings.Webhooks {
+			if settings.Webhooks[i].URL == webhook.URL {
+				settings.Webhooks[i] = webhook
+				replaced = true
+			}
+		}
+		if !replaced {
+			settings.Webhooks = append(settings.Webhooks, webhook)
+		}
+	}
+	vc.Settings[repo.FullName()] = settings
+
+	return nil
+}
+
+// RemoveWebhook removes a webhook from a mock repository
+func (vc *VersionController) RemoveWebhook(ctx context.Context, repo domain.Repository, url string) error {
+	settings := vc.Settings[repo.FullName()]
+	var webhooks []domain.Webhook
+	for _, webhook := range settings.Webhooks {
+		if webhook.URL != url {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	settings.Webhooks = webhooks
+	vc.Settings[repo.FullName()] = settings
+	return nil
+}
+
+// GetSecrets gets the secret names and variables of a mock repository
+func (vc *VersionController) GetSecrets(ctx context.Context, repo domain.Repository) (domain.RepositorySecrets, error) {
+	secrets := vc.Secrets[repo.FullName()]
+	ret := domain.RepositorySecrets{
+		Variables: map[string]string{},
+	}
+	for name := range secrets.Secrets {
+		ret.SecretNames = append(ret.SecretNames, name)
+	}
+	for name, value := range secrets.Variables {
+		ret.Variables[name] = value
+	}
+	return ret, nil
+}
+
+// SetSecret sets a secret of a mock repository
+func (vc *VersionController) SetSecret(ctx context.Context, repo domain.Repository, name, value string) error {
+	secrets := vc.repoSecrets(repo)
+	secrets.Secrets[name] = value
+	return nil
+}
+
+// SetVariable sets a variable of a mock repository
+func (vc *VersionController) SetVariable(ctx context.Context, repo domain.Repository, name, value string) error {
+	secrets := vc.repoSecrets(repo)
+	secrets.Variables[name] = value
+	return nil
+}
+
+func (vc *VersionController) repoSecrets(repo domain.Repository) domain.Secrets {
+	if vc.Secrets == nil {
+		vc.Secrets = map[string]domain.Secrets{}
+	}
+	secrets := vc.Secrets[repo.FullName()]
+	if secrets.Secrets == nil {
+		secrets.Secrets = map[string]string{}
+	}
+	if secrets.Variables == nil {
+		secrets.Variables = map[string]string{}
+	}
+	vc.Secrets[repo.FullName()] = secrets
+	return secrets
+}
+
+// GetLabels gets the labels of a mock repository
+func (vc *VersionController) GetLabels(ctx context.Context, repo domain.Repository) ([]domain.Label, error) {
+	return vc.Labels[repo.FullName()], nil
+}
+
+// CreateLabel creates a label in a mock repository
+func (vc *VersionController) CreateLabel(ctx context.Context, repo domain.Repository, label domain.Label) error {
+	if vc.Labels == nil {
+		vc.Labels = map[string][]domain.Label{}
+	}
+	label.PreviousNames = nil
+	vc.Labels[repo.FullName()] = append(vc.Labels[repo.FullName()], label)
+	return nil
+}
+
+// UpdateLabel changes a label in a mock repository
+func (vc *VersionController) UpdateLabel(ctx context.Context, repo domain.Repository, currentName string, label domain.Label) error {
+	labels := vc.Labels[repo.FullName()]
+	for i := range labels {
+		if labels[i].Name == currentName {
+			label.PreviousNames = nil
+			labels[i] = label
+			return nil
+		}
+	}
+	return errors.New("could not find label")
+}
+
+// GetPermissions gets the permissions of users and teams to a mock repository
+func (vc *VersionController) GetPermissions(ctx context.Context, repo domain.Repository) (domain.Permissions, error) {
+	permissions := vc.repoPermissions(repo)
+	ret := domain.Permissions{
+		Users: map[string]string{},
+		Teams: map[string]string{},
+	}
+	for name, permission := range permissions.Users {
+		ret.Users[name] = permission
+	}
+	for name, permission := range permissions.Teams {
+		ret.Teams[name] = permission
+	}
+	return ret, nil
+}
+
+// SetUserPermission sets the permission of a user to a mock repository
+func (vc *VersionController) SetUserPermission(ctx context.Context, repo domain.Repository, user, permission string) error {
+	vc.repoPermissions(repo).Users[user] = permission
+	return nil
+}
+
+// SetTeamPermission sets the permission of a team to a mock repository
+func (vc *VersionController) SetTeamPermission(ctx context.Context, repo domain.Repository, team, permission string) error {
+	vc.repoPermissions(repo).Teams[team] = permission
+	return nil
+}
+
+// RemoveUser removes a user from a mock repository
+func (vc *VersionController) RemoveUser(ctx context.Context, repo domain.Repository, user string) error {
+	delete(vc.repoPermissions(repo).Users, user)
+	return nil
+}
+
+// RemoveTeam removes a team from a mock repository
+func (vc *VersionController) RemoveTeam(ctx context.Context, repo domain.Repository, team string) error {
+	delete(vc.repoPermissions(repo).Teams, team)
+	return nil
+}
+
+func (vc *VersionController) repoPermissions(repo domain.Repository) domain.Permissions {
+	if vc.Permissions == nil {
+		vc.Permissions = map[string]domain.Permissions{}
+	}
+	permissions := vc.Permissions[repo.FullName()]
+	if permissions.Users == nil {
+		permissions.Users = map[string]string{}
+	}
+	if permissions.Teams == nil {
+		permissions.Teams = map[string]string{}
+	}
+	vc.Permissions[repo.FullName()] = permissions
+	return permissions
+}
+
+// GetBranchProtection gets the protection of a branch in a mock repository, the repository is skipped if the branch does not exist
+func (vc *VersionController) GetBranchProtection(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) (*domain.BranchProtection, error) {
+	r := repo.(Repository)
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gitRepo.Reference(plumbing.NewBranchReferenceName(rule.Branch), false); err == plumbing.ErrReferenceNotFound {
+		return nil, domain.SkipError{Reason: fmt.Sprintf("the branch %s does not exist", rule.Branch)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	protection, ok := vc.BranchProtections[repo.FullName()][rule.Branch]
+	if !ok {
+		return nil, nil
+	}
+	return &protection, nil
+}
+
+// ProtectBranch sets the protection of a branch in a mock repository
+func (vc *VersionController) ProtectBranch(ctx context.Context, repo domain.Repository, rule domain.BranchProtection) error {
+	if vc.BranchProtections == nil {
+		vc.BranchProtections = map[string]map[string]domain.BranchProtection{}
+	}
+	if vc.BranchProtections[repo.FullName()] == nil {
+		vc.BranchProtections[repo.FullName()] = map[string]domain.BranchProtection{}
+	}
+	vc.BranchProtections[repo.FullName()][rule.Branch] = rule
+	return nil
+}
+
+// IsArchived checks if a mock repository is archived
+func (vc *VersionController) IsArchived(ctx context.Context, repo domain.Repository) (bool, error) {
+	return vc.Archived[repo.FullName()], nil
+}
+
+// SetArchived archives or unarchives a mock repository
+func (vc *VersionController) SetArchived(ctx context.Context, repo domain.Repository, archived bool) error {
+	if vc.Archived == nil {
+		vc.Archived = map[string]bool{}
+	}
+	vc.Archived[repo.FullName()] = archived
+	return nil
+}
+
+// TagExists checks if a tag exists in a mock repository
+func (vc *VersionController) TagExists(ctx context.Context, repo domain.Repository, tag string) (bool, error) {
+	r := repo.(Repository)
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return false, err
+	}
+	_, err = gitRepo.Tag(tag)
+	if err == git.ErrTagNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateRelease creates the tag at the head of the default branch of a mock repository, and saves the release
+func (vc *VersionController) CreateRelease(ctx context.Context, repo domain.Repository, release domain.Release) error {
+	r := repo.(Repository)
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return err
+	}
+	ref, err := gitRepo.Reference(plumbing.NewBranchReferenceName(r.DefaultBranch()), false)
+	if err != nil {
+		return err
+	}
+	if _, err := gitRepo.CreateTag(release.Tag, ref.Hash(), nil); err != nil {
+		return err
+	}
+
+	if vc.Releases == nil {
+		vc.Releases = map[string][]domain.Release{}
+	}
+	vc.Releases[repo.FullName()] = append(vc.Releases[repo.FullName()], release)
+	return nil
+}
+
+// GetFile gets the content of a file on the default branch of a mock repository
+func (vc *VersionController) GetFile(ctx context.Context, repo domain.Repository, path string) (string, bool, error) {
+	r := repo.(Repository)
+
+	gitRepo, err := git.PlainOpen(r.Path)
+	if err != nil {
+		return "", false, err
+	}
+	ref, err := gitRepo.Reference(plumbing.NewBranchReferenceName(r.DefaultBranch()), false)
+	if err != nil {
+		return "", false, err
+	}
+	commit, err := gitRepo.CommitObject(ref.Hash())
+	if err != nil {
+		return "", false, err
+	}
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
 }
 
 // Clean cleans up the data on disk that exist within the version controller mock
@@ -138,13 +777,17 @@ func (vc *VersionController) Clean() {
 	for _, repo := range vc.Repositories {
 		repo.Delete()
 	}
+	for _, fork := range vc.Forks {
+		fork.Delete()
+	}
 }
 
 // PullRequest is a mock pr
 type PullRequest struct {
-	PRStatus domain.PullRequestStatus
-	PRNumber int
-	Merged   bool
+	PRStatus  domain.PullRequestStatus
+	PRNumber  int
+	Merged    bool
+	MergeType domain.MergeType // Set if the pull request was merged with a specific merge type
 
 	Repository
 	domain.NewPullRequest
@@ -160,11 +803,31 @@ func (pr PullRequest) String() string {
 	return fmt.Sprintf("%s #%d", pr.Repository.FullName(), pr.PRNumber)
 }
 
+// RepositoryName returns the full name of the repository of the pr
+func (pr PullRequest) RepositoryName() string {
+	return pr.Repository.FullName()
+}
+
+// Fork is a mock fork
+type Fork struct {
+	Repository
+	Parent  Repository
+	Updated time.Time
+}
+
+// LastUpdated returns the last time the fork was updated
+func (f Fork) LastUpdated() time.Time {
+	return f.Updated
+}
+
 // Repository is a mock repository
 type Repository struct {
-	OwnerName string
-	RepoName  string
-	Path      string
+	OwnerName         string
+	RepoName          string
+	Path              string
+	DefaultBranchName string // Defaults to "master"
+	IsArchived        bool
+	IsDisabled        bool
 }
 
 // URL return the URL (filepath) of the repository on disk
@@ -172,9 +835,12 @@ func (r Repository) URL(token string) string {
 	return fmt.Sprintf(`file://%s`, filepath.ToSlash(r.Path))
 }
 
-// DefaultBranch returns "master"
+// DefaultBranch returns the default branch, "master" if none is set
 func (r Repository) DefaultBranch() string {
-	return "master"
+	if r.DefaultBranchName == "" {
+		return "master"
+	}
+	return r.DefaultBranchName
 }
 
 // FullName returns the name of the mock repo
@@ -182,6 +848,16 @@ func (r Repository) FullName() string {
 	return fmt.Sprintf("%s/%s", r.OwnerName, r.RepoName)
 }
 
+// Archived returns if the mock repo is archived
+func (r Repository) Archived() bool {
+	return r.IsArchived
+}
+
+// Disabled returns if the mock repo is disabled
+func (r Repository) Disabled() bool {
+	return r.IsDisabled
+}
+
 // Owner returns the owner of a repo
 func (r Repository) Owner() string {
 	return r.OwnerName
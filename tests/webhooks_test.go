@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindell/multi-gitter/cmd"
+	"github.com/lindell/multi-gitter/internal/domain"
+	"github.com/lindell/multi-gitter/tests/vcmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	vcMock := &vcmock.VersionController{
+		Repositories: []vcmock.Repository{
+			createRepo(t, "owner", "old-endpoint", "i like apples"),
+			createRepo(t, "owner", "new-endpoint", "i like apples"),
+			createRepo(t, "owner", "no-webhooks", "i like apples"),
+		},
+		Settings: map[string]domain.RepositorySettings{
+			"owner/old-endpoint": {
+				Webhooks: []domain.Webhook{
+					{URL: "https://ci.example.com/old", Events: []string{"push"}, ContentType: "json"},
+				},
+			},
+			"owner/new-endpoint": {
+				Webhooks: []domain.Webhook{
+					{URL: "https://ci.example.com/new", Events: []string{"push"}, ContentType: "json"},
+				},
+			},
+		},
+	}
+	defer vcMock.Clean()
+	cmd.OverrideVersionController = vcMock
+
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "multi-git-test-webhooks-")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"webhooks",
+		"--log-file", filepath.ToSlash(filepath.Join(tmpDir, "log.txt")),
+		"--url", "https://ci.example.com/new",
+		"--remove", "https://ci.example.com/old",
+	}
+
+	command := cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "dry-run.txt")), "--dry-run"))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/old-endpoint:
+  webhook https://ci.example.com/new: none -> events: push, content type: json
+  webhook https://ci.example.com/old: events: push, content type: json -> none
+owner/new-endpoint: up to date
+owner/no-webhooks:
+  webhook https://ci.example.com/new: none -> events: push, content type: json
+
+2 would be changed, 1 up to date, 0 failed
+`, readFile(t, tmpDir, "dry-run.txt"))
+	assert.Equal(t, "https://ci.example.com/old", vcMock.Settings["owner/old-endpoint"].Webhooks[0].URL)
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "out.txt"))))
+	require.NoError(t, command.Execute())
+	for _, repo := range []string{"owner/old-endpoint", "owner/new-endpoint", "owner/no-webhooks"} {
+		require.Len(t, vcMock.Settings[repo].Webhooks, 1, repo)
+		assert.Equal(t, "https://ci.example.com/new", vcMock.Settings[repo].Webhooks[0].URL, repo)
+	}
+
+	command = cmd.RootCmd()
+	command.SetArgs(append(args, "--output", filepath.ToSlash(filepath.Join(tmpDir, "rerun.txt"))))
+	require.NoError(t, command.Execute())
+	assert.Equal(t, `owner/old-endpoint: up to date
+owner/new-endpoint: up to date
+owner/no-webhooks: up to date
+
+0 changed, 3 up to date, 0 failed
+`, readFile(t, tmpDir, "rerun.txt"))
+}